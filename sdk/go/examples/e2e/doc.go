@@ -0,0 +1,22 @@
+// Package e2e is a runnable reference for the full agent lifecycle with the
+// a2areg SDK: build an agent with AgentBuilder and validate it, publish it,
+// find it through search, fetch its card and verify the card's signature,
+// rotate the API key in use, and clean up. Against the fake registry the
+// card is signed with a key generated for the run before publishing.
+//
+// The flow lives in the package tests. By default it runs against an
+// in-memory fake registry:
+//
+//	go test ./examples/e2e
+//
+// Pointed at a real registry it doubles as an acceptance suite. Set
+//
+//	A2A_E2E_REGISTRY_URL  registry base URL
+//	A2A_E2E_API_KEY       API key allowed to publish, delete and manage keys
+//	A2A_E2E_AGENT_URL     optional endpoint advertised by the test agent
+//
+// and run the same command; the registry test is skipped when
+// A2A_E2E_REGISTRY_URL or A2A_E2E_API_KEY is unset. The test agent gets a
+// unique name and is deleted at the end, and the API key the suite creates
+// is revoked, leaving the configured key untouched.
+package e2e
@@ -0,0 +1,231 @@
+package e2e
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"a2areg/pkg/a2areg"
+	"a2areg/pkg/a2aregtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// target is a registry the lifecycle runs against.
+type target struct {
+	url      string
+	apiKey   string
+	agentURL string
+	// signer, when set, signs the card before it is published, and keySet
+	// holds its public key to verify the stored card with.
+	signer crypto.Signer
+	keySet *a2areg.JSONWebKeySet
+}
+
+func TestLifecycle_FakeRegistry(t *testing.T) {
+	registry := a2aregtest.NewFakeRegistry()
+	registry.AddAPIKey("e2e-key", "read", "write", "admin")
+	server := registry.Start()
+	defer server.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	runLifecycle(t, target{
+		url:      server.URL,
+		apiKey:   "e2e-key",
+		agentURL: "https://agents.example.com/e2e",
+		signer:   key,
+		keySet:   &a2areg.JSONWebKeySet{Keys: []a2areg.JSONWebKey{publicJWK(key, "e2e-1")}},
+	})
+}
+
+// publicJWK returns the public half of a P-256 key as a JWK.
+func publicJWK(key *ecdsa.PrivateKey, kid string) a2areg.JSONWebKey {
+	return a2areg.JSONWebKey{
+		Kty: "EC", Kid: kid, Crv: "P-256",
+		X: base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y: base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func TestLifecycle_Registry(t *testing.T) {
+	url, apiKey := os.Getenv("A2A_E2E_REGISTRY_URL"), os.Getenv("A2A_E2E_API_KEY")
+	if url == "" || apiKey == "" {
+		t.Skip("set A2A_E2E_REGISTRY_URL and A2A_E2E_API_KEY to run against a registry")
+	}
+	agentURL := os.Getenv("A2A_E2E_AGENT_URL")
+	if agentURL == "" {
+		agentURL = "https://agents.example.com/e2e"
+	}
+	runLifecycle(t, target{url: url, apiKey: apiKey, agentURL: agentURL})
+}
+
+// runLifecycle walks one agent through its life. Each step is a subtest
+// and later steps rely on earlier ones, so the first failure stops the run.
+func runLifecycle(t *testing.T, tgt target) {
+	ctx := context.Background()
+	client := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{
+		RegistryURL: tgt.url,
+		APIKey:      tgt.apiKey,
+		Timeout:     15 * time.Second,
+	})
+	name := fmt.Sprintf("E2E Lifecycle Agent %d", time.Now().UnixNano())
+
+	var agent *a2areg.Agent
+	var agentID string
+	step := func(name string, fn func(t *testing.T)) {
+		if !t.Run(name, fn) {
+			t.FailNow()
+		}
+	}
+	defer func() {
+		// Best-effort cleanup if a step failed after publishing.
+		if agentID != "" {
+			client.DeleteAgent(agentID)
+		}
+	}()
+
+	step("build", func(t *testing.T) {
+		var err error
+		agent, err = a2areg.NewAgentBuilder(name, "1.0.0").
+			Description("Exercises the SDK lifecycle end to end").
+			Provider("a2areg-e2e").
+			Tags("e2e", "testing").
+			Public(false).
+			Active(true).
+			LocationURL(tgt.agentURL).
+			WithStreaming(true).
+			AddSkill(a2areg.AgentSkill{
+				ID:          "echo",
+				Name:        "Echo",
+				Description: "Repeats its input",
+				Tags:        []string{"e2e"},
+			}).
+			AddInterface(a2areg.TransportJSONRPC, tgt.agentURL).
+			ValidateWith(client).
+			Build()
+		require.NoError(t, err)
+	})
+
+	if tgt.signer != nil {
+		step("sign card", func(t *testing.T) {
+			card, _, err := a2areg.ConvertAgentToCard(agent)
+			require.NoError(t, err)
+			require.NoError(t, a2areg.SignAgentCard(card, tgt.signer, a2areg.SignOptions{
+				KeyID: tgt.keySet.Keys[0].Kid,
+				// The fields the registry stores as published; it may add
+				// others.
+				Fields: []string{"name", "description", "version", "url", "skills", "securitySchemes", "interface"},
+			}))
+			agent.AgentCard = card
+		})
+	}
+
+	step("validate", func(t *testing.T) {
+		require.NoError(t, client.ValidateAgent(agent))
+
+		invalid := *agent
+		invalid.Version = ""
+		assert.Equal(t, a2areg.CodeValidationFailed, a2areg.ErrorCode(client.ValidateAgent(&invalid)))
+	})
+
+	step("publish", func(t *testing.T) {
+		receipt, err := client.PublishAgentVerbose(ctx, agent, a2areg.PublishOptions{Validate: true})
+		require.NoError(t, err)
+		require.NotNil(t, receipt.Agent.ID)
+		agentID = *receipt.Agent.ID
+		assert.Equal(t, name, receipt.Agent.Name)
+	})
+
+	step("search", func(t *testing.T) {
+		// Registries may index asynchronously, so allow a short delay.
+		var found bool
+		require.Eventually(t, func() bool {
+			resp, err := client.Search(ctx, a2areg.SearchRequest{Query: name, Page: 1, Limit: 10})
+			if err != nil {
+				return false
+			}
+			for _, a := range resp.Agents {
+				if a.ID != nil && *a.ID == agentID {
+					found = true
+				}
+			}
+			return found
+		}, 30*time.Second, 250*time.Millisecond, "published agent not found by search")
+	})
+
+	var card *a2areg.AgentCardSpec
+	step("fetch card", func(t *testing.T) {
+		var err error
+		card, err = client.GetAgentCard(agentID)
+		require.NoError(t, err)
+		assert.Equal(t, name, card.Name)
+		assert.Equal(t, tgt.agentURL, card.URL)
+	})
+
+	step("verify card signature", func(t *testing.T) {
+		if card.Signature == nil {
+			require.Nil(t, tgt.signer, "the registry dropped the card's signature")
+			t.Skip("the registry stored the card unsigned")
+		}
+		// With no local key set the card's JWKSUrl is fetched.
+		require.NoError(t, card.VerifySignature(ctx, a2areg.VerifyOptions{KeySet: tgt.keySet}))
+
+		tampered := *card
+		tampered.Description += " (tampered)"
+		var sigErr *a2areg.SignatureVerificationError
+		assert.ErrorAs(t, tampered.VerifySignature(ctx, a2areg.VerifyOptions{KeySet: tgt.keySet}), &sigErr)
+	})
+
+	step("check endpoint compatibility", func(t *testing.T) {
+		stored, err := client.GetAgent(agentID)
+		require.NoError(t, err)
+
+		var endpoint string
+		for _, entry := range stored.Interfaces {
+			if entry.Transport == "jsonrpc" {
+				endpoint = entry.URL
+			}
+		}
+		require.NotEmpty(t, endpoint, "no jsonrpc interface advertised")
+		assert.Equal(t, tgt.agentURL, endpoint)
+	})
+
+	step("rotate API key", func(t *testing.T) {
+		rotated, info, err := client.GenerateAPIKey([]string{"read", "write"}, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, rotated)
+		keyID, _ := info["key_id"].(string)
+		require.NotEmpty(t, keyID)
+
+		client.SetAPIKey(rotated)
+		_, err = client.GetAgent(agentID)
+		require.NoError(t, err, "rotated key rejected")
+
+		// Revoke the rotated key with the original one, then check it no
+		// longer works.
+		client.SetAPIKey(tgt.apiKey)
+		revoked, err := client.RevokeAPIKey(keyID)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		stale := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: tgt.url, APIKey: rotated})
+		_, err = stale.GetAgent(agentID)
+		assert.Equal(t, a2areg.CodeAuthRequired, a2areg.ErrorCode(err))
+	})
+
+	step("clean up", func(t *testing.T) {
+		require.NoError(t, client.DeleteAgent(agentID))
+		_, err := client.GetAgent(agentID)
+		assert.Equal(t, a2areg.CodeAgentNotFound, a2areg.ErrorCode(err))
+		agentID = ""
+	})
+}
@@ -0,0 +1,143 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a manually advanced clock. Timers and sleeps fire only when Advance
+// moves the clock past their deadline.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	waiters chan struct{}
+}
+
+// NewFake returns a fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start, waiters: make(chan struct{}, 1)}
+}
+
+// Now returns the fake current time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Sleep blocks until the clock has been advanced by at least d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// After returns a channel that receives once the clock advances by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer returns a timer that fires once the clock advances by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	f.scheduleLocked(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d and fires every timer that is due, in
+// deadline order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+
+	sort.SliceStable(f.timers, func(i, j int) bool { return f.timers[i].deadline.Before(f.timers[j].deadline) })
+	remaining := f.timers[:0]
+	for _, t := range f.timers {
+		if t.deadline.After(f.now) {
+			remaining = append(remaining, t)
+			continue
+		}
+		select {
+		case t.c <- f.now:
+		default:
+		}
+	}
+	f.timers = remaining
+}
+
+// Pending returns the number of timers (including sleeps) waiting to fire.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil waits until at least n timers are pending, which lets a test
+// advance the clock only after the code under test has started waiting. It
+// must be called from a single goroutine.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		if f.Pending() >= n {
+			return
+		}
+		<-f.waiters
+	}
+}
+
+func (f *Fake) scheduleLocked(t *fakeTimer, d time.Duration) {
+	t.deadline = f.now.Add(d)
+	if d <= 0 {
+		// Like a time.Timer, a timer whose last value was not received
+		// keeps that value rather than blocking.
+		select {
+		case t.c <- f.now:
+		default:
+		}
+		return
+	}
+	f.timers = append(f.timers, t)
+	select {
+	case f.waiters <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Fake) removeLocked(t *fakeTimer) bool {
+	for i, other := range f.timers {
+		if other == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	c        chan time.Time
+	deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.removeLocked(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.removeLocked(t)
+	t.clock.scheduleLocked(t, d)
+	return active
+}
@@ -0,0 +1,68 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake_AdvanceFiresDueTimers(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	short := f.NewTimer(time.Second)
+	long := f.After(time.Minute)
+
+	f.Advance(500 * time.Millisecond)
+	assert.Len(t, short.C(), 0)
+
+	f.Advance(500 * time.Millisecond)
+	assert.Equal(t, start.Add(time.Second), <-short.C())
+	assert.Len(t, long, 0)
+	assert.Equal(t, 1, f.Pending())
+
+	f.Advance(time.Hour)
+	assert.Equal(t, start.Add(time.Second+time.Hour), <-long)
+	assert.Equal(t, 0, f.Pending())
+}
+
+func TestFake_StopAndReset(t *testing.T) {
+	f := NewFake(time.Now())
+	timer := f.NewTimer(time.Second)
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop())
+
+	f.Advance(time.Second)
+	assert.Len(t, timer.C(), 0)
+
+	assert.False(t, timer.Reset(time.Second))
+	f.Advance(time.Second)
+	assert.Len(t, timer.C(), 1)
+}
+
+func TestFake_ResetFiredTimerToZero(t *testing.T) {
+	f := NewFake(time.Now())
+	timer := f.NewTimer(0)
+	assert.Len(t, timer.C(), 1)
+
+	// The undrained value stays; the clock is not left holding its lock.
+	assert.False(t, timer.Reset(0))
+	assert.Len(t, timer.C(), 1)
+	assert.Equal(t, 0, f.Pending())
+	f.Advance(time.Second)
+	<-timer.C()
+}
+
+func TestFake_SleepWithBlockUntil(t *testing.T) {
+	f := NewFake(time.Now())
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Minute)
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	<-done
+}
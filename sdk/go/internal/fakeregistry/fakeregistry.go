@@ -0,0 +1,503 @@
+// Package fakeregistry implements an in-memory A2A registry over HTTP for
+// tests. It speaks the subset of the registry REST API used by the SDK.
+package fakeregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry is an in-memory registry. The zero value is not usable; create one
+// with New. All methods are safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	agents  map[string]*Agent
+	nextID  int
+	calls   map[string]int
+	apiKeys map[string]*apiKey // by key id
+
+	// RejectDuplicates makes publishing a card whose name and provider
+	// match an existing agent (without naming its id) fail with 409.
+	RejectDuplicates bool
+	// ClientID and ClientSecret, when set, are the only credentials the
+	// OAuth token endpoint accepts.
+	ClientID     string
+	ClientSecret string
+	// DisableSearch removes /agents/search, like minimal deployments.
+	DisableSearch bool
+	// MaxPageSize caps the page size of listings and search results.
+	MaxPageSize int
+	// Token is the access token issued by the OAuth endpoint. Defaults to
+	// "fake-token".
+	Token string
+	// Visibility makes the registry behave like newer deployments: it
+	// advertises the "visibility" feature in /health, accepts and returns
+	// the visibility field, and leaves unlisted agents out of listings and
+	// search. Without it only is_public is understood.
+	Visibility bool
+	// FeatureMap makes /health report its features as an object of
+	// name to enabled, search included, the way the Python registry does.
+	FeatureMap bool
+}
+
+// apiKey is an API key issued by the registry.
+type apiKey struct {
+	ID     string
+	Key    string
+	Scopes []string
+	Active bool
+}
+
+// Agent is a stored agent.
+type Agent struct {
+	ID     string                 `json:"id"`
+	Public bool                   `json:"public"`
+	Active bool                   `json:"active"`
+	Card   map[string]interface{} `json:"card"`
+	// Unlisted is only set by registries with Visibility enabled.
+	Unlisted bool `json:"unlisted,omitempty"`
+}
+
+// New returns an empty registry.
+func New() *Registry {
+	return &Registry{agents: make(map[string]*Agent), calls: make(map[string]int), apiKeys: make(map[string]*apiKey)}
+}
+
+// Start serves the registry on a new httptest server. The caller must close
+// it.
+func (r *Registry) Start() *httptest.Server {
+	return httptest.NewServer(r)
+}
+
+// Calls returns how many requests matched "METHOD /path".
+func (r *Registry) Calls(route string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[route]
+}
+
+// Len returns the number of stored agents.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.agents)
+}
+
+// AddAPIKey registers key as an active API key and returns its key id. Once
+// any key exists, every route except /health and the OAuth token endpoint
+// requires an active key (as a Bearer token or X-API-Key) or the OAuth
+// access token.
+func (r *Registry) AddAPIKey(key string, scopes ...string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.addAPIKeyLocked(key, scopes)
+}
+
+func (r *Registry) addAPIKeyLocked(key string, scopes []string) string {
+	id := fmt.Sprintf("key-%d", len(r.apiKeys)+1)
+	r.apiKeys[id] = &apiKey{ID: id, Key: key, Scopes: scopes, Active: true}
+	return id
+}
+
+// Put stores a card directly, bypassing the API, and returns its id.
+func (r *Registry) Put(id string, public bool, card map[string]interface{}) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == "" {
+		id = r.newIDLocked()
+	}
+	r.agents[id] = &Agent{ID: id, Public: public, Active: true, Card: card}
+	return id
+}
+
+// Snapshot returns a deep copy of the stored agents, ordered by id.
+func (r *Registry) Snapshot() []Agent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	agents := make([]Agent, 0, len(r.agents))
+	for _, agent := range r.sortedLocked() {
+		copied := *agent
+		data, _ := json.Marshal(agent.Card)
+		copied.Card = nil
+		json.Unmarshal(data, &copied.Card)
+		agents = append(agents, copied)
+	}
+	return agents
+}
+
+// Restore replaces the stored agents with agents.
+func (r *Registry) Restore(agents []Agent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.agents = make(map[string]*Agent, len(agents))
+	for i := range agents {
+		agent := agents[i]
+		r.agents[agent.ID] = &agent
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[req.Method+" "+req.URL.Path]++
+
+	path := strings.Trim(req.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if path != "health" && path != "auth/oauth/token" && !r.authorizedLocked(req) {
+		writeError(w, http.StatusUnauthorized, "Invalid or missing credentials")
+		return
+	}
+	switch {
+	case req.Method == "GET" && path == "health":
+		health := map[string]interface{}{"status": "healthy", "version": "fake"}
+		if r.FeatureMap {
+			health["features"] = map[string]bool{"visibility": r.Visibility, "search": !r.DisableSearch}
+		} else if r.Visibility {
+			health["features"] = []string{"visibility"}
+		}
+		writeJSON(w, http.StatusOK, health)
+	case req.Method == "POST" && path == "auth/oauth/token":
+		r.token(w, req)
+	case req.Method == "GET" && path == "stats":
+		r.stats(w)
+	case req.Method == "POST" && path == "agents/publish":
+		r.publish(w, req)
+	case req.Method == "GET" && (path == "agents/public" || path == "agents/entitled"):
+		r.list(w, req, path == "agents/public")
+	case req.Method == "POST" && path == "agents/search" && !r.DisableSearch:
+		r.search(w, req)
+	case path == "security/api-keys":
+		r.apiKeysRoute(w, req)
+	case len(parts) == 3 && parts[0] == "security" && parts[1] == "api-keys" && req.Method == "DELETE":
+		key, ok := r.apiKeys[parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		key.Active = false
+		writeJSON(w, http.StatusOK, map[string]interface{}{"revoked": true})
+	case len(parts) == 2 && parts[0] == "agents":
+		r.agent(w, req, parts[1])
+	case len(parts) == 3 && parts[0] == "agents" && parts[2] == "card" && req.Method == "GET":
+		agent, ok := r.agents[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Agent not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, agent.Card)
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (r *Registry) token(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.ClientID != "" && (req.PostForm.Get("client_id") != r.ClientID || req.PostForm.Get("client_secret") != r.ClientSecret) {
+		writeError(w, http.StatusUnauthorized, "invalid client")
+		return
+	}
+	token := r.Token
+	if token == "" {
+		token = "fake-token"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": token, "token_type": "bearer", "expires_in": 3600})
+}
+
+// authorizedLocked reports whether req carries acceptable credentials. With
+// no API keys registered, every request is accepted.
+func (r *Registry) authorizedLocked(req *http.Request) bool {
+	if len(r.apiKeys) == 0 {
+		return true
+	}
+	credential := req.Header.Get("X-API-Key")
+	if bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		credential = bearer
+	}
+	if credential == "" {
+		return false
+	}
+	token := r.Token
+	if token == "" {
+		token = "fake-token"
+	}
+	if credential == token {
+		return true
+	}
+	for _, key := range r.apiKeys {
+		if key.Active && key.Key == credential {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Registry) apiKeysRoute(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "POST":
+		var body struct {
+			Scopes []string `json:"scopes"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		key := fmt.Sprintf("fake-key-%d", len(r.apiKeys)+1)
+		id := r.addAPIKeyLocked(key, body.Scopes)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"api_key":    key,
+			"key_id":     id,
+			"scopes":     body.Scopes,
+			"created_at": "2024-01-01T00:00:00Z",
+			"expires_at": nil,
+		})
+	case "GET":
+		activeOnly := req.URL.Query().Get("active_only") == "true"
+		ids := make([]string, 0, len(r.apiKeys))
+		for id := range r.apiKeys {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		keys := []map[string]interface{}{}
+		for _, id := range ids {
+			key := r.apiKeys[id]
+			if activeOnly && !key.Active {
+				continue
+			}
+			keys = append(keys, map[string]interface{}{"key_id": key.ID, "scopes": key.Scopes, "is_active": key.Active})
+		}
+		writeJSON(w, http.StatusOK, keys)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (r *Registry) stats(w http.ResponseWriter) {
+	public := 0
+	for _, agent := range r.agents {
+		if agent.Public {
+			public++
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_agents":   len(r.agents),
+		"public_agents":  public,
+		"private_agents": len(r.agents) - public,
+	})
+}
+
+func (r *Registry) publish(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		ID         string                 `json:"id"`
+		Public     bool                   `json:"public"`
+		Visibility string                 `json:"visibility"`
+		Card       map[string]interface{} `json:"card"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Card == nil {
+		writeError(w, http.StatusUnprocessableEntity, "card is required")
+		return
+	}
+
+	id := body.ID
+	if id == "" && r.RejectDuplicates {
+		for _, agent := range r.agents {
+			if agent.Card["name"] == body.Card["name"] && providerOf(agent.Card) == providerOf(body.Card) {
+				writeError(w, http.StatusConflict, "Agent already exists")
+				return
+			}
+		}
+	}
+	if id == "" {
+		id = r.newIDLocked()
+	}
+	agent := &Agent{ID: id, Public: body.Public, Active: true, Card: body.Card}
+	if r.Visibility && body.Visibility != "" {
+		agent.Public = body.Visibility == "public"
+		agent.Unlisted = body.Visibility == "unlisted"
+	}
+	r.agents[id] = agent
+	writeJSON(w, http.StatusOK, map[string]interface{}{"agentId": id})
+}
+
+func (r *Registry) agent(w http.ResponseWriter, req *http.Request, id string) {
+	agent, ok := r.agents[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Agent not found")
+		return
+	}
+
+	switch req.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, r.agentDocument(agent))
+	case "PUT":
+		var update map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		for _, key := range []string{"name", "description", "version"} {
+			if v, ok := update[key]; ok {
+				agent.Card[key] = v
+			}
+		}
+		if v, ok := update["is_public"].(bool); ok {
+			agent.Public = v
+			agent.Unlisted = false
+		}
+		if v, ok := update["visibility"].(string); ok && r.Visibility {
+			agent.Public = v == "public"
+			agent.Unlisted = v == "unlisted"
+		}
+		if v, ok := update["is_active"].(bool); ok {
+			agent.Active = v
+		}
+		writeJSON(w, http.StatusOK, r.agentDocument(agent))
+	case "DELETE":
+		delete(r.agents, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (r *Registry) list(w http.ResponseWriter, req *http.Request, publicOnly bool) {
+	var matched []*Agent
+	for _, agent := range r.sortedLocked() {
+		if (!publicOnly || agent.Public) && !agent.Unlisted {
+			matched = append(matched, agent)
+		}
+	}
+	r.writePage(w, req.URL.Query().Get("page"), req.URL.Query().Get("limit"), matched)
+}
+
+func (r *Registry) search(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Query string `json:"query"`
+		Page  int    `json:"page"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	query := strings.ToLower(body.Query)
+	var matched []*Agent
+	for _, agent := range r.sortedLocked() {
+		name, _ := agent.Card["name"].(string)
+		description, _ := agent.Card["description"].(string)
+		if strings.Contains(strings.ToLower(name+" "+description), query) && !agent.Unlisted {
+			matched = append(matched, agent)
+		}
+	}
+	r.writePage(w, strconv.Itoa(body.Page), strconv.Itoa(body.Limit), matched)
+}
+
+func (r *Registry) writePage(w http.ResponseWriter, pageParam, limitParam string, agents []*Agent) {
+	page, _ := strconv.Atoi(pageParam)
+	limit, _ := strconv.Atoi(limitParam)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if r.MaxPageSize > 0 && limit > r.MaxPageSize {
+		limit = r.MaxPageSize
+	}
+	start := (page - 1) * limit
+	if start > len(agents) {
+		start = len(agents)
+	}
+	end := start + limit
+	if end > len(agents) {
+		end = len(agents)
+	}
+
+	documents := make([]map[string]interface{}, 0, end-start)
+	for _, agent := range agents[start:end] {
+		documents = append(documents, r.agentDocument(agent))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"agents": documents,
+		"total":  len(agents),
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// sortedLocked returns the agents ordered by id.
+func (r *Registry) sortedLocked() []*Agent {
+	agents := make([]*Agent, 0, len(r.agents))
+	for _, agent := range r.agents {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents
+}
+
+func (r *Registry) newIDLocked() string {
+	for {
+		r.nextID++
+		id := fmt.Sprintf("agent-%d", r.nextID)
+		if _, taken := r.agents[id]; !taken {
+			return id
+		}
+	}
+}
+
+// agentDocument renders a stored agent in the registry's Agent shape.
+func (r *Registry) agentDocument(agent *Agent) map[string]interface{} {
+	doc := map[string]interface{}{
+		"id":         agent.ID,
+		"is_public":  agent.Public,
+		"is_active":  agent.Active,
+		"agent_card": agent.Card,
+		"provider":   providerOf(agent.Card),
+	}
+	for _, key := range []string{"name", "description", "version", "capabilities", "skills"} {
+		if v, ok := agent.Card[key]; ok {
+			doc[key] = v
+		}
+	}
+	if url, ok := agent.Card["url"]; ok {
+		doc["location_url"] = url
+	}
+	if r.Visibility {
+		// Newer registries send visibility in place of is_public.
+		delete(doc, "is_public")
+		switch {
+		case agent.Unlisted:
+			doc["visibility"] = "unlisted"
+		case agent.Public:
+			doc["visibility"] = "public"
+		default:
+			doc["visibility"] = "private"
+		}
+	}
+	return doc
+}
+
+func providerOf(card map[string]interface{}) string {
+	provider, _ := card["provider"].(map[string]interface{})
+	organization, _ := provider["organization"].(string)
+	return organization
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]interface{}{"detail": detail})
+}
@@ -0,0 +1,115 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// ActivateAgent sets an agent's IsActive, without touching its other
+// fields. It is SetAgentActive(ctx, agentID, true).
+func (c *A2ARegClient) ActivateAgent(ctx context.Context, agentID string) (*Agent, bool, error) {
+	return c.SetAgentActive(ctx, agentID, true)
+}
+
+// SetAgentActive activates or deactivates an agent now and returns it
+// updated, with whether anything changed: an agent already in that state,
+// including one the registry answers 409 for, is a successful no-op. Use
+// DeactivateAgent instead to give a reason or schedule the deactivation.
+//
+// The registry's POST /agents/{id}/activate or /deactivate is used when it
+// has them. Otherwise the agent is read and, if needed, updated with a
+// PATCH of is_active alone or, on registries without PATCH, a PUT of the
+// agent as read with only IsActive changed.
+func (c *A2ARegClient) SetAgentActive(ctx context.Context, agentID string, active bool) (*Agent, bool, error) {
+	if !c.activationUnavailable.Load() {
+		action := "/deactivate"
+		if active {
+			action = "/activate"
+		}
+		resp, err := c.sendMutation(ctx, "POST", "/agents/"+agentID+action, agentID, nil)
+		c.invalidateAgent(agentID)
+		switch {
+		case err == nil:
+			agent, err := c.activationResult(ctx, agentID, resp.body)
+			return agent, true, err
+		case StatusCode(err) == http.StatusConflict:
+			return c.alreadyActive(ctx, agentID, active, err)
+		case !isSearchUnavailable(err):
+			return nil, false, agentNotFound(err)
+		}
+	}
+
+	// A 404 above may mean the agent is missing rather than the endpoint;
+	// reading the agent tells them apart.
+	agent, err := c.readAgent(ctx, agentID)
+	if err != nil {
+		return nil, false, err
+	}
+	c.activationUnavailable.Store(true)
+	if agent.IsActive == active {
+		return agent, false, nil
+	}
+
+	if !c.patchUnavailable.Load() {
+		resp, err := c.sendMutation(ctx, "PATCH", "/agents/"+agentID, agentID, map[string]interface{}{"is_active": active})
+		c.invalidateAgent(agentID)
+		switch {
+		case err == nil:
+			agent, err := c.activationResult(ctx, agentID, resp.body)
+			return agent, true, err
+		case StatusCode(err) == http.StatusConflict:
+			return c.alreadyActive(ctx, agentID, active, err)
+		case !isSearchUnavailable(err):
+			return nil, false, agentNotFound(err)
+		}
+		c.patchUnavailable.Store(true)
+	}
+
+	agent.IsActive = active
+	resp, err := c.sendMutation(ctx, "PUT", "/agents/"+agentID, agentID, agent)
+	c.invalidateAgent(agentID)
+	if err != nil {
+		return nil, false, agentNotFound(err)
+	}
+	agent, err = c.activationResult(ctx, agentID, resp.body)
+	return agent, true, err
+}
+
+// activationResult decodes the agent an activation returned, reading it
+// afresh when the registry answered with a bare acknowledgement.
+func (c *A2ARegClient) activationResult(ctx context.Context, agentID string, body []byte) (*Agent, error) {
+	var agent Agent
+	if json.Unmarshal(body, &agent) == nil && (agent.ID != nil || agent.Name != "") {
+		agent.interfacesFromCard()
+		return &agent, nil
+	}
+	return c.readAgent(ctx, agentID)
+}
+
+// readAgent gets agentID bound to ctx, with its ETag.
+func (c *A2ARegClient) readAgent(ctx context.Context, agentID string) (*Agent, error) {
+	resp, err := c.getAgentResponse(ctx, agentID, nil)
+	if err != nil {
+		return nil, err
+	}
+	agent, err := decodeAgent(resp.body)
+	if err != nil {
+		return nil, err
+	}
+	agent.ETag = resp.header.Get("ETag")
+	return agent, nil
+}
+
+// alreadyActive handles a 409 to an activation: it is a no-op success if
+// the agent is in the requested state, and conflictErr otherwise.
+func (c *A2ARegClient) alreadyActive(ctx context.Context, agentID string, active bool, conflictErr error) (*Agent, bool, error) {
+	agent, err := c.readAgent(ctx, agentID)
+	if err != nil {
+		return nil, false, err
+	}
+	if agent.IsActive != active {
+		return nil, false, conflictErr
+	}
+	return agent, false, nil
+}
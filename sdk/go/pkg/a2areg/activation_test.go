@@ -0,0 +1,162 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activationRegistry holds one agent, agent-1. With targeted set it has the
+// activate and deactivate endpoints, answering 409 when the agent is
+// already in that state; without it, it has PATCH only if patch is set,
+// and always PUT.
+type activationRegistry struct {
+	*httptest.Server
+	targeted bool
+	patch    bool
+
+	mu       sync.Mutex
+	active   bool
+	requests []string // "METHOD path body"
+}
+
+func newActivationRegistry(t *testing.T, targeted, patch, active bool) *activationRegistry {
+	r := &activationRegistry{targeted: targeted, patch: patch, active: active}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, strings.TrimSpace(req.Method+" "+req.URL.Path+" "+string(body)))
+
+		path := req.URL.Path
+		if !strings.HasPrefix(path, "/agents/agent-1") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case req.Method == "POST" && r.targeted && (path == "/agents/agent-1/activate" || path == "/agents/agent-1/deactivate"):
+			want := path == "/agents/agent-1/activate"
+			if r.active == want {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"detail": "Agent is already in that state"}`))
+				return
+			}
+			r.active = want
+			w.Write([]byte(`{"success": true}`))
+		case req.Method == "PATCH" && r.patch && path == "/agents/agent-1":
+			var patch map[string]bool
+			require.NoError(t, json.Unmarshal(body, &patch))
+			assert.Len(t, patch, 1)
+			r.active = patch["is_active"]
+			r.writeAgent(w)
+		case req.Method == "PUT" && path == "/agents/agent-1":
+			var agent Agent
+			require.NoError(t, json.Unmarshal(body, &agent))
+			assert.Equal(t, "Toggle Agent", agent.Name, "the agent is sent as read")
+			r.active = agent.IsActive
+			r.writeAgent(w)
+		case req.Method == "GET" && path == "/agents/agent-1":
+			r.writeAgent(w)
+		case req.Method == "PATCH":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *activationRegistry) writeAgent(w http.ResponseWriter) {
+	json.NewEncoder(w).Encode(Agent{ID: stringPtr("agent-1"), Name: "Toggle Agent", Description: "d", Version: "1.0.0", Provider: "acme", IsActive: r.active})
+}
+
+func (r *activationRegistry) methods() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	methods := make([]string, len(r.requests))
+	for i, request := range r.requests {
+		fields := strings.Fields(request)
+		methods[i] = fields[0] + " " + fields[1]
+	}
+	r.requests = nil
+	return methods
+}
+
+func TestSetAgentActive_TargetedEndpoints(t *testing.T) {
+	registry := newActivationRegistry(t, true, false, false)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agent, changed, err := client.ActivateAgent(context.Background(), "agent-1")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, agent.IsActive)
+	assert.Equal(t, []string{"POST /agents/agent-1/activate", "GET /agents/agent-1"}, registry.methods())
+
+	// 409: already active.
+	agent, changed, err = client.ActivateAgent(context.Background(), "agent-1")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.True(t, agent.IsActive)
+
+	agent, changed, err = client.SetAgentActive(context.Background(), "agent-1", false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.False(t, agent.IsActive)
+}
+
+func TestSetAgentActive_PatchFallback(t *testing.T) {
+	registry := newActivationRegistry(t, false, true, true)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agent, changed, err := client.SetAgentActive(context.Background(), "agent-1", false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.False(t, agent.IsActive)
+	assert.Equal(t, []string{"POST /agents/agent-1/deactivate", "GET /agents/agent-1", "PATCH /agents/agent-1"}, registry.methods())
+
+	// The missing endpoint is remembered, and an agent already in the
+	// requested state is left alone.
+	agent, changed, err = client.SetAgentActive(context.Background(), "agent-1", false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.False(t, agent.IsActive)
+	assert.Equal(t, []string{"GET /agents/agent-1"}, registry.methods())
+}
+
+func TestSetAgentActive_ReadModifyWriteFallback(t *testing.T) {
+	registry := newActivationRegistry(t, false, false, false)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agent, changed, err := client.ActivateAgent(context.Background(), "agent-1")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, agent.IsActive)
+	assert.Equal(t, []string{"POST /agents/agent-1/activate", "GET /agents/agent-1", "PATCH /agents/agent-1", "PUT /agents/agent-1"}, registry.methods())
+
+	_, _, err = client.SetAgentActive(context.Background(), "agent-1", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /agents/agent-1", "PUT /agents/agent-1"}, registry.methods())
+}
+
+func TestSetAgentActive_AgentNotFound(t *testing.T) {
+	registry := newActivationRegistry(t, true, false, false)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	_, changed, err := client.ActivateAgent(context.Background(), "missing")
+	assert.False(t, changed)
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+
+	// A missing agent does not mark the targeted endpoints absent.
+	_, _, err = client.ActivateAgent(context.Background(), "agent-1")
+	require.NoError(t, err)
+	assert.Contains(t, registry.methods(), "POST /agents/agent-1/activate")
+}
@@ -0,0 +1,208 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// adkCard is an agent card in the dialect written by Google's Agent
+// Development Kit: modes and transport at the top level, no interface block,
+// and OpenAPI-style security schemes keyed by name.
+type adkCard struct {
+	Name                 string                   `json:"name"`
+	Description          string                   `json:"description"`
+	URL                  string                   `json:"url"`
+	Version              string                   `json:"version"`
+	Provider             *AgentProvider           `json:"provider,omitempty"`
+	DocumentationURL     *string                  `json:"documentationUrl,omitempty"`
+	Capabilities         AgentCapabilities        `json:"capabilities"`
+	SecuritySchemes      map[string]adkScheme     `json:"securitySchemes,omitempty"`
+	DefaultInputModes    []string                 `json:"defaultInputModes"`
+	DefaultOutputModes   []string                 `json:"defaultOutputModes"`
+	PreferredTransport   string                   `json:"preferredTransport,omitempty"`
+	AdditionalInterfaces []map[string]interface{} `json:"additionalInterfaces,omitempty"`
+	Skills               []AgentSkill             `json:"skills"`
+}
+
+// adkScheme is an OpenAPI security scheme object.
+type adkScheme struct {
+	Type   string             `json:"type"`
+	In     string             `json:"in,omitempty"`
+	Name   string             `json:"name,omitempty"`
+	Scheme string             `json:"scheme,omitempty"`
+	Flows  map[string]adkFlow `json:"flows,omitempty"`
+}
+
+// adkFlow is an OpenAPI OAuth2 flow object.
+type adkFlow struct {
+	TokenURL string            `json:"tokenUrl,omitempty"`
+	Scopes   map[string]string `json:"scopes"`
+}
+
+// adkTransports maps ADK transport names onto the SDK's.
+var adkTransports = map[string]Transport{
+	"JSONRPC":   TransportJSONRPC,
+	"GRPC":      TransportGRPC,
+	"HTTP+JSON": TransportHTTP,
+}
+
+// adkFlows maps OpenAPI OAuth2 flow names onto the SDK's.
+var adkFlows = map[string]string{
+	"clientCredentials": "client_credentials",
+	"authorizationCode": "authorization_code",
+	"password":          "password",
+	"implicit":          "implicit",
+}
+
+// ImportADKCard parses an agent card in the ADK dialect. The Interface block
+// is synthesized from the top-level modes, preferredTransport and
+// additionalInterfaces, and security schemes keep their keys. An http bearer
+// scheme becomes type "bearer"; an OAuth2 scheme keeps its first flow (in
+// flow-name order), with scope descriptions dropped.
+func ImportADKCard(data []byte) (*AgentCardSpec, error) {
+	var adk adkCard
+	if err := json.Unmarshal(data, &adk); err != nil {
+		return nil, withCode(NewValidationError("Invalid ADK agent card: "+err.Error(), nil), CodeDecodeFailed)
+	}
+	if adk.Name == "" || adk.Version == "" {
+		return nil, NewValidationError("ADK agent card is missing name or version", nil)
+	}
+
+	transport := TransportJSONRPC
+	if adk.PreferredTransport != "" {
+		transport = normalizeTransport(adk.PreferredTransport)
+	}
+
+	card := &AgentCardSpec{
+		Name:             adk.Name,
+		Description:      adk.Description,
+		URL:              adk.URL,
+		Version:          adk.Version,
+		Provider:         adk.Provider,
+		DocumentationURL: adk.DocumentationURL,
+		Capabilities:     adk.Capabilities,
+		Skills:           adk.Skills,
+		Interface: AgentInterface{
+			PreferredTransport:   transport,
+			DefaultInputModes:    adk.DefaultInputModes,
+			DefaultOutputModes:   adk.DefaultOutputModes,
+			AdditionalInterfaces: adk.AdditionalInterfaces,
+		},
+		DefaultInputModes:  adk.DefaultInputModes,
+		DefaultOutputModes: adk.DefaultOutputModes,
+	}
+
+	if len(adk.SecuritySchemes) > 0 {
+		card.SecuritySchemes = make(map[string]SecurityScheme, len(adk.SecuritySchemes))
+		for key, s := range adk.SecuritySchemes {
+			card.SecuritySchemes[key] = importADKScheme(s)
+		}
+	}
+	return card, nil
+}
+
+func importADKScheme(s adkScheme) SecurityScheme {
+	scheme := SecurityScheme{Type: AuthSchemeType(s.Type)}
+	if s.Type == "http" && strings.EqualFold(s.Scheme, "bearer") {
+		scheme.Type = AuthSchemeBearer
+	}
+	if s.In != "" {
+		location := normalizeLocation(s.In)
+		scheme.Location = &location
+	}
+	if s.Name != "" {
+		scheme.Name = &s.Name
+	}
+
+	flowNames := make([]string, 0, len(s.Flows))
+	for name := range s.Flows {
+		flowNames = append(flowNames, name)
+	}
+	sort.Strings(flowNames)
+	if len(flowNames) > 0 {
+		flowName := flowNames[0]
+		flow := s.Flows[flowName]
+		if mapped, ok := adkFlows[flowName]; ok {
+			flowName = mapped
+		}
+		scheme.Flow = &flowName
+		if flow.TokenURL != "" {
+			scheme.TokenURL = &flow.TokenURL
+		}
+		for scope := range flow.Scopes {
+			scheme.Scopes = append(scheme.Scopes, scope)
+		}
+		sort.Strings(scheme.Scopes)
+	}
+	return scheme
+}
+
+// ConvertToADK renders card in the ADK dialect, the reverse of
+// ImportADKCard. Top-level modes fall back to the Interface block's.
+// Credentials and the card signature have no ADK equivalent and are
+// omitted.
+func ConvertToADK(card *AgentCardSpec) ([]byte, error) {
+	adk := adkCard{
+		Name:                 card.Name,
+		Description:          card.Description,
+		URL:                  card.URL,
+		Version:              card.Version,
+		Provider:             card.Provider,
+		DocumentationURL:     card.DocumentationURL,
+		Capabilities:         card.Capabilities,
+		Skills:               card.Skills,
+		DefaultInputModes:    card.DefaultInputModes,
+		DefaultOutputModes:   card.DefaultOutputModes,
+		PreferredTransport:   string(card.Interface.PreferredTransport),
+		AdditionalInterfaces: card.Interface.AdditionalInterfaces,
+	}
+	if adk.DefaultInputModes == nil {
+		adk.DefaultInputModes = card.Interface.DefaultInputModes
+	}
+	if adk.DefaultOutputModes == nil {
+		adk.DefaultOutputModes = card.Interface.DefaultOutputModes
+	}
+	for adkName, name := range adkTransports {
+		if name == card.Interface.PreferredTransport {
+			adk.PreferredTransport = adkName
+		}
+	}
+
+	if len(card.SecuritySchemes) > 0 {
+		adk.SecuritySchemes = make(map[string]adkScheme, len(card.SecuritySchemes))
+		for key, s := range card.SecuritySchemes {
+			adk.SecuritySchemes[key] = exportADKScheme(s)
+		}
+	}
+	return json.Marshal(adk)
+}
+
+func exportADKScheme(s SecurityScheme) adkScheme {
+	scheme := adkScheme{
+		Type: string(s.Type),
+		Name: getStringValue(s.Name, ""),
+	}
+	if s.Location != nil {
+		scheme.In = string(*s.Location)
+	}
+	if s.Type == AuthSchemeBearer {
+		scheme.Type = "http"
+		scheme.Scheme = "bearer"
+	}
+
+	if s.Flow != nil {
+		flowName := *s.Flow
+		for adkName, name := range adkFlows {
+			if name == flowName {
+				flowName = adkName
+			}
+		}
+		flow := adkFlow{TokenURL: getStringValue(s.TokenURL, ""), Scopes: map[string]string{}}
+		for _, scope := range s.Scopes {
+			flow.Scopes[scope] = ""
+		}
+		scheme.Flows = map[string]adkFlow{flowName: flow}
+	}
+	return scheme
+}
@@ -0,0 +1,103 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportADKCard(t *testing.T) {
+	data, err := os.ReadFile("testdata/adk_agent.json")
+	require.NoError(t, err)
+
+	card, err := ImportADKCard(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "currency_agent", card.Name)
+	assert.Equal(t, "http://localhost:10000/", card.URL)
+	assert.Equal(t, "Example Co", card.Provider.Organization)
+	assert.True(t, *card.Capabilities.Streaming)
+
+	assert.Equal(t, TransportJSONRPC, card.Interface.PreferredTransport)
+	assert.Equal(t, []string{"text", "text/plain"}, card.Interface.DefaultInputModes)
+	assert.Equal(t, []string{"text", "text/plain"}, card.Interface.DefaultOutputModes)
+	assert.Equal(t, card.Interface.DefaultInputModes, card.DefaultInputModes)
+	assert.Len(t, card.Interface.AdditionalInterfaces, 1)
+
+	require.Len(t, card.SecuritySchemes, 3)
+	apiKey := card.SecuritySchemes["api_key"]
+	assert.Equal(t, AuthSchemeAPIKey, apiKey.Type)
+	assert.Equal(t, LocationHeader, *apiKey.Location)
+	assert.Equal(t, "X-API-Key", *apiKey.Name)
+	assert.Equal(t, AuthSchemeBearer, card.SecuritySchemes["bearer_auth"].Type)
+	oauth := card.SecuritySchemes["oauth"]
+	assert.Equal(t, "client_credentials", *oauth.Flow)
+	assert.Equal(t, "https://example.com/oauth/token", *oauth.TokenURL)
+	assert.Equal(t, []string{"rates:read"}, oauth.Scopes)
+
+	require.Len(t, card.Skills, 1)
+	assert.Equal(t, "convert_currency", card.Skills[0].ID)
+}
+
+func TestImportADKCard_Invalid(t *testing.T) {
+	_, err := ImportADKCard([]byte(`{"name": 1}`))
+	assert.Equal(t, CodeDecodeFailed, ErrorCode(err))
+
+	_, err = ImportADKCard([]byte(`{"description": "no name"}`))
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+}
+
+func TestConvertToADK_RoundTrip(t *testing.T) {
+	data, err := os.ReadFile("testdata/adk_agent.json")
+	require.NoError(t, err)
+
+	card, err := ImportADKCard(data)
+	require.NoError(t, err)
+	out, err := ConvertToADK(card)
+	require.NoError(t, err)
+
+	var want, got map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &want))
+	require.NoError(t, json.Unmarshal(out, &got))
+
+	// Scope descriptions are the only ADK data the SDK model cannot hold.
+	scopes := want["securitySchemes"].(map[string]interface{})["oauth"].(map[string]interface{})["flows"].(map[string]interface{})["clientCredentials"].(map[string]interface{})["scopes"].(map[string]interface{})
+	scopes["rates:read"] = ""
+	assert.Equal(t, want, got)
+
+	// And back again.
+	again, err := ImportADKCard(out)
+	require.NoError(t, err)
+	assert.Equal(t, card, again)
+}
+
+func TestConvertToADK_FromSDKCard(t *testing.T) {
+	location, name := LocationHeader, "X-API-Key"
+	card := &AgentCardSpec{
+		Name:    "Recipe Agent",
+		Version: "1.0.0",
+		URL:     "https://recipe.example.com",
+		SecuritySchemes: map[string]SecurityScheme{
+			"apiKey": {Type: "apiKey", Location: &location, Name: &name},
+		},
+		Interface: AgentInterface{
+			PreferredTransport: "http",
+			DefaultInputModes:  []string{"text/plain"},
+			DefaultOutputModes: []string{"application/json"},
+		},
+	}
+
+	out, err := ConvertToADK(card)
+	require.NoError(t, err)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(out, &got))
+	assert.Equal(t, "HTTP+JSON", got["preferredTransport"])
+	assert.Equal(t, []interface{}{"text/plain"}, got["defaultInputModes"])
+	assert.Equal(t, []interface{}{"application/json"}, got["defaultOutputModes"])
+	assert.Equal(t, map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"}, got["securitySchemes"].(map[string]interface{})["apiKey"])
+	assert.NotContains(t, got, "interface")
+}
@@ -0,0 +1,243 @@
+package a2areg
+
+// AgentBuilder builds an Agent without a local variable for every optional
+// field whose address is needed. Each method sets part of the agent and
+// returns the builder, so calls chain:
+//
+//	agent, err := NewAgentBuilder("Recipe Agent", "1.0.0").
+//		Description("Suggests recipes").
+//		Provider("acme").
+//		Public(true).
+//		LocationURL("https://agents.example.com/recipes").
+//		WithStreaming(true).
+//		AddAPIKeyAuth("X-API-Key").
+//		Build()
+//
+// Nothing is checked until Build or BuildCard.
+type AgentBuilder struct {
+	agent     Agent
+	validator *A2ARegClient
+}
+
+// NewAgentBuilder starts an agent with the given name and version.
+func NewAgentBuilder(name, version string) *AgentBuilder {
+	return &AgentBuilder{agent: Agent{Name: name, Version: version}}
+}
+
+// Description sets the agent's description.
+func (b *AgentBuilder) Description(description string) *AgentBuilder {
+	b.agent.Description = description
+	return b
+}
+
+// Provider sets the organization providing the agent.
+func (b *AgentBuilder) Provider(organization string) *AgentBuilder {
+	b.agent.Provider = organization
+	b.agent.ProviderInfo = nil
+	return b
+}
+
+// ProviderInfo sets the provider's organization and URL.
+func (b *AgentBuilder) ProviderInfo(provider AgentProvider) *AgentBuilder {
+	b.agent.Provider = provider.Organization
+	b.agent.ProviderInfo = &provider
+	return b
+}
+
+// Public makes the agent public, or private when public is false.
+func (b *AgentBuilder) Public(public bool) *AgentBuilder {
+	if public {
+		return b.Visibility(VisibilityPublic)
+	}
+	return b.Visibility(VisibilityPrivate)
+}
+
+// Visibility sets where the agent can be found.
+func (b *AgentBuilder) Visibility(visibility Visibility) *AgentBuilder {
+	b.agent.Visibility = visibility
+	b.agent.IsPublic = visibility == VisibilityPublic
+	return b
+}
+
+// Active marks the agent active.
+func (b *AgentBuilder) Active(active bool) *AgentBuilder {
+	b.agent.IsActive = active
+	return b
+}
+
+// LocationURL sets where the agent is served; it becomes the card's URL.
+func (b *AgentBuilder) LocationURL(url string) *AgentBuilder {
+	b.agent.LocationURL = &url
+	return b
+}
+
+// LocationType sets the kind of location, e.g. "url".
+func (b *AgentBuilder) LocationType(locationType string) *AgentBuilder {
+	b.agent.LocationType = &locationType
+	return b
+}
+
+// Tags adds agent-level tags.
+func (b *AgentBuilder) Tags(tags ...string) *AgentBuilder {
+	b.agent.Tags = append(b.agent.Tags, tags...)
+	return b
+}
+
+// Label sets a label.
+func (b *AgentBuilder) Label(key, value string) *AgentBuilder {
+	if b.agent.Labels == nil {
+		b.agent.Labels = make(map[string]string)
+	}
+	b.agent.Labels[key] = value
+	return b
+}
+
+// Region sets the agent's deployment region.
+func (b *AgentBuilder) Region(region string) *AgentBuilder {
+	b.agent.Region = region
+	return b
+}
+
+// WithStreaming sets the streaming capability.
+func (b *AgentBuilder) WithStreaming(enabled bool) *AgentBuilder {
+	b.capabilities().Streaming = &enabled
+	return b
+}
+
+// WithPushNotifications sets the push notifications capability.
+func (b *AgentBuilder) WithPushNotifications(enabled bool) *AgentBuilder {
+	b.capabilities().PushNotifications = &enabled
+	return b
+}
+
+// WithStateTransitionHistory sets the state transition history
+// capability.
+func (b *AgentBuilder) WithStateTransitionHistory(enabled bool) *AgentBuilder {
+	b.capabilities().StateTransitionHistory = &enabled
+	return b
+}
+
+// WithAuthenticatedExtendedCard sets whether the agent serves an extended
+// card to authenticated clients.
+func (b *AgentBuilder) WithAuthenticatedExtendedCard(enabled bool) *AgentBuilder {
+	b.capabilities().SupportsAuthenticatedExtendedCard = &enabled
+	return b
+}
+
+func (b *AgentBuilder) capabilities() *AgentCapabilities {
+	if b.agent.Capabilities == nil {
+		b.agent.Capabilities = &AgentCapabilities{}
+	}
+	return b.agent.Capabilities
+}
+
+// AddSkill adds a skill.
+func (b *AgentBuilder) AddSkill(skill AgentSkill) *AgentBuilder {
+	b.agent.Skills = append(b.agent.Skills, skill)
+	return b
+}
+
+// AddAPIKeyAuth adds an API key scheme sent in the given header.
+func (b *AgentBuilder) AddAPIKeyAuth(header string) *AgentBuilder {
+	location := LocationHeader
+	return b.AddAuthScheme(SecurityScheme{Type: AuthSchemeAPIKey, Location: &location, Name: &header})
+}
+
+// AddBearerAuth adds a bearer token scheme sent in the Authorization
+// header.
+func (b *AgentBuilder) AddBearerAuth() *AgentBuilder {
+	location := LocationHeader
+	return b.AddAuthScheme(SecurityScheme{Type: AuthSchemeBearer, Location: &location, Name: stringPtr("Authorization")})
+}
+
+// AddOAuth2Auth adds an OAuth2 client credentials scheme.
+func (b *AgentBuilder) AddOAuth2Auth(tokenURL string, scopes ...string) *AgentBuilder {
+	return b.AddAuthScheme(SecurityScheme{Type: AuthSchemeOAuth2, Flow: stringPtr("client_credentials"), TokenURL: &tokenURL, Scopes: scopes})
+}
+
+// AddAuthScheme adds an auth scheme as given.
+func (b *AgentBuilder) AddAuthScheme(scheme SecurityScheme) *AgentBuilder {
+	b.agent.AuthSchemes = append(b.agent.AuthSchemes, scheme)
+	return b
+}
+
+// AddInterface adds a transport endpoint. The first one added is the
+// preferred transport unless PreferredTransport says otherwise.
+func (b *AgentBuilder) AddInterface(transport Transport, url string) *AgentBuilder {
+	b.agent.Interfaces = append(b.agent.Interfaces, AgentInterfaceEntry{Transport: transport, URL: url})
+	return b
+}
+
+// PreferredTransport sets the transport clients should use.
+func (b *AgentBuilder) PreferredTransport(transport Transport) *AgentBuilder {
+	b.agent.PreferredTransport = transport
+	return b
+}
+
+// ValidateWith makes Build and BuildCard validate with c's rules (name
+// rules, allowed hosts, tag taxonomy, ...) instead of the defaults.
+func (b *AgentBuilder) ValidateWith(c *A2ARegClient) *AgentBuilder {
+	b.validator = c
+	return b
+}
+
+// Build validates the agent with ValidateAgent and returns it. The
+// builder can be changed and built again; agents already built are not
+// affected.
+func (b *AgentBuilder) Build() (*Agent, error) {
+	agent := b.copyAgent()
+	if err := b.validatorOrDefault().ValidateAgent(agent); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+// BuildCard builds the agent, converts it with ConvertAgentToCard and
+// validates the card with ValidateAgentCard. Agent fields the card has no
+// place for, such as tags, are left out.
+func (b *AgentBuilder) BuildCard() (*AgentCardSpec, error) {
+	agent, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	card, _, err := ConvertAgentToCard(agent)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.validatorOrDefault().ValidateAgentCard(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (b *AgentBuilder) validatorOrDefault() *A2ARegClient {
+	if b.validator == nil {
+		b.validator = NewA2ARegClient(A2ARegClientOptions{})
+	}
+	return b.validator
+}
+
+// copyAgent copies the agent far enough that later builder calls do not
+// change it.
+func (b *AgentBuilder) copyAgent() *Agent {
+	agent := b.agent
+	agent.Tags = append([]string(nil), b.agent.Tags...)
+	agent.Skills = append([]AgentSkill(nil), b.agent.Skills...)
+	agent.AuthSchemes = append([]SecurityScheme(nil), b.agent.AuthSchemes...)
+	agent.Interfaces = append([]AgentInterfaceEntry(nil), b.agent.Interfaces...)
+	if b.agent.Labels != nil {
+		agent.Labels = make(map[string]string, len(b.agent.Labels))
+		for key, value := range b.agent.Labels {
+			agent.Labels[key] = value
+		}
+	}
+	if b.agent.Capabilities != nil {
+		capabilities := *b.agent.Capabilities
+		agent.Capabilities = &capabilities
+	}
+	if b.agent.ProviderInfo != nil {
+		provider := *b.agent.ProviderInfo
+		agent.ProviderInfo = &provider
+	}
+	return &agent
+}
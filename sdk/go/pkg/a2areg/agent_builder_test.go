@@ -0,0 +1,113 @@
+package a2areg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recipeSkill() AgentSkill {
+	return AgentSkill{ID: "suggest", Name: "Suggest recipes", Description: "Suggests recipes", Tags: []string{"cooking"}}
+}
+
+func TestAgentBuilder_PointerFields(t *testing.T) {
+	agent, err := NewAgentBuilder("Recipe Agent", "1.0.0").
+		Description("Suggests recipes").
+		Provider("acme").
+		Public(true).
+		LocationURL("https://agents.example.com/recipes").
+		LocationType("url").
+		WithStreaming(true).
+		WithPushNotifications(false).
+		AddSkill(recipeSkill()).
+		AddAPIKeyAuth("X-API-Key").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, "Recipe Agent", agent.Name)
+	assert.Equal(t, "1.0.0", agent.Version)
+	assert.Equal(t, "acme", agent.ProviderName())
+	assert.True(t, agent.IsPublic)
+	assert.Equal(t, VisibilityPublic, agent.Visibility)
+	require.NotNil(t, agent.LocationURL)
+	assert.Equal(t, "https://agents.example.com/recipes", *agent.LocationURL)
+	require.NotNil(t, agent.LocationType)
+	assert.Equal(t, "url", *agent.LocationType)
+
+	require.NotNil(t, agent.Capabilities)
+	require.NotNil(t, agent.Capabilities.Streaming)
+	assert.True(t, *agent.Capabilities.Streaming)
+	require.NotNil(t, agent.Capabilities.PushNotifications)
+	assert.False(t, *agent.Capabilities.PushNotifications)
+	assert.Nil(t, agent.Capabilities.StateTransitionHistory, "capabilities not set stay unset")
+
+	require.Len(t, agent.AuthSchemes, 1)
+	scheme := agent.AuthSchemes[0]
+	assert.Equal(t, AuthSchemeAPIKey, scheme.Type)
+	require.NotNil(t, scheme.Location)
+	assert.Equal(t, LocationHeader, *scheme.Location)
+	require.NotNil(t, scheme.Name)
+	assert.Equal(t, "X-API-Key", *scheme.Name)
+	assert.Equal(t, []AgentSkill{recipeSkill()}, agent.Skills)
+}
+
+func TestAgentBuilder_BuildCopies(t *testing.T) {
+	builder := NewAgentBuilder("Recipe Agent", "1.0.0").Description("Suggests recipes").Provider("acme").
+		WithStreaming(true).Label("team", "kitchen")
+	first, err := builder.Build()
+	require.NoError(t, err)
+
+	builder.WithStreaming(false).Label("team", "pantry").Public(false).AddBearerAuth()
+	second, err := builder.Build()
+	require.NoError(t, err)
+
+	assert.True(t, *first.Capabilities.Streaming)
+	assert.Equal(t, "kitchen", first.Labels["team"])
+	assert.Empty(t, first.AuthSchemes)
+	assert.False(t, *second.Capabilities.Streaming)
+	assert.Equal(t, "pantry", second.Labels["team"])
+	assert.Equal(t, VisibilityPrivate, second.Visibility)
+	assert.Equal(t, AuthSchemeBearer, second.AuthSchemes[0].Type)
+}
+
+func TestAgentBuilder_Validation(t *testing.T) {
+	_, err := NewAgentBuilder("Recipe Agent", "").Description("Suggests recipes").Provider("acme").Build()
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "Agent version is required", validationErr.Message)
+
+	builder := NewAgentBuilder("Recipe Agent", "1.0.0").Description("Suggests recipes").Provider("acme").
+		LocationURL("https://agents.example.com/recipes")
+	_, err = builder.Build()
+	require.NoError(t, err)
+	_, err = builder.ValidateWith(NewA2ARegClient(A2ARegClientOptions{AllowedAgentHosts: []string{"*.internal"}})).Build()
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+func TestAgentBuilder_BuildCard(t *testing.T) {
+	card, err := NewAgentBuilder("Recipe Agent", "1.0.0").
+		Description("Suggests recipes").
+		ProviderInfo(AgentProvider{Organization: "acme", URL: "https://acme.example.com"}).
+		Tags("food").
+		AddInterface(TransportGRPC, "grpc://agents.example.com:443").
+		AddInterface(TransportJSONRPC, "https://agents.example.com/rpc").
+		WithStateTransitionHistory(true).
+		AddSkill(recipeSkill()).
+		AddOAuth2Auth("https://auth.example.com/token", "agents:read").
+		BuildCard()
+	require.NoError(t, err)
+
+	assert.Equal(t, "Recipe Agent", card.Name)
+	assert.Equal(t, TransportGRPC, card.Interface.PreferredTransport, "the first interface is preferred")
+	assert.Equal(t, "grpc://agents.example.com:443", card.URL)
+	assert.Equal(t, &AgentProvider{Organization: "acme", URL: "https://acme.example.com"}, card.Provider)
+	assert.True(t, *card.Capabilities.StateTransitionHistory)
+	assert.False(t, *card.Capabilities.Streaming, "the card states every capability")
+	oauth := card.SecuritySchemes["oauth2"]
+	assert.Equal(t, "https://auth.example.com/token", *oauth.TokenURL)
+	assert.Equal(t, []string{"agents:read"}, oauth.Scopes)
+
+	_, err = NewAgentBuilder("", "1.0.0").BuildCard()
+	assert.Error(t, err)
+}
@@ -0,0 +1,263 @@
+package a2areg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOption adjusts LoadAgentFromFile and LoadAgentCardFromFile.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	strict    bool
+	validator *A2ARegClient
+}
+
+// DisallowUnknownFields makes keys the model has no field for an error
+// instead of being ignored, so that typos do not go unnoticed.
+func DisallowUnknownFields() LoadOption {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
+}
+
+// ValidateWith validates loaded definitions with c's rules (name rules,
+// allowed hosts, tag taxonomy, ...) instead of the defaults.
+func ValidateWith(c *A2ARegClient) LoadOption {
+	return func(o *loadOptions) {
+		o.validator = c
+	}
+}
+
+// fileFormat is the format of an agent definition file, from its
+// extension.
+type fileFormat int
+
+const (
+	fileFormatJSON fileFormat = iota
+	fileFormatYAML
+)
+
+func fileFormatOf(path string) (fileFormat, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return fileFormatJSON, nil
+	case ".yaml", ".yml":
+		return fileFormatYAML, nil
+	default:
+		return 0, NewValidationError(fmt.Sprintf("Unsupported file extension %q; use .json, .yaml or .yml", ext), map[string]interface{}{"path": path})
+	}
+}
+
+// LoadAgentFromFile reads an agent definition from a .json, .yaml or .yml
+// file and validates it with ValidateAgent. YAML files use the agent's JSON
+// member names as keys. Errors about the file's contents are
+// *ValidationError values with the file in Details["path"].
+func LoadAgentFromFile(path string, opts ...LoadOption) (*Agent, error) {
+	o := newLoadOptions(opts)
+	data, err := readDefinition(path)
+	if err != nil {
+		return nil, err
+	}
+	var agent Agent
+	if err := agent.Decode(data, DecodeOptions{LenientDecoding: true, DisallowUnknownFields: o.strict}); err != nil {
+		return nil, definitionDecodeError(path, err)
+	}
+	if err := o.validator.ValidateAgent(&agent); err != nil {
+		return nil, withPath(path, err)
+	}
+	return &agent, nil
+}
+
+// LoadAgentCardFromFile reads an agent card from a .json, .yaml or .yml
+// file and validates it with ValidateAgentCard, like LoadAgentFromFile.
+func LoadAgentCardFromFile(path string, opts ...LoadOption) (*AgentCardSpec, error) {
+	o := newLoadOptions(opts)
+	data, err := readDefinition(path)
+	if err != nil {
+		return nil, err
+	}
+	var card AgentCardSpec
+	if err := unmarshalJSON(data, &card, o.strict); err != nil {
+		return nil, definitionDecodeError(path, err)
+	}
+	if err := o.validator.ValidateAgentCard(&card); err != nil {
+		return nil, withPath(path, err)
+	}
+	return &card, nil
+}
+
+// SaveToFile writes the agent to path as JSON or YAML, chosen by the
+// extension as in LoadAgentFromFile.
+func (a *Agent) SaveToFile(path string) error {
+	return saveDefinition(path, a)
+}
+
+// SaveToFile writes the card to path as JSON or YAML, chosen by the
+// extension as in LoadAgentCardFromFile.
+func (acs *AgentCardSpec) SaveToFile(path string) error {
+	return saveDefinition(path, acs)
+}
+
+func newLoadOptions(opts []LoadOption) loadOptions {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.validator == nil {
+		o.validator = NewA2ARegClient(A2ARegClientOptions{})
+	}
+	return o
+}
+
+// readDefinition reads a definition file and returns it as JSON.
+func readDefinition(path string) ([]byte, error) {
+	format, err := fileFormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to read "+path, map[string]interface{}{"error": err.Error(), "path": path}), CodeInvalidRequest)
+	}
+	if format == fileFormatJSON {
+		return data, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, definitionDecodeError(path, err)
+	}
+	var buf bytes.Buffer
+	if err := yamlToJSON(&buf, &doc); err != nil {
+		return nil, definitionDecodeError(path, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlToJSON writes node as JSON, keeping the order of mapping keys.
+func yamlToJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return yamlToJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return yamlToJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := yamlToJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := yamlToJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// saveDefinition encodes v as JSON and writes it to path in the format its
+// extension selects.
+func saveDefinition(path string, v interface{}) error {
+	format, err := fileFormatOf(path)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return withCode(NewA2AError("Failed to encode "+path, map[string]interface{}{"error": err.Error(), "path": path}), CodeEncodeFailed)
+	}
+	data = append(data, '\n')
+
+	if format == fileFormatYAML {
+		// JSON is YAML: parse it into a node tree and drop the JSON styles
+		// so that it is written in block style. Strings that would read
+		// back as another type are quoted by the encoder.
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return withCode(NewA2AError("Failed to encode "+path, map[string]interface{}{"error": err.Error(), "path": path}), CodeEncodeFailed)
+		}
+		clearYAMLStyle(&doc)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return withCode(NewA2AError("Failed to encode "+path, map[string]interface{}{"error": err.Error(), "path": path}), CodeEncodeFailed)
+		}
+		if err := enc.Close(); err != nil {
+			return withCode(NewA2AError("Failed to encode "+path, map[string]interface{}{"error": err.Error(), "path": path}), CodeEncodeFailed)
+		}
+		data = buf.Bytes()
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return withCode(NewA2AError("Failed to write "+path, map[string]interface{}{"error": err.Error(), "path": path}), CodeRequestFailed)
+	}
+	return nil
+}
+
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+// definitionDecodeError reports a definition file that cannot be decoded.
+func definitionDecodeError(path string, err error) error {
+	return withCode(NewValidationError("Invalid definition in "+path+": "+err.Error(), map[string]interface{}{"path": path}), CodeDecodeFailed)
+}
+
+// withPath adds the definition file path to a validation error.
+func withPath(path string, err error) error {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	details := map[string]interface{}{"path": path}
+	for key, value := range validationErr.Details {
+		details[key] = value
+	}
+	wrapped := withCode(NewValidationError(path+": "+validationErr.Message, details), validationErr.Code)
+	wrapped.fields = validationErr.fields
+	return wrapped
+}
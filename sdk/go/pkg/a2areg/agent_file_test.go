@@ -0,0 +1,113 @@
+package a2areg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func agentFixture(name string) string {
+	return filepath.Join("testdata", "agents", name)
+}
+
+func TestLoadAgentFromFile_Formats(t *testing.T) {
+	fromJSON, err := LoadAgentFromFile(agentFixture("recipe_agent.json"))
+	require.NoError(t, err)
+	fromYAML, err := LoadAgentFromFile(agentFixture("recipe_agent.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, fromJSON, fromYAML)
+	assert.Equal(t, "2.0", fromYAML.Version)
+	assert.Equal(t, "acme", fromYAML.ProviderName())
+	assert.Equal(t, VisibilityPublic, fromYAML.Visibility)
+	require.Len(t, fromYAML.Skills, 2)
+	assert.Equal(t, "plan", fromYAML.Skills[1].ID)
+	assert.Equal(t, map[string]string{"team": "kitchen"}, fromYAML.Labels)
+}
+
+func TestLoadAgentCardFromFile_Formats(t *testing.T) {
+	fromJSON, err := LoadAgentCardFromFile(agentFixture("recipe_card.json"), DisallowUnknownFields())
+	require.NoError(t, err)
+	fromYAML, err := LoadAgentCardFromFile(agentFixture("recipe_card.yml"), DisallowUnknownFields())
+	require.NoError(t, err)
+
+	assert.Equal(t, fromJSON, fromYAML)
+	assert.Equal(t, TransportJSONRPC, fromYAML.Interface.PreferredTransport)
+	assert.Equal(t, LocationHeader, *fromYAML.SecuritySchemes["apiKey"].Location)
+	assert.True(t, *fromYAML.Capabilities.Streaming)
+}
+
+func TestLoadAgentFromFile_UnknownField(t *testing.T) {
+	agent, err := LoadAgentFromFile(agentFixture("unknown_field.yaml"))
+	require.NoError(t, err, "unknown keys are ignored by default")
+	assert.Equal(t, "Finds recipes", agent.Description)
+
+	_, err = LoadAgentFromFile(agentFixture("unknown_field.yaml"), DisallowUnknownFields())
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, CodeDecodeFailed, ErrorCode(err))
+	assert.Contains(t, err.Error(), `unknown field "descripton"`)
+	assert.Equal(t, agentFixture("unknown_field.yaml"), validationErr.Details["path"])
+}
+
+func TestLoadAgentFromFile_Errors(t *testing.T) {
+	path := agentFixture("invalid_agent.yml")
+	_, err := LoadAgentFromFile(path)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Equal(t, path+": Agent version is required", validationErr.Message)
+	assert.Equal(t, path, validationErr.Details["path"])
+
+	// Validation uses the rules of the client given.
+	client := NewA2ARegClient(A2ARegClientOptions{AllowedAgentHosts: []string{"*.internal"}})
+	_, err = LoadAgentFromFile(agentFixture("recipe_agent.yaml"), ValidateWith(client))
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, validationErr.Message, "is not on an allowed host")
+	assert.Equal(t, "https://recipes.example.com/a2a", validationErr.Details["url"], "the error's own details are kept")
+
+	_, err = LoadAgentFromFile(agentFixture("missing.json"))
+	assert.Equal(t, CodeInvalidRequest, ErrorCode(err))
+
+	_, err = LoadAgentFromFile("agent.toml")
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, err.Error(), `Unsupported file extension ".toml"`)
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	require.NoError(t, os.WriteFile(bad, []byte("name: [unclosed"), 0o644))
+	_, err = LoadAgentFromFile(bad)
+	assert.Equal(t, CodeDecodeFailed, ErrorCode(err))
+}
+
+func TestSaveToFile_RoundTrip(t *testing.T) {
+	agent, err := LoadAgentFromFile(agentFixture("recipe_agent.json"))
+	require.NoError(t, err)
+	card, err := LoadAgentCardFromFile(agentFixture("recipe_card.json"))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	for _, name := range []string{"agent.json", "agent.yaml", "agent.yml"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, agent.SaveToFile(path))
+		loaded, err := LoadAgentFromFile(path, DisallowUnknownFields())
+		require.NoError(t, err, name)
+		assert.Equal(t, agent, loaded, name)
+	}
+	for _, name := range []string{"card.json", "card.yaml"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, card.SaveToFile(path))
+		loaded, err := LoadAgentCardFromFile(path, DisallowUnknownFields())
+		require.NoError(t, err, name)
+		assert.Equal(t, card, loaded, name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "agent.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "name: Recipe Agent\n")
+	assert.Contains(t, string(data), `version: "2.0"`, "strings that look like numbers stay strings")
+
+	assert.Error(t, agent.SaveToFile(filepath.Join(dir, "agent.txt")))
+}
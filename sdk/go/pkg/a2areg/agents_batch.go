@@ -0,0 +1,142 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the default number of GetAgent calls
+// GetAgentsByIDs runs at once when it has to fan out.
+const DefaultBatchConcurrency = 8
+
+// BatchOptions configures GetAgentsByIDs.
+type BatchOptions struct {
+	// Concurrency bounds the concurrent GetAgent calls made when the
+	// registry has no batch endpoint. Zero uses DefaultBatchConcurrency.
+	Concurrency int
+}
+
+// agentsBatchResponse is the /agents/batch response: the agents found,
+// and an error for each ID that could not be fetched.
+type agentsBatchResponse struct {
+	Agents []json.RawMessage      `json:"agents"`
+	Errors map[string]batchResult `json:"errors"`
+}
+
+// GetAgentsByIDs fetches the agents with the given IDs. It uses the
+// registry's /agents/batch endpoint and, when the registry lacks it, calls
+// GetAgent for each ID concurrently, at most opts.Concurrency at a time.
+// Repeated IDs are fetched once.
+//
+// An ID that cannot be fetched does not fail the batch: it is left out of
+// the agents map and its error is in the errors map instead, a
+// *NotFoundError with CodeAgentNotFound for unknown agents. The returned
+// error is non-nil only when the batch as a whole could not be run.
+func (c *A2ARegClient) GetAgentsByIDs(ctx context.Context, ids []string, opts BatchOptions) (map[string]*Agent, map[string]error, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return map[string]*Agent{}, map[string]error{}, nil
+	}
+	if !c.agentsBatchUnavailable.Load() {
+		agents, errs, err := c.nativeAgentsBatch(ctx, ids)
+		if err == nil || !isSearchUnavailable(err) {
+			return agents, errs, err
+		}
+		c.agentsBatchUnavailable.Store(true)
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultBatchConcurrency
+	}
+	agents, errs := c.fanOutGetAgent(ctx, ids, opts.Concurrency)
+	return agents, errs, nil
+}
+
+// uniqueIDs returns ids without repeats, in first-seen order.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// nativeAgentsBatch posts ids to /agents/batch. IDs the registry neither
+// returns nor reports an error for are reported as not found.
+func (c *A2ARegClient) nativeAgentsBatch(ctx context.Context, ids []string) (map[string]*Agent, map[string]error, error) {
+	body, err := c.makeRequestContext(ctx, "POST", "/agents/batch", map[string]interface{}{"ids": ids}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp agentsBatchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, nil, withCode(NewA2AError("Failed to decode agents batch response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+
+	requested := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		requested[id] = true
+	}
+	agents := make(map[string]*Agent, len(resp.Agents))
+	errs := make(map[string]error)
+	for _, raw := range resp.Agents {
+		agent, err := decodeAgent(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		if id := getStringValue(agent.ID, ""); requested[id] {
+			agents[id] = agent
+		}
+	}
+	for id, result := range resp.Errors {
+		if err := c.batchError(result); err != nil && requested[id] && agents[id] == nil {
+			errs[id] = agentNotFound(err)
+		}
+	}
+	for _, id := range ids {
+		if agents[id] == nil && errs[id] == nil {
+			errs[id] = withCode(NewNotFoundError("Agent not found", map[string]interface{}{"agent_id": id}), CodeAgentNotFound)
+		}
+	}
+	return agents, errs, nil
+}
+
+// fanOutGetAgent fetches each ID through getAgent, at most concurrency at
+// a time.
+func (c *A2ARegClient) fanOutGetAgent(ctx context.Context, ids []string, concurrency int) (map[string]*Agent, map[string]error) {
+	agents := make(map[string]*Agent, len(ids))
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		id := id
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			body, err := c.getAgent(ctx, id, nil)
+			var agent *Agent
+			if err == nil {
+				agent, err = decodeAgent(body)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[id] = err
+			} else {
+				agents[id] = agent
+			}
+		}()
+	}
+	wg.Wait()
+	return agents, errs
+}
@@ -0,0 +1,120 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAgentsByIDs_BatchEndpoint(t *testing.T) {
+	var singles atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/agents/batch" {
+			singles.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"agent-1", "agent-2", "agent-3", "agent-4"}, req.IDs)
+		w.Write([]byte(`{
+			"agents": [{"id": "agent-1", "name": "Recipe Agent"}, {"id": "agent-2", "name": "Weather Agent"}],
+			"errors": {"agent-3": {"error": {"detail": "Private agent"}, "status": 403}}
+		}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	agents, errs, err := client.GetAgentsByIDs(context.Background(), []string{"agent-1", "agent-2", "agent-1", "agent-3", "agent-4"}, BatchOptions{})
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+	assert.Equal(t, "Recipe Agent", agents["agent-1"].Name)
+	assert.Equal(t, "Weather Agent", agents["agent-2"].Name)
+
+	require.Len(t, errs, 2)
+	assert.Equal(t, CodeAccessDenied, ErrorCode(errs["agent-3"]))
+	assert.Equal(t, http.StatusForbidden, StatusCode(errs["agent-3"]))
+	var notFound *NotFoundError
+	require.ErrorAs(t, errs["agent-4"], &notFound)
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(errs["agent-4"]))
+	assert.Zero(t, singles.Load())
+}
+
+func TestGetAgentsByIDs_FallbackOn404(t *testing.T) {
+	var batchCalls, inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/agents/batch" {
+			batchCalls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		id := strings.TrimPrefix(r.URL.Path, "/agents/")
+		switch {
+		case strings.HasPrefix(id, "missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case id == "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			fmt.Fprintf(w, `{"id": %q, "name": "Agent %s"}`, id, id)
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	ids := []string{"missing-1", "broken"}
+	for i := 0; i < 20; i++ {
+		ids = append(ids, fmt.Sprintf("agent-%d", i))
+	}
+	agents, errs, err := client.GetAgentsByIDs(context.Background(), ids, BatchOptions{Concurrency: 3})
+	require.NoError(t, err)
+	assert.Len(t, agents, 20)
+	assert.Equal(t, "Agent agent-7", agents["agent-7"].Name)
+	require.Len(t, errs, 2)
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(errs["missing-1"]))
+	assert.ErrorIs(t, errs["broken"], ErrServer)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+
+	// The missing endpoint is remembered.
+	agents, errs, err = client.GetAgentsByIDs(context.Background(), []string{"agent-1"}, BatchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, agents, 1)
+	assert.Empty(t, errs)
+	assert.Equal(t, int32(1), batchCalls.Load())
+}
+
+func TestGetAgentsByIDs_BatchFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	_, _, err := client.GetAgentsByIDs(context.Background(), []string{"agent-1"}, BatchOptions{})
+	assert.ErrorIs(t, err, ErrServer)
+
+	agents, errs, err := client.GetAgentsByIDs(context.Background(), nil, BatchOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, agents)
+	assert.Empty(t, errs)
+}
@@ -0,0 +1,160 @@
+package a2areg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerRecorder records the auth headers of the last agent request and
+// counts calls to the OAuth token endpoint.
+type headerRecorder struct {
+	*httptest.Server
+	tokenCalls atomic.Int32
+
+	mu     sync.Mutex
+	header http.Header
+}
+
+func newHeaderRecorder(t *testing.T) *headerRecorder {
+	r := &headerRecorder{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/auth/oauth/token" {
+			r.tokenCalls.Add(1)
+			w.Write([]byte(`{"access_token":"oauth-token","expires_in":3600}`))
+			return
+		}
+		r.mu.Lock()
+		r.header = req.Header.Clone()
+		r.mu.Unlock()
+		w.Write([]byte(`{"id":"agent-1","name":"Recipe Agent"}`))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *headerRecorder) last() http.Header {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.header
+}
+
+func TestAPIKeyHeader_DefaultXAPIKey(t *testing.T) {
+	server := newHeaderRecorder(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "secret-key"})
+
+	_, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", server.last().Get("X-API-Key"))
+	assert.Empty(t, server.last().Get("Authorization"))
+}
+
+func TestAPIKeyHeader_Authorization(t *testing.T) {
+	server := newHeaderRecorder(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "secret-key", APIKeyHeader: "Authorization"})
+
+	_, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-key", server.last().Get("Authorization"))
+	assert.Empty(t, server.last().Get("X-API-Key"))
+}
+
+func TestAPIKeyHeader_CustomHeaderAndRuntimeSwitch(t *testing.T) {
+	server := newHeaderRecorder(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "secret-key", APIKeyHeader: "X-Gateway-Key"})
+
+	_, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", server.last().Get("X-Gateway-Key"))
+
+	client.SetAPIKeyHeader("authorization")
+	_, err = client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-key", server.last().Get("Authorization"))
+	assert.Empty(t, server.last().Get("X-Gateway-Key"))
+
+	client.SetAPIKeyHeader("")
+	_, err = client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", server.last().Get("X-API-Key"))
+	assert.Empty(t, server.last().Get("Authorization"))
+}
+
+func TestAPIKeyHeader_SwitchDuringRequests(t *testing.T) {
+	server := newHeaderRecorder(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "secret-key"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_, err := client.GetAgent("agent-1")
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		client.SetAPIKeyHeader([]string{"Authorization", "X-Gateway-Key", ""}[i%3])
+	}
+	wg.Wait()
+
+	client.SetAPIKeyHeader("Authorization")
+	_, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-key", server.last().Get("Authorization"))
+}
+
+func TestAPIKeyHeader_WithOAuthCredentials(t *testing.T) {
+	for _, header := range []string{"X-API-Key", "Authorization"} {
+		t.Run(header, func(t *testing.T) {
+			server := newHeaderRecorder(t)
+			client := NewA2ARegClient(A2ARegClientOptions{
+				RegistryURL:  server.URL,
+				APIKey:       "secret-key",
+				APIKeyHeader: header,
+				ClientID:     "id",
+				ClientSecret: "secret",
+			})
+
+			// The API key takes precedence; no token is fetched or sent.
+			_, err := client.GetAgent("agent-1")
+			require.NoError(t, err)
+			assert.Zero(t, server.tokenCalls.Load())
+			if header == "Authorization" {
+				assert.Equal(t, "Bearer secret-key", server.last().Get("Authorization"))
+			} else {
+				assert.Equal(t, "secret-key", server.last().Get("X-API-Key"))
+				assert.Empty(t, server.last().Get("Authorization"))
+			}
+		})
+	}
+
+	t.Run("OAuthOnly", func(t *testing.T) {
+		server := newHeaderRecorder(t)
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, ClientID: "id", ClientSecret: "secret"})
+
+		_, err := client.GetAgent("agent-1")
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), server.tokenCalls.Load())
+		assert.Equal(t, "Bearer oauth-token", server.last().Get("Authorization"))
+		assert.Empty(t, server.last().Get("X-API-Key"))
+	})
+}
+
+func TestAPIKeyHeader_ContextOverride(t *testing.T) {
+	server := newHeaderRecorder(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "client-key"})
+
+	_, err := client.GetAgentFields(WithContextAPIKey(context.Background(), "tenant-key"), "agent-1", []string{"name"})
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-key", server.last().Get("X-API-Key"))
+	assert.Empty(t, server.last().Get("Authorization"))
+}
@@ -0,0 +1,110 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AttestationOptions configures AgentTeeDetails.VerifyAttestation.
+type AttestationOptions struct {
+	// JWKSUrl is where the attestation service publishes the keys it signs
+	// attestation tokens with. A URL named by the token itself is not
+	// trusted. Required unless KeySet is set.
+	JWKSUrl string
+	// KeySet, when set, holds the keys to verify with, and JWKSUrl is not
+	// fetched.
+	KeySet *JSONWebKeySet
+	// Issuer, when set, must equal the token's "iss" claim.
+	Issuer string
+	// JWKSCache caches the fetched JWKS, and its clock is used for the
+	// token's "exp" and "nbf" claims. Defaults to the cache
+	// VerifySignature uses.
+	JWKSCache *JWKSCache
+	// HTTPClient fetches the JWKS. Defaults to JWKSCache's client.
+	HTTPClient *http.Client
+}
+
+// VerifyAttestation checks the TEE attestation, taken to be a JWT signed
+// by the attestation service with a key from opts.JWKSUrl, and returns its
+// claims. Expired and not yet valid tokens are rejected. Any failure is a
+// *SignatureVerificationError.
+func (t *AgentTeeDetails) VerifyAttestation(ctx context.Context, opts AttestationOptions) (map[string]interface{}, error) {
+	if t == nil || !t.Enabled {
+		return nil, NewSignatureVerificationError("Agent does not run in a TEE", nil)
+	}
+	token := getStringValue(t.Attestation, "")
+	if token == "" {
+		return nil, NewSignatureVerificationError("TEE attestation is missing", nil)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, NewSignatureVerificationError("TEE attestation is not a compact JWS", nil)
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, NewSignatureVerificationError("TEE attestation header is not base64url", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, NewSignatureVerificationError("TEE attestation header is not JSON", err)
+	}
+	alg, ok := jwsAlgorithms[header.Alg]
+	if !ok {
+		return nil, NewSignatureVerificationError(fmt.Sprintf("Unsupported attestation algorithm %q", header.Alg), nil)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, NewSignatureVerificationError("TEE attestation signature is not base64url", err)
+	}
+
+	if opts.JWKSUrl == "" && opts.KeySet == nil {
+		return nil, NewSignatureVerificationError("No attestation JWKS URL or key set was given", nil)
+	}
+	cache := opts.JWKSCache
+	if cache == nil {
+		cache = defaultJWKSCache
+	}
+	keys, err := signingKeys(ctx, VerifyOptions{KeySet: opts.KeySet, JWKSCache: cache, HTTPClient: opts.HTTPClient}, opts.JWKSUrl, header.Kid, alg)
+	if err != nil {
+		return nil, err
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if verifyJWS(key, alg, signingInput, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, NewSignatureVerificationError("TEE attestation signature is invalid", nil)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, NewSignatureVerificationError("TEE attestation payload is not base64url", err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, NewSignatureVerificationError("TEE attestation payload is not a JSON object", err)
+	}
+	now := cache.opts.Clock.Now()
+	if exp, ok := claims["exp"].(float64); ok && !now.Before(time.Unix(int64(exp), 0)) {
+		return nil, NewSignatureVerificationError("TEE attestation has expired", nil)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return nil, NewSignatureVerificationError("TEE attestation is not valid yet", nil)
+	}
+	if opts.Issuer != "" && claims["iss"] != opts.Issuer {
+		return nil, NewSignatureVerificationError(fmt.Sprintf("TEE attestation is issued by %v, expected %q", claims["iss"], opts.Issuer), nil)
+	}
+	return claims, nil
+}
@@ -0,0 +1,77 @@
+package a2areg
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signAttestation returns claims as an ES256 JWT signed by key.
+func signAttestation(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) *string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": "ES256", "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	input := b64(header) + "." + b64(payload)
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+	require.NoError(t, err)
+	token := input + "." + b64(append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...))
+	return &token
+}
+
+func TestVerifyAttestation(t *testing.T) {
+	teeKey, teePublic := ecJWK(t, "tee-1")
+	cardKey, cardPublic := rsaJWK(t, "card-1")
+	server, fetches := jwksServer(t, &JSONWebKeySet{Keys: []JSONWebKey{teePublic, cardPublic}})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewJWKSCache(JWKSCacheOptions{Clock: clock.NewFake(now)})
+	ctx := context.Background()
+	opts := AttestationOptions{JWKSUrl: server.URL, Issuer: "https://attest.example.com", JWKSCache: cache}
+
+	tee := &AgentTeeDetails{Enabled: true, Attestation: signAttestation(t, teeKey, "tee-1", map[string]interface{}{
+		"iss": "https://attest.example.com", "exp": now.Add(time.Hour).Unix(), "x-tee-type": "sevsnp",
+	})}
+	claims, err := tee.VerifyAttestation(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "sevsnp", claims["x-tee-type"])
+
+	// Card signatures are verified against the same cache.
+	card := testSignedCard()
+	signCard(t, card, "RS256", "card-1", server.URL, cardKey)
+	require.NoError(t, card.VerifySignature(ctx, VerifyOptions{JWKSCache: cache}))
+	assert.Equal(t, int32(1), fetches.Load())
+
+	expired := &AgentTeeDetails{Enabled: true, Attestation: signAttestation(t, teeKey, "tee-1", map[string]interface{}{
+		"iss": "https://attest.example.com", "exp": now.Add(-time.Minute).Unix(),
+	})}
+	_, err = expired.VerifyAttestation(ctx, opts)
+	assert.EqualError(t, err, "TEE attestation has expired")
+
+	otherIssuer := &AgentTeeDetails{Enabled: true, Attestation: signAttestation(t, teeKey, "tee-1", map[string]interface{}{"iss": "https://evil.example.com"})}
+	_, err = otherIssuer.VerifyAttestation(ctx, opts)
+	assert.Contains(t, err.Error(), "expected \"https://attest.example.com\"")
+
+	forged, _ := ecJWK(t, "tee-1")
+	tampered := &AgentTeeDetails{Enabled: true, Attestation: signAttestation(t, forged, "tee-1", map[string]interface{}{"iss": "https://attest.example.com"})}
+	_, err = tampered.VerifyAttestation(ctx, opts)
+	var sigErr *SignatureVerificationError
+	require.ErrorAs(t, err, &sigErr)
+	assert.Equal(t, CodeSignatureInvalid, ErrorCode(err))
+
+	_, err = (&AgentTeeDetails{Enabled: true}).VerifyAttestation(ctx, opts)
+	assert.EqualError(t, err, "TEE attestation is missing")
+	_, err = tee.VerifyAttestation(ctx, AttestationOptions{})
+	assert.Error(t, err, "no JWKS URL and no key set")
+}
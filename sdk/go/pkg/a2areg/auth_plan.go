@@ -0,0 +1,236 @@
+package a2areg
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultAuthSchemePreference is the order in which PlanAuthentication
+// considers scheme types when AvailableCredentials.Preference is empty:
+// strongest first.
+var DefaultAuthSchemePreference = []AuthSchemeType{
+	AuthSchemeMTLS,
+	AuthSchemeOAuth2,
+	AuthSchemeJWT,
+	AuthSchemeBearer,
+	AuthSchemeAPIKey,
+}
+
+// AvailableCredentials declares the credentials a caller holds, for
+// PlanAuthentication.
+type AvailableCredentials struct {
+	// APIKeys maps the parameter an API key scheme names (a header or
+	// query parameter such as "X-API-Key") to the key to send in it.
+	// Header names match case-insensitively.
+	APIKeys map[string]string
+	// OAuth2 holds OAuth2 client credentials.
+	OAuth2 *OAuth2Credentials
+	// ClientCertificate is the certificate for mTLS schemes.
+	ClientCertificate *tls.Certificate
+	// JWT is a token for JWT schemes.
+	JWT *JWTCredential
+	// BearerToken is a token for bearer schemes.
+	BearerToken string
+	// Preference orders the scheme types to consider, most preferred
+	// first. Types left out are never chosen. Empty means
+	// DefaultAuthSchemePreference.
+	Preference []AuthSchemeType
+}
+
+// OAuth2Credentials declares OAuth2 client credentials and the scopes the
+// client may request. Planning needs no secret, so none is held here.
+type OAuth2Credentials struct {
+	ClientID string
+	Scopes   []string
+}
+
+// JWTCredential is a signed token and the issuer that signed it.
+type JWTCredential struct {
+	Issuer string
+	Token  string
+}
+
+// AuthPlan is how to authenticate to an agent: the scheme chosen and where
+// its credential goes.
+type AuthPlan struct {
+	// SchemeName is the card's name for the scheme. It is empty, as is
+	// the rest of the plan, when the card requires no authentication.
+	SchemeName string
+	Scheme     SecurityScheme
+	// Location and Parameter say where the credential is sent, such as
+	// the "Authorization" header. Both are empty for mTLS, whose
+	// credential is the TLS client certificate.
+	Location  Location
+	Parameter string
+	// Value is the credential to send: the API key, or "Bearer <token>"
+	// for bearer and JWT schemes. It is empty for OAuth2, whose access
+	// token must first be obtained from TokenURL with Scopes, and for
+	// mTLS.
+	Value    string
+	TokenURL string
+	// Scopes are the OAuth2 scopes to request: those the scheme lists.
+	Scopes []string
+}
+
+// PlanAuthentication chooses the security scheme of card that available
+// can satisfy, taking scheme types in preference order and, among schemes
+// of one type, in name order. A scheme is satisfied when:
+//
+//   - apiKey: APIKeys holds a key for the parameter the scheme names;
+//   - oauth2: OAuth2 is set, the flow is client_credentials or unset, and
+//     OAuth2.Scopes includes every scope the scheme lists;
+//   - mTLS: ClientCertificate is set;
+//   - jwt: JWT is set;
+//   - bearer: BearerToken is set.
+//
+// When no scheme is satisfied the error is a *NoViableAuthError saying
+// what each scheme lacks.
+func PlanAuthentication(card *AgentCardSpec, available AvailableCredentials) (*AuthPlan, error) {
+	if card == nil {
+		return nil, NewValidationError("Agent card is required", nil)
+	}
+	if len(card.SecuritySchemes) == 0 {
+		return &AuthPlan{}, nil
+	}
+	preference := available.Preference
+	if len(preference) == 0 {
+		preference = DefaultAuthSchemePreference
+	}
+	rank := make(map[AuthSchemeType]int, len(preference))
+	for i, schemeType := range preference {
+		if _, seen := rank[schemeType]; !seen {
+			rank[schemeType] = i
+		}
+	}
+
+	names := make([]string, 0, len(card.SecuritySchemes))
+	for name := range card.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, iRanked := rank[card.SecuritySchemes[names[i]].Type]
+		rj, jRanked := rank[card.SecuritySchemes[names[j]].Type]
+		if iRanked != jRanked {
+			return iRanked
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	missing := make(map[string][]string, len(names))
+	for _, name := range names {
+		scheme := card.SecuritySchemes[name]
+		if _, ranked := rank[scheme.Type]; !ranked {
+			missing[name] = []string{fmt.Sprintf("scheme type %q is not in the preference list", scheme.Type)}
+			continue
+		}
+		plan, lacks := planScheme(scheme, available)
+		if len(lacks) > 0 {
+			missing[name] = lacks
+			continue
+		}
+		plan.SchemeName, plan.Scheme = name, scheme
+		return plan, nil
+	}
+	return nil, NewNoViableAuthError(missing)
+}
+
+// planScheme returns the plan for scheme, or what available lacks to
+// satisfy it.
+func planScheme(scheme SecurityScheme, available AvailableCredentials) (*AuthPlan, []string) {
+	switch scheme.Type {
+	case AuthSchemeAPIKey:
+		if scheme.Name == nil || *scheme.Name == "" {
+			return nil, []string{"scheme does not name the API key parameter"}
+		}
+		location := LocationHeader
+		if scheme.Location != nil {
+			location = *scheme.Location
+		}
+		key, ok := lookupAPIKey(available.APIKeys, *scheme.Name, location)
+		if !ok {
+			return nil, []string{fmt.Sprintf("API key for %s %q", location, *scheme.Name)}
+		}
+		return &AuthPlan{Location: location, Parameter: *scheme.Name, Value: key}, nil
+
+	case AuthSchemeOAuth2:
+		if scheme.Flow != nil && *scheme.Flow != "" && *scheme.Flow != "client_credentials" {
+			return nil, []string{fmt.Sprintf("OAuth2 flow %q is not supported; only client_credentials is", *scheme.Flow)}
+		}
+		if available.OAuth2 == nil {
+			return nil, []string{"OAuth2 client credentials"}
+		}
+		var lacks []string
+		held := make(map[string]bool, len(available.OAuth2.Scopes))
+		for _, scope := range available.OAuth2.Scopes {
+			held[scope] = true
+		}
+		for _, scope := range scheme.Scopes {
+			if !held[scope] {
+				lacks = append(lacks, fmt.Sprintf("OAuth2 scope %q", scope))
+			}
+		}
+		if len(lacks) > 0 {
+			return nil, lacks
+		}
+		plan := &AuthPlan{Location: LocationHeader, Parameter: "Authorization", Scopes: scheme.Scopes}
+		if scheme.TokenURL != nil {
+			plan.TokenURL = *scheme.TokenURL
+		}
+		return plan, nil
+
+	case AuthSchemeMTLS:
+		if available.ClientCertificate == nil {
+			return nil, []string{"mTLS client certificate"}
+		}
+		return &AuthPlan{}, nil
+
+	case AuthSchemeJWT:
+		if available.JWT == nil || available.JWT.Token == "" {
+			return nil, []string{"JWT"}
+		}
+		return &AuthPlan{Location: LocationHeader, Parameter: "Authorization", Value: "Bearer " + available.JWT.Token}, nil
+
+	case AuthSchemeBearer:
+		if available.BearerToken == "" {
+			return nil, []string{"bearer token"}
+		}
+		return &AuthPlan{Location: LocationHeader, Parameter: "Authorization", Value: "Bearer " + available.BearerToken}, nil
+	}
+	return nil, []string{fmt.Sprintf("unknown scheme type %q", scheme.Type)}
+}
+
+// lookupAPIKey finds the key for parameter, ignoring case for headers.
+func lookupAPIKey(keys map[string]string, parameter string, location Location) (string, bool) {
+	if key, ok := keys[parameter]; ok && key != "" {
+		return key, true
+	}
+	if location != LocationHeader {
+		return "", false
+	}
+	for name, key := range keys {
+		if key != "" && http.CanonicalHeaderKey(name) == http.CanonicalHeaderKey(parameter) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// missingSummary renders NoViableAuthError's Missing for its message.
+func missingSummary(missing map[string][]string) string {
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + strings.Join(missing[name], ", ")
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,123 @@
+package a2areg
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multiSchemeCard advertises an API key in a header, an API key in the
+// query, OAuth2 client credentials with two scopes and mTLS.
+func multiSchemeCard() *AgentCardSpec {
+	query := LocationQuery
+	queryKey := "api_key"
+	return &AgentCardSpec{
+		Name: "Multi",
+		SecuritySchemes: SecuritySchemes{
+			"headerKey": NewAPIKeyScheme("X-Agent-Key"),
+			"queryKey":  {Type: AuthSchemeAPIKey, Location: &query, Name: &queryKey},
+			"oauth":     NewOAuth2Scheme("https://auth.example.com/token", "agents:read", "agents:invoke"),
+			"mtls":      {Type: AuthSchemeMTLS},
+		},
+	}
+}
+
+func TestPlanAuthentication_PrefersStrongestSatisfiedScheme(t *testing.T) {
+	card := multiSchemeCard()
+
+	plan, err := PlanAuthentication(card, AvailableCredentials{
+		APIKeys: map[string]string{"x-agent-key": "k-123"},
+		OAuth2:  &OAuth2Credentials{ClientID: "svc", Scopes: []string{"agents:invoke", "agents:read", "admin"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "oauth", plan.SchemeName)
+	assert.Equal(t, LocationHeader, plan.Location)
+	assert.Equal(t, "Authorization", plan.Parameter)
+	assert.Equal(t, "https://auth.example.com/token", plan.TokenURL)
+	assert.Equal(t, []string{"agents:read", "agents:invoke"}, plan.Scopes)
+	assert.Empty(t, plan.Value)
+
+	plan, err = PlanAuthentication(card, AvailableCredentials{
+		APIKeys:           map[string]string{"x-agent-key": "k-123"},
+		ClientCertificate: &tls.Certificate{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "mtls", plan.SchemeName)
+	assert.Empty(t, plan.Parameter)
+}
+
+func TestPlanAuthentication_PartialCredentials(t *testing.T) {
+	card := multiSchemeCard()
+
+	// Missing a scope rules OAuth2 out; the header key matches whatever
+	// the case, and sorts before the query key.
+	plan, err := PlanAuthentication(card, AvailableCredentials{
+		APIKeys: map[string]string{"x-agent-key": "k-123", "api_key": "q-456"},
+		OAuth2:  &OAuth2Credentials{ClientID: "svc", Scopes: []string{"agents:read"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "headerKey", plan.SchemeName)
+	assert.Equal(t, LocationHeader, plan.Location)
+	assert.Equal(t, "X-Agent-Key", plan.Parameter)
+	assert.Equal(t, "k-123", plan.Value)
+
+	// Query parameters match exactly.
+	_, err = PlanAuthentication(card, AvailableCredentials{APIKeys: map[string]string{"API_KEY": "q-456"}})
+	require.Error(t, err)
+	plan, err = PlanAuthentication(card, AvailableCredentials{APIKeys: map[string]string{"api_key": "q-456"}})
+	require.NoError(t, err)
+	assert.Equal(t, "queryKey", plan.SchemeName)
+	assert.Equal(t, LocationQuery, plan.Location)
+}
+
+func TestPlanAuthentication_Preference(t *testing.T) {
+	card := multiSchemeCard()
+	available := AvailableCredentials{
+		APIKeys:           map[string]string{"X-Agent-Key": "k-123"},
+		ClientCertificate: &tls.Certificate{},
+		Preference:        []AuthSchemeType{AuthSchemeAPIKey, AuthSchemeMTLS},
+	}
+	plan, err := PlanAuthentication(card, available)
+	require.NoError(t, err)
+	assert.Equal(t, "headerKey", plan.SchemeName)
+
+	available.Preference = []AuthSchemeType{AuthSchemeOAuth2}
+	_, err = PlanAuthentication(card, available)
+	var noAuth *NoViableAuthError
+	require.ErrorAs(t, err, &noAuth, "types left out of the preference are never chosen")
+	assert.Contains(t, noAuth.Missing["mtls"][0], "not in the preference list")
+}
+
+func TestPlanAuthentication_NoViableScheme(t *testing.T) {
+	card := multiSchemeCard()
+	jwt := SecurityScheme{Type: AuthSchemeJWT}
+	card.SecuritySchemes["jwt"] = jwt
+
+	_, err := PlanAuthentication(card, AvailableCredentials{
+		OAuth2:      &OAuth2Credentials{ClientID: "svc"},
+		BearerToken: "unused",
+	})
+	var noAuth *NoViableAuthError
+	require.ErrorAs(t, err, &noAuth)
+	assert.Equal(t, CodeNoViableAuth, ErrorCode(err))
+	assert.Equal(t, map[string][]string{
+		"headerKey": {`API key for header "X-Agent-Key"`},
+		"queryKey":  {`API key for query "api_key"`},
+		"oauth":     {`OAuth2 scope "agents:read"`, `OAuth2 scope "agents:invoke"`},
+		"mtls":      {"mTLS client certificate"},
+		"jwt":       {"JWT"},
+	}, noAuth.Missing)
+	assert.Contains(t, err.Error(), `oauth: OAuth2 scope "agents:read", OAuth2 scope "agents:invoke"`)
+
+	plan, err := PlanAuthentication(card, AvailableCredentials{JWT: &JWTCredential{Issuer: "https://idp.example.com", Token: "eyJ"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer eyJ", plan.Value)
+}
+
+func TestPlanAuthentication_NoSchemes(t *testing.T) {
+	plan, err := PlanAuthentication(&AgentCardSpec{Name: "Open"}, AvailableCredentials{})
+	require.NoError(t, err)
+	assert.Equal(t, &AuthPlan{}, plan)
+}
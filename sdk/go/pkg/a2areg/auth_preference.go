@@ -0,0 +1,131 @@
+package a2areg
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// AuthPreference decides which credential a client configured with both an
+// API key and OAuth client credentials uses.
+type AuthPreference int
+
+const (
+	// AuthAPIKeyFirst uses the API key and ignores the OAuth credentials.
+	// It is the default.
+	AuthAPIKeyFirst AuthPreference = iota
+	// AuthOAuthFirst uses OAuth, and switches to the API key for good once
+	// the registry answers 401 to the OAuth token or the token endpoint
+	// rejects the client credentials.
+	AuthOAuthFirst
+	// AuthExclusive rejects configuring both; see
+	// A2ARegClientOptions.Validate.
+	AuthExclusive
+)
+
+func (p AuthPreference) String() string {
+	switch p {
+	case AuthAPIKeyFirst:
+		return "api_key_first"
+	case AuthOAuthFirst:
+		return "oauth_first"
+	case AuthExclusive:
+		return "exclusive"
+	}
+	return "unknown"
+}
+
+// AuthMode is the credential a client authenticates with.
+type AuthMode string
+
+const (
+	AuthModeNone   AuthMode = "none"
+	AuthModeAPIKey AuthMode = "api_key"
+	AuthModeOAuth  AuthMode = "oauth"
+)
+
+// Validate reports configuration errors that NewA2ARegClient cannot
+// return. Under AuthExclusive, setting both APIKey and ClientID or
+// ClientSecret is an *AuthenticationError with CodeAuthConflict. Setting
+// TLSConfig, PinnedCertificates, HostOverrides or Resolver together with a
+// transport that is not an *http.Transport, which the client cannot apply
+// them to, is a *ValidationError with CodeInvalidRequest. A client built
+// from such options fails every call with that error, so call Validate at
+// startup to catch it before the first request.
+func (opts A2ARegClientOptions) Validate() error {
+	if opts.AuthPreference == AuthExclusive && opts.APIKey != "" && (opts.ClientID != "" || opts.ClientSecret != "") {
+		return withCode(NewAuthenticationError(
+			"Both an API key and OAuth client credentials are configured, but AuthPreference is AuthExclusive; configure only one",
+			nil,
+		), CodeAuthConflict)
+	}
+	return validateTransport(opts)
+}
+
+// AuthMode returns the credential the client currently authenticates with.
+// Under AuthOAuthFirst it changes from AuthModeOAuth to AuthModeAPIKey when
+// the client falls back.
+func (c *A2ARegClient) AuthMode() AuthMode {
+	switch {
+	case c.configErr != nil:
+		return AuthModeNone
+	case c.usingAPIKey():
+		return AuthModeAPIKey
+	case c.hasOAuthCredentials():
+		return AuthModeOAuth
+	}
+	return AuthModeNone
+}
+
+// hasOAuthCredentials reports whether the client has a client ID and
+// secret.
+func (c *A2ARegClient) hasOAuthCredentials() bool {
+	return c.clientID != "" && !c.clientSecret.Empty()
+}
+
+// usingAPIKey reports whether requests carry the API key rather than an
+// OAuth token.
+func (c *A2ARegClient) usingAPIKey() bool {
+	if c.apiKey.Empty() {
+		return false
+	}
+	if c.authPreference == AuthOAuthFirst {
+		return !c.hasOAuthCredentials() || c.authFallback.Load()
+	}
+	return true
+}
+
+// fallBackToAPIKey switches an AuthOAuthFirst client to its API key after
+// OAuth was rejected, logging the switch once. It reports whether the
+// client now uses the API key.
+func (c *A2ARegClient) fallBackToAPIKey(reason string) bool {
+	if c.authPreference != AuthOAuthFirst || c.apiKey.Empty() {
+		return false
+	}
+	if c.authFallback.CompareAndSwap(false, true) {
+		c.logger.Warn("a2areg: OAuth rejected, falling back to the API key", "reason", reason)
+	}
+	return true
+}
+
+// oauthRejected reports whether err means the token endpoint refused the
+// client credentials.
+func oauthRejected(err error) bool {
+	return ErrorCode(err) == CodeAuthInvalidClient
+}
+
+// replayWithAPIKey resends req, which carried an OAuth token, with the API
+// key instead.
+func (c *A2ARegClient) replayWithAPIKey(ctx context.Context, req *http.Request) (*http.Response, error) {
+	retry, err := replayRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	retry.Header.Del("Authorization")
+	c.useAPIKey(retry.Header)
+	return c.do(retry)
+}
+
+// discardLogger is the logger of clients configured without one.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
@@ -0,0 +1,175 @@
+package a2areg
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dualAuthRegistry accepts the API key "api-key" and, unless rejectTokens
+// is set, any bearer token. It records the credential of each request.
+type dualAuthRegistry struct {
+	*httptest.Server
+	rejectTokens atomic.Bool
+	rejectClient atomic.Bool
+	tokenCalls   atomic.Int32
+
+	mu   sync.Mutex
+	seen []string
+}
+
+func newDualAuthRegistry(t *testing.T) *dualAuthRegistry {
+	r := &dualAuthRegistry{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/auth/oauth/token" {
+			r.tokenCalls.Add(1)
+			if r.rejectClient.Load() {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token": "oauth-token", "expires_in": 3600}`))
+			return
+		}
+		credential := "none"
+		switch {
+		case req.Header.Get("X-API-Key") != "":
+			credential = "key:" + req.Header.Get("X-API-Key")
+		case req.Header.Get("Authorization") != "":
+			credential = req.Header.Get("Authorization")
+		}
+		r.mu.Lock()
+		r.seen = append(r.seen, credential)
+		r.mu.Unlock()
+		if credential == "none" || (r.rejectTokens.Load() && credential == "Bearer oauth-token") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *dualAuthRegistry) credentials() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.seen...)
+}
+
+func (r *dualAuthRegistry) options(preference AuthPreference) A2ARegClientOptions {
+	return A2ARegClientOptions{
+		RegistryURL:    r.URL,
+		APIKey:         "api-key",
+		ClientID:       "id",
+		ClientSecret:   "secret",
+		AuthPreference: preference,
+	}
+}
+
+func TestAuthPreference_APIKeyFirst(t *testing.T) {
+	registry := newDualAuthRegistry(t)
+	client := NewA2ARegClient(registry.options(AuthAPIKeyFirst))
+	assert.Equal(t, AuthModeAPIKey, client.AuthMode())
+
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key:api-key"}, registry.credentials())
+	assert.Zero(t, registry.tokenCalls.Load())
+
+	oauthOnly := registry.options(AuthAPIKeyFirst)
+	oauthOnly.APIKey = ""
+	assert.Equal(t, AuthModeOAuth, NewA2ARegClient(oauthOnly).AuthMode())
+	assert.Equal(t, AuthModeNone, NewA2ARegClient(A2ARegClientOptions{}).AuthMode())
+}
+
+func TestAuthPreference_OAuthFirstFallsBackOn401(t *testing.T) {
+	registry := newDualAuthRegistry(t)
+	var logs bytes.Buffer
+	opts := registry.options(AuthOAuthFirst)
+	opts.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	client := NewA2ARegClient(opts)
+	assert.Equal(t, AuthModeOAuth, client.AuthMode())
+
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer oauth-token"}, registry.credentials())
+	assert.Empty(t, logs.String())
+
+	// The registry starts rejecting the token: after a refresh fails too,
+	// the request is replayed with the API key and the client stays on it.
+	registry.rejectTokens.Store(true)
+	_, err = client.PublishAgent(testPublishAgent(), false)
+	require.NoError(t, err)
+	assert.Equal(t, AuthModeAPIKey, client.AuthMode())
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer oauth-token", "Bearer oauth-token", "Bearer oauth-token", "key:api-key", "key:api-key"}, registry.credentials())
+	assert.Equal(t, int32(2), registry.tokenCalls.Load())
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("falling back to the API key")))
+	assert.Contains(t, logs.String(), "registry rejected the OAuth token")
+}
+
+func TestAuthPreference_OAuthFirstFallsBackOnRejectedClient(t *testing.T) {
+	registry := newDualAuthRegistry(t)
+	registry.rejectClient.Store(true)
+	var logs bytes.Buffer
+	opts := registry.options(AuthOAuthFirst)
+	opts.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	client := NewA2ARegClient(opts)
+
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key:api-key"}, registry.credentials())
+	assert.Equal(t, AuthModeAPIKey, client.AuthMode())
+	assert.Contains(t, logs.String(), "token endpoint rejected the client credentials")
+}
+
+func TestAuthPreference_OAuthFirstWithoutAPIKey(t *testing.T) {
+	registry := newDualAuthRegistry(t)
+	registry.rejectTokens.Store(true)
+	opts := registry.options(AuthOAuthFirst)
+	opts.APIKey = ""
+	client := NewA2ARegClient(opts)
+
+	_, err := client.Health(context.Background())
+	assert.Equal(t, CodeAuthRequired, ErrorCode(err))
+	assert.Equal(t, AuthModeOAuth, client.AuthMode())
+}
+
+func TestAuthPreference_Exclusive(t *testing.T) {
+	registry := newDualAuthRegistry(t)
+	opts := registry.options(AuthExclusive)
+
+	err := opts.Validate()
+	var authErr *AuthenticationError
+	require.ErrorAs(t, err, &authErr)
+	assert.Equal(t, CodeAuthConflict, ErrorCode(err))
+	assert.Contains(t, err.Error(), "configure only one")
+
+	client := NewA2ARegClient(opts)
+	assert.Equal(t, AuthModeNone, client.AuthMode())
+	_, err = client.Health(context.Background())
+	assert.Equal(t, CodeAuthConflict, ErrorCode(err))
+	assert.Equal(t, CodeAuthConflict, ErrorCode(client.Authenticate()))
+	_, err = client.Search(context.Background(), SearchRequest{Query: "recipes"})
+	assert.Equal(t, CodeAuthConflict, ErrorCode(err))
+	assert.Empty(t, registry.credentials())
+
+	_, _, err = NewClientPool().GetOrCreate(opts)
+	assert.Equal(t, CodeAuthConflict, ErrorCode(err))
+
+	opts.APIKey = ""
+	require.NoError(t, opts.Validate())
+	client = NewA2ARegClient(opts)
+	assert.Equal(t, AuthModeOAuth, client.AuthMode())
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+}
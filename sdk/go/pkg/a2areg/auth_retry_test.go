@@ -0,0 +1,104 @@
+package a2areg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// revokingRegistry issues token-1, token-2, ... and rejects every token up to
+// and including revokedThrough with a 401. It records the body of each
+// non-token request.
+type revokingRegistry struct {
+	*httptest.Server
+	tokenCalls     atomic.Int32
+	requests       atomic.Int32
+	revokedThrough int32
+	bodies         []string
+}
+
+func newRevokingRegistry(t *testing.T, revokedThrough int32) *revokingRegistry {
+	r := &revokingRegistry{revokedThrough: revokedThrough}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/auth/oauth/token" {
+			n := r.tokenCalls.Add(1)
+			fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600}`, n)
+			return
+		}
+		r.requests.Add(1)
+		body, _ := io.ReadAll(req.Body)
+		r.bodies = append(r.bodies, string(body))
+
+		var n int32
+		if _, err := fmt.Sscanf(req.Header.Get("Authorization"), "Bearer token-%d", &n); err != nil || n <= r.revokedThrough {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"agentId": "", "id": "agent-1", "name": "Recipe Agent", "status": "healthy"}`))
+	}))
+	t.Cleanup(r.Server.Close)
+	return r
+}
+
+func (r *revokingRegistry) oauthClient() *A2ARegClient {
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: r.URL, ClientID: "id", ClientSecret: "secret"})
+}
+
+func TestSend_ReauthenticatesAfterRevokedToken(t *testing.T) {
+	registry := newRevokingRegistry(t, 1)
+	client := registry.oauthClient()
+
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), registry.tokenCalls.Load())
+	assert.Equal(t, int32(2), registry.requests.Load())
+
+	// The fresh token is kept for later requests.
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), registry.tokenCalls.Load())
+}
+
+func TestSend_ReauthenticationReplaysBody(t *testing.T) {
+	registry := newRevokingRegistry(t, 1)
+	client := registry.oauthClient()
+
+	_, err := client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{SkipNormalizationCheck: true})
+	require.NoError(t, err)
+	require.Len(t, registry.bodies, 2)
+	assert.NotEmpty(t, registry.bodies[0])
+	assert.Equal(t, registry.bodies[0], registry.bodies[1])
+}
+
+func TestSend_ReauthenticatesOnlyOnce(t *testing.T) {
+	registry := newRevokingRegistry(t, 1<<30)
+	client := registry.oauthClient()
+
+	_, err := client.Health(context.Background())
+	assert.IsType(t, &AuthenticationError{}, err)
+	assert.Equal(t, CodeAuthRequired, ErrorCode(err))
+	assert.Equal(t, int32(2), registry.tokenCalls.Load())
+	assert.Equal(t, int32(2), registry.requests.Load())
+}
+
+func TestSend_NoReauthenticationWithoutOAuthToken(t *testing.T) {
+	registry := newRevokingRegistry(t, 1<<30)
+
+	apiKeyClient := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test-key"})
+	_, err := apiKeyClient.Health(context.Background())
+	assert.IsType(t, &AuthenticationError{}, err)
+	assert.Equal(t, int32(1), registry.requests.Load())
+
+	// A caller-supplied token is not the client's to refresh.
+	_, err = registry.oauthClient().makeRequestContext(WithContextToken(context.Background(), "token-0"), "GET", "/health", nil, nil)
+	assert.IsType(t, &AuthenticationError{}, err)
+	assert.Equal(t, int32(2), registry.requests.Load())
+	assert.Zero(t, registry.tokenCalls.Load())
+}
@@ -0,0 +1,124 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultSearchParallelism is the default number of searches
+// SearchAgentsBatch runs at once when it has to fan out.
+const DefaultSearchParallelism = 4
+
+// batchResult is one element of a batch response: either the result
+// itself or an error with its HTTP status.
+type batchResult struct {
+	Error  json.RawMessage `json:"error"`
+	Status int             `json:"status"`
+}
+
+// batchError returns the error carried by result, or nil if it has none.
+func (c *A2ARegClient) batchError(result batchResult) error {
+	if len(result.Error) == 0 || string(result.Error) == "null" {
+		return nil
+	}
+	status := result.Status
+	if status == 0 {
+		status = 500
+	}
+	apiErr := c.responseError(status, envelopeErrorData(result.Error))
+	apiErr.setStatusCode(status)
+	return apiErr
+}
+
+// SearchAgentsBatch runs reqs and returns one SearchResponse per request,
+// in input order. It uses the registry's /agents/search/batch endpoint and,
+// when the registry lacks it, runs the searches concurrently, at most
+// MaxSearchParallelism at a time.
+//
+// A query that fails does not fail the batch: its SearchResponse has Err
+// set instead. The returned error is non-nil only when the batch as a
+// whole could not be run.
+func (c *A2ARegClient) SearchAgentsBatch(ctx context.Context, reqs []SearchRequest) ([]SearchResponse, error) {
+	if len(reqs) == 0 {
+		return []SearchResponse{}, nil
+	}
+	if !c.batchSearchUnavailable.Load() {
+		responses, err := c.nativeSearchBatch(ctx, reqs)
+		if err == nil || !isSearchUnavailable(err) {
+			return responses, err
+		}
+		c.batchSearchUnavailable.Store(true)
+	}
+	return c.fanOutSearch(ctx, reqs), nil
+}
+
+// nativeSearchBatch posts reqs to /agents/search/batch.
+func (c *A2ARegClient) nativeSearchBatch(ctx context.Context, reqs []SearchRequest) ([]SearchResponse, error) {
+	body, err := c.makeRequestContext(ctx, "POST", "/agents/search/batch", reqs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode batch search response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if len(items) != len(reqs) {
+		return nil, withCode(NewA2AError(fmt.Sprintf("Batch search returned %d results for %d queries", len(items), len(reqs)), nil), CodeDecodeFailed)
+	}
+
+	responses := make([]SearchResponse, len(reqs))
+	for i, item := range items {
+		var result batchResult
+		if err := json.Unmarshal(item, &result); err != nil {
+			responses[i].Err = withCode(NewA2AError("Failed to decode search response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+			continue
+		}
+		if err := c.batchError(result); err != nil {
+			responses[i].Err = err
+			continue
+		}
+		responses[i] = searchResult(decodeSearchResponse(item, reqs[i]))
+	}
+	return responses, nil
+}
+
+// fanOutSearch runs each request through Search concurrently.
+func (c *A2ARegClient) fanOutSearch(ctx context.Context, reqs []SearchRequest) []SearchResponse {
+	responses := make([]SearchResponse, len(reqs))
+	sem := make(chan struct{}, c.searchParallelism)
+	var wg sync.WaitGroup
+	for i := range reqs {
+		i := i
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			responses[i] = searchResult(c.Search(ctx, reqs[i]))
+		}()
+	}
+	wg.Wait()
+	return responses
+}
+
+// searchResult folds the results of a single search into one batch
+// element. A partial-results error keeps the partial response alongside
+// the error.
+func searchResult(resp *SearchResponse, err error) SearchResponse {
+	if err == nil {
+		return *resp
+	}
+	var result SearchResponse
+	var partial *PartialResultsError
+	if errors.As(err, &partial) {
+		result = *partial.Response
+	}
+	result.Err = err
+	return result
+}
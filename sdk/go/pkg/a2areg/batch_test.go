@@ -0,0 +1,160 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchAgentsBatch_Native(t *testing.T) {
+	var singles atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/agents/search/batch" {
+			singles.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var reqs []map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&reqs))
+		require.Len(t, reqs, 3)
+		w.Write([]byte(`[
+			{"agents": [{"id": "a", "name": "` + reqs[0]["query"].(string) + `"}], "total": 1},
+			{"error": {"message": "bad filter", "code": "invalid_filter"}, "status": 422},
+			{"agents": [], "total": 0, "timed_out": true}
+		]`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	responses, err := client.SearchAgentsBatch(context.Background(), []SearchRequest{
+		{Query: "recipes"}, {Query: "bad"}, {Query: "slow"},
+	})
+	require.NoError(t, err)
+	require.Len(t, responses, 3)
+
+	assert.NoError(t, responses[0].Err)
+	require.Len(t, responses[0].Agents, 1)
+	assert.Equal(t, "recipes", responses[0].Agents[0].Name)
+
+	assert.Equal(t, "invalid_filter", ErrorCode(responses[1].Err))
+	assert.Equal(t, http.StatusUnprocessableEntity, StatusCode(responses[1].Err))
+
+	var partial *PartialResultsError
+	assert.True(t, errors.As(responses[2].Err, &partial))
+	assert.True(t, responses[2].TimedOut)
+
+	assert.Zero(t, singles.Load())
+}
+
+func TestSearchAgentsBatch_NativeLengthMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"agents": []}]`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	_, err := client.SearchAgentsBatch(context.Background(), []SearchRequest{{Query: "a"}, {Query: "b"}})
+	assert.Equal(t, CodeDecodeFailed, ErrorCode(err))
+}
+
+// fanOutServer has no batch endpoint. Searches for a query starting with
+// "fail" get a 500; the rest echo the query back after a delay that makes
+// later queries finish first.
+func fanOutServer(t *testing.T, batchCalls, inflight, peak *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/agents/search/batch" {
+			batchCalls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		var req SearchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		time.Sleep(time.Duration(10-len(req.Query)) * 2 * time.Millisecond)
+		if strings.HasPrefix(req.Query, "fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"agents": []map[string]interface{}{{"id": req.Query, "name": req.Query}},
+			"total":  1,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSearchAgentsBatch_FanOut(t *testing.T) {
+	var batchCalls, inflight, peak atomic.Int32
+	server := fanOutServer(t, &batchCalls, &inflight, &peak)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", MaxSearchParallelism: 2})
+
+	queries := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff"}
+	reqs := make([]SearchRequest, len(queries))
+	for i, q := range queries {
+		reqs[i] = SearchRequest{Query: q}
+	}
+
+	responses, err := client.SearchAgentsBatch(context.Background(), reqs)
+	require.NoError(t, err)
+	require.Len(t, responses, len(queries))
+	for i, q := range queries {
+		require.NoError(t, responses[i].Err, q)
+		require.Len(t, responses[i].Agents, 1)
+		assert.Equal(t, q, responses[i].Agents[0].Name)
+	}
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+
+	// The missing batch endpoint is remembered.
+	_, err = client.SearchAgentsBatch(context.Background(), reqs[:1])
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), batchCalls.Load())
+}
+
+func TestSearchAgentsBatch_FanOutMixedResults(t *testing.T) {
+	var batchCalls, inflight, peak atomic.Int32
+	server := fanOutServer(t, &batchCalls, &inflight, &peak)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	responses, err := client.SearchAgentsBatch(context.Background(), []SearchRequest{
+		{Query: "ok1"}, {Query: "fail"}, {Query: "ok2"},
+	})
+	require.NoError(t, err)
+	require.Len(t, responses, 3)
+
+	assert.NoError(t, responses[0].Err)
+	assert.Equal(t, "ok1", responses[0].Agents[0].Name)
+	assert.Equal(t, CodeServerError, ErrorCode(responses[1].Err))
+	assert.Empty(t, responses[1].Agents)
+	assert.NoError(t, responses[2].Err)
+	assert.Equal(t, "ok2", responses[2].Agents[0].Name)
+}
+
+func TestSearchAgentsBatch_BatchFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	responses, err := client.SearchAgentsBatch(context.Background(), []SearchRequest{{Query: "a"}})
+	assert.Nil(t, responses)
+	assert.Equal(t, CodeAuthRequired, ErrorCode(err))
+}
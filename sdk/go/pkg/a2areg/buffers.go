@@ -0,0 +1,101 @@
+package a2areg
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBufferBytes bounds the buffers kept in bufferPool, so that one
+// huge card body does not stay pinned in memory.
+const maxPooledBufferBytes = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool. Its contents are zeroed first: request
+// and response bodies can carry credentials, and a pooled buffer must never
+// expose one request's bytes to another.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferBytes {
+		return
+	}
+	buf.Reset()
+	clear(buf.Bytes()[:buf.Cap()])
+	bufferPool.Put(buf)
+}
+
+// encodeBody marshals v into a pooled buffer. The result matches
+// json.Marshal. The caller owns the buffer and must release it with
+// putBuffer.
+func encodeBody(v interface{}) (*bytes.Buffer, error) {
+	buf := getBuffer()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	buf.Truncate(buf.Len() - 1) // Encode appends a newline
+	return buf, nil
+}
+
+// readBody reads r to the end through a pooled buffer and returns a copy of
+// exactly the bytes read.
+func readBody(r io.Reader) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+// pooledBody is a request body backed by a pooled buffer. The transport may
+// close the body from another goroutine after the round trip returns, so
+// the buffer is released only once both the body has been closed and the
+// sender has called done.
+type pooledBody struct {
+	reader    bytes.Reader
+	buf       *bytes.Buffer
+	refs      atomic.Int32
+	closeOnce sync.Once
+}
+
+func newPooledBody(buf *bytes.Buffer) *pooledBody {
+	b := &pooledBody{buf: buf}
+	b.reader.Reset(buf.Bytes())
+	b.refs.Store(2)
+	return b
+}
+
+func (b *pooledBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+func (b *pooledBody) Close() error {
+	b.closeOnce.Do(b.release)
+	return nil
+}
+
+// done is called by the sender once the round trip has returned.
+func (b *pooledBody) done() {
+	b.release()
+}
+
+// getBody serves redirects and retries during the round trip with a copy,
+// which stays valid after the pooled buffer is released.
+func (b *pooledBody) getBody() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(bytes.Clone(b.buf.Bytes()))), nil
+}
+
+func (b *pooledBody) release() {
+	if b.refs.Add(-1) == 0 {
+		putBuffer(b.buf)
+	}
+}
@@ -0,0 +1,189 @@
+package a2areg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeBody_MatchesMarshal(t *testing.T) {
+	v := map[string]interface{}{"name": "<Recipe & Co>", "tags": []string{"a", "b"}}
+	want, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	buf, err := encodeBody(v)
+	require.NoError(t, err)
+	defer putBuffer(buf)
+	assert.Equal(t, want, buf.Bytes())
+}
+
+func TestPutBuffer_ZeroesContents(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("client_secret=hunter2")
+	data := buf.Bytes()[:buf.Cap()]
+
+	putBuffer(buf)
+	assert.Equal(t, 0, buf.Len())
+	assert.Equal(t, make([]byte, len(data)), data)
+}
+
+func TestPutBuffer_DropsOversizedBuffers(t *testing.T) {
+	buf := bytes.NewBuffer(make([]byte, 0, maxPooledBufferBytes+1))
+	buf.WriteString("large")
+	putBuffer(buf)
+	// Not zeroed because it was never pooled.
+	assert.Equal(t, "large", buf.String())
+}
+
+func TestReadBody_ReturnsCopy(t *testing.T) {
+	body, err := readBody(strings.NewReader(`{"ok":true}`))
+	require.NoError(t, err)
+
+	// Churn the pool; the returned slice must be unaffected.
+	for i := 0; i < 10; i++ {
+		buf := getBuffer()
+		buf.WriteString(strings.Repeat("x", 32))
+		putBuffer(buf)
+	}
+	assert.Equal(t, `{"ok":true}`, string(body))
+}
+
+func TestPooledBody_ReleasedAfterCloseAndDone(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString("payload")
+	body := newPooledBody(buf)
+
+	got, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(got))
+
+	body.Close()
+	body.Close()
+	assert.Equal(t, "payload", buf.String(), "released before done")
+
+	retry, err := body.getBody()
+	require.NoError(t, err)
+	body.done()
+	assert.Equal(t, 0, buf.Len())
+
+	got, err = io.ReadAll(retry)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(got))
+}
+
+// TestBuffers_ConcurrentPublishesDoNotBleed publishes many differently
+// sized agents at once and checks that every request the server sees, and
+// every response the client decodes, belongs to the same publish.
+func TestBuffers_ConcurrentPublishesDoNotBleed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Card struct {
+				Name        string `json:"name"`
+				Description string `json:"description"`
+			} `json:"card"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Card.Description != describe(req.Card.Name) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":          req.Card.Name,
+			"name":        req.Card.Name,
+			"description": req.Card.Description,
+		})
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		name := fmt.Sprintf("agent-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			agent, err := client.PublishAgent(&Agent{Name: name, Description: describe(name), Version: "1.0.0"}, false)
+			if assert.NoError(t, err, name) {
+				assert.Equal(t, name, agent.Name)
+				assert.Equal(t, describe(name), agent.Description)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// describe returns a description whose length varies with name, so that
+// some bodies fit the pool bound and others exceed it.
+func describe(name string) string {
+	n := 1
+	for _, r := range name {
+		n = n*31 + int(r)
+	}
+	if n < 0 {
+		n = -n
+	}
+	return strings.Repeat(name+";", n%(2*maxPooledBufferBytes/len(name)))
+}
+
+func BenchmarkEncodeBody(b *testing.B) {
+	agent := benchmarkCard()
+	b.Run("marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, _ := json.Marshal(agent)
+			_ = bytes.NewBuffer(data)
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, _ := encodeBody(agent)
+			putBuffer(buf)
+		}
+	})
+}
+
+func BenchmarkReadBody(b *testing.B) {
+	data, _ := json.Marshal(benchmarkCard())
+	b.Run("readall", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = io.ReadAll(bytes.NewReader(data))
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = readBody(bytes.NewReader(data))
+		}
+	})
+}
+
+func benchmarkCard() map[string]interface{} {
+	skills := make([]map[string]interface{}, 20)
+	for i := range skills {
+		skills[i] = map[string]interface{}{
+			"id":          fmt.Sprintf("skill-%d", i),
+			"name":        fmt.Sprintf("Skill %d", i),
+			"description": strings.Repeat("does a useful thing ", 10),
+			"tags":        []string{"cooking", "recipes"},
+		}
+	}
+	return map[string]interface{}{
+		"public": true,
+		"card":   map[string]interface{}{"name": "Recipe Agent", "version": "1.0.0", "skills": skills},
+	}
+}
@@ -0,0 +1,135 @@
+package a2areg
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorClass groups SDK errors by how a caller should react to them.
+type ErrorClass string
+
+const (
+	ClassValidation  ErrorClass = "validation"
+	ClassConflict    ErrorClass = "conflict"
+	ClassRateLimited ErrorClass = "rate_limited"
+	ClassServer      ErrorClass = "server"
+	ClassOther       ErrorClass = "other"
+)
+
+// Sentinels matching every SDK error of a class with errors.Is, including
+// the item errors inside a *BulkError:
+//
+//	if errors.Is(err, a2areg.ErrRateLimited) { ... }
+var (
+	ErrValidation  = errors.New("a2areg: validation failed")
+	ErrConflict    = errors.New("a2areg: conflict")
+	ErrRateLimited = errors.New("a2areg: rate limited")
+	ErrServer      = errors.New("a2areg: server error")
+)
+
+var classSentinels = map[ErrorClass]error{
+	ClassValidation:  ErrValidation,
+	ClassConflict:    ErrConflict,
+	ClassRateLimited: ErrRateLimited,
+	ClassServer:      ErrServer,
+}
+
+// Is reports whether target is the sentinel for the error's class.
+func (e *A2AError) Is(target error) bool {
+	sentinel, ok := classSentinels[e.class()]
+	return ok && target == sentinel
+}
+
+// class classifies the error by its code and HTTP status.
+func (e *A2AError) class() ErrorClass {
+	switch {
+	case e.Code == CodeRateLimited || e.StatusCode == http.StatusTooManyRequests:
+		return ClassRateLimited
+	case e.Code == CodeValidationFailed || e.Code == CodeRequestTooLarge ||
+		e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return ClassValidation
+	case e.StatusCode == http.StatusConflict || e.StatusCode == http.StatusPreconditionFailed:
+		return ClassConflict
+	case e.Code == CodeServerError || e.StatusCode >= 500:
+		return ClassServer
+	}
+	return ClassOther
+}
+
+// ClassOf returns the class of the first SDK error in err's chain, or
+// ClassOther.
+func ClassOf(err error) ErrorClass {
+	var coded codedError
+	if !errors.As(err, &coded) {
+		return ClassOther
+	}
+	return (&A2AError{Code: coded.errorCode(), StatusCode: coded.statusCode()}).class()
+}
+
+// BulkResult is the outcome of one item of a bulk operation.
+type BulkResult struct {
+	// Index is the item's position in the input.
+	Index int
+	// AgentID identifies the agent the item concerned, when known.
+	AgentID string
+	// Err is nil if the item succeeded.
+	Err error
+}
+
+// BulkError reports the failed items of a bulk operation that continued
+// past errors. It unwraps to the item errors, so errors.Is and errors.As
+// match if any item matches, and it composes with errors.Join.
+type BulkError struct {
+	*A2AError
+	// Total is the number of items attempted.
+	Total int
+	// Failed holds the failed items, in input order.
+	Failed []BulkResult
+}
+
+// NewBulkError returns a *BulkError for the failed entries of results, or
+// nil if every item succeeded.
+func NewBulkError(results []BulkResult) error {
+	var failed []BulkResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return newBulkError(len(results), failed)
+}
+
+// newBulkError returns a *BulkError for failed out of total items, or nil
+// if none failed, for operations that do not keep every result.
+func newBulkError(total int, failed []BulkResult) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BulkError{
+		A2AError: &A2AError{
+			Message: fmt.Sprintf("%d of %d items failed; first: item %d: %v", len(failed), total, failed[0].Index, failed[0].Err),
+			Code:    CodeBulkFailed,
+		},
+		Total:  total,
+		Failed: failed,
+	}
+}
+
+// Unwrap returns the item errors.
+func (e *BulkError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, r := range e.Failed {
+		errs[i] = r.Err
+	}
+	return errs
+}
+
+// Summary counts the failed items by error class.
+func (e *BulkError) Summary() map[ErrorClass]int {
+	summary := make(map[ErrorClass]int)
+	for _, r := range e.Failed {
+		summary[ClassOf(r.Err)]++
+	}
+	return summary
+}
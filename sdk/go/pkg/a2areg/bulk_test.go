@@ -0,0 +1,85 @@
+package a2areg
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statusError[E codedError](err E, status int) E {
+	err.setStatusCode(status)
+	return err
+}
+
+func TestBulkError_Unwrap(t *testing.T) {
+	rateLimited := statusError(NewRateLimitError("slow down", nil), http.StatusTooManyRequests)
+	invalid := NewValidationError("bad card", nil)
+	results := []BulkResult{
+		{Index: 0, AgentID: "a"},
+		{Index: 1, AgentID: "b", Err: invalid},
+		{Index: 2, AgentID: "c"},
+		{Index: 3, AgentID: "d", Err: rateLimited},
+	}
+
+	err := NewBulkError(results)
+	var bulk *BulkError
+	require.True(t, errors.As(err, &bulk))
+	assert.Equal(t, 4, bulk.Total)
+	assert.Equal(t, []error{invalid, rateLimited}, bulk.Unwrap())
+	assert.Equal(t, "2 of 4 items failed; first: item 1: bad card", err.Error())
+	assert.Equal(t, CodeBulkFailed, ErrorCode(err))
+
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.True(t, errors.Is(err, ErrValidation))
+	assert.False(t, errors.Is(err, ErrServer))
+	assert.False(t, errors.Is(err, ErrConflict))
+
+	var rateErr *RateLimitError
+	require.True(t, errors.As(err, &rateErr))
+	assert.Same(t, rateLimited, rateErr)
+
+	// It composes with errors.Join.
+	joined := errors.Join(errors.New("sync failed"), err)
+	assert.True(t, errors.Is(joined, ErrRateLimited))
+	assert.True(t, errors.As(joined, &bulk))
+}
+
+func TestBulkError_NoFailures(t *testing.T) {
+	assert.NoError(t, NewBulkError(nil))
+	assert.NoError(t, NewBulkError([]BulkResult{{Index: 0}, {Index: 1}}))
+}
+
+func TestBulkError_Summary(t *testing.T) {
+	err := NewBulkError([]BulkResult{
+		{Index: 0, Err: NewValidationError("bad", nil)},
+		{Index: 1, Err: withCode(NewValidationError("too big", nil), CodeRequestTooLarge)},
+		{Index: 2, Err: statusError(NewA2AError("exists", nil), http.StatusConflict)},
+		{Index: 3, Err: NewRateLimitError("slow down", nil)},
+		{Index: 4, Err: statusError(withCode(NewA2AError("boom", nil), CodeServerError), http.StatusBadGateway)},
+		{Index: 5, Err: statusError(NewA2AError("unavailable", nil), http.StatusServiceUnavailable)},
+		{Index: 6, Err: errors.New("disk full")},
+		{Index: 7, Err: NewNotFoundError("gone", nil)},
+	})
+
+	var bulk *BulkError
+	require.True(t, errors.As(err, &bulk))
+	assert.Equal(t, map[ErrorClass]int{
+		ClassValidation:  2,
+		ClassConflict:    1,
+		ClassRateLimited: 1,
+		ClassServer:      2,
+		ClassOther:       2,
+	}, bulk.Summary())
+}
+
+func TestClassOf(t *testing.T) {
+	assert.Equal(t, ClassRateLimited, ClassOf(statusError(NewA2AError("x", nil), http.StatusTooManyRequests)))
+	assert.Equal(t, ClassValidation, ClassOf(statusError(NewA2AError("x", nil), http.StatusBadRequest)))
+	assert.Equal(t, ClassOther, ClassOf(NewAuthenticationError("x", nil)))
+	assert.Equal(t, ClassOther, ClassOf(nil))
+	assert.True(t, errors.Is(NewRateLimitError("x", nil), ErrRateLimited))
+	assert.False(t, errors.Is(NewNotFoundError("x", nil), ErrServer))
+}
@@ -0,0 +1,209 @@
+package a2areg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCardCacheTTL is how long cached agent cards are served.
+const DefaultCardCacheTTL = 5 * time.Minute
+
+// cardCacheKey returns the Store key for an agent's card.
+func cardCacheKey(agentID string, profile CardProfile) string {
+	if profile != "" {
+		return "card:" + agentID + ";profile=" + string(profile)
+	}
+	return "card:" + agentID
+}
+
+// cachedProfile reports whether cards in profile are cached: those in the
+// current schema, the client's CardProfile and the profiles MigrateCard
+// knows, which are the ones invalidateAgent drops.
+func (c *A2ARegClient) cachedProfile(profile CardProfile) bool {
+	_, known := cardMigrations[profile]
+	return profile == "" || profile == c.cardProfile || known
+}
+
+// Names of the client caches, as used by CacheStats and CacheEvent.
+const (
+	CacheCard   = "card"
+	CacheSearch = "search"
+)
+
+// CacheStats counts what a client cache did since the client was created
+// or ResetCacheStats was called.
+type CacheStats struct {
+	Enabled bool   `json:"enabled"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	// Stores counts the entries written to the cache.
+	Stores uint64 `json:"stores"`
+	// Evictions counts the entries the client removed: invalidated by a
+	// mutation, or dropped because they could not be decoded. Entries the
+	// Store expires or evicts on its own are not seen by the client.
+	Evictions uint64 `json:"evictions"`
+}
+
+// CacheEventType is what happened in a CacheEvent.
+type CacheEventType string
+
+// Cache event types.
+const (
+	CacheEventHit      CacheEventType = "hit"
+	CacheEventMiss     CacheEventType = "miss"
+	CacheEventStore    CacheEventType = "store"
+	CacheEventEviction CacheEventType = "eviction"
+)
+
+// CacheEvent reports one cache operation to OnCacheEvent. It never holds
+// the key itself or the cached content: KeyHash is a truncated SHA-256 of
+// the key, enough to correlate events for one entry.
+type CacheEvent struct {
+	// Cache is CacheCard or CacheSearch.
+	Cache   string
+	Type    CacheEventType
+	KeyHash string
+}
+
+// cacheCounters tracks the operations of one cache.
+type cacheCounters struct {
+	name      string
+	onEvent   func(CacheEvent)
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	stores    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func (cc *cacheCounters) init(name string, onEvent func(CacheEvent)) {
+	cc.name = name
+	cc.onEvent = onEvent
+}
+
+// record counts a lookup of key and returns hit.
+func (cc *cacheCounters) record(key string, hit bool) bool {
+	if hit {
+		cc.hits.Add(1)
+		cc.emit(CacheEventHit, key)
+	} else {
+		cc.misses.Add(1)
+		cc.emit(CacheEventMiss, key)
+	}
+	return hit
+}
+
+// stored counts a write of key.
+func (cc *cacheCounters) stored(key string) {
+	cc.stores.Add(1)
+	cc.emit(CacheEventStore, key)
+}
+
+// evicted counts the removal of key.
+func (cc *cacheCounters) evicted(key string) {
+	cc.evictions.Add(1)
+	cc.emit(CacheEventEviction, key)
+}
+
+func (cc *cacheCounters) emit(eventType CacheEventType, key string) {
+	if cc.onEvent == nil {
+		return
+	}
+	sum := sha256.Sum256([]byte(key))
+	cc.onEvent(CacheEvent{Cache: cc.name, Type: eventType, KeyHash: hex.EncodeToString(sum[:8])})
+}
+
+func (cc *cacheCounters) stats(enabled bool) CacheStats {
+	return CacheStats{
+		Enabled:   enabled,
+		Hits:      cc.hits.Load(),
+		Misses:    cc.misses.Load(),
+		Stores:    cc.stores.Load(),
+		Evictions: cc.evictions.Load(),
+	}
+}
+
+func (cc *cacheCounters) reset() {
+	cc.hits.Store(0)
+	cc.misses.Store(0)
+	cc.stores.Store(0)
+	cc.evictions.Store(0)
+}
+
+// CacheStats returns the statistics of the client's caches, keyed by
+// CacheCard and CacheSearch. A cache that is not enabled reports Enabled
+// false.
+func (c *A2ARegClient) CacheStats() map[string]CacheStats {
+	stats := map[string]CacheStats{
+		CacheCard:   c.cardCacheCounters.stats(c.cardCache != nil),
+		CacheSearch: {},
+	}
+	if c.searchCache != nil {
+		stats[CacheSearch] = c.searchCache.counters.stats(true)
+	}
+	return stats
+}
+
+// ResetCacheStats zeroes the counters CacheStats reports. Operations that
+// run concurrently with the reset may be counted before or after it.
+func (c *A2ARegClient) ResetCacheStats() {
+	c.cardCacheCounters.reset()
+	if c.searchCache != nil {
+		c.searchCache.counters.reset()
+	}
+}
+
+// cachedCard returns the cached card for agentID in profile, if any.
+// Store errors and undecodable entries are treated as misses.
+func (c *A2ARegClient) cachedCard(agentID string, profile CardProfile) (*AgentCardSpec, bool) {
+	if c.cardCache == nil {
+		return nil, false
+	}
+	key := cardCacheKey(agentID, profile)
+	data, ok, err := c.cardCache.Get(key)
+	if err != nil || !ok {
+		return nil, c.cardCacheCounters.record(key, false)
+	}
+	var card AgentCardSpec
+	if err := json.Unmarshal(data, &card); err != nil {
+		if c.cardCache.Delete(key) == nil {
+			c.cardCacheCounters.evicted(key)
+		}
+		return nil, c.cardCacheCounters.record(key, false)
+	}
+	return &card, c.cardCacheCounters.record(key, true)
+}
+
+// storeCard caches the raw card document for agentID in profile. Caching
+// is best effort; Store errors are ignored.
+func (c *A2ARegClient) storeCard(agentID string, profile CardProfile, body []byte) {
+	if c.cardCache == nil {
+		return
+	}
+	key := cardCacheKey(agentID, profile)
+	if c.cardCache.Set(key, body, c.cardCacheTTL) == nil {
+		c.cardCacheCounters.stored(key)
+	}
+}
+
+// invalidateAgent drops cached data for agentID after a mutation.
+func (c *A2ARegClient) invalidateAgent(agentID string) {
+	if c.cardCache == nil {
+		return
+	}
+	profiles := map[CardProfile]bool{"": true, c.cardProfile: true}
+	for profile := range cardMigrations {
+		profiles[profile] = true
+	}
+	for profile := range profiles {
+		key := cardCacheKey(agentID, profile)
+		if _, ok, err := c.cardCache.Get(key); err != nil || !ok {
+			continue
+		}
+		if c.cardCache.Delete(key) == nil {
+			c.cardCacheCounters.evicted(key)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package a2areg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheStatsRegistry serves agent cards, searches, publishes and deletes.
+func cacheStatsRegistry(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/card"):
+			w.Write([]byte(testCardJSON))
+		case r.URL.Path == "/agents/search":
+			w.Write([]byte(`{"agents": [{"id": "a", "name": "Result"}], "total": 1}`))
+		case r.URL.Path == "/agents/publish":
+			w.Write([]byte(`{"id": "agent-1", "name": "Recipe Agent"}`))
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// eventLog collects cache events.
+type eventLog struct {
+	mu     sync.Mutex
+	events []CacheEvent
+}
+
+func (l *eventLog) add(event CacheEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *eventLog) all() []CacheEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]CacheEvent(nil), l.events...)
+}
+
+func TestCacheStats_ExactCounts(t *testing.T) {
+	server := cacheStatsRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:       server.URL,
+		APIKey:            "test",
+		CardCache:         NewMemoryStore(0),
+		EnableSearchCache: true,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := client.GetAgentCard("agent-1")
+		require.NoError(t, err)
+	}
+	_, err := client.GetAgentCard("agent-2")
+	require.NoError(t, err)
+	require.NoError(t, client.DeleteAgent("agent-1"))
+	_, err = client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+
+	for _, query := range []string{"weather", "weather", "recipes"} {
+		_, err := client.Search(ctx, SearchRequest{Query: query})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]CacheStats{
+		CacheCard:   {Enabled: true, Hits: 2, Misses: 3, Stores: 3, Evictions: 1},
+		CacheSearch: {Enabled: true, Hits: 1, Misses: 2, Stores: 2},
+	}, client.CacheStats())
+
+	client.ResetCacheStats()
+	assert.Equal(t, map[string]CacheStats{
+		CacheCard:   {Enabled: true},
+		CacheSearch: {Enabled: true},
+	}, client.CacheStats())
+}
+
+func TestCacheStats_Disabled(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: cacheStatsRegistry(t).URL, APIKey: "test"})
+
+	_, err := client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]CacheStats{CacheCard: {}, CacheSearch: {}}, client.CacheStats())
+}
+
+func TestCacheStats_Concurrent(t *testing.T) {
+	server := cacheStatsRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:       server.URL,
+		APIKey:            "test",
+		CardCache:         NewMemoryStore(0),
+		EnableSearchCache: true,
+	})
+	ctx := context.Background()
+
+	// Warm both caches so every later lookup is a hit.
+	_, err := client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	_, err = client.Search(ctx, SearchRequest{Query: "weather"})
+	require.NoError(t, err)
+	client.ResetCacheStats()
+
+	const workers, lookups = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < lookups; j++ {
+				_, err := client.GetAgentCard("agent-1")
+				assert.NoError(t, err)
+				_, err = client.Search(ctx, SearchRequest{Query: "weather"})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := client.CacheStats()
+	assert.Equal(t, CacheStats{Enabled: true, Hits: workers * lookups}, stats[CacheCard])
+	assert.Equal(t, CacheStats{Enabled: true, Hits: workers * lookups}, stats[CacheSearch])
+}
+
+func TestCacheEvents(t *testing.T) {
+	server := cacheStatsRegistry(t)
+	var log eventLog
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:       server.URL,
+		APIKey:            "test",
+		CardCache:         NewMemoryStore(0),
+		EnableSearchCache: true,
+		OnCacheEvent:      log.add,
+	})
+	ctx := context.Background()
+
+	_, err := client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	_, err = client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	_, err = client.Search(ctx, SearchRequest{Query: "weather"})
+	require.NoError(t, err)
+	_, err = client.PublishAgent(&Agent{Name: "Recipe Agent", Description: "Finds recipes", Version: "1.0.0", Provider: "acme"}, false)
+	require.NoError(t, err)
+
+	events := log.all()
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Cache+" "+string(event.Type))
+		assert.Len(t, event.KeyHash, 16)
+		assert.NotContains(t, event.KeyHash, "agent-1")
+		assert.NotContains(t, event.KeyHash, "weather")
+	}
+	assert.Equal(t, []string{
+		"card miss", "card store", "card hit",
+		"search miss", "search store", "search eviction",
+	}, types)
+
+	// Events for one entry share a hash; different entries do not.
+	assert.Equal(t, events[0].KeyHash, events[1].KeyHash)
+	assert.Equal(t, events[0].KeyHash, events[2].KeyHash)
+	assert.Equal(t, events[3].KeyHash, events[5].KeyHash)
+	assert.NotEqual(t, events[0].KeyHash, events[3].KeyHash)
+}
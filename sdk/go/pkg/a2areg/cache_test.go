@@ -0,0 +1,61 @@
+package a2areg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCardJSON = `{
+	"name": "Cached Agent",
+	"description": "Card description",
+	"url": "https://agent.example.com",
+	"version": "1.0.0",
+	"capabilities": {},
+	"securitySchemes": {},
+	"skills": [{"id": "s1", "name": "Skill", "description": "d", "tags": []}],
+	"interface": {"preferredTransport": "jsonrpc", "defaultInputModes": ["text/plain"], "defaultOutputModes": ["text/plain"]}
+}`
+
+func TestA2ARegClient_CardCache_WarmAfterRestart(t *testing.T) {
+	var cardCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/agents/agent-1/card":
+			cardCalls.Add(1)
+			w.Write([]byte(testCardJSON))
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	newClient := func() *A2ARegClient {
+		store, err := NewFileStore(dir, FileStoreOptions{})
+		require.NoError(t, err)
+		return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", CardCache: store})
+	}
+
+	card, err := newClient().GetAgentCard("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Cached Agent", card.Name)
+	assert.Equal(t, int32(1), cardCalls.Load())
+
+	// A fresh client over the same directory starts warm.
+	restarted := newClient()
+	card, err = restarted.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Cached Agent", card.Name)
+	assert.Equal(t, int32(1), cardCalls.Load())
+
+	// Mutations invalidate the cached card.
+	require.NoError(t, restarted.DeleteAgent("agent-1"))
+	_, err = restarted.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), cardCalls.Load())
+}
@@ -0,0 +1,103 @@
+package a2areg
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Registry features the client adapts to. A registry advertises the ones it
+// supports in the "features" of its /health response: a list of names, or
+// an object mapping names to whether they are enabled.
+const (
+	// FeatureVisibility means the registry understands the visibility
+	// field (public, unlisted, private) on agents.
+	FeatureVisibility = "visibility"
+	// FeatureSearch means the registry serves /agents/search. A registry
+	// reporting it as disabled is searched client-side when
+	// SearchFallback is set.
+	FeatureSearch = "search"
+)
+
+// ServerCapabilities describes what the registry reported about itself.
+type ServerCapabilities struct {
+	Version  string   `json:"version,omitempty"`
+	Features []string `json:"features"`
+
+	// disabled holds the features the registry reported as turned off,
+	// as opposed to not mentioned.
+	disabled []string
+}
+
+// Supports reports whether the registry advertised feature.
+func (s *ServerCapabilities) Supports(feature string) bool {
+	if s == nil {
+		return false
+	}
+	for _, f := range s.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilitiesState caches the first successful capability probe.
+type capabilitiesState struct {
+	mu   sync.Mutex
+	caps *ServerCapabilities
+}
+
+// ServerCapabilities probes the registry's /health endpoint for its version
+// and feature list. The first successful probe is cached for the life of
+// the client; a failed probe is retried by the next call. Registries that
+// predate feature reporting yield an empty feature list.
+func (c *A2ARegClient) ServerCapabilities(ctx context.Context) (*ServerCapabilities, error) {
+	s := &c.capabilities
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.caps != nil {
+		return s.caps, nil
+	}
+
+	body, err := c.makeRequestContext(ctx, "GET", "/health", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	health, err := decodeResponseMap(body)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to decode health response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+
+	caps := &ServerCapabilities{Features: []string{}}
+	caps.Version, _ = health.GetString("version")
+	switch features := health["features"].(type) {
+	case []interface{}:
+		for _, feature := range features {
+			if name, ok := feature.(string); ok {
+				caps.Features = append(caps.Features, name)
+			}
+		}
+	case map[string]interface{}:
+		for name, enabled := range features {
+			switch enabled {
+			case true:
+				caps.Features = append(caps.Features, name)
+			case false:
+				caps.disabled = append(caps.disabled, name)
+			}
+		}
+		sort.Strings(caps.Features)
+	}
+	s.caps = caps
+	c.noteSearchCapability(caps)
+	return caps, nil
+}
+
+// supports reports whether the registry advertised feature. A registry that
+// cannot be probed is treated as supporting nothing, so callers fall back to
+// the oldest wire format.
+func (c *A2ARegClient) supports(ctx context.Context, feature string) bool {
+	caps, err := c.ServerCapabilities(ctx)
+	return err == nil && caps.Supports(feature)
+}
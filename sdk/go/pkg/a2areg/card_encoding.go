@@ -0,0 +1,124 @@
+package a2areg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// cardAcceptEncoding is the Accept-Encoding sent for agent cards. Setting
+// it ourselves stops the transport from decompressing gzip alone.
+const cardAcceptEncoding = "gzip, deflate"
+
+// UnsupportedEncodingError is the cause of a *CardFetchError, with code
+// CodeUnsupportedEncoding, when an agent card is sent with a
+// Content-Encoding or charset the SDK cannot decode.
+type UnsupportedEncodingError struct {
+	// Header is "Content-Encoding" or "charset".
+	Header   string
+	Encoding string
+}
+
+func (e *UnsupportedEncodingError) Error() string {
+	return fmt.Sprintf("unsupported %s %q", e.Header, e.Encoding)
+}
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// cardCharsets are the charsets agent cards are accepted in, besides UTF-8.
+var cardCharsets = map[string]encoding.Encoding{
+	"utf-16":       unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"iso-8859-1":   charmap.ISO8859_1,
+	"latin1":       charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+}
+
+// readCardBody reads an agent card response as UTF-8 JSON: it undoes the
+// Content-Encoding, transcodes from the charset of the Content-Type or the
+// one a byte order mark shows, and strips any BOM. At most limit bytes of
+// the decoded body are read; tooLarge reports a longer one.
+func readCardBody(resp *http.Response, limit int64) (body []byte, tooLarge bool, err error) {
+	reader, err := decodeContentEncoding(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, false, err
+	}
+	body, err = io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(body)) > limit {
+		return nil, true, nil
+	}
+
+	charset := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		charset = strings.ToLower(strings.TrimSpace(params["charset"]))
+	}
+	// A UTF-16 byte order mark outranks the declared charset, which servers
+	// often leave at a default.
+	if bytes.HasPrefix(body, []byte{0xfe, 0xff}) || bytes.HasPrefix(body, []byte{0xff, 0xfe}) {
+		charset = "utf-16"
+	}
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii":
+	default:
+		enc, ok := cardCharsets[charset]
+		if !ok {
+			return nil, false, &UnsupportedEncodingError{Header: "charset", Encoding: charset}
+		}
+		if body, err = enc.NewDecoder().Bytes(body); err != nil {
+			return nil, false, err
+		}
+	}
+	return bytes.TrimPrefix(body, utf8BOM), false, nil
+}
+
+// decodeContentEncoding wraps r to undo the codings of a Content-Encoding
+// header, which lists them in the order they were applied.
+func decodeContentEncoding(r io.Reader, header string) (io.Reader, error) {
+	codings := strings.Split(header, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		var err error
+		switch coding := strings.ToLower(strings.TrimSpace(codings[i])); coding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(r)
+		case "deflate":
+			r, err = newDeflateReader(r)
+		default:
+			return nil, &UnsupportedEncodingError{Header: "Content-Encoding", Encoding: coding}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// newDeflateReader reads a "deflate" body. HTTP defines it as a zlib
+// stream, but some servers send raw DEFLATE; the zlib header tells them
+// apart.
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
@@ -0,0 +1,163 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// CardProfile names a revision of the agent card schema. A client asks for
+// one with the CardProfile option or WithCardProfile; the registry
+// down-converts cards to it and names it in the response's Content-Type,
+// as in "application/json; profile=a2a-card-v1".
+type CardProfile string
+
+// CardProfileV1 is the card schema of the first A2A protocol revision: no
+// signature, default input and output modes, or
+// supportsAuthenticatedExtendedCard capability.
+const CardProfileV1 CardProfile = "a2a-card-v1"
+
+// cardMigrations down-convert a card in the current schema to a profile.
+// They are the fallback for registries that ignore the profile parameter.
+var cardMigrations = map[CardProfile]func(*AgentCardSpec){
+	CardProfileV1: func(card *AgentCardSpec) {
+		card.Signature = nil
+		card.DefaultInputModes = nil
+		card.DefaultOutputModes = nil
+		card.Capabilities.SupportsAuthenticatedExtendedCard = nil
+	},
+}
+
+// UnsupportedProfileError is returned when an agent card cannot be had in
+// the requested CardProfile: the registry refused it with 406, served a
+// different profile, or ignored it and the SDK has no migration to it.
+// Served is the profile the registry named, if any.
+type UnsupportedProfileError struct {
+	*A2AError
+	Requested CardProfile
+	Served    CardProfile
+}
+
+// NewUnsupportedProfileError creates a new UnsupportedProfileError.
+func NewUnsupportedProfileError(requested, served CardProfile, details map[string]interface{}) *UnsupportedProfileError {
+	message := fmt.Sprintf("Card profile %q is not supported", requested)
+	if served != "" {
+		message += fmt.Sprintf(" (registry served %q)", served)
+	}
+	return &UnsupportedProfileError{
+		A2AError:  &A2AError{Message: message, Code: CodeUnsupportedProfile, Details: details},
+		Requested: requested,
+		Served:    served,
+	}
+}
+
+// WithCardProfile asks for the agent card in profile, overriding the
+// client's CardProfile for this call.
+func WithCardProfile(profile CardProfile) RequestOption {
+	return func(o *requestOptions) {
+		o.cardProfile = profile
+	}
+}
+
+// MigrateCard returns a copy of card, which must be in the current schema,
+// converted to profile. An empty profile returns the copy unchanged; one
+// the SDK cannot convert to returns an *UnsupportedProfileError.
+func MigrateCard(card *AgentCardSpec, profile CardProfile) (*AgentCardSpec, error) {
+	if card == nil {
+		return nil, NewValidationError("Agent card is required", nil)
+	}
+	migrated := *card
+	if profile == "" {
+		return &migrated, nil
+	}
+	migrate, ok := cardMigrations[profile]
+	if !ok {
+		return nil, NewUnsupportedProfileError(profile, "", nil)
+	}
+	migrate(&migrated)
+	return &migrated, nil
+}
+
+// GetExtendedAgentCard gets the authenticated extended card of an agent
+// whose capabilities set supportsAuthenticatedExtendedCard. It honors
+// CardProfile like GetAgentCard. Extended cards are never cached.
+func (c *A2ARegClient) GetExtendedAgentCard(ctx context.Context, agentID string, opts ...RequestOption) (*AgentCardSpec, error) {
+	ctx = withRequestOptions(ctx, opts)
+	options := requestOptionsFrom(ctx)
+	card, _, err := c.fetchProfiledCard(ctx, "/agents/"+agentID+"/card/extended", c.cardProfileFor(options))
+	if err != nil {
+		return nil, err
+	}
+	return c.verifiedCard(ctx, card, options.verifySignature)
+}
+
+// cardProfileFor returns the profile a call asks for.
+func (c *A2ARegClient) cardProfileFor(options requestOptions) CardProfile {
+	if options.cardProfile != "" {
+		return options.cardProfile
+	}
+	return c.cardProfile
+}
+
+// fetchProfiledCard fetches the card at endpoint in profile and returns it
+// with the JSON document it was decoded from.
+func (c *A2ARegClient) fetchProfiledCard(ctx context.Context, endpoint string, profile CardProfile) (*AgentCardSpec, []byte, error) {
+	if profile != "" {
+		options := requestOptionsFrom(ctx)
+		header := options.header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		header.Set("Accept", "application/json; profile="+string(profile))
+		options.header = header
+		ctx = context.WithValue(ctx, requestOptionsKey{}, options)
+	}
+
+	resp, err := c.send(ctx, "GET", endpoint, nil, nil)
+	if err != nil {
+		if profile != "" && StatusCode(err) == http.StatusNotAcceptable {
+			profileErr := NewUnsupportedProfileError(profile, "", map[string]interface{}{"error": err.Error()})
+			profileErr.setStatusCode(http.StatusNotAcceptable)
+			return nil, nil, profileErr
+		}
+		return nil, nil, agentNotFound(err)
+	}
+
+	var card AgentCardSpec
+	if err := json.Unmarshal(resp.body, &card); err != nil {
+		return nil, nil, withCode(NewA2AError("Failed to decode card response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if profile == "" {
+		return &card, resp.body, nil
+	}
+
+	switch served := servedCardProfile(resp.header); served {
+	case profile:
+		return &card, resp.body, nil
+	case "":
+		// The registry ignored the profile and sent the current schema.
+		migrated, err := MigrateCard(&card, profile)
+		if err != nil {
+			return nil, nil, err
+		}
+		body, err := json.Marshal(migrated)
+		if err != nil {
+			return nil, nil, withCode(NewA2AError("Failed to encode migrated card", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+		}
+		return migrated, body, nil
+	default:
+		return nil, nil, NewUnsupportedProfileError(profile, served, nil)
+	}
+}
+
+// servedCardProfile returns the profile parameter of a response's
+// Content-Type.
+func servedCardProfile(header http.Header) CardProfile {
+	_, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return CardProfile(params["profile"])
+}
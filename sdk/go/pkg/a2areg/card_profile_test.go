@@ -0,0 +1,211 @@
+package a2areg
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// currentCardJSON is a card in the current schema, with the fields
+// CardProfileV1 lacks.
+const currentCardJSON = `{
+	"name": "Profiled Agent",
+	"description": "Card description",
+	"url": "https://agent.example.com",
+	"version": "2.0.0",
+	"capabilities": {"streaming": true, "supportsAuthenticatedExtendedCard": true},
+	"securitySchemes": {},
+	"skills": [{"id": "s1", "name": "Skill", "description": "d", "tags": []}],
+	"interface": {"preferredTransport": "jsonrpc"},
+	"defaultInputModes": ["text/plain"],
+	"defaultOutputModes": ["application/json"],
+	"signature": {"algorithm": "RS256", "signature": "c2ln"}
+}`
+
+// v1CardJSON is currentCardJSON as the registry down-converts it.
+const v1CardJSON = `{
+	"name": "Profiled Agent v1",
+	"description": "Card description",
+	"url": "https://agent.example.com",
+	"version": "2.0.0",
+	"capabilities": {"streaming": true},
+	"securitySchemes": {},
+	"skills": [{"id": "s1", "name": "Skill", "description": "d", "tags": []}],
+	"interface": {"preferredTransport": "jsonrpc"}
+}`
+
+// profileRegistry serves agent-1's card. mode is "honor" (down-convert on
+// request), "refuse" (406 for any profile), "ignore" (always the current
+// schema) or "other" (always labels the card a2a-card-v0).
+func profileRegistry(t *testing.T, mode string, accepts *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*accepts = append(*accepts, r.Header.Get("Accept"))
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Accept"))
+		profile := params["profile"]
+		switch {
+		case mode == "refuse" && profile != "":
+			w.WriteHeader(http.StatusNotAcceptable)
+			w.Write([]byte(`{"detail": "Unsupported card profile"}`))
+		case mode == "honor" && profile == string(CardProfileV1):
+			w.Header().Set("Content-Type", "application/json; profile=a2a-card-v1")
+			w.Write([]byte(v1CardJSON))
+		case mode == "other":
+			w.Header().Set("Content-Type", "application/json; profile=a2a-card-v0")
+			w.Write([]byte(v1CardJSON))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(currentCardJSON))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetAgentCard_Profile(t *testing.T) {
+	t.Run("honored", func(t *testing.T) {
+		var accepts []string
+		server := profileRegistry(t, "honor", &accepts)
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", CardProfile: CardProfileV1})
+
+		card, err := client.GetAgentCard("agent-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Profiled Agent v1", card.Name)
+		assert.Equal(t, []string{"application/json; profile=a2a-card-v1"}, accepts)
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		var accepts []string
+		server := profileRegistry(t, "refuse", &accepts)
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", CardProfile: CardProfileV1})
+
+		_, err := client.GetAgentCard("agent-1")
+		var profileErr *UnsupportedProfileError
+		require.ErrorAs(t, err, &profileErr)
+		assert.Equal(t, CardProfileV1, profileErr.Requested)
+		assert.Equal(t, CodeUnsupportedProfile, ErrorCode(err))
+		assert.Equal(t, http.StatusNotAcceptable, StatusCode(err))
+	})
+
+	t.Run("ignored falls back to migration", func(t *testing.T) {
+		var accepts []string
+		server := profileRegistry(t, "ignore", &accepts)
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", CardProfile: CardProfileV1})
+
+		card, err := client.GetAgentCard("agent-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Profiled Agent", card.Name)
+		assert.Nil(t, card.Signature)
+		assert.Nil(t, card.DefaultInputModes)
+		assert.Nil(t, card.DefaultOutputModes)
+		assert.Nil(t, card.Capabilities.SupportsAuthenticatedExtendedCard)
+		require.NotNil(t, card.Capabilities.Streaming)
+		assert.True(t, *card.Capabilities.Streaming)
+	})
+
+	t.Run("ignored without a migration", func(t *testing.T) {
+		var accepts []string
+		server := profileRegistry(t, "ignore", &accepts)
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+		_, err := client.GetAgentCard("agent-1", WithCardProfile("a2a-card-v0"))
+		var profileErr *UnsupportedProfileError
+		require.ErrorAs(t, err, &profileErr)
+		assert.Equal(t, CardProfile("a2a-card-v0"), profileErr.Requested)
+		assert.Empty(t, profileErr.Served)
+	})
+
+	t.Run("different profile served", func(t *testing.T) {
+		var accepts []string
+		server := profileRegistry(t, "other", &accepts)
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+		_, err := client.GetAgentCard("agent-1", WithCardProfile(CardProfileV1))
+		var profileErr *UnsupportedProfileError
+		require.ErrorAs(t, err, &profileErr)
+		assert.Equal(t, CardProfile("a2a-card-v0"), profileErr.Served)
+	})
+
+	t.Run("per-request override", func(t *testing.T) {
+		var accepts []string
+		server := profileRegistry(t, "honor", &accepts)
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+		card, err := client.GetAgentCard("agent-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Profiled Agent", card.Name)
+		card, err = client.GetAgentCard("agent-1", WithCardProfile(CardProfileV1))
+		require.NoError(t, err)
+		assert.Equal(t, "Profiled Agent v1", card.Name)
+		assert.NotContains(t, accepts[0], "profile=")
+	})
+}
+
+func TestGetAgentCard_ProfileCachedSeparately(t *testing.T) {
+	var calls atomic.Int32
+	var accepts []string
+	registry := profileRegistry(t, "honor", &accepts)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		calls.Add(1)
+		registry.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", CardCache: NewMemoryStore(0)})
+
+	for i := 0; i < 2; i++ {
+		card, err := client.GetAgentCard("agent-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Profiled Agent", card.Name)
+		card, err = client.GetAgentCard("agent-1", WithCardProfile(CardProfileV1))
+		require.NoError(t, err)
+		assert.Equal(t, "Profiled Agent v1", card.Name)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+
+	require.NoError(t, client.DeleteAgent("agent-1"))
+	_, err := client.GetAgentCard("agent-1", WithCardProfile(CardProfileV1))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGetExtendedAgentCard(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(currentCardJSON))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", CardCache: NewMemoryStore(0)})
+
+	for i := 0; i < 2; i++ {
+		card, err := client.GetExtendedAgentCard(context.Background(), "agent-1", WithCardProfile(CardProfileV1))
+		require.NoError(t, err)
+		assert.Nil(t, card.Signature)
+	}
+	assert.Equal(t, []string{"/agents/agent-1/card/extended", "/agents/agent-1/card/extended"}, paths)
+}
+
+func TestMigrateCard(t *testing.T) {
+	card := &AgentCardSpec{Name: "Agent", DefaultInputModes: []string{"text/plain"}}
+
+	migrated, err := MigrateCard(card, CardProfileV1)
+	require.NoError(t, err)
+	assert.Nil(t, migrated.DefaultInputModes)
+	assert.Equal(t, []string{"text/plain"}, card.DefaultInputModes, "the original is left alone")
+
+	same, err := MigrateCard(card, "")
+	require.NoError(t, err)
+	assert.Equal(t, card, same)
+
+	_, err = MigrateCard(card, "a2a-card-v9")
+	assert.Equal(t, CodeUnsupportedProfile, ErrorCode(err))
+}
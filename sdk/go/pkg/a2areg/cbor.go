@@ -0,0 +1,417 @@
+package a2areg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// CBORCodec encodes bodies as CBOR (RFC 8949). Objects become maps with
+// text keys in sorted order, integers CBOR integers and other numbers
+// float64s. Decoding follows the RFC's CBOR-to-JSON conversion: byte
+// strings become unpadded base64url text, tags other than bignums are
+// dropped in favor of their content, and NaN, the infinities and undefined
+// become null.
+type CBORCodec struct{}
+
+var _ Codec = CBORCodec{}
+
+// maxCBORDepth bounds the nesting of decoded CBOR documents.
+const maxCBORDepth = 512
+
+// ContentType returns "application/cbor".
+func (CBORCodec) ContentType() string { return "application/cbor" }
+
+// FromJSON converts a JSON document into CBOR.
+func (CBORCodec) FromJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeCBOR(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ToJSON converts a CBOR document into JSON.
+func (CBORCodec) ToJSON(data []byte) ([]byte, error) {
+	d := &cborDecoder{data: data}
+	var buf bytes.Buffer
+	if err := d.item(&buf, 0); err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.New("cbor: trailing data after document")
+	}
+	return buf.Bytes(), nil
+}
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// writeCBORHead writes the initial byte and argument of an item.
+func writeCBORHead(buf *bytes.Buffer, major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		buf.WriteByte(major | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+// writeCBOR encodes a value decoded from JSON with UseNumber.
+func writeCBOR(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case string:
+		writeCBORHead(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			if n >= 0 {
+				writeCBORHead(buf, cborUint, uint64(n))
+			} else {
+				writeCBORHead(buf, cborNegInt, uint64(-1-n))
+			}
+			return nil
+		}
+		if n, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			writeCBORHead(buf, cborUint, n)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("cbor: number %s: %w", v, err)
+		}
+		buf.WriteByte(cborSimple<<5 | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	case []interface{}:
+		writeCBORHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			if err := writeCBOR(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		writeCBORHead(buf, cborMap, uint64(len(v)))
+		for _, key := range keys {
+			writeCBORHead(buf, cborText, uint64(len(key)))
+			buf.WriteString(key)
+			if err := writeCBOR(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: unsupported value of type %T", value)
+	}
+	return nil
+}
+
+// cborDecoder converts a CBOR document into JSON.
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// indefinite marks an item of indefinite length.
+const indefinite = math.MaxUint64
+
+func (d *cborDecoder) byte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// head reads an item's major type, additional information and argument.
+// The argument is indefinite for indefinite-length items.
+func (d *cborDecoder) head() (major, info byte, arg uint64, err error) {
+	b, err := d.byte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		raw, err := d.take(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for _, b := range raw {
+			arg = arg<<8 | uint64(b)
+		}
+		return major, info, arg, nil
+	case info == 31 && major >= cborBytes && major <= cborMap:
+		return major, info, indefinite, nil
+	}
+	return 0, 0, 0, fmt.Errorf("cbor: invalid additional information %d for major type %d", info, major)
+}
+
+// isBreak consumes the break code ending an indefinite-length item.
+func (d *cborDecoder) isBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// str reads the content of a byte or text string of major type major,
+// joining the chunks of an indefinite-length one.
+func (d *cborDecoder) str(major byte, length uint64) ([]byte, error) {
+	if length != indefinite {
+		return d.take(length)
+	}
+	var joined []byte
+	for !d.isBreak() {
+		chunkMajor, _, chunkLength, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkLength == indefinite {
+			return nil, errors.New("cbor: invalid chunk in indefinite-length string")
+		}
+		chunk, err := d.take(chunkLength)
+		if err != nil {
+			return nil, err
+		}
+		joined = append(joined, chunk...)
+	}
+	return joined, nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s)
+	buf.Write(data)
+}
+
+func writeJSONFloat(buf *bytes.Buffer, f float64) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		buf.WriteString("null")
+		return
+	}
+	buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// item converts the next data item.
+func (d *cborDecoder) item(buf *bytes.Buffer, depth int) error {
+	if depth > maxCBORDepth {
+		return errors.New("cbor: document nested too deeply")
+	}
+	major, info, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case cborUint:
+		buf.WriteString(strconv.FormatUint(arg, 10))
+	case cborNegInt:
+		n := new(big.Int).SetUint64(arg)
+		buf.WriteString(n.Neg(n).Sub(n, big.NewInt(1)).String())
+	case cborBytes:
+		b, err := d.str(major, arg)
+		if err != nil {
+			return err
+		}
+		writeJSONString(buf, base64.RawURLEncoding.EncodeToString(b))
+	case cborText:
+		b, err := d.str(major, arg)
+		if err != nil {
+			return err
+		}
+		if !utf8.Valid(b) {
+			return errors.New("cbor: text string is not valid UTF-8")
+		}
+		writeJSONString(buf, string(b))
+	case cborArray:
+		buf.WriteByte('[')
+		for i := uint64(0); arg == indefinite || i < arg; i++ {
+			if arg == indefinite && d.isBreak() {
+				break
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := d.item(buf, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case cborMap:
+		buf.WriteByte('{')
+		for i := uint64(0); arg == indefinite || i < arg; i++ {
+			if arg == indefinite && d.isBreak() {
+				break
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := d.key(buf); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := d.item(buf, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case cborTag:
+		if arg == 2 || arg == 3 {
+			return d.bignum(buf, arg == 3)
+		}
+		return d.item(buf, depth+1)
+	case cborSimple:
+		return d.simple(buf, info, arg)
+	}
+	return nil
+}
+
+// key converts a map key: text as is, and integers as their decimal text.
+func (d *cborDecoder) key(buf *bytes.Buffer) error {
+	major, _, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case cborText:
+		b, err := d.str(major, arg)
+		if err != nil {
+			return err
+		}
+		if !utf8.Valid(b) {
+			return errors.New("cbor: map key is not valid UTF-8")
+		}
+		writeJSONString(buf, string(b))
+	case cborUint:
+		writeJSONString(buf, strconv.FormatUint(arg, 10))
+	case cborNegInt:
+		n := new(big.Int).SetUint64(arg)
+		writeJSONString(buf, n.Neg(n).Sub(n, big.NewInt(1)).String())
+	default:
+		return fmt.Errorf("cbor: unsupported map key of major type %d", major)
+	}
+	return nil
+}
+
+// bignum converts the byte string of a tag 2 or 3 bignum to a number.
+func (d *cborDecoder) bignum(buf *bytes.Buffer, negative bool) error {
+	major, _, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	if major != cborBytes {
+		return errors.New("cbor: bignum content is not a byte string")
+	}
+	b, err := d.str(major, arg)
+	if err != nil {
+		return err
+	}
+	n := new(big.Int).SetBytes(b)
+	if negative {
+		n.Neg(n).Sub(n, big.NewInt(1))
+	}
+	buf.WriteString(n.String())
+	return nil
+}
+
+// simple converts a major type 7 item: booleans, null and floats.
+func (d *cborDecoder) simple(buf *bytes.Buffer, info byte, arg uint64) error {
+	switch info {
+	case 20:
+		buf.WriteString("false")
+	case 21:
+		buf.WriteString("true")
+	case 22, 23:
+		buf.WriteString("null")
+	case 25:
+		writeJSONFloat(buf, halfToFloat(uint16(arg)))
+	case 26:
+		writeJSONFloat(buf, float64(math.Float32frombits(uint32(arg))))
+	case 27:
+		writeJSONFloat(buf, math.Float64frombits(arg))
+	default:
+		return fmt.Errorf("cbor: unsupported simple value %d", arg)
+	}
+	return nil
+}
+
+// halfToFloat converts an IEEE 754 half-precision float.
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
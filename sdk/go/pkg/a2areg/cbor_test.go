@@ -0,0 +1,151 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBORCodec_RoundTrip(t *testing.T) {
+	codec := CBORCodec{}
+	for _, doc := range []string{
+		`null`,
+		`{"name": "Café ☕ Agent 🤖", "tags": ["日本語", "", "a\"b\\c\n<&>"], "count": 0, "neg": -1}`,
+		`{"big": 18446744073709551615, "min": -9223372036854775808, "pi": 3.14159, "tiny": 1e-300, "huge": 1.5e300}`,
+		`{"nested": {"a": [1, [2, [3, {"b": null}]]], "t": true, "f": false}, "empty": {}, "list": []}`,
+		`{"blob": "AAECA/7/", "long": "` + strings.Repeat("x", 70000) + `"}`,
+	} {
+		encoded, err := codec.FromJSON([]byte(doc))
+		require.NoError(t, err, doc)
+		decoded, err := codec.ToJSON(encoded)
+		require.NoError(t, err, doc)
+		assert.JSONEq(t, doc, string(decoded))
+	}
+}
+
+func TestCBORCodec_Decode(t *testing.T) {
+	for _, tc := range []struct {
+		cbor string
+		json string
+	}{
+		{"1903e8", `1000`},
+		{"3903e7", `-1000`},
+		{"3bffffffffffffffff", `-18446744073709551616`},
+		{"c249010000000000000000", `18446744073709551616`},
+		{"f93c00", `1`},
+		{"f9c400", `-4`},
+		{"fa47c35000", `100000`},
+		{"f97c00", `null`},
+		{"f7", `null`},
+		{"4401020304", `"AQIDBA"`},
+		{"7f657374726561646d696e67ff", `"streaming"`},
+		{"9f018202039f0405ffff", `[1,[2,3],[4,5]]`},
+		{"bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		{"a201020304", `{"1":2,"3":4}`},
+		{"c074323031332d30332d32315432303a30343a30305a", `"2013-03-21T20:04:00Z"`},
+	} {
+		data, err := hex.DecodeString(tc.cbor)
+		require.NoError(t, err)
+		decoded, err := CBORCodec{}.ToJSON(data)
+		require.NoError(t, err, tc.cbor)
+		assert.JSONEq(t, tc.json, string(decoded), tc.cbor)
+	}
+
+	for _, bad := range []string{"", "19", "62ff", "a1f5f5", "8301", "0102", "62c328"} {
+		data, _ := hex.DecodeString(bad)
+		_, err := CBORCodec{}.ToJSON(data)
+		assert.Error(t, err, bad)
+	}
+}
+
+// cborRegistry stores one agent and answers in CBOR when asked to, unless
+// jsonOnly is set.
+func cborRegistry(t *testing.T, jsonOnly bool) *httptest.Server {
+	codec := CBORCodec{}
+	var stored []byte
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := func(status int, body string) {
+			if !jsonOnly && strings.Contains(r.Header.Get("Accept"), "application/cbor") && status < 400 {
+				encoded, err := codec.FromJSON([]byte(body))
+				require.NoError(t, err)
+				w.Header().Set("Content-Type", "application/cbor")
+				w.WriteHeader(status)
+				w.Write(encoded)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			io.WriteString(w, body)
+		}
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/agents/publish":
+			data, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			if r.Header.Get("Content-Type") == "application/cbor" {
+				data, err = codec.ToJSON(data)
+				require.NoError(t, err)
+			}
+			var req struct {
+				Card json.RawMessage `json:"card"`
+			}
+			require.NoError(t, json.Unmarshal(data, &req))
+			stored = req.Card
+			reply(http.StatusCreated, `{"agentId": "agent-1"}`)
+		case r.URL.Path == "/agents/agent-1":
+			var card map[string]interface{}
+			require.NoError(t, json.Unmarshal(stored, &card))
+			agent, _ := json.Marshal(map[string]interface{}{
+				"id": "agent-1", "name": card["name"], "description": card["description"],
+				"version": card["version"], "provider": "acme", "agent_card": card,
+			})
+			reply(http.StatusOK, string(agent))
+		default:
+			reply(http.StatusNotFound, `{"detail": "Agent archived", "code": "agent_archived"}`)
+		}
+	}))
+}
+
+func TestWireFormat_AgentRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		format   WireFormat
+		jsonOnly bool
+	}{
+		{"json", WireFormatJSON, false},
+		{"cbor", WireFormatCBOR, false},
+		{"cbor against a JSON-only registry", WireFormatCBOR, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := cborRegistry(t, tc.jsonOnly)
+			defer server.Close()
+			client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", WireFormat: tc.format})
+
+			agent := testPublishAgent()
+			agent.Name = "Café ☕ Agent 🤖"
+			agent.Description = "Handles \x00-free binary-ish text: ÿþ <&> \"quoted\" \\ 日本語"
+			_, err := client.PublishAgentVerbose(context.Background(), agent, PublishOptions{SkipNormalizationCheck: true})
+			require.NoError(t, err)
+
+			got, err := client.GetAgent("agent-1")
+			require.NoError(t, err)
+			assert.Equal(t, agent.Name, got.Name)
+			assert.Equal(t, agent.Description, got.Description)
+			require.NotNil(t, got.AgentCard)
+			assert.Equal(t, agent.Skills[0].Tags, got.AgentCard.Skills[0].Tags)
+
+			_, err = client.GetAgent("missing")
+			var notFound *NotFoundError
+			require.ErrorAs(t, err, &notFound, "JSON errors decode in every format")
+			assert.Equal(t, "agent_archived", ErrorCode(err))
+		})
+	}
+}
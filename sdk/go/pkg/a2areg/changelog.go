@@ -0,0 +1,57 @@
+package a2areg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangelogEntry is one release in an agent's changelog.
+type ChangelogEntry struct {
+	Version string `json:"version"`
+	// Date is the release date, as YYYY-MM-DD.
+	Date    string `json:"date,omitempty"`
+	Summary string `json:"summary"`
+}
+
+// WithChangeReason records why a change is made. The reason is sent in the
+// X-Change-Reason header, which the registry keeps in its audit log. Line
+// breaks are replaced by spaces, as headers cannot hold them.
+func WithChangeReason(reason string) RequestOption {
+	return func(o *requestOptions) {
+		o.changeReason = strings.Join(strings.Fields(reason), " ")
+	}
+}
+
+// checkChangelog rejects changelog entries without a version or summary
+// and versions that do not increase from one entry to the next.
+func checkChangelog(changelog []ChangelogEntry) error {
+	for i, entry := range changelog {
+		if entry.Version == "" {
+			return NewValidationError(fmt.Sprintf("Changelog entry %d missing required field: version", i), nil)
+		}
+		if entry.Summary == "" {
+			return NewValidationError(fmt.Sprintf("Changelog entry %d missing required field: summary", i), nil)
+		}
+		if i > 0 && compareVersions(changelog[i-1].Version, entry.Version) >= 0 {
+			return NewValidationError(
+				fmt.Sprintf("Changelog versions must increase: entry %d (%s) does not follow %s", i, entry.Version, changelog[i-1].Version),
+				map[string]interface{}{"index": i, "version": entry.Version, "previous": changelog[i-1].Version},
+			)
+		}
+	}
+	return nil
+}
+
+// changelogWarning returns a warning when agent keeps a changelog that has
+// no entry for the version being published, or "" otherwise.
+func changelogWarning(agent *Agent) string {
+	if len(agent.Changelog) == 0 {
+		return ""
+	}
+	for _, entry := range agent.Changelog {
+		if entry.Version == agent.Version {
+			return ""
+		}
+	}
+	return fmt.Sprintf("Changelog has no entry for version %s", agent.Version)
+}
@@ -0,0 +1,143 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditRegistry records the X-Change-Reason header and body of each
+// mutation and answers with a stored agent carrying a changelog.
+type auditRegistry struct {
+	*httptest.Server
+	mu      sync.Mutex
+	reasons map[string]string
+	bodies  map[string]map[string]interface{}
+}
+
+func newAuditRegistry(t *testing.T) *auditRegistry {
+	r := &auditRegistry{reasons: map[string]string{}, bodies: map[string]map[string]interface{}{}}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		route := req.Method + " " + req.URL.Path
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		r.mu.Lock()
+		r.reasons[route] = req.Header.Get("X-Change-Reason")
+		r.bodies[route] = body
+		r.mu.Unlock()
+
+		switch req.Method {
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Write([]byte(`{"id": "agent-1", "name": "Recipe Agent", "version": "1.1.0", "changelog": [
+				{"version": "1.0.0", "date": "2026-01-10", "summary": "First release"},
+				{"version": "1.1.0", "date": "2026-03-02", "summary": "Adds meal plans"}
+			]}`))
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *auditRegistry) reason(route string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reasons[route]
+}
+
+func TestWithChangeReason_Header(t *testing.T) {
+	registry := newAuditRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	_, err := client.PublishAgent(testPublishAgent(), false, WithChangeReason("initial listing"))
+	require.NoError(t, err)
+	assert.Equal(t, "initial listing", registry.reason("POST /agents/publish"))
+
+	_, err = client.UpdateAgent("agent-1", testPublishAgent(), WithChangeReason("fix typo\nin description"))
+	require.NoError(t, err)
+	assert.Equal(t, "fix typo in description", registry.reason("PUT /agents/agent-1"))
+
+	require.NoError(t, client.DeleteAgent("agent-1", WithChangeReason("retired")))
+	assert.Equal(t, "retired", registry.reason("DELETE /agents/agent-1"))
+
+	_, err = client.UpdateAgent("agent-1", testPublishAgent())
+	require.NoError(t, err)
+	assert.Empty(t, registry.reason("PUT /agents/agent-1"))
+}
+
+func TestChangelog_PayloadAndGetAgent(t *testing.T) {
+	registry := newAuditRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agent := testPublishAgent()
+	agent.Version = "1.1.0"
+	agent.Changelog = []ChangelogEntry{
+		{Version: "1.0.0", Date: "2026-01-10", Summary: "First release"},
+		{Version: "1.1.0", Date: "2026-03-02", Summary: "Adds meal plans"},
+	}
+	_, err := client.UpdateAgent("agent-1", agent)
+	require.NoError(t, err)
+	sent := registry.bodies["PUT /agents/agent-1"]["changelog"].([]interface{})
+	require.Len(t, sent, 2)
+	assert.Equal(t, map[string]interface{}{"version": "1.1.0", "date": "2026-03-02", "summary": "Adds meal plans"}, sent[1])
+
+	fetched, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	require.Len(t, fetched.Changelog, 2)
+	assert.Equal(t, ChangelogEntry{Version: "1.0.0", Date: "2026-01-10", Summary: "First release"}, fetched.Changelog[0])
+}
+
+func TestChangelog_MissingEntryWarns(t *testing.T) {
+	registry := newAuditRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agent := testPublishAgent()
+	agent.Version = "1.2.0"
+	agent.Changelog = []ChangelogEntry{{Version: "1.1.0", Summary: "Adds meal plans"}}
+	receipt, err := client.PublishAgentVerbose(context.Background(), agent, PublishOptions{Validate: true, SkipNormalizationCheck: true})
+	require.NoError(t, err)
+	assert.Contains(t, receipt.Warnings, "Changelog has no entry for version 1.2.0")
+
+	agent.Changelog = append(agent.Changelog, ChangelogEntry{Version: "1.2.0", Summary: "Faster search"})
+	receipt, err = client.PublishAgentVerbose(context.Background(), agent, PublishOptions{SkipNormalizationCheck: true})
+	require.NoError(t, err)
+	assert.Empty(t, receipt.Warnings)
+}
+
+func TestChangelog_Validation(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{})
+	tests := []struct {
+		name      string
+		changelog []ChangelogEntry
+		want      string
+	}{
+		{"decreasing", []ChangelogEntry{{Version: "1.10.0", Summary: "a"}, {Version: "1.9.0", Summary: "b"}}, "entry 1 (1.9.0) does not follow 1.10.0"},
+		{"repeated", []ChangelogEntry{{Version: "1.0.0", Summary: "a"}, {Version: "1.0.0", Summary: "b"}}, "Changelog versions must increase"},
+		{"no version", []ChangelogEntry{{Summary: "a"}}, "Changelog entry 0 missing required field: version"},
+		{"no summary", []ChangelogEntry{{Version: "1.0.0"}}, "Changelog entry 0 missing required field: summary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := testPublishAgent()
+			agent.Changelog = tt.changelog
+			err := client.ValidateAgent(agent)
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Contains(t, err.Error(), tt.want)
+
+			_, err = client.UpdateAgent("agent-1", agent)
+			assert.ErrorAs(t, err, &validationErr)
+		})
+	}
+
+	agent := testPublishAgent()
+	agent.Changelog = []ChangelogEntry{{Version: "1.9.0", Summary: "a"}, {Version: "1.10.0", Summary: "b"}}
+	assert.NoError(t, client.ValidateAgent(agent))
+}
@@ -1,14 +1,20 @@
 package a2areg
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"a2areg/internal/clock"
 )
 
 // A2ARegClientOptions contains configuration options for A2ARegClient.
@@ -18,36 +24,281 @@ type A2ARegClientOptions struct {
 	ClientSecret string
 	Timeout      time.Duration
 	APIKey       string
+	// APIKeyHeader is the header APIKey is sent in. Defaults to X-API-Key;
+	// "Authorization" sends the key as a Bearer token instead.
 	APIKeyHeader string
 	Scope        string
+	// MaxRequestBytes caps the size of marshaled request bodies. Zero uses
+	// DefaultMaxRequestBytes (the registry's own limit); negative disables it.
+	MaxRequestBytes int64
+	// ReadinessTTL bounds how old a cached health result used by
+	// ReadinessCheck may be. Zero uses DefaultReadinessTTL.
+	ReadinessTTL time.Duration
+	// Clock overrides the time source used for token expiry, cache TTLs,
+	// and backoff. Nil uses the wall clock; tests can supply the fake clock
+	// from the a2aregtest package.
+	Clock Clock
+	// CardCache, when set, caches agent cards fetched by GetAgentCard. Use a
+	// FileStore to keep the cache warm across restarts.
+	CardCache Store
+	// CardCacheTTL is how long cached cards are served. Zero uses
+	// DefaultCardCacheTTL.
+	CardCacheTTL time.Duration
+	// CardProfile is the card schema revision GetAgentCard and
+	// GetExtendedAgentCard ask for. Empty takes the registry's current
+	// schema.
+	CardProfile CardProfile
+	// HTTPClient is used for every registry request, including the OAuth
+	// token request. It is copied, not modified; Timeout applies to the
+	// copy when the client sets none. Defaults to a new http.Client.
+	HTTPClient *http.Client
+	// Transport, when set, replaces the transport of HTTPClient (or of the
+	// default client), for example to add mTLS client certificates, a proxy
+	// or pool tuning.
+	Transport http.RoundTripper
+	// TLSConfig is the base TLS configuration for registry connections. It
+	// is cloned, never modified. TLSConfig, PinnedCertificates,
+	// HostOverrides and Resolver can only be applied to an *http.Transport;
+	// with a custom RoundTripper of another type they are a configuration
+	// error (see Validate).
+	TLSConfig *tls.Config
+	// PinnedCertificates are base64 SHA-256 hashes of the SubjectPublicKeyInfo
+	// of trusted registry certificates (see SPKIPin). When set, a connection
+	// is accepted only if a certificate in the verified chain matches one of
+	// the pins, in addition to normal CA validation. List several pins to
+	// rotate keys without downtime. The pins apply to the registry only:
+	// agent cards fetched from agent hosts are not checked against them.
+	PinnedCertificates []string
+	// HostOverrides maps host names, or host:port pairs, to the address to
+	// connect to instead ("10.0.0.5:443", or an IP alone to keep the port),
+	// like an /etc/hosts entry scoped to this client. TLS still uses and
+	// verifies the original host name. Overrides apply to every request the
+	// client makes, including the OAuth token request.
+	HostOverrides map[string]string
+	// Resolver, when set, resolves host names that HostOverrides does not
+	// cover, in place of the system resolver. It replaces the DialContext
+	// of an injected transport.
+	Resolver *net.Resolver
+	// SafeDelete makes DeleteAgent a two-phase operation: it returns a
+	// DeleteConfirmationRequiredError, and the agent is only deleted by a
+	// subsequent ConfirmDelete. See PrepareDelete.
+	SafeDelete bool
+	// DeterministicIDs publishes every agent under DeriveAgentID(provider,
+	// name), so re-publishing the same agent updates it instead of creating
+	// a duplicate. ValidateAgent then rejects an agent whose ID is set but
+	// differs from the derived one.
+	DeterministicIDs bool
+	// IDStrategy chooses the IDs agents are published under; see
+	// IDStrategy. It takes precedence over DeterministicIDs, which selects
+	// DerivedSlug. Defaults to ServerAssigned.
+	IDStrategy IDStrategy
+	// AllowUnknownTransports makes ValidateAgent and ValidateAgentCard
+	// accept transports other than TransportJSONRPC, TransportGRPC and
+	// TransportHTTP, for registries that support more.
+	AllowUnknownTransports bool
+	// SecuritySchemesAsArray sends the security schemes of published cards
+	// as a JSON array ordered by name, for older registries that do not
+	// accept the object form. Both forms are always accepted in responses.
+	SecuritySchemesAsArray bool
+	// SearchFallback makes Search and SearchAgents emulate search on
+	// registries that do not provide /agents/search, by scanning the agent
+	// listings and matching client-side, filters included. Fallback results
+	// have SearchResponse.Fallback and DegradedAccuracy set ("fallback" and
+	// "degraded_accuracy" in SearchAgents results) and are not cached.
+	SearchFallback bool
+	// MaxFallbackPages bounds the listing pages a fallback search reads.
+	// Defaults to DefaultMaxFallbackPages.
+	MaxFallbackPages int
+	// EnableOfflineQueue queues publishes, updates and deletes that fail
+	// with a transport error or a 5xx in OfflineQueue, and replays them in
+	// order in the background once the registry is reachable again. The
+	// failing call returns a *QueuedError.
+	EnableOfflineQueue bool
+	// OfflineQueue persists queued operations. Use a FileStore for a queue
+	// that survives restarts. Defaults to an in-memory store.
+	OfflineQueue Store
+	// OfflineQueueTTL is how long an operation may wait in the queue before
+	// it is dropped. Defaults to DefaultOfflineQueueTTL.
+	OfflineQueueTTL time.Duration
+	// OfflineRetryInterval is the delay between replay attempts. Defaults
+	// to DefaultOfflineRetryInterval.
+	OfflineRetryInterval time.Duration
+	// OnDropped is called for each queued operation that is discarded,
+	// either because it expired or because the registry rejected it on
+	// replay. It runs on the replaying goroutine.
+	OnDropped func(op QueuedOperation, err error)
+	// TagTaxonomy restricts agent and skill tags to a controlled
+	// vocabulary. When set, ValidateAgent rejects unknown tags (suggesting
+	// the closest allowed tag) and publishing rewrites deprecated aliases.
+	TagTaxonomy *TagTaxonomy
+	// WarmupOnStart runs Warmup in a background goroutine as soon as the
+	// client is constructed.
+	WarmupOnStart bool
+	// AllowedAgentHosts restricts the hosts an agent's URLs may point at.
+	// Entries are host names or IP literals, optionally with a port the
+	// URL must use, or "*.example.com" to allow any subdomain.
+	// ValidateAgent and PublishAgent reject agents advertising any other
+	// host. Empty disables the check.
+	AllowedAgentHosts []string
+	// MaxSearchParallelism bounds the concurrent searches SearchAgentsBatch
+	// runs when the registry has no batch endpoint. Defaults to
+	// DefaultSearchParallelism.
+	MaxSearchParallelism int
+	// OnMaintenance is called with the end of the window whenever the
+	// registry reports maintenance mode and the window is new or extended.
+	// It runs on the goroutine that received the response.
+	OnMaintenance func(until time.Time)
+	// OnCacheEvent is called for every card and search cache hit, miss,
+	// store and eviction. Events carry a hash of the cache key, never the
+	// key itself. It runs on the goroutine that touched the cache, so it
+	// must be quick.
+	OnCacheEvent func(CacheEvent)
+	// SkillLimits bounds skill examples and lists extra input/output mode
+	// aliases accepted by ValidateAgent.
+	SkillLimits SkillLimits
+	// NameRules are the agent name rules ValidateAgent enforces. The zero
+	// value matches the registry's defaults.
+	NameRules NameRules
+	// EnableSearchCache caches Search results in SearchCache. Requests are
+	// normalized first, so "Weather" and " weather" share an entry, as do
+	// filters listing the same values in another order. Any publish,
+	// update or delete made through the client empties the cache. Set
+	// SearchRequest.NoCache to bypass it.
+	EnableSearchCache bool
+	// SearchCache stores cached searches. Defaults to an in-memory store
+	// holding DefaultSearchCacheEntries results.
+	SearchCache Store
+	// SearchCacheTTL is how long lexical search results are cached.
+	// Defaults to DefaultSearchCacheTTL.
+	SearchCacheTTL time.Duration
+	// SemanticSearchCacheTTL is how long semantic search results are
+	// cached. Defaults to DefaultSemanticSearchCacheTTL.
+	SemanticSearchCacheTTL time.Duration
+	// AuthPreference decides which credential is used when both APIKey
+	// and ClientID/ClientSecret are set. Defaults to AuthAPIKeyFirst.
+	AuthPreference AuthPreference
+	// Logger receives the client's diagnostic messages, such as an
+	// AuthOAuthFirst client falling back to its API key. Nil discards them.
+	Logger *slog.Logger
+	// DeprecationWarnings makes the first call in the process to each
+	// deprecated method log a warning through Logger, naming its
+	// replacement.
+	DeprecationWarnings bool
+	// WireFormat selects the encoding of requests and responses. Defaults
+	// to WireFormatJSON. Responses the registry sends as JSON anyway, such
+	// as most errors, are always accepted.
+	WireFormat WireFormat
+	// Codec, when set, replaces the codec WireFormat selects.
+	Codec Codec
+	// TokenRefreshWindow is how long before the access token expires
+	// StartAutoRefresh replaces it, at most half the token's lifetime.
+	// Defaults to DefaultTokenRefreshWindow.
+	TokenRefreshWindow time.Duration
+	// OnRefreshError is called with each failure of a StartAutoRefresh
+	// refresh. It runs on the refresh goroutine.
+	OnRefreshError func(error)
+	// RevokeOnClose makes CloseContext revoke the OAuth access token at
+	// the registry's /auth/oauth/revoke.
+	RevokeOnClose bool
 }
 
+// SDKVersion is the version of this SDK, sent in the User-Agent header.
+const SDKVersion = "1.0.0"
+
+// DefaultMaxRequestBytes matches the registry's maximum accepted body size.
+const DefaultMaxRequestBytes = 1 << 20
+
 // DefaultOptions returns default options for A2ARegClient.
 func DefaultOptions() A2ARegClientOptions {
 	return A2ARegClientOptions{
-		RegistryURL:  "http://localhost:8000",
-		Timeout:      30 * time.Second,
-		APIKeyHeader: "X-API-Key",
-		Scope:        "read write",
+		RegistryURL:     "http://localhost:8000",
+		Timeout:         30 * time.Second,
+		APIKeyHeader:    "X-API-Key",
+		Scope:           "read write",
+		MaxRequestBytes: DefaultMaxRequestBytes,
 	}
 }
 
+// tokenState holds the OAuth access token. Clients derived from a pooled
+// client share it with their base (see ClientPool).
+type tokenState struct {
+	mu          sync.RWMutex // guards accessToken, issuedAt and expiresAt
+	accessToken SecretBox
+	issuedAt    time.Time
+	expiresAt   *time.Time
+}
+
 // A2ARegClient is the main client for interacting with the A2A Registry.
 type A2ARegClient struct {
-	registryURL    string
-	clientID       string
-	clientSecret   string
-	timeout        time.Duration
-	apiKey         string
-	apiKeyHeader   string
-	scope          string
-	httpClient     *http.Client
-	accessToken    string
-	tokenExpiresAt *time.Time
+	registryURL            string
+	clientID               string
+	clientSecret           SecretBox
+	timeout                time.Duration
+	apiKey                 SecretBox
+	apiKeyHeader           atomic.Value // string; see SetAPIKeyHeader
+	scope                  string
+	httpClient             *http.Client
+	cardHTTPClient         *http.Client // fetches agent cards; see newCardHTTPClient
+	tokens                 *tokenState
+	maxRequestBytes        int64
+	readiness              readinessState
+	closed                 atomic.Bool
+	authsInFlight          authsInFlight
+	clock                  Clock
+	cardCache              Store
+	cardCacheTTL           time.Duration
+	cardProfile            CardProfile
+	safeDelete             bool
+	pendingDeletes         pendingDeletes
+	deterministicIDs       bool
+	defaultIDStrategy      IDStrategy
+	allowUnknownTransports bool
+	securitySchemesAsArray bool
+	searchFallback         bool
+	maxFallbackPages       int
+	searchUnavailableAt    atomic.Int64 // UnixNano when /agents/search was last found absent, 0 if not
+	activationUnavailable  atomic.Bool  // set once /agents/{id}/activate and /deactivate are known to be absent
+	patchUnavailable       atomic.Bool  // set once PATCH /agents/{id} is known to be absent
+	dryRunUnavailable      atomic.Bool  // set once publish is known to reject validate_only
+	batchSearchUnavailable atomic.Bool  // set once /agents/search/batch is known to be absent
+	agentsBatchUnavailable atomic.Bool  // set once /agents/batch is known to be absent
+	authPreference         AuthPreference
+	authFallback           atomic.Bool // set once an AuthOAuthFirst client uses its API key
+	configErr              error       // from A2ARegClientOptions.Validate; fails every call
+	logger                 *slog.Logger
+	deprecationWarnings    bool
+	searchParallelism      int
+	maintenanceUntil       atomic.Int64 // unix nanos; requests fail fast before this
+	rateLimits             rateLimits
+	onMaintenance          func(until time.Time)
+	skillLimits            SkillLimits
+	nameRules              NameRules
+	searchCache            *searchCache
+	queue                  *offlineQueue
+	tagTaxonomy            *TagTaxonomy
+	allowedHosts           *hostAllowlist
+	warmup                 warmupState
+	capabilities           capabilitiesState
+	lastCall               atomic.Pointer[CallInfo]
+	cardCacheCounters      cacheCounters
+	codec                  Codec // nil for plain JSON
+	favorites              favoritesState
+	autoRefresh            autoRefreshState
+	tokenRefreshWindow     time.Duration
+	onRefreshError         func(error)
+	revokeOnClose          bool
 }
 
 // NewA2ARegClient creates a new A2ARegClient with the given options.
 func NewA2ARegClient(opts A2ARegClientOptions) *A2ARegClient {
+	c := newClient(opts)
+	c.start(opts)
+	return c
+}
+
+// newClient builds the client for opts without starting any of its
+// goroutines, so that callers can finish wiring it first; see start.
+func newClient(opts A2ARegClientOptions) *A2ARegClient {
 	if opts.RegistryURL == "" {
 		opts.RegistryURL = "http://localhost:8000"
 	}
@@ -60,39 +311,240 @@ func NewA2ARegClient(opts A2ARegClientOptions) *A2ARegClient {
 	if opts.Scope == "" {
 		opts.Scope = "read write"
 	}
+	if opts.MaxRequestBytes == 0 {
+		opts.MaxRequestBytes = DefaultMaxRequestBytes
+	}
+	if opts.ReadinessTTL == 0 {
+		opts.ReadinessTTL = DefaultReadinessTTL
+	}
+	if opts.TokenRefreshWindow == 0 {
+		opts.TokenRefreshWindow = DefaultTokenRefreshWindow
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	if opts.MaxFallbackPages == 0 {
+		opts.MaxFallbackPages = DefaultMaxFallbackPages
+	}
+	if opts.MaxSearchParallelism <= 0 {
+		opts.MaxSearchParallelism = DefaultSearchParallelism
+	}
+	if opts.CardCacheTTL == 0 {
+		opts.CardCacheTTL = DefaultCardCacheTTL
+	}
+	if opts.Logger == nil {
+		opts.Logger = discardLogger
+	}
 
 	registryURL := strings.TrimSuffix(opts.RegistryURL, "/")
 
-	return &A2ARegClient{
-		registryURL:  registryURL,
-		clientID:     opts.ClientID,
-		clientSecret: opts.ClientSecret,
-		timeout:      opts.Timeout,
-		apiKey:       opts.APIKey,
-		apiKeyHeader: opts.APIKeyHeader,
-		scope:        opts.Scope,
-		httpClient: &http.Client{
-			Timeout: opts.Timeout,
-		},
+	c := &A2ARegClient{
+		registryURL:            registryURL,
+		clientID:               opts.ClientID,
+		timeout:                opts.Timeout,
+		scope:                  opts.Scope,
+		maxRequestBytes:        opts.MaxRequestBytes,
+		readiness:              readinessState{ttl: opts.ReadinessTTL},
+		clock:                  opts.Clock,
+		cardCache:              opts.CardCache,
+		cardCacheTTL:           opts.CardCacheTTL,
+		cardProfile:            opts.CardProfile,
+		safeDelete:             opts.SafeDelete,
+		deterministicIDs:       opts.DeterministicIDs,
+		defaultIDStrategy:      idStrategyFor(opts),
+		allowUnknownTransports: opts.AllowUnknownTransports,
+		securitySchemesAsArray: opts.SecuritySchemesAsArray,
+		searchFallback:         opts.SearchFallback,
+		maxFallbackPages:       opts.MaxFallbackPages,
+		searchParallelism:      opts.MaxSearchParallelism,
+		onMaintenance:          opts.OnMaintenance,
+		skillLimits:            opts.SkillLimits.withDefaults(),
+		nameRules:              opts.NameRules.withDefaults(),
+		tagTaxonomy:            opts.TagTaxonomy,
+		allowedHosts:           newHostAllowlist(opts.AllowedAgentHosts),
+		httpClient:             newHTTPClient(opts),
+		tokens:                 &tokenState{},
+		authPreference:         opts.AuthPreference,
+		configErr:              opts.Validate(),
+		logger:                 opts.Logger,
+		deprecationWarnings:    opts.DeprecationWarnings,
+		codec:                  codecFor(opts),
+		tokenRefreshWindow:     opts.TokenRefreshWindow,
+		onRefreshError:         opts.OnRefreshError,
+		revokeOnClose:          opts.RevokeOnClose,
+	}
+	c.cardHTTPClient = c.httpClient
+	if len(opts.PinnedCertificates) > 0 {
+		c.cardHTTPClient = newCardHTTPClient(opts)
+	}
+	c.clientSecret.Set(opts.ClientSecret)
+	c.apiKey.Set(opts.APIKey)
+	c.apiKeyHeader.Store(opts.APIKeyHeader)
+	c.cardCacheCounters.init(CacheCard, opts.OnCacheEvent)
+	if opts.EnableSearchCache {
+		c.searchCache = newSearchCache(opts)
+	}
+	if opts.EnableOfflineQueue {
+		c.queue = newOfflineQueue(opts)
+	}
+	return c
+}
+
+// start starts the goroutines opts asks for: the offline queue worker when
+// operations were restored, and WarmupOnStart.
+func (c *A2ARegClient) start(opts A2ARegClientOptions) {
+	if c.queue != nil && c.queue.len() > 0 {
+		c.startQueueWorker()
+	}
+	if opts.WarmupOnStart {
+		go c.Warmup(context.Background())
 	}
 }
 
-// SetAPIKey sets the API key for authentication.
+// SetAPIKey sets the API key for authentication. The client keeps its own
+// copy; the previous key is zeroed.
 func (c *A2ARegClient) SetAPIKey(apiKey string) {
-	c.apiKey = apiKey
+	c.apiKey.Set(apiKey)
+}
+
+// Close is CloseContext with a background context.
+func (c *A2ARegClient) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext marks the client closed, stops its StartAutoRefresh
+// goroutine, closes its idle connections and zeroes its API key, client
+// secret and access token. With RevokeOnClose the access token is first
+// revoked at the registry; the client is closed even if that fails, and
+// the failure is returned.
+//
+// Later requests fail with CodeClientClosed; requests already in flight
+// complete. Only the first call does anything, so closing twice, or from
+// several goroutines, is safe. Clients from a ClientPool are closed by
+// their release function instead.
+func (c *A2ARegClient) CloseContext(ctx context.Context) error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	var err error
+	if c.revokeOnClose {
+		err = c.revokeToken(ctx)
+	}
+	c.shutdown()
+	return err
+}
+
+// revokeToken revokes the access token at the registry (RFC 7009). It does
+// nothing when the client holds no token.
+func (c *A2ARegClient) revokeToken(ctx context.Context) error {
+	token := c.token()
+	if token == "" {
+		return nil
+	}
+	data := url.Values{}
+	data.Set("token", token)
+	data.Set("token_type_hint", "access_token")
+	data.Set("client_id", c.clientID)
+	c.clientSecret.Use(func(secret []byte) {
+		data.Set("client_secret", string(secret))
+	})
+
+	revokeURL, err := NormalizeEndpoint(c.registryURL, "/auth/oauth/revoke")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", revokeURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return withCode(NewAuthenticationError("Failed to create request", map[string]interface{}{"error": err.Error()}), CodeInvalidRequest)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return withCode(NewAuthenticationError("Token revocation failed", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		revokeErr := withCode(NewAuthenticationError("Token revocation failed", map[string]interface{}{"status_code": resp.StatusCode}), CodeAuthFailed)
+		revokeErr.setStatusCode(resp.StatusCode)
+		return revokeErr
+	}
+	return nil
+}
+
+// wipeCredentials zeroes the secrets the client holds itself. The access
+// token may be shared with pooled clients and is wiped separately.
+func (c *A2ARegClient) wipeCredentials() {
+	c.apiKey.Wipe()
+	c.clientSecret.Wipe()
+}
+
+// SetAPIKeyHeader sets the header the API key is sent in. "Authorization"
+// sends it as a Bearer token; an empty header restores the X-API-Key
+// default.
+func (c *A2ARegClient) SetAPIKeyHeader(header string) {
+	if header == "" {
+		header = "X-API-Key"
+	}
+	c.apiKeyHeader.Store(header)
+}
+
+// apiKeyHeaderName returns the header the API key is sent in. It may be
+// changed by SetAPIKeyHeader while requests are in flight.
+func (c *A2ARegClient) apiKeyHeaderName() string {
+	return c.apiKeyHeader.Load().(string)
+}
+
+// useAPIKey puts the client's API key in h, reporting false when the
+// client has none.
+func (c *A2ARegClient) useAPIKey(h http.Header) bool {
+	var ok bool
+	c.apiKey.Use(func(key []byte) {
+		if len(key) > 0 {
+			c.setAPIKeyHeader(h, string(key))
+			ok = true
+		}
+	})
+	return ok
+}
+
+// setAPIKeyHeader puts apiKey in h under the configured API key header.
+func (c *A2ARegClient) setAPIKeyHeader(h http.Header, apiKey string) {
+	header := c.apiKeyHeaderName()
+	if strings.EqualFold(header, "Authorization") {
+		h.Set("Authorization", "Bearer "+apiKey)
+		return
+	}
+	h.Set(header, apiKey)
 }
 
 // Authenticate authenticates with the A2A registry using OAuth 2.0 client credentials flow.
 func (c *A2ARegClient) Authenticate(scope ...string) error {
-	// If API key is set, skip OAuth
-	if c.apiKey != "" {
+	return c.authenticate(context.Background(), scope...)
+}
+
+// authenticate is Authenticate bound to ctx.
+func (c *A2ARegClient) authenticate(ctx context.Context, scope ...string) error {
+	if c.closed.Load() {
+		return errClientClosed()
+	}
+	if c.configErr != nil {
+		return c.configErr
+	}
+
+	// If the API key is in use, skip OAuth
+	if c.usingAPIKey() {
 		return nil
 	}
 
-	if c.clientID == "" || c.clientSecret == "" {
-		return NewAuthenticationError("Client ID and secret are required for authentication", nil)
+	if c.clientID == "" || c.clientSecret.Empty() {
+		return withCode(NewAuthenticationError("Client ID and secret are required for authentication", nil), CodeAuthMissingCredentials)
 	}
 
+	defer c.authsInFlight.begin(c.clock.Now())()
+
 	authScope := c.scope
 	if len(scope) > 0 && scope[0] != "" {
 		authScope = scope[0]
@@ -101,23 +553,36 @@ func (c *A2ARegClient) Authenticate(scope ...string) error {
 	data := url.Values{}
 	data.Set("grant_type", "client_credentials")
 	data.Set("client_id", c.clientID)
-	data.Set("client_secret", c.clientSecret)
+	c.clientSecret.Use(func(secret []byte) {
+		data.Set("client_secret", string(secret))
+	})
 	data.Set("scope", authScope)
 
-	req, err := http.NewRequest("POST", c.registryURL+"/auth/oauth/token", strings.NewReader(data.Encode()))
+	tokenURL, err := NormalizeEndpoint(c.registryURL, "/auth/oauth/token")
 	if err != nil {
-		return NewAuthenticationError("Failed to create request", map[string]interface{}{"error": err.Error()})
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return withCode(NewAuthenticationError("Failed to create request", map[string]interface{}{"error": err.Error()}), CodeInvalidRequest)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return NewAuthenticationError("Authentication failed", map[string]interface{}{"error": err.Error()})
+		if pinErr := asPinMismatch(err); pinErr != nil {
+			return pinErr
+		}
+		return withCode(NewAuthenticationError("Authentication failed", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return NewAuthenticationError("Authentication failed", map[string]interface{}{"status_code": resp.StatusCode})
+		code := CodeAuthFailed
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusBadRequest {
+			code = CodeAuthInvalidClient
+		}
+		return withCode(NewAuthenticationError("Authentication failed", map[string]interface{}{"status_code": resp.StatusCode}), code)
 	}
 
 	var tokenData struct {
@@ -125,35 +590,55 @@ func (c *A2ARegClient) Authenticate(scope ...string) error {
 		ExpiresIn   int    `json:"expires_in"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&tokenData); err != nil {
-		return NewAuthenticationError("Failed to decode token response", map[string]interface{}{"error": err.Error()})
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return withCode(NewAuthenticationError("Failed to read token response", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	body, _, _, _ = unwrapEnvelope(body)
+	if err := json.Unmarshal(body, &tokenData); err != nil {
+		return withCode(NewAuthenticationError("Failed to decode token response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
 	}
 
 	if tokenData.AccessToken == "" {
-		return NewAuthenticationError("No access token received", nil)
+		return withCode(NewAuthenticationError("No access token received", nil), CodeAuthFailed)
 	}
 
-	c.accessToken = tokenData.AccessToken
+	c.tokens.mu.Lock()
+	c.tokens.accessToken.Set(tokenData.AccessToken)
+	c.tokens.issuedAt = c.clock.Now()
 	if tokenData.ExpiresIn > 0 {
-		expiresAt := time.Now().Add(time.Duration(tokenData.ExpiresIn-60) * time.Second)
-		c.tokenExpiresAt = &expiresAt
+		expiresAt := c.clock.Now().Add(time.Duration(tokenData.ExpiresIn-60) * time.Second)
+		c.tokens.expiresAt = &expiresAt
 	}
+	c.tokens.mu.Unlock()
 
 	return nil
 }
 
+// token returns a copy of the cached access token for a request header.
+func (c *A2ARegClient) token() string {
+	var token string
+	c.tokens.accessToken.Use(func(secret []byte) {
+		token = string(secret)
+	})
+	return token
+}
+
 // ensureAuthenticated ensures we have a valid access token.
-func (c *A2ARegClient) ensureAuthenticated() error {
-	if c.apiKey != "" {
+func (c *A2ARegClient) ensureAuthenticated(ctx context.Context) error {
+	if c.usingAPIKey() {
 		return nil
 	}
 
-	if c.accessToken == "" {
-		return c.Authenticate()
+	c.tokens.mu.RLock()
+	missing, expiresAt := c.tokens.accessToken.Empty(), c.tokens.expiresAt
+	c.tokens.mu.RUnlock()
+	if missing {
+		return c.authenticate(ctx)
 	}
 
-	if c.tokenExpiresAt != nil && time.Now().After(*c.tokenExpiresAt) {
-		return c.Authenticate()
+	if expiresAt != nil && c.clock.Now().After(*expiresAt) {
+		return c.authenticate(ctx)
 	}
 
 	return nil
@@ -161,108 +646,503 @@ func (c *A2ARegClient) ensureAuthenticated() error {
 
 // handleResponse handles the HTTP response and returns appropriate errors.
 func (c *A2ARegClient) handleResponse(resp *http.Response) ([]byte, error) {
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp.Body)
 	if err != nil {
-		return nil, NewA2AError("Failed to read response body", map[string]interface{}{"error": err.Error()})
+		return nil, withCode(NewA2AError("Failed to read response body", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
 	}
+	if body, err = c.decodeWireBody(resp, body); err != nil {
+		return nil, err
+	}
+
+	data, meta, errorValue, enveloped := unwrapEnvelope(body)
+	c.recordCall(resp, enveloped, meta)
+	c.rateLimits.observe(resp, c.clock.Now())
 
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return body, nil
+		return data, nil
+	}
+
+	var errorData map[string]interface{}
+	if enveloped && errorValue != nil {
+		errorData = envelopeErrorData(errorValue)
+	} else if err := json.Unmarshal(body, &errorData); err != nil {
+		errorData = nil
 	}
 
-	switch resp.StatusCode {
+	if maintenanceErr := c.maintenanceError(resp, errorData); maintenanceErr != nil {
+		c.enterMaintenance(maintenanceErr.Until)
+		return nil, maintenanceErr
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		errorData = rateLimitDetails(resp.Header, c.clock.Now(), errorData)
+	}
+
+	apiErr := c.responseError(resp.StatusCode, errorData)
+	apiErr.setStatusCode(resp.StatusCode)
+	return nil, apiErr
+}
+
+// responseError maps a non-2xx registry response onto the SDK error types.
+func (c *A2ARegClient) responseError(statusCode int, errorData map[string]interface{}) codedError {
+	detail, fields := decodeDetail(errorData["detail"])
+
+	switch statusCode {
 	case http.StatusUnauthorized:
-		return nil, NewAuthenticationError("Authentication required or token expired", nil)
+		return withCode(NewAuthenticationError("Authentication required or token expired", nil), serverErrorCode(errorData, CodeAuthRequired))
 	case http.StatusForbidden:
-		return nil, NewAuthenticationError("Access denied", nil)
+		return withCode(NewAuthenticationError("Access denied", nil), serverErrorCode(errorData, CodeAccessDenied))
 	case http.StatusNotFound:
-		return nil, NewNotFoundError("Resource not found", nil)
+		return withCode(NewNotFoundError("Resource not found", nil), serverErrorCode(errorData, CodeNotFound))
+	case http.StatusPreconditionFailed:
+		return withCode(NewConflictError("Agent was modified concurrently; read it again and retry", errorData), serverErrorCode(errorData, CodePreconditionFailed))
+	case http.StatusRequestEntityTooLarge:
+		// The registry's own limit wins; the client's is only a guess at it,
+		// and unknown when unset or disabled.
+		limit := c.maxRequestBytes
+		if reported, ok := errorData["limit"].(float64); ok && reported > 0 {
+			limit = int64(reported)
+		}
+		message := "Request body exceeds the registry's size limit"
+		details := map[string]interface{}{}
+		if limit > 0 {
+			message += fmt.Sprintf(" (%d bytes)", limit)
+			details["limit"] = limit
+		}
+		if errorData != nil {
+			details["response"] = errorData
+		}
+		return withCode(NewValidationError(message, details), CodeRequestTooLarge)
+	case http.StatusTooManyRequests:
+		message := "Rate limit exceeded"
+		if detail != "" {
+			message += ": " + detail
+		}
+		err := withCode(NewRateLimitError(message, errorData), serverErrorCode(errorData, CodeRateLimited))
+		scope, _ := errorData["rate_limit_scope"].(string)
+		err.Scope = RateLimitScope(scope)
+		return err
 	case http.StatusUnprocessableEntity:
-		var errorData map[string]interface{}
-		if err := json.Unmarshal(body, &errorData); err == nil {
-			detail, _ := errorData["detail"].(string)
-			return nil, NewValidationError("Validation error: "+detail, errorData)
+		if errorData == nil {
+			return NewValidationError("Validation error", nil)
 		}
-		return nil, NewValidationError("Validation error", nil)
+		message := "Validation error"
+		if detail != "" {
+			message += ": " + detail
+		}
+		err := withCode(NewValidationError(message, errorData), serverErrorCode(errorData, CodeValidationFailed))
+		err.fields = fields
+		return err
 	default:
-		var errorData map[string]interface{}
-		if err := json.Unmarshal(body, &errorData); err == nil {
-			detail, _ := errorData["detail"].(string)
-			return nil, NewA2AError("API error: "+detail, errorData)
+		code := CodeAPIError
+		if statusCode >= 500 {
+			code = CodeServerError
+		}
+		if detail == "" {
+			detail = fmt.Sprintf("status %d", statusCode)
+		}
+		if errorData != nil {
+			return withCode(NewA2AError("API error: "+detail, errorData), serverErrorCode(errorData, code))
+		}
+		return withCode(NewA2AError("API error: "+detail, nil), code)
+	}
+}
+
+// maxSummarizedFieldErrors is the number of field errors spelled out in a
+// ValidationError's message.
+const maxSummarizedFieldErrors = 3
+
+// decodeDetail reads an error body's "detail", which the registry sends as
+// a string, as a FastAPI array of {loc, msg, type} entries, or as a single
+// such object. It returns the text for the error message, empty when there
+// is none, and the field errors when detail carried any.
+func decodeDetail(detail interface{}) (string, []FieldError) {
+	switch detail := detail.(type) {
+	case string:
+		return strings.TrimSpace(detail), nil
+	case []interface{}:
+		fields := fieldErrors(detail)
+		return summarizeFieldErrors(fields), fields
+	case map[string]interface{}:
+		if _, ok := detail["loc"]; ok {
+			fields := fieldErrors([]interface{}{detail})
+			return summarizeFieldErrors(fields), fields
+		}
+		for _, key := range []string{"msg", "message", "error"} {
+			if text, ok := detail[key].(string); ok && strings.TrimSpace(text) != "" {
+				return strings.TrimSpace(text), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// fieldErrors decodes the entries of a FastAPI "detail" array. Bare strings
+// become errors without a location; entries that are neither are skipped.
+// It returns nil when no entry could be decoded.
+func fieldErrors(entries []interface{}) []FieldError {
+	var fields []FieldError
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case string:
+			if text := strings.TrimSpace(entry); text != "" {
+				fields = append(fields, FieldError{Message: text})
+			}
+		case map[string]interface{}:
+			field := FieldError{}
+			field.Message, _ = entry["msg"].(string)
+			field.Type, _ = entry["type"].(string)
+			field.Message = strings.TrimSpace(field.Message)
+			if field.Message == "" {
+				field.Message = field.Type
+			}
+			switch loc := entry["loc"].(type) {
+			case []interface{}:
+				for _, part := range loc {
+					field.Loc = append(field.Loc, fmt.Sprint(part))
+				}
+			case string:
+				field.Loc = []string{loc}
+			}
+			if field.Message == "" && len(field.Loc) == 0 {
+				continue
+			}
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// summarizeFieldErrors renders the first few field errors for a message.
+func summarizeFieldErrors(fields []FieldError) string {
+	parts := make([]string, 0, maxSummarizedFieldErrors)
+	for i, field := range fields {
+		if i == maxSummarizedFieldErrors {
+			break
 		}
-		return nil, NewA2AError(fmt.Sprintf("API error: status %d", resp.StatusCode), nil)
+		parts = append(parts, field.String())
+	}
+	summary := strings.Join(parts, "; ")
+	if more := len(fields) - len(parts); more > 0 {
+		summary += fmt.Sprintf(" (and %d more)", more)
+	}
+	return summary
+}
+
+// serverErrorCode returns the error code reported by the server in an error
+// body ("code" or "error_code"), or fallback when none is present.
+func serverErrorCode(errorData map[string]interface{}, fallback string) string {
+	for _, key := range []string{"code", "error_code"} {
+		if code, ok := errorData[key].(string); ok && code != "" {
+			return code
+		}
+	}
+	return fallback
+}
+
+// agentNotFound refines a generic not-found error from an agent endpoint into
+// one carrying CodeAgentNotFound.
+func agentNotFound(err error) error {
+	if nf, ok := err.(*NotFoundError); ok && nf.Code == CodeNotFound {
+		nf.Code = CodeAgentNotFound
+	}
+	return err
+}
+
+// makeRequest makes an HTTP request to the registry with opts applied.
+func (c *A2ARegClient) makeRequest(method, endpoint string, body interface{}, params map[string]string, opts ...RequestOption) ([]byte, error) {
+	return c.makeRequestContext(withRequestOptions(context.Background(), opts), method, endpoint, body, params)
+}
+
+// makeRequestContext is makeRequest bound to ctx.
+func (c *A2ARegClient) makeRequestContext(ctx context.Context, method, endpoint string, body interface{}, params map[string]string) ([]byte, error) {
+	resp, err := c.send(ctx, method, endpoint, body, params)
+	if err != nil {
+		return nil, err
 	}
+	return resp.body, nil
+}
+
+// apiResponse is a successful registry response.
+type apiResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
 }
 
-// makeRequest makes an HTTP request to the registry.
-func (c *A2ARegClient) makeRequest(method, endpoint string, body interface{}, params map[string]string) ([]byte, error) {
-	if err := c.ensureAuthenticated(); err != nil {
+// send makes an HTTP request to the registry and returns the successful
+// response, or the error handleResponse maps it to.
+func (c *A2ARegClient) send(ctx context.Context, method, endpoint string, body interface{}, params map[string]string) (*apiResponse, error) {
+	if c.closed.Load() {
+		return nil, errClientClosed()
+	}
+	if c.configErr != nil {
+		return nil, c.configErr
+	}
+	if err := c.checkMaintenance(); err != nil {
+		return nil, err
+	}
+	if err := c.rateLimits.wait(ctx, rateLimitScopeOf(method, endpoint), c.clock); err != nil {
 		return nil, err
 	}
 
-	reqURL := c.registryURL + endpoint
-	if params != nil && len(params) > 0 {
+	override, hasOverride := authFromContext(ctx)
+	if !hasOverride {
+		if err := c.ensureAuthenticated(ctx); err != nil && !(oauthRejected(err) && c.fallBackToAPIKey("token endpoint rejected the client credentials")) {
+			return nil, err
+		}
+	}
+
+	options := requestOptionsFrom(ctx)
+	reqURL, err := NormalizeEndpoint(c.registryURL, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > 0 || len(options.query) > 0 {
 		u, err := url.Parse(reqURL)
 		if err != nil {
-			return nil, NewA2AError("Invalid URL", map[string]interface{}{"error": err.Error()})
+			return nil, withCode(NewA2AError("Invalid URL", map[string]interface{}{"error": err.Error()}), CodeInvalidRequest)
 		}
 		q := u.Query()
 		for k, v := range params {
 			q.Set(k, v)
 		}
+		for k, v := range options.query {
+			q.Set(k, v)
+		}
 		u.RawQuery = q.Encode()
 		reqURL = u.String()
 	}
 
-	var reqBody io.Reader
+	var reqBody *pooledBody
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		buf, err := encodeBody(body)
 		if err != nil {
-			return nil, NewA2AError("Failed to marshal request body", map[string]interface{}{"error": err.Error()})
+			return nil, withCode(NewA2AError("Failed to marshal request body", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+		}
+		if c.maxRequestBytes > 0 && int64(buf.Len()) > c.maxRequestBytes {
+			tooLarge := newRequestTooLargeError(buf.Bytes(), c.maxRequestBytes)
+			putBuffer(buf)
+			return nil, tooLarge
 		}
-		reqBody = bytes.NewBuffer(jsonData)
+		if c.codec != nil && options.header.Get("Content-Type") == "" {
+			encoded, err := c.codec.FromJSON(buf.Bytes())
+			if err != nil {
+				putBuffer(buf)
+				return nil, withCode(NewA2AError("Failed to encode request body as "+c.codec.ContentType(), map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+			}
+			buf.Reset()
+			buf.Write(encoded)
+		}
+		reqBody = newPooledBody(buf)
+		defer reqBody.done()
 	}
 
-	req, err := http.NewRequest(method, reqURL, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, nil)
 	if err != nil {
-		return nil, NewA2AError("Failed to create request", map[string]interface{}{"error": err.Error()})
+		if reqBody != nil {
+			reqBody.Close()
+		}
+		return nil, withCode(NewA2AError("Failed to create request", map[string]interface{}{"error": err.Error()}), CodeInvalidRequest)
+	}
+	if reqBody != nil {
+		req.Body = reqBody
+		req.GetBody = reqBody.getBody
+		req.ContentLength = int64(reqBody.buf.Len())
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "A2A-Go-SDK/1.0.0")
-
-	if c.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	} else if c.accessToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("User-Agent", "A2A-Go-SDK/"+SDKVersion)
+	c.setCodecHeaders(req.Header, reqBody != nil)
+	options.setHeaders(req.Header)
+
+	// sentToken is the OAuth token the request carries, if any; only such
+	// requests are retried after a 401.
+	var sentToken string
+	switch {
+	case hasOverride && override.apiKey != "":
+		c.setAPIKeyHeader(req.Header, override.apiKey)
+	case hasOverride:
+		req.Header.Set("Authorization", "Bearer "+override.token)
+	case c.usingAPIKey() && c.useAPIKey(req.Header):
+	default:
+		sentToken = c.token()
+		if sentToken != "" {
+			req.Header.Set("Authorization", "Bearer "+sentToken)
+		}
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, NewA2AError("Request failed", map[string]interface{}{"error": err.Error()})
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && sentToken != "" && (req.Body == nil || req.GetBody != nil) {
+		resp.Body.Close()
+		resp, err = c.retryWithFreshToken(ctx, req, sentToken)
+		var reason string
+		switch {
+		case err == nil && resp.StatusCode == http.StatusUnauthorized:
+			reason = "registry rejected the OAuth token"
+		case oauthRejected(err):
+			reason = "token endpoint rejected the client credentials"
+		}
+		if reason != "" && c.fallBackToAPIKey(reason) {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			resp, err = c.replayWithAPIKey(ctx, req)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 	defer resp.Body.Close()
 
-	return c.handleResponse(resp)
+	data, err := c.handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &apiResponse{statusCode: resp.StatusCode, header: resp.Header, body: data}, nil
+}
+
+// do sends req, mapping transport failures onto SDK errors. A WithTimeout
+// option carried by the request's context replaces the client's Timeout.
+func (c *A2ARegClient) do(req *http.Request) (*http.Response, error) {
+	client := c.httpClient
+	if timeout := requestOptionsFrom(req.Context()).timeout; timeout > 0 {
+		perCall := *client
+		perCall.Timeout = timeout
+		client = &perCall
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if pinErr := asPinMismatch(err); pinErr != nil {
+			return nil, pinErr
+		}
+		return nil, withCode(NewA2AError("Request failed", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	return resp, nil
+}
+
+// retryWithFreshToken replays req once after it was rejected with a 401
+// despite carrying sentToken, which happens when the registry revokes a
+// token before its local expiry. The token is dropped, the client
+// authenticates again, and the request is resent from its buffered body.
+func (c *A2ARegClient) retryWithFreshToken(ctx context.Context, req *http.Request, sentToken string) (*http.Response, error) {
+	c.dropToken(sentToken)
+	if err := c.ensureAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+
+	retry, err := replayRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	retry.Header.Set("Authorization", "Bearer "+c.token())
+	return c.do(retry)
+}
+
+// replayRequest returns a copy of req, with a fresh copy of its buffered
+// body, to send again.
+func replayRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	retry := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, withCode(NewA2AError("Failed to replay request body", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+		}
+		retry.Body = body
+	}
+	return retry, nil
+}
+
+// dropToken clears the cached token if it is still token, leaving a token
+// that another request has already refreshed in place.
+func (c *A2ARegClient) dropToken(token string) {
+	c.tokens.mu.Lock()
+	defer c.tokens.mu.Unlock()
+	if c.tokens.accessToken.equal(token) {
+		c.tokens.accessToken.Wipe()
+		c.tokens.expiresAt = nil
+	}
+}
+
+// AgentList is one page of the agent listing.
+type AgentList struct {
+	Agents []Agent `json:"agents"`
+	Total  int     `json:"total"`
+	Page   int     `json:"page"`
+	Limit  int     `json:"limit"`
+}
+
+// ListAgentsPage lists one page of the public agents, or with publicOnly
+// unset of the agents the client is entitled to. With WithStarred each
+// agent has Starred set.
+func (c *A2ARegClient) ListAgentsPage(ctx context.Context, page, limit int, publicOnly bool, opts ...RequestOption) (*AgentList, error) {
+	list, _, err := c.agentPage(withRequestOptions(ctx, opts), page, limit, publicOnly)
+	return list, err
+}
+
+// agentPage fetches and decodes one page of the agent listing. It also
+// returns the document the page was decoded from, with WithStarred
+// applied as a "starred" member of each agent, for ListAgents.
+func (c *A2ARegClient) agentPage(ctx context.Context, page, limit int, publicOnly bool) (*AgentList, map[string]interface{}, error) {
+	body, err := c.listAgents(ctx, page, limit, publicOnly, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var list AgentList
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, nil, withCode(NewA2AError("Failed to decode agents response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, nil, withCode(NewA2AError("Failed to decode agents response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	for i := range list.Agents {
+		list.Agents[i].interfacesFromCard()
+	}
+
+	if requestOptionsFrom(ctx).starred {
+		ids, err := c.starredIDs(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range list.Agents {
+			list.Agents[i].Starred = ids[getStringValue(list.Agents[i].ID, "")]
+		}
+		markStarred(doc, ids)
+	}
+	return &list, doc, nil
 }
 
-// GetHealth gets the registry health status.
-func (c *A2ARegClient) GetHealth() (map[string]interface{}, error) {
-	body, err := c.makeRequest("GET", "/health", nil, nil)
+// ListSummaries lists agents as lightweight summaries. It requests the
+// registry's summary projection (?fields=summary) and projects client-side,
+// so registries that ignore the parameter and return full agents yield the
+// same result.
+func (c *A2ARegClient) ListSummaries(page, limit int, publicOnly bool, opts ...RequestOption) ([]AgentSummary, error) {
+	body, err := c.listAgents(withRequestOptions(context.Background(), opts), page, limit, publicOnly, map[string]string{"fields": "summary"})
 	if err != nil {
 		return nil, err
 	}
 
-	var health map[string]interface{}
-	if err := json.Unmarshal(body, &health); err != nil {
-		return nil, NewA2AError("Failed to decode health response", map[string]interface{}{"error": err.Error()})
+	var result struct {
+		Agents []Agent `json:"agents"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode agents response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
 	}
 
-	return health, nil
+	summaries := make([]AgentSummary, 0, len(result.Agents))
+	for i := range result.Agents {
+		summaries = append(summaries, result.Agents[i].Summary())
+	}
+	return summaries, nil
 }
 
-// ListAgents lists agents from the registry.
-func (c *A2ARegClient) ListAgents(page, limit int, publicOnly bool) (map[string]interface{}, error) {
+// listAgents fetches one page of the public or entitled agent listing.
+func (c *A2ARegClient) listAgents(ctx context.Context, page, limit int, publicOnly bool, extra map[string]string) ([]byte, error) {
 	endpoint := "/agents/public"
 	if !publicOnly {
 		endpoint = "/agents/entitled"
@@ -272,285 +1152,314 @@ func (c *A2ARegClient) ListAgents(page, limit int, publicOnly bool) (map[string]
 		"page":  fmt.Sprintf("%d", page),
 		"limit": fmt.Sprintf("%d", limit),
 	}
-
-	body, err := c.makeRequest("GET", endpoint, nil, params)
-	if err != nil {
-		return nil, err
+	if requestOptionsFrom(ctx).includeDeprecated {
+		params["include_deprecated"] = "true"
 	}
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, NewA2AError("Failed to decode agents response", map[string]interface{}{"error": err.Error()})
+	for k, v := range extra {
+		params[k] = v
 	}
 
-	return result, nil
+	return c.makeRequestContext(ctx, "GET", endpoint, nil, params)
 }
 
 // GetAgent gets a specific agent by ID.
-func (c *A2ARegClient) GetAgent(agentID string) (*Agent, error) {
-	body, err := c.makeRequest("GET", "/agents/"+agentID, nil, nil)
+func (c *A2ARegClient) GetAgent(agentID string, opts ...RequestOption) (*Agent, error) {
+	ctx := withRequestOptions(context.Background(), opts)
+	agent, err := c.readAgent(ctx, agentID)
+	if err != nil || !requestOptionsFrom(ctx).starred {
+		return agent, err
+	}
+	ids, err := c.starredIDs(ctx)
 	if err != nil {
 		return nil, err
 	}
+	agent.Starred = ids[agentID]
+	return agent, nil
+}
 
+// decodeAgent decodes an agent document.
+func decodeAgent(body []byte) (*Agent, error) {
 	var agent Agent
 	if err := json.Unmarshal(body, &agent); err != nil {
-		return nil, NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()})
+		return nil, withCode(NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
 	}
-
+	agent.interfacesFromCard()
 	return &agent, nil
 }
 
-// GetAgentCard gets an agent's card.
-func (c *A2ARegClient) GetAgentCard(agentID string) (*AgentCardSpec, error) {
-	body, err := c.makeRequest("GET", "/agents/"+agentID+"/card", nil, nil)
+// getAgent fetches the raw agent document.
+func (c *A2ARegClient) getAgent(ctx context.Context, agentID string, params map[string]string) ([]byte, error) {
+	resp, err := c.getAgentResponse(ctx, agentID, params)
 	if err != nil {
 		return nil, err
 	}
+	return resp.body, nil
+}
 
-	var card AgentCardSpec
-	if err := json.Unmarshal(body, &card); err != nil {
-		return nil, NewA2AError("Failed to decode card response", map[string]interface{}{"error": err.Error()})
+// getAgentResponse fetches the agent document with the response headers.
+func (c *A2ARegClient) getAgentResponse(ctx context.Context, agentID string, params map[string]string) (*apiResponse, error) {
+	resp, err := c.send(ctx, "GET", "/agents/"+agentID, nil, params)
+	if err != nil {
+		return nil, agentNotFound(err)
 	}
-
-	return &card, nil
+	return resp, nil
 }
 
-// SearchAgents searches for agents.
-func (c *A2ARegClient) SearchAgents(query string, filters map[string]interface{}, semantic bool, page, limit int) (map[string]interface{}, error) {
-	searchData := map[string]interface{}{
-		"query":    query,
-		"filters":  filters,
-		"semantic": semantic,
-		"page":     page,
-		"limit":    limit,
+// GetAgentCard gets an agent's card, in the CardProfile asked for if any.
+// A registry that ignores the profile has its card converted with
+// MigrateCard; one that refuses it gives an *UnsupportedProfileError.
+// Calls with a WithHeader or WithQueryParam option, or a WithCardProfile
+// the SDK does not know, bypass the card cache.
+func (c *A2ARegClient) GetAgentCard(agentID string, opts ...RequestOption) (*AgentCardSpec, error) {
+	ctx := withRequestOptions(context.Background(), opts)
+	options := requestOptionsFrom(ctx)
+	profile := c.cardProfileFor(options)
+	cacheable := !options.varies() && c.cachedProfile(profile)
+	if cacheable {
+		if card, ok := c.cachedCard(agentID, profile); ok {
+			return c.verifiedCard(ctx, card, options.verifySignature)
+		}
 	}
 
-	body, err := c.makeRequest("POST", "/agents/search", searchData, nil)
+	card, body, err := c.fetchProfiledCard(ctx, "/agents/"+agentID+"/card", profile)
 	if err != nil {
 		return nil, err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, NewA2AError("Failed to decode search response", map[string]interface{}{"error": err.Error()})
+	if cacheable {
+		c.storeCard(agentID, profile, body)
 	}
+	return c.verifiedCard(ctx, card, options.verifySignature)
+}
 
-	return result, nil
+// verifiedCard returns card, or its signature verification error when opts
+// is set and the signature does not verify.
+func (c *A2ARegClient) verifiedCard(ctx context.Context, card *AgentCardSpec, opts *VerifyOptions) (*AgentCardSpec, error) {
+	if opts == nil {
+		return card, nil
+	}
+	if err := card.VerifySignature(ctx, *opts); err != nil {
+		return nil, err
+	}
+	return card, nil
 }
 
-// GetRegistryStats gets registry statistics.
-func (c *A2ARegClient) GetRegistryStats() (map[string]interface{}, error) {
-	body, err := c.makeRequest("GET", "/stats", nil, nil)
+// GetRegistryStats gets registry statistics. Fields the SDK does not know
+// are kept in RegistryStats.Extra.
+func (c *A2ARegClient) GetRegistryStats(opts ...RequestOption) (*RegistryStats, error) {
+	body, err := c.makeRequest("GET", "/stats", nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	var stats map[string]interface{}
+	var stats RegistryStats
 	if err := json.Unmarshal(body, &stats); err != nil {
-		return nil, NewA2AError("Failed to decode stats response", map[string]interface{}{"error": err.Error()})
+		return nil, withCode(NewA2AError("Failed to decode stats response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
 	}
 
-	return stats, nil
+	return &stats, nil
 }
 
 // PublishAgent publishes a new agent to the registry.
-func (c *A2ARegClient) PublishAgent(agent *Agent, validate bool) (*Agent, error) {
-	if validate {
-		if err := c.ValidateAgent(agent); err != nil {
-			return nil, err
-		}
-	}
-
-	cardData := c.convertToCardSpec(agent)
-
-	requestBody := map[string]interface{}{
-		"public": agent.IsPublic,
-		"card":   cardData,
-	}
-
-	body, err := c.makeRequest("POST", "/agents/publish", requestBody, nil)
+func (c *A2ARegClient) PublishAgent(agent *Agent, validate bool, opts ...RequestOption) (*Agent, error) {
+	receipt, err := c.publish(withRequestOptions(context.Background(), opts), agent, PublishOptions{Validate: validate, SkipNormalizationCheck: true})
 	if err != nil {
 		return nil, err
 	}
+	return receipt.Agent, nil
+}
 
-	var publishedData map[string]interface{}
-	if err := json.Unmarshal(body, &publishedData); err != nil {
-		return nil, NewA2AError("Failed to decode publish response", map[string]interface{}{"error": err.Error()})
-	}
-
-	// If agentId is returned, fetch the full agent
-	if agentID, ok := publishedData["agentId"].(string); ok {
-		return c.GetAgent(agentID)
-	}
-
-	// Otherwise, convert response to Agent
-	var publishedAgent Agent
-	if err := json.Unmarshal(body, &publishedAgent); err != nil {
-		return nil, NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()})
-	}
-
-	return &publishedAgent, nil
+// UpdateAgent updates an existing agent. Registries without visibility
+// support receive only is_public, so an unlisted agent becomes private
+// there; use SetAgentVisibility to have that fail instead.
+//
+// When agent.ETag is set, as it is on agents read with GetAgent, the
+// update is conditional on the agent being unchanged since: if it was
+// modified in between, the registry refuses it and a *ConflictError is
+// returned.
+func (c *A2ARegClient) UpdateAgent(agentID string, agent *Agent, opts ...RequestOption) (*Agent, error) {
+	return c.updateAgent(withRequestOptions(context.Background(), opts), agentID, agent)
 }
 
-// UpdateAgent updates an existing agent.
-func (c *A2ARegClient) UpdateAgent(agentID string, agent *Agent) (*Agent, error) {
-	body, err := c.makeRequest("PUT", "/agents/"+agentID, agent, nil)
+func (c *A2ARegClient) updateAgent(ctx context.Context, agentID string, agent *Agent) (*Agent, error) {
+	if err := checkChangelog(agent.Changelog); err != nil {
+		return nil, err
+	}
+	agent, _, err := c.wireVisibility(ctx, agent)
 	if err != nil {
 		return nil, err
 	}
+	resp, err := c.sendMutation(ifMatch(ctx, agent.ETag), "PUT", "/agents/"+agentID, agentID, agent)
+	c.invalidateAgent(agentID)
+	if err != nil {
+		return nil, agentNotFound(err)
+	}
 
 	var updatedAgent Agent
-	if err := json.Unmarshal(body, &updatedAgent); err != nil {
-		return nil, NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()})
+	if err := json.Unmarshal(resp.body, &updatedAgent); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
 	}
+	updatedAgent.ETag = resp.header.Get("ETag")
 
 	return &updatedAgent, nil
 }
 
-// DeleteAgent deletes an agent from the registry.
-func (c *A2ARegClient) DeleteAgent(agentID string) error {
-	_, err := c.makeRequest("DELETE", "/agents/"+agentID, nil, nil)
-	return err
+// DeleteAgent deletes an agent from the registry. When the client was
+// created with SafeDelete, nothing is deleted; instead the returned
+// *DeleteConfirmationRequiredError carries a DeleteConfirmation to pass to
+// ConfirmDelete.
+func (c *A2ARegClient) DeleteAgent(agentID string, opts ...RequestOption) error {
+	ctx := withRequestOptions(context.Background(), opts)
+	if c.safeDelete {
+		confirmation, err := c.PrepareDelete(ctx, agentID)
+		if err != nil {
+			return err
+		}
+		return NewDeleteConfirmationRequiredError(confirmation)
+	}
+	return c.deleteAgent(ctx, agentID)
+}
+
+// deleteAgent issues the DELETE for agentID.
+func (c *A2ARegClient) deleteAgent(ctx context.Context, agentID string) error {
+	_, err := c.sendMutation(ctx, "DELETE", "/agents/"+agentID, agentID, nil)
+	c.invalidateAgent(agentID)
+	return agentNotFound(err)
 }
 
 // ValidateAgent validates an agent configuration.
 func (c *A2ARegClient) ValidateAgent(agent *Agent) error {
-	if agent.Name == "" {
-		return NewValidationError("Agent name is required", nil)
+	var first error
+	c.checkAgent(agent, func(_ string, err error) bool {
+		first = err
+		return false
+	})
+	return first
+}
+
+// problemFunc receives each problem found by checkAgent or checkAgentCard
+// with the path of the offending field, empty when the problem is not
+// about one field. It returns whether to keep checking.
+type problemFunc func(path string, err error) bool
+
+// checkAgent implements ValidateAgent, passing each problem to found.
+func (c *A2ARegClient) checkAgent(agent *Agent, found problemFunc) {
+	if err := c.nameRules.Validate(agent.Name); err != nil && !found("name", err) {
+		return
 	}
-	if agent.Description == "" {
-		return NewValidationError("Agent description is required", nil)
+	if agent.Description == "" && !found("description", NewValidationError("Agent description is required", nil)) {
+		return
 	}
-	if agent.Version == "" {
-		return NewValidationError("Agent version is required", nil)
+	if agent.Version == "" && !found("version", NewValidationError("Agent version is required", nil)) {
+		return
 	}
-	if agent.Provider == "" {
-		return NewValidationError("Agent provider is required", nil)
+	if agent.ProviderName() == "" && !found("provider", NewValidationError("Agent provider is required", nil)) {
+		return
 	}
-
-	for i, scheme := range agent.AuthSchemes {
-		if scheme.Type == "" {
-			return NewValidationError(fmt.Sprintf("Auth scheme %d missing required field: type", i), nil)
+	if agent.ProviderInfo != nil {
+		if agent.ProviderInfo.Organization == "" && !found("provider.organization", NewValidationError("Agent provider organization is required", nil)) {
+			return
 		}
-		validTypes := map[string]bool{"apiKey": true, "oauth2": true, "jwt": true, "mTLS": true, "bearer": true}
-		if !validTypes[scheme.Type] {
-			return NewValidationError(fmt.Sprintf("Auth scheme %d has invalid type: %s", i, scheme.Type), nil)
+		if !isValidHTTPURL(agent.ProviderInfo.URL) && !found("provider.url", NewValidationError(fmt.Sprintf("Agent provider has invalid url: %q", agent.ProviderInfo.URL), nil)) {
+			return
 		}
 	}
-
-	if agent.AgentCard != nil {
-		if agent.AgentCard.Name == "" {
-			return NewValidationError("Agent card name is required", nil)
-		}
-		if agent.AgentCard.Description == "" {
-			return NewValidationError("Agent card description is required", nil)
-		}
-		if agent.AgentCard.Version == "" {
-			return NewValidationError("Agent card version is required", nil)
-		}
+	if err := checkChangelog(agent.Changelog); err != nil && !found("changelog", err) {
+		return
 	}
-
-	return nil
-}
-
-// convertToCardSpec converts an Agent to AgentCardSpec format.
-func (c *A2ARegClient) convertToCardSpec(agent *Agent) map[string]interface{} {
-	capabilities := map[string]bool{
-		"streaming":                         false,
-		"pushNotifications":                 false,
-		"stateTransitionHistory":            false,
-		"supportsAuthenticatedExtendedCard": false,
+	if err := checkLabels(agent.Labels); err != nil && !found("labels", err) {
+		return
 	}
-
-	if agent.Capabilities != nil {
-		if agent.Capabilities.Streaming != nil {
-			capabilities["streaming"] = *agent.Capabilities.Streaming
+	if c.deterministicIDs && agent.ID != nil && *agent.ID != "" {
+		if derived := DeriveAgentID(agent.ProviderName(), agent.Name); *agent.ID != derived {
+			if !found("id", NewValidationError(fmt.Sprintf("Agent ID %q does not match derived ID %q", *agent.ID, derived), map[string]interface{}{"id": *agent.ID, "derived_id": derived})) {
+				return
+			}
 		}
-		if agent.Capabilities.PushNotifications != nil {
-			capabilities["pushNotifications"] = *agent.Capabilities.PushNotifications
-		}
-		if agent.Capabilities.StateTransitionHistory != nil {
-			capabilities["stateTransitionHistory"] = *agent.Capabilities.StateTransitionHistory
+	}
+
+	for i, scheme := range agent.AuthSchemes {
+		path := fmt.Sprintf("auth_schemes[%d]", i)
+		var err error
+		switch {
+		case scheme.Type == "":
+			err = NewValidationError(fmt.Sprintf("Auth scheme %d missing required field: type", i), nil)
+			path += ".type"
+		case !scheme.Type.IsValid():
+			err = NewValidationError(fmt.Sprintf("Auth scheme %d has invalid type: %s", i, scheme.Type), nil)
+			path += ".type"
+		case scheme.Location != nil && !scheme.Location.IsValid():
+			err = NewValidationError(fmt.Sprintf("Auth scheme %d has invalid location: %s", i, *scheme.Location), nil)
+			path += ".location"
 		}
-		if agent.Capabilities.SupportsAuthenticatedExtendedCard != nil {
-			capabilities["supportsAuthenticatedExtendedCard"] = *agent.Capabilities.SupportsAuthenticatedExtendedCard
+		if err != nil && !found(path, err) {
+			return
 		}
 	}
 
-	// Convert auth schemes to security schemes (as map for ADK compatibility)
-	securitySchemes := make(map[string]map[string]interface{})
-	for _, authScheme := range agent.AuthSchemes {
-		schemeType := authScheme.Type
-		if schemeType == "" {
-			schemeType = "apiKey"
+	if agent.PreferredTransport != "" && !c.validTransport(agent.PreferredTransport) {
+		if !found("preferred_transport", NewValidationError(fmt.Sprintf("Agent has invalid preferred transport: %s", agent.PreferredTransport), nil)) {
+			return
 		}
-		scheme := map[string]interface{}{
-			"type":     schemeType,
-			"location": "header",
+	}
+	for i, entry := range agent.Interfaces {
+		if !c.validTransport(entry.Transport) && !found(fmt.Sprintf("interfaces[%d].transport", i), NewValidationError(fmt.Sprintf("Interface %d has invalid transport: %s", i, entry.Transport), nil)) {
+			return
 		}
-		if authScheme.Name != nil {
-			scheme["name"] = *authScheme.Name
-		} else {
-			scheme["name"] = "Authorization"
+		if entry.URL == "" && !found(fmt.Sprintf("interfaces[%d].url", i), NewValidationError(fmt.Sprintf("Interface %d missing required field: url", i), nil)) {
+			return
 		}
-		securitySchemes[schemeType] = scheme
+	}
+	if err := c.checkAgentHosts(agent); err != nil && !found("", err) {
+		return
 	}
 
-	skills := []map[string]interface{}{}
-	for _, skill := range agent.Skills {
-		skillMap := map[string]interface{}{
-			"id":          skill.ID,
-			"name":        skill.Name,
-			"description": skill.Description,
-			"tags":        skill.Tags,
-		}
-		if len(skill.Examples) > 0 {
-			skillMap["examples"] = skill.Examples
+	if agent.AgentCard != nil {
+		if agent.AgentCard.Name == "" && !found("card.name", NewValidationError("Agent card name is required", nil)) {
+			return
 		}
-		if len(skill.InputModes) > 0 {
-			skillMap["inputModes"] = skill.InputModes
+		if agent.AgentCard.Description == "" && !found("card.description", NewValidationError("Agent card description is required", nil)) {
+			return
 		}
-		if len(skill.OutputModes) > 0 {
-			skillMap["outputModes"] = skill.OutputModes
+		if agent.AgentCard.Version == "" && !found("card.version", NewValidationError("Agent card version is required", nil)) {
+			return
 		}
-		skills = append(skills, skillMap)
 	}
 
-	interfaceMap := map[string]interface{}{
-		"preferredTransport": "jsonrpc",
-		"defaultInputModes":  []string{"text/plain"},
-		"defaultOutputModes": []string{"text/plain"},
+	if violations := c.skillLimits.checkSkills(agent.Skills); len(violations) > 0 {
+		messages := make([]string, len(violations))
+		for i, violation := range violations {
+			messages[i] = violation.String()
+		}
+		if !found("skills", NewValidationError("Agent has invalid skills: "+strings.Join(messages, "; "), map[string]interface{}{"violations": violations})) {
+			return
+		}
 	}
 
-	if agent.LocationURL != nil {
-		interfaceMap["additionalInterfaces"] = []map[string]interface{}{
-			{"transport": "http", "url": *agent.LocationURL},
+	if c.tagTaxonomy != nil {
+		if issues := c.tagTaxonomy.Check(agent); len(issues) > 0 {
+			messages := make([]string, len(issues))
+			for i, issue := range issues {
+				messages[i] = issue.String()
+			}
+			found("tags", NewValidationError("Agent has tags outside the taxonomy: "+strings.Join(messages, "; "), map[string]interface{}{"unknown_tags": issues}))
 		}
 	}
+}
 
-	cardSpec := map[string]interface{}{
-		"name":            agent.Name,
-		"description":     agent.Description,
-		"url":             getStringValue(agent.LocationURL, "https://example.com"),
-		"version":         agent.Version,
-		"capabilities":    capabilities,
-		"securitySchemes": securitySchemes,
-		"skills":          skills,
-		"interface":       interfaceMap,
-		// Add top-level defaultInputModes and defaultOutputModes for ADK compatibility
-		"defaultInputModes":  interfaceMap["defaultInputModes"],
-		"defaultOutputModes": interfaceMap["defaultOutputModes"],
-	}
+// validTransport reports whether t is a known transport, or any non-empty
+// transport when the client allows unknown ones.
+func (c *A2ARegClient) validTransport(t Transport) bool {
+	return t.IsValid() || (c.allowUnknownTransports && t != "")
+}
 
-	if agent.Provider != "" {
-		cardSpec["provider"] = map[string]interface{}{
-			"organization": agent.Provider,
-			"url":          getStringValue(agent.LocationURL, "https://example.com"),
-		}
+// isValidHTTPURL reports whether s is an absolute http(s) URL with a host.
+func isValidHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
 	}
-
-	return cardSpec
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
 
 // getStringValue returns the string value or a default.
@@ -562,7 +1471,7 @@ func getStringValue(s *string, defaultValue string) string {
 }
 
 // GenerateAPIKey generates a new API key.
-func (c *A2ARegClient) GenerateAPIKey(scopes []string, expiresDays *int) (string, map[string]interface{}, error) {
+func (c *A2ARegClient) GenerateAPIKey(scopes []string, expiresDays *int) (string, ResponseMap, error) {
 	payload := map[string]interface{}{
 		"scopes": scopes,
 	}
@@ -575,13 +1484,13 @@ func (c *A2ARegClient) GenerateAPIKey(scopes []string, expiresDays *int) (string
 		return "", nil, err
 	}
 
-	var response map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", nil, NewA2AError("Failed to decode API key response", map[string]interface{}{"error": err.Error()})
+	response, err := decodeResponseMap(body)
+	if err != nil {
+		return "", nil, withCode(NewA2AError("Failed to decode API key response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
 	}
 
 	apiKey, _ := response["api_key"].(string)
-	keyInfo := map[string]interface{}{
+	keyInfo := ResponseMap{
 		"key_id":     response["key_id"],
 		"scopes":     response["scopes"],
 		"created_at": response["created_at"],
@@ -592,7 +1501,7 @@ func (c *A2ARegClient) GenerateAPIKey(scopes []string, expiresDays *int) (string
 }
 
 // GenerateAPIKeyAndAuthenticate generates a new API key and authenticates with it.
-func (c *A2ARegClient) GenerateAPIKeyAndAuthenticate(scopes []string, expiresDays *int) (string, map[string]interface{}, error) {
+func (c *A2ARegClient) GenerateAPIKeyAndAuthenticate(scopes []string, expiresDays *int) (string, ResponseMap, error) {
 	apiKey, keyInfo, err := c.GenerateAPIKey(scopes, expiresDays)
 	if err != nil {
 		return "", nil, err
@@ -603,7 +1512,7 @@ func (c *A2ARegClient) GenerateAPIKeyAndAuthenticate(scopes []string, expiresDay
 }
 
 // ValidateAPIKey validates an API key.
-func (c *A2ARegClient) ValidateAPIKey(apiKey string, requiredScopes []string) (map[string]interface{}, error) {
+func (c *A2ARegClient) ValidateAPIKey(apiKey string, requiredScopes []string) (ResponseMap, error) {
 	payload := map[string]interface{}{
 		"api_key": apiKey,
 	}
@@ -620,9 +1529,9 @@ func (c *A2ARegClient) ValidateAPIKey(apiKey string, requiredScopes []string) (m
 		return nil, err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, NewA2AError("Failed to decode validation response", map[string]interface{}{"error": err.Error()})
+	result, err := decodeResponseMap(body)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to decode validation response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
 	}
 
 	return result, nil
@@ -641,7 +1550,7 @@ func (c *A2ARegClient) RevokeAPIKey(keyID string) (bool, error) {
 }
 
 // ListAPIKeys lists all API keys.
-func (c *A2ARegClient) ListAPIKeys(activeOnly bool) ([]map[string]interface{}, error) {
+func (c *A2ARegClient) ListAPIKeys(activeOnly bool) ([]ResponseMap, error) {
 	params := map[string]string{
 		"active_only": fmt.Sprintf("%t", activeOnly),
 	}
@@ -651,9 +1560,9 @@ func (c *A2ARegClient) ListAPIKeys(activeOnly bool) ([]map[string]interface{}, e
 		return nil, err
 	}
 
-	var keys []map[string]interface{}
-	if err := json.Unmarshal(body, &keys); err != nil {
-		return nil, NewA2AError("Failed to decode API keys response", map[string]interface{}{"error": err.Error()})
+	var keys []ResponseMap
+	if err := decodeNumbers(body, &keys); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode API keys response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
 	}
 
 	return keys, nil
@@ -1,12 +1,20 @@
 package a2areg
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"a2areg/internal/clock"
+	"a2areg/internal/fakeregistry"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -45,29 +53,6 @@ func TestNewA2ARegClient(t *testing.T) {
 	}
 }
 
-func TestA2ARegClient_GetHealth(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/health", r.URL.Path)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status":  "healthy",
-			"version": "1.0.0",
-		})
-	}))
-	defer server.Close()
-
-	client := NewA2ARegClient(A2ARegClientOptions{
-		RegistryURL: server.URL,
-		APIKey:      "test-key",
-	})
-
-	health, err := client.GetHealth()
-	require.NoError(t, err)
-	assert.Equal(t, "healthy", health["status"])
-	assert.Equal(t, "1.0.0", health["version"])
-}
-
 func TestA2ARegClient_Authenticate(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "/auth/oauth/token", r.URL.Path)
@@ -89,8 +74,8 @@ func TestA2ARegClient_Authenticate(t *testing.T) {
 
 	err := client.Authenticate()
 	require.NoError(t, err)
-	assert.Equal(t, "test-token", client.accessToken)
-	assert.NotNil(t, client.tokenExpiresAt)
+	assert.Equal(t, "test-token", client.token())
+	assert.NotNil(t, client.tokens.expiresAt)
 }
 
 func TestA2ARegClient_Authenticate_WithAPIKey(t *testing.T) {
@@ -102,7 +87,7 @@ func TestA2ARegClient_Authenticate_WithAPIKey(t *testing.T) {
 	err := client.Authenticate()
 	require.NoError(t, err)
 	// Should not make any requests
-	assert.Equal(t, "test-key", client.apiKey)
+	assert.True(t, client.apiKey.equal("test-key"))
 }
 
 func TestA2ARegClient_Authenticate_MissingCredentials(t *testing.T) {
@@ -115,33 +100,6 @@ func TestA2ARegClient_Authenticate_MissingCredentials(t *testing.T) {
 	assert.IsType(t, &AuthenticationError{}, err)
 }
 
-func TestA2ARegClient_ListAgents(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/agents/public", r.URL.Path)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"agents": []map[string]interface{}{
-				{"id": "agent-1", "name": "Test Agent"},
-			},
-			"total": 1,
-			"page":  1,
-			"limit": 20,
-		})
-	}))
-	defer server.Close()
-
-	client := NewA2ARegClient(A2ARegClientOptions{
-		RegistryURL: server.URL,
-		APIKey:      "test-key",
-	})
-
-	result, err := client.ListAgents(1, 20, true)
-	require.NoError(t, err)
-	agents, _ := result["agents"].([]interface{})
-	assert.Len(t, agents, 1)
-}
-
 func TestA2ARegClient_GetAgent(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "/agents/agent-1", r.URL.Path)
@@ -334,33 +292,7 @@ func TestA2ARegClient_ErrorHandling(t *testing.T) {
 func TestA2ARegClient_SetAPIKey(t *testing.T) {
 	client := NewA2ARegClient(DefaultOptions())
 	client.SetAPIKey("new-key")
-	assert.Equal(t, "new-key", client.apiKey)
-}
-
-func TestA2ARegClient_SearchAgents(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/agents/search", r.URL.Path)
-		assert.Equal(t, "POST", r.Method)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"agents": []map[string]interface{}{
-				{"id": "agent-1", "name": "Recipe Agent"},
-			},
-			"total": 1,
-		})
-	}))
-	defer server.Close()
-
-	client := NewA2ARegClient(A2ARegClientOptions{
-		RegistryURL: server.URL,
-		APIKey:      "test-key",
-	})
-
-	result, err := client.SearchAgents("recipe", map[string]interface{}{"tags": []string{"cooking"}}, false, 1, 20)
-	require.NoError(t, err)
-	agents, _ := result["agents"].([]interface{})
-	assert.Len(t, agents, 1)
+	assert.True(t, client.apiKey.equal("new-key"))
 }
 
 func TestA2ARegClient_GenerateAPIKey(t *testing.T) {
@@ -433,8 +365,8 @@ func TestA2ARegClient_ListAPIKeys(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode([]map[string]interface{}{
 			{
-				"key_id":    "key-1",
-				"scopes":    []string{"read"},
+				"key_id":     "key-1",
+				"scopes":     []string{"read"},
 				"created_at": "2024-01-01T00:00:00Z",
 			},
 		})
@@ -451,3 +383,479 @@ func TestA2ARegClient_ListAPIKeys(t *testing.T) {
 	assert.Len(t, keys, 1)
 }
 
+func TestA2ARegClient_ValidateAgent_ProviderInfo(t *testing.T) {
+	client := NewA2ARegClient(DefaultOptions())
+	base := func(p *AgentProvider) *Agent {
+		return &Agent{Name: "Test Agent", Description: "A test agent", Version: "1.0.0", ProviderInfo: p}
+	}
+
+	assert.NoError(t, client.ValidateAgent(base(&AgentProvider{Organization: "acme", URL: "https://acme.example.com"})))
+	assert.IsType(t, &ValidationError{}, client.ValidateAgent(base(&AgentProvider{URL: "https://acme.example.com"})))
+	assert.IsType(t, &ValidationError{}, client.ValidateAgent(base(&AgentProvider{Organization: "acme", URL: "not a url"})))
+}
+
+func TestConvertAgentToCard_Provider(t *testing.T) {
+	location := "https://agent.example.com"
+
+	card, _, err := ConvertAgentToCard(&Agent{Name: "a", Provider: "acme", LocationURL: &location})
+	require.NoError(t, err)
+	assert.Equal(t, &AgentProvider{Organization: "acme"}, card.Provider)
+	data, err := json.Marshal(card.Provider)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"organization": "acme"}`, string(data))
+
+	card, _, err = ConvertAgentToCard(&Agent{Name: "a", ProviderInfo: &AgentProvider{Organization: "acme", URL: "https://acme.example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, &AgentProvider{Organization: "acme", URL: "https://acme.example.com"}, card.Provider)
+}
+
+func TestA2ARegClient_ErrorCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		call       func(c *A2ARegClient) error
+		want       string
+	}{
+		{"agent not found", http.StatusNotFound, "", func(c *A2ARegClient) error { _, err := c.GetAgent("x"); return err }, CodeAgentNotFound},
+		{"card not found", http.StatusNotFound, "", func(c *A2ARegClient) error { _, err := c.GetAgentCard("x"); return err }, CodeAgentNotFound},
+		{"delete not found", http.StatusNotFound, "", func(c *A2ARegClient) error { return c.DeleteAgent("x") }, CodeAgentNotFound},
+		{"resource not found", http.StatusNotFound, "", func(c *A2ARegClient) error { _, err := c.GetRegistryStats(); return err }, CodeNotFound},
+		{"auth required", http.StatusUnauthorized, "", func(c *A2ARegClient) error { _, err := c.Health(context.Background()); return err }, CodeAuthRequired},
+		{"access denied", http.StatusForbidden, "", func(c *A2ARegClient) error { _, err := c.Health(context.Background()); return err }, CodeAccessDenied},
+		{"validation", http.StatusUnprocessableEntity, `{"detail":"bad"}`, func(c *A2ARegClient) error { _, err := c.Health(context.Background()); return err }, CodeValidationFailed},
+		{"rate limited", http.StatusTooManyRequests, "", func(c *A2ARegClient) error { _, err := c.Health(context.Background()); return err }, CodeRateLimited},
+		{"server error", http.StatusInternalServerError, "", func(c *A2ARegClient) error { _, err := c.Health(context.Background()); return err }, CodeServerError},
+		{"other api error", http.StatusConflict, "", func(c *A2ARegClient) error { _, err := c.Health(context.Background()); return err }, CodeAPIError},
+		{"server supplied code", http.StatusConflict, `{"detail":"dup","code":"agent_exists"}`, func(c *A2ARegClient) error { _, err := c.Health(context.Background()); return err }, "agent_exists"},
+		{"decode failure", http.StatusOK, `not json`, func(c *A2ARegClient) error { _, err := c.Health(context.Background()); return err }, CodeDecodeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+			assert.Equal(t, tt.want, ErrorCode(tt.call(client)))
+		})
+	}
+}
+
+func TestA2ARegClient_ErrorCodes_Auth(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: "http://localhost:8000"})
+	assert.Equal(t, CodeAuthMissingCredentials, ErrorCode(client.Authenticate()))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client = NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, ClientID: "id", ClientSecret: "wrong"})
+	assert.Equal(t, CodeAuthInvalidClient, ErrorCode(client.Authenticate()))
+
+	server.Close()
+	assert.Equal(t, CodeRequestFailed, ErrorCode(client.Authenticate()))
+}
+
+func TestA2ARegClient_PublishAgent_RequestTooLarge(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	examples := make([]string, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		examples = append(examples, strings.Repeat("example ", 80))
+	}
+	agent := &Agent{
+		Name:        "Big Agent",
+		Description: "An agent with far too many examples",
+		Version:     "1.0.0",
+		Provider:    "test-provider",
+		Skills: []AgentSkill{
+			{ID: "s1", Name: "Skill", Description: "Padded", Tags: []string{"x"}, Examples: examples},
+		},
+	}
+
+	_, err := client.PublishAgent(agent, false)
+	require.Error(t, err)
+	assert.Equal(t, 0, requests, "oversized body must not be sent")
+
+	var vErr *ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, CodeRequestTooLarge, vErr.Code)
+	assert.Equal(t, int64(DefaultMaxRequestBytes), vErr.Details["limit"])
+	assert.Greater(t, vErr.Details["size"], DefaultMaxRequestBytes)
+	largest := vErr.Details["largest_fields"].([]fieldSize)
+	require.NotEmpty(t, largest)
+	assert.Equal(t, "card.skills", largest[0].Field)
+	assert.Contains(t, vErr.Error(), "card.skills")
+}
+
+func TestA2ARegClient_MaxRequestBytes_Custom(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "agent-1", "name": "x"})
+	}))
+	defer server.Close()
+
+	agent := &Agent{Name: "x", Description: strings.Repeat("d", 200)}
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", MaxRequestBytes: 100})
+	_, err := client.UpdateAgent("agent-1", agent)
+	assert.Equal(t, CodeRequestTooLarge, ErrorCode(err))
+
+	client = NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", MaxRequestBytes: -1})
+	_, err = client.UpdateAgent("agent-1", agent)
+	assert.NoError(t, err)
+}
+
+func TestA2ARegClient_ServerRequestTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+	_, err := client.UpdateAgent("agent-1", &Agent{Name: "x"})
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, CodeRequestTooLarge, ErrorCode(err))
+	assert.Equal(t, int64(DefaultMaxRequestBytes), err.(*ValidationError).Details["limit"])
+}
+
+func TestA2ARegClient_ResponseError_RequestTooLargeLimit(t *testing.T) {
+	limitOf := func(c *A2ARegClient, errorData map[string]interface{}) (string, interface{}) {
+		err := c.responseError(http.StatusRequestEntityTooLarge, errorData).(*ValidationError)
+		return err.Message, err.Details["limit"]
+	}
+
+	message, limit := limitOf(&A2ARegClient{}, nil)
+	assert.Equal(t, "Request body exceeds the registry's size limit", message)
+	assert.Nil(t, limit)
+
+	message, limit = limitOf(&A2ARegClient{maxRequestBytes: -1}, nil)
+	assert.Equal(t, "Request body exceeds the registry's size limit", message)
+	assert.Nil(t, limit)
+
+	message, limit = limitOf(&A2ARegClient{maxRequestBytes: 4096}, map[string]interface{}{"limit": float64(2048)})
+	assert.Equal(t, "Request body exceeds the registry's size limit (2048 bytes)", message)
+	assert.Equal(t, int64(2048), limit)
+}
+
+func TestA2ARegClient_TokenExpiry(t *testing.T) {
+	var tokenCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/oauth/token" {
+			tokenCalls++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": fmt.Sprintf("token-%d", tokenCalls),
+				"expires_in":   3600,
+			})
+			return
+		}
+		assert.Equal(t, fmt.Sprintf("Bearer token-%d", tokenCalls), r.Header.Get("Authorization"))
+		w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	defer server.Close()
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:  server.URL,
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		Clock:        fake,
+	})
+
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, tokenCalls)
+	assert.Equal(t, fake.Now().Add(59*time.Minute), *client.tokens.expiresAt)
+
+	// The token is refreshed 60s before the server-side expiry.
+	fake.Advance(59 * time.Minute)
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, tokenCalls)
+
+	fake.Advance(time.Second)
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, tokenCalls)
+}
+
+func TestA2ARegClient_ListSummaries(t *testing.T) {
+	full := map[string]interface{}{
+		"agents": []map[string]interface{}{
+			{
+				"id":           "agent-1",
+				"name":         "Recipe Agent",
+				"description":  "Finds recipes",
+				"version":      "1.2.0",
+				"provider":     map[string]interface{}{"organization": "acme", "url": "https://acme.example.com"},
+				"tags":         []string{"cooking"},
+				"capabilities": map[string]interface{}{"streaming": true},
+				"skills": []map[string]interface{}{
+					{"id": "s1", "name": "Search", "description": "Search recipes", "tags": []string{"search"}, "examples": []string{"pasta"}},
+				},
+			},
+		},
+		"total": 1,
+	}
+	summary := map[string]interface{}{
+		"agents": []map[string]interface{}{
+			{
+				"id":           "agent-1",
+				"name":         "Recipe Agent",
+				"version":      "1.2.0",
+				"provider":     "acme",
+				"tags":         []string{"cooking"},
+				"capabilities": map[string]interface{}{"streaming": true, "pushNotifications": false},
+			},
+		},
+		"total": 1,
+	}
+
+	list := func(t *testing.T, response map[string]interface{}) []AgentSummary {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/agents/public", r.URL.Path)
+			assert.Equal(t, "summary", r.URL.Query().Get("fields"))
+			assert.Equal(t, "20", r.URL.Query().Get("limit"))
+			json.NewEncoder(w).Encode(response)
+		}))
+		defer server.Close()
+
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+		summaries, err := client.ListSummaries(1, 20, true)
+		require.NoError(t, err)
+		return summaries
+	}
+
+	fromSummary := list(t, summary)
+	fromFull := list(t, full)
+	assert.Equal(t, fromSummary, fromFull)
+	assert.Equal(t, []AgentSummary{{
+		ID:           "agent-1",
+		Name:         "Recipe Agent",
+		Version:      "1.2.0",
+		Provider:     "acme",
+		Tags:         []string{"cooking"},
+		Capabilities: CapabilityFlags{Streaming: true},
+	}}, fromSummary)
+}
+
+func TestConvertAgentToCard_Interfaces(t *testing.T) {
+	agent := testPublishAgent()
+	agent.PreferredTransport = "grpc"
+	agent.Interfaces = []AgentInterfaceEntry{
+		{Transport: "jsonrpc", URL: "https://agent.example.com/a2a"},
+		{Transport: "grpc", URL: "https://agent.example.com:50051"},
+	}
+	card, _, err := ConvertAgentToCard(agent)
+	require.NoError(t, err)
+
+	assert.Equal(t, TransportGRPC, card.Interface.PreferredTransport)
+	assert.Equal(t, []map[string]interface{}{
+		{"transport": "jsonrpc", "url": "https://agent.example.com/a2a"},
+		{"transport": "grpc", "url": "https://agent.example.com:50051"},
+	}, card.Interface.AdditionalInterfaces)
+	assert.Equal(t, "https://agent.example.com:50051", card.URL)
+
+	// Without interfaces the legacy defaults apply.
+	card, _, err = ConvertAgentToCard(testPublishAgent())
+	require.NoError(t, err)
+	assert.Equal(t, TransportJSONRPC, card.Interface.PreferredTransport)
+	assert.Empty(t, card.Interface.AdditionalInterfaces)
+}
+
+func TestA2ARegClient_ValidateAgent_Interfaces(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{})
+
+	agent := testPublishAgent()
+	agent.PreferredTransport = "websocket"
+	assert.Error(t, client.ValidateAgent(agent))
+
+	agent.PreferredTransport = "http"
+	agent.Interfaces = []AgentInterfaceEntry{{Transport: "smtp", URL: "mailto:agent@example.com"}}
+	assert.Error(t, client.ValidateAgent(agent))
+
+	agent.Interfaces = []AgentInterfaceEntry{{Transport: "http"}}
+	assert.Error(t, client.ValidateAgent(agent))
+
+	agent.Interfaces = []AgentInterfaceEntry{{Transport: "http", URL: "https://agent.example.com"}}
+	assert.NoError(t, client.ValidateAgent(agent))
+}
+
+func TestA2ARegClient_PublishAgent_MultipleInterfaces(t *testing.T) {
+	registry := fakeregistry.New()
+	server := registry.Start()
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	agent := testPublishAgent()
+	agent.PreferredTransport = "jsonrpc"
+	agent.Interfaces = []AgentInterfaceEntry{
+		{Transport: "jsonrpc", URL: "https://agent.example.com/a2a"},
+		{Transport: "grpc", URL: "https://agent.example.com:50051"},
+	}
+
+	published, err := client.PublishAgent(agent, true)
+	require.NoError(t, err)
+	got, err := client.GetAgent(*published.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, TransportJSONRPC, got.PreferredTransport)
+	assert.Equal(t, agent.Interfaces, got.Interfaces)
+}
+
+func TestValidationError_FieldErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+		fields  []FieldError
+	}{
+		{
+			name: "detail array",
+			body: `{"detail": [
+				{"loc": ["body", "name"], "msg": "field required", "type": "value_error.missing"},
+				{"loc": ["body", "skills", 0, "id"], "msg": "str type expected", "type": "type_error.str"},
+				{"loc": ["body", "url"], "msg": "invalid or missing URL scheme", "type": "value_error.url.scheme"},
+				{"loc": ["query", "limit"], "msg": "ensure this value is less than or equal to 100", "type": "value_error.number.not_le"}
+			]}`,
+			message: "Validation error: body.name: field required; body.skills.0.id: str type expected; body.url: invalid or missing URL scheme (and 1 more)",
+			fields: []FieldError{
+				{Loc: []string{"body", "name"}, Message: "field required", Type: "value_error.missing"},
+				{Loc: []string{"body", "skills", "0", "id"}, Message: "str type expected", Type: "type_error.str"},
+				{Loc: []string{"body", "url"}, Message: "invalid or missing URL scheme", Type: "value_error.url.scheme"},
+				{Loc: []string{"query", "limit"}, Message: "ensure this value is less than or equal to 100", Type: "value_error.number.not_le"},
+			},
+		},
+		{
+			name:    "detail string",
+			body:    `{"detail": "name is required"}`,
+			message: "Validation error: name is required",
+		},
+		{
+			name:    "no detail",
+			body:    `{"code": "invalid_card"}`,
+			message: "Validation error",
+		},
+		{
+			name:    "invalid JSON",
+			body:    `<html>Unprocessable</html>`,
+			message: "Validation error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+			_, err := client.PublishAgent(testPublishAgent(), false)
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.message, err.Error())
+			assert.Equal(t, tt.fields, validationErr.Fields())
+			assert.Equal(t, http.StatusUnprocessableEntity, StatusCode(err))
+		})
+	}
+}
+
+// TestValidationError_Fixtures replays 422 bodies captured from FastAPI
+// (pydantic v1 and v2) and the malformed variants seen in the wild.
+func TestValidationError_Fixtures(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		message  string
+		pointers []string
+	}{
+		{
+			fixture:  "pydantic_v1.json",
+			message:  "Validation error: body.name: field required; body.skills.0.id: str type expected",
+			pointers: []string{"/body/name", "/body/skills/0/id"},
+		},
+		{
+			fixture:  "pydantic_v2.json",
+			message:  "Validation error: body.name: Field required; body.url: Input should be a valid URL, relative URL without a base; body.metadata.a/b~c: Input should be a valid string",
+			pointers: []string{"/body/name", "/body/url", "/body/metadata/a~1b~0c"},
+		},
+		{
+			fixture: "http_exception.json",
+			message: "Validation error: Agent card is missing a name",
+		},
+		{
+			fixture:  "detail_object.json",
+			message:  "Validation error: body.url: URL must use https",
+			pointers: []string{"/body/url"},
+		},
+		{
+			fixture: "detail_object_message.json",
+			message: "Validation error: Agent card failed schema validation",
+		},
+		{
+			fixture:  "malformed_entries.json",
+			message:  "Validation error: body.tags; description is too long",
+			pointers: []string{"/body/tags", ""},
+		},
+		{
+			fixture:  "duplicate_detail.json",
+			message:  "Validation error: query.limit: ensure this value is less than or equal to 100",
+			pointers: []string{"/query/limit"},
+		},
+		{
+			fixture: "empty_detail.json",
+			message: "Validation error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", "validation", tt.fixture))
+			require.NoError(t, err)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write(body)
+			}))
+			defer server.Close()
+			client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+			_, err = client.PublishAgent(testPublishAgent(), false)
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.message, err.Error())
+			assert.False(t, strings.HasSuffix(err.Error(), ":"))
+
+			var pointers []string
+			for _, field := range validationErr.Fields() {
+				pointers = append(pointers, field.Pointer())
+			}
+			assert.Equal(t, tt.pointers, pointers)
+		})
+	}
+}
+
+func TestAPIError_NoTrailingColon(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"detail": {"unexpected": true}}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	_, err := client.GetAgent("agent-1")
+	require.Error(t, err)
+	assert.Equal(t, "API error: status 400", err.Error())
+}
@@ -0,0 +1,11 @@
+package a2areg
+
+import "a2areg/internal/clock"
+
+// Clock is the time source used by the client for token expiry, cache TTLs,
+// retry backoff, and heartbeats. It exists so that time-dependent behavior
+// can be tested deterministically; production code should leave it unset.
+type Clock = clock.Clock
+
+// Timer is the timer abstraction returned by Clock.NewTimer.
+type Timer = clock.Timer
@@ -0,0 +1,128 @@
+package a2areg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenRevocationRegistry issues one token and records the form of every
+// revocation request.
+type tokenRevocationRegistry struct {
+	*httptest.Server
+	mu          sync.Mutex
+	revocations []map[string]string
+	status      int // revocation response status; 200 when zero
+}
+
+func newTokenRevocationRegistry(t *testing.T) *tokenRevocationRegistry {
+	r := &tokenRevocationRegistry{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/auth/oauth/token":
+			w.Write([]byte(`{"access_token": "issued-token", "expires_in": 3600}`))
+		case "/auth/oauth/revoke":
+			require.NoError(t, req.ParseForm())
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.revocations = append(r.revocations, map[string]string{
+				"token":           req.PostForm.Get("token"),
+				"token_type_hint": req.PostForm.Get("token_type_hint"),
+				"client_id":       req.PostForm.Get("client_id"),
+				"client_secret":   req.PostForm.Get("client_secret"),
+			})
+			if r.status != 0 {
+				w.WriteHeader(r.status)
+			}
+		default:
+			w.Write([]byte(`{"status": "healthy"}`))
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *tokenRevocationRegistry) revoked() []map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]string(nil), r.revocations...)
+}
+
+func TestCloseContext_RevokesTokenOnce(t *testing.T) {
+	registry := newTokenRevocationRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:   registry.URL,
+		ClientID:      "svc",
+		ClientSecret:  "secret",
+		RevokeOnClose: true,
+	})
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.CloseContext(context.Background()))
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, client.Close())
+
+	assert.Equal(t, []map[string]string{{
+		"token":           "issued-token",
+		"token_type_hint": "access_token",
+		"client_id":       "svc",
+		"client_secret":   "secret",
+	}}, registry.revoked())
+	assert.True(t, client.tokens.accessToken.Empty())
+
+	_, err = client.Health(context.Background())
+	assert.Equal(t, CodeClientClosed, ErrorCode(err))
+}
+
+func TestCloseContext_NoRevocationByDefault(t *testing.T) {
+	registry := newTokenRevocationRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, ClientID: "svc", ClientSecret: "secret"})
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, client.Close())
+	assert.Empty(t, registry.revoked())
+}
+
+func TestCloseContext_NoTokenNoRevocation(t *testing.T) {
+	registry := newTokenRevocationRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "key", RevokeOnClose: true})
+
+	require.NoError(t, client.Close())
+	assert.Empty(t, registry.revoked())
+}
+
+func TestCloseContext_RevocationFailureStillCloses(t *testing.T) {
+	registry := newTokenRevocationRegistry(t)
+	registry.status = http.StatusBadRequest
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:   registry.URL,
+		ClientID:      "svc",
+		ClientSecret:  "secret",
+		RevokeOnClose: true,
+	})
+	require.NoError(t, client.Authenticate())
+
+	err := client.CloseContext(context.Background())
+	var authErr *AuthenticationError
+	require.ErrorAs(t, err, &authErr)
+	assert.Equal(t, http.StatusBadRequest, StatusCode(err))
+	assert.True(t, client.tokens.accessToken.Empty())
+	assert.NoError(t, client.Close())
+
+	_, err = client.Health(context.Background())
+	assert.Equal(t, CodeClientClosed, ErrorCode(err))
+}
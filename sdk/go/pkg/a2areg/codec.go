@@ -0,0 +1,80 @@
+package a2areg
+
+import (
+	"mime"
+	"net/http"
+)
+
+// Codec converts request and response bodies between JSON, which the
+// client's models are written in, and another media type. Set one with
+// A2ARegClientOptions.WireFormat or Codec.
+type Codec interface {
+	// ContentType is the media type the codec reads and writes.
+	ContentType() string
+	// FromJSON converts a JSON request body into the codec's format.
+	FromJSON(data []byte) ([]byte, error)
+	// ToJSON converts a response body in the codec's format into JSON.
+	ToJSON(data []byte) ([]byte, error)
+}
+
+// WireFormat selects the encoding of registry requests and responses.
+type WireFormat int
+
+const (
+	// WireFormatJSON sends and accepts JSON. It is the default.
+	WireFormatJSON WireFormat = iota
+	// WireFormatCBOR sends CBOR and asks for CBOR responses, which are
+	// smaller on constrained links; see CBORCodec.
+	WireFormatCBOR
+)
+
+func (f WireFormat) String() string {
+	switch f {
+	case WireFormatJSON:
+		return "json"
+	case WireFormatCBOR:
+		return "cbor"
+	}
+	return "unknown"
+}
+
+// codecFor returns the codec opts select, or nil for plain JSON.
+func codecFor(opts A2ARegClientOptions) Codec {
+	if opts.Codec != nil {
+		return opts.Codec
+	}
+	if opts.WireFormat == WireFormatCBOR {
+		return CBORCodec{}
+	}
+	return nil
+}
+
+// setCodecHeaders makes a request ask for the codec's format, with JSON as
+// the fallback, and labels a body encoded with it.
+func (c *A2ARegClient) setCodecHeaders(h http.Header, hasBody bool) {
+	if c.codec == nil {
+		return
+	}
+	h.Set("Accept", c.codec.ContentType()+", application/json;q=0.5")
+	if hasBody {
+		h.Set("Content-Type", c.codec.ContentType())
+	}
+}
+
+// decodeWireBody converts a response body in the codec's format to JSON.
+// Bodies of any other type, such as the JSON a registry may answer with
+// regardless of Accept, are returned as they are.
+func (c *A2ARegClient) decodeWireBody(resp *http.Response, body []byte) ([]byte, error) {
+	if c.codec == nil || len(body) == 0 {
+		return body, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != c.codec.ContentType() {
+		return body, nil
+	}
+	data, err := c.codec.ToJSON(body)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to decode "+mediaType+" response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	return data, nil
+}
@@ -0,0 +1,28 @@
+package a2areg
+
+import "sync"
+
+// Deprecated methods stay as thin wrappers over the typed methods that
+// replace them: both share the request and the decoding, and the wrapper
+// only hands back the decoded document as a map. They are built unless the
+// a2areg_strict build tag is set, so that
+//
+//	go build -tags a2areg_strict ./...
+//
+// fails wherever code still uses them.
+
+// deprecationWarned holds the deprecated methods that have logged their
+// warning in this process.
+var deprecationWarned sync.Map
+
+// warnDeprecated logs that method is deprecated in favor of replacement,
+// once per process, when the client has DeprecationWarnings set.
+func (c *A2ARegClient) warnDeprecated(method, replacement string) {
+	if !c.deprecationWarnings {
+		return
+	}
+	if _, warned := deprecationWarned.LoadOrStore(method, true); warned {
+		return
+	}
+	c.logger.Warn("a2areg: deprecated method called", "method", method, "replacement", replacement)
+}
@@ -0,0 +1,212 @@
+//go:build !a2areg_strict
+
+package a2areg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compatRegistry is a fake registry with public and private agents.
+func compatRegistry(t *testing.T, configure func(*fakeregistry.Registry)) *A2ARegClient {
+	registry := fakeregistry.New()
+	for i, name := range []string{"Recipe Agent", "Travel Agent", "Recipe Planner", "Weather Agent", "Private Recipes"} {
+		registry.Put(fmt.Sprintf("agent-%d", i+1), i < 4, map[string]interface{}{
+			"name":        name,
+			"description": "An example agent",
+			"version":     "1.0.0",
+			"provider":    map[string]interface{}{"organization": "acme"},
+			"skills":      []interface{}{map[string]interface{}{"id": "s1", "name": "Skill", "description": "Does it", "tags": []interface{}{"example"}}},
+		})
+	}
+	if configure != nil {
+		configure(registry)
+	}
+	server := registry.Start()
+	t.Cleanup(server.Close)
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+}
+
+// compatScenario is one request made through a legacy method and through
+// its typed replacement. legacy decodes its map into the typed form.
+type compatScenario struct {
+	name   string
+	legacy func(c *A2ARegClient) (interface{}, error)
+	typed  func(c *A2ARegClient) (interface{}, error)
+}
+
+func compatScenarios() []compatScenario {
+	ctx := context.Background()
+	listing := func(page, limit int, publicOnly bool) compatScenario {
+		return compatScenario{
+			name: fmt.Sprintf("ListAgents page=%d limit=%d public=%t", page, limit, publicOnly),
+			legacy: func(c *A2ARegClient) (interface{}, error) {
+				doc, err := c.ListAgents(page, limit, publicOnly)
+				if err != nil {
+					return nil, err
+				}
+				var list AgentList
+				if err := remarshal(doc, &list); err != nil {
+					return nil, err
+				}
+				for i := range list.Agents {
+					list.Agents[i].interfacesFromCard()
+				}
+				return &list, nil
+			},
+			typed: func(c *A2ARegClient) (interface{}, error) {
+				return c.ListAgentsPage(ctx, page, limit, publicOnly)
+			},
+		}
+	}
+	search := func(query string, filters map[string]interface{}, typedFilters *SearchFilters) compatScenario {
+		return compatScenario{
+			name: fmt.Sprintf("SearchAgents %q %v", query, filters),
+			legacy: func(c *A2ARegClient) (interface{}, error) {
+				doc, err := c.SearchAgents(query, filters, false, 1, 10)
+				if err != nil {
+					return nil, err
+				}
+				var resp SearchResponse
+				return &resp, remarshal(doc, &resp)
+			},
+			typed: func(c *A2ARegClient) (interface{}, error) {
+				return c.Search(ctx, SearchRequest{Query: query, Filters: typedFilters, Page: 1, Limit: 10})
+			},
+		}
+	}
+	return []compatScenario{
+		{
+			name: "GetHealth",
+			legacy: func(c *A2ARegClient) (interface{}, error) {
+				doc, err := c.GetHealth()
+				if err != nil {
+					return nil, err
+				}
+				var health HealthStatus
+				return &health, remarshal(doc, &health)
+			},
+			typed: func(c *A2ARegClient) (interface{}, error) {
+				return c.Health(ctx)
+			},
+		},
+		listing(1, 2, true),
+		listing(2, 2, true),
+		listing(1, 10, false),
+		listing(9, 10, true),
+		search("recipe", nil, nil),
+		search("agent", map[string]interface{}{"tags": []interface{}{"example"}}, &SearchFilters{Tags: []string{"example"}}),
+		search("nothing matches", nil, nil),
+	}
+}
+
+func remarshal(doc interface{}, v interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func TestLegacyWrappers_MatchTypedMethods(t *testing.T) {
+	clients := map[string]*A2ARegClient{
+		"registry": compatRegistry(t, nil),
+		"search fallback": compatRegistry(t, func(r *fakeregistry.Registry) {
+			r.DisableSearch = true
+		}),
+	}
+	clients["search fallback"].searchFallback = true
+
+	for clientName, client := range clients {
+		for _, scenario := range compatScenarios() {
+			t.Run(clientName+"/"+scenario.name, func(t *testing.T) {
+				legacy, legacyErr := scenario.legacy(client)
+				typed, typedErr := scenario.typed(client)
+				require.NoError(t, typedErr)
+				require.NoError(t, legacyErr)
+				assert.Equal(t, typed, legacy)
+			})
+		}
+	}
+}
+
+func TestLegacyWrappers_MatchTypedErrors(t *testing.T) {
+	client := compatRegistry(t, nil)
+	client.registryURL += "/missing"
+
+	for _, scenario := range compatScenarios() {
+		t.Run(scenario.name, func(t *testing.T) {
+			_, legacyErr := scenario.legacy(client)
+			_, typedErr := scenario.typed(client)
+			require.Error(t, typedErr)
+			assert.Equal(t, typedErr.Error(), legacyErr.Error())
+			assert.Equal(t, ErrorCode(typedErr), ErrorCode(legacyErr))
+			assert.Equal(t, StatusCode(typedErr), StatusCode(legacyErr))
+		})
+	}
+}
+
+func TestSearchAgents_SendsFiltersAsGiven(t *testing.T) {
+	var sent []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		sent = append(sent, body["filters"])
+		w.Write([]byte(`{"agents": [], "total": 0}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	filters := map[string]interface{}{"tags": []interface{}{"cooking"}, "min_rating": float64(4)}
+	_, err := client.SearchAgents("recipe", filters, false, 1, 10)
+	require.NoError(t, err)
+	_, err = client.Search(context.Background(), SearchRequest{Query: "recipe", Filters: &SearchFilters{
+		Tags:  []string{"cooking"},
+		Extra: map[string]interface{}{"min_rating": 4, "tags": "overridden"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{filters, filters}, sent, "filters the SDK does not model are kept")
+}
+
+func TestDeprecationWarnings(t *testing.T) {
+	deprecationWarned.Range(func(key, _ interface{}) bool {
+		deprecationWarned.Delete(key)
+		return true
+	})
+	var logs bytes.Buffer
+	client := compatRegistry(t, nil)
+	client.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	_, err := client.GetHealth()
+	require.NoError(t, err)
+	assert.Empty(t, logs.String(), "warnings are off by default")
+
+	client.deprecationWarnings = true
+	for i := 0; i < 3; i++ {
+		_, err = client.GetHealth()
+		require.NoError(t, err)
+		_, err = client.ListAgents(1, 10, true)
+		require.NoError(t, err)
+	}
+	other := compatRegistry(t, nil)
+	other.logger, other.deprecationWarnings = client.logger, true
+	_, err = other.GetHealth()
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2, "one warning per method per process")
+	assert.Contains(t, lines[0], "method=GetHealth replacement=Health")
+	assert.Contains(t, lines[1], "method=ListAgents replacement=ListAgentsPage")
+}
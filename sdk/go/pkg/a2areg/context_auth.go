@@ -0,0 +1,35 @@
+package a2areg
+
+import "context"
+
+// contextAuthKey is the context key for per-call credential overrides.
+type contextAuthKey struct{}
+
+// contextAuth is a credential carried in a context.
+type contextAuth struct {
+	apiKey string
+	token  string
+}
+
+// WithContextAPIKey returns a context whose requests authenticate with apiKey
+// instead of the client's credentials. The override applies only to calls
+// made with the returned context; it is never cached on the client, so one
+// client can serve many tenants while reusing its connections.
+func WithContextAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, contextAuthKey{}, contextAuth{apiKey: apiKey})
+}
+
+// WithContextToken is like WithContextAPIKey for an OAuth access token. The
+// token is sent as-is; the client does not refresh it.
+func WithContextToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, contextAuthKey{}, contextAuth{token: token})
+}
+
+// authFromContext returns the credential override carried by ctx, if any.
+func authFromContext(ctx context.Context) (contextAuth, bool) {
+	auth, ok := ctx.Value(contextAuthKey{}).(contextAuth)
+	if !ok || (auth.apiKey == "" && auth.token == "") {
+		return contextAuth{}, false
+	}
+	return auth, true
+}
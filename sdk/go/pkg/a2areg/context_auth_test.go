@@ -0,0 +1,94 @@
+package a2areg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestA2ARegClient_ContextAuthOverrides(t *testing.T) {
+	var tokenCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/oauth/token" {
+			tokenCalls.Add(1)
+			w.Write([]byte(`{"access_token":"client-token","expires_in":3600}`))
+			return
+		}
+		// Echo the credentials back as the agent name.
+		fmt.Fprintf(w, `{"id":"agent-1","name":%q}`, r.Header.Get("Authorization")+r.Header.Get("X-API-Key"))
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, ClientID: "id", ClientSecret: "secret"})
+
+	tenantA := WithContextAPIKey(context.Background(), "key-a")
+	tenantB := WithContextAPIKey(context.Background(), "key-b")
+	user := WithContextToken(context.Background(), "user-token")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for ctx, want := range map[context.Context]string{
+			tenantA: "key-a",
+			tenantB: "key-b",
+			user:    "Bearer user-token",
+		} {
+			wg.Add(1)
+			go func(ctx context.Context, want string) {
+				defer wg.Done()
+				agent, err := client.GetAgentFields(ctx, "agent-1", []string{"name"})
+				if assert.NoError(t, err) {
+					assert.Equal(t, want, agent.Name)
+				}
+			}(ctx, want)
+		}
+	}
+	wg.Wait()
+
+	// Overrides never touch the client's own token state.
+	assert.Equal(t, int32(0), tokenCalls.Load())
+	assert.Empty(t, client.token())
+
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer client-token", agent.Name)
+	assert.Equal(t, int32(1), tokenCalls.Load())
+}
+
+func TestA2ARegClient_PublishWithContextKey(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-API-Key")+" "+r.Header.Get("X-Trace"))
+		mu.Unlock()
+		if r.Header.Get("X-API-Key") != "tenant-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case "POST":
+			w.Write([]byte(`{"agentId":"agent-1"}`))
+		default:
+			w.Write([]byte(`{"id":"agent-1","name":"Recipe Agent"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL})
+	ctx := WithContextOptions(WithContextAPIKey(context.Background(), "tenant-key"), WithHeader("X-Trace", "t1"))
+	receipt, err := client.PublishAgentVerbose(ctx, testPublishAgent(), PublishOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Recipe Agent", receipt.Agent.Name)
+	assert.Contains(t, seen, "GET /agents/agent-1 tenant-key t1")
+	for _, request := range seen {
+		assert.True(t, strings.HasSuffix(request, " tenant-key t1"), "every request uses the publish context: %s", request)
+	}
+}
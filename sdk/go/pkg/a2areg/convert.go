@@ -0,0 +1,192 @@
+package a2areg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ConversionWarning reports an Agent field that ConvertAgentToCard could not
+// carry into the card, or carried only in part.
+type ConversionWarning struct {
+	// Field is the JSON path of the field on the Agent, e.g. "tee_details"
+	// or "auth_schemes[1]".
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// String formats the warning as "field: message".
+func (w ConversionWarning) String() string {
+	return w.Field + ": " + w.Message
+}
+
+// ConvertAgentToCard builds the Agent Card the registry stores for agent.
+//
+// Every card field is derived from the agent: name, description, version,
+// provider, capabilities, auth schemes, skills and interfaces. From an
+// embedded AgentCard the documentation URL, signature, default modes and
+// any extra security schemes are carried over; its other fields are
+// replaced by the agent's own, with a warning where they differed. Agent
+// fields the card has no place for (tags, location_type, tee_details)
+// produce a warning each. ID, ClientID, timestamps, IsPublic and IsActive
+// are registry metadata rather than card content and are not reported.
+func ConvertAgentToCard(agent *Agent) (*AgentCardSpec, []ConversionWarning, error) {
+	if agent == nil {
+		return nil, nil, NewValidationError("Agent is required", nil)
+	}
+
+	var warnings []ConversionWarning
+	warn := func(field, format string, args ...interface{}) {
+		warnings = append(warnings, ConversionWarning{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	embedded := agent.AgentCard
+	if embedded == nil {
+		embedded = &AgentCardSpec{}
+	}
+
+	card := &AgentCardSpec{
+		Name:             agent.Name,
+		Description:      agent.Description,
+		Version:          agent.Version,
+		Capabilities:     cardCapabilities(agent.Capabilities),
+		SecuritySchemes:  make(map[string]SecurityScheme),
+		Skills:           make([]AgentSkill, 0, len(agent.Skills)),
+		DocumentationURL: embedded.DocumentationURL,
+		Signature:        embedded.Signature,
+	}
+	if agent.Capabilities == nil && agent.AgentCard != nil {
+		card.Capabilities = cardCapabilities(&agent.AgentCard.Capabilities)
+	}
+
+	for i, scheme := range agent.AuthSchemes {
+		if scheme.Type == "" {
+			scheme.Type = AuthSchemeAPIKey
+		}
+		if scheme.Location == nil {
+			location := LocationHeader
+			scheme.Location = &location
+		}
+		if scheme.Name == nil {
+			scheme.Name = stringPtr("Authorization")
+		}
+		scheme.Scopes = append([]string(nil), scheme.Scopes...)
+		// Keyed the way SecuritySchemes names schemes decoded from an
+		// array, so a second scheme of a type is kept rather than replacing
+		// the first.
+		name := string(scheme.Type)
+		if _, taken := card.SecuritySchemes[name]; taken {
+			name = fmt.Sprintf("%s_%d", scheme.Type, i)
+		}
+		card.SecuritySchemes[name] = scheme
+	}
+	for name, scheme := range embedded.SecuritySchemes {
+		if _, ok := card.SecuritySchemes[name]; !ok {
+			card.SecuritySchemes[name] = scheme
+		}
+	}
+
+	card.Skills = append(card.Skills, agent.Skills...)
+
+	card.Interface, card.URL = cardInterface(agent, embedded)
+	card.DefaultInputModes = card.Interface.DefaultInputModes
+	card.DefaultOutputModes = card.Interface.DefaultOutputModes
+
+	if agent.ProviderInfo != nil {
+		provider := *agent.ProviderInfo
+		card.Provider = &provider
+	} else if agent.Provider != "" {
+		// Only the organization is known; don't invent a URL for it.
+		card.Provider = &AgentProvider{Organization: agent.Provider}
+	} else if embedded.Provider != nil {
+		provider := *embedded.Provider
+		card.Provider = &provider
+	}
+
+	if len(agent.Tags) > 0 {
+		warn("tags", "the card has no agent-level tags; %d tags were not published (skill tags are kept)", len(agent.Tags))
+	}
+	if agent.LocationType != nil && *agent.LocationType != "" {
+		warn("location_type", "the card has no location type; %q was not published", *agent.LocationType)
+	}
+	if agent.TEEDetails != nil {
+		warn("tee_details", "the card has no TEE details; they were not published")
+	}
+
+	if agent.AgentCard != nil {
+		superseded := []struct {
+			field       string
+			card, agent interface{}
+			set         bool
+		}{
+			{"name", embedded.Name, card.Name, embedded.Name != ""},
+			{"description", embedded.Description, card.Description, embedded.Description != ""},
+			{"url", embedded.URL, card.URL, embedded.URL != ""},
+			{"version", embedded.Version, card.Version, embedded.Version != ""},
+			{"skills", embedded.Skills, card.Skills, len(embedded.Skills) > 0},
+		}
+		for _, s := range superseded {
+			if s.set && !reflect.DeepEqual(s.card, s.agent) {
+				warn("agent_card."+s.field, "differs from the agent's own value, which was published instead")
+			}
+		}
+	}
+
+	return card, warnings, nil
+}
+
+// cardCapabilities resolves capabilities to explicit values, so the card
+// states false rather than leaving capabilities unset.
+func cardCapabilities(capabilities *AgentCapabilities) AgentCapabilities {
+	flags := capabilities.Flags()
+	return AgentCapabilities{
+		Streaming:                         &flags.Streaming,
+		PushNotifications:                 &flags.PushNotifications,
+		StateTransitionHistory:            &flags.StateTransitionHistory,
+		SupportsAuthenticatedExtendedCard: &flags.SupportsAuthenticatedExtendedCard,
+	}
+}
+
+// cardInterface builds the card's interface object and its URL. The URL is
+// the agent's LocationURL, else the URL of the preferred interface, else a
+// placeholder.
+func cardInterface(agent *Agent, embedded *AgentCardSpec) (AgentInterface, string) {
+	iface := AgentInterface{
+		PreferredTransport: TransportJSONRPC,
+		DefaultInputModes:  firstNonEmpty(embedded.Interface.DefaultInputModes, embedded.DefaultInputModes, []string{ModeText}),
+		DefaultOutputModes: firstNonEmpty(embedded.Interface.DefaultOutputModes, embedded.DefaultOutputModes, []string{ModeText}),
+	}
+	cardURL := getStringValue(agent.LocationURL, "https://example.com")
+
+	if agent.PreferredTransport != "" || len(agent.Interfaces) > 0 {
+		preferred := agent.PreferredTransport
+		if preferred == "" {
+			preferred = agent.Interfaces[0].Transport
+		}
+		iface.PreferredTransport = preferred
+
+		for _, entry := range agent.Interfaces {
+			iface.AdditionalInterfaces = append(iface.AdditionalInterfaces, map[string]interface{}{"transport": string(entry.Transport), "url": entry.URL})
+			if agent.LocationURL == nil && entry.Transport == preferred {
+				cardURL = entry.URL
+			}
+		}
+	} else if agent.LocationURL != nil {
+		iface.AdditionalInterfaces = []map[string]interface{}{
+			{"transport": string(TransportHTTP), "url": *agent.LocationURL},
+		}
+	}
+	return iface, cardURL
+}
+
+func firstNonEmpty(lists ...[]string) []string {
+	for _, list := range lists {
+		if len(list) > 0 {
+			return list
+		}
+	}
+	return nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
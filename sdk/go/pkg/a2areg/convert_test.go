@@ -0,0 +1,214 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func warningFields(warnings []ConversionWarning) []string {
+	fields := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		fields = append(fields, w.Field)
+	}
+	return fields
+}
+
+func TestConvertAgentToCard_NoWarningsForCleanAgent(t *testing.T) {
+	card, warnings, err := ConvertAgentToCard(testPublishAgent())
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, "Recipe Agent", card.Name)
+	assert.Equal(t, []string{"text/plain"}, card.DefaultInputModes)
+	require.NotNil(t, card.Capabilities.Streaming)
+	assert.False(t, *card.Capabilities.Streaming)
+}
+
+// Each field below used to be dropped silently. It must now either reach the
+// card or produce a warning.
+func TestConvertAgentToCard_PreviouslyDroppedFields(t *testing.T) {
+	docs := "https://docs.example.com/recipes"
+	algorithm := "RS256"
+	tokenURL := "https://auth.example.com/token"
+	flow := "client_credentials"
+	query := LocationQuery
+	keyName := "api_key"
+	locationType := "url"
+	provider := "sgx"
+
+	tests := []struct {
+		name     string
+		modify   func(a *Agent)
+		transfer func(t *testing.T, card *AgentCardSpec)
+		warning  string
+	}{
+		{
+			name:    "tags",
+			modify:  func(a *Agent) { a.Tags = []string{"food"} },
+			warning: "tags",
+		},
+		{
+			name:    "location_type",
+			modify:  func(a *Agent) { a.LocationType = &locationType },
+			warning: "location_type",
+		},
+		{
+			name:    "tee_details",
+			modify:  func(a *Agent) { a.TEEDetails = &AgentTeeDetails{Enabled: true, Provider: &provider} },
+			warning: "tee_details",
+		},
+		{
+			name:   "documentation url",
+			modify: func(a *Agent) { a.AgentCard = &AgentCardSpec{DocumentationURL: &docs} },
+			transfer: func(t *testing.T, card *AgentCardSpec) {
+				require.NotNil(t, card.DocumentationURL)
+				assert.Equal(t, docs, *card.DocumentationURL)
+			},
+		},
+		{
+			name:   "signature",
+			modify: func(a *Agent) { a.AgentCard = &AgentCardSpec{Signature: &AgentCardSignature{Algorithm: &algorithm}} },
+			transfer: func(t *testing.T, card *AgentCardSpec) {
+				require.NotNil(t, card.Signature)
+				assert.Equal(t, algorithm, *card.Signature.Algorithm)
+			},
+		},
+		{
+			name: "default modes",
+			modify: func(a *Agent) {
+				a.AgentCard = &AgentCardSpec{Interface: AgentInterface{DefaultInputModes: []string{"application/json"}}, DefaultOutputModes: []string{"text/markdown"}}
+			},
+			transfer: func(t *testing.T, card *AgentCardSpec) {
+				assert.Equal(t, []string{"application/json"}, card.Interface.DefaultInputModes)
+				assert.Equal(t, []string{"application/json"}, card.DefaultInputModes)
+				assert.Equal(t, []string{"text/markdown"}, card.Interface.DefaultOutputModes)
+			},
+		},
+		{
+			name: "auth scheme details",
+			modify: func(a *Agent) {
+				a.AuthSchemes = []SecurityScheme{
+					{Type: "oauth2", Flow: &flow, TokenURL: &tokenURL, Scopes: []string{"read"}},
+					{Type: "apiKey", Location: &query, Name: &keyName},
+				}
+			},
+			transfer: func(t *testing.T, card *AgentCardSpec) {
+				oauth := card.SecuritySchemes["oauth2"]
+				assert.Equal(t, flow, *oauth.Flow)
+				assert.Equal(t, tokenURL, *oauth.TokenURL)
+				assert.Equal(t, []string{"read"}, oauth.Scopes)
+				assert.Equal(t, LocationHeader, *oauth.Location)
+				apiKey := card.SecuritySchemes["apiKey"]
+				assert.Equal(t, query, *apiKey.Location)
+				assert.Equal(t, keyName, *apiKey.Name)
+			},
+		},
+		{
+			name: "duplicate auth scheme type",
+			modify: func(a *Agent) {
+				a.AuthSchemes = []SecurityScheme{{Type: "apiKey"}, {Type: "apiKey", Name: &keyName}}
+			},
+			transfer: func(t *testing.T, card *AgentCardSpec) {
+				assert.Equal(t, "Authorization", *card.SecuritySchemes["apiKey"].Name)
+				assert.Equal(t, keyName, *card.SecuritySchemes["apiKey_1"].Name)
+			},
+		},
+		{
+			name: "card-only security scheme",
+			modify: func(a *Agent) {
+				a.AgentCard = &AgentCardSpec{SecuritySchemes: map[string]SecurityScheme{"mTLS": {Type: "mTLS"}}}
+			},
+			transfer: func(t *testing.T, card *AgentCardSpec) {
+				assert.Contains(t, card.SecuritySchemes, "mTLS")
+			},
+		},
+		{
+			name:    "conflicting card name",
+			modify:  func(a *Agent) { a.AgentCard = &AgentCardSpec{Name: "Old Name"} },
+			warning: "agent_card.name",
+		},
+		{
+			name:    "conflicting card skills",
+			modify:  func(a *Agent) { a.AgentCard = &AgentCardSpec{Skills: []AgentSkill{{ID: "old"}}} },
+			warning: "agent_card.skills",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := testPublishAgent()
+			tt.modify(agent)
+			card, warnings, err := ConvertAgentToCard(agent)
+			require.NoError(t, err)
+			if tt.transfer != nil {
+				assert.Empty(t, warnings)
+				tt.transfer(t, card)
+			}
+			if tt.warning != "" {
+				assert.Equal(t, []string{tt.warning}, warningFields(warnings))
+			}
+		})
+	}
+}
+
+func TestConvertAgentToCard_SchemesRoundTrip(t *testing.T) {
+	flow, tokenURL, query, keyName := "client_credentials", "https://auth.example.com/token", LocationQuery, "api_key"
+	agent := testPublishAgent()
+	agent.AuthSchemes = []SecurityScheme{
+		{Type: AuthSchemeOAuth2, Flow: &flow, TokenURL: &tokenURL, Scopes: []string{"agents:read", "agents:write"}},
+		{Type: AuthSchemeAPIKey, Location: &query, Name: &keyName},
+		{Type: AuthSchemeAPIKey},
+	}
+	card, _, err := ConvertAgentToCard(agent)
+	require.NoError(t, err)
+
+	data, err := card.ToJSON()
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.IsType(t, map[string]interface{}{}, doc["securitySchemes"], "securitySchemes is an object keyed by scheme")
+
+	var decoded AgentCardSpec
+	require.NoError(t, decoded.FromJSON(data))
+	assert.Equal(t, card.SecuritySchemes, decoded.SecuritySchemes)
+	require.Len(t, decoded.SecuritySchemes, 3)
+	oauth := decoded.SecuritySchemes["oauth2"]
+	assert.Equal(t, flow, *oauth.Flow)
+	assert.Equal(t, tokenURL, *oauth.TokenURL)
+	assert.Equal(t, []string{"agents:read", "agents:write"}, oauth.Scopes)
+	apiKey := decoded.SecuritySchemes["apiKey"]
+	assert.Equal(t, LocationQuery, *apiKey.Location)
+	assert.Equal(t, keyName, *apiKey.Name)
+	assert.Equal(t, LocationHeader, *decoded.SecuritySchemes["apiKey_2"].Location)
+
+	card.SecuritySchemes["oauth2"].Scopes[0] = "changed"
+	assert.Equal(t, "agents:read", agent.AuthSchemes[0].Scopes[0], "the card does not share the agent's scopes")
+}
+
+func TestConvertAgentToCard_MatchingCardIsNotReported(t *testing.T) {
+	agent := testPublishAgent()
+	agent.AgentCard = &AgentCardSpec{Name: agent.Name, Version: agent.Version, Skills: agent.Skills}
+	_, warnings, err := ConvertAgentToCard(agent)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestConvertAgentToCard_NilAgent(t *testing.T) {
+	_, _, err := ConvertAgentToCard(nil)
+	assert.IsType(t, &ValidationError{}, err)
+}
+
+func TestA2ARegClient_PublishAgentVerbose_ConversionWarnings(t *testing.T) {
+	server, _ := normalizingRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	agent := testPublishAgent()
+	agent.Tags = []string{"food"}
+	agent.TEEDetails = &AgentTeeDetails{Enabled: true}
+	receipt, err := client.PublishAgentVerbose(context.Background(), agent, PublishOptions{SkipNormalizationCheck: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tags", "tee_details"}, warningFields(receipt.ConversionWarnings))
+}
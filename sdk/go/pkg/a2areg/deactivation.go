@@ -0,0 +1,63 @@
+package a2areg
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DeactivateOptions configures DeactivateAgent.
+type DeactivateOptions struct {
+	// Reason records why the agent is deactivated.
+	Reason string
+	// EffectiveAt schedules the deactivation instead of deactivating the
+	// agent now. It must be in the future.
+	EffectiveAt *time.Time
+}
+
+// DeactivationInfo describes an agent's deactivation, as the registry
+// reports it on the agent.
+type DeactivationInfo struct {
+	Reason string `json:"reason,omitempty"`
+	// Actor is who deactivated the agent or scheduled its deactivation.
+	Actor string `json:"actor,omitempty"`
+	// EffectiveAt is when the agent was or will be deactivated.
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+// Pending reports whether the deactivation is scheduled for after now.
+func (d *DeactivationInfo) Pending(now time.Time) bool {
+	return d != nil && d.EffectiveAt.After(now)
+}
+
+// DeactivateAgent deactivates an agent, now or, with opts.EffectiveAt, at
+// a scheduled time. A scheduled deactivation can be withdrawn with
+// CancelScheduledDeactivation. An EffectiveAt that is not in the future is
+// rejected with a *ValidationError before anything is sent.
+func (c *A2ARegClient) DeactivateAgent(ctx context.Context, agentID string, opts DeactivateOptions) error {
+	body := map[string]interface{}{}
+	if reason := strings.TrimSpace(opts.Reason); reason != "" {
+		body["reason"] = reason
+	}
+	if opts.EffectiveAt != nil {
+		if !opts.EffectiveAt.After(c.clock.Now()) {
+			return NewValidationError("Deactivation cannot be scheduled in the past", map[string]interface{}{
+				"agent_id":     agentID,
+				"effective_at": opts.EffectiveAt.UTC().Format(time.RFC3339),
+			})
+		}
+		body["effective_at"] = opts.EffectiveAt.UTC().Format(time.RFC3339)
+	}
+
+	_, err := c.sendMutation(ctx, "POST", "/agents/"+agentID+"/deactivate", agentID, body)
+	c.invalidateAgent(agentID)
+	return agentNotFound(err)
+}
+
+// CancelScheduledDeactivation withdraws an agent's scheduled deactivation.
+// An agent that is already deactivated stays so.
+func (c *A2ARegClient) CancelScheduledDeactivation(ctx context.Context, agentID string) error {
+	_, err := c.sendMutation(ctx, "DELETE", "/agents/"+agentID+"/deactivate", agentID, nil)
+	c.invalidateAgent(agentID)
+	return agentNotFound(err)
+}
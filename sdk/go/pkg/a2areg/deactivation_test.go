@@ -0,0 +1,91 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deactivationRequest struct {
+	method string
+	path   string
+	body   map[string]interface{}
+}
+
+func deactivationRegistry(t *testing.T) (*httptest.Server, *[]deactivationRequest) {
+	var requests []deactivationRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := deactivationRequest{method: r.Method, path: r.URL.Path}
+		if r.Method == "POST" {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req.body))
+		}
+		requests = append(requests, req)
+		if r.URL.Path == "/agents/missing/deactivate" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"success": true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestDeactivateAgent_Immediate(t *testing.T) {
+	server, requests := deactivationRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	require.NoError(t, client.DeactivateAgent(context.Background(), "agent-1", DeactivateOptions{Reason: " superseded by v2 "}))
+	require.Len(t, *requests, 1)
+	assert.Equal(t, deactivationRequest{"POST", "/agents/agent-1/deactivate", map[string]interface{}{"reason": "superseded by v2"}}, (*requests)[0])
+
+	err := client.DeactivateAgent(context.Background(), "missing", DeactivateOptions{})
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+}
+
+func TestDeactivateAgent_Scheduled(t *testing.T) {
+	server, requests := deactivationRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	at := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+	require.NoError(t, client.DeactivateAgent(context.Background(), "agent-1", DeactivateOptions{Reason: "end of quarter", EffectiveAt: &at}))
+	require.Len(t, *requests, 1)
+	assert.Equal(t, map[string]interface{}{
+		"reason":       "end of quarter",
+		"effective_at": at.UTC().Format(time.RFC3339),
+	}, (*requests)[0].body)
+
+	past := time.Now().Add(-time.Minute)
+	err := client.DeactivateAgent(context.Background(), "agent-1", DeactivateOptions{EffectiveAt: &past})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, *requests, 1, "nothing is sent")
+
+	require.NoError(t, client.CancelScheduledDeactivation(context.Background(), "agent-1"))
+	require.Len(t, *requests, 2)
+	assert.Equal(t, deactivationRequest{method: "DELETE", path: "/agents/agent-1/deactivate"}, (*requests)[1])
+}
+
+func TestAgent_DecodesDeactivationInfo(t *testing.T) {
+	var agent Agent
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "agent-1", "name": "Recipe Agent", "is_active": true,
+		"deactivation": {"reason": "end of quarter", "actor": "ops@example.com", "effective_at": "2030-03-31T23:59:59Z"}
+	}`), &agent))
+	require.NotNil(t, agent.Deactivation)
+	assert.Equal(t, "end of quarter", agent.Deactivation.Reason)
+	assert.Equal(t, "ops@example.com", agent.Deactivation.Actor)
+	assert.Equal(t, time.Date(2030, 3, 31, 23, 59, 59, 0, time.UTC), agent.Deactivation.EffectiveAt)
+	assert.True(t, agent.Deactivation.Pending(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, agent.Deactivation.Pending(time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	var active Agent
+	require.NoError(t, json.Unmarshal([]byte(`{"id": "agent-2", "name": "Weather Agent"}`), &active))
+	assert.Nil(t, active.Deactivation)
+	assert.False(t, active.Deactivation.Pending(time.Now()))
+}
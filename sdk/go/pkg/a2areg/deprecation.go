@@ -0,0 +1,48 @@
+package a2areg
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// WithIncludeDeprecated makes ListAgents, ListSummaries and ListAllAgents
+// include deprecated agents, which registries leave out of listings by
+// default.
+func WithIncludeDeprecated() RequestOption {
+	return func(o *requestOptions) {
+		o.includeDeprecated = true
+	}
+}
+
+// DeprecateAgent marks an agent deprecated without deleting it: consumers
+// still find it, flagged with the reason and, if sunsetDate is set, the
+// date it is due to be removed. A sunsetDate that is not in the future is
+// rejected with a *ValidationError before anything is sent.
+func (c *A2ARegClient) DeprecateAgent(ctx context.Context, agentID, reason string, sunsetDate *time.Time) error {
+	body := map[string]interface{}{}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		body["reason"] = reason
+	}
+	if sunsetDate != nil {
+		if !sunsetDate.After(c.clock.Now()) {
+			return NewValidationError("Sunset date must be in the future", map[string]interface{}{
+				"agent_id":    agentID,
+				"sunset_date": sunsetDate.UTC().Format(time.RFC3339),
+			})
+		}
+		body["sunset_date"] = sunsetDate.UTC().Format(time.RFC3339)
+	}
+
+	_, err := c.sendMutation(ctx, "POST", "/agents/"+agentID+"/deprecate", agentID, body)
+	c.invalidateAgent(agentID)
+	return agentNotFound(err)
+}
+
+// UndeprecateAgent withdraws an agent's deprecation, along with its reason
+// and sunset date.
+func (c *A2ARegClient) UndeprecateAgent(ctx context.Context, agentID string) error {
+	_, err := c.sendMutation(ctx, "DELETE", "/agents/"+agentID+"/deprecate", agentID, nil)
+	c.invalidateAgent(agentID)
+	return agentNotFound(err)
+}
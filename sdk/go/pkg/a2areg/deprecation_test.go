@@ -0,0 +1,120 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deprecatedAgentJSON = `{
+	"id": "agent-1",
+	"name": "Legacy Agent",
+	"description": "Old",
+	"version": "1.0.0",
+	"provider": "acme",
+	"is_public": true,
+	"is_active": true,
+	"deprecated": true,
+	"deprecation_reason": "Use Weather Agent v2",
+	"sunset_date": "2025-06-30T00:00:00Z"
+}`
+
+func TestDeprecateAgent(t *testing.T) {
+	var requests []deactivationRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := deactivationRequest{method: r.Method, path: r.URL.Path}
+		if r.Method == "POST" {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req.body))
+		}
+		requests = append(requests, req)
+		if r.URL.Path == "/agents/missing/deprecate" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	sunset := time.Now().Add(60 * 24 * time.Hour).Truncate(time.Second)
+	require.NoError(t, client.DeprecateAgent(context.Background(), "agent-1", " replaced by v2 ", &sunset))
+	require.NoError(t, client.DeprecateAgent(context.Background(), "agent-2", "", nil))
+	require.NoError(t, client.UndeprecateAgent(context.Background(), "agent-1"))
+	assert.Equal(t, []deactivationRequest{
+		{"POST", "/agents/agent-1/deprecate", map[string]interface{}{"reason": "replaced by v2", "sunset_date": sunset.UTC().Format(time.RFC3339)}},
+		{"POST", "/agents/agent-2/deprecate", map[string]interface{}{}},
+		{"DELETE", "/agents/agent-1/deprecate", nil},
+	}, requests)
+
+	past := time.Now().Add(-time.Hour)
+	err := client.DeprecateAgent(context.Background(), "agent-1", "", &past)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Len(t, requests, 3, "nothing is sent for a past sunset date")
+
+	err = client.UndeprecateAgent(context.Background(), "missing")
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+}
+
+func TestAgent_DeprecationFieldsRoundTrip(t *testing.T) {
+	var agent Agent
+	require.NoError(t, agent.FromJSON([]byte(deprecatedAgentJSON)))
+	assert.True(t, agent.Deprecated)
+	assert.Equal(t, "Use Weather Agent v2", agent.DeprecationReason)
+	require.NotNil(t, agent.SunsetDate)
+	assert.Equal(t, time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), *agent.SunsetDate)
+
+	data, err := agent.ToJSON()
+	require.NoError(t, err)
+	var again Agent
+	require.NoError(t, again.FromJSON(data))
+	assert.Equal(t, agent, again)
+
+	var plain Agent
+	require.NoError(t, plain.FromJSON([]byte(`{"name": "Current Agent"}`)))
+	assert.False(t, plain.Deprecated)
+	assert.Nil(t, plain.SunsetDate)
+	data, err = plain.ToJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "deprecat")
+	assert.NotContains(t, string(data), "sunset")
+}
+
+func TestDeprecation_ReadPaths(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/agents/agent-1":
+			w.Write([]byte(deprecatedAgentJSON))
+		case "/agents/search":
+			w.Write([]byte(`{"agents": [` + deprecatedAgentJSON + `], "total": 1}`))
+		default:
+			queries = append(queries, r.URL.Query().Get("include_deprecated"))
+			w.Write([]byte(`{"agents": [` + deprecatedAgentJSON + `], "total": 1}`))
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.True(t, agent.Deprecated)
+	assert.Equal(t, "Use Weather Agent v2", agent.DeprecationReason)
+
+	resp, err := client.Search(context.Background(), SearchRequest{Query: "legacy"})
+	require.NoError(t, err)
+	require.Len(t, resp.Agents, 1)
+	assert.True(t, resp.Agents[0].Deprecated)
+	assert.NotNil(t, resp.Agents[0].SunsetDate)
+
+	_, err = client.ListAgentsPage(context.Background(), 1, 20, true)
+	require.NoError(t, err)
+	_, err = client.ListAgentsPage(context.Background(), 1, 20, true, WithIncludeDeprecated())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "true"}, queries)
+}
@@ -0,0 +1,204 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Diagnostics is a snapshot of a client's configuration and of what it
+// knows about the registry, for attaching to support requests. It holds no
+// secrets: credentials are reported only as set or not set.
+type Diagnostics struct {
+	SDKVersion  string    `json:"sdk_version"`
+	GoVersion   string    `json:"go_version"`
+	GeneratedAt time.Time `json:"generated_at"`
+	AuthMode    AuthMode  `json:"auth_mode"`
+	Closed      bool      `json:"closed"`
+	// ConfigError is the error every call fails with when the options are
+	// invalid; see A2ARegClientOptions.Validate.
+	ConfigError string             `json:"config_error,omitempty"`
+	Options     DiagnosticsOptions `json:"options"`
+	// Server is nil when the capability probe failed; ServerError says why.
+	Server       *ServerCapabilities `json:"server,omitempty"`
+	ServerError  string              `json:"server_error,omitempty"`
+	Connectivity ConnectivityCheck   `json:"connectivity"`
+	// LastCall is the last response received before the report was
+	// gathered, or nil if there was none.
+	LastCall *CallInfo             `json:"last_call,omitempty"`
+	Caches   map[string]CacheStats `json:"caches"`
+	// QueuedOperations is the length of the offline queue, or nil when it
+	// is disabled.
+	QueuedOperations *int `json:"queued_operations,omitempty"`
+}
+
+// DiagnosticsOptions is the effective client configuration, with
+// credentials reduced to whether they are set. Durations are rendered as
+// strings such as "30s".
+type DiagnosticsOptions struct {
+	RegistryURL            string          `json:"registry_url"`
+	Timeout                string          `json:"timeout"`
+	APIKeySet              bool            `json:"api_key_set"`
+	APIKeyHeader           string          `json:"api_key_header"`
+	ClientIDSet            bool            `json:"client_id_set"`
+	ClientSecretSet        bool            `json:"client_secret_set"`
+	Scope                  string          `json:"scope"`
+	AuthPreference         string          `json:"auth_preference"`
+	MaxRequestBytes        int64           `json:"max_request_bytes"`
+	CardCacheTTL           string          `json:"card_cache_ttl,omitempty"`
+	SafeDelete             bool            `json:"safe_delete"`
+	DeterministicIDs       bool            `json:"deterministic_ids"`
+	AllowUnknownTransports bool            `json:"allow_unknown_transports"`
+	SecuritySchemesAsArray bool            `json:"security_schemes_as_array"`
+	SearchFallback         bool            `json:"search_fallback"`
+	MaxFallbackPages       int             `json:"max_fallback_pages"`
+	MaxSearchParallelism   int             `json:"max_search_parallelism"`
+	OfflineQueue           bool            `json:"offline_queue"`
+	TagTaxonomy            bool            `json:"tag_taxonomy"`
+	AllowedAgentHosts      bool            `json:"allowed_agent_hosts"`
+	ContentType            string          `json:"content_type"`
+	Unavailable            map[string]bool `json:"unavailable_endpoints,omitempty"`
+}
+
+// ConnectivityCheck is the outcome of the health request made while
+// gathering Diagnostics.
+type ConnectivityCheck struct {
+	OK         bool   `json:"ok"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Latency    string `json:"latency"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+}
+
+// DiagnosticsReport gathers Diagnostics for the client. It makes a health
+// request to check connectivity and probes the server capabilities; a
+// failure of either is recorded in the report rather than returned, so a
+// report is produced even for an unreachable registry or a closed client.
+// The returned error is ctx's error if ctx is done before gathering starts.
+func (c *A2ARegClient) DiagnosticsReport(ctx context.Context) (*Diagnostics, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	d := &Diagnostics{
+		SDKVersion:  SDKVersion,
+		GoVersion:   runtime.Version(),
+		GeneratedAt: c.clock.Now().UTC(),
+		AuthMode:    c.AuthMode(),
+		Closed:      c.closed.Load(),
+		Options:     c.diagnosticsOptions(),
+		Caches:      c.CacheStats(),
+	}
+	if c.configErr != nil {
+		d.ConfigError = c.configErr.Error()
+	}
+	if c.queue != nil {
+		n := c.queue.len()
+		d.QueuedOperations = &n
+	}
+	if info := c.lastCall.Load(); info != nil {
+		last := *info
+		last.URL = redactURL(last.URL)
+		d.LastCall = &last
+	}
+
+	start := c.clock.Now()
+	_, err := c.makeRequestContext(ctx, "GET", "/health", nil, nil)
+	d.Connectivity.Latency = c.clock.Since(start).String()
+	if err != nil {
+		d.Connectivity.StatusCode = StatusCode(err)
+		d.Connectivity.Error = err.Error()
+		d.Connectivity.ErrorCode = ErrorCode(err)
+	} else {
+		d.Connectivity.OK = true
+		d.Connectivity.StatusCode = http.StatusOK
+	}
+
+	if caps, err := c.ServerCapabilities(ctx); err != nil {
+		d.ServerError = err.Error()
+	} else {
+		d.Server = caps
+	}
+	return d, nil
+}
+
+func (c *A2ARegClient) diagnosticsOptions() DiagnosticsOptions {
+	o := DiagnosticsOptions{
+		RegistryURL:            redactURL(c.registryURL),
+		Timeout:                c.timeout.String(),
+		APIKeySet:              !c.apiKey.Empty(),
+		APIKeyHeader:           c.apiKeyHeaderName(),
+		ClientIDSet:            c.clientID != "",
+		ClientSecretSet:        !c.clientSecret.Empty(),
+		Scope:                  c.scope,
+		AuthPreference:         c.authPreference.String(),
+		MaxRequestBytes:        c.maxRequestBytes,
+		SafeDelete:             c.safeDelete,
+		DeterministicIDs:       c.deterministicIDs,
+		AllowUnknownTransports: c.allowUnknownTransports,
+		SecuritySchemesAsArray: c.securitySchemesAsArray,
+		SearchFallback:         c.searchFallback,
+		MaxFallbackPages:       c.maxFallbackPages,
+		MaxSearchParallelism:   c.searchParallelism,
+		OfflineQueue:           c.queue != nil,
+		TagTaxonomy:            c.tagTaxonomy != nil,
+		AllowedAgentHosts:      c.allowedHosts != nil,
+		ContentType:            "application/json",
+	}
+	if c.codec != nil {
+		o.ContentType = c.codec.ContentType()
+	}
+	if c.cardCache != nil {
+		o.CardCacheTTL = c.cardCacheTTL.String()
+	}
+	for endpoint, unavailable := range map[string]bool{
+		"/agents/search":       c.searchMissing(),
+		"/agents/search/batch": c.batchSearchUnavailable.Load(),
+		"/agents/batch":        c.agentsBatchUnavailable.Load(),
+	} {
+		if unavailable {
+			if o.Unavailable == nil {
+				o.Unavailable = make(map[string]bool)
+			}
+			o.Unavailable[endpoint] = true
+		}
+	}
+	return o
+}
+
+// redactURL replaces the user info and query values of rawURL, either of
+// which may carry credentials, with "[REDACTED]".
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "[unparseable URL]"
+	}
+	if u.User != nil {
+		u.User = url.User(redacted)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{redacted}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// String renders d as indented JSON in a Markdown code block, ready to
+// paste into an issue.
+func (d *Diagnostics) String() string {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "a2areg diagnostics: " + err.Error()
+	}
+	var b strings.Builder
+	b.WriteString("a2areg diagnostics\n```json\n")
+	b.Write(data)
+	b.WriteString("\n```\n")
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnosticsReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/oauth/token":
+			w.Write([]byte(`{"access_token": "access-token", "expires_in": 3600}`))
+		case "/health":
+			w.Write([]byte(`{"status": "healthy", "version": "2.3.0", "features": ["visibility"]}`))
+		default:
+			w.Write([]byte(`{"name": "Recipe Agent", "description": "d", "url": "https://a.example.com", "version": "1.0.0"}`))
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:    server.URL,
+		APIKey:         "api-key-123",
+		ClientID:       "id",
+		ClientSecret:   "client-secret",
+		AuthPreference: AuthOAuthFirst,
+		CardCache:      NewMemoryStore(0),
+	})
+	_, err := client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	_, err = client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+
+	d, err := client.DiagnosticsReport(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, SDKVersion, d.SDKVersion)
+	assert.Equal(t, AuthModeOAuth, d.AuthMode)
+	assert.True(t, d.Connectivity.OK)
+	assert.Equal(t, http.StatusOK, d.Connectivity.StatusCode)
+	require.NotNil(t, d.Server)
+	assert.Equal(t, "2.3.0", d.Server.Version)
+	assert.Equal(t, CacheStats{Enabled: true, Hits: 1, Misses: 1, Stores: 1}, d.Caches["card"])
+	assert.False(t, d.Caches["search"].Enabled)
+	assert.True(t, d.Options.APIKeySet)
+	assert.True(t, d.Options.ClientSecretSet)
+	assert.Equal(t, "oauth_first", d.Options.AuthPreference)
+	assert.Equal(t, "30s", d.Options.Timeout)
+	require.NotNil(t, d.LastCall)
+	assert.Equal(t, "/agents/agent-1/card", d.LastCall.URL[len(server.URL):])
+
+	rendered := d.String()
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	for _, secret := range []string{"api-key-123", "client-secret", "access-token"} {
+		assert.NotContains(t, rendered, secret)
+		assert.NotContains(t, string(data), secret)
+	}
+	assert.Contains(t, rendered, `"sdk_version": "`+SDKVersion+`"`)
+}
+
+func TestDiagnosticsReport_UnreachableRegistry(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: "http://user:hunter2@" + server.Listener.Addr().String() + "/?token=t0ken", APIKey: "api-key-123"})
+
+	d, err := client.DiagnosticsReport(context.Background())
+	require.NoError(t, err)
+	assert.False(t, d.Connectivity.OK)
+	assert.NotEmpty(t, d.Connectivity.Error)
+	assert.Nil(t, d.Server)
+	assert.NotEmpty(t, d.ServerError)
+	assert.Nil(t, d.LastCall)
+	assert.Equal(t, AuthModeAPIKey, d.AuthMode)
+
+	rendered := d.String()
+	for _, secret := range []string{"hunter2", "t0ken", "api-key-123"} {
+		assert.NotContains(t, rendered, secret)
+	}
+
+	require.NoError(t, client.Close())
+	d, err = client.DiagnosticsReport(context.Background())
+	require.NoError(t, err)
+	assert.True(t, d.Closed)
+	assert.Equal(t, CodeClientClosed, d.Connectivity.ErrorCode)
+}
@@ -0,0 +1,120 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind classifies a FieldChange.
+type ChangeKind string
+
+// Kinds of change reported in an AgentDiff.
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// FieldChange is one difference between two agent documents.
+type FieldChange struct {
+	// Path is a JSON pointer (RFC 6901) to the changed value.
+	Path   string      `json:"path"`
+	Kind   ChangeKind  `json:"kind"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AgentDiff is the set of differences between two agent or card documents,
+// ordered by path.
+type AgentDiff struct {
+	Changes []FieldChange `json:"changes"`
+}
+
+// IsEmpty reports whether the documents were identical.
+func (d AgentDiff) IsEmpty() bool {
+	return len(d.Changes) == 0
+}
+
+// diffDocuments compares before (any JSON-marshalable value) with after (a
+// JSON document).
+func diffDocuments(before interface{}, after []byte) (AgentDiff, error) {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return AgentDiff{}, withCode(NewA2AError("Failed to encode document", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+	var beforeTree, afterTree interface{}
+	if err := json.Unmarshal(beforeJSON, &beforeTree); err != nil {
+		return AgentDiff{}, withCode(NewA2AError("Failed to decode document", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if err := json.Unmarshal(after, &afterTree); err != nil {
+		return AgentDiff{}, withCode(NewA2AError("Failed to decode document", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+
+	var diff AgentDiff
+	diffValues("", beforeTree, afterTree, &diff.Changes)
+	return diff, nil
+}
+
+// diffValues appends the changes between two decoded JSON values.
+func diffValues(path string, before, after interface{}, changes *[]FieldChange) {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(b)+len(a))
+		for k := range b {
+			keys = append(keys, k)
+		}
+		for k := range a {
+			if _, seen := b[k]; !seen {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + escapePointer(k)
+			bv, inBefore := b[k]
+			av, inAfter := a[k]
+			switch {
+			case !inAfter:
+				*changes = append(*changes, FieldChange{Path: child, Kind: ChangeRemoved, Before: bv})
+			case !inBefore:
+				*changes = append(*changes, FieldChange{Path: child, Kind: ChangeAdded, After: av})
+			default:
+				diffValues(child, bv, av, changes)
+			}
+		}
+		return
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(b) || i < len(a); i++ {
+			child := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(a):
+				*changes = append(*changes, FieldChange{Path: child, Kind: ChangeRemoved, Before: b[i]})
+			case i >= len(b):
+				*changes = append(*changes, FieldChange{Path: child, Kind: ChangeAdded, After: a[i]})
+			default:
+				diffValues(child, b[i], a[i], changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, FieldChange{Path: path, Kind: ChangeModified, Before: before, After: after})
+	}
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,185 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ValidationReport is the outcome of PublishAgentDryRun.
+type ValidationReport struct {
+	// Errors are the problems that would make the registry refuse the
+	// publish. Paths are given as in FieldViolation, relative to the
+	// publish request ("card.skills[0].id").
+	Errors []FieldViolation `json:"errors,omitempty"`
+	// Warnings are problems the registry would accept the agent with.
+	Warnings []FieldViolation `json:"warnings,omitempty"`
+	// LocalOnly is set when the registry does not support dry runs and
+	// the agent was only checked by the client. Server-side rules such
+	// as name uniqueness were then not checked.
+	LocalOnly bool `json:"local_only"`
+}
+
+// Valid reports whether the report has no errors.
+func (r *ValidationReport) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// PublishAgentDryRun checks agent against the registry without publishing
+// it, by sending the publish request with validate_only=true. The registry
+// then applies the rules the client cannot check itself, such as name
+// uniqueness and URL reachability. Rejections are reported in the
+// returned ValidationReport rather than as an error; errors are returned
+// for failures to reach the registry.
+//
+// Registries that answer the flag with 404 or 400 do not support dry runs.
+// The agent is then checked with the client's own rules, reporting every
+// problem found rather than only the first as ValidateAgent does, and the
+// report is marked LocalOnly.
+func (c *A2ARegClient) PublishAgentDryRun(ctx context.Context, agent *Agent) (*ValidationReport, error) {
+	if c.tagTaxonomy != nil {
+		agent = c.tagTaxonomy.normalizeAgentTags(agent)
+	}
+	if c.dryRunUnavailable.Load() {
+		return c.localValidationReport(agent)
+	}
+
+	payload, err := c.publishPayload(ctx, agent, c.defaultIDStrategy)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(ctx, "POST", "/agents/publish", payload.body, map[string]string{"validate_only": "true"})
+	if err != nil {
+		switch StatusCode(err) {
+		case http.StatusNotFound, http.StatusBadRequest:
+			c.dryRunUnavailable.Store(true)
+			return c.localValidationReport(agent)
+		case http.StatusUnprocessableEntity, http.StatusConflict:
+			report := &ValidationReport{Errors: errorViolations(err)}
+			report.addWarnings(payload, agent)
+			return report, nil
+		}
+		return nil, err
+	}
+
+	var result struct {
+		Errors   []interface{} `json:"errors"`
+		Warnings []interface{} `json:"warnings"`
+	}
+	if err := json.Unmarshal(resp.body, &result); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode dry run response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	report := &ValidationReport{}
+	for _, field := range fieldErrors(result.Errors) {
+		report.Errors = append(report.Errors, fieldViolation(field))
+	}
+	for _, field := range fieldErrors(result.Warnings) {
+		report.Warnings = append(report.Warnings, fieldViolation(field))
+	}
+	for _, warning := range headerWarnings(resp.header) {
+		report.Warnings = append(report.Warnings, FieldViolation{Message: warning})
+	}
+	report.addWarnings(payload, agent)
+	return report, nil
+}
+
+// addWarnings adds the client-side warnings about publishing agent.
+func (r *ValidationReport) addWarnings(payload *publishPayload, agent *Agent) {
+	for _, warning := range payload.conversionWarnings {
+		r.Warnings = append(r.Warnings, FieldViolation{Path: warning.Field, Message: warning.Message})
+	}
+	for _, warning := range payload.warnings(agent) {
+		r.Warnings = append(r.Warnings, FieldViolation{Message: warning})
+	}
+}
+
+// localValidationReport checks agent with the rules of ValidateAgent, and
+// the card it would be published as with those of ValidateAgentCard,
+// reporting every problem found.
+func (c *A2ARegClient) localValidationReport(agent *Agent) (*ValidationReport, error) {
+	report := &ValidationReport{LocalOnly: true}
+	seen := make(map[FieldViolation]bool)
+	add := func(prefix string) problemFunc {
+		return func(path string, err error) bool {
+			for _, violation := range errorViolations(err) {
+				if violation.Path == "" && path != "" {
+					violation.Path = prefix + path
+				}
+				if !seen[violation] {
+					seen[violation] = true
+					report.Errors = append(report.Errors, violation)
+				}
+			}
+			return true
+		}
+	}
+
+	c.checkAgent(agent, add(""))
+	card, conversionWarnings, err := ConvertAgentToCard(agent)
+	if err != nil {
+		return nil, err
+	}
+	c.checkAgentCard(card, add("card."))
+	report.addWarnings(&publishPayload{conversionWarnings: conversionWarnings}, agent)
+	return report, nil
+}
+
+// errorViolations lists the problems err reports: its field errors or
+// skill violations when it has any, its message otherwise.
+func errorViolations(err error) []FieldViolation {
+	var violations []FieldViolation
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		for _, field := range validationErr.Fields() {
+			violations = append(violations, fieldViolation(field))
+		}
+		if skills, ok := validationErr.Details["violations"].([]FieldViolation); ok {
+			violations = append(violations, skills...)
+		}
+		if len(violations) > 0 {
+			return violations
+		}
+	}
+	var apiErr *A2AError
+	if errors.As(err, &apiErr) {
+		return []FieldViolation{{Message: apiErr.Message}}
+	}
+	return []FieldViolation{{Message: err.Error()}}
+}
+
+// fieldViolation converts a registry field error, dropping the leading
+// "body" of its location: ["body", "card", "skills", "0", "id"] becomes
+// "card.skills[0].id".
+func fieldViolation(field FieldError) FieldViolation {
+	loc := field.Loc
+	if len(loc) > 0 && loc[0] == "body" {
+		loc = loc[1:]
+	}
+	var path strings.Builder
+	for _, part := range loc {
+		if isDecimal(part) {
+			fmt.Fprintf(&path, "[%s]", part)
+			continue
+		}
+		if path.Len() > 0 {
+			path.WriteByte('.')
+		}
+		path.WriteString(part)
+	}
+	return FieldViolation{Path: path.String(), Message: field.Message}
+}
+
+func isDecimal(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
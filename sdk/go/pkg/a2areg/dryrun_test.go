@@ -0,0 +1,111 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dryRunRegistry validates publish requests sent with validate_only=true:
+// the name "Taken" is in use, and the card description must not be
+// "TODO". With supported unset it answers the flag with 400.
+func dryRunRegistry(t *testing.T, supported bool) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Method != "POST" || r.URL.Path != "/agents/publish" || r.URL.Query().Get("validate_only") != "true" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !supported {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"detail": "Unknown query parameter validate_only"}`))
+			return
+		}
+		var body struct {
+			Card map[string]interface{} `json:"card"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch {
+		case body.Card["name"] == "Taken":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"detail": "Agent already exists"}`))
+		case body.Card["description"] == "TODO":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"detail": [{"loc": ["body", "card", "description"], "msg": "placeholder description", "type": "value_error"}]}`))
+		default:
+			w.Header().Add("X-Registry-Warning", "url is not reachable")
+			w.Write([]byte(`{"valid": true, "errors": [], "warnings": [{"loc": ["body", "card", "skills", 0, "examples"], "msg": "examples are recommended"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func dryRunAgent() *Agent {
+	agent := testPublishAgent()
+	location := "https://recipes.example.com"
+	agent.LocationURL = &location
+	return agent
+}
+
+func TestPublishAgentDryRun_Server(t *testing.T) {
+	server, _ := dryRunRegistry(t, true)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	ctx := context.Background()
+
+	report, err := client.PublishAgentDryRun(ctx, dryRunAgent())
+	require.NoError(t, err)
+	assert.True(t, report.Valid())
+	assert.False(t, report.LocalOnly)
+	assert.Equal(t, []FieldViolation{
+		{Path: "card.skills[0].examples", Message: "examples are recommended"},
+		{Message: "url is not reachable"},
+	}, report.Warnings)
+
+	placeholder := dryRunAgent()
+	placeholder.Description = "TODO"
+	report, err = client.PublishAgentDryRun(ctx, placeholder)
+	require.NoError(t, err)
+	assert.False(t, report.Valid())
+	assert.Equal(t, []FieldViolation{{Path: "card.description", Message: "placeholder description"}}, report.Errors)
+
+	taken := dryRunAgent()
+	taken.Name = "Taken"
+	report, err = client.PublishAgentDryRun(ctx, taken)
+	require.NoError(t, err)
+	require.Len(t, report.Errors, 1)
+	assert.Contains(t, report.Errors[0].Message, "Agent already exists")
+}
+
+func TestPublishAgentDryRun_LocalFallback(t *testing.T) {
+	server, requests := dryRunRegistry(t, false)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	ctx := context.Background()
+
+	report, err := client.PublishAgentDryRun(ctx, dryRunAgent())
+	require.NoError(t, err)
+	assert.True(t, report.LocalOnly)
+	assert.True(t, report.Valid())
+
+	// Every problem is reported, not only the first.
+	agent := dryRunAgent()
+	agent.Description = ""
+	agent.Skills[0].ID = "not a valid id"
+	report, err = client.PublishAgentDryRun(ctx, agent)
+	require.NoError(t, err)
+	assert.True(t, report.LocalOnly)
+	assert.Equal(t, []FieldViolation{
+		{Path: "description", Message: "Agent description is required"},
+		{Path: "skills[0].id", Message: `"not a valid id" is not a valid skill ID (letters, digits, '_', '-' and '.', at most 64 characters)`},
+		{Path: "card.description", Message: "Agent card description is required"},
+	}, report.Errors)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests), "the registry is only asked once")
+}
@@ -0,0 +1,51 @@
+package a2areg
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeEndpoint joins a registry base URL and an endpoint path into a
+// request URL. Exactly one slash separates them, runs of slashes in the path
+// collapse to one, and "." segments are dropped, so a base configured as
+// "https://host/api/" and an endpoint "/agents" give "https://host/api/agents".
+// A query string on the endpoint is kept as is. Endpoints containing ".."
+// segments (including percent-encoded ones) or a scheme are rejected, so a
+// value interpolated into an endpoint cannot escape the base path.
+func NormalizeEndpoint(base, endpoint string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", withCode(NewA2AError("Invalid registry URL: "+base, nil), CodeInvalidRequest)
+	}
+
+	path, query, hasQuery := strings.Cut(endpoint, "?")
+	if strings.Contains(path, "://") {
+		return "", withCode(NewA2AError("Endpoint must be a path, got "+endpoint, nil), CodeInvalidRequest)
+	}
+
+	segments := strings.Split(u.EscapedPath()+"/"+path, "/")
+	kept := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", withCode(NewA2AError("Invalid endpoint path: "+endpoint, nil), CodeInvalidRequest)
+		}
+		switch decoded {
+		case "..":
+			return "", withCode(NewA2AError("Endpoint must not contain '..' segments: "+endpoint, nil), CodeInvalidRequest)
+		case "", ".":
+			continue
+		}
+		kept = append(kept, segment)
+	}
+
+	root := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host}
+	joined := root.String() + "/" + strings.Join(kept, "/")
+	if strings.HasSuffix(path, "/") && len(kept) > 0 {
+		joined += "/"
+	}
+	if hasQuery {
+		joined += "?" + query
+	}
+	return joined, nil
+}
@@ -0,0 +1,113 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EntitlementStatus is the state of an entitlement.
+type EntitlementStatus string
+
+// Entitlement statuses. A request starts pending and is decided once, by
+// approval; an approved entitlement can then be revoked.
+const (
+	EntitlementPending  EntitlementStatus = "pending"
+	EntitlementApproved EntitlementStatus = "approved"
+	EntitlementRevoked  EntitlementStatus = "revoked"
+)
+
+// Entitlement grants a client access to a non-public agent.
+type Entitlement struct {
+	ID       string            `json:"id"`
+	AgentID  string            `json:"agent_id"`
+	ClientID string            `json:"client_id"`
+	Status   EntitlementStatus `json:"status"`
+	Reason   string            `json:"reason,omitempty"`
+	// RequestedAt is when access was requested.
+	RequestedAt time.Time `json:"requested_at"`
+	// DecidedAt is when the entitlement was last approved or revoked, or
+	// nil while it is pending.
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+}
+
+// RequestEntitlement asks for the client to be granted access to agentID.
+// The entitlement is pending until the agent's owner approves it.
+func (c *A2ARegClient) RequestEntitlement(ctx context.Context, agentID, reason string) (*Entitlement, error) {
+	body := map[string]interface{}{"agent_id": agentID}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		body["reason"] = reason
+	}
+	data, err := c.makeRequestContext(ctx, "POST", "/entitlements", body, nil)
+	if err != nil {
+		return nil, agentNotFound(err)
+	}
+	return decodeEntitlement(data)
+}
+
+// ListEntitlements lists the entitlements visible to the client: those it
+// requested and those for agents it owns. An empty status lists them all.
+func (c *A2ARegClient) ListEntitlements(ctx context.Context, status EntitlementStatus, page, limit int) ([]Entitlement, error) {
+	params := map[string]string{
+		"page":  fmt.Sprintf("%d", page),
+		"limit": fmt.Sprintf("%d", limit),
+	}
+	if status != "" {
+		params["status"] = string(status)
+	}
+	data, err := c.makeRequestContext(ctx, "GET", "/entitlements", nil, params)
+	if err != nil {
+		return nil, err
+	}
+	var listing struct {
+		Entitlements []Entitlement `json:"entitlements"`
+	}
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode entitlements response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	return listing.Entitlements, nil
+}
+
+// ApproveEntitlement grants a pending entitlement. Only the owner of the
+// agent may approve: otherwise the registry's 403 is returned as an
+// *AuthenticationError. An entitlement that is no longer pending gives an
+// *EntitlementDecidedError.
+func (c *A2ARegClient) ApproveEntitlement(ctx context.Context, entitlementID string) (*Entitlement, error) {
+	return c.decideEntitlement(ctx, entitlementID, "approve")
+}
+
+// RevokeEntitlement withdraws an approved entitlement. Errors are as for
+// ApproveEntitlement; revoking one that is already revoked gives an
+// *EntitlementDecidedError.
+func (c *A2ARegClient) RevokeEntitlement(ctx context.Context, entitlementID string) (*Entitlement, error) {
+	return c.decideEntitlement(ctx, entitlementID, "revoke")
+}
+
+func (c *A2ARegClient) decideEntitlement(ctx context.Context, entitlementID, action string) (*Entitlement, error) {
+	data, err := c.makeRequestContext(ctx, "POST", "/entitlements/"+url.PathEscape(entitlementID)+"/"+action, nil, nil)
+	if err != nil {
+		var apiErr *A2AError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			status, _ := apiErr.Details["status"].(string)
+			return nil, NewEntitlementDecidedError(entitlementID, EntitlementStatus(status), apiErr.Details)
+		}
+		return nil, err
+	}
+	return decodeEntitlement(data)
+}
+
+func decodeEntitlement(data []byte) (*Entitlement, error) {
+	var entitlement Entitlement
+	if err := json.Unmarshal(data, &entitlement); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode entitlement response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if entitlement.ID == "" {
+		return nil, withCode(NewA2AError("Entitlement response has no id", nil), CodeDecodeFailed)
+	}
+	return &entitlement, nil
+}
@@ -0,0 +1,146 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// entitlementRegistry serves /entitlements. Requests authenticated with
+// the "owner" key may decide entitlements; others get a 403.
+func entitlementRegistry(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	var entitlements []*Entitlement
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/entitlements":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			e := &Entitlement{
+				ID:          fmt.Sprintf("ent-%d", len(entitlements)+1),
+				AgentID:     body["agent_id"],
+				ClientID:    "client-1",
+				Status:      EntitlementPending,
+				Reason:      body["reason"],
+				RequestedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			}
+			entitlements = append(entitlements, e)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(e)
+		case r.Method == "GET" && r.URL.Path == "/entitlements":
+			listed := []*Entitlement{}
+			for _, e := range entitlements {
+				if status := r.URL.Query().Get("status"); status == "" || string(e.Status) == status {
+					listed = append(listed, e)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"entitlements": listed, "total": len(listed)})
+		case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/entitlements/"):
+			if r.Header.Get("X-API-Key") != "owner" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"detail": "not the agent owner"}`))
+				return
+			}
+			id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/entitlements/"), "/")
+			for _, e := range entitlements {
+				if e.ID != id {
+					continue
+				}
+				from, to := EntitlementPending, EntitlementApproved
+				if action == "revoke" {
+					from, to = EntitlementApproved, EntitlementRevoked
+				}
+				if e.Status != from {
+					w.WriteHeader(http.StatusConflict)
+					json.NewEncoder(w).Encode(map[string]interface{}{"detail": "already decided", "status": e.Status})
+					return
+				}
+				decided := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+				e.Status, e.DecidedAt = to, &decided
+				json.NewEncoder(w).Encode(e)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEntitlements_RequestApproveList(t *testing.T) {
+	server := entitlementRegistry(t)
+	requester := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "requester"})
+	owner := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "owner"})
+	ctx := context.Background()
+
+	requested, err := requester.RequestEntitlement(ctx, "agent-1", "  integration testing ")
+	require.NoError(t, err)
+	assert.Equal(t, "agent-1", requested.AgentID)
+	assert.Equal(t, EntitlementPending, requested.Status)
+	assert.Equal(t, "integration testing", requested.Reason)
+	assert.Nil(t, requested.DecidedAt)
+
+	pending, err := owner.ListEntitlements(ctx, EntitlementPending, 1, 20)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, requested.ID, pending[0].ID)
+
+	approved, err := owner.ApproveEntitlement(ctx, requested.ID)
+	require.NoError(t, err)
+	assert.Equal(t, EntitlementApproved, approved.Status)
+	require.NotNil(t, approved.DecidedAt)
+
+	pending, err = owner.ListEntitlements(ctx, EntitlementPending, 1, 20)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	all, err := requester.ListEntitlements(ctx, "", 1, 20)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, EntitlementApproved, all[0].Status)
+
+	revoked, err := owner.RevokeEntitlement(ctx, requested.ID)
+	require.NoError(t, err)
+	assert.Equal(t, EntitlementRevoked, revoked.Status)
+}
+
+func TestEntitlements_DecisionErrors(t *testing.T) {
+	server := entitlementRegistry(t)
+	requester := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "requester"})
+	owner := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "owner"})
+	ctx := context.Background()
+
+	requested, err := requester.RequestEntitlement(ctx, "agent-1", "")
+	require.NoError(t, err)
+
+	_, err = requester.ApproveEntitlement(ctx, requested.ID)
+	var authErr *AuthenticationError
+	require.ErrorAs(t, err, &authErr)
+	assert.Equal(t, http.StatusForbidden, StatusCode(err))
+
+	_, err = owner.ApproveEntitlement(ctx, requested.ID)
+	require.NoError(t, err)
+	_, err = owner.ApproveEntitlement(ctx, requested.ID)
+	var decided *EntitlementDecidedError
+	require.ErrorAs(t, err, &decided)
+	assert.Equal(t, requested.ID, decided.EntitlementID)
+	assert.Equal(t, EntitlementApproved, decided.Status)
+	assert.Equal(t, CodeEntitlementDecided, ErrorCode(err))
+	assert.Equal(t, http.StatusConflict, StatusCode(err))
+
+	_, err = owner.RevokeEntitlement(ctx, "missing")
+	var notFound *NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
@@ -0,0 +1,118 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// CallInfo describes the most recent registry response.
+type CallInfo struct {
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	// RequestID is the registry's request ID, from the envelope metadata or
+	// the X-Request-ID header.
+	RequestID string `json:"request_id,omitempty"`
+	// Enveloped reports whether the response was wrapped in a
+	// {"data": ..., "meta": ...} envelope.
+	Enveloped bool `json:"enveloped"`
+	// Meta is the envelope metadata, or nil for bare responses.
+	Meta map[string]interface{} `json:"meta,omitempty"`
+	// Pagination is parsed from Meta["pagination"] when present.
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination is the paging metadata of an enveloped listing response.
+type Pagination struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	Total int `json:"total"`
+}
+
+// LastCallInfo returns information about the most recent response the
+// client received. With concurrent calls, "most recent" is whichever
+// finished last. The zero CallInfo is returned before any call completes.
+func (c *A2ARegClient) LastCallInfo() CallInfo {
+	if info := c.lastCall.Load(); info != nil {
+		return *info
+	}
+	return CallInfo{}
+}
+
+// envelopeKeys are the only top-level keys an envelope may have.
+var envelopeKeys = map[string]bool{"data": true, "meta": true, "error": true}
+
+// unwrapEnvelope detects a {"data": ..., "meta": ..., "error": ...} response
+// envelope and returns its data, metadata and error. A document counts as an
+// envelope only if every top-level key is one of those three and "meta" or
+// "error" is present, so a bare payload that merely has a "data" field is
+// left alone. Bare responses are returned unchanged with ok false.
+func unwrapEnvelope(body []byte) (data []byte, meta map[string]interface{}, errorValue json.RawMessage, ok bool) {
+	var top map[string]json.RawMessage
+	if json.Unmarshal(body, &top) != nil || len(top) == 0 {
+		return body, nil, nil, false
+	}
+	for key := range top {
+		if !envelopeKeys[key] {
+			return body, nil, nil, false
+		}
+	}
+	_, hasMeta := top["meta"]
+	_, hasError := top["error"]
+	if !hasMeta && !hasError {
+		return body, nil, nil, false
+	}
+
+	if raw, ok := top["meta"]; ok {
+		json.Unmarshal(raw, &meta)
+	}
+	return top["data"], meta, top["error"], true
+}
+
+// envelopeErrorData converts an envelope's error value into the error body
+// shape responseError expects: objects are used as is and strings become
+// the detail.
+func envelopeErrorData(errorValue json.RawMessage) map[string]interface{} {
+	var errorData map[string]interface{}
+	if json.Unmarshal(errorValue, &errorData) == nil && errorData != nil {
+		if _, ok := errorData["detail"]; !ok {
+			if message, ok := errorData["message"].(string); ok {
+				errorData["detail"] = message
+			}
+		}
+		return errorData
+	}
+	var message string
+	if json.Unmarshal(errorValue, &message) == nil {
+		return map[string]interface{}{"detail": message}
+	}
+	return nil
+}
+
+// recordCall stores the CallInfo for resp.
+func (c *A2ARegClient) recordCall(resp *http.Response, enveloped bool, meta map[string]interface{}) {
+	info := &CallInfo{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		Enveloped:  enveloped,
+		Meta:       meta,
+	}
+	if resp.Request != nil {
+		info.Method = resp.Request.Method
+		info.URL = resp.Request.URL.String()
+	}
+	for _, key := range []string{"request_id", "requestId"} {
+		if id, ok := meta[key].(string); ok && id != "" {
+			info.RequestID = id
+		}
+	}
+	if pagination, ok := meta["pagination"]; ok {
+		if raw, err := json.Marshal(pagination); err == nil {
+			var p Pagination
+			if json.Unmarshal(raw, &p) == nil {
+				info.Pagination = &p
+			}
+		}
+	}
+	c.lastCall.Store(info)
+}
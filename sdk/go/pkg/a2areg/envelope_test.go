@@ -0,0 +1,118 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envelopeServer(t *testing.T, status int, body string) *A2ARegClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "header-id")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+}
+
+func TestEnvelope_Wrapped(t *testing.T) {
+	client := envelopeServer(t, http.StatusOK, `{
+		"data": {"id": "agent-1", "name": "Recipe Agent", "version": "1.0.0", "provider": "acme"},
+		"meta": {"request_id": "req-123"}
+	}`)
+
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Recipe Agent", agent.Name)
+
+	info := client.LastCallInfo()
+	assert.True(t, info.Enveloped)
+	assert.Equal(t, "req-123", info.RequestID)
+	assert.Equal(t, http.StatusOK, info.StatusCode)
+	assert.Equal(t, "GET", info.Method)
+}
+
+func TestEnvelope_WrappedListingPagination(t *testing.T) {
+	client := envelopeServer(t, http.StatusOK, `{
+		"data": {"agents": [{"id": "a", "name": "A"}]},
+		"meta": {"pagination": {"page": 2, "limit": 1, "total": 7}}
+	}`)
+
+	summaries, err := client.ListSummaries(2, 1, true)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "A", summaries[0].Name)
+
+	info := client.LastCallInfo()
+	assert.Equal(t, &Pagination{Page: 2, Limit: 1, Total: 7}, info.Pagination)
+	assert.Equal(t, "header-id", info.RequestID)
+}
+
+func TestEnvelope_Bare(t *testing.T) {
+	client := envelopeServer(t, http.StatusOK, `{"id": "agent-1", "name": "Recipe Agent"}`)
+
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Recipe Agent", agent.Name)
+
+	info := client.LastCallInfo()
+	assert.False(t, info.Enveloped)
+	assert.Nil(t, info.Meta)
+	assert.Equal(t, "header-id", info.RequestID)
+}
+
+func TestEnvelope_Ambiguous(t *testing.T) {
+	// A bare payload whose only key happens to be "data" is not an envelope.
+	client := envelopeServer(t, http.StatusOK, `{"data": {"total_agents": 3}}`)
+	stats, err := client.GetRegistryStats()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"total_agents": json.Number("3")}, stats.Extra["data"])
+	assert.False(t, client.LastCallInfo().Enveloped)
+
+	// Extra keys beside data/meta mean it is a bare document too.
+	client = envelopeServer(t, http.StatusOK, `{"data": "x", "meta": {}, "name": "data"}`)
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "data", agent.Name)
+}
+
+func TestEnvelope_WrappedError(t *testing.T) {
+	client := envelopeServer(t, http.StatusNotFound, `{
+		"error": {"code": "agent_missing", "message": "No such agent"},
+		"meta": {"request_id": "req-404"}
+	}`)
+
+	_, err := client.GetAgent("missing")
+	require.Error(t, err)
+	assert.Equal(t, "agent_missing", ErrorCode(err))
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+	assert.Equal(t, "req-404", client.LastCallInfo().RequestID)
+
+	client = envelopeServer(t, http.StatusUnprocessableEntity, `{"error": "name is required"}`)
+	_, err = client.GetAgent("x")
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Contains(t, err.Error(), "name is required")
+}
+
+func TestEnvelope_WrappedToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/oauth/token" {
+			w.Write([]byte(`{"data": {"access_token": "wrapped-token", "expires_in": 3600}, "meta": {}}`))
+			return
+		}
+		assert.Equal(t, "Bearer wrapped-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"data": {"status": "healthy"}, "meta": {}}`))
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, ClientID: "id", ClientSecret: "secret"})
+	health, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", health.Status)
+}
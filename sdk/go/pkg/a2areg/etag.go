@@ -0,0 +1,59 @@
+package a2areg
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultUpdateRetries is the number of retries UpdateAgentWithRetry makes
+// after a conflict when given a negative count.
+const DefaultUpdateRetries = 3
+
+// ifMatch returns ctx making the request conditional on etag, or ctx
+// itself when etag is empty.
+func ifMatch(ctx context.Context, etag string) context.Context {
+	if etag == "" {
+		return ctx
+	}
+	return withRequestOptions(ctx, []RequestOption{WithHeader("If-Match", etag)})
+}
+
+// decodeUpdatedAgent decodes the agent an update returned, with its ETag.
+func decodeUpdatedAgent(resp *apiResponse) (*Agent, error) {
+	agent, err := decodeAgent(resp.body)
+	if err != nil {
+		return nil, err
+	}
+	agent.ETag = resp.header.Get("ETag")
+	return agent, nil
+}
+
+// UpdateAgentWithRetry reads the agent, applies mutate to it and writes it
+// back conditionally on its ETag. When another writer got there first, the
+// registry's 412 makes it read the agent again and reapply mutate, up to
+// retries more times; after that the *ConflictError is returned. A
+// negative retries means DefaultUpdateRetries. An error from mutate stops
+// the update and is returned as is.
+//
+// mutate may run several times, each time on a freshly read agent, so it
+// must not depend on state from earlier calls. Registries that send no
+// ETag get an unconditional update.
+func (c *A2ARegClient) UpdateAgentWithRetry(ctx context.Context, agentID string, mutate func(*Agent) error, retries int) (*Agent, error) {
+	if retries < 0 {
+		retries = DefaultUpdateRetries
+	}
+	for attempt := 0; ; attempt++ {
+		agent, err := c.readAgent(ctx, agentID)
+		if err != nil {
+			return nil, err
+		}
+		if err := mutate(agent); err != nil {
+			return nil, err
+		}
+		updated, err := c.updateAgent(ctx, agentID, agent)
+		var conflict *ConflictError
+		if err == nil || !errors.As(err, &conflict) || attempt >= retries {
+			return updated, err
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// etagRegistry holds agent-1 at a version that every write bumps. Writes
+// with a stale If-Match get 412. interfere is the number of upcoming writes
+// preceded by a write from another client, making their If-Match stale.
+type etagRegistry struct {
+	*httptest.Server
+	noETags bool
+
+	mu        sync.Mutex
+	version   int
+	agent     map[string]interface{}
+	interfere int
+	ifMatch   []string
+}
+
+func newETagRegistry(t *testing.T, allowPatch bool) *etagRegistry {
+	r := &etagRegistry{version: 1}
+	require.NoError(t, json.Unmarshal([]byte(patchStoredAgentJSON), &r.agent))
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if req.URL.Path != "/agents/agent-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method == "GET" {
+			r.write(w)
+			return
+		}
+		if req.Method == "PATCH" && !allowPatch {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		r.ifMatch = append(r.ifMatch, req.Header.Get("If-Match"))
+		if r.interfere > 0 {
+			r.interfere--
+			r.version++
+		}
+		if match := req.Header.Get("If-Match"); match != "" && match != r.etag() {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"detail": "ETag does not match"}`))
+			return
+		}
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		if req.Method == "PATCH" {
+			r.agent = mergePatch(r.agent, body).(map[string]interface{})
+		} else {
+			r.agent = body
+		}
+		r.version++
+		r.write(w)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *etagRegistry) etag() string {
+	return fmt.Sprintf(`"v%d"`, r.version)
+}
+
+func (r *etagRegistry) write(w http.ResponseWriter) {
+	if !r.noETags {
+		w.Header().Set("ETag", r.etag())
+	}
+	json.NewEncoder(w).Encode(r.agent)
+}
+
+func (r *etagRegistry) sentIfMatch() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sent := r.ifMatch
+	r.ifMatch = nil
+	return sent
+}
+
+func TestUpdateAgent_IfMatch(t *testing.T) {
+	registry := newETagRegistry(t, true)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, agent.ETag)
+
+	agent.Description = "First edit"
+	updated, err := client.UpdateAgent("agent-1", agent)
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, updated.ETag)
+	assert.Equal(t, []string{`"v1"`}, registry.sentIfMatch())
+
+	// The agent read at v1 is now stale.
+	agent.Description = "Lost edit"
+	_, err = client.UpdateAgent("agent-1", agent)
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, CodePreconditionFailed, ErrorCode(err))
+	assert.Equal(t, http.StatusPreconditionFailed, StatusCode(err))
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// Without an ETag the update is unconditional.
+	agent.ETag = ""
+	_, err = client.UpdateAgent("agent-1", agent)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`"v1"`, ""}, registry.sentIfMatch())
+}
+
+func TestUpdateAgentWithRetry(t *testing.T) {
+	registry := newETagRegistry(t, true)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+	ctx := context.Background()
+
+	calls := 0
+	addTag := func(agent *Agent) error {
+		calls++
+		agent.Tags = append(agent.Tags, "retried")
+		return nil
+	}
+
+	registry.interfere = 2
+	agent, err := client.UpdateAgentWithRetry(ctx, "agent-1", addTag, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []string{"cooking", "retried"}, agent.Tags, "each attempt mutates a fresh read")
+	assert.Equal(t, []string{`"v1"`, `"v2"`, `"v3"`}, registry.sentIfMatch())
+
+	calls = 0
+	registry.interfere = 5
+	_, err = client.UpdateAgentWithRetry(ctx, "agent-1", addTag, 1)
+	var conflict *ConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 2, calls)
+
+	stop := errors.New("not today")
+	_, err = client.UpdateAgentWithRetry(ctx, "agent-1", func(*Agent) error { return stop }, 3)
+	assert.ErrorIs(t, err, stop)
+}
+
+func TestPatchAgent_IfMatch(t *testing.T) {
+	registry := newETagRegistry(t, true)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+	ctx := context.Background()
+
+	version := "1.1.0"
+	agent, err := client.PatchAgent(ctx, "agent-1", AgentPatch{Version: &version, ETag: `"v1"`})
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, agent.ETag)
+
+	_, err = client.PatchAgent(ctx, "agent-1", AgentPatch{Version: &version, ETag: `"v1"`})
+	var conflict *ConflictError
+	assert.ErrorAs(t, err, &conflict)
+
+	_, err = client.PatchAgent(ctx, "agent-1", AgentPatch{Version: &version})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`"v1"`, `"v1"`, ""}, registry.sentIfMatch())
+}
+
+func TestPatchAgent_FallbackUsesReadETag(t *testing.T) {
+	registry := newETagRegistry(t, false)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	// Another writer updates the agent between the read and the PUT.
+	registry.interfere = 1
+	version := "2.0.0"
+	_, err := client.PatchAgent(context.Background(), "agent-1", AgentPatch{Version: &version})
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []string{`"v1"`}, registry.sentIfMatch())
+}
+
+func TestGetAgent_NoETag(t *testing.T) {
+	registry := newETagRegistry(t, true)
+	registry.noETags = true
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Empty(t, agent.ETag)
+	_, err = client.UpdateAgentWithRetry(context.Background(), "agent-1", func(a *Agent) error {
+		a.Description = "Unconditional"
+		return nil
+	}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, registry.sentIfMatch())
+}
@@ -0,0 +1,189 @@
+package a2areg_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"a2areg/pkg/a2areg"
+	"a2areg/pkg/a2aregtest"
+)
+
+// seedRegistry stores a few public agents in a fake registry and returns it
+// with a client pointed at it. Callers must call the returned stop func.
+func seedRegistry() (*a2areg.A2ARegClient, func()) {
+	registry := a2aregtest.NewFakeRegistry()
+	for i, name := range []string{"Recipe Agent", "Travel Agent", "Recipe Planner", "Weather Agent", "Translation Agent"} {
+		registry.Put(fmt.Sprintf("agent-%d", i+1), true, map[string]interface{}{
+			"name":        name,
+			"description": "An example agent",
+			"version":     "1.0.0",
+			"provider":    map[string]interface{}{"organization": "acme"},
+		})
+	}
+	server := registry.Start()
+	client := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: server.URL, APIKey: "example-key"})
+	return client, server.Close
+}
+
+func ExampleNewA2ARegClient() {
+	registry := a2aregtest.NewFakeRegistry()
+	server := registry.Start()
+	defer server.Close()
+
+	client := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{
+		RegistryURL: server.URL,
+		APIKey:      "example-key",
+		Timeout:     10 * time.Second,
+		// Search results are cached for a short while and dropped
+		// whenever this client publishes, updates or deletes an agent.
+		EnableSearchCache: true,
+	})
+
+	health, err := client.Health(context.Background())
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println("status:", health.Status)
+	// Output:
+	// status: healthy
+}
+
+func ExampleA2ARegClient_PublishAgent() {
+	registry := a2aregtest.NewFakeRegistry()
+	server := registry.Start()
+	defer server.Close()
+	client := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: server.URL, APIKey: "example-key"})
+
+	url := "https://agents.example.com/recipes"
+	agent, err := client.PublishAgent(&a2areg.Agent{
+		Name:        "Recipe Agent",
+		Description: "Suggests recipes from what is in the fridge",
+		Version:     "1.0.0",
+		Provider:    "acme",
+		IsPublic:    true,
+		LocationURL: &url,
+		Skills: []a2areg.AgentSkill{
+			{ID: "suggest", Name: "Suggest recipes", Description: "Suggests recipes", Tags: []string{"cooking"}},
+		},
+	}, true)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(*agent.ID, agent.Name, agent.Version)
+	// Output:
+	// agent-1 Recipe Agent 1.0.0
+}
+
+func ExampleA2ARegClient_Search() {
+	client, stop := seedRegistry()
+	defer stop()
+
+	results, err := client.Search(context.Background(), a2areg.SearchRequest{Query: "recipe", Page: 1, Limit: 10})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println("total:", results.Total)
+	for _, agent := range results.Agents {
+		fmt.Println(*agent.ID, agent.Name)
+	}
+	// Output:
+	// total: 2
+	// agent-1 Recipe Agent
+	// agent-3 Recipe Planner
+}
+
+// IterAgents fetches successive listing pages as the loop consumes them.
+func Example_pagination() {
+	client, stop := seedRegistry()
+	defer stop()
+
+	it := client.IterAgents(context.Background(), a2areg.IterOptions{PublicOnly: true, PageSize: 2})
+	for it.Next() {
+		agent := it.Agent()
+		fmt.Println(*agent.ID, agent.Name)
+	}
+	if err := it.Err(); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// agent-1 Recipe Agent
+	// agent-2 Travel Agent
+	// agent-3 Recipe Planner
+	// agent-4 Weather Agent
+	// agent-5 Translation Agent
+}
+
+// Errors returned by the client are typed; use errors.As to get at the
+// concrete type, or ErrorCode and StatusCode for a quick check.
+func Example_errorHandling() {
+	client, stop := seedRegistry()
+	defer stop()
+
+	_, err := client.GetAgent("no-such-agent")
+
+	var notFound *a2areg.NotFoundError
+	if errors.As(err, &notFound) {
+		fmt.Println("not found:", notFound.Message)
+	}
+	fmt.Println("code:", a2areg.ErrorCode(err))
+	fmt.Println("status:", a2areg.StatusCode(err))
+
+	_, err = client.PublishAgent(&a2areg.Agent{Version: "1.0.0"}, true)
+	var invalid *a2areg.ValidationError
+	fmt.Println("validation error:", errors.As(err, &invalid))
+	// Output:
+	// not found: Resource not found
+	// code: agent_not_found
+	// status: 404
+	// validation error: true
+}
+
+func ExampleNewAgentBuilder() {
+	agent, err := a2areg.NewAgentBuilder("Recipe Agent", "1.0.0").
+		Description("Suggests recipes from what is in the fridge").
+		Provider("acme").
+		Public(true).
+		LocationURL("https://agents.example.com/recipes").
+		WithStreaming(true).
+		AddSkill(a2areg.AgentSkill{ID: "suggest", Name: "Suggest recipes", Description: "Suggests recipes", Tags: []string{"cooking"}}).
+		AddAPIKeyAuth("X-API-Key").
+		Build()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(agent.Name, agent.Version, *agent.LocationURL)
+	fmt.Println("streaming:", *agent.Capabilities.Streaming)
+	fmt.Println("auth:", agent.AuthSchemes[0].Type, *agent.AuthSchemes[0].Name)
+
+	_, err = a2areg.NewAgentBuilder("Recipe Agent", "").Description("No version").Provider("acme").Build()
+	fmt.Println("error:", err)
+	// Output:
+	// Recipe Agent 1.0.0 https://agents.example.com/recipes
+	// streaming: true
+	// auth: apiKey X-API-Key
+	// error: Agent version is required
+}
+
+func ExampleAgentBuilder_BuildCard() {
+	card, err := a2areg.NewAgentBuilder("Recipe Agent", "1.0.0").
+		Description("Suggests recipes from what is in the fridge").
+		Provider("acme").
+		AddInterface(a2areg.TransportJSONRPC, "https://agents.example.com/rpc").
+		AddSkill(a2areg.AgentSkill{ID: "suggest", Name: "Suggest recipes", Description: "Suggests recipes", Tags: []string{"cooking"}}).
+		BuildCard()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(card.Name, card.URL, card.Interface.PreferredTransport)
+	fmt.Println("streaming:", *card.Capabilities.Streaming)
+	// Output:
+	// Recipe Agent https://agents.example.com/rpc jsonrpc
+	// streaming: false
+}
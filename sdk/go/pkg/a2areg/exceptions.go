@@ -1,21 +1,130 @@
 package a2areg
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Stable, machine-readable error codes. Unlike messages, these never change
+// once released and are safe to key alerting on.
+const (
+	CodeRequestFailed          = "request_failed"
+	CodeInvalidRequest         = "invalid_request"
+	CodeEncodeFailed           = "encode_failed"
+	CodeDecodeFailed           = "decode_failed"
+	CodeAuthRequired           = "auth_required"
+	CodeAuthMissingCredentials = "auth_missing_credentials"
+	CodeAuthInvalidClient      = "auth_invalid_client"
+	CodeAuthFailed             = "auth_failed"
+	CodeAuthConflict           = "auth_conflicting_credentials"
+	CodeAccessDenied           = "access_denied"
+	CodeNotFound               = "not_found"
+	CodeAgentNotFound          = "agent_not_found"
+	CodeValidationFailed       = "validation_failed"
+	CodeRequestTooLarge        = "request_too_large"
+	CodeRateLimited            = "rate_limited"
+	CodeServerError            = "server_error"
+	CodeRegistryUnhealthy      = "registry_unhealthy"
+	CodeClientClosed           = "client_closed"
+	CodeAuthRefreshStuck       = "auth_refresh_stuck"
+	CodePinMismatch            = "pin_mismatch"
+	CodeConfirmationRequired   = "confirmation_required"
+	CodeConfirmationInvalid    = "confirmation_invalid"
+	CodeAgentChanged           = "agent_changed"
+	CodeLinkExpired            = "link_expired"
+	CodeHostNotAllowed         = "host_not_allowed"
+	CodeQueued                 = "queued"
+	CodeQueueExpired           = "queue_expired"
+	CodePartialResults         = "partial_results"
+	CodeMaintenance            = "maintenance"
+	CodeAmbiguousMatch         = "ambiguous_match"
+	CodeBulkFailed             = "bulk_failed"
+	CodeUnsupportedFeature     = "unsupported_feature"
+	CodeCardFetchFailed        = "card_fetch_failed"
+	CodeSignatureInvalid       = "signature_invalid"
+	CodeJWKSFetchFailed        = "jwks_fetch_failed"
+	CodeEntitlementDecided     = "entitlement_decided"
+	CodeNoViableAuth           = "no_viable_auth"
+	CodeUnsupportedEncoding    = "unsupported_encoding"
+	CodeUnsupportedProfile     = "unsupported_profile"
+	CodeVersionNotFound        = "version_not_found"
+	CodePreconditionFailed     = "precondition_failed"
+	CodeIDMismatch             = "id_mismatch"
+	CodeAPIError               = "api_error"
+)
 
 // A2AError is the base error type for A2A Registry SDK.
 type A2AError struct {
 	Message string
-	Details map[string]interface{}
-	Err     error
+	Code    string
+	// StatusCode is the HTTP status of the registry response that produced
+	// the error, or 0 if the error did not come from a response.
+	StatusCode int
+	Details    map[string]interface{}
+	Err        error
 }
 
 func (e *A2AError) Error() string {
 	if e.Err != nil {
-		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+		return e.Message + ": " + e.Err.Error()
 	}
 	return e.Message
 }
 
+func (e *A2AError) errorCode() string {
+	return e.Code
+}
+
+func (e *A2AError) setCode(code string) {
+	e.Code = code
+}
+
+func (e *A2AError) statusCode() int {
+	return e.StatusCode
+}
+
+func (e *A2AError) setStatusCode(status int) {
+	e.StatusCode = status
+}
+
+// codedError is implemented by every SDK error type via the embedded A2AError.
+type codedError interface {
+	error
+	errorCode() string
+	setCode(code string)
+	statusCode() int
+	setStatusCode(status int)
+}
+
+// withCode sets the code on an SDK error and returns it with its concrete type.
+func withCode[E codedError](err E, code string) E {
+	err.setCode(code)
+	return err
+}
+
+// StatusCode returns the HTTP status of the first SDK error in err's chain
+// that came from a registry response, or 0.
+func StatusCode(err error) int {
+	var coded codedError
+	if errors.As(err, &coded) {
+		return coded.statusCode()
+	}
+	return 0
+}
+
+// ErrorCode returns the stable code of the first SDK error in err's chain, or
+// an empty string if there is none.
+func ErrorCode(err error) string {
+	var coded codedError
+	if errors.As(err, &coded) {
+		return coded.errorCode()
+	}
+	return ""
+}
+
 func (e *A2AError) Unwrap() error {
 	return e.Err
 }
@@ -36,19 +145,58 @@ type AuthenticationError struct {
 // NewAuthenticationError creates a new AuthenticationError.
 func NewAuthenticationError(message string, details map[string]interface{}) *AuthenticationError {
 	return &AuthenticationError{
-		A2AError: NewA2AError(message, details),
+		A2AError: &A2AError{Message: message, Code: CodeAuthFailed, Details: details},
 	}
 }
 
 // ValidationError represents a validation failure.
 type ValidationError struct {
 	*A2AError
+	fields []FieldError
+}
+
+// Fields returns the per-field errors the registry reported, or nil when it
+// gave only a message.
+func (e *ValidationError) Fields() []FieldError {
+	return e.fields
+}
+
+// FieldError is one entry of a FastAPI validation error's "detail" array.
+type FieldError struct {
+	// Loc is the path to the offending value, such as ["body", "name"].
+	// Array indices are given in decimal.
+	Loc     []string `json:"loc"`
+	Message string   `json:"msg"`
+	Type    string   `json:"type"`
+}
+
+func (e FieldError) String() string {
+	switch {
+	case len(e.Loc) == 0:
+		return e.Message
+	case e.Message == "":
+		return strings.Join(e.Loc, ".")
+	}
+	return strings.Join(e.Loc, ".") + ": " + e.Message
 }
 
+// Pointer returns Loc as a JSON pointer (RFC 6901), such as
+// "/body/skills/0/id". It returns "" when Loc is empty.
+func (e FieldError) Pointer() string {
+	var b strings.Builder
+	for _, part := range e.Loc {
+		b.WriteByte('/')
+		b.WriteString(jsonPointerEscaper.Replace(part))
+	}
+	return b.String()
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
 // NewValidationError creates a new ValidationError.
 func NewValidationError(message string, details map[string]interface{}) *ValidationError {
 	return &ValidationError{
-		A2AError: NewA2AError(message, details),
+		A2AError: &A2AError{Message: message, Code: CodeValidationFailed, Details: details},
 	}
 }
 
@@ -60,19 +208,22 @@ type NotFoundError struct {
 // NewNotFoundError creates a new NotFoundError.
 func NewNotFoundError(message string, details map[string]interface{}) *NotFoundError {
 	return &NotFoundError{
-		A2AError: NewA2AError(message, details),
+		A2AError: &A2AError{Message: message, Code: CodeNotFound, Details: details},
 	}
 }
 
 // RateLimitError represents a rate limit error.
 type RateLimitError struct {
 	*A2AError
+	// Scope is the bucket the registry reported exhausted, from the
+	// X-RateLimit-Scope header; empty when it did not say.
+	Scope RateLimitScope
 }
 
 // NewRateLimitError creates a new RateLimitError.
 func NewRateLimitError(message string, details map[string]interface{}) *RateLimitError {
 	return &RateLimitError{
-		A2AError: NewA2AError(message, details),
+		A2AError: &A2AError{Message: message, Code: CodeRateLimited, Details: details},
 	}
 }
 
@@ -84,7 +235,243 @@ type ServerError struct {
 // NewServerError creates a new ServerError.
 func NewServerError(message string, details map[string]interface{}) *ServerError {
 	return &ServerError{
-		A2AError: NewA2AError(message, details),
+		A2AError: &A2AError{Message: message, Code: CodeServerError, Details: details},
+	}
+}
+
+// ConflictError reports that a conditional update was refused with 412
+// Precondition Failed: the agent changed since the ETag sent was read.
+// Read the agent again and retry, or use UpdateAgentWithRetry.
+type ConflictError struct {
+	*A2AError
+}
+
+// NewConflictError creates a new ConflictError.
+func NewConflictError(message string, details map[string]interface{}) *ConflictError {
+	return &ConflictError{
+		A2AError: &A2AError{Message: message, Code: CodePreconditionFailed, Details: details},
+	}
+}
+
+// PinMismatchError reports that the registry presented a certificate chain
+// matching none of the configured PinnedCertificates.
+type PinMismatchError struct {
+	*A2AError
+	// Pin is the SPKI pin of the presented leaf certificate.
+	Pin string
+}
+
+// NewPinMismatchError creates a new PinMismatchError for the presented pin.
+func NewPinMismatchError(pin string) *PinMismatchError {
+	return &PinMismatchError{
+		A2AError: &A2AError{
+			Message: "Registry certificate does not match any pinned key (presented pin " + pin + ")",
+			Code:    CodePinMismatch,
+			Details: map[string]interface{}{"pin": pin},
+		},
+		Pin: pin,
 	}
 }
 
+// DeleteConfirmationRequiredError is returned by DeleteAgent in SafeDelete
+// mode. Nothing has been deleted; pass Confirmation to ConfirmDelete.
+type DeleteConfirmationRequiredError struct {
+	*A2AError
+	Confirmation *DeleteConfirmation
+}
+
+// NewDeleteConfirmationRequiredError creates a new DeleteConfirmationRequiredError.
+func NewDeleteConfirmationRequiredError(confirmation *DeleteConfirmation) *DeleteConfirmationRequiredError {
+	return &DeleteConfirmationRequiredError{
+		A2AError: &A2AError{
+			Message: fmt.Sprintf("Deleting agent %q (%s by %s) requires confirmation", confirmation.AgentID, confirmation.Name, confirmation.Provider),
+			Code:    CodeConfirmationRequired,
+		},
+		Confirmation: confirmation,
+	}
+}
+
+// ExpiredLinkError reports that a card share link is past its expiry.
+type ExpiredLinkError struct {
+	*A2AError
+	// ExpiresAt is when the link expired, if the registry said.
+	ExpiresAt *time.Time
+}
+
+// NewExpiredLinkError creates a new ExpiredLinkError.
+func NewExpiredLinkError(expiresAt *time.Time) *ExpiredLinkError {
+	message := "Share link has expired"
+	if expiresAt != nil {
+		message += " (at " + expiresAt.UTC().Format(time.RFC3339) + ")"
+	}
+	return &ExpiredLinkError{
+		A2AError:  &A2AError{Message: message, Code: CodeLinkExpired, StatusCode: http.StatusGone},
+		ExpiresAt: expiresAt,
+	}
+}
+
+// QueuedError is returned by a mutating call that could not reach the
+// registry and was added to the offline queue instead. Err is the failure
+// that caused queueing, or nil if the call was queued behind earlier
+// operations without being attempted.
+type QueuedError struct {
+	*A2AError
+	Operation QueuedOperation
+}
+
+// NewQueuedError creates a new QueuedError for op.
+func NewQueuedError(op QueuedOperation, cause error) *QueuedError {
+	return &QueuedError{
+		A2AError: &A2AError{
+			Message: fmt.Sprintf("Registry unavailable; %s %s queued for replay", op.Method, op.Endpoint),
+			Code:    CodeQueued,
+			Err:     cause,
+		},
+		Operation: op,
+	}
+}
+
+// PartialResultsError is returned by Search when the registry timed out and
+// returned only part of the result set. Response holds what was returned.
+type PartialResultsError struct {
+	*A2AError
+	Response *SearchResponse
+}
+
+// NewPartialResultsError creates a new PartialResultsError for resp.
+func NewPartialResultsError(resp *SearchResponse) *PartialResultsError {
+	return &PartialResultsError{
+		A2AError: &A2AError{
+			Message: fmt.Sprintf("Search timed out with %d partial results", len(resp.Agents)),
+			Code:    CodePartialResults,
+		},
+		Response: resp,
+	}
+}
+
+// MaintenanceError is returned while the registry is in maintenance mode.
+// The client makes no further requests before Until.
+type MaintenanceError struct {
+	*A2AError
+	Until time.Time
+}
+
+// NewMaintenanceError creates a new MaintenanceError for a window ending at until.
+func NewMaintenanceError(until time.Time, details map[string]interface{}) *MaintenanceError {
+	return &MaintenanceError{
+		A2AError: &A2AError{
+			Message: "Registry is in maintenance until " + until.UTC().Format(time.RFC3339),
+			Code:    CodeMaintenance,
+			Details: details,
+		},
+		Until: until,
+	}
+}
+
+// AmbiguousMatchError is returned by ResolveRef when several agents match a
+// reference equally well. Candidates holds them.
+type AmbiguousMatchError struct {
+	*A2AError
+	Candidates []Agent
+}
+
+// NewAmbiguousMatchError creates a new AmbiguousMatchError for ref.
+func NewAmbiguousMatchError(ref string, candidates []Agent) *AmbiguousMatchError {
+	return &AmbiguousMatchError{
+		A2AError: &A2AError{
+			Message: fmt.Sprintf("%d agents match %s", len(candidates), ref),
+			Code:    CodeAmbiguousMatch,
+			Details: map[string]interface{}{"ref": ref},
+		},
+		Candidates: candidates,
+	}
+}
+
+// CardFetchError is returned when an agent card could not be fetched from
+// the agent's own host, as opposed to a failure of the registry. URL is the
+// card URL that failed. The agent host's response status, if there was one,
+// is in Details["status"]; StatusCode stays 0 because it describes registry
+// responses.
+type CardFetchError struct {
+	*A2AError
+	URL string
+}
+
+// NewCardFetchError creates a new CardFetchError for cardURL.
+func NewCardFetchError(cardURL, message string, cause error) *CardFetchError {
+	return &CardFetchError{
+		A2AError: &A2AError{
+			Message: message,
+			Code:    CodeCardFetchFailed,
+			Details: map[string]interface{}{"url": cardURL},
+			Err:     cause,
+		},
+		URL: cardURL,
+	}
+}
+
+// SignatureVerificationError is returned when an agent card's signature
+// does not verify. Code is CodeJWKSFetchFailed when the key set could not
+// be fetched, with its URL in Details["url"], and CodeSignatureInvalid
+// otherwise.
+type SignatureVerificationError struct {
+	*A2AError
+}
+
+// NewSignatureVerificationError creates a new SignatureVerificationError.
+func NewSignatureVerificationError(message string, cause error) *SignatureVerificationError {
+	return &SignatureVerificationError{
+		A2AError: &A2AError{
+			Message: message,
+			Code:    CodeSignatureInvalid,
+			Err:     cause,
+		},
+	}
+}
+
+// EntitlementDecidedError is returned by ApproveEntitlement and
+// RevokeEntitlement when the registry answers 409 because the entitlement
+// has already been decided. Status is its current status, if the registry
+// said.
+type EntitlementDecidedError struct {
+	*A2AError
+	EntitlementID string
+	Status        EntitlementStatus
+}
+
+// NewEntitlementDecidedError creates a new EntitlementDecidedError.
+func NewEntitlementDecidedError(entitlementID string, status EntitlementStatus, details map[string]interface{}) *EntitlementDecidedError {
+	message := fmt.Sprintf("Entitlement %s has already been decided", entitlementID)
+	if status != "" {
+		message += " (" + string(status) + ")"
+	}
+	return &EntitlementDecidedError{
+		A2AError: &A2AError{
+			Message:    message,
+			Code:       CodeEntitlementDecided,
+			StatusCode: http.StatusConflict,
+			Details:    details,
+		},
+		EntitlementID: entitlementID,
+		Status:        status,
+	}
+}
+
+// NoViableAuthError is returned by PlanAuthentication when the available
+// credentials satisfy none of a card's security schemes. Missing maps each
+// scheme name to what it lacks.
+type NoViableAuthError struct {
+	*A2AError
+	Missing map[string][]string
+}
+
+// NewNoViableAuthError creates a new NoViableAuthError.
+func NewNoViableAuthError(missing map[string][]string) *NoViableAuthError {
+	return &NoViableAuthError{
+		A2AError: &A2AError{
+			Message: "No security scheme can be satisfied (" + missingSummary(missing) + ")",
+			Code:    CodeNoViableAuth,
+		},
+		Missing: missing,
+	}
+}
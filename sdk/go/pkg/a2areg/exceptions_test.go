@@ -2,6 +2,7 @@ package a2areg
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -95,3 +96,15 @@ func TestErrorInheritance(t *testing.T) {
 	}
 }
 
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, CodeValidationFailed, ErrorCode(NewValidationError("bad", nil)))
+	assert.Equal(t, CodeNotFound, ErrorCode(NewNotFoundError("missing", nil)))
+	assert.Equal(t, CodeRateLimited, ErrorCode(NewRateLimitError("slow down", nil)))
+	assert.Equal(t, CodeServerError, ErrorCode(NewServerError("boom", nil)))
+	assert.Equal(t, "", ErrorCode(NewA2AError("plain", nil)))
+	assert.Equal(t, "", ErrorCode(errors.New("not ours")))
+	assert.Equal(t, "", ErrorCode(nil))
+
+	wrapped := fmt.Errorf("publishing: %w", withCode(NewAuthenticationError("nope", nil), CodeAuthInvalidClient))
+	assert.Equal(t, CodeAuthInvalidClient, ErrorCode(wrapped))
+}
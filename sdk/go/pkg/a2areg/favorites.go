@@ -0,0 +1,130 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// FavoritesCacheTTL is how long the starred agent IDs used by WithStarred
+// are reused before the favorites list is fetched again. StarAgent and
+// UnstarAgent refresh them immediately.
+const FavoritesCacheTTL = 30 * time.Second
+
+// favoritesPageSize is the page size used to collect the starred IDs.
+const favoritesPageSize = 100
+
+// StarredAgentList is a page of the client's starred agents.
+type StarredAgentList struct {
+	Agents []Agent `json:"agents"`
+	Total  int     `json:"total"`
+	Page   int     `json:"page"`
+	Limit  int     `json:"limit"`
+}
+
+// favoritesState caches the IDs of the starred agents for WithStarred.
+type favoritesState struct {
+	mu        sync.Mutex
+	ids       map[string]bool
+	fetchedAt time.Time
+}
+
+// WithStarred sets Agent.Starred on the result of GetAgent, and a "starred"
+// boolean on each agent of a ListAgents page, by looking the agents up in
+// the client's favorites. The favorites are cached for FavoritesCacheTTL.
+func WithStarred() RequestOption {
+	return func(o *requestOptions) {
+		o.starred = true
+	}
+}
+
+// StarAgent adds agentID to the client's favorites. Starring an agent that
+// is already starred is not an error; starring one that does not exist
+// returns a *NotFoundError.
+func (c *A2ARegClient) StarAgent(ctx context.Context, agentID string) error {
+	_, err := c.makeRequestContext(ctx, "PUT", "/me/favorites/"+url.PathEscape(agentID), nil, nil)
+	c.invalidateFavorites()
+	return agentNotFound(err)
+}
+
+// UnstarAgent removes agentID from the client's favorites.
+func (c *A2ARegClient) UnstarAgent(ctx context.Context, agentID string) error {
+	_, err := c.makeRequestContext(ctx, "DELETE", "/me/favorites/"+url.PathEscape(agentID), nil, nil)
+	c.invalidateFavorites()
+	return agentNotFound(err)
+}
+
+// ListStarredAgents lists the client's starred agents, most recently
+// starred first. Every agent in the result has Starred set.
+func (c *A2ARegClient) ListStarredAgents(ctx context.Context, page, limit int) (*StarredAgentList, error) {
+	body, err := c.makeRequestContext(ctx, "GET", "/me/favorites", nil, map[string]string{
+		"page":  fmt.Sprintf("%d", page),
+		"limit": fmt.Sprintf("%d", limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var list StarredAgentList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode favorites response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	for i := range list.Agents {
+		list.Agents[i].interfacesFromCard()
+		list.Agents[i].Starred = true
+	}
+	return &list, nil
+}
+
+// starredIDs returns the IDs of the starred agents, from the cache while it
+// is fresh.
+func (c *A2ARegClient) starredIDs(ctx context.Context) (map[string]bool, error) {
+	s := &c.favorites
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ids != nil && c.clock.Since(s.fetchedAt) < FavoritesCacheTTL {
+		return s.ids, nil
+	}
+
+	// The cached IDs serve later calls too, so the options of the call
+	// being decorated are not applied to the favorites requests.
+	ctx = context.WithValue(ctx, requestOptionsKey{}, requestOptions{})
+	ids := make(map[string]bool)
+	for page := 1; ; page++ {
+		list, err := c.ListStarredAgents(ctx, page, favoritesPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, agent := range list.Agents {
+			if agent.ID != nil {
+				ids[*agent.ID] = true
+			}
+		}
+		if len(list.Agents) < favoritesPageSize || (list.Total > 0 && len(ids) >= list.Total) {
+			break
+		}
+	}
+	s.ids, s.fetchedAt = ids, c.clock.Now()
+	return ids, nil
+}
+
+// invalidateFavorites drops the cached starred IDs.
+func (c *A2ARegClient) invalidateFavorites() {
+	c.favorites.mu.Lock()
+	c.favorites.ids = nil
+	c.favorites.mu.Unlock()
+}
+
+// markStarred sets the "starred" member of each agent in a listing
+// document to whether ids holds its ID.
+func markStarred(listing map[string]interface{}, ids map[string]bool) {
+	agents, _ := listing["agents"].([]interface{})
+	for _, agent := range agents {
+		if agent, ok := agent.(map[string]interface{}); ok {
+			id, _ := agent["id"].(string)
+			agent["starred"] = ids[id]
+		}
+	}
+}
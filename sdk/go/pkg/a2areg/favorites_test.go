@@ -0,0 +1,152 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// favoritesRegistry serves three agents and the client's favorites,
+// counting the requests for the favorites list.
+type favoritesRegistry struct {
+	*httptest.Server
+	mu         sync.Mutex
+	starred    []string
+	listCalls  atomic.Int32
+	lastHeader atomic.Value
+}
+
+func newFavoritesRegistry(t *testing.T) *favoritesRegistry {
+	r := &favoritesRegistry{}
+	exists := map[string]bool{"agent-1": true, "agent-2": true, "agent-3": true}
+	agent := func(id string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "name": "Agent " + id}
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		switch {
+		case req.URL.Path == "/me/favorites":
+			r.listCalls.Add(1)
+			r.lastHeader.Store(req.Header.Get("X-Trace"))
+			agents := []map[string]interface{}{}
+			for i := len(r.starred) - 1; i >= 0; i-- {
+				agents = append(agents, agent(r.starred[i]))
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"agents": agents, "total": len(agents), "page": 1, "limit": 100})
+		case strings.HasPrefix(req.URL.Path, "/me/favorites/"):
+			id := strings.TrimPrefix(req.URL.Path, "/me/favorites/")
+			if !exists[id] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			kept := []string{}
+			for _, starred := range r.starred {
+				if starred != id {
+					kept = append(kept, starred)
+				}
+			}
+			if req.Method == "PUT" {
+				kept = append(kept, id)
+			}
+			r.starred = kept
+			w.WriteHeader(http.StatusNoContent)
+		case req.URL.Path == "/agents/public":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"agents": []map[string]interface{}{agent("agent-1"), agent("agent-2"), agent("agent-3")},
+				"total":  3,
+			})
+		case strings.HasPrefix(req.URL.Path, "/agents/"):
+			json.NewEncoder(w).Encode(agent(strings.TrimPrefix(req.URL.Path, "/agents/")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestFavorites_StarUnstarList(t *testing.T) {
+	registry := newFavoritesRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+	ctx := context.Background()
+
+	require.NoError(t, client.StarAgent(ctx, "agent-1"))
+	require.NoError(t, client.StarAgent(ctx, "agent-3"))
+	require.NoError(t, client.StarAgent(ctx, "agent-3"), "starring twice is not an error")
+
+	list, err := client.ListStarredAgents(ctx, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 2, list.Total)
+	require.Len(t, list.Agents, 2)
+	assert.Equal(t, "agent-3", *list.Agents[0].ID)
+	assert.Equal(t, "agent-1", *list.Agents[1].ID)
+	assert.True(t, list.Agents[0].Starred)
+
+	require.NoError(t, client.UnstarAgent(ctx, "agent-1"))
+	list, err = client.ListStarredAgents(ctx, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.Total)
+
+	err = client.StarAgent(ctx, "missing")
+	var notFound *NotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+}
+
+func TestFavorites_WithStarredCaching(t *testing.T) {
+	registry := newFavoritesRegistry(t)
+	registry.starred = []string{"agent-2"}
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test", Clock: fake})
+
+	agent, err := client.GetAgent("agent-2")
+	require.NoError(t, err)
+	assert.False(t, agent.Starred, "only decorated calls look at favorites")
+	assert.Equal(t, int32(0), registry.listCalls.Load())
+
+	agent, err = client.GetAgent("agent-2", WithStarred(), WithHeader("X-Trace", "get"))
+	require.NoError(t, err)
+	assert.True(t, agent.Starred)
+	assert.Equal(t, "", registry.lastHeader.Load(), "call options do not apply to the favorites lookup")
+
+	listing, err := client.ListAgentsPage(context.Background(), 1, 10, true, WithStarred())
+	require.NoError(t, err)
+	var starred []bool
+	for _, a := range listing.Agents {
+		starred = append(starred, a.Starred)
+	}
+	assert.Equal(t, []bool{false, true, false}, starred)
+	assert.Equal(t, int32(1), registry.listCalls.Load(), "favorites are cached between calls")
+
+	// A change made elsewhere shows once the cache expires.
+	registry.mu.Lock()
+	registry.starred = append(registry.starred, "agent-1")
+	registry.mu.Unlock()
+	agent, err = client.GetAgent("agent-1", WithStarred())
+	require.NoError(t, err)
+	assert.False(t, agent.Starred)
+	fake.Advance(FavoritesCacheTTL)
+	agent, err = client.GetAgent("agent-1", WithStarred())
+	require.NoError(t, err)
+	assert.True(t, agent.Starred)
+	assert.Equal(t, int32(2), registry.listCalls.Load())
+
+	// Starring through the client takes effect at once.
+	require.NoError(t, client.UnstarAgent(context.Background(), "agent-1"))
+	agent, err = client.GetAgent("agent-1", WithStarred())
+	require.NoError(t, err)
+	assert.False(t, agent.Starred)
+	assert.Equal(t, int32(3), registry.listCalls.Load())
+}
@@ -0,0 +1,87 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// agentFieldNames is the set of JSON field names of Agent.
+var agentFieldNames = jsonFieldNames(reflect.TypeOf(agentJSON{}))
+
+// jsonFieldNames returns the JSON names of the exported fields of struct t.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// GetAgentFields fetches an agent with only the given fields populated, using
+// the registry's sparse fieldsets (?fields=a,b,c). Field names are the Agent
+// JSON names (e.g. "location_url", "capabilities", "auth_schemes") and are
+// validated before any request is made. Registries that do not support
+// sparse fieldsets are handled transparently: if the parameter is rejected
+// the full agent is fetched, and in either case the result is projected
+// client-side so that unrequested fields are always zero.
+func (c *A2ARegClient) GetAgentFields(ctx context.Context, agentID string, fields []string) (*Agent, error) {
+	if len(fields) == 0 {
+		return nil, NewValidationError("At least one field is required", nil)
+	}
+	var unknown []string
+	for _, field := range fields {
+		if !agentFieldNames[field] {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, NewValidationError(fmt.Sprintf("Unknown agent fields: %s", strings.Join(unknown, ", ")), map[string]interface{}{"fields": unknown})
+	}
+
+	body, err := c.getAgent(ctx, agentID, map[string]string{"fields": strings.Join(fields, ",")})
+	if err != nil {
+		switch StatusCode(err) {
+		case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusNotImplemented:
+			body, err = c.getAgent(ctx, agentID, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	projected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if raw, ok := document[field]; ok {
+			projected[field] = raw
+		}
+	}
+	data, err := json.Marshal(projected)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to encode projected agent", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+
+	var agent Agent
+	if err := json.Unmarshal(data, &agent); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	return &agent, nil
+}
@@ -0,0 +1,114 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fullAgentResponse = map[string]interface{}{
+	"id":           "agent-1",
+	"name":         "Router Target",
+	"description":  "A routed agent",
+	"version":      "1.0.0",
+	"provider":     "acme",
+	"location_url": "https://agent.example.com",
+	"capabilities": map[string]interface{}{"streaming": true},
+	"auth_schemes": []map[string]interface{}{{"type": "apiKey", "name": "X-API-Key"}},
+	"skills": []map[string]interface{}{
+		{"id": "s1", "name": "Skill", "description": "Big", "tags": []string{"x"}, "examples": []string{"a", "b"}},
+	},
+}
+
+func TestA2ARegClient_GetAgentFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/agents/agent-1", r.URL.Path)
+		assert.Equal(t, "location_url,capabilities,auth_schemes", r.URL.Query().Get("fields"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"location_url": fullAgentResponse["location_url"],
+			"capabilities": fullAgentResponse["capabilities"],
+			"auth_schemes": fullAgentResponse["auth_schemes"],
+		})
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+	agent, err := client.GetAgentFields(context.Background(), "agent-1", []string{"location_url", "capabilities", "auth_schemes"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://agent.example.com", *agent.LocationURL)
+	assert.True(t, *agent.Capabilities.Streaming)
+	require.Len(t, agent.AuthSchemes, 1)
+	assert.Equal(t, AuthSchemeAPIKey, agent.AuthSchemes[0].Type)
+}
+
+func TestA2ARegClient_GetAgentFields_ProjectsIgnoredParam(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(fullAgentResponse)
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+	agent, err := client.GetAgentFields(context.Background(), "agent-1", []string{"location_url", "capabilities"})
+	require.NoError(t, err)
+	assert.Equal(t, &Agent{
+		LocationURL:  agent.LocationURL,
+		Capabilities: agent.Capabilities,
+	}, agent)
+	assert.Equal(t, "https://agent.example.com", *agent.LocationURL)
+	assert.Nil(t, agent.Skills)
+	assert.Empty(t, agent.Name)
+}
+
+func TestA2ARegClient_GetAgentFields_FallbackOnRejectedParam(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Has("fields") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"detail":"unknown parameter: fields"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(fullAgentResponse)
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+	agent, err := client.GetAgentFields(context.Background(), "agent-1", []string{"name", "skills"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fields=name%2Cskills", ""}, queries)
+	assert.Equal(t, "Router Target", agent.Name)
+	assert.Len(t, agent.Skills, 1)
+	assert.Nil(t, agent.Capabilities)
+}
+
+func TestA2ARegClient_GetAgentFields_Validation(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: "http://127.0.0.1:1", APIKey: "test-key"})
+
+	_, err := client.GetAgentFields(context.Background(), "agent-1", []string{"url", "capabilities", "bogus"})
+	var vErr *ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []string{"bogus", "url"}, vErr.Details["fields"])
+
+	_, err = client.GetAgentFields(context.Background(), "agent-1", nil)
+	assert.IsType(t, &ValidationError{}, err)
+}
+
+func TestA2ARegClient_GetAgentFields_NotFoundIsNotRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+	_, err := client.GetAgentFields(context.Background(), "missing", []string{"name"})
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+	assert.Equal(t, 1, calls)
+}
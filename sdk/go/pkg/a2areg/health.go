@@ -0,0 +1,173 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReadinessTTL is how long a health result is reused by ReadinessCheck.
+const DefaultReadinessTTL = 10 * time.Second
+
+// readinessState caches the outcome of the last registry health check.
+type readinessState struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	err       error
+	checkedAt time.Time
+	inflight  chan struct{}
+}
+
+// ReadinessCheck reports whether the registry is reachable and healthy. It is
+// designed to back an HTTP readiness probe: results are cached for
+// ReadinessTTL, a refresh is started in the background once half of the TTL
+// has elapsed, and a probe only waits on the network when no result younger
+// than the TTL exists. Concurrent probes share a single health request.
+func (c *A2ARegClient) ReadinessCheck(ctx context.Context) error {
+	if c.closed.Load() {
+		return errClientClosed()
+	}
+
+	r := &c.readiness
+	r.mu.Lock()
+	age := c.clock.Since(r.checkedAt)
+	if !r.checkedAt.IsZero() && age < r.ttl {
+		err := r.err
+		if age >= r.ttl/2 {
+			c.startReadinessRefreshLocked()
+		}
+		r.mu.Unlock()
+		return err
+	}
+	done := c.startReadinessRefreshLocked()
+	r.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// startReadinessRefreshLocked starts a background health check unless one is
+// already running, and returns a channel closed when it completes. The caller
+// must hold c.readiness.mu.
+func (c *A2ARegClient) startReadinessRefreshLocked() <-chan struct{} {
+	r := &c.readiness
+	if r.inflight != nil {
+		return r.inflight
+	}
+	done := make(chan struct{})
+	r.inflight = done
+
+	go func() {
+		// The refresh outlives the probe that triggered it, so it is bounded
+		// by the client timeout rather than the caller's context.
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		err := c.checkHealth(ctx)
+
+		r.mu.Lock()
+		r.err = err
+		r.checkedAt = c.clock.Now()
+		r.inflight = nil
+		r.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// checkHealth queries /health and treats any status other than healthy/ok as
+// unhealthy. A response without a status field counts as healthy. During a
+// maintenance window it returns a *MaintenanceError rather than a server
+// error, so callers can tell planned downtime from an outage.
+func (c *A2ARegClient) checkHealth(ctx context.Context) error {
+	body, err := c.makeRequestContext(ctx, "GET", "/health", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(body, &health); err != nil {
+		return withCode(NewA2AError("Failed to decode health response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	switch strings.ToLower(health.Status) {
+	case "", "healthy", "ok":
+		return nil
+	default:
+		return withCode(NewServerError(fmt.Sprintf("Registry reports status %q", health.Status), map[string]interface{}{"status": health.Status}), CodeRegistryUnhealthy)
+	}
+}
+
+// LivenessCheck reports whether the client itself is usable. It never touches
+// the network: it fails only if the client has been closed or a token refresh
+// has been running for longer than twice the client timeout.
+func (c *A2ARegClient) LivenessCheck(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c.closed.Load() {
+		return errClientClosed()
+	}
+	if started, ok := c.authsInFlight.oldest(); ok {
+		if elapsed := c.clock.Since(started); elapsed > 2*c.timeout {
+			return withCode(NewAuthenticationError(fmt.Sprintf("Token refresh has been running for %s", elapsed.Round(time.Millisecond)), nil), CodeAuthRefreshStuck)
+		}
+	}
+	return nil
+}
+
+// authsInFlight tracks when each running token request started, so that a
+// stuck request stays visible while others start and finish around it.
+type authsInFlight struct {
+	mu      sync.Mutex
+	next    uint64
+	started map[uint64]time.Time
+}
+
+// begin records a token request started at now and returns the func that
+// ends it.
+func (a *authsInFlight) begin(now time.Time) (end func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.started == nil {
+		a.started = make(map[uint64]time.Time)
+	}
+	id := a.next
+	a.next++
+	a.started[id] = now
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		delete(a.started, id)
+	}
+}
+
+// oldest returns the start time of the longest-running token request,
+// reporting false when none is running.
+func (a *authsInFlight) oldest() (time.Time, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var oldest time.Time
+	for _, started := range a.started {
+		if oldest.IsZero() || started.Before(oldest) {
+			oldest = started
+		}
+	}
+	return oldest, !oldest.IsZero()
+}
+
+// errClientClosed is returned by operations on a closed client.
+func errClientClosed() *A2AError {
+	return withCode(NewA2AError("Client is closed", nil), CodeClientClosed)
+}
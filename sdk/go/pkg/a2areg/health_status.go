@@ -0,0 +1,162 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HealthStatus is the registry's /health response.
+type HealthStatus struct {
+	// Status is the overall status, such as "healthy", "ok" or "degraded".
+	Status  string
+	Version string
+	// Uptime is how long the registry has been running, or zero if it did
+	// not say.
+	Uptime time.Duration
+	// Dependencies reports the registry's backing services by name
+	// ("database", "search_index", "cache"). It is nil when the registry
+	// reports only its own status.
+	Dependencies map[string]DependencyHealth
+}
+
+// DependencyHealth is the state of one service the registry depends on.
+type DependencyHealth struct {
+	Status string
+	// Latency is the dependency's last measured response time, or zero if
+	// the registry did not say.
+	Latency time.Duration
+}
+
+// Healthy reports whether Status is "healthy" or "ok", in any case.
+func (h *HealthStatus) Healthy() bool {
+	return isHealthyStatus(h.Status)
+}
+
+// Healthy reports whether Status is "healthy" or "ok", in any case.
+func (d DependencyHealth) Healthy() bool {
+	return isHealthyStatus(d.Status)
+}
+
+func isHealthyStatus(status string) bool {
+	switch strings.ToLower(status) {
+	case "healthy", "ok":
+		return true
+	}
+	return false
+}
+
+// UnmarshalJSON reads uptime from "uptime" (seconds, or a duration string
+// like "72h3m") or "uptime_seconds", and dependencies from "dependencies"
+// or "checks".
+func (h *HealthStatus) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Status        string                      `json:"status"`
+		Version       string                      `json:"version"`
+		Uptime        json.RawMessage             `json:"uptime"`
+		UptimeSeconds *float64                    `json:"uptime_seconds"`
+		Dependencies  map[string]DependencyHealth `json:"dependencies"`
+		Checks        map[string]DependencyHealth `json:"checks"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*h = HealthStatus{Status: raw.Status, Version: raw.Version, Dependencies: raw.Dependencies}
+	if h.Dependencies == nil {
+		h.Dependencies = raw.Checks
+	}
+	if raw.UptimeSeconds != nil {
+		h.Uptime = time.Duration(*raw.UptimeSeconds * float64(time.Second))
+	}
+	if len(raw.Uptime) > 0 && string(raw.Uptime) != "null" {
+		uptime, err := parseDurationValue(raw.Uptime, time.Second)
+		if err != nil {
+			return fmt.Errorf("a2areg: invalid uptime: %w", err)
+		}
+		h.Uptime = uptime
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts a dependency as an object with "status" and
+// "latency_ms" (or "latency", in milliseconds or as a duration string), or
+// as a bare status string.
+func (d *DependencyHealth) UnmarshalJSON(data []byte) error {
+	var status string
+	if json.Unmarshal(data, &status) == nil {
+		*d = DependencyHealth{Status: status}
+		return nil
+	}
+	var raw struct {
+		Status    string          `json:"status"`
+		LatencyMS *float64        `json:"latency_ms"`
+		Latency   json.RawMessage `json:"latency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*d = DependencyHealth{Status: raw.Status}
+	if raw.LatencyMS != nil {
+		d.Latency = time.Duration(*raw.LatencyMS * float64(time.Millisecond))
+	} else if len(raw.Latency) > 0 && string(raw.Latency) != "null" {
+		latency, err := parseDurationValue(raw.Latency, time.Millisecond)
+		if err != nil {
+			return fmt.Errorf("a2areg: invalid latency: %w", err)
+		}
+		d.Latency = latency
+	}
+	return nil
+}
+
+// parseDurationValue reads a JSON number of units or a Go duration string.
+func parseDurationValue(data json.RawMessage, unit time.Duration) (time.Duration, error) {
+	var n float64
+	if json.Unmarshal(data, &n) == nil {
+		return time.Duration(n * float64(unit)), nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, err
+	}
+	return time.ParseDuration(s)
+}
+
+// Health fetches the registry's health as a HealthStatus. A registry that
+// answers with an unhealthy status is not an error; check Healthy.
+func (c *A2ARegClient) Health(ctx context.Context, opts ...RequestOption) (*HealthStatus, error) {
+	health, _, err := c.health(withRequestOptions(ctx, opts))
+	return health, err
+}
+
+// health fetches and decodes /health. It also returns the document the
+// status was decoded from, for GetHealth.
+func (c *A2ARegClient) health(ctx context.Context) (*HealthStatus, ResponseMap, error) {
+	body, err := c.makeRequestContext(ctx, "GET", "/health", nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var health HealthStatus
+	if err := json.Unmarshal(body, &health); err != nil {
+		return nil, nil, withCode(NewA2AError("Failed to decode health response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	doc, err := decodeResponseMap(body)
+	if err != nil {
+		return nil, nil, withCode(NewA2AError("Failed to decode health response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	return &health, doc, nil
+}
+
+// Ready reports whether the registry is up and reports itself "healthy" or
+// "ok"; any other status, a missing one included, is not ready. The error
+// is set only when the health could not be fetched, for example a
+// *MaintenanceError during planned downtime. Unlike ReadinessCheck, every
+// call queries the registry.
+func (c *A2ARegClient) Ready(ctx context.Context, opts ...RequestOption) (bool, error) {
+	health, err := c.Health(ctx, opts...)
+	if err != nil {
+		return false, err
+	}
+	return health.Healthy(), nil
+}
@@ -0,0 +1,195 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestA2ARegClient_ReadinessCheck_CachesWithinTTL(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", ReadinessTTL: time.Hour})
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, client.ReadinessCheck(context.Background()))
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestA2ARegClient_ReadinessCheck_RefreshesAfterTTL(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	fake := clock.NewFake(time.Now())
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", ReadinessTTL: time.Minute, Clock: fake})
+
+	assert.Error(t, client.ReadinessCheck(context.Background()))
+	fake.Advance(29 * time.Second)
+	assert.Error(t, client.ReadinessCheck(context.Background()), "cached failure is served within the TTL")
+	assert.Equal(t, int32(1), calls.Load())
+
+	fake.Advance(time.Minute)
+	assert.NoError(t, client.ReadinessCheck(context.Background()))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestA2ARegClient_ReadinessCheck_DoesNotBlockWhenFresh(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	fake := clock.NewFake(time.Now())
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", ReadinessTTL: time.Minute, Clock: fake})
+	require.NoError(t, client.ReadinessCheck(context.Background()))
+
+	// Past half the TTL a background refresh starts and hangs on the server,
+	// but probes keep answering from the still-fresh cached result.
+	fake.Advance(40 * time.Second)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, client.ReadinessCheck(context.Background()))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("probe blocked on the in-flight health request")
+	}
+	assert.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestA2ARegClient_ReadinessCheck_Unhealthy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"degraded"}`))
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+	err := client.ReadinessCheck(context.Background())
+	assert.Equal(t, CodeRegistryUnhealthy, ErrorCode(err))
+}
+
+func TestA2ARegClient_LivenessCheck(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "test-key", Timeout: time.Second, Clock: fake})
+	assert.NoError(t, client.LivenessCheck(context.Background()))
+
+	end := client.authsInFlight.begin(fake.Now())
+	fake.Advance(2 * time.Second)
+	assert.NoError(t, client.LivenessCheck(context.Background()))
+	fake.Advance(time.Millisecond)
+	assert.Equal(t, CodeAuthRefreshStuck, ErrorCode(client.LivenessCheck(context.Background())))
+	end()
+	assert.NoError(t, client.LivenessCheck(context.Background()))
+
+	client.closed.Store(true)
+	assert.Equal(t, CodeClientClosed, ErrorCode(client.LivenessCheck(context.Background())))
+}
+
+func TestA2ARegClient_LivenessCheck_OverlappingRefreshes(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "test-key", Timeout: time.Second, Clock: fake})
+
+	// A refresh that finishes must not hide an older one that is stuck.
+	stuck := client.authsInFlight.begin(fake.Now())
+	fake.Advance(time.Second)
+	client.authsInFlight.begin(fake.Now())()
+	fake.Advance(time.Second + time.Millisecond)
+	assert.Equal(t, CodeAuthRefreshStuck, ErrorCode(client.LivenessCheck(context.Background())))
+
+	stuck()
+	assert.NoError(t, client.LivenessCheck(context.Background()))
+}
+
+func TestA2ARegClient_Health_Dependencies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "degraded", "version": "2.4.1", "uptime_seconds": 3600,
+			"dependencies": {
+				"database": {"status": "healthy", "latency_ms": 4.5},
+				"search_index": {"status": "unhealthy", "latency": "1.2s"},
+				"cache": "ok"
+			}}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	health, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, "2.4.1", health.Version)
+	assert.Equal(t, time.Hour, health.Uptime)
+	assert.False(t, health.Healthy())
+	assert.Equal(t, map[string]DependencyHealth{
+		"database":     {Status: "healthy", Latency: 4500 * time.Microsecond},
+		"search_index": {Status: "unhealthy", Latency: 1200 * time.Millisecond},
+		"cache":        {Status: "ok"},
+	}, health.Dependencies)
+	assert.False(t, health.Dependencies["search_index"].Healthy())
+
+	ready, err := client.Ready(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ready)
+}
+
+func TestA2ARegClient_Health_StatusOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	health, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &HealthStatus{Status: "ok"}, health)
+	assert.Nil(t, health.Dependencies)
+
+	ready, err := client.Ready(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ready)
+}
+
+func TestA2ARegClient_Ready_Errors(t *testing.T) {
+	var uptime HealthStatus
+	assert.Error(t, json.Unmarshal([]byte(`{"status":"ok","uptime":"forever"}`), &uptime))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	ready, err := client.Ready(context.Background())
+	assert.False(t, ready)
+	assert.ErrorIs(t, err, ErrServer)
+}
@@ -0,0 +1,103 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// SearchHit is one search result with its score and highlight fragments.
+type SearchHit struct {
+	Agent Agent
+	// Score is zero when the registry returned no scores.
+	Score float64
+	// Highlights maps each matched field to its fragments, with the match
+	// wrapped in <em>. It is nil when the registry returned none.
+	Highlights map[string][]string
+}
+
+// Hits returns the results of r as SearchHits.
+func (r *SearchResponse) Hits() []SearchHit {
+	hits := make([]SearchHit, len(r.Agents))
+	for i, agent := range r.Agents {
+		hits[i].Agent = agent
+		if i < len(r.Scores) {
+			hits[i].Score = r.Scores[i]
+		}
+		if i < len(r.Highlights) {
+			hits[i].Highlights = r.Highlights[i]
+		}
+	}
+	return hits
+}
+
+// decodeHighlights decodes a result's highlights object, whose values are
+// a fragment or a list of them, and sanitizes every fragment. Anything
+// else decodes to nil, as does an object without fragments.
+func decodeHighlights(data json.RawMessage) map[string][]string {
+	var raw map[string]json.RawMessage
+	if len(data) == 0 || json.Unmarshal(data, &raw) != nil {
+		return nil
+	}
+	var highlights map[string][]string
+	for field, value := range raw {
+		var fragments []string
+		if json.Unmarshal(value, &fragments) != nil {
+			var fragment string
+			if json.Unmarshal(value, &fragment) != nil {
+				continue
+			}
+			fragments = []string{fragment}
+		}
+		for _, fragment := range fragments {
+			if fragment == "" {
+				continue
+			}
+			if highlights == nil {
+				highlights = make(map[string][]string)
+			}
+			highlights[field] = append(highlights[field], sanitizeHighlight(fragment))
+		}
+	}
+	return highlights
+}
+
+// highlightTag matches a markup tag, or a stray "<" or ">".
+var highlightTag = regexp.MustCompile(`<[^<>]*>|[<>]`)
+
+var angleEscaper = strings.NewReplacer("<", "&lt;", ">", "&gt;")
+
+// sanitizeHighlight makes a fragment safe to insert into HTML: <em> and
+// </em>, without attributes, are kept and balanced; every other tag and
+// stray angle bracket is escaped.
+func sanitizeHighlight(fragment string) string {
+	var b strings.Builder
+	open := 0
+	last := 0
+	for _, loc := range highlightTag.FindAllStringIndex(fragment, -1) {
+		b.WriteString(fragment[last:loc[0]])
+		last = loc[1]
+		tag := fragment[loc[0]:loc[1]]
+		name := ""
+		if len(tag) > 2 {
+			name = strings.ToLower(strings.TrimSpace(tag[1 : len(tag)-1]))
+		}
+		switch {
+		case name == "em":
+			b.WriteString("<em>")
+			open++
+		case name == "/em":
+			if open > 0 {
+				b.WriteString("</em>")
+				open--
+			}
+		default:
+			b.WriteString(angleEscaper.Replace(tag))
+		}
+	}
+	b.WriteString(fragment[last:])
+	for ; open > 0; open-- {
+		b.WriteString("</em>")
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch_Highlights(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"agents": [
+			{"id": "a", "name": "Recipe Agent", "score": 0.9, "highlights": {
+				"name": ["<em>Recipe</em> Agent"],
+				"skills.examples": ["Suggest a <em>recipe</em> for dinner", "Scale a <EM>recipe</EM>"]
+			}},
+			{"id": "b", "name": "Pantry Agent", "score": 0.4},
+			{"agent": {"id": "c", "name": "Meal Planner"}, "highlights": {"description": "Weekly <em>recipe</em> plans"}}
+		], "total": 3}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	resp, err := client.Search(context.Background(), SearchRequest{
+		Query:           "recipe",
+		Highlight:       true,
+		HighlightFields: []string{"name", "skills.examples"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, got["highlight"])
+	assert.Equal(t, []interface{}{"name", "skills.examples"}, got["highlight_fields"])
+
+	hits := resp.Hits()
+	require.Len(t, hits, 3)
+	assert.Equal(t, "Recipe Agent", hits[0].Agent.Name)
+	assert.Equal(t, 0.9, hits[0].Score)
+	assert.Equal(t, map[string][]string{
+		"name":            {"<em>Recipe</em> Agent"},
+		"skills.examples": {"Suggest a <em>recipe</em> for dinner", "Scale a <em>recipe</em>"},
+	}, hits[0].Highlights)
+	assert.Nil(t, hits[1].Highlights)
+	assert.Equal(t, map[string][]string{"description": {"Weekly <em>recipe</em> plans"}}, hits[2].Highlights)
+}
+
+func TestSearch_HighlightsUnsupported(t *testing.T) {
+	var got map[string]interface{}
+	client := timeoutSearchServer(t, &got)
+
+	resp, err := client.Search(context.Background(), SearchRequest{Query: "recipes", AllowPartial: true})
+	require.NoError(t, err)
+	assert.NotContains(t, got, "highlight")
+	assert.NotContains(t, got, "highlight_fields")
+	assert.Nil(t, resp.Highlights)
+	require.Len(t, resp.Hits(), 1)
+	assert.Nil(t, resp.Hits()[0].Highlights)
+}
+
+func TestSanitizeHighlight(t *testing.T) {
+	tests := []struct {
+		fragment string
+		want     string
+	}{
+		{"plain text", "plain text"},
+		{"<em>match</em>", "<em>match</em>"},
+		{"<EM>match</Em >", "<em>match</em>"},
+		{"<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{`<em onmouseover="alert(1)">x</em>`, `&lt;em onmouseover="alert(1)"&gt;x`},
+		{`<img src=x onerror=alert(1)>`, `&lt;img src=x onerror=alert(1)&gt;`},
+		{"<b><em>bold</em></b>", "&lt;b&gt;<em>bold</em>&lt;/b&gt;"},
+		{"unclosed <em>match", "unclosed <em>match</em>"},
+		{"stray </em> close", "stray  close"},
+		{"a < b > c", "a &lt; b &gt; c"},
+		{"<<em>>", "&lt;<em>&gt;</em>"},
+		{"<!-- <em>x</em> -->", "&lt;!-- <em>x</em> --&gt;"},
+		{"&lt;already escaped&gt;", "&lt;already escaped&gt;"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sanitizeHighlight(tt.fragment), tt.fragment)
+	}
+}
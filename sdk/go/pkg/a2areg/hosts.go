@@ -0,0 +1,117 @@
+package a2areg
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// hostAllowlist is the compiled form of A2ARegClientOptions.AllowedAgentHosts.
+type hostAllowlist struct {
+	exact     map[string]bool
+	hostPorts map[string]bool // "agents.example.com:8443" for that pattern
+	suffixes  []string        // ".corp.example.com" for "*.corp.example.com"
+}
+
+// newHostAllowlist compiles patterns, returning nil when there are none.
+// A pattern is a host name or IP literal, optionally with a port that the
+// URL must then name too, or "*." followed by a domain, which matches any
+// subdomain of that domain but not the domain itself.
+func newHostAllowlist(patterns []string) *hostAllowlist {
+	if len(patterns) == 0 {
+		return nil
+	}
+	allow := &hostAllowlist{exact: make(map[string]bool), hostPorts: make(map[string]bool)}
+	for _, pattern := range patterns {
+		if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+			allow.suffixes = append(allow.suffixes, "."+normalizeHost(domain))
+			continue
+		}
+		if host, port, err := net.SplitHostPort(pattern); err == nil {
+			allow.hostPorts[net.JoinHostPort(normalizeHost(host), port)] = true
+			continue
+		}
+		allow.exact[normalizeHost(pattern)] = true
+	}
+	return allow
+}
+
+// allows reports whether rawURL is an absolute URL whose host is allowed.
+func (a *hostAllowlist) allows(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := normalizeHost(u.Hostname())
+	if a.exact[host] {
+		return true
+	}
+	if port := u.Port(); port != "" && a.hostPorts[net.JoinHostPort(host, port)] {
+		return true
+	}
+	for _, suffix := range a.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHost returns the canonical comparison form of host: IP literals
+// in their standard text form, and names mapped to their ASCII "xn--" form
+// by IDNA lookup rules, so that a Unicode name and its ASCII form compare
+// equal while lookalike characters do not. Names IDNA rejects are only
+// NFKC-folded and lowercased.
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		return ascii
+	}
+	return strings.ToLower(norm.NFKC.String(host))
+}
+
+// agentURLs lists every endpoint URL an agent will advertise.
+func agentURLs(agent *Agent) []string {
+	var urls []string
+	if agent.LocationURL != nil {
+		urls = append(urls, *agent.LocationURL)
+	}
+	for _, entry := range agent.Interfaces {
+		urls = append(urls, entry.URL)
+	}
+	if card := agent.AgentCard; card != nil {
+		if card.URL != "" {
+			urls = append(urls, card.URL)
+		}
+		for _, iface := range card.Interface.AdditionalInterfaces {
+			if u, ok := iface["url"].(string); ok {
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
+
+// checkAgentHosts rejects agents advertising a URL outside AllowedAgentHosts.
+func (c *A2ARegClient) checkAgentHosts(agent *Agent) error {
+	if c.allowedHosts == nil {
+		return nil
+	}
+	for _, u := range agentURLs(agent) {
+		if !c.allowedHosts.allows(u) {
+			return NewValidationError(fmt.Sprintf("Agent URL %q is not on an allowed host", u), map[string]interface{}{"url": u})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package a2areg
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	assert.Equal(t, "xn--bcher-kva.example", normalizeHost("Bücher.Example."))
+	assert.Equal(t, "xn--bcher-kva.example", normalizeHost("xn--bcher-kva.example"))
+	assert.Equal(t, "xn--bcher-kva.example", normalizeHost("ＢÜＣＨＥＲ.example"))
+	assert.Equal(t, "xn--mnchen-3ya.example", normalizeHost("münchen.example"))
+	assert.Equal(t, "2001:db8::1", normalizeHost("[2001:0db8::1]"))
+	assert.Equal(t, "10.0.0.1", normalizeHost("10.0.0.1"))
+	assert.Equal(t, "agents.example.com", normalizeHost("agents.example.com:8443"))
+	assert.Equal(t, "xn--gents-3ve.example.com", normalizeHost("аgents.example.com"))
+}
+
+func TestHostAllowlist(t *testing.T) {
+	allow := newHostAllowlist([]string{"agents.example.com", "*.corp.example.com", "bücher.example", "10.0.0.1", "[2001:db8::1]", "Build.example.com:8443"})
+
+	allowed := []string{
+		"https://agents.example.com/a2a",
+		"https://AGENTS.example.com:8443/a2a",
+		"https://svc.corp.example.com",
+		"https://deep.svc.corp.example.com/x",
+		"https://xn--bcher-kva.example/",
+		"https://bücher.example/",
+		"http://10.0.0.1:9000/",
+		"http://[2001:db8:0::1]:9000/",
+		"https://build.example.com:8443/",
+	}
+	for _, u := range allowed {
+		assert.True(t, allow.allows(u), u)
+	}
+
+	denied := []string{
+		"https://corp.example.com",            // wildcard excludes the apex
+		"https://evilcorp.example.com",        // not a subdomain
+		"https://agents.example.com.evil.net", // suffix on the wrong side
+		"https://10.0.0.2/",
+		"https://build.example.com/",      // the pattern names a port
+		"https://build.example.com:9443/", // and only that port
+		"/relative/path",
+		"not a url",
+		// Cyrillic "а" (U+0430) in place of the Latin "a".
+		"https://аgents.example.com/a2a",
+		"https://xn--gents-3ve.example.com/a2a",
+	}
+	for _, u := range denied {
+		assert.False(t, allow.allows(u), u)
+	}
+}
+
+func TestNewHostAllowlist_EmptyDisables(t *testing.T) {
+	assert.Nil(t, newHostAllowlist(nil))
+
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "test"})
+	agent := validAgent()
+	agent.Interfaces = []AgentInterfaceEntry{{Transport: "jsonrpc", URL: "https://anywhere.example.net"}}
+	assert.NoError(t, client.ValidateAgent(agent))
+}
+
+func validAgent() *Agent {
+	location := "https://agents.example.com/a2a"
+	return &Agent{
+		Name:        "Recipe Agent",
+		Description: "Finds recipes",
+		Version:     "1.0.0",
+		Provider:    "acme",
+		LocationURL: &location,
+	}
+}
+
+func TestValidateAgent_AllowedAgentHosts(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "test", AllowedAgentHosts: []string{"agents.example.com"}})
+
+	assert.NoError(t, client.ValidateAgent(validAgent()))
+
+	agent := validAgent()
+	agent.Interfaces = []AgentInterfaceEntry{
+		{Transport: "jsonrpc", URL: "https://agents.example.com/rpc"},
+		{Transport: "grpc", URL: "https://exfil.example.net:443"},
+	}
+	err := client.ValidateAgent(agent)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "https://exfil.example.net:443", validationErr.Details["url"])
+	assert.Contains(t, err.Error(), "exfil.example.net")
+
+	agent = validAgent()
+	agent.AgentCard = &AgentCardSpec{Name: "n", Description: "d", Version: "1", URL: "https://agents.example.com"}
+	agent.AgentCard.Interface.AdditionalInterfaces = []map[string]interface{}{{"transport": "http", "url": "https://аgents.example.com"}}
+	assert.Error(t, client.ValidateAgent(agent))
+}
+
+func TestPublishAgent_AllowedAgentHostsWithoutValidation(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"id": "a", "name": "Recipe Agent"}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", AllowedAgentHosts: []string{"*.example.org"}})
+
+	_, err := client.PublishAgent(validAgent(), false)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Zero(t, calls.Load())
+}
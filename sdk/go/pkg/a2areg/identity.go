@@ -0,0 +1,73 @@
+package a2areg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxDerivedIDLength caps the length of IDs returned by DeriveAgentID.
+const maxDerivedIDLength = 64
+
+// DeriveAgentID returns a stable, URL-safe agent ID for provider and name.
+//
+// Each part is slugged: accents are stripped (é becomes e), letters are
+// lowercased, and every run of other characters becomes a single hyphen. The
+// slugs are joined with "--", which a slug never contains. When slugging
+// changed either input, or the result had to be truncated, an 8-character
+// hash of the original values is appended, so that inputs which only differ
+// in case, punctuation or emoji still get distinct IDs. Names that are
+// already slugs map to themselves: DeriveAgentID("acme", "weather-bot") is
+// "acme--weather-bot".
+func DeriveAgentID(provider, name string) string {
+	providerSlug, nameSlug := slugify(provider), slugify(name)
+	lossy := providerSlug != provider || nameSlug != name
+	if providerSlug == "" {
+		providerSlug = "provider"
+	}
+	if nameSlug == "" {
+		nameSlug = "agent"
+	}
+
+	id := providerSlug + "--" + nameSlug
+	if len(id) > maxDerivedIDLength {
+		lossy = true
+	}
+	if !lossy {
+		return id
+	}
+
+	sum := sha256.Sum256([]byte(norm.NFC.String(provider) + "\x00" + norm.NFC.String(name)))
+	suffix := hex.EncodeToString(sum[:4])
+	if limit := maxDerivedIDLength - len(suffix) - 1; len(id) > limit {
+		id = strings.TrimRight(id[:limit], "-")
+	}
+	return id + "-" + suffix
+}
+
+// slugify lowercases s, strips combining marks after compatibility
+// decomposition, and replaces each run of characters outside [a-z0-9] with
+// a hyphen, trimming hyphens at either end.
+func slugify(s string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range norm.NFKD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingHyphen = true
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package a2areg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveAgentID(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		agent    string
+		want     string
+	}{
+		{"already canonical", "acme", "weather-bot", "acme--weather-bot"},
+		{"case and spaces", "Acme", "Weather Bot", "acme--weather-bot-227b86d1"},
+		{"accents", "Café Ltd", "Crème Brûlée", "cafe-ltd--creme-brulee-a657f810"},
+		{"emoji", "acme", "🤖 Robot 🚀", "acme--robot-95d0a411"},
+		{"only emoji", "acme", "🤖", "acme--agent-82732c78"},
+		{"full width", "Ｆｕｌｌ", "ｗｉｄｔｈ", "full--width-dc9dcff0"},
+		{"non latin", "東京", "エージェント", "provider--agent-72ba671a"},
+		{"very long", "acme", strings.Repeat("very long name ", 10), "acme--very-long-name-very-long-name-very-long-name-very-c439a5f6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := DeriveAgentID(tt.provider, tt.agent)
+			assert.Equal(t, tt.want, id)
+			assert.LessOrEqual(t, len(id), maxDerivedIDLength)
+		})
+	}
+}
+
+func TestDeriveAgentID_DistinguishesLossyInputs(t *testing.T) {
+	ids := map[string]bool{}
+	for _, name := range []string{"weather-bot", "Weather Bot", "weather bot", "weather_bot", "🌧 weather bot"} {
+		ids[DeriveAgentID("acme", name)] = true
+	}
+	assert.Len(t, ids, 5)
+
+	// Composed and decomposed forms of the same text are the same name.
+	assert.Equal(t, DeriveAgentID("acme", "Caf\u00e9"), DeriveAgentID("acme", "Cafe\u0301"))
+}
+
+func TestA2ARegClient_DeterministicIDs_IdempotentPublish(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.RejectDuplicates = true
+	server := registry.Start()
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", DeterministicIDs: true})
+
+	first, err := client.PublishAgent(testPublishAgent(), true)
+	require.NoError(t, err)
+	second, err := client.PublishAgent(testPublishAgent(), true)
+	require.NoError(t, err)
+
+	require.NotNil(t, first.ID)
+	assert.Equal(t, DeriveAgentID("acme", "Recipe Agent"), *first.ID)
+	assert.Equal(t, *first.ID, *second.ID)
+	assert.Equal(t, 1, registry.Len())
+}
+
+func TestA2ARegClient_PublishWithoutDeriveID_Duplicates(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.RejectDuplicates = true
+	server := registry.Start()
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	_, err := client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{SkipNormalizationCheck: true})
+	require.NoError(t, err)
+	_, err = client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{SkipNormalizationCheck: true})
+	assert.Error(t, err)
+
+	receipt, err := client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{SkipNormalizationCheck: true, DeriveID: true})
+	require.NoError(t, err)
+	assert.Equal(t, DeriveAgentID("acme", "Recipe Agent"), *receipt.Agent.ID)
+}
+
+func TestA2ARegClient_ValidateAgent_DeterministicIDs(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{DeterministicIDs: true})
+
+	agent := testPublishAgent()
+	wrong := "recipe-agent"
+	agent.ID = &wrong
+	err := client.ValidateAgent(agent)
+	require.Error(t, err)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+
+	derived := DeriveAgentID("acme", "Recipe Agent")
+	agent.ID = &derived
+	assert.NoError(t, client.ValidateAgent(agent))
+}
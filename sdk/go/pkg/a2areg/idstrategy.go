@@ -0,0 +1,122 @@
+package a2areg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// IDStrategy chooses the ID an agent is published under. Set one for every
+// publish with A2ARegClientOptions.IDStrategy, or for one publish with
+// PublishOptions.IDStrategy.
+//
+// A strategy that returns a non-empty ID has it sent in the publish
+// request; the publish then fails with CodeIDMismatch if the registry
+// stores the agent under another ID. An empty ID leaves the choice to the
+// registry.
+type IDStrategy interface {
+	AllocateID(agent *Agent) (string, error)
+}
+
+// ServerAssigned lets the registry choose agent IDs. It is the default.
+type ServerAssigned struct{}
+
+// AllocateID returns "".
+func (ServerAssigned) AllocateID(*Agent) (string, error) {
+	return "", nil
+}
+
+// DerivedSlug publishes agents under DeriveAgentID(provider, name), so
+// re-publishing an agent updates it instead of creating a duplicate. It is
+// what DeterministicIDs and PublishOptions.DeriveID select.
+type DerivedSlug struct{}
+
+// AllocateID returns DeriveAgentID(agent.ProviderName(), agent.Name).
+func (DerivedSlug) AllocateID(agent *Agent) (string, error) {
+	return DeriveAgentID(agent.ProviderName(), agent.Name), nil
+}
+
+// UUIDv7 publishes agents under new time-ordered UUIDs (RFC 9562), so IDs
+// sort by creation time.
+type UUIDv7 struct {
+	// Clock supplies the timestamp. Nil uses the clock of the client
+	// publishing the agent, or the wall clock outside a client.
+	Clock Clock
+}
+
+// AllocateID returns a new version 7 UUID in its canonical text form.
+func (u UUIDv7) AllocateID(*Agent) (string, error) {
+	if u.Clock == nil {
+		return newUUIDv7(time.Now())
+	}
+	return newUUIDv7(u.Clock.Now())
+}
+
+// newUUIDv7 returns a version 7 UUID for now: 48 bits of Unix milliseconds
+// followed by random bits, with the version and variant set.
+func newUUIDv7(now time.Time) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", withCode(NewA2AError("Failed to generate agent ID", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	ms := uint64(now.UnixMilli())
+	for i := 0; i < 6; i++ {
+		b[i] = byte(ms >> (40 - 8*i))
+	}
+	b[6] = 0x70 | b[6]&0x0f
+	b[8] = 0x80 | b[8]&0x3f
+
+	h := hex.EncodeToString(b[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+// idStrategy returns the strategy a publish with opts uses.
+func (c *A2ARegClient) idStrategy(opts PublishOptions) IDStrategy {
+	switch {
+	case opts.IDStrategy != nil:
+		return withClock(opts.IDStrategy, c.clock)
+	case opts.DeriveID:
+		return DerivedSlug{}
+	}
+	return c.defaultIDStrategy
+}
+
+// idStrategyFor returns the client-wide strategy opts select.
+func idStrategyFor(opts A2ARegClientOptions) IDStrategy {
+	switch {
+	case opts.IDStrategy != nil:
+		return withClock(opts.IDStrategy, opts.Clock)
+	case opts.DeterministicIDs:
+		return DerivedSlug{}
+	}
+	return ServerAssigned{}
+}
+
+// withClock gives a UUIDv7 strategy without a clock the client's clock.
+func withClock(strategy IDStrategy, clock Clock) IDStrategy {
+	if u, ok := strategy.(UUIDv7); ok && u.Clock == nil {
+		return UUIDv7{Clock: clock}
+	}
+	return strategy
+}
+
+// checkAssignedID fails when the registry stored an agent under another ID
+// than the requested one. A response without an ID is taken to accept it.
+func checkAssignedID(agent *Agent, requested string) error {
+	if requested == "" {
+		return nil
+	}
+	assigned := getStringValue(agent.ID, "")
+	if assigned == "" {
+		agent.ID = &requested
+		return nil
+	}
+	if assigned != requested {
+		return withCode(NewA2AError(
+			fmt.Sprintf("Registry published the agent as %q instead of the requested ID %q", assigned, requested),
+			map[string]interface{}{"requested_id": requested, "assigned_id": assigned},
+		), CodeIDMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var uuidv7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// fixedID allocates the same ID for every agent.
+type fixedID string
+
+func (id fixedID) AllocateID(*Agent) (string, error) {
+	return string(id), nil
+}
+
+type failingID struct{}
+
+func (failingID) AllocateID(*Agent) (string, error) {
+	return "", errors.New("no IDs left")
+}
+
+func TestIDStrategy_Publish(t *testing.T) {
+	registry := fakeregistry.New()
+	server := registry.Start()
+	defer server.Close()
+	ctx := context.Background()
+	publish := func(client *A2ARegClient, opts PublishOptions) string {
+		t.Helper()
+		opts.SkipNormalizationCheck = true
+		receipt, err := client.PublishAgentVerbose(ctx, testPublishAgent(), opts)
+		require.NoError(t, err)
+		return *receipt.Agent.ID
+	}
+
+	serverAssigned := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	first, second := publish(serverAssigned, PublishOptions{}), publish(serverAssigned, PublishOptions{})
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, DeriveAgentID("acme", "Recipe Agent"), first)
+
+	derived := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", IDStrategy: DerivedSlug{}})
+	assert.Equal(t, DeriveAgentID("acme", "Recipe Agent"), publish(derived, PublishOptions{}))
+
+	id := publish(derived, PublishOptions{IDStrategy: UUIDv7{}})
+	assert.Regexp(t, uuidv7Pattern, id, "the per-call strategy wins")
+	var stored []string
+	for _, agent := range registry.Snapshot() {
+		stored = append(stored, agent.ID)
+	}
+	assert.Contains(t, stored, id)
+}
+
+func TestIDStrategy_PerCallOverridesClient(t *testing.T) {
+	registry := fakeregistry.New()
+	server := registry.Start()
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", DeterministicIDs: true})
+	ctx := context.Background()
+
+	receipt, err := client.PublishAgentVerbose(ctx, testPublishAgent(), PublishOptions{SkipNormalizationCheck: true, IDStrategy: fixedID("recipes-prod")})
+	require.NoError(t, err)
+	assert.Equal(t, "recipes-prod", *receipt.Agent.ID)
+
+	receipt, err = client.PublishAgentVerbose(ctx, testPublishAgent(), PublishOptions{SkipNormalizationCheck: true, IDStrategy: ServerAssigned{}})
+	require.NoError(t, err)
+	assert.NotEqual(t, DeriveAgentID("acme", "Recipe Agent"), *receipt.Agent.ID)
+
+	_, err = client.PublishAgentVerbose(ctx, testPublishAgent(), PublishOptions{IDStrategy: failingID{}})
+	assert.EqualError(t, err, "no IDs left")
+	assert.Equal(t, 2, registry.Calls("POST /agents/publish"))
+}
+
+func TestIDStrategy_Mismatch(t *testing.T) {
+	var sentIDs []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/agents/publish":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			sentIDs = append(sentIDs, body["id"])
+			w.Write([]byte(`{"agentId": "server-1"}`))
+		case "/agents/server-1":
+			w.Write([]byte(`{"id": "server-1", "name": "Recipe Agent"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", IDStrategy: fixedID("recipes-prod")})
+
+	_, err := client.PublishAgent(testPublishAgent(), false)
+	require.Error(t, err)
+	assert.Equal(t, CodeIDMismatch, ErrorCode(err))
+	var apiErr *A2AError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, map[string]interface{}{"requested_id": "recipes-prod", "assigned_id": "server-1"}, apiErr.Details)
+
+	_, err = client.PublishAgentCard(testAgentCard(), true)
+	assert.Equal(t, CodeIDMismatch, ErrorCode(err))
+
+	// Server-assigned IDs are not checked.
+	receipt, err := client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{SkipNormalizationCheck: true, IDStrategy: ServerAssigned{}})
+	require.NoError(t, err)
+	assert.Equal(t, "server-1", *receipt.Agent.ID)
+	assert.Equal(t, []interface{}{"recipes-prod", "recipes-prod", nil}, sentIDs)
+}
+
+func TestNewUUIDv7(t *testing.T) {
+	now := time.UnixMilli(0x0192_3456_789a)
+	id, err := newUUIDv7(now)
+	require.NoError(t, err)
+	assert.Regexp(t, uuidv7Pattern, id)
+	assert.Equal(t, "01923456-789a-7", id[:15])
+
+	later, err := newUUIDv7(now.Add(time.Millisecond))
+	require.NoError(t, err)
+	assert.Less(t, id, later, "IDs sort by creation time")
+
+	again, err := UUIDv7{}.AllocateID(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, id, again)
+}
+
+func TestUUIDv7_UsesClientClock(t *testing.T) {
+	fake := clock.NewFake(time.UnixMilli(0x0192_3456_789a))
+	id, err := UUIDv7{Clock: fake}.AllocateID(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "01923456-789a-7", id[:15])
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: "http://registry.invalid", APIKey: "test", IDStrategy: UUIDv7{}, Clock: fake})
+	id, err = client.idStrategy(PublishOptions{}).AllocateID(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "01923456-789a-7", id[:15])
+
+	id, err = client.idStrategy(PublishOptions{IDStrategy: UUIDv7{}}).AllocateID(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "01923456-789a-7", id[:15])
+}
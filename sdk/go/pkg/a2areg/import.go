@@ -0,0 +1,285 @@
+package a2areg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportOptions configures ImportAgents.
+type ImportOptions struct {
+	// Publish is used for every agent published.
+	Publish PublishOptions
+	// OnProgress is called with the progress so far, at most once per
+	// ProgressInterval, and once more when the import stops. It runs on
+	// the importing goroutine.
+	OnProgress func(ImportProgress)
+	// ProgressInterval is the minimum time between OnProgress calls. Zero
+	// reports after every record.
+	ProgressInterval time.Duration
+	// Checkpoint resumes an interrupted import: pass the Checkpoint of the
+	// last progress reported, and the same input. Records up to it are
+	// skipped without being published, by seeking when the reader is an
+	// io.Seeker and by reading past them otherwise.
+	Checkpoint string
+}
+
+// ImportProgress describes how far an import has got. The counts cover the
+// current run only; Line and BytesRead are positions in the whole input.
+type ImportProgress struct {
+	// Processed counts the records handled: Succeeded + Failed + Skipped.
+	Processed int `json:"processed"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Skipped counts the agents that were already in the registry as
+	// imported, typically published by the run that was interrupted.
+	Skipped   int   `json:"skipped"`
+	BytesRead int64 `json:"bytes_read"`
+	// Line is the number of the last line handled, counting from 1.
+	Line int `json:"line"`
+	// Checkpoint resumes the import after Line; see
+	// ImportOptions.Checkpoint.
+	Checkpoint string `json:"checkpoint"`
+}
+
+// ImportResult is the outcome of ImportAgents.
+type ImportResult struct {
+	ImportProgress
+}
+
+// ImportAgents publishes the agents in r, a stream of newline-delimited
+// JSON agent documents such as an NDJSON export. The input is read a line
+// at a time, never held in memory whole; blank lines are ignored. A record
+// that cannot be decoded or published does not stop the import; the
+// error is then a *BulkError whose items are the failed records, with
+// Index their line number.
+//
+// An agent is skipped when the registry already holds it with the same
+// card, so resuming from a checkpoint that lags the last publish does not
+// create it twice. Under derived IDs (Publish.DeriveID, DeterministicIDs
+// or the DerivedSlug strategy) the agent's derived ID is checked, always.
+// Under other strategies, whose IDs cannot be known in advance, a resumed
+// import (one given a Checkpoint) searches for agents of the same provider
+// and name; this needs the registry's search, or SearchFallback.
+//
+// When the import stops early, because ctx was done or r failed, the error
+// says why, joined with the *BulkError of the records failed so far, and
+// the result holds the checkpoint to resume from.
+func (c *A2ARegClient) ImportAgents(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	line, offset, err := parseImportCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImportResult{}
+	result.Line, result.BytesRead = line, offset
+	if seeker, ok := r.(io.Seeker); ok && offset > 0 {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, withCode(NewA2AError("Failed to seek to the import checkpoint", map[string]interface{}{"error": err.Error()}), CodeInvalidRequest)
+		}
+		offset = 0
+	}
+
+	reader := bufio.NewReader(r)
+	var lastReport time.Time
+	report := func(force bool) {
+		if opts.OnProgress == nil {
+			return
+		}
+		now := c.clock.Now()
+		if force || opts.ProgressInterval <= 0 || now.Sub(lastReport) >= opts.ProgressInterval {
+			lastReport = now
+			opts.OnProgress(result.ImportProgress)
+		}
+	}
+	defer report(true)
+
+	var failed []BulkResult
+	stop := func(err error) (*ImportResult, error) {
+		if bulkErr := newBulkError(result.Processed, failed); bulkErr != nil {
+			if err == nil {
+				return result, bulkErr
+			}
+			return result, errors.Join(err, bulkErr)
+		}
+		return result, err
+	}
+	resuming := opts.Checkpoint != ""
+
+	// skipped counts the bytes read past without seeking.
+	var skipped int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return stop(err)
+		}
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return stop(withCode(NewA2AError("Failed to read import input", map[string]interface{}{"error": readErr.Error(), "line": result.Line + 1}), CodeInvalidRequest))
+		}
+		if len(data) == 0 && readErr == io.EOF {
+			if skipped < offset {
+				return stop(withCode(NewA2AError("Import input ends before the checkpoint", nil), CodeInvalidRequest))
+			}
+			return stop(nil)
+		}
+		if skipped < offset {
+			skipped += int64(len(data))
+			continue
+		}
+
+		result.Line++
+		result.BytesRead += int64(len(data))
+		if record := bytes.TrimSpace(data); len(record) > 0 {
+			result.Processed++
+			agentID, skip, err := c.importAgent(ctx, record, opts.Publish, resuming)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				// The record was interrupted, not rejected: leave the
+				// checkpoint before it so a resumed import retries it.
+				result.Line--
+				result.BytesRead -= int64(len(data))
+				result.Processed--
+				return stop(ctx.Err())
+			case err != nil:
+				result.Failed++
+				failed = append(failed, BulkResult{Index: result.Line, AgentID: agentID, Err: err})
+			case skip:
+				result.Skipped++
+			default:
+				result.Succeeded++
+			}
+		}
+		result.Checkpoint = formatImportCheckpoint(result.Line, result.BytesRead)
+		report(false)
+		if readErr == io.EOF {
+			return stop(nil)
+		}
+	}
+}
+
+// importAgent publishes one NDJSON record, reporting skip when the registry
+// already holds the agent unchanged, and the agent's ID when known.
+// Agents under other than derived IDs are only looked for when resuming.
+func (c *A2ARegClient) importAgent(ctx context.Context, record []byte, opts PublishOptions, resuming bool) (agentID string, skip bool, err error) {
+	agent, err := decodeAgent(record)
+	if err != nil {
+		return "", false, err
+	}
+	if c.tagTaxonomy != nil {
+		agent = c.tagTaxonomy.normalizeAgentTags(agent)
+	}
+	var candidates []string
+	if _, derived := c.idStrategy(opts).(DerivedSlug); derived {
+		candidates = []string{DeriveAgentID(agent.ProviderName(), agent.Name)}
+	} else if resuming {
+		if candidates, err = c.agentIDsNamed(ctx, agent.ProviderName(), agent.Name); err != nil {
+			return "", false, err
+		}
+	}
+	for _, candidate := range candidates {
+		unchanged, err := c.agentCardUnchanged(ctx, candidate, agent)
+		if err != nil {
+			return candidate, false, err
+		}
+		if unchanged {
+			return candidate, true, nil
+		}
+	}
+	receipt, err := c.publish(ctx, agent, opts)
+	if receipt != nil && receipt.Agent != nil {
+		agentID = getStringValue(receipt.Agent.ID, "")
+	}
+	return agentID, false, err
+}
+
+// agentIDsNamed returns the IDs of the agents of provider called name, found
+// by searching as ResolveRef does.
+func (c *A2ARegClient) agentIDsNamed(ctx context.Context, provider, name string) ([]string, error) {
+	var ids []string
+	for page := 1; ; page++ {
+		resp, err := c.Search(ctx, SearchRequest{
+			Query:   name,
+			Filters: &SearchFilters{Provider: provider},
+			Page:    page,
+			Limit:   resolvePageSize,
+			NoCache: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, agent := range resp.Agents {
+			if agent.Name == name && agent.ProviderName() == provider && agent.ID != nil {
+				ids = append(ids, *agent.ID)
+			}
+		}
+		if len(resp.Agents) < resolvePageSize || (resp.Total > 0 && page*resolvePageSize >= resp.Total) {
+			return ids, nil
+		}
+	}
+}
+
+// agentCardUnchanged reports whether the registry holds agentID with the
+// card agent would be published as, comparing fingerprints.
+func (c *A2ARegClient) agentCardUnchanged(ctx context.Context, agentID string, agent *Agent) (bool, error) {
+	stored, err := c.makeRequestContext(ctx, "GET", "/agents/"+agentID+"/card", nil, nil)
+	if err != nil {
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	card, _, err := ConvertAgentToCard(agent)
+	if err != nil {
+		return false, err
+	}
+	wire, err := json.Marshal(c.wireCard(card))
+	if err != nil {
+		return false, withCode(NewA2AError("Failed to encode agent card", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+	want, err := agentFingerprint(wire)
+	if err != nil {
+		return false, err
+	}
+	have, err := agentFingerprint(stored)
+	if err != nil {
+		return false, err
+	}
+	return want == have, nil
+}
+
+// importCheckpointPrefix versions the checkpoint format.
+const importCheckpointPrefix = "v1:"
+
+// formatImportCheckpoint encodes the line and byte offset an import has
+// reached.
+func formatImportCheckpoint(line int, offset int64) string {
+	return fmt.Sprintf("%s%d:%d", importCheckpointPrefix, line, offset)
+}
+
+func parseImportCheckpoint(checkpoint string) (line int, offset int64, err error) {
+	if checkpoint == "" {
+		return 0, 0, nil
+	}
+	invalid := NewValidationError("Invalid import checkpoint: "+checkpoint, nil)
+	rest, ok := strings.CutPrefix(checkpoint, importCheckpointPrefix)
+	if !ok {
+		return 0, 0, invalid
+	}
+	lineText, offsetText, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, 0, invalid
+	}
+	line, lineErr := strconv.Atoi(lineText)
+	offset, offsetErr := strconv.ParseInt(offsetText, 10, 64)
+	if lineErr != nil || offsetErr != nil || line < 0 || offset < 0 {
+		return 0, 0, invalid
+	}
+	return line, offset, nil
+}
@@ -0,0 +1,190 @@
+package a2areg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// importRegistry stores published cards under the client-chosen ID, or a
+// server-assigned one, counts the publishes of each ID and searches the
+// cards by name.
+type importRegistry struct {
+	mu        sync.Mutex
+	cards     map[string]json.RawMessage
+	publishes map[string]int
+}
+
+func newImportRegistry(t *testing.T) (*importRegistry, *httptest.Server) {
+	reg := &importRegistry{cards: map[string]json.RawMessage{}, publishes: map[string]int{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reg.mu.Lock()
+		defer reg.mu.Unlock()
+		if r.URL.Path == "/agents/publish" {
+			var req struct {
+				ID   string          `json:"id"`
+				Card json.RawMessage `json:"card"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if req.ID == "" {
+				req.ID = fmt.Sprintf("srv-%d", len(reg.cards)+1)
+			}
+			reg.cards[req.ID] = req.Card
+			reg.publishes[req.ID]++
+			fmt.Fprintf(w, `{"agentId": %q}`, req.ID)
+			return
+		}
+		if r.URL.Path == "/agents/search" {
+			var req struct {
+				Query string `json:"query"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			agents := []map[string]interface{}{}
+			for id, raw := range reg.cards {
+				var card map[string]interface{}
+				require.NoError(t, json.Unmarshal(raw, &card))
+				if card["name"] == req.Query {
+					agents = append(agents, map[string]interface{}{"id": id, "name": card["name"], "provider": card["provider"]})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"agents": agents, "total": len(agents)})
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/agents/")
+		id, isCard := strings.CutSuffix(id, "/card")
+		card, ok := reg.cards[id]
+		switch {
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case isCard:
+			w.Write(card)
+		default:
+			fmt.Fprintf(w, `{"id": %q, "name": %q}`, id, id)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return reg, server
+}
+
+func importInput(n int) []byte {
+	var buf bytes.Buffer
+	for i := 1; i <= n; i++ {
+		agent := testPublishAgent()
+		agent.Name = fmt.Sprintf("agent-%d", i)
+		data, _ := json.Marshal(agent)
+		buf.Write(data)
+		buf.WriteString("\n")
+		if i == 3 {
+			buf.WriteString("\n{not json}\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// onlyReader hides the io.Seeker of a reader.
+type onlyReader struct{ r *bytes.Reader }
+
+func (o onlyReader) Read(p []byte) (int, error) { return o.r.Read(p) }
+
+func TestImportAgents(t *testing.T) {
+	reg, server := newImportRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", DeterministicIDs: true})
+	input := importInput(5)
+
+	var reports []ImportProgress
+	result, err := client.ImportAgents(context.Background(), onlyReader{bytes.NewReader(input)}, ImportOptions{
+		Publish:    PublishOptions{SkipNormalizationCheck: true},
+		OnProgress: func(p ImportProgress) { reports = append(reports, p) },
+	})
+	var bulkErr *BulkError
+	require.ErrorAs(t, err, &bulkErr)
+	assert.Equal(t, CodeBulkFailed, ErrorCode(err))
+	assert.Equal(t, 6, result.Processed)
+	assert.Equal(t, 5, result.Succeeded)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 7, result.Line)
+	assert.Equal(t, int64(len(input)), result.BytesRead)
+	assert.Equal(t, 6, bulkErr.Total)
+	require.Len(t, bulkErr.Failed, 1)
+	assert.Equal(t, 5, bulkErr.Failed[0].Index, "the line number")
+	assert.Equal(t, CodeDecodeFailed, ErrorCode(bulkErr.Failed[0].Err))
+	assert.Len(t, reg.cards, 5)
+
+	require.NotEmpty(t, reports)
+	assert.Equal(t, result.ImportProgress, reports[len(reports)-1])
+	assert.Equal(t, 1, reports[0].Processed)
+}
+
+func TestImportAgents_ResumeAfterCrash(t *testing.T) {
+	for name, tc := range map[string]struct {
+		wrap    func(*bytes.Reader) io.Reader
+		options A2ARegClientOptions
+	}{
+		"seeker":          {func(r *bytes.Reader) io.Reader { return r }, A2ARegClientOptions{DeterministicIDs: true}},
+		"non-seeker":      {func(r *bytes.Reader) io.Reader { return onlyReader{r} }, A2ARegClientOptions{DeterministicIDs: true}},
+		"server-assigned": {func(r *bytes.Reader) io.Reader { return r }, A2ARegClientOptions{}},
+		"uuidv7":          {func(r *bytes.Reader) io.Reader { return r }, A2ARegClientOptions{IDStrategy: UUIDv7{}}},
+	} {
+		wrap := tc.wrap
+		t.Run(name, func(t *testing.T) {
+			reg, server := newImportRegistry(t)
+			options := tc.options
+			options.RegistryURL, options.APIKey = server.URL, "test"
+			client := NewA2ARegClient(options)
+			input := importInput(10)
+			opts := ImportOptions{Publish: PublishOptions{SkipNormalizationCheck: true}}
+
+			// Kill the import after agent 4 (the fifth record, after the
+			// invalid line), keeping only the
+			// checkpoint reported after the second, as if the later ones
+			// were lost in the crash.
+			ctx, cancel := context.WithCancel(context.Background())
+			var checkpoint string
+			opts.OnProgress = func(p ImportProgress) {
+				if p.Processed == 2 {
+					checkpoint = p.Checkpoint
+				}
+				if p.Processed == 5 {
+					cancel()
+				}
+			}
+			result, err := client.ImportAgents(ctx, wrap(bytes.NewReader(input)), opts)
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Equal(t, 5, result.Processed)
+			require.NotEmpty(t, checkpoint)
+
+			opts.OnProgress = nil
+			opts.Checkpoint = checkpoint
+			result, err = client.ImportAgents(context.Background(), wrap(bytes.NewReader(input)), opts)
+			var bulkErr *BulkError
+			require.ErrorAs(t, err, &bulkErr)
+			assert.Len(t, bulkErr.Failed, 1)
+			assert.Equal(t, 1, result.Failed, "the invalid line")
+			assert.Equal(t, 2, result.Skipped, "agents 3 and 4 were already imported")
+			assert.Equal(t, 6, result.Succeeded)
+			assert.Equal(t, int64(len(input)), result.BytesRead)
+
+			assert.Len(t, reg.cards, 10)
+			for id, n := range reg.publishes {
+				assert.Equal(t, 1, n, id)
+			}
+		})
+	}
+}
+
+func TestImportAgents_InvalidCheckpoint(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "test"})
+	_, err := client.ImportAgents(context.Background(), strings.NewReader(""), ImportOptions{Checkpoint: "line 7"})
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
@@ -0,0 +1,347 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultIterPageSize is the page size used by IterAgents when
+// IterOptions.PageSize is not set.
+const DefaultIterPageSize = 100
+
+// Prefetched pages that are rate limited are retried up to
+// maxPrefetchRetries times, after the registry's Retry-After or
+// defaultPrefetchBackoff when it sends none.
+const (
+	maxPrefetchRetries     = 3
+	defaultPrefetchBackoff = time.Second
+)
+
+// IterOptions configures IterAgents and ListAllAgents.
+type IterOptions struct {
+	// PublicOnly lists public agents instead of those the client is
+	// entitled to.
+	PublicOnly bool
+	// PageSize is the number of agents fetched per request. Defaults to
+	// DefaultIterPageSize.
+	PageSize int
+	// Max stops the iteration after this many agents. Zero means no limit.
+	Max int
+	// Summary requests the registry's summary projection, so only the
+	// fields of AgentSummary are populated. Agents are projected
+	// client-side as well, for registries that return full agents.
+	Summary bool
+	// Region lists only agents deployed in the region.
+	Region string
+	// Prefetch fetches up to this many pages ahead of the one being read,
+	// concurrently. Agents are still returned in listing order, and at most
+	// Prefetch pages are held besides the current one. When any page is
+	// rate limited, no further page is requested until the registry's
+	// Retry-After has passed. Zero fetches one page at a time.
+	Prefetch int
+}
+
+// AgentIterator walks an agent listing page by page, fetching each page
+// when the previous one is used up:
+//
+//	it := client.IterAgents(ctx, a2areg.IterOptions{PublicOnly: true})
+//	for it.Next() {
+//		agent := it.Agent()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// With IterOptions.Prefetch, call Close when stopping before Next returns
+// false, to cancel the pages still being fetched.
+//
+// An AgentIterator is not safe for concurrent use.
+type AgentIterator struct {
+	client *A2ARegClient
+	ctx    context.Context
+	opts   IterOptions
+	extra  map[string]string
+
+	page    int
+	fetched int // agents received so far
+	buf     []Agent
+	current Agent
+	seen    int // agents returned by Next so far
+	last    bool
+	err     error
+
+	// Prefetch state: pending holds the pages in flight in page order, the
+	// first being page+1. lastPage is the final page when the registry
+	// reports a total, and zero otherwise.
+	pending  []chan listingPage
+	lastPage int
+	backoff  pageBackoff
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	closed   bool
+}
+
+// listingPage is one decoded page of an agent listing.
+type listingPage struct {
+	agents []Agent
+	total  int
+	err    error
+}
+
+// IterAgents returns an iterator over the agent listing. Nothing is fetched
+// until the first call to Next. Iteration ends when the registry returns
+// an empty page, a page shorter than PageSize when it reports no total, or
+// once the reported total has been reached.
+func (c *A2ARegClient) IterAgents(ctx context.Context, opts IterOptions) *AgentIterator {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultIterPageSize
+	}
+	extra := map[string]string{}
+	if opts.Summary {
+		extra["fields"] = "summary"
+	}
+	if opts.Region != "" {
+		extra["region"] = opts.Region
+	}
+	return &AgentIterator{client: c, ctx: ctx, opts: opts, extra: extra}
+}
+
+// Next advances to the next agent, fetching another page if needed. It
+// returns false at the end of the listing or on error; check Err to tell
+// them apart.
+func (it *AgentIterator) Next() bool {
+	if it.closed || it.err != nil {
+		return false
+	}
+	if it.opts.Max > 0 && it.seen >= it.opts.Max {
+		it.Close()
+		return false
+	}
+	for len(it.buf) == 0 {
+		if it.last {
+			it.Close()
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			it.Close()
+			return false
+		}
+	}
+	it.current, it.buf = it.buf[0], it.buf[1:]
+	it.seen++
+	return true
+}
+
+// Agent returns the agent Next advanced to.
+func (it *AgentIterator) Agent() Agent {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *AgentIterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration, canceling the pages being prefetched and
+// waiting for their requests to return. Next returns false afterwards. The
+// iterator closes itself when Next returns false, so Close is only needed
+// to stop early; it may be called more than once.
+func (it *AgentIterator) Close() {
+	if it.closed {
+		return
+	}
+	it.closed = true
+	if it.cancel != nil {
+		it.cancel()
+	}
+	it.wg.Wait()
+	it.pending, it.buf = nil, nil
+}
+
+// fetch reads the next page into buf and records whether it is the last.
+func (it *AgentIterator) fetch() error {
+	if err := it.ctx.Err(); err != nil {
+		return err
+	}
+	it.page++
+	var page listingPage
+	switch {
+	case it.opts.Prefetch <= 0:
+		page = it.fetchPage(it.ctx, it.page)
+	case len(it.pending) == 0:
+		// The first page is fetched alone: its total tells how many pages
+		// are worth prefetching.
+		page = it.fetchPageWithBackoff(it.ctx, it.page)
+	default:
+		page = <-it.pending[0]
+		it.pending = it.pending[1:]
+	}
+	if page.err != nil {
+		return page.err
+	}
+	it.buf = page.agents
+	it.fetched += len(page.agents)
+
+	// Registries may cap the page size, so a short page only ends the
+	// listing when no total is reported.
+	switch {
+	case len(page.agents) == 0:
+		it.last = true
+	case page.total > 0:
+		it.last = it.fetched >= page.total
+		it.lastPage = (page.total + it.opts.PageSize - 1) / it.opts.PageSize
+	default:
+		it.last = len(page.agents) < it.opts.PageSize
+	}
+	if it.opts.Prefetch > 0 && !it.last {
+		it.prefetch()
+	}
+	return nil
+}
+
+// prefetch starts fetching pages until Prefetch pages are in flight or the
+// last page has been requested.
+func (it *AgentIterator) prefetch() {
+	if it.cancel == nil {
+		var ctx context.Context
+		ctx, it.cancel = context.WithCancel(it.ctx)
+		it.ctx = ctx
+	}
+	lastPage := it.lastPage
+	if it.opts.Max > 0 {
+		// Pages past Max would be discarded unread.
+		if maxPage := (it.opts.Max + it.opts.PageSize - 1) / it.opts.PageSize; lastPage == 0 || maxPage < lastPage {
+			lastPage = maxPage
+		}
+	}
+	ctx := it.ctx
+	for len(it.pending) < it.opts.Prefetch {
+		page := it.page + len(it.pending) + 1
+		if lastPage > 0 && page > lastPage {
+			return
+		}
+		// Buffered so the fetch can finish even if the iterator is closed
+		// before the page is read.
+		result := make(chan listingPage, 1)
+		it.pending = append(it.pending, result)
+		it.wg.Add(1)
+		go func() {
+			defer it.wg.Done()
+			result <- it.fetchPageWithBackoff(ctx, page)
+		}()
+	}
+}
+
+// fetchPageWithBackoff fetches a page once no rate limit is in force,
+// retrying it when it is rate limited.
+func (it *AgentIterator) fetchPageWithBackoff(ctx context.Context, page int) listingPage {
+	for attempt := 0; ; attempt++ {
+		if err := it.backoff.wait(ctx, it.client.clock); err != nil {
+			return listingPage{err: err}
+		}
+		result := it.fetchPage(ctx, page)
+		var rateLimited *RateLimitError
+		if attempt == maxPrefetchRetries || !errors.As(result.err, &rateLimited) {
+			return result
+		}
+		delay := rateLimited.RetryAfter()
+		if delay <= 0 {
+			delay = defaultPrefetchBackoff
+		}
+		it.backoff.extend(it.client.clock.Now().Add(delay))
+	}
+}
+
+// fetchPage fetches and decodes one page of the listing.
+func (it *AgentIterator) fetchPage(ctx context.Context, page int) listingPage {
+	body, err := it.client.listAgents(ctx, page, it.opts.PageSize, it.opts.PublicOnly, it.extra)
+	if err != nil {
+		return listingPage{err: err}
+	}
+	var listing struct {
+		Agents []Agent `json:"agents"`
+		Total  int     `json:"total"`
+	}
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return listingPage{err: withCode(NewA2AError("Failed to decode agents response", map[string]interface{}{"error": err.Error(), "page": page}), CodeDecodeFailed)}
+	}
+	if it.opts.Summary {
+		for i := range listing.Agents {
+			listing.Agents[i] = summaryAgent(&listing.Agents[i])
+		}
+	}
+	return listingPage{agents: listing.Agents, total: listing.Total}
+}
+
+// summaryAgent returns an Agent holding only the fields of a's Summary.
+func summaryAgent(a *Agent) Agent {
+	capabilities := a.Capabilities
+	if capabilities == nil && a.AgentCard != nil {
+		capabilities = &a.AgentCard.Capabilities
+	}
+	return Agent{
+		ID:           a.ID,
+		Name:         a.Name,
+		Version:      a.Version,
+		Provider:     a.ProviderName(),
+		Tags:         a.Tags,
+		Capabilities: capabilities,
+	}
+}
+
+// pageBackoff is the rate-limit backoff shared by an iterator's fetches:
+// once one page is rate limited, none is requested until the time it
+// holds.
+type pageBackoff struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// extend moves the backoff to until unless it already lasts longer.
+func (b *pageBackoff) extend(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until.After(b.until) {
+		b.until = until
+	}
+}
+
+// wait blocks until the backoff has passed, which may have been extended
+// in the meantime, or ctx is done.
+func (b *pageBackoff) wait(ctx context.Context, clk Clock) error {
+	for {
+		b.mu.Lock()
+		delay := b.until.Sub(clk.Now())
+		b.mu.Unlock()
+		if delay <= 0 {
+			return ctx.Err()
+		}
+		timer := clk.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// ListAllAgents collects the whole listing into a slice. Set opts.Max to
+// bound memory use; the result is then truncated to Max agents.
+func (c *A2ARegClient) ListAllAgents(ctx context.Context, opts IterOptions) ([]Agent, error) {
+	it := c.IterAgents(ctx, opts)
+	defer it.Close()
+	var agents []Agent
+	for it.Next() {
+		agents = append(agents, it.Agent())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
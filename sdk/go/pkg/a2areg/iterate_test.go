@@ -0,0 +1,288 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedRegistry serves total agents on /agents/public, failing page
+// failPage with a 500 when it is set. With hideTotal the listing omits
+// "total". When hook is set it runs first and may answer the request
+// itself by returning true.
+type pagedRegistry struct {
+	*httptest.Server
+	total     int
+	failPage  int
+	hideTotal bool
+	hook      func(w http.ResponseWriter, req *http.Request, page int) bool
+	requests  atomic.Int32
+	lastQuery atomic.Value
+}
+
+func newPagedRegistry(t *testing.T, total int) *pagedRegistry {
+	r := &pagedRegistry{total: total}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.requests.Add(1)
+		r.lastQuery.Store(req.URL.RawQuery)
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+		if r.hook != nil && r.hook(w, req, page) {
+			return
+		}
+		if page == r.failPage {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"detail": "boom"}`))
+			return
+		}
+		agents := []map[string]interface{}{}
+		for i := (page - 1) * limit; i < page*limit && i < r.total; i++ {
+			agents = append(agents, map[string]interface{}{"id": fmt.Sprintf("agent-%02d", i+1), "name": fmt.Sprintf("Agent %d", i+1)})
+		}
+		listing := map[string]interface{}{"agents": agents, "page": page, "limit": limit}
+		if !r.hideTotal {
+			listing["total"] = r.total
+		}
+		json.NewEncoder(w).Encode(listing)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestIterAgents_ThreePages(t *testing.T) {
+	for _, hideTotal := range []bool{false, true} {
+		registry := newPagedRegistry(t, 7)
+		registry.hideTotal = hideTotal
+		client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+		it := client.IterAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 3})
+		var ids []string
+		for it.Next() {
+			agent := it.Agent()
+			ids = append(ids, *agent.ID)
+		}
+		require.NoError(t, it.Err())
+		assert.Len(t, ids, 7)
+		assert.Equal(t, "agent-01", ids[0])
+		assert.Equal(t, "agent-07", ids[6])
+		assert.Equal(t, int32(3), registry.requests.Load())
+	}
+}
+
+func TestIterAgents_ExactMultipleStopsAtTotal(t *testing.T) {
+	registry := newPagedRegistry(t, 6)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agents, err := client.ListAllAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 3})
+	require.NoError(t, err)
+	assert.Len(t, agents, 6)
+	assert.Equal(t, int32(2), registry.requests.Load())
+}
+
+func TestIterAgents_MidStreamError(t *testing.T) {
+	registry := newPagedRegistry(t, 7)
+	registry.failPage = 2
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	it := client.IterAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 3})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, 3, count)
+	require.ErrorIs(t, it.Err(), ErrServer)
+	assert.Equal(t, http.StatusInternalServerError, StatusCode(it.Err()))
+	assert.False(t, it.Next())
+
+	_, err := client.ListAllAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 3})
+	assert.ErrorIs(t, err, ErrServer)
+}
+
+func TestListAllAgents_Max(t *testing.T) {
+	registry := newPagedRegistry(t, 100)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agents, err := client.ListAllAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 10, Max: 15, Summary: true})
+	require.NoError(t, err)
+	assert.Len(t, agents, 15)
+	assert.Equal(t, int32(2), registry.requests.Load())
+	assert.Contains(t, registry.lastQuery.Load(), "fields=summary")
+}
+
+func TestListAllAgents_SummaryProjectsFullAgents(t *testing.T) {
+	registry := newPagedRegistry(t, 1)
+	registry.hook = func(w http.ResponseWriter, req *http.Request, page int) bool {
+		// A registry that ignores fields=summary.
+		w.Write([]byte(`{"agents": [{"id": "agent-01", "name": "Agent 1", "version": "1.0.0",
+			"provider": {"organization": "acme"}, "tags": ["a"], "description": "Full agent",
+			"skills": [{"id": "s1", "name": "Search"}], "capabilities": {"streaming": true}}], "total": 1}`))
+		return true
+	}
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agents, err := client.ListAllAgents(context.Background(), IterOptions{PublicOnly: true, Summary: true})
+	require.NoError(t, err)
+	require.Len(t, agents, 1)
+	assert.Empty(t, agents[0].Description)
+	assert.Empty(t, agents[0].Skills)
+	assert.Equal(t, AgentSummary{
+		ID: "agent-01", Name: "Agent 1", Version: "1.0.0", Provider: "acme", Tags: []string{"a"},
+		Capabilities: CapabilityFlags{Streaming: true},
+	}, agents[0].Summary())
+}
+
+func TestIterAgents_CanceledContext(t *testing.T) {
+	registry := newPagedRegistry(t, 7)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	it := client.IterAgents(ctx, IterOptions{PublicOnly: true})
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+	assert.Equal(t, int32(0), registry.requests.Load())
+}
+
+func expectedAgentIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("agent-%02d", i+1)
+	}
+	return ids
+}
+
+func TestIterAgents_PrefetchKeepsOrder(t *testing.T) {
+	registry := newPagedRegistry(t, 50)
+	var inFlight, maxInFlight atomic.Int32
+	registry.hook = func(w http.ResponseWriter, req *http.Request, page int) bool {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if n <= seen || maxInFlight.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+		// Later pages answer sooner, so they arrive out of order.
+		time.Sleep(time.Duration(12-page) * 2 * time.Millisecond)
+		return false
+	}
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	agents, err := client.ListAllAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 5, Prefetch: 4})
+	require.NoError(t, err)
+	assert.Equal(t, expectedAgentIDs(50), agentIDs(agents))
+	assert.Equal(t, int32(10), registry.requests.Load(), "no page past the total is requested")
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(4))
+	assert.Greater(t, maxInFlight.Load(), int32(1), "pages are fetched concurrently")
+
+	agents, err = client.ListAllAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 5, Prefetch: 4, Max: 12})
+	require.NoError(t, err)
+	assert.Equal(t, expectedAgentIDs(12), agentIDs(agents))
+	assert.Equal(t, int32(13), registry.requests.Load(), "no page past Max is requested")
+}
+
+func TestIterAgents_PrefetchCloseCancels(t *testing.T) {
+	registry := newPagedRegistry(t, 1000)
+	var canceled atomic.Int32
+	registry.hook = func(w http.ResponseWriter, req *http.Request, page int) bool {
+		if page <= 2 {
+			return false
+		}
+		// Later pages hang until the client gives up on them.
+		<-req.Context().Done()
+		canceled.Add(1)
+		return true
+	}
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL: registry.URL,
+		APIKey:      "test",
+		HTTPClient:  &http.Client{Transport: &http.Transport{DisableKeepAlives: true}},
+	})
+	baseline := runtime.NumGoroutine()
+
+	it := client.IterAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 10, Prefetch: 5})
+	for i := 0; i < 20; i++ {
+		require.True(t, it.Next())
+	}
+	closed := make(chan struct{})
+	go func() {
+		it.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not cancel the prefetched pages")
+	}
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+	// Pages requested before Close are canceled; those still being
+	// dialed may never reach the registry.
+	assert.Eventually(t, func() bool { return canceled.Load() == registry.requests.Load()-2 }, 5*time.Second, 10*time.Millisecond)
+	// Polled by hand, since Eventually runs its condition on goroutines of
+	// its own.
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), baseline, "prefetch goroutines leaked")
+}
+
+func TestIterAgents_PrefetchRateLimitBacksOff(t *testing.T) {
+	registry := newPagedRegistry(t, 100)
+	var limited atomic.Bool
+	registry.hook = func(w http.ResponseWriter, req *http.Request, page int) bool {
+		if page != 3 || limited.Load() {
+			return false
+		}
+		// Answer once pages 1 to 6 have all been requested, so every
+		// request made after this one is made during the backoff.
+		require.Eventually(t, func() bool { return registry.requests.Load() >= 6 }, 5*time.Second, time.Millisecond)
+		limited.Store(true)
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"detail": "slow down"}`))
+		return true
+	}
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test", Clock: fake})
+
+	type result struct {
+		agents []Agent
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		agents, err := client.ListAllAgents(context.Background(), IterOptions{PublicOnly: true, PageSize: 10, Prefetch: 4})
+		done <- result{agents, err}
+	}()
+
+	fake.BlockUntil(1)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(6), registry.requests.Load(), "no page is requested during the backoff")
+
+	for {
+		select {
+		case res := <-done:
+			require.NoError(t, res.err)
+			assert.Equal(t, expectedAgentIDs(100), agentIDs(res.agents))
+			assert.Equal(t, int32(11), registry.requests.Load(), "only the rate-limited page is retried")
+			return
+		case <-time.After(10 * time.Millisecond):
+			fake.Advance(2 * time.Second)
+		}
+	}
+}
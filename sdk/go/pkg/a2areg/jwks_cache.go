@@ -0,0 +1,368 @@
+package a2areg
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"a2areg/internal/clock"
+)
+
+// DefaultJWKSCacheTTL is how long a fetched JWKS is reused when neither
+// JWKSCacheOptions.TTL nor the response's Cache-Control max-age is
+// shorter.
+const DefaultJWKSCacheTTL = 15 * time.Minute
+
+// DefaultJWKSStaleGrace is how long past its expiry a key set is still
+// used while fetching a new one fails.
+const DefaultJWKSStaleGrace = time.Hour
+
+// DefaultJWKSMaxBytes caps the size of a fetched JWKS document.
+const DefaultJWKSMaxBytes = 1 << 20
+
+// DefaultJWKSMaxEntries is how many key sets a JWKSCache keeps.
+const DefaultJWKSMaxEntries = 256
+
+// DefaultJWKSMinRefetchInterval is how long after fetching a key set a
+// lookup for a key ID missing from it waits before fetching it again.
+const DefaultJWKSMinRefetchInterval = 30 * time.Second
+
+// JWKSCacheOptions configures a JWKSCache. Zero values select the
+// defaults.
+type JWKSCacheOptions struct {
+	// TTL is how long a fetched key set is used before it is fetched
+	// again. A shorter Cache-Control max-age on the JWKS response wins, so
+	// each URL can have its own TTL. Defaults to DefaultJWKSCacheTTL.
+	TTL time.Duration
+	// RefreshAhead is how long before expiry a lookup starts fetching the
+	// key set again in the background, so that verifications do not wait
+	// on the fetch. It is at most half a key set's TTL. Defaults to a
+	// tenth of TTL; negative disables.
+	RefreshAhead time.Duration
+	// StaleGrace is how long past expiry a key set is still served when
+	// fetching it again fails. Defaults to DefaultJWKSStaleGrace; negative
+	// disables.
+	StaleGrace time.Duration
+	// MaxBytes caps the size of a JWKS document; larger ones are
+	// rejected. Defaults to DefaultJWKSMaxBytes.
+	MaxBytes int64
+	// MaxEntries caps the number of key sets kept; the least recently
+	// used is dropped first. Defaults to DefaultJWKSMaxEntries.
+	MaxEntries int
+	// MinRefetchInterval is how soon after a key set was fetched a lookup
+	// for a key ID it lacks may fetch it again; until then the lookup
+	// fails without a request, so that cards naming unknown keys cannot
+	// make every verification hit the JWKS host. Defaults to
+	// DefaultJWKSMinRefetchInterval; negative disables the limit.
+	MinRefetchInterval time.Duration
+	// HTTPClient fetches key sets. Defaults to a client with a 10 second
+	// timeout.
+	HTTPClient *http.Client
+	// Clock overrides the time source used for expiry.
+	Clock Clock
+}
+
+// JWKSCache fetches JWKS documents and keeps them by URL, for
+// AgentCardSpec.VerifySignature and AgentTeeDetails.VerifyAttestation.
+// When a key ID is not in a cached set, the set is fetched again once, at
+// most once per MinRefetchInterval, so that rotated keys are picked up
+// without waiting for the TTL. Concurrent lookups that need the same URL
+// share one fetch. A JWKSCache is safe for concurrent use.
+type JWKSCache struct {
+	opts JWKSCacheOptions
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List // of *jwksEntry, front is most recently used
+	inflight map[string]*jwksFetch
+}
+
+type jwksEntry struct {
+	url        string
+	set        *JSONWebKeySet
+	fetchedAt  time.Time
+	expiresAt  time.Time
+	refreshAt  time.Time
+	refreshing bool
+}
+
+// jwksFetch is a fetch in progress, shared by the lookups waiting on it.
+type jwksFetch struct {
+	done chan struct{}
+	set  *JSONWebKeySet
+	err  error
+}
+
+// defaultJWKSCache is used when VerifyOptions and AttestationOptions name
+// no cache.
+var defaultJWKSCache = NewJWKSCache(JWKSCacheOptions{})
+
+// NewJWKSCache returns an empty cache.
+func NewJWKSCache(opts JWKSCacheOptions) *JWKSCache {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultJWKSCacheTTL
+	}
+	if opts.RefreshAhead == 0 {
+		opts.RefreshAhead = opts.TTL / 10
+	}
+	if opts.StaleGrace == 0 {
+		opts.StaleGrace = DefaultJWKSStaleGrace
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultJWKSMaxBytes
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultJWKSMaxEntries
+	}
+	if opts.MinRefetchInterval == 0 {
+		opts.MinRefetchInterval = DefaultJWKSMinRefetchInterval
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	return &JWKSCache{
+		opts:     opts,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		inflight: make(map[string]*jwksFetch),
+	}
+}
+
+// KeySet returns the key set at jwksURL, from the cache while it has not
+// expired.
+func (jc *JWKSCache) KeySet(ctx context.Context, jwksURL string) (*JSONWebKeySet, error) {
+	return jc.lookup(ctx, nil, jwksURL, "")
+}
+
+// Key returns the key with ID kid from the key set at jwksURL. If the
+// cached set has no such key, the set is fetched again once, unless it was
+// fetched within MinRefetchInterval, before failing with a
+// *SignatureVerificationError.
+func (jc *JWKSCache) Key(ctx context.Context, jwksURL, kid string) (JSONWebKey, error) {
+	set, err := jc.lookup(ctx, nil, jwksURL, kid)
+	if err != nil {
+		return JSONWebKey{}, err
+	}
+	key, _ := set.key(kid)
+	return key, nil
+}
+
+// lookup returns the key set at jwksURL. When kid is set the returned set
+// holds it: a cached set without it is fetched again once, unless it is
+// younger than MinRefetchInterval. client overrides the cache's HTTP
+// client.
+func (jc *JWKSCache) lookup(ctx context.Context, client *http.Client, jwksURL, kid string) (*JSONWebKeySet, error) {
+	if client == nil {
+		client = jc.opts.HTTPClient
+	}
+	now := jc.opts.Clock.Now()
+	missing := func() error {
+		return NewSignatureVerificationError(fmt.Sprintf("No key %q in the JWKS at %s", kid, jwksURL), nil)
+	}
+
+	jc.mu.Lock()
+	var entry *jwksEntry
+	if elem, ok := jc.entries[jwksURL]; ok {
+		jc.lru.MoveToFront(elem)
+		entry = elem.Value.(*jwksEntry)
+	}
+	fresh := entry != nil && now.Before(entry.expiresAt)
+	if fresh && jc.opts.RefreshAhead > 0 && !entry.refreshing && !now.Before(entry.refreshAt) {
+		entry.refreshing = true
+		go jc.refreshInBackground(client, jwksURL)
+	}
+	jc.mu.Unlock()
+
+	if fresh {
+		if _, ok := entry.set.key(kid); ok || kid == "" {
+			return entry.set, nil
+		}
+		// The key may have been rotated in since the set was fetched, but
+		// a set fetched moments ago is not fetched again.
+		if jc.opts.MinRefetchInterval > 0 && now.Before(entry.fetchedAt.Add(jc.opts.MinRefetchInterval)) {
+			return nil, missing()
+		}
+	}
+	set, err := jc.fetch(ctx, client, jwksURL)
+	if err != nil {
+		if entry == nil || fresh || jc.opts.StaleGrace < 0 || !now.Before(entry.expiresAt.Add(jc.opts.StaleGrace)) {
+			return nil, err
+		}
+		set = entry.set
+	}
+	if _, ok := set.key(kid); !ok && kid != "" {
+		return nil, missing()
+	}
+	return set, nil
+}
+
+// refreshInBackground fetches the key set at jwksURL ahead of its expiry.
+// On failure the cached set is kept.
+func (jc *JWKSCache) refreshInBackground(client *http.Client, jwksURL string) {
+	if _, err := jc.fetch(context.Background(), client, jwksURL); err != nil {
+		jc.mu.Lock()
+		if elem, ok := jc.entries[jwksURL]; ok {
+			elem.Value.(*jwksEntry).refreshing = false
+		}
+		jc.mu.Unlock()
+	}
+}
+
+// fetch fetches the key set at jwksURL and caches it. A fetch of jwksURL
+// already in progress is waited for instead of starting another.
+func (jc *JWKSCache) fetch(ctx context.Context, client *http.Client, jwksURL string) (*JSONWebKeySet, error) {
+	jc.mu.Lock()
+	if call, ok := jc.inflight[jwksURL]; ok {
+		jc.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.set, call.err
+		case <-ctx.Done():
+			return nil, jwksFetchError(jwksURL, "Failed to fetch JWKS from "+jwksURL, ctx.Err())
+		}
+	}
+	call := &jwksFetch{done: make(chan struct{})}
+	jc.inflight[jwksURL] = call
+	jc.mu.Unlock()
+
+	call.set, call.err = jc.fetchNow(ctx, client, jwksURL)
+	jc.mu.Lock()
+	delete(jc.inflight, jwksURL)
+	jc.mu.Unlock()
+	close(call.done)
+	return call.set, call.err
+}
+
+// fetchNow fetches the key set at jwksURL and caches it, dropping the least
+// recently used set when the cache is full.
+func (jc *JWKSCache) fetchNow(ctx context.Context, client *http.Client, jwksURL string) (*JSONWebKeySet, error) {
+	set, maxAge, err := fetchJWKS(ctx, client, jwksURL, jc.opts.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	ttl := jc.opts.TTL
+	if maxAge > 0 && maxAge < ttl {
+		ttl = maxAge
+	}
+	ahead := jc.opts.RefreshAhead
+	if ahead > ttl/2 {
+		ahead = ttl / 2
+	}
+	now := jc.opts.Clock.Now()
+	entry := &jwksEntry{url: jwksURL, set: set, fetchedAt: now, expiresAt: now.Add(ttl), refreshAt: now.Add(ttl - ahead)}
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	if elem, ok := jc.entries[jwksURL]; ok {
+		elem.Value = entry
+		jc.lru.MoveToFront(elem)
+		return set, nil
+	}
+	jc.entries[jwksURL] = jc.lru.PushFront(entry)
+	for jc.lru.Len() > jc.opts.MaxEntries {
+		oldest := jc.lru.Back()
+		jc.lru.Remove(oldest)
+		delete(jc.entries, oldest.Value.(*jwksEntry).url)
+	}
+	return set, nil
+}
+
+// key returns the key in s with ID kid.
+func (s *JSONWebKeySet) key(kid string) (JSONWebKey, bool) {
+	for _, jwk := range s.Keys {
+		if jwk.Kid == kid {
+			return jwk, true
+		}
+	}
+	return JSONWebKey{}, false
+}
+
+var errJWKSTooLarge = errors.New("JWKS document too large")
+
+// limitedReader is io.LimitedReader failing with errJWKSTooLarge instead of
+// ending the stream at the limit, so that an oversized document is not
+// mistaken for a short one.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, errJWKSTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// fetchJWKS fetches and decodes the key set at jwksURL, reading at most
+// maxBytes, and returns it with the response's Cache-Control max-age.
+func fetchJWKS(ctx context.Context, client *http.Client, jwksURL string, maxBytes int64) (*JSONWebKeySet, time.Duration, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	fail := func(message string, cause error) error {
+		return jwksFetchError(jwksURL, message, cause)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", jwksURL, nil)
+	if err != nil {
+		return nil, 0, fail("Invalid JWKS URL: "+jwksURL, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fail("Failed to fetch JWKS from "+jwksURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fail(fmt.Sprintf("Failed to fetch JWKS from %s: status %d", jwksURL, resp.StatusCode), nil)
+	}
+	tooLarge := fail(fmt.Sprintf("JWKS at %s is larger than %d bytes", jwksURL, maxBytes), nil)
+	if resp.ContentLength > maxBytes {
+		return nil, 0, tooLarge
+	}
+
+	var set JSONWebKeySet
+	if err := json.NewDecoder(&limitedReader{r: resp.Body, n: maxBytes}).Decode(&set); err != nil {
+		if errors.Is(err, errJWKSTooLarge) {
+			return nil, 0, tooLarge
+		}
+		return nil, 0, fail("Invalid JWKS at "+jwksURL, err)
+	}
+	return &set, cacheMaxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// jwksFetchError reports that the key set at jwksURL could not be fetched.
+func jwksFetchError(jwksURL, message string, cause error) error {
+	err := NewSignatureVerificationError(message, cause)
+	err.Code = CodeJWKSFetchFailed
+	err.Details = map[string]interface{}{"url": jwksURL}
+	return err
+}
+
+// cacheMaxAge returns the max-age directive of a Cache-Control header, or
+// 0 if it has none.
+func cacheMaxAge(header string) time.Duration {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
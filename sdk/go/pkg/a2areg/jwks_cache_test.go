@@ -0,0 +1,243 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rotatingJWKS serves a key set the test can replace, optionally with a
+// Cache-Control header, and counts the fetches.
+type rotatingJWKS struct {
+	mu           sync.Mutex
+	body         []byte
+	cacheControl string
+	fetches      atomic.Int32
+}
+
+func newRotatingJWKS(t *testing.T) (*rotatingJWKS, *httptest.Server) {
+	jwks := &rotatingJWKS{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwks.fetches.Add(1)
+		jwks.mu.Lock()
+		body, cacheControl := jwks.body, jwks.cacheControl
+		jwks.mu.Unlock()
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return jwks, server
+}
+
+func (j *rotatingJWKS) serve(t *testing.T, kids ...string) {
+	t.Helper()
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, kid := range kids {
+		set.Keys = append(set.Keys, JSONWebKey{Kty: "EC", Kid: kid, Crv: "P-256"})
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	j.serveRaw(data)
+}
+
+func (j *rotatingJWKS) serveRaw(body []byte) {
+	j.mu.Lock()
+	j.body = body
+	j.mu.Unlock()
+}
+
+func TestJWKSCache_Rotation(t *testing.T) {
+	jwks, server := newRotatingJWKS(t)
+	jwks.serve(t, "2024-01-01")
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cache := NewJWKSCache(JWKSCacheOptions{Clock: fake})
+	ctx := context.Background()
+
+	key, err := cache.Key(ctx, server.URL, "2024-01-01")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01", key.Kid)
+	_, err = cache.Key(ctx, server.URL, "2024-01-01")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), jwks.fetches.Load(), "the key set is cached")
+
+	// The publisher rotates: the new kid is not cached, so the set is
+	// fetched again once.
+	jwks.serve(t, "2024-01-01", "2024-01-02")
+	fake.Advance(DefaultJWKSMinRefetchInterval)
+	key, err = cache.Key(ctx, server.URL, "2024-01-02")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-02", key.Kid)
+	assert.Equal(t, int32(2), jwks.fetches.Load())
+
+	// A set fetched within MinRefetchInterval is not fetched again for a
+	// kid it lacks.
+	for i := 0; i < 3; i++ {
+		_, err = cache.Key(ctx, server.URL, "unknown")
+		var sigErr *SignatureVerificationError
+		require.ErrorAs(t, err, &sigErr)
+		assert.Contains(t, err.Error(), `No key "unknown"`)
+	}
+	assert.Equal(t, int32(2), jwks.fetches.Load(), "kid misses are rate limited")
+
+	fake.Advance(DefaultJWKSMinRefetchInterval)
+	_, err = cache.Key(ctx, server.URL, "unknown")
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), jwks.fetches.Load(), "a missing kid is fetched again only once")
+}
+
+func TestJWKSCache_ConcurrentMissesShareFetch(t *testing.T) {
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		w.Write([]byte(`{"keys": [{"kty": "EC", "kid": "k1", "crv": "P-256"}]}`))
+	}))
+	defer server.Close()
+	cache := NewJWKSCache(JWKSCacheOptions{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Key(context.Background(), server.URL, "k1")
+			assert.NoError(t, err)
+		}()
+	}
+	require.Eventually(t, func() bool { return fetches.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), fetches.Load())
+}
+
+func TestJWKSCache_MaxEntries(t *testing.T) {
+	jwks, server := newRotatingJWKS(t)
+	jwks.serve(t, "k1")
+	cache := NewJWKSCache(JWKSCacheOptions{MaxEntries: 2})
+	ctx := context.Background()
+
+	for _, u := range []string{"/a", "/b", "/a", "/c"} {
+		_, err := cache.KeySet(ctx, server.URL+u)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), jwks.fetches.Load())
+	assert.Equal(t, 2, cache.lru.Len())
+
+	// "/b" was the least recently used and is gone; "/a" is still cached.
+	_, err := cache.KeySet(ctx, server.URL+"/a")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), jwks.fetches.Load())
+	_, err = cache.KeySet(ctx, server.URL+"/b")
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), jwks.fetches.Load())
+	assert.Len(t, cache.entries, 2)
+}
+
+func TestJWKSCache_TTLAndRefreshAhead(t *testing.T) {
+	jwks, server := newRotatingJWKS(t)
+	jwks.serve(t, "k1")
+	jwks.cacheControl = "public, max-age=120"
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cache := NewJWKSCache(JWKSCacheOptions{TTL: 10 * time.Minute, RefreshAhead: time.Minute, Clock: fake})
+	ctx := context.Background()
+
+	_, err := cache.KeySet(ctx, server.URL)
+	require.NoError(t, err)
+	fake.Advance(30 * time.Second)
+	_, err = cache.KeySet(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), jwks.fetches.Load())
+
+	// Within a minute of the 120 second max-age the cached set is returned
+	// and fetched again in the background.
+	jwks.serve(t, "k2")
+	fake.Advance(45 * time.Second)
+	set, err := cache.KeySet(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", set.Keys[0].Kid)
+	require.Eventually(t, func() bool {
+		set, err := cache.KeySet(ctx, server.URL)
+		return err == nil && set.Keys[0].Kid == "k2"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(2), jwks.fetches.Load())
+
+	// Past expiry the set is fetched before being returned.
+	jwks.serve(t, "k3")
+	fake.Advance(3 * time.Minute)
+	set, err = cache.KeySet(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "k3", set.Keys[0].Kid)
+}
+
+func TestJWKSCache_PoisonedOversizedDocument(t *testing.T) {
+	jwks, server := newRotatingJWKS(t)
+	jwks.serve(t, "k1")
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cache := NewJWKSCache(JWKSCacheOptions{TTL: time.Minute, StaleGrace: 10 * time.Minute, MaxBytes: 4096, Clock: fake})
+	ctx := context.Background()
+	_, err := cache.Key(ctx, server.URL, "k1")
+	require.NoError(t, err)
+
+	// A syntactically valid document padded past the limit, so that a
+	// truncated read cannot pass for it.
+	poisoned := `{"keys": [{"kty": "EC", "kid": "k1", "crv": "P-256"}], "padding": "` + strings.Repeat("A", 8192) + `"}`
+	jwks.serveRaw([]byte(poisoned))
+
+	// Within the grace window the stale keys are served.
+	fake.Advance(5 * time.Minute)
+	key, err := cache.Key(ctx, server.URL, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.Kid)
+	assert.Equal(t, int32(2), jwks.fetches.Load())
+
+	fake.Advance(10 * time.Minute)
+	_, err = cache.Key(ctx, server.URL, "k1")
+	var sigErr *SignatureVerificationError
+	require.ErrorAs(t, err, &sigErr)
+	assert.Equal(t, CodeJWKSFetchFailed, ErrorCode(err))
+	assert.Contains(t, err.Error(), "larger than 4096 bytes")
+	assert.Equal(t, server.URL, sigErr.Details["url"])
+
+	// A declared Content-Length over the limit is rejected before reading.
+	declared := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(poisoned)))
+		w.Write([]byte(poisoned))
+	}))
+	defer declared.Close()
+	_, err = cache.KeySet(ctx, declared.URL)
+	assert.Equal(t, CodeJWKSFetchFailed, ErrorCode(err))
+	assert.Contains(t, err.Error(), "larger than 4096 bytes")
+
+	// Without a grace window a failed fetch is an error right away.
+	strict := NewJWKSCache(JWKSCacheOptions{TTL: time.Minute, StaleGrace: -1, Clock: fake})
+	jwks.serve(t, "k1")
+	_, err = strict.KeySet(ctx, server.URL)
+	require.NoError(t, err)
+	jwks.serveRaw([]byte("not json"))
+	fake.Advance(2 * time.Minute)
+	_, err = strict.KeySet(ctx, server.URL)
+	assert.Equal(t, CodeJWKSFetchFailed, ErrorCode(err))
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	assert.Equal(t, 300*time.Second, cacheMaxAge("public, max-age=300"))
+	assert.Equal(t, 60*time.Second, cacheMaxAge(`Max-Age="60", must-revalidate`))
+	assert.Equal(t, time.Duration(0), cacheMaxAge("no-store"))
+	assert.Equal(t, time.Duration(0), cacheMaxAge("max-age=abc"))
+}
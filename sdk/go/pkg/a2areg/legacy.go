@@ -0,0 +1,64 @@
+//go:build !a2areg_strict
+
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// legacyRegistryClient holds the deprecated methods of RegistryClient.
+type legacyRegistryClient interface {
+	GetHealth(opts ...RequestOption) (ResponseMap, error)
+	ListAgents(page, limit int, publicOnly bool, opts ...RequestOption) (map[string]interface{}, error)
+	SearchAgents(query string, filters map[string]interface{}, semantic bool, page, limit int, opts ...RequestOption) (map[string]interface{}, error)
+}
+
+// GetHealth gets the registry health status.
+//
+// Deprecated: Use Health, which decodes the response into a HealthStatus.
+func (c *A2ARegClient) GetHealth(opts ...RequestOption) (ResponseMap, error) {
+	c.warnDeprecated("GetHealth", "Health")
+	_, doc, err := c.health(withRequestOptions(context.Background(), opts))
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ListAgents lists agents from the registry.
+//
+// Deprecated: Use ListAgentsPage, which decodes the page into an
+// AgentList.
+func (c *A2ARegClient) ListAgents(page, limit int, publicOnly bool, opts ...RequestOption) (map[string]interface{}, error) {
+	c.warnDeprecated("ListAgents", "ListAgentsPage")
+	_, doc, err := c.agentPage(withRequestOptions(context.Background(), opts), page, limit, publicOnly)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// SearchAgents searches for agents. With SearchFallback enabled, a registry
+// that answers 404, 405 or 501 is searched client-side instead; see
+// fallbackSearch for what that result contains. filters are sent as given.
+//
+// Deprecated: Use Search, which takes typed filters and decodes the
+// results into a SearchResponse.
+func (c *A2ARegClient) SearchAgents(query string, filters map[string]interface{}, semantic bool, page, limit int, opts ...RequestOption) (map[string]interface{}, error) {
+	c.warnDeprecated("SearchAgents", "Search")
+	req := SearchRequest{Query: query, Semantic: semantic, Page: page, Limit: limit, AllowPartial: true}
+	if len(filters) > 0 {
+		req.Filters = &SearchFilters{Extra: filters}
+	}
+	_, body, err := c.searchDocument(withRequestOptions(context.Background(), opts), req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode search response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package a2areg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DecodeOptions controls Agent.Decode.
+type DecodeOptions struct {
+	// LenientDecoding accepts the shapes older registry builds send: tags
+	// as one comma-separated string ("weather, forecast") and skills as an
+	// object keyed by skill ID. They are converted to the canonical shapes,
+	// so the agent re-encodes with tags and skills as arrays; skills keep
+	// the order of the object and take their ID from the key when they
+	// have none. Without it such documents are an error.
+	//
+	// FromJSON, json.Unmarshal and the client itself decode leniently.
+	LenientDecoding bool
+	// DisallowUnknownFields makes members the models have no field for an
+	// error, in nested objects too. Members of security schemes are not
+	// checked.
+	DisallowUnknownFields bool
+}
+
+// Decode decodes an agent document as configured by opts.
+func (a *Agent) Decode(data []byte, opts DecodeOptions) error {
+	return a.decode(data, opts)
+}
+
+// unmarshalJSON is json.Unmarshal, rejecting unknown members when strict
+// is set.
+func unmarshalJSON(data []byte, v interface{}, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// decodeAgentTags decodes the tags member of an agent document.
+func decodeAgentTags(raw json.RawMessage, lenient bool) ([]string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	if raw[0] != '"' {
+		var tags []string
+		if err := json.Unmarshal(raw, &tags); err != nil {
+			return nil, err
+		}
+		return tags, nil
+	}
+	if !lenient {
+		return nil, fmt.Errorf("a2areg: tags must be an array, got %s", raw)
+	}
+	var joined string
+	if err := json.Unmarshal(raw, &joined); err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, tag := range strings.Split(joined, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// decodeAgentSkills decodes the skills member of an agent document.
+func decodeAgentSkills(raw json.RawMessage, opts DecodeOptions) ([]AgentSkill, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	if raw[0] != '{' {
+		var skills []AgentSkill
+		if err := unmarshalJSON(raw, &skills, opts.DisallowUnknownFields); err != nil {
+			return nil, err
+		}
+		return skills, nil
+	}
+	if !opts.LenientDecoding {
+		return nil, fmt.Errorf("a2areg: skills must be an array, got an object")
+	}
+
+	// Read the members in order: a map would lose it.
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	skills := []AgentSkill{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		var skill AgentSkill
+		if err := dec.Decode(&skill); err != nil {
+			return nil, fmt.Errorf("a2areg: skill %q: %w", key, err)
+		}
+		if skill.ID == "" {
+			skill.ID = key
+		}
+		skills = append(skills, skill)
+	}
+	return skills, nil
+}
@@ -0,0 +1,116 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readLegacyFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "legacy", name))
+	require.NoError(t, err)
+	return data
+}
+
+func TestAgent_LegacyTagString(t *testing.T) {
+	data := readLegacyFixture(t, "tags_string.json")
+
+	var agent Agent
+	require.NoError(t, agent.FromJSON(data))
+	assert.Equal(t, []string{"weather", "forecast", "climate"}, agent.Tags)
+	assert.Equal(t, "Acme Weather", agent.Provider)
+	require.Len(t, agent.Skills, 1)
+
+	var strict Agent
+	err := strict.Decode(data, DecodeOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tags must be an array")
+
+	// Re-encoding gives the canonical shape, which strict decoding accepts.
+	canonical, err := agent.ToJSON()
+	require.NoError(t, err)
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal(canonical, &document))
+	assert.Equal(t, []interface{}{"weather", "forecast", "climate"}, document["tags"])
+	require.NoError(t, strict.Decode(canonical, DecodeOptions{}))
+	assert.Equal(t, agent, strict)
+}
+
+func TestAgent_LegacySkillMap(t *testing.T) {
+	data := readLegacyFixture(t, "skills_map.json")
+
+	var agent Agent
+	require.NoError(t, json.Unmarshal(data, &agent))
+	require.Len(t, agent.Skills, 3)
+	assert.Equal(t, AgentSkill{ID: "search", Name: "Search", Description: "Search recipes", Tags: []string{"cooking", "search"}}, agent.Skills[0])
+	assert.Equal(t, "convert-units", agent.Skills[1].ID, "an ID in the skill wins over its key")
+	assert.Equal(t, []string{"2 cups in ml"}, agent.Skills[1].Examples)
+	assert.Equal(t, "pair", agent.Skills[2].ID)
+	assert.Equal(t, "Acme Kitchen", agent.Provider)
+
+	var strict Agent
+	err := strict.Decode(data, DecodeOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "skills must be an array")
+
+	canonical, err := agent.ToJSON()
+	require.NoError(t, err)
+	var document struct {
+		Skills []map[string]interface{} `json:"skills"`
+	}
+	require.NoError(t, json.Unmarshal(canonical, &document))
+	require.Len(t, document.Skills, 3)
+	assert.Equal(t, "search", document.Skills[0]["id"])
+	require.NoError(t, strict.Decode(canonical, DecodeOptions{}))
+	assert.Equal(t, agent, strict)
+}
+
+func TestAgent_CanonicalShapesUnchanged(t *testing.T) {
+	data := []byte(`{"name": "Current", "tags": ["a", "b"], "skills": [{"id": "s1", "name": "One", "description": "d", "tags": []}]}`)
+	for _, opts := range []DecodeOptions{{}, {LenientDecoding: true}} {
+		var agent Agent
+		require.NoError(t, agent.Decode(data, opts))
+		assert.Equal(t, []string{"a", "b"}, agent.Tags)
+		assert.Equal(t, []AgentSkill{{ID: "s1", Name: "One", Description: "d", Tags: []string{}}}, agent.Skills)
+	}
+
+	var agent Agent
+	require.NoError(t, agent.FromJSON([]byte(`{"name": "Bare", "tags": null, "skills": null}`)))
+	assert.Nil(t, agent.Tags)
+	assert.Nil(t, agent.Skills)
+	assert.Error(t, agent.FromJSON([]byte(`{"name": "Bad", "tags": 42}`)))
+}
+
+func TestA2ARegClient_LegacyRegistryResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/agents/legacy":
+			w.Write(readLegacyFixture(t, "skills_map.json"))
+		case "/agents/entitled":
+			w.Write(readLegacyFixture(t, "list_mixed.json"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	agent, err := client.GetAgent("legacy")
+	require.NoError(t, err)
+	assert.Len(t, agent.Skills, 3)
+
+	agents, err := client.ListAllAgents(context.Background(), IterOptions{})
+	require.NoError(t, err)
+	require.Len(t, agents, 3)
+	assert.Equal(t, []string{"alpha", "beta"}, agents[0].Tags)
+	assert.Equal(t, []AgentSkill{{ID: "s1", Name: "One", Description: "First", Tags: []string{}}}, agents[1].Skills)
+	assert.Equal(t, []string{"gamma"}, agents[2].Tags)
+}
@@ -0,0 +1,7 @@
+//go:build a2areg_strict
+
+package a2areg
+
+// legacyRegistryClient is empty: a2areg_strict builds leave the deprecated
+// methods out.
+type legacyRegistryClient interface{}
@@ -0,0 +1,89 @@
+//go:build !a2areg_strict
+
+package a2areg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestA2ARegClient_GetHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "healthy",
+			"version": "1.0.0",
+		})
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL: server.URL,
+		APIKey:      "test-key",
+	})
+
+	health, err := client.GetHealth()
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", health["status"])
+	assert.Equal(t, "1.0.0", health["version"])
+}
+
+func TestA2ARegClient_ListAgents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/agents/public", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"agents": []map[string]interface{}{
+				{"id": "agent-1", "name": "Test Agent"},
+			},
+			"total": 1,
+			"page":  1,
+			"limit": 20,
+		})
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL: server.URL,
+		APIKey:      "test-key",
+	})
+
+	result, err := client.ListAgents(1, 20, true)
+	require.NoError(t, err)
+	agents, _ := result["agents"].([]interface{})
+	assert.Len(t, agents, 1)
+}
+
+func TestA2ARegClient_SearchAgents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/agents/search", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"agents": []map[string]interface{}{
+				{"id": "agent-1", "name": "Recipe Agent"},
+			},
+			"total": 1,
+		})
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL: server.URL,
+		APIKey:      "test-key",
+	})
+
+	result, err := client.SearchAgents("recipe", map[string]interface{}{"tags": []string{"cooking"}}, false, 1, 20)
+	require.NoError(t, err)
+	agents, _ := result["agents"].([]interface{})
+	assert.Len(t, agents, 1)
+}
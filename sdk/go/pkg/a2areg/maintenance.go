@@ -0,0 +1,94 @@
+package a2areg
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultMaintenanceWait is how long the client holds off when the registry
+// announces maintenance without saying when it ends.
+const DefaultMaintenanceWait = 30 * time.Second
+
+// maintenanceError inspects a non-2xx response for the registry's
+// maintenance shape: 503 with {"maintenance": true, "until": "..."}. The
+// end of the window comes from "until", then Retry-After, then
+// DefaultMaintenanceWait.
+func (c *A2ARegClient) maintenanceError(resp *http.Response, errorData map[string]interface{}) *MaintenanceError {
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		return nil
+	}
+	if flag, _ := errorData["maintenance"].(bool); !flag {
+		return nil
+	}
+
+	now := c.clock.Now()
+	until := now.Add(DefaultMaintenanceWait)
+	if s, ok := errorData["until"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			until = t
+		}
+	} else if t, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		until = t
+	}
+
+	maintenanceErr := NewMaintenanceError(until, errorData)
+	maintenanceErr.setStatusCode(resp.StatusCode)
+	return maintenanceErr
+}
+
+// parseRetryAfter parses a Retry-After header given as either seconds or
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// enterMaintenance records that the registry is in maintenance until the
+// given time and calls OnMaintenance if that starts or extends the window.
+func (c *A2ARegClient) enterMaintenance(until time.Time) {
+	for {
+		current := c.maintenanceUntil.Load()
+		if until.UnixNano() <= current {
+			return
+		}
+		if c.maintenanceUntil.CompareAndSwap(current, until.UnixNano()) {
+			break
+		}
+	}
+	if c.onMaintenance != nil {
+		c.onMaintenance(until)
+	}
+}
+
+// maintenanceRemaining returns how long the current maintenance window has
+// left, or zero outside one.
+func (c *A2ARegClient) maintenanceRemaining() time.Duration {
+	until := c.maintenanceUntil.Load()
+	if until == 0 {
+		return 0
+	}
+	if remaining := time.Unix(0, until).Sub(c.clock.Now()); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// checkMaintenance fails fast while a maintenance window is open, so that
+// callers retrying in a loop do not reach the registry before it is back.
+func (c *A2ARegClient) checkMaintenance() error {
+	if c.maintenanceRemaining() == 0 {
+		return nil
+	}
+	maintenanceErr := NewMaintenanceError(time.Unix(0, c.maintenanceUntil.Load()), nil)
+	maintenanceErr.setStatusCode(http.StatusServiceUnavailable)
+	return maintenanceErr
+}
@@ -0,0 +1,181 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// maintenanceRegistry answers 503 with the maintenance body while until is
+// in the future according to fake, and counts every request it receives.
+type maintenanceRegistry struct {
+	*httptest.Server
+	hits       atomic.Int32
+	until      atomic.Int64
+	retryAfter string
+	plain      bool
+}
+
+func newMaintenanceRegistry(t *testing.T, fake *clock.Fake) *maintenanceRegistry {
+	r := &maintenanceRegistry{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.hits.Add(1)
+		if until := r.until.Load(); until != 0 && fake.Now().UnixNano() < until {
+			if r.retryAfter != "" {
+				w.Header().Set("Retry-After", r.retryAfter)
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			body := map[string]interface{}{"maintenance": !r.plain}
+			if r.retryAfter == "" {
+				body["until"] = time.Unix(0, until).UTC().Format(time.RFC3339)
+			}
+			json.NewEncoder(w).Encode(body)
+			return
+		}
+		switch req.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status": "healthy"}`))
+		case "/agents/agent-1":
+			if req.Method == "DELETE" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Write([]byte(`{"id": "agent-1", "name": "Recipe Agent"}`))
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestMaintenance_TypedErrorAndRetrySuppression(t *testing.T) {
+	fake := clock.NewFake(time.Now().Truncate(time.Second))
+	registry := newMaintenanceRegistry(t, fake)
+	until := fake.Now().Add(5 * time.Minute)
+	registry.until.Store(until.UnixNano())
+
+	var notified []time.Time
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:   registry.URL,
+		APIKey:        "test",
+		Clock:         fake,
+		OnMaintenance: func(u time.Time) { notified = append(notified, u) },
+	})
+
+	_, err := client.GetAgent("agent-1")
+	var maintenanceErr *MaintenanceError
+	require.ErrorAs(t, err, &maintenanceErr)
+	assert.True(t, until.Equal(maintenanceErr.Until))
+	assert.Equal(t, CodeMaintenance, ErrorCode(err))
+	assert.Equal(t, http.StatusServiceUnavailable, StatusCode(err))
+	require.Len(t, notified, 1)
+	assert.True(t, until.Equal(notified[0]))
+
+	// A caller hammering the client during the window never reaches the
+	// registry.
+	for i := 0; i < 10; i++ {
+		_, err = client.GetAgent("agent-1")
+		require.ErrorAs(t, err, &maintenanceErr)
+	}
+	fake.Advance(4 * time.Minute)
+	_, err = client.GetAgent("agent-1")
+	require.ErrorAs(t, err, &maintenanceErr)
+	assert.Equal(t, int32(1), registry.hits.Load())
+	assert.Len(t, notified, 1)
+
+	fake.Advance(time.Minute)
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Recipe Agent", agent.Name)
+	assert.Equal(t, int32(2), registry.hits.Load())
+}
+
+func TestMaintenance_RetryAfterHeader(t *testing.T) {
+	fake := clock.NewFake(time.Now().Truncate(time.Second))
+	registry := newMaintenanceRegistry(t, fake)
+	registry.retryAfter = strconv.Itoa(120)
+	registry.until.Store(fake.Now().Add(2 * time.Minute).UnixNano())
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test", Clock: fake})
+
+	_, err := client.GetAgent("agent-1")
+	var maintenanceErr *MaintenanceError
+	require.ErrorAs(t, err, &maintenanceErr)
+	assert.True(t, fake.Now().Add(2*time.Minute).Equal(maintenanceErr.Until))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	got, ok := parseRetryAfter("90", now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(90*time.Second), got)
+
+	got, ok = parseRetryAfter("Wed, 01 May 2024 12:10:00 GMT", now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(10*time.Minute), got)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+func TestMaintenance_Plain503IsServerError(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	registry := newMaintenanceRegistry(t, fake)
+	registry.plain = true
+	registry.until.Store(fake.Now().Add(time.Minute).UnixNano())
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test", Clock: fake})
+
+	_, err := client.GetAgent("agent-1")
+	assert.Equal(t, CodeServerError, ErrorCode(err))
+	_, err = client.GetAgent("agent-1")
+	assert.Equal(t, CodeServerError, ErrorCode(err))
+	assert.Equal(t, int32(2), registry.hits.Load())
+}
+
+func TestMaintenance_HealthReportsMaintenance(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	registry := newMaintenanceRegistry(t, fake)
+	registry.until.Store(fake.Now().Add(time.Minute).UnixNano())
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test", Clock: fake})
+
+	err := client.ReadinessCheck(context.Background())
+	assert.Equal(t, CodeMaintenance, ErrorCode(err))
+}
+
+func TestMaintenance_OfflineQueueWaitsForWindow(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	registry := newMaintenanceRegistry(t, fake)
+	registry.until.Store(fake.Now().Add(5 * time.Minute).UnixNano())
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:        registry.URL,
+		APIKey:             "test",
+		Clock:              fake,
+		EnableOfflineQueue: true,
+		OfflineQueueTTL:    time.Hour,
+	})
+
+	var queued *QueuedError
+	require.ErrorAs(t, client.DeleteAgent("agent-1"), &queued)
+	require.Equal(t, 1, client.OfflineQueueLen())
+
+	// The replay worker sleeps through the window instead of retrying
+	// every OfflineRetryInterval.
+	fake.BlockUntil(1)
+	fake.Advance(DefaultOfflineRetryInterval)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), registry.hits.Load())
+	assert.Equal(t, 1, client.OfflineQueueLen())
+
+	fake.Advance(5*time.Minute - DefaultOfflineRetryInterval)
+	assert.Eventually(t, func() bool { return client.OfflineQueueLen() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), registry.hits.Load())
+}
@@ -0,0 +1,234 @@
+package a2areg
+
+import (
+	"context"
+	"errors"
+)
+
+// MigrateOptions configures PlanMigration and MigrateAgents. The zero value
+// migrates every agent the source client is entitled to, unchanged.
+type MigrateOptions struct {
+	// AgentIDs restricts the migration to these source agent IDs.
+	AgentIDs []string
+	// Tags restricts the migration to agents carrying at least one of these
+	// tags, on the agent or any of its skills. Combined with AgentIDs, an
+	// agent must match both.
+	Tags []string
+	// ForcePrivate publishes every agent as private in the destination,
+	// whatever its visibility in the source.
+	ForcePrivate bool
+	// ProviderMap renames providers: an agent whose provider is a key is
+	// published under the value instead. Its destination ID is derived from
+	// the new provider.
+	ProviderMap map[string]string
+	// DryRun makes MigrateAgents stop after planning: nothing is written to
+	// the destination, and the results list the agents that would be.
+	DryRun bool
+	// Publish is used for every agent published. DeriveID is always set.
+	Publish PublishOptions
+}
+
+// SyncAction is what a SyncPlan does with one agent.
+type SyncAction string
+
+// Actions of a SyncItem.
+const (
+	SyncCreate    SyncAction = "create"
+	SyncUpdate    SyncAction = "update"
+	SyncUnchanged SyncAction = "unchanged"
+)
+
+// SyncItem is one agent of a SyncPlan.
+type SyncItem struct {
+	// SourceID is the agent's ID in the source registry.
+	SourceID string `json:"source_id"`
+	// DestID is the ID the agent has, or will have, in the destination:
+	// DeriveAgentID of its provider and name.
+	DestID string     `json:"dest_id"`
+	Action SyncAction `json:"action"`
+	// Agent is the agent as it will be published, with provider and
+	// visibility rewritten.
+	Agent *Agent `json:"agent"`
+}
+
+// SyncPlan is what a migration will do, computed by PlanMigration before
+// anything is written.
+type SyncPlan struct {
+	// Items lists the selected agents ordered by action (create, update,
+	// unchanged), then name and provider.
+	Items []SyncItem `json:"items"`
+	// NotMigrated describes what a migration deliberately leaves behind.
+	NotMigrated []string `json:"not_migrated"`
+}
+
+// Pending returns the items that would be written: those not unchanged.
+func (p *SyncPlan) Pending() []SyncItem {
+	var pending []SyncItem
+	for _, item := range p.Items {
+		if item.Action != SyncUnchanged {
+			pending = append(pending, item)
+		}
+	}
+	return pending
+}
+
+// notMigrated is the SyncPlan.NotMigrated of every plan. Access grants and
+// credentials belong to one registry and are never copied to another.
+var notMigrated = []string{
+	"api keys: keys issued by the source registry are not copied and do not work in the destination",
+	"entitlements: access granted to source agents is not copied; grant it again in the destination",
+}
+
+// PlanMigration works out what MigrateAgents would do with the same
+// arguments, reading both registries and writing to neither. An agent
+// whose destination ID holds the same card with the same visibility is
+// planned as unchanged, so re-running a migration plans nothing new.
+func PlanMigration(ctx context.Context, source, dest *A2ARegClient, opts MigrateOptions) (*SyncPlan, error) {
+	agents, err := source.ListAllAgents(ctx, IterOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := newMigrationFilter(opts)
+	plan := &SyncPlan{NotMigrated: append([]string(nil), notMigrated...)}
+	for i := range agents {
+		agent := &agents[i]
+		if !filter.selects(agent) {
+			continue
+		}
+		migrated := opts.rewrite(agent)
+		if dest.tagTaxonomy != nil {
+			migrated = dest.tagTaxonomy.normalizeAgentTags(migrated)
+		}
+		item := SyncItem{
+			SourceID: getStringValue(agent.ID, ""),
+			DestID:   DeriveAgentID(migrated.ProviderName(), migrated.Name),
+			Agent:    migrated,
+		}
+		item.Action, err = dest.syncAction(ctx, item.DestID, migrated)
+		if err != nil {
+			return nil, err
+		}
+		plan.Items = append(plan.Items, item)
+	}
+	sortSyncItems(plan.Items)
+	return plan, nil
+}
+
+// MigrateAgents copies agents from the source registry to the destination,
+// publishing each under its derived ID (see PublishOptions.DeriveID) so the
+// destination client's credentials are the only ones needed there. Agents
+// already in the destination unchanged are skipped, which makes re-running
+// a migration a no-op. Entitlements and API keys are not migrated; see
+// SyncPlan.NotMigrated.
+//
+// The results cover the agents created or updated, with Index their
+// position in the plan's Items. A failed agent does not stop the migration;
+// the error is then a *BulkError. Errors reading either registry while
+// planning are returned before anything is written.
+func MigrateAgents(ctx context.Context, source, dest *A2ARegClient, opts MigrateOptions) ([]BulkResult, error) {
+	plan, err := PlanMigration(ctx, source, dest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	publish := opts.Publish
+	publish.DeriveID = true
+	var results []BulkResult
+	for i, item := range plan.Items {
+		if item.Action == SyncUnchanged {
+			continue
+		}
+		result := BulkResult{Index: i, AgentID: item.DestID}
+		if !opts.DryRun {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			_, result.Err = dest.publish(ctx, item.Agent, publish)
+		}
+		results = append(results, result)
+	}
+	return results, NewBulkError(results)
+}
+
+// migrationFilter selects agents by MigrateOptions.AgentIDs and Tags.
+type migrationFilter struct {
+	ids  map[string]bool
+	tags map[string]bool
+}
+
+func newMigrationFilter(opts MigrateOptions) migrationFilter {
+	f := migrationFilter{ids: make(map[string]bool), tags: make(map[string]bool)}
+	for _, id := range opts.AgentIDs {
+		f.ids[id] = true
+	}
+	for _, tag := range opts.Tags {
+		f.tags[tag] = true
+	}
+	return f
+}
+
+// selects reports whether agent passes the filter.
+func (f migrationFilter) selects(agent *Agent) bool {
+	if len(f.ids) > 0 && !f.ids[getStringValue(agent.ID, "")] {
+		return false
+	}
+	if len(f.tags) == 0 {
+		return true
+	}
+	for _, tag := range agent.Tags {
+		if f.tags[tag] {
+			return true
+		}
+	}
+	for _, skill := range agent.Skills {
+		for _, tag := range skill.Tags {
+			if f.tags[tag] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rewrite returns a copy of agent with the provider and visibility it is
+// to be published with.
+func (o MigrateOptions) rewrite(agent *Agent) *Agent {
+	migrated := *agent
+	if provider, ok := o.ProviderMap[agent.ProviderName()]; ok {
+		migrated.Provider = provider
+		if agent.ProviderInfo != nil {
+			info := *agent.ProviderInfo
+			info.Organization = provider
+			migrated.ProviderInfo = &info
+		}
+	}
+	if o.ForcePrivate {
+		migrated.Visibility = VisibilityPrivate
+		migrated.IsPublic = false
+	}
+	return &migrated
+}
+
+// syncAction compares agent with what the registry holds under agentID.
+func (c *A2ARegClient) syncAction(ctx context.Context, agentID string, agent *Agent) (SyncAction, error) {
+	existing, err := c.readAgent(ctx, agentID)
+	if err != nil {
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			return SyncCreate, nil
+		}
+		return "", err
+	}
+	if existing.EffectiveVisibility() != agent.EffectiveVisibility() {
+		return SyncUpdate, nil
+	}
+	unchanged, err := c.agentCardUnchanged(ctx, agentID, agent)
+	if err != nil {
+		return "", err
+	}
+	if unchanged {
+		return SyncUnchanged, nil
+	}
+	return SyncUpdate, nil
+}
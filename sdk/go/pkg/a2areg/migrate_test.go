@@ -0,0 +1,126 @@
+package a2areg
+
+import (
+	"context"
+	"testing"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// migrationFixtures publishes three agents to a new source registry.
+func migrationFixtures(t *testing.T) (*fakeregistry.Registry, *A2ARegClient) {
+	registry := fakeregistry.New()
+	server := registry.Start()
+	t.Cleanup(server.Close)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	recipes := testPublishAgent()
+	recipes.IsPublic = true
+	weather := &Agent{
+		Name: "Weather Agent", Description: "Forecasts", Version: "2.0.0", Provider: "staging-corp", IsPublic: true,
+		Skills: []AgentSkill{{ID: "s1", Name: "Forecast", Description: "Forecast weather", Tags: []string{"weather"}}},
+	}
+	internal := &Agent{
+		Name: "Internal Agent", Description: "Back office", Version: "0.1.0", Provider: "staging-corp",
+		Skills: []AgentSkill{{ID: "s1", Name: "Ledger", Description: "Reads the ledger", Tags: []string{"finance"}}},
+	}
+	for _, agent := range []*Agent{recipes, weather, internal} {
+		_, err := client.PublishAgentVerbose(context.Background(), agent, PublishOptions{SkipNormalizationCheck: true})
+		require.NoError(t, err)
+	}
+	return registry, client
+}
+
+func newMigrationDest(t *testing.T) (*fakeregistry.Registry, *A2ARegClient) {
+	registry := fakeregistry.New()
+	server := registry.Start()
+	t.Cleanup(server.Close)
+	return registry, NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "prod"})
+}
+
+func TestMigrateAgents_SecondRunIsNoOp(t *testing.T) {
+	_, source := migrationFixtures(t)
+	destRegistry, dest := newMigrationDest(t)
+	opts := MigrateOptions{ProviderMap: map[string]string{"staging-corp": "acme"}, ForcePrivate: true}
+
+	results, err := MigrateAgents(context.Background(), source, dest, opts)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, 3, destRegistry.Len())
+	assert.Equal(t, 3, destRegistry.Calls("POST /agents/publish"))
+	for _, agent := range destRegistry.Snapshot() {
+		assert.False(t, agent.Public, agent.ID)
+		assert.Equal(t, "acme", agent.Card["provider"].(map[string]interface{})["organization"])
+	}
+	assert.ElementsMatch(t, []string{
+		DeriveAgentID("acme", "Recipe Agent"),
+		DeriveAgentID("acme", "Weather Agent"),
+		DeriveAgentID("acme", "Internal Agent"),
+	}, []string{results[0].AgentID, results[1].AgentID, results[2].AgentID})
+
+	before := destRegistry.Snapshot()
+	results, err = MigrateAgents(context.Background(), source, dest, opts)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, 3, destRegistry.Calls("POST /agents/publish"), "nothing is republished")
+	assert.Equal(t, before, destRegistry.Snapshot())
+
+	plan, err := PlanMigration(context.Background(), source, dest, opts)
+	require.NoError(t, err)
+	require.Len(t, plan.Items, 3)
+	assert.Empty(t, plan.Pending())
+}
+
+func TestMigrateAgents_VisibilityChangeIsAnUpdate(t *testing.T) {
+	_, source := migrationFixtures(t)
+	destRegistry, dest := newMigrationDest(t)
+
+	_, err := MigrateAgents(context.Background(), source, dest, MigrateOptions{})
+	require.NoError(t, err)
+
+	plan, err := PlanMigration(context.Background(), source, dest, MigrateOptions{ForcePrivate: true})
+	require.NoError(t, err)
+	var actions []SyncAction
+	for _, item := range plan.Items {
+		actions = append(actions, item.Action)
+	}
+	// Only the two public agents change.
+	assert.ElementsMatch(t, []SyncAction{SyncUpdate, SyncUpdate, SyncUnchanged}, actions)
+
+	results, err := MigrateAgents(context.Background(), source, dest, MigrateOptions{ForcePrivate: true})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 3, destRegistry.Len())
+}
+
+func TestPlanMigration_FiltersAndDryRun(t *testing.T) {
+	sourceRegistry, source := migrationFixtures(t)
+	destRegistry, dest := newMigrationDest(t)
+
+	plan, err := PlanMigration(context.Background(), source, dest, MigrateOptions{Tags: []string{"weather", "finance"}})
+	require.NoError(t, err)
+	require.Len(t, plan.Items, 2)
+	for _, item := range plan.Items {
+		assert.Equal(t, SyncCreate, item.Action)
+		assert.Equal(t, "staging-corp", item.Agent.Provider)
+	}
+	assert.Len(t, plan.NotMigrated, 2)
+	assert.Contains(t, plan.NotMigrated[0], "api keys")
+	assert.Contains(t, plan.NotMigrated[1], "entitlements")
+
+	recipesID := sourceRegistry.Snapshot()[0].ID
+	plan, err = PlanMigration(context.Background(), source, dest, MigrateOptions{AgentIDs: []string{recipesID}, Tags: []string{"cooking"}})
+	require.NoError(t, err)
+	require.Len(t, plan.Items, 1)
+	assert.Equal(t, "Recipe Agent", plan.Items[0].Agent.Name)
+	assert.Equal(t, recipesID, plan.Items[0].SourceID)
+
+	results, err := MigrateAgents(context.Background(), source, dest, MigrateOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, 0, destRegistry.Len())
+	assert.Equal(t, 0, destRegistry.Calls("POST /agents/publish"))
+}
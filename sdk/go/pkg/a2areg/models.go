@@ -1,7 +1,10 @@
 package a2areg
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 )
 
@@ -9,28 +12,28 @@ import (
 // Section 5.5.1 of the A2A Protocol specification.
 type AgentProvider struct {
 	Organization string `json:"organization"`
-	URL         string `json:"url"`
+	URL          string `json:"url,omitempty"`
 }
 
 // AgentCapabilities represents optional capabilities supported by the Agent.
 // Section 5.5.2 of the A2A Protocol specification.
 type AgentCapabilities struct {
-	Streaming                      *bool `json:"streaming,omitempty"`
-	PushNotifications              *bool `json:"pushNotifications,omitempty"`
-	StateTransitionHistory         *bool `json:"stateTransitionHistory,omitempty"`
+	Streaming                         *bool `json:"streaming,omitempty"`
+	PushNotifications                 *bool `json:"pushNotifications,omitempty"`
+	StateTransitionHistory            *bool `json:"stateTransitionHistory,omitempty"`
 	SupportsAuthenticatedExtendedCard *bool `json:"supportsAuthenticatedExtendedCard,omitempty"`
 }
 
 // SecurityScheme represents authentication requirements for the Agent.
 // Section 5.5.3 of the A2A Protocol specification.
 type SecurityScheme struct {
-	Type        string   `json:"type"` // apiKey, oauth2, jwt, mTLS
-	Location    *string  `json:"location,omitempty"` // header, query, body
-	Name        *string  `json:"name,omitempty"` // Parameter name for credentials
-	Flow        *string  `json:"flow,omitempty"` // OAuth2 flow type
-	TokenURL    *string  `json:"tokenUrl,omitempty"` // OAuth2 token URL
-	Scopes      []string `json:"scopes,omitempty"` // OAuth2 scopes
-	Credentials *string  `json:"credentials,omitempty"` // Credentials for private Cards
+	Type        AuthSchemeType `json:"type"`
+	Location    *Location      `json:"location,omitempty"`
+	Name        *string        `json:"name,omitempty"`        // Parameter name for credentials
+	Flow        *string        `json:"flow,omitempty"`        // OAuth2 flow type
+	TokenURL    *string        `json:"tokenUrl,omitempty"`    // OAuth2 token URL
+	Scopes      []string       `json:"scopes,omitempty"`      // OAuth2 scopes
+	Credentials *string        `json:"credentials,omitempty"` // Credentials for private Cards
 }
 
 // AgentTeeDetails represents Trusted Execution Environment details.
@@ -55,12 +58,18 @@ type AgentSkill struct {
 // AgentInterface represents transport and interaction capabilities.
 // Section 5.5.5 of the A2A Protocol specification.
 type AgentInterface struct {
-	PreferredTransport string                   `json:"preferredTransport"` // jsonrpc, grpc, http
-	DefaultInputModes  []string                 `json:"defaultInputModes"`
-	DefaultOutputModes []string                 `json:"defaultOutputModes"`
+	PreferredTransport   Transport                `json:"preferredTransport"`
+	DefaultInputModes    []string                 `json:"defaultInputModes"`
+	DefaultOutputModes   []string                 `json:"defaultOutputModes"`
 	AdditionalInterfaces []map[string]interface{} `json:"additionalInterfaces,omitempty"`
 }
 
+// AgentInterfaceEntry is one transport endpoint served by an Agent.
+type AgentInterfaceEntry struct {
+	Transport Transport `json:"transport"`
+	URL       string    `json:"url"`
+}
+
 // AgentCardSignature represents digital signature information.
 // Section 5.5.6 of the A2A Protocol specification.
 type AgentCardSignature struct {
@@ -69,49 +78,328 @@ type AgentCardSignature struct {
 	JWKSUrl   *string `json:"jwksUrl,omitempty"`
 }
 
+// SecuritySchemes holds a card's security schemes keyed by scheme name, the
+// form ADK and current registries use. Older registries send a JSON array
+// instead; it is accepted too, each scheme keyed by its type.
+type SecuritySchemes map[string]SecurityScheme
+
+// UnmarshalJSON accepts either a JSON object keyed by scheme name or a JSON
+// array of schemes. Array entries are keyed by their type; a second scheme
+// of the same type (or one without a type) is keyed "<type>_<index>".
+func (s *SecuritySchemes) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		var schemes map[string]SecurityScheme
+		if err := json.Unmarshal(data, &schemes); err != nil {
+			return err
+		}
+		*s = schemes
+		return nil
+	}
+
+	var list []SecurityScheme
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	schemes := make(SecuritySchemes, len(list))
+	for i, scheme := range list {
+		name := string(scheme.Type)
+		if _, taken := schemes[name]; taken || name == "" {
+			name = fmt.Sprintf("%s_%d", scheme.Type, i)
+		}
+		schemes[name] = scheme
+	}
+	*s = schemes
+	return nil
+}
+
+// List returns the schemes ordered by name, the array form older
+// registries expect.
+func (s SecuritySchemes) List() []SecurityScheme {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]SecurityScheme, len(names))
+	for i, name := range names {
+		list[i] = s[name]
+	}
+	return list
+}
+
 // AgentCardSpec represents the Agent Card specification following A2A Protocol.
 // Section 5.5 of the A2A Protocol specification.
 type AgentCardSpec struct {
-	Name             string                       `json:"name"`
-	Description      string                       `json:"description"`
-	URL              string                       `json:"url"`
-	Version          string                       `json:"version"`
-	Capabilities     AgentCapabilities            `json:"capabilities"`
-	SecuritySchemes  map[string]SecurityScheme    `json:"securitySchemes"`  // Changed from slice to map for ADK compatibility
-	Skills           []AgentSkill                 `json:"skills"`
-	Interface        AgentInterface               `json:"interface"`
-	Provider         *AgentProvider               `json:"provider,omitempty"`
-	DocumentationURL *string                      `json:"documentationUrl,omitempty"`
-	Signature        *AgentCardSignature          `json:"signature,omitempty"`
-	DefaultInputModes []string                    `json:"defaultInputModes,omitempty"`  // ADK-compatible top-level field
-	DefaultOutputModes []string                   `json:"defaultOutputModes,omitempty"`  // ADK-compatible top-level field
+	Name               string              `json:"name"`
+	Description        string              `json:"description"`
+	URL                string              `json:"url"`
+	Version            string              `json:"version"`
+	Capabilities       AgentCapabilities   `json:"capabilities"`
+	SecuritySchemes    SecuritySchemes     `json:"securitySchemes"`
+	Skills             []AgentSkill        `json:"skills"`
+	Interface          AgentInterface      `json:"interface"`
+	Provider           *AgentProvider      `json:"provider,omitempty"`
+	DocumentationURL   *string             `json:"documentationUrl,omitempty"`
+	Signature          *AgentCardSignature `json:"signature,omitempty"`
+	DefaultInputModes  []string            `json:"defaultInputModes,omitempty"`  // ADK-compatible top-level field
+	DefaultOutputModes []string            `json:"defaultOutputModes,omitempty"` // ADK-compatible top-level field
 }
 
 // Agent represents an A2A Agent.
 type Agent struct {
-	ID           *string         `json:"id,omitempty"`
+	ID           *string        `json:"id,omitempty"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	Version      string         `json:"version"`
+	Provider     string         `json:"provider"`
+	ProviderInfo *AgentProvider `json:"-"` // Structured provider; takes precedence over Provider when set
+	Tags         []string       `json:"tags,omitempty"`
+	// IsPublic is kept for registries without visibility support. When
+	// Visibility is set it wins, and IsPublic is derived from it on encode.
+	IsPublic   bool       `json:"is_public"`
+	Visibility Visibility `json:"visibility,omitempty"`
+	IsActive   bool       `json:"is_active"`
+	// Deactivation describes the agent's deactivation, done or scheduled;
+	// nil when there is none.
+	Deactivation *DeactivationInfo `json:"deactivation,omitempty"`
+	// Deprecated marks an agent kept for existing consumers but no longer
+	// recommended; see DeprecateAgent.
+	Deprecated        bool   `json:"deprecated,omitempty"`
+	DeprecationReason string `json:"deprecation_reason,omitempty"`
+	// SunsetDate is when a deprecated agent is due to be removed, if the
+	// owner announced one.
+	SunsetDate         *time.Time            `json:"sunset_date,omitempty"`
+	LocationURL        *string               `json:"location_url,omitempty"`
+	LocationType       *string               `json:"location_type,omitempty"`
+	Capabilities       *AgentCapabilities    `json:"capabilities,omitempty"`
+	AuthSchemes        []SecurityScheme      `json:"auth_schemes,omitempty"`
+	TEEDetails         *AgentTeeDetails      `json:"tee_details,omitempty"`
+	Skills             []AgentSkill          `json:"skills,omitempty"`
+	PreferredTransport Transport             `json:"preferred_transport,omitempty"`
+	Interfaces         []AgentInterfaceEntry `json:"interfaces,omitempty"`
+	// Changelog lists the agent's releases, oldest first. The registry
+	// maintains it and returns it with the agent.
+	Changelog []ChangelogEntry `json:"changelog,omitempty"`
+	// Region is the deployment region of this instance ("eu-west-1").
+	Region string `json:"region,omitempty"`
+	// Labels are free-form metadata; keys must be DNS labels (see
+	// ValidateAgent).
+	Labels    map[string]string `json:"labels,omitempty"`
+	AgentCard *AgentCardSpec    `json:"agent_card,omitempty"`
+	ClientID  *string           `json:"client_id,omitempty"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"`
+	UpdatedAt *time.Time        `json:"updated_at,omitempty"`
+	// Starred reports whether the agent is in the client's favorites. It
+	// is only set by ListStarredAgents and calls made WithStarred.
+	Starred bool `json:"-"`
+	// ETag is the registry's version tag for the agent, from the ETag
+	// header of the response it was read from. UpdateAgent sends it as
+	// If-Match; empty when the registry sends none.
+	ETag string `json:"-"`
+}
+
+// Visibility controls where an agent can be found.
+type Visibility string
+
+const (
+	// VisibilityPublic agents appear in public listings and search.
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted agents can be fetched by ID or direct link but are
+	// left out of listings and search.
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityPrivate agents are only visible to their owner and
+	// entitled clients.
+	VisibilityPrivate Visibility = "private"
+)
+
+// valid reports whether v is one of the known visibilities.
+func (v Visibility) valid() bool {
+	return v == VisibilityPublic || v == VisibilityUnlisted || v == VisibilityPrivate
+}
+
+// EffectiveVisibility returns Visibility, or the visibility implied by
+// IsPublic when Visibility is unset.
+func (a *Agent) EffectiveVisibility() Visibility {
+	if a.Visibility != "" {
+		return a.Visibility
+	}
+	if a.IsPublic {
+		return VisibilityPublic
+	}
+	return VisibilityPrivate
+}
+
+// AgentSummary is the lightweight projection of an Agent used for listings.
+type AgentSummary struct {
+	ID           string          `json:"id"`
 	Name         string          `json:"name"`
-	Description  string          `json:"description"`
 	Version      string          `json:"version"`
 	Provider     string          `json:"provider"`
 	Tags         []string        `json:"tags,omitempty"`
-	IsPublic     bool            `json:"is_public"`
-	IsActive     bool            `json:"is_active"`
-	LocationURL  *string          `json:"location_url,omitempty"`
-	LocationType *string          `json:"location_type,omitempty"`
-	Capabilities *AgentCapabilities `json:"capabilities,omitempty"`
-	AuthSchemes  []SecurityScheme `json:"auth_schemes,omitempty"`
-	TEEDetails   *AgentTeeDetails `json:"tee_details,omitempty"`
-	Skills       []AgentSkill     `json:"skills,omitempty"`
-	AgentCard    *AgentCardSpec   `json:"agent_card,omitempty"`
-	ClientID     *string          `json:"client_id,omitempty"`
-	CreatedAt    *time.Time       `json:"created_at,omitempty"`
-	UpdatedAt    *time.Time       `json:"updated_at,omitempty"`
-}
-
-// FromJSON creates an Agent from JSON data.
+	Capabilities CapabilityFlags `json:"capabilities"`
+}
+
+// CapabilityFlags is AgentCapabilities with unset values resolved to false.
+type CapabilityFlags struct {
+	Streaming                         bool `json:"streaming"`
+	PushNotifications                 bool `json:"pushNotifications"`
+	StateTransitionHistory            bool `json:"stateTransitionHistory"`
+	SupportsAuthenticatedExtendedCard bool `json:"supportsAuthenticatedExtendedCard"`
+}
+
+// Flags resolves the optional capabilities to plain booleans.
+func (ac *AgentCapabilities) Flags() CapabilityFlags {
+	if ac == nil {
+		return CapabilityFlags{}
+	}
+	return CapabilityFlags{
+		Streaming:                         getBoolValue(ac.Streaming),
+		PushNotifications:                 getBoolValue(ac.PushNotifications),
+		StateTransitionHistory:            getBoolValue(ac.StateTransitionHistory),
+		SupportsAuthenticatedExtendedCard: getBoolValue(ac.SupportsAuthenticatedExtendedCard),
+	}
+}
+
+// Summary projects the agent onto an AgentSummary. Capabilities fall back to
+// those of the embedded agent card when the agent has none of its own.
+func (a *Agent) Summary() AgentSummary {
+	capabilities := a.Capabilities
+	if capabilities == nil && a.AgentCard != nil {
+		capabilities = &a.AgentCard.Capabilities
+	}
+	return AgentSummary{
+		ID:           getStringValue(a.ID, ""),
+		Name:         a.Name,
+		Version:      a.Version,
+		Provider:     a.ProviderName(),
+		Tags:         a.Tags,
+		Capabilities: capabilities.Flags(),
+	}
+}
+
+// getBoolValue returns the bool value or false.
+func getBoolValue(b *bool) bool {
+	return b != nil && *b
+}
+
+// agentJSON is an alias of Agent without its JSON methods, used to avoid
+// recursion in MarshalJSON and UnmarshalJSON.
+type agentJSON Agent
+
+// MarshalJSON emits the provider as an object when ProviderInfo is set and as
+// the legacy string otherwise. is_public is derived from Visibility when it
+// is set.
+func (a Agent) MarshalJSON() ([]byte, error) {
+	a.IsPublic = a.EffectiveVisibility() == VisibilityPublic
+	aux := struct {
+		*agentJSON
+		Provider interface{} `json:"provider"`
+	}{
+		agentJSON: (*agentJSON)(&a),
+		Provider:  a.Provider,
+	}
+	if a.ProviderInfo != nil {
+		aux.Provider = a.ProviderInfo
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON accepts the provider either as a plain string (legacy) or as
+// an AgentProvider object. When an object is received, Provider is set to its
+// organization so existing callers keep working. Visibility and IsPublic are
+// filled from whichever of visibility and is_public the registry sent. The
+// legacy tag and skill shapes are accepted as with Decode and
+// LenientDecoding.
+func (a *Agent) UnmarshalJSON(data []byte) error {
+	return a.decode(data, DecodeOptions{LenientDecoding: true})
+}
+
+func (a *Agent) decode(data []byte, opts DecodeOptions) error {
+	aux := struct {
+		*agentJSON
+		Provider json.RawMessage `json:"provider"`
+		IsPublic *bool           `json:"is_public"`
+		Tags     json.RawMessage `json:"tags"`
+		Skills   json.RawMessage `json:"skills"`
+	}{
+		agentJSON: (*agentJSON)(a),
+	}
+	a.Visibility = ""
+	if err := unmarshalJSON(data, &aux, opts.DisallowUnknownFields); err != nil {
+		return err
+	}
+	var err error
+	if a.Tags, err = decodeAgentTags(aux.Tags, opts.LenientDecoding); err != nil {
+		return err
+	}
+	if a.Skills, err = decodeAgentSkills(aux.Skills, opts); err != nil {
+		return err
+	}
+	switch {
+	case a.Visibility != "":
+		a.IsPublic = a.Visibility == VisibilityPublic
+	case aux.IsPublic != nil:
+		a.IsPublic = *aux.IsPublic
+		a.Visibility = a.EffectiveVisibility()
+	}
+
+	a.Provider = ""
+	a.ProviderInfo = nil
+	raw := bytes.TrimSpace(aux.Provider)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+
+	switch raw[0] {
+	case '"':
+		return json.Unmarshal(raw, &a.Provider)
+	case '{':
+		var provider AgentProvider
+		if err := unmarshalJSON(raw, &provider, opts.DisallowUnknownFields); err != nil {
+			return err
+		}
+		a.ProviderInfo = &provider
+		a.Provider = provider.Organization
+		return nil
+	default:
+		return fmt.Errorf("a2areg: provider must be a string or an object, got %s", raw)
+	}
+}
+
+// interfacesFromCard fills PreferredTransport and Interfaces from the
+// agent's card when the registry did not return them directly.
+func (a *Agent) interfacesFromCard() {
+	if a.AgentCard == nil {
+		return
+	}
+	if a.PreferredTransport == "" {
+		a.PreferredTransport = a.AgentCard.Interface.PreferredTransport
+	}
+	if len(a.Interfaces) > 0 {
+		return
+	}
+	for _, entry := range a.AgentCard.Interface.AdditionalInterfaces {
+		transport, _ := entry["transport"].(string)
+		url, _ := entry["url"].(string)
+		if transport != "" || url != "" {
+			a.Interfaces = append(a.Interfaces, AgentInterfaceEntry{Transport: normalizeTransport(transport), URL: url})
+		}
+	}
+}
+
+// ProviderName returns the provider organization, preferring ProviderInfo.
+func (a *Agent) ProviderName() string {
+	if a.ProviderInfo != nil && a.ProviderInfo.Organization != "" {
+		return a.ProviderInfo.Organization
+	}
+	return a.Provider
+}
+
+// FromJSON creates an Agent from JSON data, accepting the legacy shapes
+// described at LenientDecoding.
 func (a *Agent) FromJSON(data []byte) error {
-	return json.Unmarshal(data, a)
+	return a.Decode(data, DecodeOptions{LenientDecoding: true})
 }
 
 // ToJSON converts an Agent to JSON.
@@ -128,4 +416,3 @@ func (acs *AgentCardSpec) FromJSON(data []byte) error {
 func (acs *AgentCardSpec) ToJSON() ([]byte, error) {
 	return json.Marshal(acs)
 }
-
@@ -2,6 +2,7 @@ package a2areg
 
 import (
 	"encoding/json"
+	"sort"
 	"testing"
 	"time"
 
@@ -93,7 +94,7 @@ func TestAgent_WithAuthSchemes(t *testing.T) {
 	}
 
 	assert.Len(t, agent.AuthSchemes, 1)
-	assert.Equal(t, "apiKey", agent.AuthSchemes[0].Type)
+	assert.Equal(t, AuthSchemeAPIKey, agent.AuthSchemes[0].Type)
 	assert.Equal(t, "X-API-Key", *agent.AuthSchemes[0].Name)
 }
 
@@ -135,13 +136,13 @@ func TestAgentCardSpec_FromJSON(t *testing.T) {
 
 func TestAgentCardSpec_ToJSON(t *testing.T) {
 	card := &AgentCardSpec{
-		Name:        "Test Agent Card",
-		Description: "Card description",
-		URL:         "https://test.com",
-		Version:     "1.0.0",
+		Name:         "Test Agent Card",
+		Description:  "Card description",
+		URL:          "https://test.com",
+		Version:      "1.0.0",
 		Capabilities: AgentCapabilities{},
-		SecuritySchemes: []SecurityScheme{
-			{Type: "apiKey"},
+		SecuritySchemes: map[string]SecurityScheme{
+			"apiKey": {Type: "apiKey"},
 		},
 		Skills: []AgentSkill{
 			{
@@ -185,3 +186,104 @@ func TestAgent_WithTimestamps(t *testing.T) {
 	assert.Equal(t, now, *agent.UpdatedAt)
 }
 
+func TestAgent_ProviderLegacyString_RoundTrip(t *testing.T) {
+	data := []byte(`{"name": "Test Agent", "provider": "acme", "is_public": false, "is_active": true}`)
+
+	var agent Agent
+	require.NoError(t, agent.FromJSON(data))
+	assert.Equal(t, "acme", agent.Provider)
+	assert.Nil(t, agent.ProviderInfo)
+
+	out, err := agent.ToJSON()
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(out, &raw))
+	assert.Equal(t, "acme", raw["provider"])
+}
+
+func TestAgent_ProviderObject_RoundTrip(t *testing.T) {
+	data := []byte(`{
+		"name": "Test Agent",
+		"provider": {"organization": "Acme Corp", "url": "https://acme.example.com"}
+	}`)
+
+	var agent Agent
+	require.NoError(t, agent.FromJSON(data))
+	require.NotNil(t, agent.ProviderInfo)
+	assert.Equal(t, "Acme Corp", agent.ProviderInfo.Organization)
+	assert.Equal(t, "https://acme.example.com", agent.ProviderInfo.URL)
+	assert.Equal(t, "Acme Corp", agent.Provider)
+
+	out, err := agent.ToJSON()
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(out, &raw))
+	assert.Equal(t, map[string]interface{}{
+		"organization": "Acme Corp",
+		"url":          "https://acme.example.com",
+	}, raw["provider"])
+
+	var again Agent
+	require.NoError(t, again.FromJSON(out))
+	assert.Equal(t, agent.ProviderInfo, again.ProviderInfo)
+}
+
+func TestAgent_ProviderInvalidShape(t *testing.T) {
+	var agent Agent
+	assert.Error(t, agent.FromJSON([]byte(`{"name": "x", "provider": 42}`)))
+}
+
+func TestSecuritySchemes_BothShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"object", `{"apiKey": {"type": "apiKey", "name": "X-API-Key"}, "oauth": {"type": "oauth2"}}`, []string{"apiKey", "oauth"}},
+		{"array", `[{"type": "apiKey", "name": "X-API-Key"}, {"type": "oauth2"}]`, []string{"apiKey", "oauth2"}},
+		{"array duplicates", `[{"type": "apiKey"}, {"type": "apiKey"}, {}]`, []string{"_2", "apiKey", "apiKey_1"}},
+		{"empty array", `[]`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var card AgentCardSpec
+			require.NoError(t, json.Unmarshal([]byte(`{"name": "Recipe Agent", "securitySchemes": `+tt.doc+`}`), &card))
+			names := []string{}
+			for name := range card.SecuritySchemes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	var card AgentCardSpec
+	require.NoError(t, json.Unmarshal([]byte(`{"securitySchemes": null}`), &card))
+	assert.Nil(t, card.SecuritySchemes)
+	assert.Error(t, json.Unmarshal([]byte(`{"securitySchemes": "apiKey"}`), &card))
+}
+
+func TestSecuritySchemes_RoundTrip(t *testing.T) {
+	var card AgentCardSpec
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "Recipe Agent", "securitySchemes": [{"type": "apiKey", "location": "header"}, {"type": "oauth2", "flow": "client_credentials"}]}`), &card))
+
+	first, err := json.Marshal(card)
+	require.NoError(t, err)
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(first, &raw))
+	assert.IsType(t, map[string]interface{}{}, raw["securitySchemes"])
+
+	var again AgentCardSpec
+	require.NoError(t, json.Unmarshal(first, &again))
+	assert.Equal(t, card, again)
+	second, err := json.Marshal(again)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(first), string(second))
+
+	list := again.SecuritySchemes.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, AuthSchemeAPIKey, list[0].Type)
+	assert.Equal(t, AuthSchemeOAuth2, list[1].Type)
+}
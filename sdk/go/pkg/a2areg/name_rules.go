@@ -0,0 +1,110 @@
+package a2areg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Defaults for NameRules, matching the registry's own.
+const (
+	DefaultMinAgentNameLength = 3
+	DefaultMaxAgentNameLength = 64
+)
+
+// NameRules are the agent name rules ValidateAgent enforces: a length in
+// characters, no leading or trailing whitespace, and only unicode letters,
+// digits, '-' and ' '. Zero fields use the defaults; negative lengths
+// disable that limit. Deployments that customize the registry's rules set
+// the same values here. Uniqueness within a provider is checked by the
+// registry only.
+type NameRules struct {
+	MinLength int
+	MaxLength int
+	// ExtraCharacters lists characters allowed besides the default ones,
+	// such as "_.".
+	ExtraCharacters string
+}
+
+// withDefaults fills in zero limits.
+func (r NameRules) withDefaults() NameRules {
+	if r.MinLength == 0 {
+		r.MinLength = DefaultMinAgentNameLength
+	}
+	if r.MaxLength == 0 {
+		r.MaxLength = DefaultMaxAgentNameLength
+	}
+	return r
+}
+
+// ValidateAgentName checks name against the registry's default NameRules,
+// for validating form input before an Agent is built.
+func ValidateAgentName(name string) error {
+	return NameRules{}.Validate(name)
+}
+
+// Validate checks name against r. The *ValidationError names the rule that
+// failed in its "rule" detail: "required", "leading_whitespace",
+// "trailing_whitespace", "invalid_character" (with the 1-based character
+// "position"), "too_short" or "too_long".
+func (r NameRules) Validate(name string) error {
+	r = r.withDefaults()
+	fail := func(rule, message string, details map[string]interface{}) error {
+		if details == nil {
+			details = make(map[string]interface{})
+		}
+		details["name"] = name
+		details["rule"] = rule
+		return NewValidationError(message, details)
+	}
+
+	if name == "" {
+		return fail("required", "Agent name is required", nil)
+	}
+	first, _ := utf8.DecodeRuneInString(name)
+	if unicode.IsSpace(first) {
+		return fail("leading_whitespace", "Agent name has leading whitespace", nil)
+	}
+	last, _ := utf8.DecodeLastRuneInString(name)
+	if unicode.IsSpace(last) {
+		return fail("trailing_whitespace", "Agent name has trailing whitespace", nil)
+	}
+
+	length := 0
+	var previous rune
+	for _, ch := range name {
+		length++
+		if !r.allows(ch, previous) {
+			return fail("invalid_character",
+				fmt.Sprintf("Agent name has invalid character %q at position %d", ch, length),
+				map[string]interface{}{"position": length, "character": string(ch)})
+		}
+		previous = ch
+	}
+
+	if r.MinLength > 0 && length < r.MinLength {
+		return fail("too_short",
+			fmt.Sprintf("Agent name is too short: %d characters, at least %d are required", length, r.MinLength),
+			map[string]interface{}{"length": length, "min_length": r.MinLength})
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		return fail("too_long",
+			fmt.Sprintf("Agent name is too long: %d characters, at most %d are allowed", length, r.MaxLength),
+			map[string]interface{}{"length": length, "max_length": r.MaxLength})
+	}
+	return nil
+}
+
+// allows reports whether ch may appear in a name after previous. Combining
+// marks count as part of the letter they follow, so names in decomposed
+// form or in scripts such as Devanagari pass.
+func (r NameRules) allows(ch, previous rune) bool {
+	switch {
+	case unicode.IsLetter(ch), unicode.IsDigit(ch), ch == '-', ch == ' ':
+		return true
+	case unicode.Is(unicode.M, ch):
+		return unicode.IsLetter(previous) || unicode.Is(unicode.M, previous)
+	}
+	return strings.ContainsRune(r.ExtraCharacters, ch)
+}
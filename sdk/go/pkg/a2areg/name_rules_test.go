@@ -0,0 +1,80 @@
+package a2areg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAgentName(t *testing.T) {
+	valid := []string{
+		"abc",
+		"Weather Agent",
+		"invoice-parser-2",
+		"Café Bot",
+		"Cafe\u0301 Bot", // decomposed é
+		"Мой агент",
+		"天气助手",
+		"हिन्दी सहायक",
+		"مساعد الطقس",
+		"Agent ٣", // Arabic-Indic digit
+		strings.Repeat("a", 64),
+		strings.Repeat("ж", 64),
+	}
+	for _, name := range valid {
+		assert.NoError(t, ValidateAgentName(name), "%q", name)
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		message  string
+		position int
+	}{
+		{"", "required", "Agent name is required", 0},
+		{" Weather", "leading_whitespace", "Agent name has leading whitespace", 0},
+		{"\tWeather", "leading_whitespace", "Agent name has leading whitespace", 0},
+		{"Weather ", "trailing_whitespace", "Agent name has trailing whitespace", 0},
+		{"Weather\u00a0", "trailing_whitespace", "Agent name has trailing whitespace", 0},
+		{"ab", "too_short", "Agent name is too short: 2 characters, at least 3 are required", 0},
+		{"天气", "too_short", "Agent name is too short: 2 characters, at least 3 are required", 0},
+		{strings.Repeat("a", 65), "too_long", "Agent name is too long: 65 characters, at most 64 are allowed", 0},
+		{"weather_agent", "invalid_character", `Agent name has invalid character '_' at position 8`, 8},
+		{"Weather\tAgent", "invalid_character", `Agent name has invalid character '\t' at position 8`, 8},
+		{"Agent.v2", "invalid_character", `Agent name has invalid character '.' at position 6`, 6},
+		{"Café!", "invalid_character", `Agent name has invalid character '!' at position 5`, 5},
+		{"Bot 🤖", "invalid_character", `Agent name has invalid character '🤖' at position 5`, 5},
+		{"\u0301abc", "invalid_character", "Agent name has invalid character '\u0301' at position 1", 1},
+	}
+	for _, tt := range tests {
+		err := ValidateAgentName(tt.name)
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr), "%q", tt.name)
+		assert.Equal(t, tt.message, validationErr.Message, "%q", tt.name)
+		assert.Equal(t, tt.rule, validationErr.Details["rule"], "%q", tt.name)
+		if tt.position > 0 {
+			assert.Equal(t, tt.position, validationErr.Details["position"], "%q", tt.name)
+		}
+	}
+}
+
+func TestNameRules_Custom(t *testing.T) {
+	rules := NameRules{MinLength: 1, MaxLength: -1, ExtraCharacters: "_."}
+	assert.NoError(t, rules.Validate("a"))
+	assert.NoError(t, rules.Validate("agent_v2.1"))
+	assert.NoError(t, rules.Validate(strings.Repeat("a", 200)))
+	assert.Error(t, rules.Validate("agent!"))
+
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "key", NameRules: NameRules{MaxLength: 8}})
+	agent := testPublishAgent()
+	agent.Name = "Long agent name"
+	err := client.ValidateAgent(agent)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Contains(t, err.Error(), "at most 8 are allowed")
+
+	agent.Name = "Short"
+	assert.NoError(t, client.ValidateAgent(agent))
+}
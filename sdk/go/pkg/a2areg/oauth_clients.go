@@ -0,0 +1,104 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuthClient is an OAuth client registered with the registry.
+type OAuthClient struct {
+	ClientID string `json:"client_id"`
+	// ClientSecret is only returned by RegisterOAuthClient and
+	// RotateOAuthClientSecret; the registry never discloses it again.
+	ClientSecret string     `json:"client_secret,omitempty"`
+	Name         string     `json:"name"`
+	Scopes       []string   `json:"scopes,omitempty"`
+	RedirectURIs []string   `json:"redirect_uris,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+}
+
+// RotateSecretOptions configures RotateOAuthClientSecret.
+type RotateSecretOptions struct {
+	// UpdateCredentials makes the client use the new secret from now on
+	// when the rotated OAuth client is the one it authenticates as. Its
+	// access token is dropped, so the next call authenticates with the new
+	// secret.
+	UpdateCredentials bool
+}
+
+// RegisterOAuthClient registers a new OAuth client. The returned client
+// carries its secret, which cannot be retrieved later.
+func (c *A2ARegClient) RegisterOAuthClient(ctx context.Context, name string, scopes, redirectURIs []string) (*OAuthClient, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, NewValidationError("OAuth client name is required", nil)
+	}
+	body := map[string]interface{}{"name": name, "scopes": scopes}
+	if len(redirectURIs) > 0 {
+		body["redirect_uris"] = redirectURIs
+	}
+	data, err := c.makeRequestContext(ctx, "POST", "/auth/clients", body, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOAuthClient(data)
+}
+
+// ListOAuthClients lists the registered OAuth clients, without their
+// secrets.
+func (c *A2ARegClient) ListOAuthClients(ctx context.Context) ([]OAuthClient, error) {
+	data, err := c.makeRequestContext(ctx, "GET", "/auth/clients", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var listing struct {
+		Clients []OAuthClient `json:"clients"`
+	}
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode OAuth clients response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	return listing.Clients, nil
+}
+
+// RotateOAuthClientSecret replaces an OAuth client's secret, invalidating
+// the old one. The returned client carries the new secret.
+func (c *A2ARegClient) RotateOAuthClientSecret(ctx context.Context, clientID string, opts RotateSecretOptions) (*OAuthClient, error) {
+	data, err := c.makeRequestContext(ctx, "POST", "/auth/clients/"+url.PathEscape(clientID)+"/rotate-secret", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := decodeOAuthClient(data)
+	if err != nil {
+		return nil, err
+	}
+	if opts.UpdateCredentials && clientID == c.clientID {
+		c.clientSecret.Set(client.ClientSecret)
+		c.tokens.mu.Lock()
+		c.tokens.accessToken.Wipe()
+		c.tokens.expiresAt = nil
+		c.tokens.mu.Unlock()
+	}
+	return client, nil
+}
+
+// DeleteOAuthClient deletes an OAuth client. Tokens already issued to it
+// stop working.
+func (c *A2ARegClient) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	_, err := c.makeRequestContext(ctx, "DELETE", "/auth/clients/"+url.PathEscape(clientID), nil, nil)
+	return err
+}
+
+// decodeOAuthClient decodes an OAuth client returned with its secret.
+func decodeOAuthClient(data []byte) (*OAuthClient, error) {
+	var client OAuthClient
+	if err := json.Unmarshal(data, &client); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode OAuth client response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if client.ClientID == "" || client.ClientSecret == "" {
+		return nil, withCode(NewA2AError("OAuth client response has no client_id or client_secret", nil), CodeDecodeFailed)
+	}
+	return &client, nil
+}
@@ -0,0 +1,109 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthClients_RegisterListDelete(t *testing.T) {
+	var bodies []map[string]interface{}
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case "POST":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			bodies = append(bodies, body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"client_id": "svc-1", "client_secret": "s3cret", "name": "ingest", "scopes": ["read", "write"]}`))
+		case "GET":
+			w.Write([]byte(`{"clients": [{"client_id": "svc-1", "name": "ingest", "scopes": ["read", "write"], "created_at": "2024-01-01T00:00:00Z"}]}`))
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "admin"})
+	ctx := context.Background()
+
+	registered, err := client.RegisterOAuthClient(ctx, " ingest ", []string{"read", "write"}, []string{"https://portal.example.com/callback"})
+	require.NoError(t, err)
+	assert.Equal(t, "svc-1", registered.ClientID)
+	assert.Equal(t, "s3cret", registered.ClientSecret)
+	assert.Equal(t, map[string]interface{}{
+		"name":          "ingest",
+		"scopes":        []interface{}{"read", "write"},
+		"redirect_uris": []interface{}{"https://portal.example.com/callback"},
+	}, bodies[0])
+
+	clients, err := client.ListOAuthClients(ctx)
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	assert.Equal(t, "ingest", clients[0].Name)
+	assert.Empty(t, clients[0].ClientSecret)
+	require.NotNil(t, clients[0].CreatedAt)
+
+	require.NoError(t, client.DeleteOAuthClient(ctx, "svc-1"))
+	assert.Equal(t, []string{"POST /auth/clients", "GET /auth/clients", "DELETE /auth/clients/svc-1"}, paths)
+
+	_, err = client.RegisterOAuthClient(ctx, " ", nil, nil)
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Len(t, paths, 3)
+}
+
+func TestOAuthClients_RotateSecretUpdatesCredentials(t *testing.T) {
+	var mu sync.Mutex
+	secret, tokens := "old-secret", 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/auth/oauth/token":
+			require.NoError(t, r.ParseForm())
+			if r.PostForm.Get("client_secret") != secret {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			tokens++
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token-" + secret, "expires_in": 3600})
+		case "/auth/clients/other/rotate-secret":
+			w.Write([]byte(`{"client_id": "other", "client_secret": "other-secret", "name": "export"}`))
+		case "/auth/clients/svc-1/rotate-secret":
+			assert.Equal(t, "POST", r.Method)
+			secret = "new-secret"
+			w.Write([]byte(`{"client_id": "svc-1", "client_secret": "new-secret", "name": "ingest"}`))
+		default:
+			assert.Equal(t, "Bearer token-"+secret, r.Header.Get("Authorization"))
+			w.Write([]byte(`{"status": "healthy"}`))
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, ClientID: "svc-1", ClientSecret: "old-secret"})
+	ctx := context.Background()
+
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+
+	// Rotating another client leaves the credentials alone.
+	_, err = client.RotateOAuthClientSecret(ctx, "other", RotateSecretOptions{UpdateCredentials: true})
+	require.NoError(t, err)
+	client.clientSecret.Use(func(s []byte) { assert.Equal(t, "old-secret", string(s)) })
+
+	rotated, err := client.RotateOAuthClientSecret(ctx, "svc-1", RotateSecretOptions{UpdateCredentials: true})
+	require.NoError(t, err)
+	assert.Equal(t, "new-secret", rotated.ClientSecret)
+	client.clientSecret.Use(func(s []byte) { assert.Equal(t, "new-secret", string(s)) })
+
+	_, err = client.Health(context.Background())
+	require.NoError(t, err, "the next call authenticates with the new secret")
+	assert.Equal(t, 2, tokens)
+}
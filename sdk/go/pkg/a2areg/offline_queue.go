@@ -0,0 +1,299 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultOfflineQueueTTL is how long a queued operation is kept when
+	// OfflineQueueTTL is not set.
+	DefaultOfflineQueueTTL = 24 * time.Hour
+	// DefaultOfflineRetryInterval is the delay between replay attempts when
+	// OfflineRetryInterval is not set.
+	DefaultOfflineRetryInterval = 30 * time.Second
+)
+
+// offlineQueueKeyPrefix namespaces queue entries, so the queue can share a
+// Store with the card cache.
+const offlineQueueKeyPrefix = "offline-queue/"
+
+// QueuedOperation is a mutating request waiting in the offline queue.
+type QueuedOperation struct {
+	Seq      uint64          `json:"seq"`
+	Method   string          `json:"method"`
+	Endpoint string          `json:"endpoint"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	AgentID  string          `json:"agent_id,omitempty"`
+	// ChangeReason, IdempotencyKey, Header and Query are the RequestOptions
+	// of the original call, sent again on replay.
+	ChangeReason   string            `json:"change_reason,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+	Header         http.Header       `json:"header,omitempty"`
+	Query          map[string]string `json:"query,omitempty"`
+	EnqueuedAt     time.Time         `json:"enqueued_at"`
+	ExpiresAt      time.Time         `json:"expires_at"`
+}
+
+// requestOptions returns the options op is replayed with.
+func (op QueuedOperation) requestOptions() requestOptions {
+	return requestOptions{
+		changeReason:   op.ChangeReason,
+		idempotencyKey: op.IdempotencyKey,
+		header:         op.Header,
+		query:          op.Query,
+	}
+}
+
+func (op QueuedOperation) key() string {
+	return fmt.Sprintf("%s%020d", offlineQueueKeyPrefix, op.Seq)
+}
+
+// offlineQueue holds failed mutations in a Store, ordered by sequence
+// number.
+type offlineQueue struct {
+	store     Store
+	ttl       time.Duration
+	retry     time.Duration
+	onDropped func(QueuedOperation, error)
+
+	mu      sync.Mutex // guards nextSeq, pending and running
+	nextSeq uint64
+	pending int
+	running bool // a worker goroutine is active
+
+	replayMu sync.Mutex // serializes replays
+}
+
+func newOfflineQueue(opts A2ARegClientOptions) *offlineQueue {
+	q := &offlineQueue{
+		store:     opts.OfflineQueue,
+		ttl:       opts.OfflineQueueTTL,
+		retry:     opts.OfflineRetryInterval,
+		onDropped: opts.OnDropped,
+		nextSeq:   1,
+	}
+	if q.store == nil {
+		q.store = NewMemoryStore(0)
+	}
+	if q.ttl == 0 {
+		q.ttl = DefaultOfflineQueueTTL
+	}
+	if q.retry == 0 {
+		q.retry = DefaultOfflineRetryInterval
+	}
+
+	// Resume numbering after entries left by a previous process.
+	for _, op := range q.operations() {
+		q.pending++
+		if op.Seq >= q.nextSeq {
+			q.nextSeq = op.Seq + 1
+		}
+	}
+	return q
+}
+
+func (q *offlineQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending
+}
+
+// operations returns the queued operations in order. Undecodable entries
+// are skipped.
+func (q *offlineQueue) operations() []QueuedOperation {
+	var ops []QueuedOperation
+	q.store.Range(func(key string, value []byte) bool {
+		if !strings.HasPrefix(key, offlineQueueKeyPrefix) {
+			return true
+		}
+		var op QueuedOperation
+		if json.Unmarshal(value, &op) == nil {
+			ops = append(ops, op)
+		}
+		return true
+	})
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Seq < ops[j].Seq })
+	return ops
+}
+
+func (q *offlineQueue) enqueue(now time.Time, method, endpoint, agentID string, options requestOptions, body interface{}) (QueuedOperation, error) {
+	op := QueuedOperation{
+		Method:         method,
+		Endpoint:       endpoint,
+		AgentID:        agentID,
+		ChangeReason:   options.changeReason,
+		IdempotencyKey: options.idempotencyKey,
+		Header:         options.header,
+		Query:          options.query,
+		EnqueuedAt:     now,
+		ExpiresAt:      now.Add(q.ttl),
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return op, err
+		}
+		op.Body = data
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	op.Seq = q.nextSeq
+	data, err := json.Marshal(op)
+	if err != nil {
+		return op, err
+	}
+	// Expiry is enforced on replay, so that OnDropped sees every drop.
+	if err := q.store.Set(op.key(), data, 0); err != nil {
+		return op, err
+	}
+	q.nextSeq++
+	q.pending++
+	return op, nil
+}
+
+func (q *offlineQueue) remove(op QueuedOperation) {
+	q.store.Delete(op.key())
+	q.mu.Lock()
+	q.pending--
+	q.mu.Unlock()
+}
+
+func (q *offlineQueue) drop(op QueuedOperation, err error) {
+	q.remove(op)
+	if q.onDropped != nil {
+		q.onDropped(op, err)
+	}
+}
+
+// queueable reports whether a failed mutation should be queued: the
+// registry was unreachable or failed server-side, and the caller did not
+// give up.
+func queueable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return ErrorCode(err) == CodeRequestFailed || StatusCode(err) >= 500
+}
+
+// sendMutation sends a mutating request, queueing it when the offline queue
+// is enabled and either the request fails with a queueable error or earlier
+// operations are still queued (so that replay preserves call order).
+func (c *A2ARegClient) sendMutation(ctx context.Context, method, endpoint, agentID string, body interface{}) (*apiResponse, error) {
+	// A failed mutation may still have been applied, so searches are
+	// invalidated whatever the outcome.
+	defer c.invalidateSearches()
+	if c.queue == nil {
+		return c.send(ctx, method, endpoint, body, nil)
+	}
+
+	var cause error
+	if c.queue.len() == 0 {
+		resp, err := c.send(ctx, method, endpoint, body, nil)
+		if err == nil || !queueable(ctx, err) {
+			return resp, err
+		}
+		cause = err
+	}
+
+	op, err := c.queue.enqueue(c.clock.Now(), method, endpoint, agentID, requestOptionsFrom(ctx), body)
+	if err != nil {
+		if cause != nil {
+			return nil, cause
+		}
+		return nil, withCode(NewA2AError("Failed to queue operation", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	c.startQueueWorker()
+	return nil, NewQueuedError(op, cause)
+}
+
+// OfflineQueueLen returns the number of operations waiting in the offline
+// queue, or 0 if the queue is disabled.
+func (c *A2ARegClient) OfflineQueueLen() int {
+	if c.queue == nil {
+		return 0
+	}
+	return c.queue.len()
+}
+
+// FlushOfflineQueue replays queued operations now, in order, without
+// waiting for the background worker. It stops at the first operation that
+// still cannot reach the registry and returns that error; operations the
+// registry rejects or that have expired are dropped and reported to
+// OnDropped.
+func (c *A2ARegClient) FlushOfflineQueue(ctx context.Context) error {
+	if c.queue == nil {
+		return nil
+	}
+	q := c.queue
+	q.replayMu.Lock()
+	defer q.replayMu.Unlock()
+
+	for _, op := range q.operations() {
+		if !c.clock.Now().Before(op.ExpiresAt) {
+			q.drop(op, withCode(NewA2AError(fmt.Sprintf("Queued %s %s expired", op.Method, op.Endpoint), nil), CodeQueueExpired))
+			continue
+		}
+
+		var body interface{}
+		if len(op.Body) > 0 {
+			body = op.Body
+		}
+		opCtx := context.WithValue(ctx, requestOptionsKey{}, op.requestOptions())
+		_, err := c.send(opCtx, op.Method, op.Endpoint, body, nil)
+		if op.AgentID != "" {
+			c.invalidateAgent(op.AgentID)
+		}
+		c.invalidateSearches()
+		switch {
+		case err == nil:
+			q.remove(op)
+		case queueable(ctx, err):
+			return err
+		default:
+			q.drop(op, err)
+		}
+	}
+	return nil
+}
+
+// startQueueWorker starts the background replay loop unless one is running.
+// The worker exits once the queue is empty or the client is closed.
+func (c *A2ARegClient) startQueueWorker() {
+	q := c.queue
+	q.mu.Lock()
+	if q.running {
+		q.mu.Unlock()
+		return
+	}
+	q.running = true
+	q.mu.Unlock()
+
+	go func() {
+		for {
+			delay := q.retry
+			if remaining := c.maintenanceRemaining(); remaining > delay {
+				delay = remaining
+			}
+			<-c.clock.After(delay)
+			if !c.closed.Load() {
+				c.FlushOfflineQueue(context.Background())
+			}
+
+			q.mu.Lock()
+			if q.pending == 0 || c.closed.Load() {
+				q.running = false
+				q.mu.Unlock()
+				return
+			}
+			q.mu.Unlock()
+		}
+	}()
+}
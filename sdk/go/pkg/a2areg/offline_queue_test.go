@@ -0,0 +1,208 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyRegistry answers 503 while down is set and otherwise records each
+// mutating request it accepts.
+type flakyRegistry struct {
+	*httptest.Server
+	down   atomic.Bool
+	reject atomic.Bool
+
+	mu       sync.Mutex
+	accepted []string
+}
+
+func newFlakyRegistry(t *testing.T) *flakyRegistry {
+	r := &flakyRegistry{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.reject.Load() {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{"detail": "bad agent"})
+			return
+		}
+		r.mu.Lock()
+		r.accepted = append(r.accepted, req.Method+" "+req.URL.Path)
+		r.mu.Unlock()
+		switch {
+		case req.Method == "POST":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "agent-1", "name": "Recipe Agent"})
+		case req.Method == "PUT":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "agent-1"})
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *flakyRegistry) requests() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.accepted...)
+}
+
+func queueClient(registry *flakyRegistry, fake *clock.Fake, store Store, onDropped func(QueuedOperation, error)) *A2ARegClient {
+	return NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:        registry.URL,
+		APIKey:             "test",
+		Clock:              fake,
+		EnableOfflineQueue: true,
+		OfflineQueue:       store,
+		OfflineQueueTTL:    time.Hour,
+		OnDropped:          onDropped,
+	})
+}
+
+func TestOfflineQueue_OrderedReplay(t *testing.T) {
+	registry := newFlakyRegistry(t)
+	client := queueClient(registry, clock.NewFake(time.Now()), nil, nil)
+
+	registry.down.Store(true)
+	_, err := client.PublishAgent(testPublishAgent(), false)
+	var queued *QueuedError
+	require.ErrorAs(t, err, &queued)
+	assert.Equal(t, CodeQueued, ErrorCode(err))
+	assert.Equal(t, http.StatusServiceUnavailable, StatusCode(queued.Err))
+
+	// Later mutations queue behind the first, even once the registry is back.
+	registry.down.Store(false)
+	_, err = client.UpdateAgent("agent-1", &Agent{Name: "Renamed"})
+	require.ErrorAs(t, err, &queued)
+	assert.Nil(t, queued.Err)
+	require.ErrorAs(t, client.DeleteAgent("agent-1"), &queued)
+	assert.Equal(t, 3, client.OfflineQueueLen())
+	assert.Empty(t, registry.requests())
+
+	require.NoError(t, client.FlushOfflineQueue(context.Background()))
+	assert.Equal(t, []string{"POST /agents/publish", "PUT /agents/agent-1", "DELETE /agents/agent-1"}, registry.requests())
+	assert.Equal(t, 0, client.OfflineQueueLen())
+
+	// With the queue drained, calls go straight through again.
+	_, err = client.PublishAgent(testPublishAgent(), false)
+	require.NoError(t, err)
+}
+
+func TestOfflineQueue_FlushStopsWhileDown(t *testing.T) {
+	registry := newFlakyRegistry(t)
+	client := queueClient(registry, clock.NewFake(time.Now()), nil, nil)
+
+	registry.down.Store(true)
+	client.DeleteAgent("agent-1")
+	client.DeleteAgent("agent-2")
+
+	err := client.FlushOfflineQueue(context.Background())
+	assert.Equal(t, CodeServerError, ErrorCode(err))
+	assert.Equal(t, 2, client.OfflineQueueLen())
+}
+
+func TestOfflineQueue_NotQueuedOnClientErrors(t *testing.T) {
+	registry := newFlakyRegistry(t)
+	registry.reject.Store(true)
+	client := queueClient(registry, clock.NewFake(time.Now()), nil, nil)
+
+	_, err := client.PublishAgent(testPublishAgent(), false)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Equal(t, 0, client.OfflineQueueLen())
+}
+
+func TestOfflineQueue_BackgroundReplay(t *testing.T) {
+	registry := newFlakyRegistry(t)
+	fake := clock.NewFake(time.Now())
+	client := queueClient(registry, fake, nil, nil)
+
+	registry.down.Store(true)
+	client.DeleteAgent("agent-1")
+	registry.down.Store(false)
+
+	fake.BlockUntil(1)
+	fake.Advance(DefaultOfflineRetryInterval)
+	assert.Eventually(t, func() bool { return client.OfflineQueueLen() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"DELETE /agents/agent-1"}, registry.requests())
+}
+
+func TestOfflineQueue_TTLExpiry(t *testing.T) {
+	registry := newFlakyRegistry(t)
+	fake := clock.NewFake(time.Now())
+	dropped := make(chan error, 1)
+	client := queueClient(registry, fake, nil, func(op QueuedOperation, err error) {
+		assert.Equal(t, "/agents/agent-1", op.Endpoint)
+		dropped <- err
+	})
+
+	registry.down.Store(true)
+	client.DeleteAgent("agent-1")
+	registry.down.Store(false)
+
+	fake.Advance(2 * time.Hour)
+	require.NoError(t, client.FlushOfflineQueue(context.Background()))
+
+	select {
+	case err := <-dropped:
+		assert.Equal(t, CodeQueueExpired, ErrorCode(err))
+	case <-time.After(time.Second):
+		t.Fatal("OnDropped not called")
+	}
+	assert.Equal(t, 0, client.OfflineQueueLen())
+	assert.Empty(t, registry.requests())
+}
+
+func TestOfflineQueue_DropsRejectedOnReplay(t *testing.T) {
+	registry := newFlakyRegistry(t)
+	var dropped []error
+	client := queueClient(registry, clock.NewFake(time.Now()), nil, func(op QueuedOperation, err error) {
+		dropped = append(dropped, err)
+	})
+
+	registry.down.Store(true)
+	client.DeleteAgent("agent-1")
+	registry.down.Store(false)
+	registry.reject.Store(true)
+
+	require.NoError(t, client.FlushOfflineQueue(context.Background()))
+	require.Len(t, dropped, 1)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(dropped[0]))
+}
+
+func TestOfflineQueue_SurvivesRestart(t *testing.T) {
+	registry := newFlakyRegistry(t)
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir, FileStoreOptions{})
+	require.NoError(t, err)
+	client := queueClient(registry, clock.NewFake(time.Now()), store, nil)
+	registry.down.Store(true)
+	client.PublishAgent(testPublishAgent(), false)
+	client.DeleteAgent("agent-1")
+	require.Equal(t, 2, client.OfflineQueueLen())
+
+	registry.down.Store(false)
+	store, err = NewFileStore(dir, FileStoreOptions{})
+	require.NoError(t, err)
+	restarted := queueClient(registry, clock.NewFake(time.Now()), store, nil)
+	assert.Equal(t, 2, restarted.OfflineQueueLen())
+
+	// New operations continue the sequence after the recovered ones.
+	restarted.DeleteAgent("agent-2")
+	require.NoError(t, restarted.FlushOfflineQueue(context.Background()))
+	assert.Equal(t, []string{"POST /agents/publish", "DELETE /agents/agent-1", "DELETE /agents/agent-2"}, registry.requests())
+}
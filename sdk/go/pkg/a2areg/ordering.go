@@ -0,0 +1,150 @@
+package a2areg
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SortField names an agent attribute to order listings by.
+type SortField string
+
+const (
+	SortByName     SortField = "name"
+	SortByProvider SortField = "provider"
+	SortByVersion  SortField = "version"
+	SortByCreated  SortField = "created_at"
+	SortByUpdated  SortField = "updated_at"
+)
+
+// SortOrder is the direction of a sort.
+type SortOrder string
+
+const (
+	OrderAsc  SortOrder = "asc"
+	OrderDesc SortOrder = "desc"
+)
+
+// ListOrder selects the ordering of a listing. The zero value is the default
+// ordering: name ascending, then provider ascending, then version descending
+// (newest first). When Sort is set, it becomes the primary key and the
+// default ordering breaks ties. Agent ID is always the final tie-breaker, so
+// the order is fully determined by the set of agents, not by the order in
+// which pages or sources arrived.
+type ListOrder struct {
+	Sort  SortField
+	Order SortOrder // defaults to OrderAsc
+}
+
+// SortAgents sorts agents in place by order.
+func SortAgents(agents []Agent, order ListOrder) {
+	sort.SliceStable(agents, func(i, j int) bool {
+		return compareAgents(&agents[i], &agents[j], order) < 0
+	})
+}
+
+// MergeAgents concatenates agents from several pages or sources and sorts
+// the result by order. The inputs are not modified.
+func MergeAgents(order ListOrder, sources ...[]Agent) []Agent {
+	n := 0
+	for _, source := range sources {
+		n += len(source)
+	}
+	merged := make([]Agent, 0, n)
+	for _, source := range sources {
+		merged = append(merged, source...)
+	}
+	SortAgents(merged, order)
+	return merged
+}
+
+func compareAgents(a, b *Agent, order ListOrder) int {
+	if order.Sort != "" {
+		c := compareField(a, b, order.Sort)
+		if order.Order == OrderDesc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	if c := compareField(a, b, SortByName); c != 0 {
+		return c
+	}
+	if c := compareField(a, b, SortByProvider); c != 0 {
+		return c
+	}
+	if c := compareField(a, b, SortByVersion); c != 0 {
+		return -c
+	}
+	return strings.Compare(getStringValue(a.ID, ""), getStringValue(b.ID, ""))
+}
+
+// compareField compares one attribute in ascending order. Names and
+// providers compare case-insensitively, falling back to exact comparison.
+// Missing timestamps sort first.
+func compareField(a, b *Agent, field SortField) int {
+	switch field {
+	case SortByName:
+		return compareFold(a.Name, b.Name)
+	case SortByProvider:
+		return compareFold(a.ProviderName(), b.ProviderName())
+	case SortByVersion:
+		return compareVersions(a.Version, b.Version)
+	case SortByCreated, SortByUpdated:
+		ta, tb := a.CreatedAt, b.CreatedAt
+		if field == SortByUpdated {
+			ta, tb = a.UpdatedAt, b.UpdatedAt
+		}
+		switch {
+		case ta == nil && tb == nil:
+			return 0
+		case ta == nil:
+			return -1
+		case tb == nil:
+			return 1
+		}
+		return ta.Compare(*tb)
+	}
+	return 0
+}
+
+func compareFold(a, b string) int {
+	if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// compareVersions compares dotted version strings component by component,
+// numerically where both components are numbers ("1.10.0" > "1.9.0"). A
+// leading "v" is ignored.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		switch {
+		case xerr == nil && yerr == nil:
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+		default:
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
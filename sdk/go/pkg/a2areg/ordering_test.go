@@ -0,0 +1,103 @@
+package a2areg
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func orderingAgent(id, name, provider, version string) Agent {
+	return Agent{ID: &id, Name: name, Provider: provider, Version: version}
+}
+
+func agentIDs(agents []Agent) []string {
+	ids := make([]string, len(agents))
+	for i := range agents {
+		ids[i] = *agents[i].ID
+	}
+	return ids
+}
+
+func TestSortAgents_DefaultOrder(t *testing.T) {
+	agents := []Agent{
+		orderingAgent("5", "weather", "acme", "1.0.0"),
+		orderingAgent("4", "Alpha", "zeta", "1.0.0"),
+		orderingAgent("3", "alpha", "acme", "1.9.0"),
+		orderingAgent("2", "alpha", "acme", "1.10.0"),
+		orderingAgent("1", "alpha", "acme", "1.10.0"),
+	}
+
+	SortAgents(agents, ListOrder{})
+	// "Alpha" and "alpha" tie case-insensitively and are split by the exact
+	// name; equal name, provider and version fall back to the ID.
+	assert.Equal(t, []string{"4", "1", "2", "3", "5"}, agentIDs(agents))
+}
+
+func TestSortAgents_ExplicitOrder(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	agents := []Agent{
+		orderingAgent("a", "one", "p", "1.0.0"),
+		orderingAgent("b", "two", "p", "1.0.0"),
+		orderingAgent("c", "three", "p", "1.0.0"),
+	}
+	agents[0].UpdatedAt = &older
+	agents[1].UpdatedAt = &newer
+
+	SortAgents(agents, ListOrder{Sort: SortByUpdated, Order: OrderDesc})
+	assert.Equal(t, []string{"b", "a", "c"}, agentIDs(agents))
+
+	SortAgents(agents, ListOrder{Sort: SortByVersion})
+	assert.Equal(t, []string{"a", "c", "b"}, agentIDs(agents), "ties fall back to the default order")
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 1, compareVersions("1.10.0", "1.9.0"))
+	assert.Equal(t, 0, compareVersions("v1.2", "1.2"))
+	assert.Equal(t, -1, compareVersions("1.2", "1.2.1"))
+	assert.Equal(t, -1, compareVersions("1.0.0-beta", "1.0.0-rc"))
+}
+
+func TestMergeAgents_IndependentOfArrivalOrder(t *testing.T) {
+	names := []string{"alpha", "Beta", "beta", "gamma", "delta"}
+	providers := []string{"acme", "globex", "initech"}
+	versions := []string{"1.0.0", "1.2.0", "2.0.0", "1.10.0"}
+
+	rng := rand.New(rand.NewSource(1))
+	var corpus []Agent
+	for i := 0; i < 60; i++ {
+		corpus = append(corpus, orderingAgent(
+			string(rune('A'+i%26))+string(rune('a'+i/26)),
+			names[rng.Intn(len(names))],
+			providers[rng.Intn(len(providers))],
+			versions[rng.Intn(len(versions))],
+		))
+	}
+
+	for _, order := range []ListOrder{{}, {Sort: SortByProvider, Order: OrderDesc}, {Sort: SortByVersion}} {
+		want := agentIDs(MergeAgents(order, corpus))
+
+		for trial := 0; trial < 50; trial++ {
+			shuffled := append([]Agent(nil), corpus...)
+			rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+			// Split into randomly sized pages, delivered in random order.
+			var pages [][]Agent
+			for len(shuffled) > 0 {
+				n := 1 + rng.Intn(15)
+				if n > len(shuffled) {
+					n = len(shuffled)
+				}
+				pages = append(pages, shuffled[:n])
+				shuffled = shuffled[n:]
+			}
+			rng.Shuffle(len(pages), func(i, j int) { pages[i], pages[j] = pages[j], pages[i] })
+
+			got := MergeAgents(order, pages...)
+			require.Equal(t, want, agentIDs(got), "order %+v, trial %d", order, trial)
+		}
+	}
+}
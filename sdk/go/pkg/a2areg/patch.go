@@ -0,0 +1,121 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// MergePatchContentType is the media type of RFC 7396 JSON merge patches.
+const MergePatchContentType = "application/merge-patch+json"
+
+// AgentPatch is a partial agent update for PatchAgent. Only the fields that
+// are set are sent; the registry leaves every other field as it is.
+type AgentPatch struct {
+	Name        *string       `json:"name,omitempty"`
+	Description *string       `json:"description,omitempty"`
+	Version     *string       `json:"version,omitempty"`
+	Provider    *string       `json:"provider,omitempty"`
+	Tags        *[]string     `json:"tags,omitempty"`
+	IsPublic    *bool         `json:"is_public,omitempty"`
+	Visibility  *Visibility   `json:"visibility,omitempty"`
+	IsActive    *bool         `json:"is_active,omitempty"`
+	LocationURL *string       `json:"location_url,omitempty"`
+	Skills      *[]AgentSkill `json:"skills,omitempty"`
+	Region      *string       `json:"region,omitempty"`
+	// Labels are merged into the agent's labels: a label set to nil is
+	// removed, and labels not listed are kept.
+	Labels map[string]*string `json:"labels,omitempty"`
+	// ETag, when set, makes the patch conditional on the agent being
+	// unchanged since it was read with that ETag; see UpdateAgent.
+	ETag string `json:"-"`
+}
+
+// PatchAgent updates only the fields set in patch, leaving the rest of the
+// agent as stored, so concurrent updates of different fields do not undo
+// each other. The patch is sent as an RFC 7396 merge patch with PATCH
+// /agents/{id}. Registries that refuse PATCH get the agent read, patched
+// and written back with PUT, which narrows the window for lost updates
+// without closing it.
+//
+// Registries without visibility support receive a Visibility as is_public,
+// as with UpdateAgent. A patch with an ETag, and the PUT of the fallback,
+// are sent with If-Match, giving a *ConflictError if the agent changed.
+func (c *A2ARegClient) PatchAgent(ctx context.Context, agentID string, patch AgentPatch) (*Agent, error) {
+	if patch.Visibility != nil {
+		if err := checkVisibility(*patch.Visibility); err != nil {
+			return nil, err
+		}
+		if !c.supports(ctx, FeatureVisibility) {
+			public := *patch.Visibility == VisibilityPublic
+			patch.IsPublic = &public
+			patch.Visibility = nil
+		}
+	}
+	document, err := json.Marshal(patch)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to encode agent patch", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+
+	if !c.patchUnavailable.Load() {
+		patchCtx := withRequestOptions(ifMatch(ctx, patch.ETag), []RequestOption{WithHeader("Content-Type", MergePatchContentType)})
+		resp, err := c.sendMutation(patchCtx, "PATCH", "/agents/"+agentID, agentID, json.RawMessage(document))
+		c.invalidateAgent(agentID)
+		if err == nil {
+			return decodeUpdatedAgent(resp)
+		}
+		if status := StatusCode(err); status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			return nil, agentNotFound(err)
+		}
+		c.patchUnavailable.Store(true)
+	}
+
+	read, err := c.getAgentResponse(ctx, agentID, nil)
+	if err != nil {
+		return nil, err
+	}
+	etag := patch.ETag
+	if etag == "" {
+		etag = read.header.Get("ETag")
+	}
+	var stored, changes interface{}
+	if err := json.Unmarshal(read.body, &stored); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if err := json.Unmarshal(document, &changes); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode agent patch", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	merged, err := json.Marshal(mergePatch(stored, changes))
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to encode agent", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+
+	resp, err := c.sendMutation(ifMatch(ctx, etag), "PUT", "/agents/"+agentID, agentID, json.RawMessage(merged))
+	c.invalidateAgent(agentID)
+	if err != nil {
+		return nil, agentNotFound(err)
+	}
+	return decodeUpdatedAgent(resp)
+}
+
+// mergePatch applies an RFC 7396 merge patch to target, both decoded JSON
+// values: objects are merged member by member, a null member removes it,
+// and any other patch value replaces the target outright.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
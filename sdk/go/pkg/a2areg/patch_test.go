@@ -0,0 +1,173 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const patchStoredAgentJSON = `{
+	"id": "agent-1",
+	"name": "Recipe Agent",
+	"description": "Finds recipes",
+	"version": "1.0.0",
+	"provider": "acme",
+	"tags": ["cooking"],
+	"is_public": true,
+	"is_active": true,
+	"labels": {"team": "kitchen", "tier": "gold"}
+}`
+
+// patchRequest is a request received by a patch test server.
+type patchRequest struct {
+	method      string
+	contentType string
+	body        map[string]interface{}
+}
+
+// patchRegistry serves agent-1. It accepts PATCH unless allowPatch is
+// false, when it answers 405, and echoes PATCH and PUT bodies back.
+func patchRegistry(t *testing.T, allowPatch bool) (*httptest.Server, *[]patchRequest) {
+	var requests []patchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := patchRequest{method: r.Method, contentType: r.Header.Get("Content-Type")}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			require.NoError(t, json.Unmarshal(data, &req.body))
+		}
+		requests = append(requests, req)
+		if r.URL.Path != "/agents/agent-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			w.Write([]byte(patchStoredAgentJSON))
+		case "PATCH":
+			if !allowPatch {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			var stored map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(patchStoredAgentJSON), &stored))
+			json.NewEncoder(w).Encode(mergePatch(stored, req.body))
+		case "PUT":
+			json.NewEncoder(w).Encode(req.body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestPatchAgent_SendsOnlySetFields(t *testing.T) {
+	server, requests := patchRegistry(t, true)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	description := "Finds and ranks recipes"
+	private := false
+	agent, err := client.PatchAgent(context.Background(), "agent-1", AgentPatch{Description: &description, IsPublic: &private})
+	require.NoError(t, err)
+	assert.Equal(t, "Finds and ranks recipes", agent.Description)
+	assert.False(t, agent.IsPublic)
+	assert.Equal(t, "Recipe Agent", agent.Name)
+	assert.Equal(t, []string{"cooking"}, agent.Tags)
+
+	require.Len(t, *requests, 1)
+	sent := (*requests)[0]
+	assert.Equal(t, "PATCH", sent.method)
+	assert.Equal(t, MergePatchContentType, sent.contentType)
+	assert.Equal(t, map[string]interface{}{"description": "Finds and ranks recipes", "is_public": false}, sent.body)
+}
+
+func TestAgentPatch_UnsetFieldsAreOmitted(t *testing.T) {
+	data, err := json.Marshal(AgentPatch{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(data))
+
+	// Zero values that are set are sent; nil label values remove labels.
+	empty, none := "", []string{}
+	data, err = json.Marshal(AgentPatch{Region: &empty, Tags: &none, Labels: map[string]*string{"tier": nil}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"region": "", "tags": [], "labels": {"tier": null}}`, string(data))
+}
+
+func TestPatchAgent_PutFallback(t *testing.T) {
+	server, requests := patchRegistry(t, false)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	version, platinum := "1.1.0", "platinum"
+	patch := AgentPatch{Version: &version, Labels: map[string]*string{"tier": &platinum, "team": nil}}
+	agent, err := client.PatchAgent(context.Background(), "agent-1", patch)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", agent.Version)
+	assert.Equal(t, map[string]string{"tier": "platinum"}, agent.Labels)
+
+	var methods []string
+	for _, req := range *requests {
+		methods = append(methods, req.method)
+	}
+	assert.Equal(t, []string{"PATCH", "GET", "PUT"}, methods)
+	put := (*requests)[2]
+	assert.Equal(t, "application/json", put.contentType)
+	assert.Equal(t, "Recipe Agent", put.body["name"], "fields not in the patch are written back as read")
+	assert.Equal(t, []interface{}{"cooking"}, put.body["tags"])
+	assert.Equal(t, true, put.body["is_public"])
+
+	// The refused PATCH is not tried again.
+	*requests = nil
+	_, err = client.PatchAgent(context.Background(), "agent-1", patch)
+	require.NoError(t, err)
+	require.Len(t, *requests, 2)
+	assert.Equal(t, "GET", (*requests)[0].method)
+}
+
+func TestPatchAgent_Errors(t *testing.T) {
+	server, requests := patchRegistry(t, true)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	name := "Renamed"
+	_, err := client.PatchAgent(context.Background(), "missing", AgentPatch{Name: &name})
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+
+	hidden := Visibility("hidden")
+	_, err = client.PatchAgent(context.Background(), "agent-1", AgentPatch{Visibility: &hidden})
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Len(t, *requests, 1, "an invalid visibility is not sent")
+}
+
+func TestMergePatch_RFC7396Examples(t *testing.T) {
+	// The examples of RFC 7396, Appendix A.
+	tests := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			var target, patch interface{}
+			require.NoError(t, json.NewDecoder(strings.NewReader(tt.target)).Decode(&target))
+			require.NoError(t, json.NewDecoder(strings.NewReader(tt.patch)).Decode(&patch))
+			got, err := json.Marshal(mergePatch(target, patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package a2areg
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// PlanFormat is an output format of SyncPlan.ExportPlan.
+type PlanFormat string
+
+// Formats ExportPlan writes.
+const (
+	// PlanFormatJSON is the plan as MarshalStable JSON.
+	PlanFormatJSON PlanFormat = "json"
+	// PlanFormatText is one line per agent followed by a summary, for
+	// reading in a terminal or a pull request.
+	PlanFormatText PlanFormat = "text"
+)
+
+// ExportOption adjusts SyncPlan.ExportPlan.
+type ExportOption func(*exportOptions)
+
+type exportOptions struct {
+	timestamps bool
+}
+
+// ExportTimestamps keeps the agents' CreatedAt and UpdatedAt in exported
+// plans, in UTC. They are left out by default: they describe the source
+// registry, not the migration, and change the output between runs.
+func ExportTimestamps() ExportOption {
+	return func(o *exportOptions) {
+		o.timestamps = true
+	}
+}
+
+// syncActionOrder ranks actions in exported plans.
+var syncActionOrder = map[SyncAction]int{SyncCreate: 0, SyncUpdate: 1, SyncUnchanged: 2}
+
+// sortSyncItems orders items by action, then agent name, provider and
+// destination ID, so that plans over the same agents list them the same
+// way however they were built.
+func sortSyncItems(items []SyncItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if ra, rb := syncActionOrder[a.Action], syncActionOrder[b.Action]; ra != rb {
+			return ra < rb
+		}
+		if a.Action != b.Action {
+			return a.Action < b.Action
+		}
+		if an, bn := syncItemName(a), syncItemName(b); an != bn {
+			return an < bn
+		}
+		if ap, bp := syncItemProvider(a), syncItemProvider(b); ap != bp {
+			return ap < bp
+		}
+		return a.DestID < b.DestID
+	})
+}
+
+func syncItemName(item SyncItem) string {
+	if item.Agent == nil {
+		return ""
+	}
+	return item.Agent.Name
+}
+
+func syncItemProvider(item SyncItem) string {
+	if item.Agent == nil {
+		return ""
+	}
+	return item.Agent.ProviderName()
+}
+
+// ExportPlan writes the plan in format for review. Equal plans give
+// byte-identical output whatever order their items and notes were built
+// in: items are ordered by action (create, update, unchanged), agent name
+// and provider, notes are sorted, and timestamps are left out unless
+// ExportTimestamps is given.
+func (p *SyncPlan) ExportPlan(w io.Writer, format PlanFormat, opts ...ExportOption) error {
+	var o exportOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	plan := p.normalized(o)
+
+	var out []byte
+	switch format {
+	case PlanFormatJSON:
+		data, err := MarshalStable(plan)
+		if err != nil {
+			return err
+		}
+		out = data
+	case PlanFormatText:
+		out = []byte(plan.text())
+	default:
+		return NewValidationError("Unknown plan format: "+string(format), map[string]interface{}{"format": string(format)})
+	}
+	if _, err := w.Write(out); err != nil {
+		return withCode(NewA2AError("Failed to write plan", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	return nil
+}
+
+// normalized returns a copy of p in export order, with timestamps removed
+// or converted to UTC as o asks.
+func (p *SyncPlan) normalized(o exportOptions) *SyncPlan {
+	plan := &SyncPlan{
+		Items:       make([]SyncItem, len(p.Items)),
+		NotMigrated: append([]string(nil), p.NotMigrated...),
+	}
+	for i, item := range p.Items {
+		if item.Agent != nil {
+			agent := *item.Agent
+			if o.timestamps {
+				if agent.CreatedAt != nil {
+					created := agent.CreatedAt.UTC()
+					agent.CreatedAt = &created
+				}
+				if agent.UpdatedAt != nil {
+					updated := agent.UpdatedAt.UTC()
+					agent.UpdatedAt = &updated
+				}
+			} else {
+				agent.CreatedAt, agent.UpdatedAt = nil, nil
+			}
+			item.Agent = &agent
+		}
+		plan.Items[i] = item
+	}
+	sortSyncItems(plan.Items)
+	sort.Strings(plan.NotMigrated)
+	return plan
+}
+
+// text renders p in PlanFormatText.
+func (p *SyncPlan) text() string {
+	var b strings.Builder
+	counts := make(map[SyncAction]int)
+	for _, item := range p.Items {
+		counts[item.Action]++
+		fmt.Fprintf(&b, "%-9s %s: %q by %q", item.Action, item.DestID, syncItemName(item), syncItemProvider(item))
+		if item.SourceID != "" {
+			fmt.Fprintf(&b, " (source %s)", item.SourceID)
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "%d agents: %d create, %d update, %d unchanged\n", len(p.Items), counts[SyncCreate], counts[SyncUpdate], counts[SyncUnchanged])
+	if len(p.NotMigrated) > 0 {
+		b.WriteString("not migrated:\n")
+		for _, note := range p.NotMigrated {
+			fmt.Fprintf(&b, "  - %s\n", note)
+		}
+	}
+	return b.String()
+}
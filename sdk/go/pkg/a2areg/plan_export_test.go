@@ -0,0 +1,128 @@
+package a2areg
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// assertGolden compares got with testdata/golden/name, rewriting the file
+// instead when the tests run with -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+// goldenPlanItems are the items of the golden plan, in no particular order.
+func goldenPlanItems(zone *time.Location) []SyncItem {
+	created := time.Date(2025, 1, 15, 9, 30, 0, 0, time.UTC).In(zone)
+	return []SyncItem{
+		{SourceID: "agent-7", DestID: "acme--weather-agent", Action: SyncUnchanged, Agent: &Agent{
+			Name: "Weather Agent", Description: "Forecasts", Version: "2.0.0", Provider: "acme", IsPublic: true, Visibility: VisibilityPublic,
+			Labels: map[string]string{"tier": "gold", "team": "weather"}, CreatedAt: &created,
+		}},
+		{SourceID: "agent-2", DestID: "acme--recipe-agent", Action: SyncCreate, Agent: &Agent{
+			Name: "Recipe Agent", Description: "Finds <recipes> & more", Version: "1.0.0", Provider: "acme", Visibility: VisibilityPrivate,
+			Skills:    []AgentSkill{{ID: "s1", Name: "Search", Description: "Search recipes", Tags: []string{"cooking"}}},
+			CreatedAt: &created, UpdatedAt: &created,
+		}},
+		{SourceID: "agent-3", DestID: "globex--recipe-agent", Action: SyncCreate, Agent: &Agent{
+			Name: "Recipe Agent", Description: "Globex recipes", Version: "0.3.0", Provider: "globex", Visibility: VisibilityPrivate,
+		}},
+		{SourceID: "agent-1", DestID: "acme--ledger", Action: SyncUpdate, Agent: &Agent{
+			Name: "Ledger", Description: "Back office", Version: "0.1.0", Provider: "acme", Visibility: VisibilityPrivate,
+			UpdatedAt: &created,
+		}},
+	}
+}
+
+// goldenPlans returns the golden plan built twice, with items and notes in
+// different orders and timestamps in different zones.
+func goldenPlans() (*SyncPlan, *SyncPlan) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		tokyo = time.FixedZone("JST", 9*60*60)
+	}
+	first := &SyncPlan{Items: goldenPlanItems(time.UTC), NotMigrated: append([]string(nil), notMigrated...)}
+
+	items := goldenPlanItems(tokyo)
+	reversed := make([]SyncItem, len(items))
+	for i, item := range items {
+		reversed[len(items)-1-i] = item
+	}
+	second := &SyncPlan{Items: reversed, NotMigrated: []string{notMigrated[1], notMigrated[0]}}
+	return first, second
+}
+
+func TestSyncPlan_ExportPlanGolden(t *testing.T) {
+	first, second := goldenPlans()
+
+	tests := []struct {
+		golden string
+		format PlanFormat
+		opts   []ExportOption
+	}{
+		{"sync_plan.json", PlanFormatJSON, nil},
+		{"sync_plan_timestamps.json", PlanFormatJSON, []ExportOption{ExportTimestamps()}},
+		{"sync_plan.txt", PlanFormatText, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			var a, b bytes.Buffer
+			require.NoError(t, first.ExportPlan(&a, tt.format, tt.opts...))
+			require.NoError(t, second.ExportPlan(&b, tt.format, tt.opts...))
+			assert.Equal(t, a.Bytes(), b.Bytes(), "plans built in different orders export identically")
+			assertGolden(t, tt.golden, a.Bytes())
+		})
+	}
+}
+
+func TestSyncPlan_ExportPlanLeavesPlanUntouched(t *testing.T) {
+	_, plan := goldenPlans()
+	firstID := plan.Items[0].DestID
+
+	var buf bytes.Buffer
+	require.NoError(t, plan.ExportPlan(&buf, PlanFormatJSON))
+	assert.Equal(t, firstID, plan.Items[0].DestID)
+	assert.NotNil(t, plan.Items[2].Agent.CreatedAt, "timestamps are only dropped from the export")
+
+	err := plan.ExportPlan(&buf, PlanFormat("yaml"))
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+}
+
+func TestMarshalStable(t *testing.T) {
+	type ordered struct {
+		Zebra int               `json:"zebra"`
+		Apple string            `json:"apple"`
+		Inner map[string]uint64 `json:"inner"`
+	}
+	data, err := MarshalStable(ordered{Zebra: 1, Apple: "<a&b>", Inner: map[string]uint64{"y": 18446744073709551615, "x": 0}})
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "apple": "<a&b>",
+  "inner": {
+    "x": 0,
+    "y": 18446744073709551615
+  },
+  "zebra": 1
+}
+`, string(data))
+
+	_, err = MarshalStable(func() {})
+	assert.Equal(t, CodeEncodeFailed, ErrorCode(err))
+}
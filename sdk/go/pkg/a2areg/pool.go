@@ -0,0 +1,219 @@
+package a2areg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ClientPool shares clients between the parts of an application that talk
+// to the same registry with the same credentials, so they share one
+// connection pool and one OAuth token instead of each holding their own.
+//
+// Clients are keyed by their identity: registry URL, credentials and
+// connection settings (HTTPClient, Transport, TLSConfig,
+// PinnedCertificates, HostOverrides, Resolver). A caller whose options
+// match the first caller's and who sets no callbacks (OnMaintenance,
+// OnDropped, OnCacheEvent, ...) gets the shared client itself; any other
+// caller gets a derived client that has its own settings and callbacks but
+// reuses the shared connections and token. The shared connections are
+// closed when the last client for an identity is released.
+//
+// A ClientPool is safe for concurrent use.
+type ClientPool struct {
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+}
+
+// poolEntry is one shared client and the number of clients handed out for
+// it, itself and derived ones included.
+type poolEntry struct {
+	opts   A2ARegClientOptions
+	client *A2ARegClient
+	refs   int
+}
+
+// DefaultClientPool is a process-wide ClientPool.
+var DefaultClientPool = NewClientPool()
+
+// NewClientPool returns an empty ClientPool.
+func NewClientPool() *ClientPool {
+	return &ClientPool{entries: make(map[string]*poolEntry)}
+}
+
+// GetOrCreate returns a client for opts, creating the shared client for its
+// identity if there is none yet. The caller must call release once it is
+// done with the client and must not use the client afterwards; release is
+// safe to call more than once. Options that fail Validate are rejected.
+func (p *ClientPool) GetOrCreate(opts A2ARegClientOptions) (*A2ARegClient, func(), error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+	key, err := poolKey(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[key]
+	if !ok {
+		shared := opts
+		if shared.Transport == nil && (shared.HTTPClient == nil || shared.HTTPClient.Transport == nil) {
+			// A transport of its own, so that closing it when the last
+			// reference goes does not touch http.DefaultTransport.
+			shared.Transport = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		entry = &poolEntry{opts: opts, client: NewA2ARegClient(shared)}
+		p.entries[key] = entry
+	}
+	entry.refs++
+
+	client := entry.client
+	if ok && !sameOptions(opts, entry.opts) {
+		client = entry.client.derive(opts)
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			if client != entry.client {
+				client.closed.Store(true)
+				client.stopAutoRefresh()
+				client.wipeCredentials()
+			}
+			p.release(key, entry)
+		})
+	}
+	return client, release, nil
+}
+
+// Len returns the number of shared clients in the pool.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// release drops one reference to entry, shutting its client down when it
+// was the last.
+func (p *ClientPool) release(key string, entry *poolEntry) {
+	p.mu.Lock()
+	entry.refs--
+	last := entry.refs == 0
+	if last && p.entries[key] == entry {
+		delete(p.entries, key)
+	}
+	p.mu.Unlock()
+
+	if last {
+		entry.client.shutdown()
+	}
+}
+
+// sameOptions reports whether a client built from a serves b as is. Go
+// cannot compare funcs, so options with a callback on either side never
+// match: the caller's callbacks must be the ones its client calls.
+func sameOptions(a, b A2ARegClientOptions) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		fa, fb := va.Field(i), vb.Field(i)
+		if fa.Kind() == reflect.Func {
+			if !fa.IsNil() || !fb.IsNil() {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// derive returns a client configured by opts that shares c's connections
+// and OAuth token. Both are in place before the client's goroutines start.
+func (c *A2ARegClient) derive(opts A2ARegClientOptions) *A2ARegClient {
+	derived := newClient(opts)
+	derived.httpClient.Transport = c.httpClient.Transport
+	derived.tokens = c.tokens
+	derived.start(opts)
+	return derived
+}
+
+// shutdown marks the client closed, zeroes its secrets and closes its idle
+// connections.
+func (c *A2ARegClient) shutdown() {
+	c.closed.Store(true)
+	c.stopAutoRefresh()
+	c.wipeCredentials()
+	c.tokens.mu.Lock()
+	c.tokens.accessToken.Wipe()
+	c.tokens.expiresAt = nil
+	c.tokens.mu.Unlock()
+	if c.httpClient.Transport != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+	if c.cardHTTPClient != c.httpClient {
+		c.cardHTTPClient.CloseIdleConnections()
+	}
+}
+
+// poolKey derives the ClientPool key of opts. Secrets are hashed rather
+// than kept in the key.
+func poolKey(opts A2ARegClientOptions) (string, error) {
+	registryURL := opts.RegistryURL
+	if registryURL == "" {
+		registryURL = "http://localhost:8000"
+	}
+	u, err := url.Parse(strings.TrimSuffix(registryURL, "/"))
+	if err != nil || u.Host == "" {
+		return "", withCode(NewA2AError("Invalid registry URL: "+opts.RegistryURL, nil), CodeInvalidRequest)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	header := opts.APIKeyHeader
+	if header == "" {
+		header = "X-API-Key"
+	}
+	scope := opts.Scope
+	if scope == "" {
+		scope = "read write"
+	}
+	hosts := make([]string, 0, len(opts.HostOverrides))
+	for host, target := range opts.HostOverrides {
+		hosts = append(hosts, strings.ToLower(host)+"="+target)
+	}
+	sort.Strings(hosts)
+
+	identity := []interface{}{
+		u.String(),
+		hashSecret(opts.APIKey), http.CanonicalHeaderKey(header),
+		opts.ClientID, hashSecret(opts.ClientSecret), scope,
+		fmt.Sprintf("%p", opts.HTTPClient), fmt.Sprintf("%p", opts.Transport),
+		fmt.Sprintf("%p", opts.TLSConfig), fmt.Sprintf("%p", opts.Resolver),
+		opts.PinnedCertificates, hosts,
+	}
+	data, err := json.Marshal(identity)
+	if err != nil {
+		return "", withCode(NewA2AError("Failed to encode pool key", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func hashSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
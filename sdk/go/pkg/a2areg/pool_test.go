@@ -0,0 +1,201 @@
+package a2areg
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func poolRegistry(t *testing.T) (*fakeregistry.Registry, string) {
+	registry := fakeregistry.New()
+	server := registry.Start()
+	t.Cleanup(server.Close)
+	return registry, server.URL
+}
+
+func TestClientPool_Dedup(t *testing.T) {
+	registry, url := poolRegistry(t)
+	pool := NewClientPool()
+	opts := A2ARegClientOptions{RegistryURL: url, ClientID: "id", ClientSecret: "secret"}
+
+	var wg sync.WaitGroup
+	clients := make([]*A2ARegClient, 8)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, release, err := pool.GetOrCreate(opts)
+			assert.NoError(t, err)
+			t.Cleanup(release)
+			clients[i] = client
+		}(i)
+	}
+	wg.Wait()
+
+	for _, client := range clients {
+		assert.Same(t, clients[0], client)
+	}
+	assert.Equal(t, 1, pool.Len())
+
+	// A trailing slash and host case do not change the identity.
+	opts.RegistryURL = strings.ToUpper(url) + "/"
+	client, release, err := pool.GetOrCreate(opts)
+	require.NoError(t, err)
+	defer release()
+	assert.Equal(t, 1, pool.Len())
+	assert.Same(t, clients[0].tokens, client.tokens)
+
+	_, err = clients[0].Health(context.Background())
+	require.NoError(t, err)
+	_, err = clients[1].GetRegistryStats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.Calls("POST /auth/oauth/token"))
+}
+
+func TestClientPool_DerivedClient(t *testing.T) {
+	registry, url := poolRegistry(t)
+	pool := NewClientPool()
+
+	base, releaseBase, err := pool.GetOrCreate(A2ARegClientOptions{RegistryURL: url, ClientID: "id", ClientSecret: "secret"})
+	require.NoError(t, err)
+	defer releaseBase()
+	derived, releaseDerived, err := pool.GetOrCreate(A2ARegClientOptions{RegistryURL: url, ClientID: "id", ClientSecret: "secret", Timeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	assert.NotSame(t, base, derived)
+	assert.Equal(t, 1, pool.Len())
+	assert.Equal(t, 5*time.Second, derived.httpClient.Timeout)
+	assert.NotEqual(t, derived.httpClient.Timeout, base.httpClient.Timeout)
+	assert.Same(t, base.tokens, derived.tokens)
+	assert.Equal(t, base.httpClient.Transport, derived.httpClient.Transport)
+
+	_, err = base.GetRegistryStats()
+	require.NoError(t, err)
+	_, err = derived.GetRegistryStats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.Calls("POST /auth/oauth/token"))
+
+	releaseDerived()
+	assert.Equal(t, CodeClientClosed, ErrorCode(derived.LivenessCheck(context.Background())))
+	assert.NoError(t, base.LivenessCheck(context.Background()))
+}
+
+func TestClientPool_RefcountedClose(t *testing.T) {
+	_, url := poolRegistry(t)
+	pool := NewClientPool()
+	opts := A2ARegClientOptions{RegistryURL: url, APIKey: "test"}
+
+	first, releaseFirst, err := pool.GetOrCreate(opts)
+	require.NoError(t, err)
+	second, releaseSecond, err := pool.GetOrCreate(opts)
+	require.NoError(t, err)
+	require.Same(t, first, second)
+
+	releaseFirst()
+	releaseFirst()
+	assert.NoError(t, first.LivenessCheck(context.Background()))
+	assert.Equal(t, 1, pool.Len())
+
+	releaseSecond()
+	assert.Equal(t, CodeClientClosed, ErrorCode(first.LivenessCheck(context.Background())))
+	assert.Equal(t, 0, pool.Len())
+
+	third, releaseThird, err := pool.GetOrCreate(opts)
+	require.NoError(t, err)
+	defer releaseThird()
+	assert.NotSame(t, first, third)
+	assert.NoError(t, third.LivenessCheck(context.Background()))
+}
+
+func TestClientPool_CredentialIsolation(t *testing.T) {
+	_, url := poolRegistry(t)
+	pool := NewClientPool()
+
+	alice, release, err := pool.GetOrCreate(A2ARegClientOptions{RegistryURL: url, ClientID: "alice", ClientSecret: "secret"})
+	require.NoError(t, err)
+	defer release()
+	bob, release, err := pool.GetOrCreate(A2ARegClientOptions{RegistryURL: url, ClientID: "bob", ClientSecret: "secret"})
+	require.NoError(t, err)
+	defer release()
+	other, release, err := pool.GetOrCreate(A2ARegClientOptions{RegistryURL: url, ClientID: "alice", ClientSecret: "other"})
+	require.NoError(t, err)
+	defer release()
+	keyed, release, err := pool.GetOrCreate(A2ARegClientOptions{RegistryURL: url, APIKey: "key"})
+	require.NoError(t, err)
+	defer release()
+
+	assert.Equal(t, 4, pool.Len())
+	assert.NotSame(t, alice, bob)
+	assert.NotSame(t, alice, other)
+	assert.NotSame(t, alice.tokens, bob.tokens)
+	assert.NotSame(t, alice.tokens, other.tokens)
+	assert.NotSame(t, alice, keyed)
+
+	_, _, err = pool.GetOrCreate(A2ARegClientOptions{RegistryURL: "not a url"})
+	assert.Equal(t, CodeInvalidRequest, ErrorCode(err))
+}
+
+func TestClientPool_DerivedWarmupSharesToken(t *testing.T) {
+	registry, url := poolRegistry(t)
+	pool := NewClientPool()
+	opts := A2ARegClientOptions{RegistryURL: url, ClientID: "id", ClientSecret: "secret"}
+
+	base, release, err := pool.GetOrCreate(opts)
+	require.NoError(t, err)
+	defer release()
+	opts.WarmupOnStart = true
+	derived, release, err := pool.GetOrCreate(opts)
+	require.NoError(t, err)
+	defer release()
+
+	assert.Same(t, base.tokens, derived.tokens)
+	assert.Eventually(t, func() bool { return registry.Calls("POST /auth/oauth/token") == 1 }, time.Second, 5*time.Millisecond)
+	_, err = base.GetRegistryStats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.Calls("POST /auth/oauth/token"), "the warmup token is the shared one")
+}
+
+func TestClientPool_CallbacksDerive(t *testing.T) {
+	_, url := poolRegistry(t)
+	pool := NewClientPool()
+	var mu sync.Mutex
+	events := map[string]int{}
+	opts := func(caller string) A2ARegClientOptions {
+		return A2ARegClientOptions{RegistryURL: url, APIKey: "test", EnableSearchCache: true, OnCacheEvent: func(CacheEvent) {
+			mu.Lock()
+			events[caller]++
+			mu.Unlock()
+		}}
+	}
+
+	first, release, err := pool.GetOrCreate(opts("first"))
+	require.NoError(t, err)
+	defer release()
+	second, release, err := pool.GetOrCreate(opts("second"))
+	require.NoError(t, err)
+	defer release()
+	require.NotSame(t, first, second)
+	assert.Same(t, first.tokens, second.tokens)
+
+	_, err = second.Search(context.Background(), SearchRequest{Query: "recipes"})
+	require.NoError(t, err)
+	mu.Lock()
+	assert.Zero(t, events["first"])
+	assert.NotZero(t, events["second"], "the second caller's callback fires")
+	mu.Unlock()
+
+	// A caller without callbacks does not get a client calling another's.
+	plain := opts("")
+	plain.OnCacheEvent = nil
+	third, release, err := pool.GetOrCreate(plain)
+	require.NoError(t, err)
+	defer release()
+	assert.NotSame(t, first, third)
+}
@@ -0,0 +1,388 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// PublishOptions controls PublishAgentVerbose.
+type PublishOptions struct {
+	// Validate runs ValidateAgent before publishing.
+	Validate bool
+	// SkipNormalizationCheck skips reading the stored card back after
+	// publishing, leaving PublishReceipt.Normalizations empty.
+	SkipNormalizationCheck bool
+	// DeriveID sends DeriveAgentID(provider, name) as the agent ID, for
+	// registries that let clients choose IDs. It is implied when the client
+	// was created with DeterministicIDs.
+	DeriveID bool
+	// IDStrategy, when set, chooses the agent ID in place of DeriveID and
+	// the client's IDStrategy.
+	IDStrategy IDStrategy
+	// Upsert updates the existing agent when the registry refuses the
+	// publish with 409 because an agent with the same name and provider
+	// exists. The agent is found by its derived ID when the DerivedSlug
+	// strategy is used, by searching otherwise, and replaced with
+	// UpdateAgent.
+	Upsert bool
+}
+
+// PublishReceipt describes the outcome of a publish.
+type PublishReceipt struct {
+	// Agent is the agent as stored by the registry.
+	Agent *Agent `json:"agent"`
+	// Warnings are non-fatal messages returned by the registry, from the
+	// response body or warning headers.
+	Warnings []string `json:"warnings,omitempty"`
+	// ConversionWarnings lists agent fields that could not be represented
+	// in the published card (see ConvertAgentToCard).
+	ConversionWarnings []ConversionWarning `json:"conversion_warnings,omitempty"`
+	// Normalizations lists how the stored card differs from the submitted
+	// one (for example lowercased tags or deduplicated modes).
+	Normalizations AgentDiff `json:"normalizations"`
+	// Created is false when an Upsert publish updated an existing agent
+	// instead of creating one.
+	Created bool `json:"created"`
+}
+
+// PublishAgentVerbose publishes an agent and reports what the registry did
+// with it: server warnings and, unless SkipNormalizationCheck is set, the
+// differences between the submitted card and the card the registry stored
+// (found by reading the card back immediately after publishing).
+func (c *A2ARegClient) PublishAgentVerbose(ctx context.Context, agent *Agent, opts PublishOptions) (*PublishReceipt, error) {
+	return c.publish(ctx, agent, opts)
+}
+
+// publish implements PublishAgent and PublishAgentVerbose.
+func (c *A2ARegClient) publish(ctx context.Context, agent *Agent, opts PublishOptions) (*PublishReceipt, error) {
+	if c.tagTaxonomy != nil {
+		agent = c.tagTaxonomy.normalizeAgentTags(agent)
+	}
+	if opts.Validate {
+		if err := c.ValidateAgent(agent); err != nil {
+			return nil, err
+		}
+	} else if err := c.checkAgentHosts(agent); err != nil {
+		return nil, err
+	} else if err := checkChangelog(agent.Changelog); err != nil {
+		return nil, err
+	} else if err := checkLabels(agent.Labels); err != nil {
+		return nil, err
+	}
+
+	strategy := c.idStrategy(opts)
+	payload, err := c.publishPayload(ctx, agent, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := c.postPublish(ctx, payload.body)
+	if err != nil {
+		if !opts.Upsert || StatusCode(err) != http.StatusConflict {
+			return nil, err
+		}
+		existingID := ""
+		if _, derived := strategy.(DerivedSlug); derived {
+			existingID = payload.id
+		}
+		if receipt, err = c.updateExisting(ctx, agent, existingID, err); err != nil {
+			return nil, err
+		}
+	}
+	receipt.ConversionWarnings = payload.conversionWarnings
+	receipt.Warnings = append(receipt.Warnings, payload.warnings(agent)...)
+
+	agentID := getStringValue(receipt.Agent.ID, "")
+	if opts.SkipNormalizationCheck || agentID == "" {
+		return receipt, nil
+	}
+
+	// The agent exists from here on: a failed read-back is a warning, so
+	// that the caller learns its ID instead of publishing it again.
+	stored, err := c.makeRequestContext(ctx, "GET", "/agents/"+agentID+"/card", nil, nil)
+	if err == nil {
+		receipt.Normalizations, err = diffDocuments(payload.card, stored)
+	}
+	if err != nil {
+		receipt.Warnings = append(receipt.Warnings, fmt.Sprintf("Agent %s was published, but its stored card could not be read back to check normalizations: %v", agentID, err))
+	}
+	return receipt, nil
+}
+
+// publishPayload is the body of a publish request for an agent.
+type publishPayload struct {
+	body map[string]interface{}
+	// id is the ID sent in body, empty when the registry chooses it.
+	id string
+	// card is the card in body, in wire form.
+	card               interface{}
+	conversionWarnings []ConversionWarning
+	// downgraded is set when an unlisted agent is sent as private because
+	// the registry does not support unlisted agents.
+	downgraded bool
+}
+
+// publishPayload builds the publish request for agent, with the ID
+// strategy allocates.
+func (c *A2ARegClient) publishPayload(ctx context.Context, agent *Agent, strategy IDStrategy) (*publishPayload, error) {
+	sent, downgraded, err := c.wireVisibility(ctx, agent)
+	if err != nil {
+		return nil, err
+	}
+	cardData, conversionWarnings, err := ConvertAgentToCard(agent)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &publishPayload{
+		card:               c.wireCard(cardData),
+		conversionWarnings: conversionWarnings,
+		downgraded:         downgraded,
+	}
+	payload.body = map[string]interface{}{
+		"public": sent.EffectiveVisibility() == VisibilityPublic,
+		"card":   payload.card,
+	}
+	if sent.Visibility != "" {
+		payload.body["visibility"] = sent.Visibility
+	}
+	if agent.Region != "" {
+		payload.body["region"] = agent.Region
+	}
+	if len(agent.Labels) > 0 {
+		payload.body["labels"] = agent.Labels
+	}
+	if payload.id, err = strategy.AllocateID(agent); err != nil {
+		return nil, err
+	}
+	if payload.id != "" {
+		payload.body["id"] = payload.id
+	}
+	return payload, nil
+}
+
+// warnings returns the client-side warnings about publishing agent with
+// the payload.
+func (p *publishPayload) warnings(agent *Agent) []string {
+	var warnings []string
+	if p.downgraded {
+		warnings = append(warnings, "Registry does not support unlisted agents; published as private")
+	}
+	if warning := changelogWarning(agent); warning != "" {
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+// postPublish sends a publish request and reads back the published agent:
+// fetched by ID when the registry answers with an agentId, decoded from the
+// response otherwise. If the fetch fails, the receipt's agent holds only
+// the ID, with a warning. When the request names an ID, the agent must have
+// been published under it.
+func (c *A2ARegClient) postPublish(ctx context.Context, requestBody map[string]interface{}) (*PublishReceipt, error) {
+	resp, err := c.sendMutation(ctx, "POST", "/agents/publish", "", requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var publishedData struct {
+		AgentID  string   `json:"agentId"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(resp.body, &publishedData); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode publish response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	receipt := &PublishReceipt{
+		Warnings: append(publishedData.Warnings, headerWarnings(resp.header)...),
+		Created:  true,
+	}
+
+	if publishedData.AgentID != "" {
+		// If agentId is returned, fetch the full agent, with the
+		// publish request's context and so its credentials and options.
+		receipt.Agent, err = c.readAgent(ctx, publishedData.AgentID)
+		if err != nil {
+			receipt.Agent = &Agent{}
+			receipt.Warnings = append(receipt.Warnings, fmt.Sprintf("Agent %s was published, but reading it back failed: %v", publishedData.AgentID, err))
+		}
+		if receipt.Agent.ID == nil {
+			receipt.Agent.ID = &publishedData.AgentID
+		}
+	} else {
+		// Otherwise, convert response to Agent
+		var publishedAgent Agent
+		if err := json.Unmarshal(resp.body, &publishedAgent); err != nil {
+			return nil, withCode(NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+		}
+		receipt.Agent = &publishedAgent
+	}
+	requested, _ := requestBody["id"].(string)
+	if err := checkAssignedID(receipt.Agent, requested); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// updateExisting finishes an Upsert publish that conflicted with an
+// existing agent by updating that agent with agent. The agent is agentID
+// when known, found by searching otherwise.
+func (c *A2ARegClient) updateExisting(ctx context.Context, agent *Agent, agentID string, conflict error) (*PublishReceipt, error) {
+	if agentID == "" {
+		existing, err := c.ResolveRef(ctx, &AgentRef{Org: agent.ProviderName(), Name: agent.Name})
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			return nil, withCode(NewNotFoundError(
+				fmt.Sprintf("Publish conflicted with an existing agent, but no agent named %q by %q was found to update", agent.Name, agent.ProviderName()),
+				map[string]interface{}{"name": agent.Name, "provider": agent.ProviderName(), "conflict": conflict.Error()},
+			), CodeAgentNotFound)
+		}
+		if err != nil {
+			return nil, err
+		}
+		agentID = getStringValue(existing.ID, "")
+	}
+
+	updated, err := c.updateAgent(ctx, agentID, agent)
+	if err != nil {
+		return nil, err
+	}
+	if updated.ID == nil {
+		updated.ID = &agentID
+	}
+	return &PublishReceipt{Agent: updated}, nil
+}
+
+// PublishAgentCard publishes card exactly as given, without converting it
+// from an Agent, so every card field reaches the registry. The card is
+// checked with ValidateAgentCard first.
+func (c *A2ARegClient) PublishAgentCard(card *AgentCardSpec, public bool) (*Agent, error) {
+	if err := c.ValidateAgentCard(card); err != nil {
+		return nil, err
+	}
+	return c.publishAgentCard(context.Background(), card, public)
+}
+
+// publishAgentCard publishes an already validated card.
+func (c *A2ARegClient) publishAgentCard(ctx context.Context, card *AgentCardSpec, public bool) (*Agent, error) {
+	requestBody := map[string]interface{}{
+		"public": public,
+		"card":   c.wireCard(card),
+	}
+	agent := &Agent{Name: card.Name, AgentCard: card}
+	if card.Provider != nil {
+		agent.Provider = card.Provider.Organization
+	}
+	agentID, err := c.defaultIDStrategy.AllocateID(agent)
+	if err != nil {
+		return nil, err
+	}
+	if agentID != "" {
+		requestBody["id"] = agentID
+	}
+
+	receipt, err := c.postPublish(ctx, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	return receipt.Agent, nil
+}
+
+// ValidateAgentCard checks that card has the fields the registry requires
+// (name, description, version, url and at least one skill), that its
+// transport, security scheme locations and skills are well formed, and that
+// its URLs are on an allowed host.
+func (c *A2ARegClient) ValidateAgentCard(card *AgentCardSpec) error {
+	if card == nil {
+		return NewValidationError("Agent card is required", nil)
+	}
+	var first error
+	c.checkAgentCard(card, func(_ string, err error) bool {
+		first = err
+		return false
+	})
+	return first
+}
+
+// checkAgentCard implements ValidateAgentCard for a non-nil card, passing
+// each problem to found. Paths are relative to the card.
+func (c *A2ARegClient) checkAgentCard(card *AgentCardSpec, found problemFunc) {
+	required := []struct {
+		path, value, message string
+	}{
+		{"name", card.Name, "Agent card name is required"},
+		{"description", card.Description, "Agent card description is required"},
+		{"version", card.Version, "Agent card version is required"},
+		{"url", card.URL, "Agent card url is required"},
+	}
+	for _, field := range required {
+		if field.value == "" && !found(field.path, NewValidationError(field.message, nil)) {
+			return
+		}
+	}
+	if len(card.Skills) == 0 && !found("skills", NewValidationError("Agent card must have at least one skill", nil)) {
+		return
+	}
+	if card.Interface.PreferredTransport != "" && !c.validTransport(card.Interface.PreferredTransport) {
+		if !found("preferredTransport", NewValidationError(fmt.Sprintf("Agent card has invalid preferred transport: %s", card.Interface.PreferredTransport), nil)) {
+			return
+		}
+	}
+	names := make([]string, 0, len(card.SecuritySchemes))
+	for name := range card.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if scheme := card.SecuritySchemes[name]; scheme.Location != nil && !scheme.Location.IsValid() {
+			if !found("securitySchemes."+name+".location", NewValidationError(fmt.Sprintf("Security scheme %q has invalid location: %s", name, *scheme.Location), nil)) {
+				return
+			}
+		}
+	}
+	if violations := c.skillLimits.checkSkills(card.Skills); len(violations) > 0 {
+		messages := make([]string, len(violations))
+		for i, violation := range violations {
+			messages[i] = violation.String()
+		}
+		if !found("skills", NewValidationError("Agent card has invalid skills: "+strings.Join(messages, "; "), map[string]interface{}{"violations": violations})) {
+			return
+		}
+	}
+	if err := c.checkAgentHosts(&Agent{AgentCard: card}); err != nil {
+		found("", err)
+	}
+}
+
+// wireCard returns card in the form sent to the registry: as is, or with
+// its security schemes as an array when the client was created with
+// SecuritySchemesAsArray.
+func (c *A2ARegClient) wireCard(card *AgentCardSpec) interface{} {
+	if !c.securitySchemesAsArray {
+		return card
+	}
+	return struct {
+		*AgentCardSpec
+		SecuritySchemes []SecurityScheme `json:"securitySchemes"`
+	}{card, card.SecuritySchemes.List()}
+}
+
+// headerWarnings extracts warning texts from X-Registry-Warning headers and
+// standard Warning headers (`199 - "text"`).
+func headerWarnings(header http.Header) []string {
+	var warnings []string
+	warnings = append(warnings, header.Values("X-Registry-Warning")...)
+	for _, value := range header.Values("Warning") {
+		if start := strings.IndexByte(value, '"'); start >= 0 {
+			if end := strings.IndexByte(value[start+1:], '"'); end >= 0 {
+				value = value[start+1 : start+1+end]
+			}
+		}
+		warnings = append(warnings, value)
+	}
+	return warnings
+}
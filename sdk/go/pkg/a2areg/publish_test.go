@@ -0,0 +1,304 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// normalizingRegistry stores published cards with skill tags lowercased and
+// reports a warning in both the body and a header.
+func normalizingRegistry(t *testing.T) (*httptest.Server, *int) {
+	var stored map[string]interface{}
+	cardReads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/agents/publish":
+			var req struct {
+				Card map[string]interface{} `json:"card"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			for _, skill := range req.Card["skills"].([]interface{}) {
+				skill := skill.(map[string]interface{})
+				tags := skill["tags"].([]interface{})
+				for i, tag := range tags {
+					tags[i] = strings.ToLower(tag.(string))
+				}
+			}
+			stored = req.Card
+			w.Header().Add("Warning", `199 - "tags were lowercased"`)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"agentId":  "agent-1",
+				"warnings": []string{"documentationUrl is recommended"},
+			})
+		case r.URL.Path == "/agents/agent-1/card":
+			cardReads++
+			json.NewEncoder(w).Encode(stored)
+		case r.URL.Path == "/agents/agent-1":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "agent-1", "name": stored["name"]})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &cardReads
+}
+
+func testPublishAgent() *Agent {
+	return &Agent{
+		Name:        "Recipe Agent",
+		Description: "Finds recipes",
+		Version:     "1.0.0",
+		Provider:    "acme",
+		Skills: []AgentSkill{
+			{ID: "s1", Name: "Search", Description: "Search recipes", Tags: []string{"cooking", "search"}},
+		},
+	}
+}
+
+func TestA2ARegClient_PublishAgentVerbose(t *testing.T) {
+	server, cardReads := normalizingRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	// Validation would reject the uppercase tag before the registry could
+	// normalize it.
+	agent := testPublishAgent()
+	agent.Skills[0].Tags[0] = "Cooking"
+	receipt, err := client.PublishAgentVerbose(context.Background(), agent, PublishOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "agent-1", *receipt.Agent.ID)
+	assert.Equal(t, []string{"documentationUrl is recommended", "tags were lowercased"}, receipt.Warnings)
+	assert.Equal(t, 1, *cardReads)
+	assert.Equal(t, []FieldChange{
+		{Path: "/skills/0/tags/0", Kind: ChangeModified, Before: "Cooking", After: "cooking"},
+	}, receipt.Normalizations.Changes)
+}
+
+func TestA2ARegClient_PublishAgentVerbose_SkipNormalizationCheck(t *testing.T) {
+	server, cardReads := normalizingRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	receipt, err := client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{SkipNormalizationCheck: true})
+	require.NoError(t, err)
+	assert.Equal(t, 0, *cardReads)
+	assert.True(t, receipt.Normalizations.IsEmpty())
+	assert.Len(t, receipt.Warnings, 2)
+}
+
+func TestA2ARegClient_PublishAgentVerbose_ReadBackFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/agents/publish" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"agentId": "agent-1"})
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	receipt, err := client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "agent-1", *receipt.Agent.ID)
+	require.Len(t, receipt.Warnings, 2)
+	assert.Contains(t, receipt.Warnings[0], "reading it back failed")
+	assert.Contains(t, receipt.Warnings[1], "could not be read back")
+	assert.True(t, receipt.Normalizations.IsEmpty())
+}
+
+func TestDiffDocuments(t *testing.T) {
+	diff, err := diffDocuments(
+		map[string]interface{}{"a": 1, "b": []string{"x", "y"}, "c/d": true},
+		[]byte(`{"a": 2, "b": ["x"], "e": null}`),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []FieldChange{
+		{Path: "/a", Kind: ChangeModified, Before: float64(1), After: float64(2)},
+		{Path: "/b/1", Kind: ChangeRemoved, Before: "y"},
+		{Path: "/c~1d", Kind: ChangeRemoved, Before: true},
+		{Path: "/e", Kind: ChangeAdded},
+	}, diff.Changes)
+}
+
+func testAgentCard() *AgentCardSpec {
+	docs := "https://docs.example.com/recipes"
+	flow, tokenURL := "client_credentials", "https://auth.example.com/token"
+	algorithm, signature := "ES256", "c2lnbmF0dXJl"
+	return &AgentCardSpec{
+		Name:        "Recipe Agent",
+		Description: "Finds recipes",
+		URL:         "https://agents.example.com/recipes",
+		Version:     "1.2.0",
+		SecuritySchemes: map[string]SecurityScheme{
+			"oauth": {Type: "oauth2", Flow: &flow, TokenURL: &tokenURL, Scopes: []string{"recipes:read"}},
+		},
+		Skills: []AgentSkill{
+			{ID: "search", Name: "Search", Description: "Search recipes", Tags: []string{"cooking"}},
+		},
+		Interface:        AgentInterface{PreferredTransport: "jsonrpc", DefaultInputModes: []string{"text/plain"}, DefaultOutputModes: []string{"application/json"}},
+		Provider:         &AgentProvider{Organization: "acme", URL: "https://acme.example.com"},
+		DocumentationURL: &docs,
+		Signature:        &AgentCardSignature{Algorithm: &algorithm, Signature: &signature},
+	}
+}
+
+func TestA2ARegClient_PublishAgentCard_SendsCardAsIs(t *testing.T) {
+	var posted struct {
+		Public bool            `json:"public"`
+		Card   json.RawMessage `json:"card"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/agents/publish":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+			w.Write([]byte(`{"agentId": "agent-7"}`))
+		case r.URL.Path == "/agents/agent-7":
+			w.Write([]byte(`{"id": "agent-7", "name": "Recipe Agent", "version": "1.2.0", "provider": "acme"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	card := testAgentCard()
+	agent, err := client.PublishAgentCard(card, true)
+	require.NoError(t, err)
+	assert.Equal(t, "agent-7", *agent.ID)
+	assert.Equal(t, "Recipe Agent", agent.Name)
+
+	want, err := json.Marshal(card)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(posted.Card))
+	assert.True(t, posted.Public)
+}
+
+func TestA2ARegClient_PublishAgentCard_SecuritySchemesAsArray(t *testing.T) {
+	var posted struct {
+		Card map[string]json.RawMessage `json:"card"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/agents/publish" {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		}
+		w.Write([]byte(`{"id": "agent-7", "name": "Recipe Agent"}`))
+	}))
+	defer server.Close()
+
+	card := testAgentCard()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	_, err := client.PublishAgentCard(card, true)
+	require.NoError(t, err)
+	assert.Equal(t, byte('{'), posted.Card["securitySchemes"][0])
+
+	client = NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", SecuritySchemesAsArray: true})
+	_, err = client.PublishAgentCard(card, true)
+	require.NoError(t, err)
+	var schemes []SecurityScheme
+	require.NoError(t, json.Unmarshal(posted.Card["securitySchemes"], &schemes))
+	assert.Equal(t, card.SecuritySchemes.List(), schemes)
+	assert.Equal(t, `"Recipe Agent"`, string(posted.Card["name"]))
+	skills, err := json.Marshal(card.Skills)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(skills), string(posted.Card["skills"]))
+}
+
+func TestA2ARegClient_ValidateAgentCard(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "test", AllowedAgentHosts: []string{"*.example.com"}})
+	require.NoError(t, client.ValidateAgentCard(testAgentCard()))
+
+	tests := []struct {
+		name   string
+		mutate func(card *AgentCardSpec)
+		want   string
+	}{
+		{"name", func(card *AgentCardSpec) { card.Name = "" }, "Agent card name is required"},
+		{"description", func(card *AgentCardSpec) { card.Description = "" }, "Agent card description is required"},
+		{"version", func(card *AgentCardSpec) { card.Version = "" }, "Agent card version is required"},
+		{"url", func(card *AgentCardSpec) { card.URL = "" }, "Agent card url is required"},
+		{"skills", func(card *AgentCardSpec) { card.Skills = nil }, "Agent card must have at least one skill"},
+		{"skill id", func(card *AgentCardSpec) { card.Skills[0].ID = "" }, "Agent card has invalid skills"},
+		{"host", func(card *AgentCardSpec) { card.URL = "https://evil.test/agent" }, "is not on an allowed host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := testAgentCard()
+			tt.mutate(card)
+			_, err := client.PublishAgentCard(card, true)
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	_, err := client.PublishAgentCard(nil, true)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+}
+
+func TestPublishAgentVerbose_Upsert(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.RejectDuplicates = true
+	server := registry.Start()
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	ctx := context.Background()
+	opts := PublishOptions{Validate: true, SkipNormalizationCheck: true, Upsert: true}
+
+	receipt, err := client.PublishAgentVerbose(ctx, testPublishAgent(), opts)
+	require.NoError(t, err)
+	assert.True(t, receipt.Created)
+	agentID := *receipt.Agent.ID
+
+	agent := testPublishAgent()
+	agent.Description = "Finds better recipes"
+	receipt, err = client.PublishAgentVerbose(ctx, agent, opts)
+	require.NoError(t, err)
+	assert.False(t, receipt.Created)
+	assert.Equal(t, agentID, *receipt.Agent.ID)
+	assert.Equal(t, 1, registry.Len())
+	assert.Equal(t, "Finds better recipes", registry.Snapshot()[0].Card["description"])
+	assert.Equal(t, 1, registry.Calls("PUT /agents/"+agentID))
+
+	// Without Upsert the conflict is returned as is.
+	opts.Upsert = false
+	_, err = client.PublishAgentVerbose(ctx, agent, opts)
+	assert.Equal(t, http.StatusConflict, StatusCode(err))
+
+	// Validation still comes first.
+	opts.Upsert = true
+	invalid := testPublishAgent()
+	invalid.Version = ""
+	_, err = client.PublishAgentVerbose(ctx, invalid, opts)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Equal(t, 3, registry.Calls("POST /agents/publish"))
+}
+
+func TestPublishAgentVerbose_UpsertLookupFindsNothing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/agents/publish":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"detail": "Agent already exists"}`))
+		case "/agents/search":
+			w.Write([]byte(`{"agents": [], "total": 0}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	_, err := client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{SkipNormalizationCheck: true, Upsert: true})
+	var notFound *NotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+	assert.Contains(t, err.Error(), `no agent named "Recipe Agent" by "acme"`)
+}
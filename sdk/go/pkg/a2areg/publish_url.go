@@ -0,0 +1,126 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxAgentCardBytes caps the size of an agent card fetched from an agent's
+// host.
+const maxAgentCardBytes = 1 << 20
+
+// wellKnownCardPaths are the locations of an agent card under an agent's
+// base URL, in the order they are tried.
+var wellKnownCardPaths = []string{"/.well-known/agent.json", "/.well-known/agent-card.json"}
+
+// PublishAgentFromURL fetches the agent card an agent serves and publishes
+// it. agentURL is the agent's base URL, whose /.well-known/agent.json and
+// then /.well-known/agent-card.json are tried, or the URL of a card ending
+// in .json. The card is checked with ValidateAgentCard and published as
+// PublishAgentCard does.
+//
+// Failures to fetch the card are returned as a *CardFetchError, so they can
+// be told apart from registry failures.
+func (c *A2ARegClient) PublishAgentFromURL(ctx context.Context, agentURL string, public bool) (*Agent, error) {
+	card, err := c.FetchAgentCard(ctx, agentURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ValidateAgentCard(card); err != nil {
+		return nil, err
+	}
+	return c.publishAgentCard(ctx, card, public)
+}
+
+// FetchAgentCard fetches the agent card served at agentURL, trying the
+// well-known locations as PublishAgentFromURL does. The request uses the
+// client's timeout and transport but sends no registry credentials.
+//
+// The card may be gzip or deflate encoded, and in UTF-8, UTF-16 (told by
+// the charset or a byte order mark), ISO-8859-1 or Windows-1252. Any other
+// encoding gives a *CardFetchError caused by an *UnsupportedEncodingError.
+func (c *A2ARegClient) FetchAgentCard(ctx context.Context, agentURL string) (*AgentCardSpec, error) {
+	u, err := url.Parse(agentURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, NewCardFetchError(agentURL, fmt.Sprintf("Invalid agent URL: %q", agentURL), err)
+	}
+
+	candidates := []string{u.String()}
+	if !strings.HasSuffix(u.Path, ".json") {
+		candidates = candidates[:0]
+		for _, path := range wellKnownCardPaths {
+			candidate := *u
+			candidate.Path = strings.TrimSuffix(u.Path, "/") + path
+			candidate.RawQuery, candidate.Fragment = "", ""
+			candidates = append(candidates, candidate.String())
+		}
+	}
+
+	var lastErr error
+	for _, cardURL := range candidates {
+		card, err := c.fetchCard(ctx, cardURL)
+		if err == nil {
+			return card, nil
+		}
+		lastErr = err
+		var fetchErr *CardFetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Details["status"] != http.StatusNotFound {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// fetchCard fetches and decodes the card at cardURL.
+func (c *A2ARegClient) fetchCard(ctx context.Context, cardURL string) (*AgentCardSpec, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", cardURL, nil)
+	if err != nil {
+		return nil, NewCardFetchError(cardURL, "Failed to create card request", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", cardAcceptEncoding)
+
+	resp, err := c.cardHTTPClient.Do(req)
+	if err != nil {
+		return nil, NewCardFetchError(cardURL, "Failed to fetch agent card", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		fetchErr := NewCardFetchError(cardURL, fmt.Sprintf("Agent card request failed with status %d", resp.StatusCode), nil)
+		fetchErr.Details["status"] = resp.StatusCode
+		return nil, fetchErr
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil ||
+		(mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		fetchErr := NewCardFetchError(cardURL, "Agent card is not JSON", nil)
+		fetchErr.Details["content_type"] = resp.Header.Get("Content-Type")
+		return nil, fetchErr
+	}
+
+	body, tooLarge, err := readCardBody(resp, maxAgentCardBytes)
+	var encodingErr *UnsupportedEncodingError
+	if errors.As(err, &encodingErr) {
+		return nil, withCode(NewCardFetchError(cardURL, "Agent card uses an "+encodingErr.Error(), err), CodeUnsupportedEncoding)
+	}
+	if err != nil {
+		return nil, NewCardFetchError(cardURL, "Failed to read agent card", err)
+	}
+	if tooLarge {
+		fetchErr := NewCardFetchError(cardURL, fmt.Sprintf("Agent card exceeds %d bytes", maxAgentCardBytes), nil)
+		fetchErr.Details["limit"] = maxAgentCardBytes
+		return nil, fetchErr
+	}
+
+	var card AgentCardSpec
+	if err := json.Unmarshal(body, &card); err != nil {
+		return nil, NewCardFetchError(cardURL, "Failed to decode agent card", err)
+	}
+	return &card, nil
+}
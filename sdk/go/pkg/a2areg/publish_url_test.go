@@ -0,0 +1,257 @@
+package a2areg
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// agentHost serves handler as an agent's own host.
+func agentHost(t *testing.T, handler http.HandlerFunc) string {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func serveCard(t *testing.T, path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		require.NoError(t, json.NewEncoder(w).Encode(testAgentCard()))
+	}
+}
+
+func TestA2ARegClient_PublishAgentFromURL(t *testing.T) {
+	for _, path := range wellKnownCardPaths {
+		t.Run(path, func(t *testing.T) {
+			registry := fakeregistry.New()
+			server := registry.Start()
+			defer server.Close()
+			client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+			host := agentHost(t, serveCard(t, path))
+
+			agent, err := client.PublishAgentFromURL(context.Background(), host+"/", true)
+			require.NoError(t, err)
+			require.NotNil(t, agent.ID)
+			assert.Equal(t, "Recipe Agent", agent.Name)
+			assert.Equal(t, 1, registry.Len())
+
+			card, err := client.GetAgentCard(*agent.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "https://agents.example.com/recipes", card.URL)
+		})
+	}
+}
+
+func TestA2ARegClient_PublishAgentFromURL_FetchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		message string
+	}{
+		{"not found", http.NotFound, "status 404"},
+		{"not json", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		}, "not JSON"},
+		{"too large", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name": "` + strings.Repeat("a", maxAgentCardBytes) + `"}`))
+		}, "exceeds"},
+		{"invalid json", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":`))
+		}, "decode"},
+		{"timeout", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}, "Failed to fetch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := fakeregistry.New()
+			server := registry.Start()
+			defer server.Close()
+			client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", Timeout: 50 * time.Millisecond})
+			host := agentHost(t, tt.handler)
+
+			_, err := client.PublishAgentFromURL(context.Background(), host, true)
+			var fetchErr *CardFetchError
+			require.ErrorAs(t, err, &fetchErr)
+			assert.Equal(t, CodeCardFetchFailed, ErrorCode(err))
+			assert.Contains(t, err.Error(), tt.message)
+			assert.True(t, strings.HasPrefix(fetchErr.URL, host+"/.well-known/"))
+			assert.Equal(t, 0, registry.Calls("POST /agents/publish"))
+		})
+	}
+
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "test"})
+	_, err := client.PublishAgentFromURL(context.Background(), "ftp://agents.example.com", true)
+	assert.Equal(t, CodeCardFetchFailed, ErrorCode(err))
+}
+
+func TestA2ARegClient_PublishAgentFromURL_CardURL(t *testing.T) {
+	registry := fakeregistry.New()
+	server := registry.Start()
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	host := agentHost(t, serveCard(t, "/cards/recipes.json"))
+
+	_, err := client.PublishAgentFromURL(context.Background(), host+"/cards/recipes.json", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.Len())
+}
+
+func TestA2ARegClient_PublishAgentFromURL_RegistryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"detail": "boom"}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	host := agentHost(t, serveCard(t, "/.well-known/agent.json"))
+
+	_, err := client.PublishAgentFromURL(context.Background(), host, true)
+	require.Error(t, err)
+	var fetchErr *CardFetchError
+	assert.False(t, errors.As(err, &fetchErr))
+	assert.ErrorIs(t, err, ErrServer)
+}
+
+func TestA2ARegClient_PublishAgentFromURL_InvalidCard(t *testing.T) {
+	registry := fakeregistry.New()
+	server := registry.Start()
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	host := agentHost(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "Recipe Agent"}`))
+	})
+
+	_, err := client.PublishAgentFromURL(context.Background(), host, true)
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, 0, registry.Calls("POST /agents/publish"))
+}
+
+// encodedCardHost serves the test card's JSON transformed by encode, with
+// the given Content-Type and Content-Encoding.
+func encodedCardHost(t *testing.T, contentType, contentEncoding string, encode func([]byte) []byte) string {
+	data, err := json.Marshal(encodedTestCard())
+	require.NoError(t, err)
+	body := encode(data)
+	return agentHost(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, cardAcceptEncoding, r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Type", contentType)
+		if contentEncoding != "" {
+			w.Header().Set("Content-Encoding", contentEncoding)
+		}
+		w.Write(body)
+	})
+}
+
+// encodedTestCard is the test card with text outside ASCII, to tell a
+// transcoded body from one that merely happens to decode.
+func encodedTestCard() *AgentCardSpec {
+	card := testAgentCard()
+	card.Description = "Finds crème brûlée recipes"
+	return card
+}
+
+func compress(t *testing.T, newWriter func(io.Writer) io.WriteCloser) func([]byte) []byte {
+	return func(data []byte) []byte {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		_, err := w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+}
+
+func transcode(t *testing.T, enc encoding.Encoding, prefix ...byte) func([]byte) []byte {
+	return func(data []byte) []byte {
+		out, err := enc.NewEncoder().Bytes(data)
+		require.NoError(t, err)
+		return append(prefix, out...)
+	}
+}
+
+func TestFetchAgentCard_EncodedBodies(t *testing.T) {
+	identity := func(data []byte) []byte { return data }
+	tests := []struct {
+		name            string
+		contentType     string
+		contentEncoding string
+		encode          func([]byte) []byte
+	}{
+		{"gzip", "application/json", "gzip", compress(t, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })},
+		{"zlib deflate", "application/json", "deflate", compress(t, func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })},
+		{"raw deflate", "application/json", "Deflate", compress(t, func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		})},
+		{"utf-8 BOM", "application/json; charset=utf-8", "", func(data []byte) []byte { return append([]byte{0xef, 0xbb, 0xbf}, data...) }},
+		{"utf-16le with BOM", "application/json; charset=utf-16", "", transcode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM))},
+		{"utf-16be without BOM", "application/json; charset=UTF-16BE", "", transcode(t, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM))},
+		{"utf-16le BOM, no charset", "application/json", "", transcode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM))},
+		{"latin1", "application/json; charset=ISO-8859-1", "", transcode(t, charmap.ISO8859_1)},
+		{"gzipped utf-16", "application/json; charset=utf-16", "gzip", func(data []byte) []byte {
+			return compress(t, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })(
+				transcode(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM))(data))
+		}},
+		{"identity", "application/json", "identity", identity},
+	}
+	client := NewA2ARegClient(A2ARegClientOptions{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := encodedCardHost(t, tt.contentType, tt.contentEncoding, tt.encode)
+			card, err := client.FetchAgentCard(context.Background(), host+"/card.json")
+			require.NoError(t, err)
+			assert.Equal(t, encodedTestCard().Name, card.Name)
+			assert.Equal(t, encodedTestCard().Description, card.Description)
+		})
+	}
+}
+
+func TestFetchAgentCard_UnsupportedEncoding(t *testing.T) {
+	identity := func(data []byte) []byte { return data }
+	client := NewA2ARegClient(A2ARegClientOptions{})
+	for _, tt := range []struct {
+		contentType, contentEncoding, header, encoding string
+	}{
+		{"application/json", "br", "Content-Encoding", "br"},
+		{"application/json; charset=shift_jis", "", "charset", "shift_jis"},
+	} {
+		host := encodedCardHost(t, tt.contentType, tt.contentEncoding, identity)
+		_, err := client.FetchAgentCard(context.Background(), host+"/card.json")
+		var fetchErr *CardFetchError
+		require.ErrorAs(t, err, &fetchErr)
+		var encodingErr *UnsupportedEncodingError
+		require.ErrorAs(t, err, &encodingErr)
+		assert.Equal(t, tt.header, encodingErr.Header)
+		assert.Equal(t, tt.encoding, encodingErr.Encoding)
+		assert.Equal(t, CodeUnsupportedEncoding, ErrorCode(err))
+	}
+}
@@ -0,0 +1,185 @@
+package a2areg
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitDetails collects the rate-limit headers of a 429 response into
+// error details: "retry_after" (time.Duration), "rate_limit_remaining" (int),
+// "rate_limit_reset" (time.Time) and "rate_limit_scope" (string, lowercased).
+// X-RateLimit-Reset is accepted as a Unix timestamp or as seconds from now.
+// Absent or malformed headers are left out.
+func rateLimitDetails(header http.Header, now time.Time, errorData map[string]interface{}) map[string]interface{} {
+	details := make(map[string]interface{}, len(errorData)+3)
+	for k, v := range errorData {
+		details[k] = v
+	}
+	if t, ok := parseRetryAfter(header.Get("Retry-After"), now); ok {
+		details["retry_after"] = max(t.Sub(now), 0)
+	}
+	if remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil {
+		details["rate_limit_remaining"] = remaining
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil && reset >= 0 {
+		// Values this large cannot be a delay; they are epoch seconds.
+		if reset >= 1_000_000_000 {
+			details["rate_limit_reset"] = time.Unix(reset, 0)
+		} else {
+			details["rate_limit_reset"] = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	if scope := strings.ToLower(strings.TrimSpace(header.Get("X-RateLimit-Scope"))); scope != "" {
+		details["rate_limit_scope"] = scope
+	}
+	return details
+}
+
+// RetryAfter returns how long the registry asked the caller to wait, or
+// zero when it did not say. It reads Details["retry_after"], which is a
+// time.Duration for errors produced by the client and is taken as seconds
+// when it is a number.
+func (e *RateLimitError) RetryAfter() time.Duration {
+	switch v := e.Details["retry_after"].(type) {
+	case time.Duration:
+		return v
+	case int:
+		return time.Duration(v) * time.Second
+	case float64:
+		return time.Duration(v * float64(time.Second))
+	}
+	return 0
+}
+
+// RateLimitScope names a bucket the registry rate-limits separately, as
+// sent in the X-RateLimit-Scope header.
+type RateLimitScope string
+
+// The scopes the registry rate-limits separately. Requests to the search
+// endpoints are in RateLimitScopeSearch, other GETs in RateLimitScopeRead,
+// and every other request in RateLimitScopeWrite.
+const (
+	RateLimitScopeSearch RateLimitScope = "search"
+	RateLimitScopeRead   RateLimitScope = "read"
+	RateLimitScopeWrite  RateLimitScope = "write"
+)
+
+// rateLimitScopeOf returns the scope of a request.
+func rateLimitScopeOf(method, endpoint string) RateLimitScope {
+	switch {
+	case strings.HasPrefix(endpoint, "/agents/search"):
+		return RateLimitScopeSearch
+	case method == http.MethodGet:
+		return RateLimitScopeRead
+	}
+	return RateLimitScopeWrite
+}
+
+// RateLimitState is what the client knows of one rate-limit scope.
+type RateLimitState struct {
+	// Remaining is the number of requests left in the window, or -1 when
+	// the registry has not said.
+	Remaining int `json:"remaining"`
+	// Reset is when the window resets; zero when unknown.
+	Reset time.Time `json:"reset,omitempty"`
+	// CooldownUntil is when the last 429 for the scope stops applying.
+	// Until then requests in the scope wait before being sent, while
+	// requests in other scopes go ahead.
+	CooldownUntil time.Time `json:"cooldown_until,omitempty"`
+	// Limited reports whether the cooldown was in force when the state was
+	// read.
+	Limited bool `json:"limited"`
+}
+
+// rateLimits tracks the scopes the registry reported on.
+type rateLimits struct {
+	mu     sync.Mutex
+	scopes map[RateLimitScope]*scopeLimit
+}
+
+// scopeLimit is the state of one scope.
+type scopeLimit struct {
+	remaining int
+	reset     time.Time
+	cooldown  pageBackoff
+}
+
+// scope returns the state of scope, creating it if needed.
+func (r *rateLimits) scope(scope RateLimitScope) *scopeLimit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scopes == nil {
+		r.scopes = make(map[RateLimitScope]*scopeLimit)
+	}
+	s, ok := r.scopes[scope]
+	if !ok {
+		s = &scopeLimit{remaining: -1}
+		r.scopes[scope] = s
+	}
+	return s
+}
+
+// observe records the rate-limit headers of a response naming its scope,
+// and starts the scope's cooldown when the response is a 429. A 429 gives
+// no cooldown when it says neither how long to wait nor when the window
+// resets.
+func (r *rateLimits) observe(resp *http.Response, now time.Time) {
+	details := rateLimitDetails(resp.Header, now, nil)
+	scope, _ := details["rate_limit_scope"].(string)
+	if scope == "" {
+		return
+	}
+	s := r.scope(RateLimitScope(scope))
+	r.mu.Lock()
+	if remaining, ok := details["rate_limit_remaining"].(int); ok {
+		s.remaining = remaining
+	}
+	if reset, ok := details["rate_limit_reset"].(time.Time); ok {
+		s.reset = reset
+	}
+	r.mu.Unlock()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	if retryAfter, ok := details["retry_after"].(time.Duration); ok {
+		s.cooldown.extend(now.Add(retryAfter))
+	} else if reset, ok := details["rate_limit_reset"].(time.Time); ok {
+		s.cooldown.extend(reset)
+	}
+}
+
+// wait blocks while scope is cooling down, or until ctx is done.
+func (r *rateLimits) wait(ctx context.Context, scope RateLimitScope, clk Clock) error {
+	r.mu.Lock()
+	s, ok := r.scopes[scope]
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.cooldown.wait(ctx, clk)
+}
+
+// LastRateLimit returns the client's view of each rate-limit scope the
+// registry has reported on with X-RateLimit-Scope, keyed by scope.
+func (c *A2ARegClient) LastRateLimit() map[RateLimitScope]RateLimitState {
+	now := c.clock.Now()
+	c.rateLimits.mu.Lock()
+	defer c.rateLimits.mu.Unlock()
+	states := make(map[RateLimitScope]RateLimitState, len(c.rateLimits.scopes))
+	for scope, s := range c.rateLimits.scopes {
+		s.cooldown.mu.Lock()
+		until := s.cooldown.until
+		s.cooldown.mu.Unlock()
+		states[scope] = RateLimitState{
+			Remaining:     s.remaining,
+			Reset:         s.reset,
+			CooldownUntil: until,
+			Limited:       until.After(now),
+		}
+	}
+	return states
+}
@@ -0,0 +1,197 @@
+package a2areg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rateLimitedClient(t *testing.T, fake *clock.Fake, headers map[string]string, body string) *A2ARegClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", Clock: fake})
+}
+
+func TestHandleResponse_RateLimited(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		wantAfter time.Duration
+		wantReset time.Time
+	}{
+		{
+			name:      "retry-after seconds",
+			headers:   map[string]string{"Retry-After": "30", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "45"},
+			wantAfter: 30 * time.Second,
+			wantReset: now.Add(45 * time.Second),
+		},
+		{
+			name:      "retry-after date",
+			headers:   map[string]string{"Retry-After": now.Add(2 * time.Minute).Format(http.TimeFormat), "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1740830520"},
+			wantAfter: 2 * time.Minute,
+			wantReset: time.Unix(1740830520, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := rateLimitedClient(t, clock.NewFake(now), tt.headers, `{"detail":"slow down"}`)
+
+			_, err := client.Health(context.Background())
+			var rateErr *RateLimitError
+			require.True(t, errors.As(err, &rateErr), "expected a RateLimitError, got %v", err)
+			assert.Equal(t, "Rate limit exceeded: slow down", rateErr.Error())
+			assert.Equal(t, CodeRateLimited, ErrorCode(err))
+			assert.Equal(t, http.StatusTooManyRequests, StatusCode(err))
+			assert.Equal(t, tt.wantAfter, rateErr.RetryAfter())
+			assert.Equal(t, 0, rateErr.Details["rate_limit_remaining"])
+			assert.True(t, tt.wantReset.Equal(rateErr.Details["rate_limit_reset"].(time.Time)))
+			assert.Equal(t, "slow down", rateErr.Details["detail"])
+		})
+	}
+}
+
+func TestHandleResponse_RateLimitedWithoutHeaders(t *testing.T) {
+	client := rateLimitedClient(t, clock.NewFake(time.Now()), nil, "")
+
+	_, err := client.Health(context.Background())
+	var rateErr *RateLimitError
+	require.True(t, errors.As(err, &rateErr), "expected a RateLimitError, got %v", err)
+	assert.Equal(t, "Rate limit exceeded", rateErr.Error())
+	assert.Zero(t, rateErr.RetryAfter())
+	assert.Empty(t, rateErr.Details)
+}
+
+func TestRateLimitError_RetryAfterFromDetails(t *testing.T) {
+	assert.Equal(t, time.Minute, NewRateLimitError("slow", map[string]interface{}{"retry_after": 60}).RetryAfter())
+	assert.Equal(t, 1500*time.Millisecond, NewRateLimitError("slow", map[string]interface{}{"retry_after": 1.5}).RetryAfter())
+	assert.Zero(t, NewRateLimitError("slow", nil).RetryAfter())
+}
+
+// scopedRateLimitRegistry rate-limits the first search in the search scope
+// for 30 seconds and accepts publishes, reporting the write scope's
+// remaining budget.
+func scopedRateLimitRegistry(t *testing.T, fake *clock.Fake) (*A2ARegClient, *atomic.Int32, *atomic.Int32) {
+	var searches, publishes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/agents/search":
+			if searches.Add(1) == 1 {
+				w.Header().Set("X-RateLimit-Scope", "Search")
+				w.Header().Set("Retry-After", "30")
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"detail": "search limit exceeded"}`))
+				return
+			}
+			w.Write([]byte(`{"agents": [], "total": 0}`))
+		case "/agents/publish":
+			publishes.Add(1)
+			w.Header().Set("X-RateLimit-Scope", "write")
+			w.Header().Set("X-RateLimit-Remaining", "41")
+			w.Write([]byte(`{"id": "agent-1", "name": "Recipe Agent"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", Clock: fake})
+	return client, &searches, &publishes
+}
+
+func TestRateLimitScopes_SearchLimitDoesNotThrottlePublishes(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	client, searches, publishes := scopedRateLimitRegistry(t, fake)
+	ctx := context.Background()
+
+	_, err := client.Search(ctx, SearchRequest{Query: "weather"})
+	var rateErr *RateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	assert.Equal(t, RateLimitScopeSearch, rateErr.Scope)
+
+	// Publishes go straight through while the search scope cools down; a
+	// throttled publish would block on the fake clock.
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 3; i++ {
+			if _, err := client.PublishAgent(testPublishAgent(), false); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishes were throttled by the search rate limit")
+	}
+	assert.Equal(t, int32(3), publishes.Load())
+
+	assert.Equal(t, map[RateLimitScope]RateLimitState{
+		RateLimitScopeSearch: {Remaining: 0, CooldownUntil: now.Add(30 * time.Second), Limited: true},
+		RateLimitScopeWrite:  {Remaining: 41},
+	}, client.LastRateLimit())
+
+	// A search waits out the cooldown before reaching the registry.
+	searched := make(chan error, 1)
+	go func() {
+		_, err := client.Search(ctx, SearchRequest{Query: "weather"})
+		searched <- err
+	}()
+	fake.BlockUntil(1)
+	assert.Equal(t, int32(1), searches.Load())
+	fake.Advance(30 * time.Second)
+	require.NoError(t, <-searched)
+	assert.Equal(t, int32(2), searches.Load())
+	assert.False(t, client.LastRateLimit()[RateLimitScopeSearch].Limited)
+}
+
+func TestRateLimitScopes_WaitHonorsContext(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	client, searches, _ := scopedRateLimitRegistry(t, fake)
+
+	_, err := client.Search(context.Background(), SearchRequest{Query: "weather"})
+	require.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	searched := make(chan error, 1)
+	go func() {
+		_, err := client.Search(ctx, SearchRequest{Query: "weather"})
+		searched <- err
+	}()
+	fake.BlockUntil(1)
+	cancel()
+	assert.ErrorIs(t, <-searched, context.Canceled)
+	assert.Equal(t, int32(1), searches.Load())
+}
+
+func TestRateLimitScopes_UnscopedLimitsAreNotTracked(t *testing.T) {
+	client := rateLimitedClient(t, clock.NewFake(time.Now()), map[string]string{"Retry-After": "30"}, "")
+
+	_, err := client.Health(context.Background())
+	var rateErr *RateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	assert.Empty(t, rateErr.Scope)
+	assert.Empty(t, client.LastRateLimit())
+}
@@ -0,0 +1,40 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Do sends an authenticated request to an arbitrary registry endpoint,
+// for endpoints the SDK does not wrap yet. It behaves like the client's
+// own calls: credentials, request options, envelope unwrapping and error
+// mapping are the same. body is encoded as JSON unless nil, and a
+// successful response is decoded into out unless out is nil or the
+// response is empty.
+//
+// endpoint is a path relative to the registry URL and must start with
+// "/". Requests other than GET and HEAD empty the search cache, as the
+// client cannot tell what they change; cached agent cards are left alone.
+//
+// Do is an escape hatch and its behavior is less stable than that of the
+// typed methods; prefer them where they exist.
+func (c *A2ARegClient) Do(ctx context.Context, method, endpoint string, body, out interface{}, opts ...RequestOption) error {
+	if !strings.HasPrefix(endpoint, "/") {
+		return NewValidationError("Endpoint must start with \"/\": "+endpoint, map[string]interface{}{"endpoint": endpoint})
+	}
+	method = strings.ToUpper(method)
+	if method != http.MethodGet && method != http.MethodHead {
+		defer c.invalidateSearches()
+	}
+
+	data, err := c.makeRequestContext(withRequestOptions(ctx, opts), method, endpoint, body, nil)
+	if err != nil || out == nil || len(data) == 0 {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return withCode(NewA2AError("Failed to decode response", map[string]interface{}{"error": err.Error(), "endpoint": endpoint}), CodeDecodeFailed)
+	}
+	return nil
+}
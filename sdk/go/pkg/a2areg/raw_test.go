@@ -0,0 +1,61 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestA2ARegClient_Do(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.Header.Get("X-API-Key"))
+		switch r.URL.Path {
+		case "/agents/agent-1/endorsements":
+			if r.Method == "POST" {
+				var body map[string]string
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, "great agent", body["comment"])
+				assert.Equal(t, "trace-1", r.Header.Get("X-Trace"))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Write([]byte(`{"data": {"count": 2, "latest": "great agent"}, "meta": {"request_id": "req-1"}}`))
+		case "/broken":
+			w.Write([]byte(`not json`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+	ctx := context.Background()
+
+	var endorsements struct {
+		Count  int    `json:"count"`
+		Latest string `json:"latest"`
+	}
+	require.NoError(t, client.Do(ctx, "GET", "/agents/agent-1/endorsements", nil, &endorsements))
+	assert.Equal(t, 2, endorsements.Count)
+	assert.Equal(t, "great agent", endorsements.Latest)
+	assert.Equal(t, "req-1", client.LastCallInfo().RequestID)
+
+	require.NoError(t, client.Do(ctx, "POST", "/agents/agent-1/endorsements", map[string]string{"comment": "great agent"}, nil, WithHeader("X-Trace", "trace-1")))
+
+	err := client.Do(ctx, "GET", "/unknown", nil, &endorsements)
+	var notFound *NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+
+	err = client.Do(ctx, "GET", "/broken", nil, &endorsements)
+	assert.Equal(t, CodeDecodeFailed, ErrorCode(err))
+	assert.NoError(t, client.Do(ctx, "GET", "/broken", nil, nil))
+
+	err = client.Do(ctx, "GET", "agents", nil, nil)
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
@@ -0,0 +1,254 @@
+package a2areg
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// resolvePageSize is the search page size ResolveRef reads candidates with.
+const resolvePageSize = 100
+
+// AgentRef is a compact agent reference of the form "org/name",
+// "org/name@1.2.0" or "org/name@>=1.2,<2". Org and Name are compared with
+// the provider and name of registry agents after slugging (see
+// DeriveAgentID), so "acme/invoice-parser" refers to the agent "Invoice
+// Parser" of provider "ACME".
+type AgentRef struct {
+	Org  string
+	Name string
+	// Constraint is the version part after "@", empty for any version.
+	Constraint  string
+	constraints []versionClause
+}
+
+// versionClause is one comparison of a version constraint.
+type versionClause struct {
+	op      string // "=", ">", ">=", "<" or "<="
+	version string
+}
+
+var refVersionPattern = regexp.MustCompile(`^v?\d+(\.\d+){0,2}$`)
+
+// ParseAgentRef parses an "org/name[@constraint]" reference. A constraint
+// is a bare version (exact match), a comparison (=, >, >=, <, <=), a caret
+// or tilde range (^1.2 allows <2.0.0, ~1.2 allows <1.3.0), or several of
+// these separated by commas or spaces, all of which must hold. "*" matches
+// any version.
+func ParseAgentRef(s string) (*AgentRef, error) {
+	invalid := func(reason string) error {
+		return NewValidationError(fmt.Sprintf("Invalid agent reference %q: %s", s, reason), map[string]interface{}{"ref": s})
+	}
+
+	path, constraint, hasVersion := strings.Cut(strings.TrimSpace(s), "@")
+	org, name, ok := strings.Cut(path, "/")
+	if !ok || org == "" || name == "" || strings.Contains(name, "/") {
+		return nil, invalid("expected org/name")
+	}
+	ref := &AgentRef{Org: org, Name: name, Constraint: strings.TrimSpace(constraint)}
+	if !hasVersion {
+		return ref, nil
+	}
+	if ref.Constraint == "" {
+		return nil, invalid("empty version after @")
+	}
+
+	clauses, err := parseVersionConstraint(ref.Constraint)
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+	ref.constraints = clauses
+	return ref, nil
+}
+
+// parseVersionConstraint expands a constraint, in the syntax ParseAgentRef
+// accepts after "@", into comparisons that must all hold.
+func parseVersionConstraint(constraint string) ([]versionClause, error) {
+	var clauses []versionClause
+	for _, term := range strings.FieldsFunc(constraint, func(r rune) bool { return r == ',' || r == ' ' }) {
+		termClauses, err := parseVersionTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, termClauses...)
+	}
+	return clauses, nil
+}
+
+// parseVersionTerm expands one constraint term into comparisons.
+func parseVersionTerm(term string) ([]versionClause, error) {
+	if term == "*" {
+		return nil, nil
+	}
+	op := ""
+	for _, candidate := range []string{">=", "<=", "==", ">", "<", "=", "^", "~"} {
+		if rest, ok := strings.CutPrefix(term, candidate); ok {
+			op, term = candidate, rest
+			break
+		}
+	}
+	if !refVersionPattern.MatchString(term) {
+		return nil, fmt.Errorf("%q is not a version", term)
+	}
+
+	switch op {
+	case "", "=", "==":
+		return []versionClause{{"=", term}}, nil
+	case "^", "~":
+		return []versionClause{{">=", term}, {"<", rangeUpperBound(op, term)}}, nil
+	default:
+		return []versionClause{{op, term}}, nil
+	}
+}
+
+// rangeUpperBound returns the exclusive upper bound of a caret or tilde
+// range. ^ keeps the leftmost non-zero component fixed; ~ keeps the minor
+// version fixed when one is given and the major version otherwise.
+func rangeUpperBound(op, version string) string {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		fmt.Sscan(p, &nums[i])
+	}
+
+	fixed := 0 // index of the component that is bumped
+	if op == "^" {
+		for fixed < len(nums)-1 && nums[fixed] == 0 {
+			fixed++
+		}
+	} else if len(nums) > 1 {
+		fixed = 1
+	}
+
+	bound := make([]string, 3)
+	for i := range bound {
+		switch {
+		case i < fixed:
+			bound[i] = fmt.Sprint(nums[i])
+		case i == fixed:
+			bound[i] = fmt.Sprint(nums[i] + 1)
+		default:
+			bound[i] = "0"
+		}
+	}
+	return strings.Join(bound, ".")
+}
+
+// String formats the reference as it was parsed.
+func (r *AgentRef) String() string {
+	if r.Constraint == "" {
+		return r.Org + "/" + r.Name
+	}
+	return r.Org + "/" + r.Name + "@" + r.Constraint
+}
+
+// Matches reports whether agent has the reference's org and name.
+func (r *AgentRef) Matches(agent *Agent) bool {
+	return slugify(agent.ProviderName()) == slugify(r.Org) && slugify(agent.Name) == slugify(r.Name)
+}
+
+// Allows reports whether version satisfies the reference's constraint.
+func (r *AgentRef) Allows(version string) bool {
+	return versionAllowed(r.constraints, version)
+}
+
+// versionAllowed reports whether version satisfies every clause.
+func versionAllowed(clauses []versionClause, version string) bool {
+	for _, clause := range clauses {
+		cmp := compareRefVersions(version, clause.version)
+		var ok bool
+		switch clause.op {
+		case "=":
+			ok = cmp == 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compareRefVersions is compareVersions with missing components read as
+// zero, so that "1.2" and "1.2.0" are equal.
+func compareRefVersions(a, b string) int {
+	na := strings.Count(a, ".")
+	nb := strings.Count(b, ".")
+	if na < nb {
+		a += strings.Repeat(".0", nb-na)
+	} else if nb < na {
+		b += strings.Repeat(".0", na-nb)
+	}
+	return compareVersions(a, b)
+}
+
+// ResolveRef finds the agent ref refers to. The registry has no lookup by
+// name, so candidates are found by searching for the name within the
+// org's agents and keeping exact matches. Among the versions allowed by the
+// constraint the highest wins. It returns a *NotFoundError when no agent or
+// no allowed version exists and an *AmbiguousMatchError when several agents
+// share the winning version.
+func (c *A2ARegClient) ResolveRef(ctx context.Context, ref *AgentRef) (*Agent, error) {
+	var candidates []Agent
+	for page := 1; ; page++ {
+		resp, err := c.Search(ctx, SearchRequest{
+			Query:   ref.Name,
+			Filters: &SearchFilters{Provider: ref.Org},
+			Page:    page,
+			Limit:   resolvePageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range resp.Agents {
+			if ref.Matches(&resp.Agents[i]) {
+				candidates = append(candidates, resp.Agents[i])
+			}
+		}
+		if len(resp.Agents) < resolvePageSize || (resp.Total > 0 && page*resolvePageSize >= resp.Total) {
+			break
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, withCode(NewNotFoundError(fmt.Sprintf("No agent matches %s", ref), map[string]interface{}{"ref": ref.String()}), CodeAgentNotFound)
+	}
+
+	var allowed []Agent
+	versions := make([]string, 0, len(candidates))
+	for _, agent := range candidates {
+		versions = append(versions, agent.Version)
+		if ref.Allows(agent.Version) {
+			allowed = append(allowed, agent)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, withCode(NewNotFoundError(
+			fmt.Sprintf("No version of %s/%s satisfies %s", ref.Org, ref.Name, ref.Constraint),
+			map[string]interface{}{"ref": ref.String(), "versions": versions},
+		), CodeAgentNotFound)
+	}
+
+	sort.SliceStable(allowed, func(i, j int) bool {
+		return compareRefVersions(allowed[i].Version, allowed[j].Version) > 0
+	})
+	best := 1
+	for best < len(allowed) && compareRefVersions(allowed[best].Version, allowed[0].Version) == 0 {
+		best++
+	}
+	if best > 1 {
+		return nil, NewAmbiguousMatchError(ref.String(), allowed[:best])
+	}
+	agent := allowed[0]
+	agent.interfacesFromCard()
+	return &agent, nil
+}
@@ -0,0 +1,133 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refRegistry answers every search with agents, regardless of the query.
+func refRegistry(t *testing.T, agents ...Agent) *A2ARegClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SearchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "/agents/search", r.URL.Path)
+		assert.Equal(t, "acme", req.Filters.Provider)
+		json.NewEncoder(w).Encode(map[string]interface{}{"agents": agents, "total": len(agents)})
+	}))
+	t.Cleanup(server.Close)
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+}
+
+func refAgent(id, provider, name, version string) Agent {
+	return Agent{ID: &id, Name: name, Provider: provider, Version: version}
+}
+
+func TestParseAgentRef(t *testing.T) {
+	ref, err := ParseAgentRef("acme/invoice-parser@>=1.2, <2")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", ref.Org)
+	assert.Equal(t, "invoice-parser", ref.Name)
+	assert.Equal(t, ">=1.2, <2", ref.Constraint)
+	assert.Equal(t, "acme/invoice-parser@>=1.2, <2", ref.String())
+
+	ref, err = ParseAgentRef("acme/invoice-parser")
+	require.NoError(t, err)
+	assert.Empty(t, ref.Constraint)
+	assert.True(t, ref.Allows("0.0.1"))
+
+	for _, bad := range []string{"", "invoice-parser", "/name", "acme/", "acme/a/b", "acme/x@", "acme/x@>=one", "acme/x@1.2.3.4", "acme/x@>>1"} {
+		_, err := ParseAgentRef(bad)
+		assert.Equal(t, CodeValidationFailed, ErrorCode(err), "ref %q", bad)
+	}
+}
+
+func TestAgentRef_Allows(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allowed    []string
+		rejected   []string
+	}{
+		{"1.2", []string{"1.2", "1.2.0", "v1.2.0"}, []string{"1.2.1", "1.3"}},
+		{">=1.2", []string{"1.2.0", "1.10.0", "2.0.0"}, []string{"1.1.9"}},
+		{">1.2 <=2", []string{"1.2.1", "2.0.0"}, []string{"1.2.0", "2.0.1"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{"~1.2", []string{"1.2.0", "1.2.7"}, []string{"1.3.0", "1.1.0"}},
+		{"~1", []string{"1.0.0", "1.9.9"}, []string{"2.0.0"}},
+		{"*", []string{"0.0.1", "9.9.9"}, nil},
+	}
+	for _, tt := range tests {
+		ref, err := ParseAgentRef("acme/x@" + tt.constraint)
+		require.NoError(t, err, tt.constraint)
+		for _, v := range tt.allowed {
+			assert.True(t, ref.Allows(v), "%s should allow %s", tt.constraint, v)
+		}
+		for _, v := range tt.rejected {
+			assert.False(t, ref.Allows(v), "%s should reject %s", tt.constraint, v)
+		}
+	}
+}
+
+func TestResolveRef(t *testing.T) {
+	client := refRegistry(t,
+		refAgent("a1", "acme", "Invoice Parser", "1.1.0"),
+		refAgent("a2", "ACME", "invoice parser", "1.4.0"),
+		refAgent("a3", "acme", "Invoice Parser", "1.10.0"),
+		refAgent("a4", "acme", "Invoice Parser", "2.0.0"),
+		refAgent("a5", "acme", "Invoice Parser Pro", "9.0.0"),
+	)
+
+	resolve := func(s string) (*Agent, error) {
+		ref, err := ParseAgentRef(s)
+		require.NoError(t, err)
+		return client.ResolveRef(context.Background(), ref)
+	}
+
+	agent, err := resolve("acme/invoice-parser")
+	require.NoError(t, err)
+	assert.Equal(t, "a4", *agent.ID)
+
+	agent, err = resolve("acme/invoice-parser@>=1.2,<2")
+	require.NoError(t, err)
+	assert.Equal(t, "a3", *agent.ID, "1.10.0 is the highest satisfying version")
+
+	agent, err = resolve("acme/invoice-parser@1.4")
+	require.NoError(t, err)
+	assert.Equal(t, "a2", *agent.ID)
+
+	_, err = resolve("acme/invoice-parser@3.0.0")
+	var notFound *NotFoundError
+	require.True(t, errors.As(err, &notFound), "expected a NotFoundError, got %v", err)
+	assert.Equal(t, CodeAgentNotFound, notFound.Code)
+	assert.Equal(t, []string{"1.1.0", "1.4.0", "1.10.0", "2.0.0"}, notFound.Details["versions"])
+
+	_, err = resolve("acme/receipt-parser")
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+}
+
+func TestResolveRef_Ambiguous(t *testing.T) {
+	client := refRegistry(t,
+		refAgent("a1", "acme", "Invoice Parser", "1.2.0"),
+		refAgent("a2", "acme", "invoice-parser", "1.2"),
+		refAgent("a3", "acme", "Invoice Parser", "1.0.0"),
+	)
+
+	ref, err := ParseAgentRef("acme/invoice-parser@^1")
+	require.NoError(t, err)
+	_, err = client.ResolveRef(context.Background(), ref)
+
+	var ambiguous *AmbiguousMatchError
+	require.True(t, errors.As(err, &ambiguous), "expected an AmbiguousMatchError, got %v", err)
+	assert.Equal(t, CodeAmbiguousMatch, ErrorCode(err))
+	require.Len(t, ambiguous.Candidates, 2)
+	assert.Equal(t, "a1", *ambiguous.Candidates[0].ID)
+	assert.Equal(t, "a2", *ambiguous.Candidates[1].ID)
+}
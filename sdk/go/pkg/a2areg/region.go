@@ -0,0 +1,71 @@
+package a2areg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// labelKeyPattern is the form of a label key: a DNS label, lowercase
+// letters, digits and inner hyphens, at most 63 characters.
+var labelKeyPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// checkLabels rejects label keys that are not DNS labels.
+func checkLabels(labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !labelKeyPattern.MatchString(key) {
+			return NewValidationError(fmt.Sprintf("Invalid label key %q: must be a DNS label (lowercase letters, digits and '-', at most 63 characters)", key), map[string]interface{}{"label": key})
+		}
+	}
+	return nil
+}
+
+// SelectNearestAgent picks the agent in the most preferred region.
+// preference lists regions from most to least preferred, compared
+// case-insensitively. Agents in none of them are only picked when no agent
+// is in a preferred region. Ties are broken by ID, then by name, so the
+// choice is the same whatever the order of agents. It returns false if
+// agents is empty.
+func SelectNearestAgent(agents []Agent, preference []string) (Agent, bool) {
+	if len(agents) == 0 {
+		return Agent{}, false
+	}
+	rank := make(map[string]int, len(preference))
+	for i, region := range preference {
+		region = strings.ToLower(region)
+		if _, dup := rank[region]; !dup {
+			rank[region] = i
+		}
+	}
+	rankOf := func(agent *Agent) int {
+		if r, ok := rank[strings.ToLower(agent.Region)]; ok {
+			return r
+		}
+		return len(preference)
+	}
+
+	best := &agents[0]
+	for i := 1; i < len(agents); i++ {
+		candidate := &agents[i]
+		cr, br := rankOf(candidate), rankOf(best)
+		if cr < br || (cr == br && nearestTieBefore(candidate, best)) {
+			best = candidate
+		}
+	}
+	return *best, true
+}
+
+// nearestTieBefore orders agents of equal rank by ID, then name.
+func nearestTieBefore(a, b *Agent) bool {
+	aID, bID := getStringValue(a.ID, ""), getStringValue(b.ID, "")
+	if aID != bID {
+		return aID < bID
+	}
+	return a.Name < b.Name
+}
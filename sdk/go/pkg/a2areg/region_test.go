@@ -0,0 +1,110 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func regionAgent(id, region string) Agent {
+	return Agent{ID: &id, Name: "Recipe Agent", Region: region}
+}
+
+func TestSelectNearestAgent(t *testing.T) {
+	agents := []Agent{
+		regionAgent("agent-4", "us-east-1"),
+		regionAgent("agent-3", "EU-West-1"),
+		regionAgent("agent-2", "ap-south-1"),
+		regionAgent("agent-1", "eu-west-1"),
+	}
+
+	tests := []struct {
+		name       string
+		preference []string
+		want       string
+	}{
+		{"first preference wins", []string{"eu-west-1", "us-east-1"}, "agent-1"},
+		{"falls through preferences", []string{"eu-central-1", "us-east-1"}, "agent-4"},
+		{"no preferred region", []string{"sa-east-1"}, "agent-1"},
+		{"no preference", nil, "agent-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent, ok := SelectNearestAgent(agents, tt.preference)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, *agent.ID)
+
+			// The choice does not depend on input order.
+			reversed := make([]Agent, len(agents))
+			for i := range agents {
+				reversed[len(agents)-1-i] = agents[i]
+			}
+			agent, _ = SelectNearestAgent(reversed, tt.preference)
+			assert.Equal(t, tt.want, *agent.ID)
+		})
+	}
+
+	_, ok := SelectNearestAgent(nil, []string{"eu-west-1"})
+	assert.False(t, ok)
+}
+
+func TestRegion_FilterEncoding(t *testing.T) {
+	var query string
+	var searchBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/agents/search" {
+			json.NewDecoder(r.Body).Decode(&searchBody)
+		} else {
+			query = r.URL.RawQuery
+		}
+		w.Write([]byte(`{"agents": [{"id": "agent-1", "name": "Recipe Agent", "region": "eu-west-1", "labels": {"tier": "gold"}}], "total": 1}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	agents, err := client.ListAllAgents(context.Background(), IterOptions{PublicOnly: true, Region: "eu-west-1"})
+	require.NoError(t, err)
+	assert.Contains(t, query, "region=eu-west-1")
+	require.Len(t, agents, 1)
+	assert.Equal(t, "eu-west-1", agents[0].Region)
+	assert.Equal(t, map[string]string{"tier": "gold"}, agents[0].Labels)
+
+	_, err = client.Search(context.Background(), SearchRequest{Query: "recipes", Filters: &SearchFilters{Region: "eu-west-1"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"region": "eu-west-1"}, searchBody["filters"])
+
+	assert.NotEqual(t,
+		searchCacheKey(SearchRequest{Query: "recipes", Filters: &SearchFilters{Region: "eu-west-1"}}),
+		searchCacheKey(SearchRequest{Query: "recipes"}))
+}
+
+func TestRegion_PublishAndLabels(t *testing.T) {
+	var posted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&posted)
+		w.Write([]byte(`{"id": "agent-1", "name": "Recipe Agent"}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	agent := testPublishAgent()
+	agent.Region = "eu-west-1"
+	agent.Labels = map[string]string{"tier": "gold", "team-2": "search"}
+	_, err := client.PublishAgent(agent, true)
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", posted["region"])
+	assert.Equal(t, map[string]interface{}{"tier": "gold", "team-2": "search"}, posted["labels"])
+
+	for _, key := range []string{"Tier", "-tier", "tier-", "tier.level", "", string(make([]byte, 64))} {
+		agent.Labels = map[string]string{key: "x"}
+		var validationErr *ValidationError
+		assert.ErrorAs(t, client.ValidateAgent(agent), &validationErr, key)
+		_, err := client.PublishAgent(agent, false)
+		assert.ErrorAs(t, err, &validationErr, key)
+	}
+}
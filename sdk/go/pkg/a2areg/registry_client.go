@@ -0,0 +1,34 @@
+package a2areg
+
+import "context"
+
+// RegistryClient is the set of registry operations offered by
+// *A2ARegClient. Code that talks to the registry can accept a
+// RegistryClient instead, so its tests can substitute
+// a2aregtest.FakeClient or a mock of their own. Under the a2areg_strict
+// build tag it leaves out the deprecated methods, like *A2ARegClient.
+type RegistryClient interface {
+	legacyRegistryClient
+
+	Health(ctx context.Context, opts ...RequestOption) (*HealthStatus, error)
+	Ready(ctx context.Context, opts ...RequestOption) (bool, error)
+	GetRegistryStats(opts ...RequestOption) (*RegistryStats, error)
+
+	ListAgentsPage(ctx context.Context, page, limit int, publicOnly bool, opts ...RequestOption) (*AgentList, error)
+	ListSummaries(page, limit int, publicOnly bool, opts ...RequestOption) ([]AgentSummary, error)
+	GetAgent(agentID string, opts ...RequestOption) (*Agent, error)
+	GetAgentCard(agentID string, opts ...RequestOption) (*AgentCardSpec, error)
+	Search(ctx context.Context, req SearchRequest, opts ...RequestOption) (*SearchResponse, error)
+
+	PublishAgent(agent *Agent, validate bool, opts ...RequestOption) (*Agent, error)
+	UpdateAgent(agentID string, agent *Agent, opts ...RequestOption) (*Agent, error)
+	DeleteAgent(agentID string, opts ...RequestOption) error
+	ValidateAgent(agent *Agent) error
+
+	GenerateAPIKey(scopes []string, expiresDays *int) (string, ResponseMap, error)
+	ValidateAPIKey(apiKey string, requiredScopes []string) (ResponseMap, error)
+	RevokeAPIKey(keyID string) (bool, error)
+	ListAPIKeys(activeOnly bool) ([]ResponseMap, error)
+}
+
+var _ RegistryClient = (*A2ARegClient)(nil)
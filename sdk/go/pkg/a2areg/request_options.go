@@ -0,0 +1,133 @@
+package a2areg
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestOption adjusts a single registry call. Options apply only to the
+// call they are passed to; the client's own settings are never changed.
+type RequestOption func(*requestOptions)
+
+// requestOptions is the result of applying RequestOptions.
+type requestOptions struct {
+	changeReason   string
+	idempotencyKey string
+	timeout        time.Duration
+	header         http.Header
+	query          map[string]string
+	// verifySignature is set by WithVerifySignature.
+	verifySignature *VerifyOptions
+	// starred is set by WithStarred.
+	starred bool
+	// cardProfile is set by WithCardProfile.
+	cardProfile CardProfile
+	// includeDeprecated is set by WithIncludeDeprecated.
+	includeDeprecated bool
+}
+
+// requestOptionsKey is the context key carrying requestOptions from the
+// public methods down to send.
+type requestOptionsKey struct{}
+
+// WithHeader sets header key to value on the request, replacing the value
+// the client would send. Credentials are set after it, so it cannot
+// override the client's Authorization or API key header. Setting
+// Content-Type sends the request body as JSON even when the client has a
+// Codec.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Set(key, value)
+	}
+}
+
+// WithQueryParam sets query parameter key to value, replacing any value the
+// method itself sends.
+func WithQueryParam(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.query == nil {
+			o.query = make(map[string]string)
+		}
+		o.query[key] = value
+	}
+}
+
+// WithTimeout bounds the call by d instead of the client's Timeout; d may
+// be shorter or longer. A deadline on the call's context still applies.
+func WithTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+// WithIdempotencyKey sends key in the Idempotency-Key header, so the
+// registry applies a mutation at most once. The same key is sent when the
+// request is retried after a token refresh or replayed from the offline
+// queue.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(o *requestOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithContextOptions returns a context carrying opts, for methods that take
+// a context rather than RequestOptions. Options passed to a method are
+// applied after those carried by its context.
+func WithContextOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	return withRequestOptions(ctx, opts)
+}
+
+// withRequestOptions returns ctx carrying the options it already carries
+// with opts applied on top, or ctx itself if there are none.
+func withRequestOptions(ctx context.Context, opts []RequestOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	o := requestOptionsFrom(ctx).clone()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return context.WithValue(ctx, requestOptionsKey{}, o)
+}
+
+// requestOptionsFrom returns the request options carried by ctx.
+func requestOptionsFrom(ctx context.Context) requestOptions {
+	o, _ := ctx.Value(requestOptionsKey{}).(requestOptions)
+	return o
+}
+
+// clone returns a copy of o that options can modify without affecting o.
+func (o requestOptions) clone() requestOptions {
+	o.header = o.header.Clone()
+	if o.query != nil {
+		query := make(map[string]string, len(o.query))
+		for k, v := range o.query {
+			query[k] = v
+		}
+		o.query = query
+	}
+	return o
+}
+
+// varies reports whether o can change what the registry answers, in which
+// case the response must neither come from nor go into a cache.
+func (o requestOptions) varies() bool {
+	return len(o.header) > 0 || len(o.query) > 0
+}
+
+// setHeaders adds the headers o asks for to h.
+func (o requestOptions) setHeaders(h http.Header) {
+	for key, values := range o.header {
+		h[key] = append([]string(nil), values...)
+	}
+	if o.changeReason != "" {
+		h.Set("X-Change-Reason", o.changeReason)
+	}
+	if o.idempotencyKey != "" {
+		h.Set("Idempotency-Key", o.idempotencyKey)
+	}
+}
@@ -0,0 +1,215 @@
+package a2areg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerRegistry records the headers and query of every registry request.
+// While failures is positive it answers 503 and counts it down.
+type headerRegistry struct {
+	*httptest.Server
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+}
+
+func newHeaderRegistry(t *testing.T, failures int) *headerRegistry {
+	r := &headerRegistry{failures: failures}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.requests = append(r.requests, req)
+		fail := r.failures > 0
+		if fail {
+			r.failures--
+		}
+		r.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id": "agent-1", "name": "Recipe Agent", "status": "healthy"}`))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *headerRegistry) last() *http.Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests[len(r.requests)-1]
+}
+
+func (r *headerRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func TestRequestOptions_HeadersAndQueryOnTheWire(t *testing.T) {
+	registry := newHeaderRegistry(t, 0)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	_, err := client.GetAgent("agent-1",
+		WithHeader("X-Tenant-ID", "acme"),
+		WithHeader("User-Agent", "recipes/2.0"),
+		WithQueryParam("include", "card"),
+	)
+	require.NoError(t, err)
+	req := registry.last()
+	assert.Equal(t, "acme", req.Header.Get("X-Tenant-ID"))
+	assert.Equal(t, "recipes/2.0", req.Header.Get("User-Agent"))
+	assert.Equal(t, "test", req.Header.Get("X-API-Key"))
+	assert.Equal(t, "card", req.URL.Query().Get("include"))
+
+	_, err = client.ListAgentsPage(context.Background(), 1, 10, true, WithQueryParam("limit", "5"))
+	require.NoError(t, err)
+	assert.Equal(t, "5", registry.last().URL.Query().Get("limit"))
+
+	_, err = client.PublishAgent(testPublishAgent(), false, WithIdempotencyKey("publish-42"))
+	require.NoError(t, err)
+	assert.Equal(t, "publish-42", registry.last().Header.Get("Idempotency-Key"))
+
+	// Options never stick to the client.
+	_, err = client.GetAgent("agent-1")
+	require.NoError(t, err)
+	req = registry.last()
+	assert.Empty(t, req.Header.Get("X-Tenant-ID"))
+	assert.Empty(t, req.Header.Get("Idempotency-Key"))
+	assert.Equal(t, "A2A-Go-SDK/1.0.0", req.Header.Get("User-Agent"))
+	assert.Empty(t, req.URL.RawQuery)
+}
+
+func TestRequestOptions_CannotOverrideCredentials(t *testing.T) {
+	registry := newHeaderRegistry(t, 0)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	_, err := client.Health(context.Background(), WithHeader("X-API-Key", "other"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test"}, registry.last().Header.Values("X-API-Key"))
+}
+
+func TestWithContextOptions_Composes(t *testing.T) {
+	registry := newHeaderRegistry(t, 0)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test"})
+
+	ctx := WithContextOptions(context.Background(), WithHeader("X-Tenant-ID", "acme"), WithQueryParam("region", "eu"))
+	_, err := client.Search(ctx, SearchRequest{Query: "recipes"}, WithQueryParam("region", "us"))
+	require.NoError(t, err)
+	req := registry.last()
+	assert.Equal(t, "acme", req.Header.Get("X-Tenant-ID"))
+	assert.Equal(t, "us", req.URL.Query().Get("region"))
+
+	// The per-call option did not leak into the context's options.
+	require.NoError(t, client.SetAgentVisibility(ctx, "agent-1", VisibilityPublic))
+	assert.Equal(t, "eu", registry.last().URL.Query().Get("region"))
+}
+
+func TestWithTimeout_OverridesClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(200 * time.Millisecond):
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", Timeout: time.Minute})
+	start := time.Now()
+	_, err := client.Health(context.Background(), WithTimeout(20*time.Millisecond))
+	assert.Equal(t, CodeRequestFailed, ErrorCode(err))
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+
+	// A longer per-call timeout wins over a short client timeout, and the
+	// client's own timeout is unchanged afterwards.
+	client = NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", Timeout: 20 * time.Millisecond})
+	_, err = client.Health(context.Background(), WithTimeout(time.Minute))
+	require.NoError(t, err)
+	_, err = client.Health(context.Background())
+	assert.Equal(t, CodeRequestFailed, ErrorCode(err))
+}
+
+func TestWithIdempotencyKey_ReusedAcrossRetries(t *testing.T) {
+	var mu sync.Mutex
+	var tokens int
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/auth/oauth/token" {
+			tokens++
+			fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600}`, tokens)
+			return
+		}
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id": "agent-1", "name": "Recipe Agent"}`))
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, ClientID: "id", ClientSecret: "secret"})
+	_, err := client.PublishAgent(testPublishAgent(), false, WithIdempotencyKey("publish-42"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"publish-42", "publish-42"}, keys)
+}
+
+func TestRequestOptions_ReplayedFromOfflineQueue(t *testing.T) {
+	registry := newHeaderRegistry(t, 1)
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:        registry.URL,
+		APIKey:             "test",
+		Clock:              clock.NewFake(time.Now()),
+		EnableOfflineQueue: true,
+		OfflineQueueTTL:    time.Hour,
+	})
+
+	err := client.DeleteAgent("agent-1", WithIdempotencyKey("delete-7"), WithHeader("X-Tenant-ID", "acme"), WithQueryParam("hard", "true"))
+	var queued *QueuedError
+	require.ErrorAs(t, err, &queued)
+	assert.Equal(t, "delete-7", queued.Operation.IdempotencyKey)
+
+	require.NoError(t, client.FlushOfflineQueue(context.Background()))
+	assert.Equal(t, 2, registry.count())
+	req := registry.last()
+	assert.Equal(t, "delete-7", req.Header.Get("Idempotency-Key"))
+	assert.Equal(t, "acme", req.Header.Get("X-Tenant-ID"))
+	assert.Equal(t, "true", req.URL.Query().Get("hard"))
+}
+
+func TestRequestOptions_BypassCaches(t *testing.T) {
+	registry := newHeaderRegistry(t, 0)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "test", CardCache: NewMemoryStore(0), EnableSearchCache: true})
+
+	_, err := client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	_, err = client.GetAgentCard("agent-1", WithHeader("X-Tenant-ID", "acme"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, registry.count())
+
+	_, err = client.Search(context.Background(), SearchRequest{Query: "recipes"}, WithHeader("X-Tenant-ID", "acme"))
+	require.NoError(t, err)
+	_, err = client.Search(context.Background(), SearchRequest{Query: "recipes"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, registry.count())
+
+	// A timeout alone does not change the answer, so the cache still serves.
+	_, err = client.Search(context.Background(), SearchRequest{Query: "recipes"}, WithTimeout(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 4, registry.count())
+}
@@ -0,0 +1,77 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxReportedFields is the number of largest fields named in a
+// request-too-large error.
+const maxReportedFields = 3
+
+// fieldSize is the marshaled size of one section of a request body.
+type fieldSize struct {
+	Field string `json:"field"`
+	Bytes int    `json:"bytes"`
+}
+
+// newRequestTooLargeError builds a ValidationError reporting the body size,
+// the limit, and the fields that contribute most to the size.
+func newRequestTooLargeError(body []byte, limit int64) *ValidationError {
+	largest := largestFields(body, maxReportedFields)
+
+	parts := make([]string, 0, len(largest))
+	for _, f := range largest {
+		parts = append(parts, fmt.Sprintf("%s (%d bytes)", f.Field, f.Bytes))
+	}
+	message := fmt.Sprintf("Request body is %d bytes, exceeding the %d-byte limit", len(body), limit)
+	if len(parts) > 0 {
+		message += "; largest fields: " + strings.Join(parts, ", ")
+	}
+
+	return withCode(NewValidationError(message, map[string]interface{}{
+		"size":           len(body),
+		"limit":          limit,
+		"largest_fields": largest,
+	}), CodeRequestTooLarge)
+}
+
+// largestFields returns up to n top-level sections of a JSON object body,
+// largest first. When one section dominates and is itself an object (such as
+// the "card" in a publish payload), its sections are reported instead so the
+// result points at something the caller can actually trim.
+func largestFields(body []byte, n int) []fieldSize {
+	var sizes []fieldSize
+	prefix := ""
+	for {
+		var sections map[string]json.RawMessage
+		if err := json.Unmarshal(body, &sections); err != nil || len(sections) == 0 {
+			break
+		}
+
+		sizes = sizes[:0]
+		for key, raw := range sections {
+			sizes = append(sizes, fieldSize{Field: prefix + key, Bytes: len(raw)})
+		}
+		sort.Slice(sizes, func(i, j int) bool {
+			if sizes[i].Bytes != sizes[j].Bytes {
+				return sizes[i].Bytes > sizes[j].Bytes
+			}
+			return sizes[i].Field < sizes[j].Field
+		})
+
+		top := sections[strings.TrimPrefix(sizes[0].Field, prefix)]
+		if len(top) == 0 || top[0] != '{' || len(top)*2 < len(body) {
+			break
+		}
+		prefix = sizes[0].Field + "."
+		body = top
+	}
+
+	if len(sizes) > n {
+		sizes = sizes[:n]
+	}
+	return sizes
+}
@@ -0,0 +1,105 @@
+package a2areg
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// ResponseMap is a registry response decoded without a fixed schema. Numbers
+// are kept as json.Number, so counters and IDs beyond 2^53 keep their exact
+// value; read them with GetInt64 or GetFloat64 rather than asserting
+// float64.
+type ResponseMap map[string]interface{}
+
+// decodeResponseMap decodes body into a ResponseMap, keeping numbers as
+// json.Number.
+func decodeResponseMap(body []byte) (ResponseMap, error) {
+	var m ResponseMap
+	if err := decodeNumbers(body, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decodeNumbers is json.Unmarshal with UseNumber.
+func decodeNumbers(body []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// GetString returns the value at key if it is a string.
+func (m ResponseMap) GetString(key string) (string, bool) {
+	s, ok := m[key].(string)
+	return s, ok
+}
+
+// GetInt64 returns the value at key as an int64. It accepts json.Number,
+// Go integer and float64 values; floats must be integral.
+func (m ResponseMap) GetInt64(key string) (int64, bool) {
+	switch v := m[key].(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, true
+		}
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return int64(f), true
+		}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v), true
+		}
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// GetFloat64 returns the numeric value at key as a float64. Callers that
+// asserted m[key].(float64) before numbers were kept as json.Number can
+// switch to this.
+func (m ResponseMap) GetFloat64(key string) (float64, bool) {
+	switch v := m[key].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// GetTime returns the value at key as a time. Strings are parsed as
+// RFC 3339; numbers are Unix epochs in seconds, or in milliseconds when
+// they are too large to be seconds (beyond the year 5138).
+func (m ResponseMap) GetTime(key string) (time.Time, bool) {
+	if s, ok := m[key].(string); ok {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	}
+	if n, ok := m.GetInt64(key); ok {
+		if n >= 1e11 || n <= -1e11 {
+			return time.UnixMilli(n), true
+		}
+		return time.Unix(n, 0), true
+	}
+	if f, ok := m.GetFloat64(key); ok {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	}
+	return time.Time{}, false
+}
+
+// GetMap returns the object at key as a ResponseMap.
+func (m ResponseMap) GetMap(key string) (ResponseMap, bool) {
+	v, ok := m[key].(map[string]interface{})
+	return ResponseMap(v), ok
+}
@@ -0,0 +1,106 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRegistryStats_LargeCounters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"total_requests": 9007199254740993, "total_agents": 12, "uptime_ratio": 0.999, "started_at": 1735689600, "data": {"nested": 9007199254740995}}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	stats, err := client.GetRegistryStats()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(12), stats.TotalAgents)
+
+	// 2^53 + 1 is not representable as a float64.
+	extra := stats.Extra
+	requests, ok := extra.GetInt64("total_requests")
+	require.True(t, ok)
+	assert.Equal(t, int64(9007199254740993), requests)
+
+	ratio, ok := extra.GetFloat64("uptime_ratio")
+	require.True(t, ok)
+	assert.Equal(t, 0.999, ratio)
+	_, ok = extra.GetInt64("uptime_ratio")
+	assert.False(t, ok)
+
+	started, ok := extra.GetTime("started_at")
+	require.True(t, ok)
+	assert.True(t, started.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	nested, ok := extra.GetMap("data")
+	require.True(t, ok)
+	n, ok := nested.GetInt64("nested")
+	require.True(t, ok)
+	assert.Equal(t, int64(9007199254740995), n)
+}
+
+func TestListAPIKeys_KeepsNumbers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"key_id": 18014398509481985, "created_at": "2024-01-01T00:00:00Z", "expires_at": 1735689600000}]`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+
+	keys, err := client.ListAPIKeys(true)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	id, ok := keys[0].GetInt64("key_id")
+	require.True(t, ok)
+	assert.Equal(t, int64(18014398509481985), id)
+
+	created, ok := keys[0].GetTime("created_at")
+	require.True(t, ok)
+	assert.True(t, created.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	expires, ok := keys[0].GetTime("expires_at")
+	require.True(t, ok, "millisecond epochs are recognised")
+	assert.True(t, expires.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestResponseMap_Accessors(t *testing.T) {
+	m := ResponseMap{
+		"legacy": float64(42),
+		"int":    7,
+		"frac":   json.Number("1.5"),
+		"name":   "registry",
+		"bad":    "soon",
+	}
+
+	n, ok := m.GetInt64("legacy")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), n)
+	n, ok = m.GetInt64("int")
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), n)
+	_, ok = m.GetInt64("frac")
+	assert.False(t, ok)
+	_, ok = m.GetInt64("missing")
+	assert.False(t, ok)
+
+	f, ok := m.GetFloat64("frac")
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, f)
+
+	s, ok := m.GetString("name")
+	assert.True(t, ok)
+	assert.Equal(t, "registry", s)
+
+	_, ok = m.GetTime("bad")
+	assert.False(t, ok)
+	ts, ok := m.GetTime("frac")
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1, 5e8), ts)
+}
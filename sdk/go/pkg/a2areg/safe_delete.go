@@ -0,0 +1,150 @@
+package a2areg
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// DeleteConfirmationTTL is how long a DeleteConfirmation stays valid after
+// PrepareDelete issued it.
+const DeleteConfirmationTTL = 10 * time.Minute
+
+// DeleteConfirmation identifies an agent that is about to be deleted. It is
+// produced by PrepareDelete and consumed, exactly once, by ConfirmDelete.
+type DeleteConfirmation struct {
+	AgentID  string `json:"agent_id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	// Token binds the confirmation to the agent's state when it was
+	// prepared. It is single-use.
+	Token string `json:"token"`
+	// ExpiresAt is when the token stops being accepted, DeleteConfirmationTTL
+	// after it was issued.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// pendingDeletes tracks prepared, not yet confirmed, deletions by token.
+type pendingDeletes struct {
+	mu     sync.Mutex
+	tokens map[string]pendingDelete
+}
+
+type pendingDelete struct {
+	agentID     string
+	fingerprint string
+	issuedAt    time.Time
+}
+
+// sweepLocked drops the tokens issued DeleteConfirmationTTL or more before
+// now, so abandoned confirmations don't accumulate.
+func (p *pendingDeletes) sweepLocked(now time.Time) {
+	for token, pending := range p.tokens {
+		if pending.expired(now) {
+			delete(p.tokens, token)
+		}
+	}
+}
+
+func (d pendingDelete) expired(now time.Time) bool {
+	return !now.Before(d.issuedAt.Add(DeleteConfirmationTTL))
+}
+
+// PrepareDelete fetches the agent and returns a DeleteConfirmation for it
+// without deleting anything. It is what DeleteAgent does in SafeDelete mode,
+// and may be used directly regardless of that option.
+func (c *A2ARegClient) PrepareDelete(ctx context.Context, agentID string) (*DeleteConfirmation, error) {
+	body, err := c.getAgent(ctx, agentID, nil)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := agentFingerprint(body)
+	if err != nil {
+		return nil, err
+	}
+	var agent Agent
+	if err := json.Unmarshal(body, &agent); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, withCode(NewA2AError("Failed to generate confirmation token", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	sum := sha256.Sum256(append([]byte(fingerprint), nonce...))
+	token := hex.EncodeToString(sum[:])
+
+	now := c.clock.Now()
+	p := &c.pendingDeletes
+	p.mu.Lock()
+	if p.tokens == nil {
+		p.tokens = make(map[string]pendingDelete)
+	}
+	p.sweepLocked(now)
+	p.tokens[token] = pendingDelete{agentID: agentID, fingerprint: fingerprint, issuedAt: now}
+	p.mu.Unlock()
+
+	return &DeleteConfirmation{
+		AgentID:   agentID,
+		Name:      agent.Name,
+		Provider:  agent.ProviderName(),
+		Token:     token,
+		ExpiresAt: now.Add(DeleteConfirmationTTL),
+	}, nil
+}
+
+// ConfirmDelete deletes the agent described by confirmation. It fails
+// without deleting if the token is unknown, expired or was already used, or
+// if the agent has changed since the confirmation was prepared.
+func (c *A2ARegClient) ConfirmDelete(ctx context.Context, confirmation *DeleteConfirmation) error {
+	if confirmation == nil {
+		return withCode(NewValidationError("Delete confirmation is required", nil), CodeConfirmationInvalid)
+	}
+
+	now := c.clock.Now()
+	p := &c.pendingDeletes
+	p.mu.Lock()
+	pending, ok := p.tokens[confirmation.Token]
+	delete(p.tokens, confirmation.Token) // single use, whatever the outcome
+	p.sweepLocked(now)
+	p.mu.Unlock()
+	if !ok || pending.agentID != confirmation.AgentID {
+		return withCode(NewValidationError("Delete confirmation token is invalid or has already been used", map[string]interface{}{"agent_id": confirmation.AgentID}), CodeConfirmationInvalid)
+	}
+	if pending.expired(now) {
+		return withCode(NewValidationError("Delete confirmation token has expired; prepare the deletion again", map[string]interface{}{"agent_id": confirmation.AgentID}), CodeConfirmationInvalid)
+	}
+
+	body, err := c.getAgent(ctx, pending.agentID, nil)
+	if err != nil {
+		return err
+	}
+	fingerprint, err := agentFingerprint(body)
+	if err != nil {
+		return err
+	}
+	if fingerprint != pending.fingerprint {
+		return withCode(NewA2AError("Agent changed since the deletion was prepared; prepare it again", map[string]interface{}{"agent_id": pending.agentID}), CodeAgentChanged)
+	}
+
+	return c.deleteAgent(ctx, pending.agentID)
+}
+
+// agentFingerprint hashes an agent document in canonical form (object keys
+// sorted), so that formatting differences don't count as changes.
+func agentFingerprint(body []byte) (string, error) {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return "", withCode(NewA2AError("Failed to decode agent response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	canonical, err := json.Marshal(document)
+	if err != nil {
+		return "", withCode(NewA2AError("Failed to encode agent", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,135 @@
+package a2areg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// safeDeleteServer serves a single agent whose version can be bumped, and
+// counts DELETE requests.
+type safeDeleteServer struct {
+	mu      sync.Mutex
+	version string
+	deletes int
+}
+
+func (s *safeDeleteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case "GET":
+		w.Write([]byte(`{"id":"agent-1","name":"Billing Agent","provider":"acme","version":"` + s.version + `"}`))
+	case "DELETE":
+		s.deletes++
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newSafeDeleteClient(t *testing.T) (*A2ARegClient, *safeDeleteServer) {
+	return newSafeDeleteClientWithClock(t, nil)
+}
+
+func newSafeDeleteClientWithClock(t *testing.T, c Clock) (*A2ARegClient, *safeDeleteServer) {
+	backend := &safeDeleteServer{version: "1.0.0"}
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key", SafeDelete: true, Clock: c}), backend
+}
+
+func TestA2ARegClient_SafeDelete_ConfirmFlow(t *testing.T) {
+	client, backend := newSafeDeleteClient(t)
+
+	err := client.DeleteAgent("agent-1")
+	var confirmErr *DeleteConfirmationRequiredError
+	require.ErrorAs(t, err, &confirmErr)
+	assert.Equal(t, CodeConfirmationRequired, ErrorCode(err))
+	assert.Equal(t, 0, backend.deletes)
+
+	confirmation := confirmErr.Confirmation
+	assert.Equal(t, "agent-1", confirmation.AgentID)
+	assert.Equal(t, "Billing Agent", confirmation.Name)
+	assert.Equal(t, "acme", confirmation.Provider)
+	assert.NotEmpty(t, confirmation.Token)
+
+	require.NoError(t, client.ConfirmDelete(context.Background(), confirmation))
+	assert.Equal(t, 1, backend.deletes)
+}
+
+func TestA2ARegClient_SafeDelete_RejectsChangedAgent(t *testing.T) {
+	client, backend := newSafeDeleteClient(t)
+
+	confirmation, err := client.PrepareDelete(context.Background(), "agent-1")
+	require.NoError(t, err)
+
+	backend.mu.Lock()
+	backend.version = "1.0.1"
+	backend.mu.Unlock()
+
+	err = client.ConfirmDelete(context.Background(), confirmation)
+	assert.Equal(t, CodeAgentChanged, ErrorCode(err))
+	assert.Equal(t, 0, backend.deletes)
+}
+
+func TestA2ARegClient_SafeDelete_RefusesTokenReuse(t *testing.T) {
+	client, backend := newSafeDeleteClient(t)
+
+	confirmation, err := client.PrepareDelete(context.Background(), "agent-1")
+	require.NoError(t, err)
+	require.NoError(t, client.ConfirmDelete(context.Background(), confirmation))
+
+	err = client.ConfirmDelete(context.Background(), confirmation)
+	assert.Equal(t, CodeConfirmationInvalid, ErrorCode(err))
+	assert.Equal(t, 1, backend.deletes)
+
+	forged := *confirmation
+	forged.Token = "not-a-token"
+	assert.Equal(t, CodeConfirmationInvalid, ErrorCode(client.ConfirmDelete(context.Background(), &forged)))
+}
+
+func TestA2ARegClient_SafeDelete_TokensExpire(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client, backend := newSafeDeleteClientWithClock(t, fake)
+
+	stale, err := client.PrepareDelete(context.Background(), "agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, fake.Now().Add(DeleteConfirmationTTL), stale.ExpiresAt)
+
+	fake.Advance(DeleteConfirmationTTL)
+	err = client.ConfirmDelete(context.Background(), stale)
+	assert.Equal(t, CodeConfirmationInvalid, ErrorCode(err))
+	assert.Contains(t, err.Error(), "expired")
+	assert.Equal(t, 0, backend.deletes)
+
+	// Abandoned tokens are swept when the next one is issued.
+	_, err = client.PrepareDelete(context.Background(), "agent-1")
+	require.NoError(t, err)
+	fake.Advance(DeleteConfirmationTTL)
+	fresh, err := client.PrepareDelete(context.Background(), "agent-1")
+	require.NoError(t, err)
+	client.pendingDeletes.mu.Lock()
+	assert.Len(t, client.pendingDeletes.tokens, 1)
+	client.pendingDeletes.mu.Unlock()
+
+	fake.Advance(DeleteConfirmationTTL - time.Second)
+	require.NoError(t, client.ConfirmDelete(context.Background(), fresh))
+	assert.Equal(t, 1, backend.deletes)
+}
+
+func TestA2ARegClient_DeleteAgent_UnsafeByDefault(t *testing.T) {
+	backend := &safeDeleteServer{version: "1.0.0"}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+	require.NoError(t, client.DeleteAgent("agent-1"))
+	assert.Equal(t, 1, backend.deletes)
+}
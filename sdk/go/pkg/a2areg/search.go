@@ -0,0 +1,248 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// SearchRequest describes a registry search.
+type SearchRequest struct {
+	Query    string         `json:"query"`
+	Filters  *SearchFilters `json:"filters,omitempty"`
+	Semantic bool           `json:"semantic"`
+	Page     int            `json:"page"`
+	Limit    int            `json:"limit"`
+	// TimeoutMS asks the registry to stop searching after this many
+	// milliseconds and return what it has found so far. Zero leaves the
+	// server default in place.
+	TimeoutMS int `json:"timeout_ms,omitempty"`
+	// AllowPartial returns a timed-out response with TimedOut set instead
+	// of a *PartialResultsError.
+	AllowPartial bool `json:"-"`
+	// NoCache skips the search cache and fetches fresh results, which
+	// then replace the cached ones.
+	NoCache bool `json:"-"`
+	// Highlight asks the registry for fragments showing where the query
+	// matched. HighlightFields limits them to the named fields ("name",
+	// "description", "skills.examples", ...); empty leaves the choice to
+	// the registry.
+	Highlight       bool     `json:"highlight,omitempty"`
+	HighlightFields []string `json:"highlight_fields,omitempty"`
+}
+
+// SearchFilters narrows a search. Empty fields do not filter.
+type SearchFilters struct {
+	Tags     []string `json:"tags,omitempty"`
+	Provider string   `json:"provider,omitempty"`
+	// Capabilities lists capabilities the agent must have enabled, by
+	// their card names ("streaming", "pushNotifications", ...).
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Skills lists skill IDs the agent must offer.
+	Skills []string `json:"skills,omitempty"`
+	// Region limits results to agents deployed in the region.
+	Region string `json:"region,omitempty"`
+	// Extra holds filters the SDK does not model, sent as given. The
+	// typed fields win over entries of the same name.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON encodes the typed filters merged into Extra.
+func (f SearchFilters) MarshalJSON() ([]byte, error) {
+	type plain SearchFilters
+	data, err := json.Marshal(plain(f))
+	if err != nil || len(f.Extra) == 0 {
+		return data, err
+	}
+	merged := make(map[string]interface{}, len(f.Extra))
+	for key, value := range f.Extra {
+		merged[key] = value
+	}
+	var typed map[string]json.RawMessage
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return nil, err
+	}
+	for key, value := range typed {
+		merged[key] = value
+	}
+	return json.Marshal(merged)
+}
+
+// SearchResponse is the result of Search.
+type SearchResponse struct {
+	Agents []Agent `json:"agents"`
+	// Scores holds the relevance score of each agent, in the same order,
+	// when the registry returned scores (semantic searches). It is nil
+	// otherwise; agents without a score get zero.
+	Scores []float64 `json:"scores,omitempty"`
+	// Highlights holds the match fragments of each agent, in the same
+	// order, when the registry returned any for a Highlight search. It is
+	// nil otherwise. Fragments are sanitized: <em> is the only markup left.
+	Highlights []map[string][]string `json:"highlights,omitempty"`
+	Total      int                   `json:"total"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	// TimedOut is set when the registry hit its search timeout before
+	// finishing; the results are whatever had been found by then.
+	TimedOut bool `json:"timed_out"`
+	// PartialResults is set when the registry reports that Agents is not
+	// the complete result set.
+	PartialResults bool `json:"partial_results"`
+	// Fallback is set when the registry has no search endpoint and the
+	// results come from a client-side scan of the listings (see
+	// A2ARegClientOptions.SearchFallback). DegradedAccuracy is then set
+	// too: matching is plain token matching, without ranking. Truncated is
+	// set when MaxFallbackPages stopped the scan early.
+	Fallback         bool `json:"fallback,omitempty"`
+	DegradedAccuracy bool `json:"degraded_accuracy,omitempty"`
+	Truncated        bool `json:"truncated,omitempty"`
+	// Err is set by SearchAgentsBatch when this query failed; the other
+	// fields are then empty, except for a *PartialResultsError.
+	Err error `json:"-"`
+}
+
+// UnmarshalJSON accepts results under "agents" or, from older registries,
+// "results". Each result is an agent document, optionally carrying a
+// "score" (or "relevance_score") and "highlights", or an object wrapping
+// the document as "agent" next to them.
+func (r *SearchResponse) UnmarshalJSON(data []byte) error {
+	type plain SearchResponse
+	var raw struct {
+		plain
+		Agents  []json.RawMessage `json:"agents"`
+		Results []json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = SearchResponse(raw.plain)
+
+	items := raw.Agents
+	if items == nil {
+		items = raw.Results
+	}
+	r.Agents = make([]Agent, len(items))
+	r.Scores = nil
+	r.Highlights = nil
+	for i, item := range items {
+		var result struct {
+			Agent          json.RawMessage `json:"agent"`
+			Score          *float64        `json:"score"`
+			RelevanceScore *float64        `json:"relevance_score"`
+			Highlights     json.RawMessage `json:"highlights"`
+		}
+		if err := json.Unmarshal(item, &result); err != nil {
+			return err
+		}
+		document := item
+		if len(result.Agent) > 0 {
+			document = result.Agent
+		}
+		if err := json.Unmarshal(document, &r.Agents[i]); err != nil {
+			return err
+		}
+
+		score := result.Score
+		if score == nil {
+			score = result.RelevanceScore
+		}
+		if score != nil {
+			if r.Scores == nil {
+				r.Scores = make([]float64, len(items))
+			}
+			r.Scores[i] = *score
+		}
+
+		if highlights := decodeHighlights(result.Highlights); highlights != nil {
+			if r.Highlights == nil {
+				r.Highlights = make([]map[string][]string, len(items))
+			}
+			r.Highlights[i] = highlights
+		}
+	}
+	return nil
+}
+
+// Search runs req against the registry. A response the registry marks as
+// timed out is returned as a *PartialResultsError carrying the partial
+// response, unless req.AllowPartial is set. Calls with a WithHeader or
+// WithQueryParam option bypass the search cache.
+func (c *A2ARegClient) Search(ctx context.Context, req SearchRequest, opts ...RequestOption) (*SearchResponse, error) {
+	resp, _, err := c.searchDocument(withRequestOptions(ctx, opts), req)
+	return resp, err
+}
+
+// searchDocument runs a search like Search. It also returns the response
+// document the result was decoded from, for SearchAgents.
+func (c *A2ARegClient) searchDocument(ctx context.Context, req SearchRequest) (*SearchResponse, []byte, error) {
+	if c.searchCache == nil || requestOptionsFrom(ctx).varies() {
+		body, err := c.search(ctx, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		resp, err := decodeSearchResponse(body, req)
+		return resp, body, err
+	}
+
+	key := searchCacheKey(req)
+	if !req.NoCache {
+		if body, ok := c.searchCache.get(key); ok {
+			resp, err := decodeSearchResponse(body, req)
+			return resp, body, err
+		}
+	}
+	generation := c.searchCache.generation.Load()
+	body, err := c.search(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := decodeSearchResponse(body, req)
+	if err == nil && !resp.TimedOut && !resp.PartialResults && !resp.DegradedAccuracy {
+		c.searchCache.put(key, body, req.Semantic, generation)
+	}
+	return resp, body, err
+}
+
+// decodeSearchResponse decodes the registry's answer to req.
+func decodeSearchResponse(body []byte, req SearchRequest) (*SearchResponse, error) {
+	var resp SearchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode search response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if resp.TimedOut && !req.AllowPartial {
+		return nil, NewPartialResultsError(&resp)
+	}
+	return &resp, nil
+}
+
+// search posts req to /agents/search, falling back to a client-side scan
+// when SearchFallback is enabled and the endpoint is missing: it answered
+// 404, 405 or 501, or the capability probe reported search disabled. The
+// endpoint is tried again searchReprobeInterval later.
+func (c *A2ARegClient) search(ctx context.Context, req SearchRequest) ([]byte, error) {
+	if c.searchFallback && c.searchMissing() {
+		return c.fallbackSearchBody(ctx, req)
+	}
+
+	body, err := c.makeRequestContext(ctx, "POST", "/agents/search", req, nil)
+	if err != nil {
+		if c.searchFallback && isSearchUnavailable(err) {
+			c.setSearchMissing(true)
+			return c.fallbackSearchBody(ctx, req)
+		}
+		return nil, err
+	}
+	c.setSearchMissing(false)
+	return body, nil
+}
+
+func (c *A2ARegClient) fallbackSearchBody(ctx context.Context, req SearchRequest) ([]byte, error) {
+	result, err := c.fallbackSearch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to encode search response", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+	return body, nil
+}
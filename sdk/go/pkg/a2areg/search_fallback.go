@@ -0,0 +1,288 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultMaxFallbackPages bounds how many listing pages a fallback search
+// reads when MaxFallbackPages is not set.
+const DefaultMaxFallbackPages = 10
+
+// fallbackPageSize is the page size requested while scanning listings for a
+// fallback search. Registries may return fewer agents per page.
+const fallbackPageSize = 100
+
+// searchReprobeInterval is how long /agents/search is treated as missing
+// once found absent; the next search after that tries it again.
+const searchReprobeInterval = 5 * time.Minute
+
+// searchMissing reports whether /agents/search was found absent within the
+// last searchReprobeInterval.
+func (c *A2ARegClient) searchMissing() bool {
+	at := c.searchUnavailableAt.Load()
+	return at != 0 && c.clock.Now().Before(time.Unix(0, at).Add(searchReprobeInterval))
+}
+
+// setSearchMissing records whether /agents/search is absent.
+func (c *A2ARegClient) setSearchMissing(missing bool) {
+	var at int64
+	if missing {
+		at = c.clock.Now().UnixNano()
+	}
+	c.searchUnavailableAt.Store(at)
+}
+
+// noteSearchCapability updates the search state from a capability probe.
+// Only an explicit answer counts: registries that report no features say
+// nothing about search.
+func (c *A2ARegClient) noteSearchCapability(caps *ServerCapabilities) {
+	if caps.Supports(FeatureSearch) {
+		c.setSearchMissing(false)
+		return
+	}
+	for _, name := range caps.disabled {
+		if name == FeatureSearch {
+			c.setSearchMissing(true)
+		}
+	}
+}
+
+// isSearchUnavailable reports whether err means the registry has no search
+// endpoint.
+func isSearchUnavailable(err error) bool {
+	switch StatusCode(err) {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// fallbackSearch emulates a search by paging through the agent listings
+// and matching client-side. Every query token must occur in the agent's
+// name, description, tags or skill tags, and the agent must pass req's
+// filters (see fallbackFilters); semantic ranking is not applied. The
+// result has the shape of a search response plus "fallback" and
+// "degraded_accuracy" set to true, and "truncated" set when
+// MaxFallbackPages stopped the scan before the listing was exhausted.
+func (c *A2ARegClient) fallbackSearch(ctx context.Context, req SearchRequest) (map[string]interface{}, error) {
+	filters, err := fallbackFilters(req.Filters)
+	if err != nil {
+		return nil, err
+	}
+	// Credentials see the entitled listing, which includes public agents.
+	publicOnly := c.apiKey.Empty() && c.clientID == ""
+	if _, ok := authFromContext(ctx); ok {
+		publicOnly = false
+	}
+	tokens := searchTokens(req.Query)
+	page, limit := req.Page, req.Limit
+
+	var matches []map[string]interface{}
+	truncated := false
+	seen := 0
+	for listPage := 1; ; listPage++ {
+		if listPage > c.maxFallbackPages {
+			truncated = true
+			break
+		}
+		body, err := c.listAgents(ctx, listPage, fallbackPageSize, publicOnly, nil)
+		if err != nil {
+			return nil, err
+		}
+		var listing struct {
+			Agents []json.RawMessage `json:"agents"`
+			Total  int               `json:"total"`
+		}
+		if err := json.Unmarshal(body, &listing); err != nil {
+			return nil, withCode(NewA2AError("Failed to decode agents response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+		}
+
+		for _, raw := range listing.Agents {
+			var agent Agent
+			var doc map[string]interface{}
+			if json.Unmarshal(raw, &agent) != nil || json.Unmarshal(raw, &doc) != nil {
+				continue
+			}
+			if matchesTokens(&agent, tokens) && filters.matches(&agent) {
+				matches = append(matches, doc)
+			}
+		}
+
+		seen += len(listing.Agents)
+		if len(listing.Agents) == 0 || (listing.Total > 0 && seen >= listing.Total) {
+			break
+		}
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	start := (page - 1) * limit
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := start + limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+	agents := make([]interface{}, 0, end-start)
+	for _, doc := range matches[start:end] {
+		agents = append(agents, doc)
+	}
+
+	return map[string]interface{}{
+		"agents":            agents,
+		"total":             len(matches),
+		"page":              page,
+		"limit":             limit,
+		"fallback":          true,
+		"degraded_accuracy": true,
+		"truncated":         truncated,
+	}, nil
+}
+
+// fallbackFilters returns the filters a fallback search applies: f's typed
+// fields, with Extra entries of the same names ("tags", "provider",
+// "capabilities", "skills", "region") filling those left empty. Any other
+// Extra entry is a *ValidationError with CodeInvalidRequest, since the
+// client cannot tell what the registry would do with it.
+func fallbackFilters(f *SearchFilters) (SearchFilters, error) {
+	if f == nil {
+		return SearchFilters{}, nil
+	}
+	filters := *f
+	filters.Extra = nil
+	for name, value := range f.Extra {
+		var ok bool
+		switch name {
+		case "tags":
+			ok = filters.Tags != nil || decodeFilter(value, &filters.Tags)
+		case "provider":
+			ok = filters.Provider != "" || decodeFilter(value, &filters.Provider)
+		case "capabilities":
+			ok = filters.Capabilities != nil || decodeFilter(value, &filters.Capabilities)
+		case "skills":
+			ok = filters.Skills != nil || decodeFilter(value, &filters.Skills)
+		case "region":
+			ok = filters.Region != "" || decodeFilter(value, &filters.Region)
+		}
+		if !ok {
+			return SearchFilters{}, withCode(NewValidationError(
+				"The registry has no search endpoint and the fallback search cannot apply the filter "+strconv.Quote(name),
+				map[string]interface{}{"filter": name},
+			), CodeInvalidRequest)
+		}
+	}
+	return filters, nil
+}
+
+// decodeFilter converts an untyped filter value into target through JSON,
+// reporting whether it had the right shape.
+func decodeFilter(value, target interface{}) bool {
+	data, err := json.Marshal(value)
+	return err == nil && json.Unmarshal(data, target) == nil
+}
+
+// matches reports whether agent passes every filter. Tags may be on the
+// agent or its skills; capabilities must be enabled on the agent or its
+// card. Names and values compare case-insensitively.
+func (f SearchFilters) matches(agent *Agent) bool {
+	if f.Provider != "" && !strings.EqualFold(agent.ProviderName(), f.Provider) {
+		return false
+	}
+	if f.Region != "" && !strings.EqualFold(agent.Region, f.Region) {
+		return false
+	}
+	skills := agent.Skills
+	if agent.AgentCard != nil {
+		skills = append(skills[:len(skills):len(skills)], agent.AgentCard.Skills...)
+	}
+	tags := agent.Tags
+	var skillIDs []string
+	for _, skill := range skills {
+		tags = append(tags[:len(tags):len(tags)], skill.Tags...)
+		skillIDs = append(skillIDs, skill.ID)
+	}
+	if !containsAllFold(tags, f.Tags) || !containsAllFold(skillIDs, f.Skills) {
+		return false
+	}
+	enabled := agent.Capabilities.enabled()
+	if agent.AgentCard != nil {
+		enabled = append(enabled, agent.AgentCard.Capabilities.enabled()...)
+	}
+	return containsAllFold(enabled, f.Capabilities)
+}
+
+// enabled returns the card names of the capabilities set to true.
+func (caps *AgentCapabilities) enabled() []string {
+	if caps == nil {
+		return nil
+	}
+	var names []string
+	for name, flag := range map[string]*bool{
+		"streaming":                         caps.Streaming,
+		"pushNotifications":                 caps.PushNotifications,
+		"stateTransitionHistory":            caps.StateTransitionHistory,
+		"supportsAuthenticatedExtendedCard": caps.SupportsAuthenticatedExtendedCard,
+	} {
+		if flag != nil && *flag {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// containsAllFold reports whether every want occurs in have, ignoring case.
+func containsAllFold(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// searchTokens splits a query into lowercase alphanumeric tokens.
+func searchTokens(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchesTokens reports whether every token occurs in the agent's searchable
+// text.
+func matchesTokens(agent *Agent, tokens []string) bool {
+	fields := []string{agent.Name, agent.Description}
+	fields = append(fields, agent.Tags...)
+	skills := agent.Skills
+	if agent.AgentCard != nil {
+		skills = append(skills[:len(skills):len(skills)], agent.AgentCard.Skills...)
+	}
+	for _, skill := range skills {
+		fields = append(fields, skill.Tags...)
+	}
+	text := strings.ToLower(strings.Join(fields, " "))
+
+	for _, token := range tokens {
+		if !strings.Contains(text, token) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+//go:build !a2areg_strict
+
+package a2areg
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedSearchCorpus(registry *fakeregistry.Registry) {
+	cards := []map[string]interface{}{
+		{"name": "Weather Agent", "description": "Forecasts for any city"},
+		{"name": "Recipe Agent", "description": "Finds recipes by ingredient"},
+		{"name": "Travel Planner", "description": "Plans trips and checks the weather"},
+		{"name": "Translator", "description": "Translates text", "skills": []interface{}{
+			map[string]interface{}{"id": "t", "name": "Translate", "description": "Translate", "tags": []interface{}{"language"}},
+		}},
+	}
+	for i, card := range cards {
+		registry.Put(fmt.Sprintf("agent-%d", i+1), true, card)
+	}
+}
+
+func resultIDs(t *testing.T, result map[string]interface{}) []string {
+	var ids []string
+	for _, agent := range result["agents"].([]interface{}) {
+		ids = append(ids, agent.(map[string]interface{})["id"].(string))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestA2ARegClient_SearchAgents_Fallback(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.DisableSearch = true
+	seedSearchCorpus(registry)
+	server := registry.Start()
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", SearchFallback: true})
+
+	result, err := client.SearchAgents("weather", nil, false, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, true, result["fallback"])
+	assert.Equal(t, true, result["degraded_accuracy"])
+	assert.Equal(t, false, result["truncated"])
+	assert.Equal(t, []string{"agent-1", "agent-3"}, resultIDs(t, result))
+
+	// Skill tags are searched too.
+	result, err = client.SearchAgents("language", nil, false, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent-4"}, resultIDs(t, result))
+
+	// Once the endpoint is known to be missing it is not retried.
+	assert.Equal(t, 1, registry.Calls("POST /agents/search"))
+}
+
+func TestA2ARegClient_SearchAgents_NoFallbackByDefault(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.DisableSearch = true
+	server := registry.Start()
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	_, err := client.SearchAgents("weather", nil, false, 1, 10)
+	require.Error(t, err)
+	assert.Equal(t, CodeNotFound, ErrorCode(err))
+}
+
+func TestA2ARegClient_SearchAgents_FallbackPageBound(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.DisableSearch = true
+	registry.MaxPageSize = 2
+	for i := 0; i < 10; i++ {
+		registry.Put(fmt.Sprintf("agent-%02d", i), true, map[string]interface{}{"name": "Agent", "description": "same"})
+	}
+	server := registry.Start()
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", SearchFallback: true, MaxFallbackPages: 3})
+
+	result, err := client.SearchAgents("agent", nil, false, 1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 3, registry.Calls("GET /agents/entitled"))
+	assert.Equal(t, float64(6), result["total"], "decoded like a registry response")
+	assert.Equal(t, true, result["truncated"])
+}
+
+func TestA2ARegClient_SearchAgents_FallbackParity(t *testing.T) {
+	full := fakeregistry.New()
+	seedSearchCorpus(full)
+	fullServer := full.Start()
+	defer fullServer.Close()
+
+	minimal := fakeregistry.New()
+	minimal.DisableSearch = true
+	seedSearchCorpus(minimal)
+	minimalServer := minimal.Start()
+	defer minimalServer.Close()
+
+	fullClient := NewA2ARegClient(A2ARegClientOptions{RegistryURL: fullServer.URL, APIKey: "test"})
+	fallbackClient := NewA2ARegClient(A2ARegClientOptions{RegistryURL: minimalServer.URL, APIKey: "test", SearchFallback: true})
+
+	for _, query := range []string{"weather", "recipes", "translates", "planner", "nothing"} {
+		want, err := fullClient.SearchAgents(query, nil, false, 1, 10)
+		require.NoError(t, err)
+		got, err := fallbackClient.SearchAgents(query, nil, false, 1, 10)
+		require.NoError(t, err)
+
+		assert.Equal(t, resultIDs(t, want), resultIDs(t, got), query)
+		assert.Nil(t, want["fallback"])
+	}
+}
@@ -0,0 +1,279 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutSearchServer answers every search with one agent and the
+// timed_out flag set, recording the decoded request.
+func timeoutSearchServer(t *testing.T, got *map[string]interface{}) *A2ARegClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/agents/search", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(got))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"agents":          []map[string]interface{}{{"id": "a", "name": "Recipe Agent"}},
+			"total":           1,
+			"timed_out":       true,
+			"partial_results": true,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+}
+
+func TestSearch_TimedOutIsError(t *testing.T) {
+	var got map[string]interface{}
+	client := timeoutSearchServer(t, &got)
+
+	resp, err := client.Search(context.Background(), SearchRequest{Query: "recipes", Semantic: true, TimeoutMS: 250})
+	assert.Nil(t, resp)
+
+	var partial *PartialResultsError
+	require.True(t, errors.As(err, &partial))
+	assert.Equal(t, CodePartialResults, ErrorCode(err))
+	require.Len(t, partial.Response.Agents, 1)
+	assert.Equal(t, "Recipe Agent", partial.Response.Agents[0].Name)
+	assert.True(t, partial.Response.TimedOut)
+
+	assert.Equal(t, float64(250), got["timeout_ms"])
+	assert.Equal(t, "recipes", got["query"])
+}
+
+func TestSearch_AllowPartial(t *testing.T) {
+	var got map[string]interface{}
+	client := timeoutSearchServer(t, &got)
+
+	resp, err := client.Search(context.Background(), SearchRequest{Query: "recipes", AllowPartial: true})
+	require.NoError(t, err)
+	assert.True(t, resp.TimedOut)
+	assert.True(t, resp.PartialResults)
+	assert.Len(t, resp.Agents, 1)
+
+	_, sent := got["timeout_ms"]
+	assert.False(t, sent)
+	_, sent = got["AllowPartial"]
+	assert.False(t, sent)
+}
+
+func TestSearch_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"agents": [{"id": "a", "name": "A"}, {"id": "b", "name": "B"}], "total": 2}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	resp, err := client.Search(context.Background(), SearchRequest{Query: "x"})
+	require.NoError(t, err)
+	assert.False(t, resp.TimedOut)
+	assert.Equal(t, 2, resp.Total)
+	assert.Len(t, resp.Agents, 2)
+}
+
+func TestSearch_MarshaledRequest(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"agents": [], "total": 0}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	_, err := client.Search(context.Background(), SearchRequest{
+		Query: "recipes",
+		Filters: &SearchFilters{
+			Tags:         []string{"cooking"},
+			Provider:     "acme",
+			Capabilities: []string{"streaming"},
+			Skills:       []string{"find_recipe"},
+		},
+		Semantic: true,
+		Page:     2,
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"query": "recipes",
+		"filters": map[string]interface{}{
+			"tags":         []interface{}{"cooking"},
+			"provider":     "acme",
+			"capabilities": []interface{}{"streaming"},
+			"skills":       []interface{}{"find_recipe"},
+		},
+		"semantic": true,
+		"page":     float64(2),
+		"limit":    float64(5),
+	}, got)
+
+	// Without filters the key is left out entirely.
+	got = nil
+	_, err = client.Search(context.Background(), SearchRequest{Query: "recipes"})
+	require.NoError(t, err)
+	_, sent := got["filters"]
+	assert.False(t, sent)
+}
+
+func TestSearchResponse_Decoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		names  []string
+		scores []float64
+	}{
+		{
+			name:  "agents without scores",
+			body:  `{"agents": [{"id": "a", "name": "A"}, {"id": "b", "name": "B"}], "total": 2}`,
+			names: []string{"A", "B"},
+		},
+		{
+			name:  "results key",
+			body:  `{"results": [{"id": "a", "name": "A"}], "total": 1}`,
+			names: []string{"A"},
+		},
+		{
+			name:   "inline scores",
+			body:   `{"agents": [{"id": "a", "name": "A", "score": 0.9}, {"id": "b", "name": "B", "relevance_score": 0.4}], "total": 2}`,
+			names:  []string{"A", "B"},
+			scores: []float64{0.9, 0.4},
+		},
+		{
+			name:   "wrapped results with a missing score",
+			body:   `{"results": [{"agent": {"id": "a", "name": "A"}, "score": 0.7}, {"agent": {"id": "b", "name": "B"}}], "total": 2}`,
+			names:  []string{"A", "B"},
+			scores: []float64{0.7, 0},
+		},
+		{
+			name:  "empty",
+			body:  `{"total": 0}`,
+			names: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp SearchResponse
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &resp))
+			var names []string
+			for _, agent := range resp.Agents {
+				names = append(names, agent.Name)
+			}
+			assert.Equal(t, tt.names, names)
+			assert.Equal(t, tt.scores, resp.Scores)
+			assert.Equal(t, len(tt.names), resp.Total)
+		})
+	}
+}
+
+func TestSearch_FallbackFromCapabilities(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.DisableSearch = true
+	registry.FeatureMap = true
+	registry.Put("agent-1", true, map[string]interface{}{"name": "Weather Agent", "description": "Forecasts"})
+	server := registry.Start()
+	defer server.Close()
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", SearchFallback: true, Clock: fake})
+	caps, err := client.ServerCapabilities(context.Background())
+	require.NoError(t, err)
+	assert.False(t, caps.Supports(FeatureSearch))
+
+	// The probe said search is off, so the endpoint is not tried.
+	resp, err := client.Search(context.Background(), SearchRequest{Query: "weather", Page: 1, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, resp.Agents, 1)
+	assert.Equal(t, 0, registry.Calls("POST /agents/search"))
+
+	// Later the endpoint is probed again, and still missing.
+	fake.Advance(searchReprobeInterval)
+	_, err = client.Search(context.Background(), SearchRequest{Query: "weather", Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.Calls("POST /agents/search"))
+
+	// Once the registry gains search it is used from the next probe on.
+	registry.DisableSearch = false
+	fake.Advance(searchReprobeInterval)
+	for i := 0; i < 2; i++ {
+		resp, err = client.Search(context.Background(), SearchRequest{Query: "weather", Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, resp.Agents, 1)
+	}
+	assert.Equal(t, 3, registry.Calls("POST /agents/search"))
+}
+
+func TestSearch_CapabilitiesWithoutSearchKeepEndpoint(t *testing.T) {
+	// Registries that report no features say nothing about search.
+	registry := fakeregistry.New()
+	server := registry.Start()
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", SearchFallback: true})
+	_, err := client.ServerCapabilities(context.Background())
+	require.NoError(t, err)
+	_, err = client.Search(context.Background(), SearchRequest{Query: "weather", Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.Calls("POST /agents/search"))
+}
+
+func TestSearch_FallbackTyped(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.DisableSearch = true
+	registry.Put("agent-1", true, map[string]interface{}{
+		"name": "Weather Agent", "description": "Forecasts",
+		"capabilities": map[string]interface{}{"streaming": true},
+		"skills": []interface{}{
+			map[string]interface{}{"id": "forecast", "name": "Forecast", "description": "Forecast", "tags": []interface{}{"weather"}},
+		},
+	})
+	registry.Put("agent-2", true, map[string]interface{}{"name": "Weather Archive", "description": "Past weather"})
+	server := registry.Start()
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", SearchFallback: true, EnableSearchCache: true})
+	search := func(filters *SearchFilters) (*SearchResponse, error) {
+		return client.Search(context.Background(), SearchRequest{Query: "weather", Filters: filters, Page: 1, Limit: 10})
+	}
+
+	resp, err := search(nil)
+	require.NoError(t, err)
+	assert.True(t, resp.Fallback)
+	assert.True(t, resp.DegradedAccuracy)
+	assert.False(t, resp.Truncated)
+	assert.Len(t, resp.Agents, 2)
+
+	// Filters are applied client-side.
+	for _, filters := range []*SearchFilters{
+		{Tags: []string{"Weather"}},
+		{Skills: []string{"forecast"}},
+		{Capabilities: []string{"streaming"}},
+		{Extra: map[string]interface{}{"tags": []interface{}{"weather"}}},
+	} {
+		resp, err = search(filters)
+		require.NoError(t, err)
+		require.Len(t, resp.Agents, 1, "%+v", filters)
+		assert.Equal(t, "agent-1", *resp.Agents[0].ID)
+	}
+	resp, err = search(&SearchFilters{Region: "eu-west-1"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Agents)
+
+	// Filters the client cannot apply fail rather than being ignored.
+	_, err = search(&SearchFilters{Extra: map[string]interface{}{"protocolVersion": "0.3"}})
+	assert.Equal(t, CodeInvalidRequest, ErrorCode(err))
+
+	// Degraded results are not cached.
+	listings := registry.Calls("GET /agents/entitled")
+	_, err = search(nil)
+	require.NoError(t, err)
+	assert.Greater(t, registry.Calls("GET /agents/entitled"), listings)
+}
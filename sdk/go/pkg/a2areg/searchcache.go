@@ -0,0 +1,149 @@
+package a2areg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultSearchCacheTTL is how long cached lexical search results are
+	// served.
+	DefaultSearchCacheTTL = 30 * time.Second
+	// DefaultSemanticSearchCacheTTL is how long cached semantic search
+	// results are served. They cost the registry more to compute, so they
+	// are kept longer.
+	DefaultSemanticSearchCacheTTL = 2 * time.Minute
+	// DefaultSearchCacheEntries bounds the in-memory search cache used when
+	// SearchCache is not set.
+	DefaultSearchCacheEntries = 256
+)
+
+// searchCachePrefix starts the Store key of every cached search.
+const searchCachePrefix = "search:"
+
+// searchCache caches raw search responses keyed by normalized request.
+type searchCache struct {
+	store       Store
+	ttl         time.Duration
+	semanticTTL time.Duration
+	// generation is bumped by every invalidation, so that a search that
+	// was in flight during a mutation does not store its stale result.
+	generation atomic.Uint64
+	counters   cacheCounters
+}
+
+func newSearchCache(opts A2ARegClientOptions) *searchCache {
+	sc := &searchCache{
+		store:       opts.SearchCache,
+		ttl:         opts.SearchCacheTTL,
+		semanticTTL: opts.SemanticSearchCacheTTL,
+	}
+	sc.counters.init(CacheSearch, opts.OnCacheEvent)
+	if sc.store == nil {
+		sc.store = NewMemoryStore(DefaultSearchCacheEntries).WithClock(opts.Clock)
+	}
+	if sc.ttl == 0 {
+		sc.ttl = DefaultSearchCacheTTL
+	}
+	if sc.semanticTTL == 0 {
+		sc.semanticTTL = DefaultSemanticSearchCacheTTL
+	}
+	return sc
+}
+
+// searchCacheKey returns the Store key for req. Requests that differ only
+// in query case or spacing, or in the order of filter values, share a key.
+func searchCacheKey(req SearchRequest) string {
+	normalized := req
+	normalized.Query = strings.ToLower(strings.Join(strings.Fields(req.Query), " "))
+	normalized.Filters = nil
+	if f := req.Filters; f != nil {
+		filters := SearchFilters{
+			Tags:         normalizedSet(f.Tags),
+			Provider:     strings.ToLower(strings.TrimSpace(f.Provider)),
+			Capabilities: normalizedSet(f.Capabilities),
+			Skills:       normalizedSet(f.Skills),
+			Region:       strings.ToLower(strings.TrimSpace(f.Region)),
+			Extra:        f.Extra,
+		}
+		if filters.Tags != nil || filters.Provider != "" || filters.Capabilities != nil || filters.Skills != nil || filters.Region != "" || len(filters.Extra) > 0 {
+			normalized.Filters = &filters
+		}
+	}
+
+	// Struct fields marshal in declaration order and map keys sorted, so
+	// this is canonical.
+	data, _ := json.Marshal(normalized)
+	sum := sha256.Sum256(data)
+	return searchCachePrefix + hex.EncodeToString(sum[:])
+}
+
+// normalizedSet returns values trimmed, deduplicated and sorted, or nil
+// when none are left.
+func normalizedSet(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// get returns the cached response body for key, if any. Store
+// errors are treated as misses.
+func (sc *searchCache) get(key string) ([]byte, bool) {
+	data, ok, err := sc.store.Get(key)
+	if err != nil || !ok {
+		return nil, sc.counters.record(key, false)
+	}
+	return data, sc.counters.record(key, true)
+}
+
+// put caches body under key unless the cache was invalidated since
+// generation was read. Caching is best effort; Store errors are ignored.
+func (sc *searchCache) put(key string, body []byte, semantic bool, generation uint64) {
+	if sc.generation.Load() != generation {
+		return
+	}
+	ttl := sc.ttl
+	if semantic {
+		ttl = sc.semanticTTL
+	}
+	if sc.store.Set(key, body, ttl) == nil {
+		sc.counters.stored(key)
+	}
+}
+
+// invalidate drops every cached search.
+func (sc *searchCache) invalidate() {
+	sc.generation.Add(1)
+	var keys []string
+	sc.store.Range(func(key string, _ []byte) bool {
+		if strings.HasPrefix(key, searchCachePrefix) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	for _, key := range keys {
+		if sc.store.Delete(key) == nil {
+			sc.counters.evicted(key)
+		}
+	}
+}
+
+// invalidateSearches drops cached search results after a mutation.
+func (c *A2ARegClient) invalidateSearches() {
+	if c.searchCache != nil {
+		c.searchCache.invalidate()
+	}
+}
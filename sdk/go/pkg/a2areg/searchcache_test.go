@@ -0,0 +1,142 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSearchRegistry answers searches with an agent named after the
+// number of searches served so far, and accepts publishes.
+func countingSearchRegistry(t *testing.T, fake *clock.Fake) (*A2ARegClient, *atomic.Int32) {
+	var searches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/agents/search":
+			n := searches.Add(1)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"agents": []map[string]interface{}{{"id": "a", "name": fmt.Sprintf("result-%d", n)}},
+				"total":  1,
+			})
+		case "/agents/publish":
+			w.Write([]byte(`{"id": "agent-1", "name": "Recipe Agent"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:       server.URL,
+		APIKey:            "test",
+		Clock:             fake,
+		EnableSearchCache: true,
+	})
+	return client, &searches
+}
+
+func searchName(t *testing.T, client *A2ARegClient, req SearchRequest) string {
+	t.Helper()
+	resp, err := client.Search(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Agents, 1)
+	return resp.Agents[0].Name
+}
+
+func TestSearchCache_NormalizedHits(t *testing.T) {
+	client, searches := countingSearchRegistry(t, clock.NewFake(time.Now()))
+
+	first := SearchRequest{
+		Query:   "Weather  Forecast",
+		Filters: &SearchFilters{Tags: []string{"weather", "forecast"}, Capabilities: []string{"streaming"}},
+		Limit:   10,
+	}
+	equivalent := SearchRequest{
+		Query:   " weather forecast",
+		Filters: &SearchFilters{Tags: []string{"forecast", "weather", "weather"}, Capabilities: []string{"streaming"}},
+		Limit:   10,
+	}
+	assert.Equal(t, "result-1", searchName(t, client, first))
+	assert.Equal(t, "result-1", searchName(t, client, equivalent))
+	assert.Equal(t, int32(1), searches.Load())
+
+	// Empty filters are the same as none.
+	assert.Equal(t, searchCacheKey(SearchRequest{Query: "x"}), searchCacheKey(SearchRequest{Query: "x", Filters: &SearchFilters{}}))
+
+	// A different page, filter or mode is a different search.
+	for _, req := range []SearchRequest{
+		{Query: first.Query, Filters: first.Filters, Limit: 10, Page: 2},
+		{Query: first.Query, Filters: &SearchFilters{Tags: []string{"weather"}}, Limit: 10},
+		{Query: first.Query, Filters: first.Filters, Limit: 10, Semantic: true},
+	} {
+		searchName(t, client, req)
+	}
+	assert.Equal(t, int32(4), searches.Load())
+}
+
+func TestSearchCache_TTLs(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	client, searches := countingSearchRegistry(t, fake)
+
+	lexical := SearchRequest{Query: "weather"}
+	semantic := SearchRequest{Query: "weather", Semantic: true}
+	searchName(t, client, lexical)
+	searchName(t, client, semantic)
+
+	fake.Advance(DefaultSearchCacheTTL)
+	assert.Equal(t, "result-3", searchName(t, client, lexical), "lexical entry expired")
+	assert.Equal(t, "result-2", searchName(t, client, semantic), "semantic entry still cached")
+
+	fake.Advance(DefaultSemanticSearchCacheTTL)
+	assert.Equal(t, "result-4", searchName(t, client, semantic))
+	assert.Equal(t, int32(4), searches.Load())
+}
+
+func TestSearchCache_NoCacheBypasses(t *testing.T) {
+	client, searches := countingSearchRegistry(t, clock.NewFake(time.Now()))
+
+	searchName(t, client, SearchRequest{Query: "weather"})
+	assert.Equal(t, "result-2", searchName(t, client, SearchRequest{Query: "weather", NoCache: true}))
+	// The fresh result replaced the cached one.
+	assert.Equal(t, "result-2", searchName(t, client, SearchRequest{Query: "weather"}))
+	assert.Equal(t, int32(2), searches.Load())
+}
+
+func TestSearchCache_InvalidatedByPublish(t *testing.T) {
+	client, searches := countingSearchRegistry(t, clock.NewFake(time.Now()))
+
+	searchName(t, client, SearchRequest{Query: "weather"})
+	searchName(t, client, SearchRequest{Query: "recipes", Semantic: true})
+
+	_, err := client.PublishAgentVerbose(context.Background(), testPublishAgent(), PublishOptions{SkipNormalizationCheck: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, "result-3", searchName(t, client, SearchRequest{Query: "weather"}))
+	assert.Equal(t, "result-4", searchName(t, client, SearchRequest{Query: "recipes", Semantic: true}))
+	assert.Equal(t, int32(4), searches.Load())
+}
+
+func TestSearchCache_Disabled(t *testing.T) {
+	var searches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches.Add(1)
+		w.Write([]byte(`{"agents": [], "total": 0}`))
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Search(context.Background(), SearchRequest{Query: "weather"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), searches.Load())
+}
@@ -0,0 +1,88 @@
+package a2areg
+
+import (
+	"fmt"
+	"sync"
+)
+
+// redacted is what a SecretBox prints as.
+const redacted = "[REDACTED]"
+
+// SecretBox holds a secret (an API key, client secret or access token) as
+// bytes that are overwritten with zeros when the secret is replaced or the
+// box is wiped, so it does not outlive its use in memory the way a string
+// does. The secret is only reachable through Use; printing or encoding a
+// SecretBox yields "[REDACTED]".
+//
+// The zero SecretBox is empty and ready to use. A SecretBox must not be
+// copied after first use.
+type SecretBox struct {
+	mu   sync.RWMutex
+	data []byte
+}
+
+// NewSecretBox returns a box holding a copy of secret.
+func NewSecretBox(secret string) *SecretBox {
+	b := &SecretBox{}
+	b.Set(secret)
+	return b
+}
+
+// Use calls fn with the secret, which is empty if the box is. fn must not
+// retain the slice or modify it; the bytes are zeroed once the secret is
+// replaced or wiped.
+func (b *SecretBox) Use(fn func(secret []byte)) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	fn(b.data)
+}
+
+// Set replaces the secret with a copy of secret, zeroing the old one.
+func (b *SecretBox) Set(secret string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.wipeLocked()
+	if secret != "" {
+		b.data = []byte(secret)
+	}
+}
+
+// Wipe zeroes the secret and empties the box.
+func (b *SecretBox) Wipe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.wipeLocked()
+}
+
+func (b *SecretBox) wipeLocked() {
+	for i := range b.data {
+		b.data[i] = 0
+	}
+	b.data = nil
+}
+
+// Empty reports whether the box holds no secret.
+func (b *SecretBox) Empty() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.data) == 0
+}
+
+// equal reports whether the box holds secret.
+func (b *SecretBox) equal(secret string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return string(b.data) == secret
+}
+
+// String returns "[REDACTED]".
+func (b *SecretBox) String() string { return redacted }
+
+// GoString returns "[REDACTED]", so %#v does not print the secret either.
+func (b *SecretBox) GoString() string { return redacted }
+
+// Format prints "[REDACTED]" for every verb.
+func (b *SecretBox) Format(f fmt.State, verb rune) { fmt.Fprint(f, redacted) }
+
+// MarshalJSON encodes the box as "[REDACTED]".
+func (b *SecretBox) MarshalJSON() ([]byte, error) { return []byte(`"` + redacted + `"`), nil }
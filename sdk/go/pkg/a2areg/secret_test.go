@@ -0,0 +1,175 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secretBytes returns the slice backing box, so tests can check that it is
+// zeroed after the box lets go of it.
+func secretBytes(box *SecretBox) []byte {
+	var data []byte
+	box.Use(func(secret []byte) { data = secret })
+	return data
+}
+
+func assertZeroed(t *testing.T, data []byte) {
+	t.Helper()
+	require.NotEmpty(t, data)
+	assert.Equal(t, make([]byte, len(data)), data)
+}
+
+func TestSecretBox_SetAndWipe(t *testing.T) {
+	original := "s3cret"
+	box := NewSecretBox(original)
+	assert.False(t, box.Empty())
+
+	old := secretBytes(box)
+	box.Set("rotated")
+	assertZeroed(t, old)
+	assert.Equal(t, "s3cret", original, "the caller's string is copied, not retained")
+	assert.True(t, box.equal("rotated"))
+
+	current := secretBytes(box)
+	box.Wipe()
+	assertZeroed(t, current)
+	assert.True(t, box.Empty())
+
+	var zero SecretBox
+	assert.True(t, zero.Empty())
+	zero.Use(func(secret []byte) { assert.Empty(t, secret) })
+}
+
+func TestSecretBox_NeverPrinted(t *testing.T) {
+	box := NewSecretBox("s3cret")
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x"} {
+		assert.Equal(t, "[REDACTED]", fmt.Sprintf(format, box), format)
+	}
+	data, err := json.Marshal(map[string]interface{}{"key": box})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key": "[REDACTED]"}`, string(data))
+}
+
+func TestA2ARegClient_CloseZeroesSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/oauth/token" {
+			w.Write([]byte(`{"access_token": "access-token", "expires_in": 3600}`))
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, ClientID: "id", ClientSecret: "client-secret"})
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+	client.SetAPIKey("api-key")
+
+	secret := secretBytes(&client.clientSecret)
+	key := secretBytes(&client.apiKey)
+	token := secretBytes(&client.tokens.accessToken)
+	require.NoError(t, client.Close())
+	assertZeroed(t, secret)
+	assertZeroed(t, key)
+	assertZeroed(t, token)
+	assert.True(t, client.clientSecret.Empty())
+	assert.True(t, client.apiKey.Empty())
+	assert.True(t, client.tokens.accessToken.Empty())
+
+	_, err = client.Health(context.Background())
+	assert.Equal(t, CodeClientClosed, ErrorCode(err))
+	assert.NoError(t, client.Close())
+}
+
+func TestA2ARegClient_RotationZeroesSecrets(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{APIKey: "first-key"})
+	old := secretBytes(&client.apiKey)
+	client.SetAPIKey("second-key")
+	assertZeroed(t, old)
+
+	client.tokens.accessToken.Set("revoked-token")
+	token := secretBytes(&client.tokens.accessToken)
+	client.dropToken("revoked-token")
+	assertZeroed(t, token)
+}
+
+// secretsIn returns the secrets found anywhere in v, descending into maps,
+// slices and wrapped errors.
+func secretsIn(v interface{}, secrets []string) []string {
+	var found []string
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for key, value := range v {
+			found = append(found, secretsIn(key, secrets)...)
+			found = append(found, secretsIn(value, secrets)...)
+		}
+	case []interface{}:
+		for _, value := range v {
+			found = append(found, secretsIn(value, secrets)...)
+		}
+	case error:
+		found = append(found, secretsIn(v.Error(), secrets)...)
+		var a2aErr *A2AError
+		if errors.As(v, &a2aErr) {
+			found = append(found, secretsIn(a2aErr.Details, secrets)...)
+			if a2aErr.Err != nil {
+				found = append(found, secretsIn(a2aErr.Err, secrets)...)
+			}
+		}
+	default:
+		s := fmt.Sprintf("%v", v)
+		for _, secret := range secrets {
+			if strings.Contains(s, secret) {
+				found = append(found, secret)
+			}
+		}
+	}
+	return found
+}
+
+func TestErrors_DoNotCaptureSecrets(t *testing.T) {
+	secrets := []string{"client-secret", "api-key-123", "access-token"}
+	var status atomic.Int32
+	status.Store(http.StatusUnauthorized)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/oauth/token" {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"detail": "boom"}`))
+	}))
+
+	oauth := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, ClientID: "id", ClientSecret: "client-secret"})
+	keyed := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "api-key-123"})
+	var errs []error
+	_, err := oauth.Health(context.Background())
+	errs = append(errs, err)
+	status.Store(http.StatusInternalServerError)
+	_, err = oauth.Health(context.Background())
+	errs = append(errs, err)
+	_, err = keyed.Health(context.Background())
+	errs = append(errs, err)
+	oauth.tokens.accessToken.Set("access-token")
+	server.Close()
+	_, err = oauth.GetAgent("agent-1")
+	errs = append(errs, err)
+	_, err = keyed.PublishAgent(testPublishAgent(), false)
+	errs = append(errs, err)
+
+	for i, err := range errs {
+		require.Error(t, err, i)
+		assert.Empty(t, secretsIn(err, secrets), "error %d: %v", i, err)
+	}
+}
@@ -0,0 +1,167 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ShareLink is a signed, time-limited URL granting read access to one
+// agent's card without registry credentials.
+type ShareLink struct {
+	ID        string    `json:"id"`
+	AgentID   string    `json:"agent_id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateCardShareLink mints a signed URL for agentID's card, valid for ttl.
+// Hand the URL to FetchSharedCard.
+func (c *A2ARegClient) CreateCardShareLink(ctx context.Context, agentID string, ttl time.Duration) (*ShareLink, error) {
+	if ttl <= 0 {
+		return nil, NewValidationError("Share link TTL must be positive", map[string]interface{}{"ttl": ttl.String()})
+	}
+
+	body, err := c.makeRequestContext(ctx, "POST", "/agents/"+url.PathEscape(agentID)+"/share", map[string]interface{}{
+		"ttl_seconds": int64(ttl / time.Second),
+	}, nil)
+	if err != nil {
+		return nil, agentNotFound(err)
+	}
+
+	var link ShareLink
+	if err := json.Unmarshal(body, &link); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode share link response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if link.URL == "" {
+		return nil, withCode(NewA2AError("Share link response has no url", nil), CodeDecodeFailed)
+	}
+	if link.AgentID == "" {
+		link.AgentID = agentID
+	}
+	return &link, nil
+}
+
+// RevokeShareLink invalidates a share link before it expires.
+func (c *A2ARegClient) RevokeShareLink(ctx context.Context, linkID string) error {
+	_, err := c.makeRequestContext(ctx, "DELETE", "/share-links/"+url.PathEscape(linkID), nil, nil)
+	return err
+}
+
+// SharedCardOptions configures FetchSharedCardWithOptions.
+type SharedCardOptions struct {
+	// AllowedHosts lists the hosts the share URL, and every redirect it
+	// leads to, may point at, in the form of AllowedAgentHosts. With none,
+	// every URL is refused.
+	AllowedHosts []string
+	// AllowInsecureHTTP accepts plain http share URLs and redirects. By
+	// default only https is fetched, since the URL's signature is the
+	// credential.
+	AllowInsecureHTTP bool
+	// HTTPClient makes the request; its CheckRedirect is replaced. Defaults
+	// to a client using http.DefaultTransport.
+	HTTPClient *http.Client
+}
+
+// FetchSharedCard fetches the agent card behind a signed https share URL.
+// It is FetchSharedCardWithOptions with only AllowedHosts set.
+func FetchSharedCard(ctx context.Context, signedURL string, allowedHosts ...string) (*AgentCardSpec, error) {
+	return FetchSharedCardWithOptions(ctx, signedURL, SharedCardOptions{AllowedHosts: allowedHosts})
+}
+
+// FetchSharedCardWithOptions fetches the agent card behind a signed share
+// URL. No credentials are sent. The URL's host must be one of
+// opts.AllowedHosts, and so must the host of every redirect followed, so a
+// link from an untrusted source cannot make the caller fetch from an
+// arbitrary server. An expired link returns an ExpiredLinkError.
+func FetchSharedCardWithOptions(ctx context.Context, signedURL string, opts SharedCardOptions) (*AgentCardSpec, error) {
+	allowed := newHostAllowlist(opts.AllowedHosts)
+	u, err := url.Parse(signedURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, withCode(NewValidationError(fmt.Sprintf("Invalid share URL: %q", signedURL), nil), CodeInvalidRequest)
+	}
+	if err := checkShareURL(u, allowed, opts); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{}
+	if opts.HTTPClient != nil {
+		copied := *opts.HTTPClient
+		client = &copied
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return withCode(NewA2AError("Share URL redirected too many times", nil), CodeRequestFailed)
+		}
+		return checkShareURL(req.URL, allowed, opts)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to create request", map[string]interface{}{"error": err.Error()}), CodeInvalidRequest)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var refused codedError
+		if errors.As(err, &refused) {
+			return nil, refused
+		}
+		return nil, withCode(NewA2AError("Request failed", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxRequestBytes))
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to read response body", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+
+	body, _, errorValue, enveloped := unwrapEnvelope(body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errorData map[string]interface{}
+		if enveloped && errorValue != nil {
+			errorData = envelopeErrorData(errorValue)
+		} else if err := json.Unmarshal(body, &errorData); err != nil {
+			errorData = nil
+		}
+		if resp.StatusCode == http.StatusGone || serverErrorCode(errorData, "") == CodeLinkExpired {
+			var expiresAt *time.Time
+			if s, ok := errorData["expires_at"].(string); ok {
+				if t, err := time.Parse(time.RFC3339, s); err == nil {
+					expiresAt = &t
+				}
+			}
+			return nil, NewExpiredLinkError(expiresAt)
+		}
+		apiErr := (&A2ARegClient{}).responseError(resp.StatusCode, errorData)
+		apiErr.setStatusCode(resp.StatusCode)
+		return nil, apiErr
+	}
+
+	var card AgentCardSpec
+	if err := json.Unmarshal(body, &card); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode shared card", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	if card.Name == "" || card.Version == "" {
+		return nil, NewValidationError("Shared card is missing name or version", nil)
+	}
+	return &card, nil
+}
+
+// checkShareURL refuses a share URL, or a redirect target, that is not on
+// an allowed host or that uses plain http without AllowInsecureHTTP.
+func checkShareURL(u *url.URL, allowed *hostAllowlist, opts SharedCardOptions) error {
+	if u.Scheme != "https" && !(u.Scheme == "http" && opts.AllowInsecureHTTP) {
+		return withCode(NewValidationError(fmt.Sprintf("Share URL %q must use https", u.Redacted()), map[string]interface{}{"scheme": u.Scheme}), CodeInvalidRequest)
+	}
+	if allowed == nil || !allowed.allows(u.String()) {
+		return withCode(NewValidationError(fmt.Sprintf("Share URL host %q is not allowed", u.Host), map[string]interface{}{"host": u.Host, "allowed_hosts": opts.AllowedHosts}), CodeHostNotAllowed)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// insecureLocal allows the plain http test servers on the loopback address.
+var insecureLocal = SharedCardOptions{AllowedHosts: []string{"127.0.0.1"}, AllowInsecureHTTP: true}
+
+// shareRegistry mints share links for agent-1 and serves them until they
+// expire (according to *now) or are revoked.
+func shareRegistry(t *testing.T, now *time.Time) *httptest.Server {
+	type link struct {
+		expiresAt time.Time
+		revoked   bool
+	}
+	links := map[string]*link{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/agents/agent-1/share":
+			var req struct {
+				TTLSeconds int64 `json:"ttl_seconds"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			expiresAt := now.Add(time.Duration(req.TTLSeconds) * time.Second)
+			links["link-1"] = &link{expiresAt: expiresAt}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":         "link-1",
+				"url":        server.URL + "/shared/link-1?sig=abc",
+				"expires_at": expiresAt.Format(time.RFC3339),
+			})
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/share"):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"detail": "Agent not found"})
+		case r.Method == "DELETE" && r.URL.Path == "/share-links/link-1":
+			links["link-1"].revoked = true
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && r.URL.Path == "/shared/link-1":
+			assert.Empty(t, r.Header.Get("Authorization"))
+			l := links["link-1"]
+			switch {
+			case l == nil || l.revoked || r.URL.Query().Get("sig") != "abc":
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]interface{}{"detail": "Link not found"})
+			case !now.Before(l.expiresAt):
+				w.WriteHeader(http.StatusGone)
+				json.NewEncoder(w).Encode(map[string]interface{}{"detail": "Link expired", "expires_at": l.expiresAt.Format(time.RFC3339)})
+			default:
+				json.NewEncoder(w).Encode(map[string]interface{}{"name": "Private Agent", "description": "Secret", "version": "1.0.0", "url": "https://agent.example.com"})
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestA2ARegClient_ShareLink_MintFetchRevoke(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := shareRegistry(t, &now)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+	ctx := context.Background()
+
+	link, err := client.CreateCardShareLink(ctx, "agent-1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "link-1", link.ID)
+	assert.Equal(t, "agent-1", link.AgentID)
+	assert.Equal(t, now.Add(time.Hour), link.ExpiresAt)
+
+	card, err := FetchSharedCardWithOptions(ctx, link.URL, insecureLocal)
+	require.NoError(t, err)
+	assert.Equal(t, "Private Agent", card.Name)
+
+	require.NoError(t, client.RevokeShareLink(ctx, link.ID))
+	_, err = FetchSharedCardWithOptions(ctx, link.URL, insecureLocal)
+	require.Error(t, err)
+	assert.Equal(t, CodeNotFound, ErrorCode(err))
+}
+
+func TestA2ARegClient_CreateCardShareLink_Errors(t *testing.T) {
+	now := time.Now()
+	server := shareRegistry(t, &now)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	_, err := client.CreateCardShareLink(context.Background(), "agent-1", 0)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+
+	_, err = client.CreateCardShareLink(context.Background(), "missing", time.Hour)
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+}
+
+func TestFetchSharedCard_Expired(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := shareRegistry(t, &now)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	link, err := client.CreateCardShareLink(context.Background(), "agent-1", time.Minute)
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+
+	_, err = FetchSharedCardWithOptions(context.Background(), link.URL, insecureLocal)
+	var expired *ExpiredLinkError
+	require.ErrorAs(t, err, &expired)
+	assert.Equal(t, CodeLinkExpired, ErrorCode(err))
+	require.NotNil(t, expired.ExpiresAt)
+	assert.True(t, expired.ExpiresAt.Equal(link.ExpiresAt))
+}
+
+func TestFetchSharedCard_HostAllowlist(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	for _, allowed := range [][]string{nil, {"registry.example.com"}, {"127.0.0.1:1"}} {
+		_, err := FetchSharedCardWithOptions(ctx, server.URL+"/shared/x", SharedCardOptions{AllowedHosts: allowed, AllowInsecureHTTP: true})
+		assert.Equal(t, CodeHostNotAllowed, ErrorCode(err), allowed)
+	}
+	_, err := FetchSharedCard(ctx, "ftp://127.0.0.1/x", "127.0.0.1")
+	assert.Equal(t, CodeInvalidRequest, ErrorCode(err))
+	_, err = FetchSharedCard(ctx, server.URL+"/shared/x", "127.0.0.1")
+	assert.Equal(t, CodeInvalidRequest, ErrorCode(err), "plain http needs AllowInsecureHTTP")
+	assert.Equal(t, 0, requests)
+}
+
+func TestFetchSharedCard_Redirects(t *testing.T) {
+	card := map[string]interface{}{"name": "Other Agent", "description": "Elsewhere", "version": "1.0.0"}
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(card)
+	}))
+	defer other.Close()
+	otherURL, err := url.Parse(other.URL)
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/to-localhost":
+			http.Redirect(w, r, "http://localhost:"+otherURL.Port()+"/card", http.StatusFound)
+		case "/to-same-host":
+			http.Redirect(w, r, other.URL+"/card", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	_, err = FetchSharedCardWithOptions(ctx, server.URL+"/to-localhost", insecureLocal)
+	assert.Equal(t, CodeHostNotAllowed, ErrorCode(err), "redirects are held to the allowlist")
+
+	got, err := FetchSharedCardWithOptions(ctx, server.URL+"/to-same-host", insecureLocal)
+	require.NoError(t, err)
+	assert.Equal(t, "Other Agent", got.Name)
+
+	_, err = FetchSharedCardWithOptions(ctx, server.URL+"/to-same-host", SharedCardOptions{AllowedHosts: []string{server.Listener.Addr().String()}, AllowInsecureHTTP: true})
+	assert.Equal(t, CodeHostNotAllowed, ErrorCode(err), "a host:port entry allows only that port")
+}
+
+func TestFetchSharedCard_HTTPS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": "Private Agent", "description": "Secret", "version": "1.0.0"})
+	}))
+	defer server.Close()
+
+	card, err := FetchSharedCardWithOptions(context.Background(), server.URL+"/shared/x", SharedCardOptions{
+		AllowedHosts: []string{"127.0.0.1"},
+		HTTPClient:   server.Client(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Private Agent", card.Name)
+}
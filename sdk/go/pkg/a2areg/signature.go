@@ -0,0 +1,459 @@
+package a2areg
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// JSONWebKey is a public key in JWK form (RFC 7517). RSA keys use N and E,
+// EC keys Crv, X and Y.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a JWKS document, as served from a card's JWKSUrl.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// VerifyOptions configures AgentCardSpec.VerifySignature.
+type VerifyOptions struct {
+	// KeyID, when set, requires the card to be signed with the key of
+	// this ID.
+	KeyID string
+	// KeySet, when set, holds the keys to verify with, and the card's
+	// JWKSUrl is not fetched.
+	KeySet *JSONWebKeySet
+	// JWKSCache caches the fetched JWKS. Defaults to a cache shared by the
+	// process, with the default JWKSCacheOptions.
+	JWKSCache *JWKSCache
+	// HTTPClient fetches the JWKS. Defaults to JWKSCache's client.
+	HTTPClient *http.Client
+}
+
+// jwsAlgorithm is a supported JWS signature algorithm.
+type jwsAlgorithm struct {
+	hash crypto.Hash
+	kty  string
+	// size is the byte length of each of R and S in an ECDSA signature.
+	size int
+}
+
+var jwsAlgorithms = map[string]jwsAlgorithm{
+	"RS256": {hash: crypto.SHA256, kty: "RSA"},
+	"RS384": {hash: crypto.SHA384, kty: "RSA"},
+	"RS512": {hash: crypto.SHA512, kty: "RSA"},
+	"ES256": {hash: crypto.SHA256, kty: "EC", size: 32},
+	"ES384": {hash: crypto.SHA384, kty: "EC", size: 48},
+}
+
+// CanonicalJSON returns the bytes a card signature covers: the card
+// without its signature block, encoded with sorted keys, no insignificant
+// whitespace and no HTML escaping. Fields the SDK does not model are not
+// part of it.
+func (acs *AgentCardSpec) CanonicalJSON() ([]byte, error) {
+	return acs.canonicalJSON(nil)
+}
+
+// signatureRequiredFields are the card fields every signature must cover,
+// whatever its "fields" header says: where the agent is reached, how
+// clients authenticate to it, and how they talk to it.
+var signatureRequiredFields = []string{"url", "securitySchemes", "interface"}
+
+// cardFieldNames returns the JSON names of the top-level AgentCardSpec
+// fields a signature can cover.
+var cardFieldNames = sync.OnceValue(func() map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(AgentCardSpec{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && name != "signature" {
+			names[name] = true
+		}
+	}
+	return names
+})
+
+// checkSignedFields reports what is wrong with a "fields" list, or "" if
+// it is acceptable. A nil list covers the whole card; a non-nil one must
+// name only card fields and include signatureRequiredFields.
+func checkSignedFields(fields []string) string {
+	if fields == nil {
+		return ""
+	}
+	if len(fields) == 0 {
+		return "signature field list is empty"
+	}
+	listed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !cardFieldNames()[field] {
+			return fmt.Sprintf("signature covers unknown field %q", field)
+		}
+		listed[field] = true
+	}
+	for _, field := range signatureRequiredFields {
+		if !listed[field] {
+			return fmt.Sprintf("signature does not cover %q", field)
+		}
+	}
+	return ""
+}
+
+// canonicalJSON is CanonicalJSON limited to the top-level fields named in
+// fields, or covering every field when fields is empty.
+func (acs *AgentCardSpec) canonicalJSON(fields []string) ([]byte, error) {
+	unsigned := *acs
+	unsigned.Signature = nil
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value map[string]interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		covered := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if v, ok := value[field]; ok {
+				covered[field] = v
+			}
+		}
+		value = covered
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// VerifySignature checks the card's signature: a compact JWS over
+// CanonicalJSON, limited to the fields listed in the protected header's
+// "fields" member if it has one (see SignOptions.Fields), with the payload
+// either embedded or detached (empty), made with Signature.Algorithm by a
+// key from the JWKS at Signature.JWKSUrl. RS256, RS384, RS512, ES256 and
+// ES384 are supported. Fetched key sets are kept in opts.JWKSCache and
+// fetched again when the signing key is not in the cached set. A "fields"
+// list that is empty, names a field the card does not have, or leaves out
+// url, securitySchemes or interface is refused.
+//
+// Any failure, including an unreachable JWKS, is a
+// *SignatureVerificationError.
+func (acs *AgentCardSpec) VerifySignature(ctx context.Context, opts VerifyOptions) error {
+	sig := acs.Signature
+	if sig == nil || getStringValue(sig.Signature, "") == "" {
+		return NewSignatureVerificationError("Agent card is not signed", nil)
+	}
+	parts := strings.Split(*sig.Signature, ".")
+	if len(parts) != 3 {
+		return NewSignatureVerificationError("Agent card signature is not a compact JWS", nil)
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return NewSignatureVerificationError("Agent card signature header is not base64url", err)
+	}
+	var header struct {
+		Alg    string   `json:"alg"`
+		Kid    string   `json:"kid"`
+		Fields []string `json:"fields"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return NewSignatureVerificationError("Agent card signature header is not JSON", err)
+	}
+
+	declared := getStringValue(sig.Algorithm, "")
+	switch {
+	case header.Alg == "":
+		header.Alg = declared
+	case declared != "" && declared != header.Alg:
+		return NewSignatureVerificationError(fmt.Sprintf("Agent card declares algorithm %s but is signed with %s", declared, header.Alg), nil)
+	}
+	alg, ok := jwsAlgorithms[header.Alg]
+	if !ok {
+		return NewSignatureVerificationError(fmt.Sprintf("Unsupported signature algorithm %q", header.Alg), nil)
+	}
+	if opts.KeyID != "" && header.Kid != opts.KeyID {
+		return NewSignatureVerificationError(fmt.Sprintf("Agent card is signed with key %q, expected %q", header.Kid, opts.KeyID), nil)
+	}
+	if problem := checkSignedFields(header.Fields); problem != "" {
+		return NewSignatureVerificationError("Agent card "+problem, nil)
+	}
+
+	payload, err := acs.canonicalJSON(header.Fields)
+	if err != nil {
+		return NewSignatureVerificationError("Failed to canonicalize agent card", err)
+	}
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	if parts[1] != "" && parts[1] != encodedPayload {
+		return NewSignatureVerificationError("Agent card signature payload does not match the card", nil)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return NewSignatureVerificationError("Agent card signature is not base64url", err)
+	}
+	signingInput := []byte(parts[0] + "." + encodedPayload)
+
+	jwksURL := getStringValue(sig.JWKSUrl, "")
+	if jwksURL == "" && opts.KeySet == nil {
+		return NewSignatureVerificationError("Agent card signature has no JWKS URL and no key set was given", nil)
+	}
+	keys, err := signingKeys(ctx, opts, jwksURL, header.Kid, alg)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if verifyJWS(key, alg, signingInput, signature) {
+			return nil
+		}
+	}
+	return NewSignatureVerificationError("Agent card signature is invalid", nil)
+}
+
+// signingKeys returns the public keys that may have made a signature with
+// alg under kid: from opts.KeySet, or else from the JWKS at jwksURL,
+// fetched again if the cached set lacks kid.
+func signingKeys(ctx context.Context, opts VerifyOptions, jwksURL, kid string, alg jwsAlgorithm) ([]crypto.PublicKey, error) {
+	if opts.KeySet != nil {
+		return opts.KeySet.publicKeys(kid, alg)
+	}
+	cache := opts.JWKSCache
+	if cache == nil {
+		cache = defaultJWKSCache
+	}
+	set, err := cache.lookup(ctx, opts.HTTPClient, jwksURL, kid)
+	if err != nil {
+		return nil, err
+	}
+	return set.publicKeys(kid, alg)
+}
+
+// publicKeys returns the keys in s usable for alg, limited to kid when it
+// is set.
+func (s *JSONWebKeySet) publicKeys(kid string, alg jwsAlgorithm) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for _, jwk := range s.Keys {
+		if (kid != "" && jwk.Kid != kid) || jwk.Kty != alg.kty || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, NewSignatureVerificationError(fmt.Sprintf("Invalid JWKS key %q", jwk.Kid), err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, NewSignatureVerificationError(fmt.Sprintf("No %s key %q in the JWKS", alg.kty, kid), nil)
+	}
+	return keys, nil
+}
+
+// PublicKey returns k as an *rsa.PublicKey or *ecdsa.PublicKey.
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("missing")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// verifyJWS reports whether signature is a valid alg signature of input
+// by key.
+func verifyJWS(key crypto.PublicKey, alg jwsAlgorithm, input, signature []byte) bool {
+	h := alg.hash.New()
+	h.Write(input)
+	digest := h.Sum(nil)
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, alg.hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		if len(signature) != 2*alg.size || key.Curve.Params().BitSize != 8*alg.size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:alg.size])
+		s := new(big.Int).SetBytes(signature[alg.size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
+
+// WithVerifySignature makes GetAgentCard verify the card's signature with
+// opts before returning it, failing with a *SignatureVerificationError if
+// it does not verify.
+func WithVerifySignature(opts VerifyOptions) RequestOption {
+	return func(o *requestOptions) {
+		o.verifySignature = &opts
+	}
+}
+
+// SignOptions configures SignAgentCard.
+type SignOptions struct {
+	// Algorithm is the JWS algorithm. Defaults to RS256 for RSA keys, and
+	// to ES256 or ES384 for P-256 and P-384 ECDSA keys.
+	Algorithm string
+	// KeyID is put in the "kid" header, naming the key in the JWKS.
+	KeyID string
+	// JWKSUrl is where verifiers fetch the public key; it is stored in the
+	// card's signature block.
+	JWKSUrl string
+	// Fields limits the signature to these top-level card fields, by JSON
+	// name, listed in the "fields" header. Empty covers the whole card.
+	// Fields left out can be changed without invalidating the signature,
+	// so url, securitySchemes and interface must always be listed.
+	Fields []string
+}
+
+// SignAgentCard signs card with signer and sets card.Signature to the
+// result: a compact JWS with a detached payload over the card's canonical
+// form (see CanonicalJSON), which VerifySignature checks. signer must hold
+// an RSA or a P-256 or P-384 ECDSA key.
+func SignAgentCard(card *AgentCardSpec, signer crypto.Signer, opts SignOptions) error {
+	name := opts.Algorithm
+	if name == "" {
+		switch key := signer.Public().(type) {
+		case *rsa.PublicKey:
+			name = "RS256"
+		case *ecdsa.PublicKey:
+			name = map[int]string{256: "ES256", 384: "ES384"}[key.Curve.Params().BitSize]
+		}
+	}
+	alg, ok := jwsAlgorithms[name]
+	if !ok {
+		return NewValidationError(fmt.Sprintf("Unsupported signing key or algorithm %q", name), nil)
+	}
+	switch key := signer.Public().(type) {
+	case *rsa.PublicKey:
+		ok = alg.kty == "RSA"
+	case *ecdsa.PublicKey:
+		ok = alg.kty == "EC" && key.Curve.Params().BitSize == 8*alg.size
+	default:
+		ok = false
+	}
+	if !ok {
+		return NewValidationError(fmt.Sprintf("Signing key does not match algorithm %s", name), nil)
+	}
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = nil
+	}
+	if problem := checkSignedFields(fields); problem != "" {
+		return NewValidationError("Cannot sign agent card: "+problem, map[string]interface{}{"fields": fields})
+	}
+
+	header, err := json.Marshal(struct {
+		Alg    string   `json:"alg"`
+		Kid    string   `json:"kid,omitempty"`
+		Fields []string `json:"fields,omitempty"`
+	}{name, opts.KeyID, fields})
+	if err != nil {
+		return err
+	}
+	payload, err := card.canonicalJSON(fields)
+	if err != nil {
+		return err
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(header)
+	h := alg.hash.New()
+	h.Write([]byte(encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload)))
+	signature, err := signer.Sign(rand.Reader, h.Sum(nil), alg.hash)
+	if err != nil {
+		return fmt.Errorf("a2areg: signing agent card: %w", err)
+	}
+	if alg.kty == "EC" {
+		if signature, err = rawECDSASignature(signature, alg.size); err != nil {
+			return err
+		}
+	}
+
+	card.Signature = &AgentCardSignature{
+		Algorithm: stringPtr(name),
+		Signature: stringPtr(encodedHeader + ".." + base64.RawURLEncoding.EncodeToString(signature)),
+	}
+	if opts.JWKSUrl != "" {
+		card.Signature.JWKSUrl = stringPtr(opts.JWKSUrl)
+	}
+	return nil
+}
+
+// rawECDSASignature converts the ASN.1 signature crypto.Signer returns for
+// ECDSA keys into the fixed-size R || S form JWS uses.
+func rawECDSASignature(der []byte, size int) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, fmt.Errorf("a2areg: decoding ECDSA signature: %w", err)
+	}
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return raw, nil
+}
@@ -0,0 +1,317 @@
+package a2areg
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func rsaJWK(t *testing.T, kid string) (*rsa.PrivateKey, JSONWebKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key, JSONWebKey{
+		Kty: "RSA", Kid: kid, Use: "sig",
+		N: b64(key.N.Bytes()),
+		E: b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(t *testing.T, kid string) (*ecdsa.PrivateKey, JSONWebKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key, JSONWebKey{
+		Kty: "EC", Kid: kid, Crv: "P-256",
+		X: b64(key.X.FillBytes(make([]byte, 32))),
+		Y: b64(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// signCard signs card with key as a detached compact JWS and sets its
+// signature block.
+func signCard(t *testing.T, card *AgentCardSpec, alg, kid, jwksURL string, key crypto.Signer) {
+	t.Helper()
+	card.Signature = nil
+	payload, err := card.CanonicalJSON()
+	require.NoError(t, err)
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid})
+	require.NoError(t, err)
+	input := b64(header) + "." + b64(payload)
+
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(input))
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	card.Signature = &AgentCardSignature{
+		Algorithm: stringPtr(alg),
+		Signature: stringPtr(b64(header) + ".." + b64(signature)),
+		JWKSUrl:   stringPtr(jwksURL),
+	}
+}
+
+func testSignedCard() *AgentCardSpec {
+	return &AgentCardSpec{
+		Name:        "Recipe Agent",
+		Description: "Finds recipes <fast> & cheap",
+		URL:         "https://recipes.example.com",
+		Version:     "1.0.0",
+	}
+}
+
+// jwksServer serves set and counts the fetches.
+func jwksServer(t *testing.T, set *JSONWebKeySet) (*httptest.Server, *atomic.Int32) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func TestVerifySignature_Valid(t *testing.T) {
+	rsaKey, rsaPublic := rsaJWK(t, "rsa-1")
+	ecKey, ecPublic := ecJWK(t, "ec-1")
+	server, fetches := jwksServer(t, &JSONWebKeySet{Keys: []JSONWebKey{rsaPublic, ecPublic}})
+
+	card := testSignedCard()
+	signCard(t, card, "RS256", "rsa-1", server.URL, rsaKey)
+	require.NoError(t, card.VerifySignature(context.Background(), VerifyOptions{}))
+	require.NoError(t, card.VerifySignature(context.Background(), VerifyOptions{KeyID: "rsa-1"}))
+
+	signCard(t, card, "ES256", "ec-1", server.URL, ecKey)
+	require.NoError(t, card.VerifySignature(context.Background(), VerifyOptions{}))
+	assert.Equal(t, int32(1), fetches.Load(), "the key set is cached")
+
+	var sigErr *SignatureVerificationError
+	err := card.VerifySignature(context.Background(), VerifyOptions{KeyID: "rsa-1"})
+	require.ErrorAs(t, err, &sigErr)
+	assert.Equal(t, CodeSignatureInvalid, ErrorCode(err))
+}
+
+func TestVerifySignature_Tampered(t *testing.T) {
+	key, public := rsaJWK(t, "rsa-1")
+	server, _ := jwksServer(t, &JSONWebKeySet{Keys: []JSONWebKey{public}})
+
+	card := testSignedCard()
+	signCard(t, card, "RS256", "rsa-1", server.URL, key)
+	card.URL = "https://attacker.example.com"
+
+	err := card.VerifySignature(context.Background(), VerifyOptions{})
+	var sigErr *SignatureVerificationError
+	require.ErrorAs(t, err, &sigErr)
+	assert.Equal(t, CodeSignatureInvalid, ErrorCode(err))
+
+	card.URL = "https://recipes.example.com"
+	card.Signature.Algorithm = stringPtr("ES256")
+	assert.Error(t, card.VerifySignature(context.Background(), VerifyOptions{}), "declared algorithm must match the JWS")
+}
+
+func TestVerifySignature_UnreachableJWKS(t *testing.T) {
+	key, _ := rsaJWK(t, "rsa-1")
+	server := httptest.NewServer(http.NotFoundHandler())
+	jwksURL := server.URL + "/jwks.json"
+	server.Close()
+
+	card := testSignedCard()
+	signCard(t, card, "RS256", "rsa-1", jwksURL, key)
+	err := card.VerifySignature(context.Background(), VerifyOptions{})
+	var sigErr *SignatureVerificationError
+	require.ErrorAs(t, err, &sigErr)
+	assert.Equal(t, CodeJWKSFetchFailed, ErrorCode(err))
+	assert.Equal(t, jwksURL, sigErr.Details["url"])
+}
+
+func TestVerifySignature_LocalKeySetAndRotation(t *testing.T) {
+	oldKey, oldPublic := rsaJWK(t, "old")
+	newKey, newPublic := rsaJWK(t, "new")
+
+	card := testSignedCard()
+	signCard(t, card, "RS256", "old", "", oldKey)
+	set := &JSONWebKeySet{Keys: []JSONWebKey{oldPublic}}
+	require.NoError(t, card.VerifySignature(context.Background(), VerifyOptions{KeySet: set}))
+	assert.Error(t, card.VerifySignature(context.Background(), VerifyOptions{}), "no JWKS URL and no key set")
+
+	// A key missing from the cached set makes the set be fetched again.
+	server, fetches := jwksServer(t, set)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := VerifyOptions{JWKSCache: NewJWKSCache(JWKSCacheOptions{Clock: fake})}
+	signCard(t, card, "RS256", "old", server.URL, oldKey)
+	require.NoError(t, card.VerifySignature(context.Background(), opts))
+	set.Keys = append(set.Keys, newPublic)
+	fake.Advance(DefaultJWKSMinRefetchInterval)
+	signCard(t, card, "RS256", "new", server.URL, newKey)
+	require.NoError(t, card.VerifySignature(context.Background(), opts))
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestGetAgentCard_WithVerifySignature(t *testing.T) {
+	key, public := rsaJWK(t, "rsa-1")
+	jwks, _ := jwksServer(t, &JSONWebKeySet{Keys: []JSONWebKey{public}})
+	card := testSignedCard()
+	signCard(t, card, "RS256", "rsa-1", jwks.URL, key)
+	tampered := *card
+	tampered.Name = "Evil Agent"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/agents/tampered/card" {
+			json.NewEncoder(w).Encode(&tampered)
+			return
+		}
+		json.NewEncoder(w).Encode(card)
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", CardCache: NewMemoryStore(0)})
+
+	got, err := client.GetAgentCard("agent-1", WithVerifySignature(VerifyOptions{}))
+	require.NoError(t, err)
+	assert.Equal(t, "Recipe Agent", got.Name)
+
+	_, err = client.GetAgentCard("tampered")
+	require.NoError(t, err, "unverified calls ignore the signature")
+	_, err = client.GetAgentCard("tampered", WithVerifySignature(VerifyOptions{}))
+	var sigErr *SignatureVerificationError
+	require.ErrorAs(t, err, &sigErr, "cached cards are verified too")
+}
+
+func TestSignAgentCard_RoundTrip(t *testing.T) {
+	rsaKey, rsaPublic := rsaJWK(t, "rsa-1")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	ecPublic := JSONWebKey{
+		Kty: "EC", Kid: "ec-384", Crv: "P-384",
+		X: b64(ecKey.X.FillBytes(make([]byte, 48))),
+		Y: b64(ecKey.Y.FillBytes(make([]byte, 48))),
+	}
+	server, _ := jwksServer(t, &JSONWebKeySet{Keys: []JSONWebKey{rsaPublic, ecPublic}})
+
+	for _, tc := range []struct {
+		signer crypto.Signer
+		opts   SignOptions
+		alg    string
+	}{
+		{rsaKey, SignOptions{KeyID: "rsa-1", JWKSUrl: server.URL}, "RS256"},
+		{rsaKey, SignOptions{KeyID: "rsa-1", JWKSUrl: server.URL, Algorithm: "RS512"}, "RS512"},
+		{ecKey, SignOptions{KeyID: "ec-384", JWKSUrl: server.URL}, "ES384"},
+	} {
+		card := testSignedCard()
+		require.NoError(t, SignAgentCard(card, tc.signer, tc.opts))
+		assert.Equal(t, tc.alg, *card.Signature.Algorithm)
+		assert.Equal(t, server.URL, *card.Signature.JWKSUrl)
+		require.NoError(t, card.VerifySignature(context.Background(), VerifyOptions{KeyID: tc.opts.KeyID}), tc.alg)
+
+		card.Version = "2.0.0"
+		assert.Error(t, card.VerifySignature(context.Background(), VerifyOptions{}), tc.alg)
+	}
+}
+
+func TestSignAgentCard_Fields(t *testing.T) {
+	key, public := rsaJWK(t, "rsa-1")
+	set := &JSONWebKeySet{Keys: []JSONWebKey{public}}
+	fields := []string{"name", "url", "securitySchemes", "interface"}
+
+	card := testSignedCard()
+	require.NoError(t, SignAgentCard(card, key, SignOptions{KeyID: "rsa-1", Fields: fields}))
+	card.Description = "Changed"
+	require.NoError(t, card.VerifySignature(context.Background(), VerifyOptions{KeySet: set}), "description is not covered")
+
+	tampers := map[string]func(*AgentCardSpec){
+		"url": func(c *AgentCardSpec) { c.URL = "https://attacker.example.com" },
+		"securitySchemes": func(c *AgentCardSpec) {
+			c.SecuritySchemes = SecuritySchemes{"none": {Type: "none"}}
+		},
+		"interface": func(c *AgentCardSpec) { c.Interface.PreferredTransport = "grpc" },
+	}
+	for field, tamper := range tampers {
+		card := testSignedCard()
+		require.NoError(t, SignAgentCard(card, key, SignOptions{KeyID: "rsa-1", Fields: fields}))
+		tamper(card)
+		assert.Error(t, card.VerifySignature(context.Background(), VerifyOptions{KeySet: set}), field)
+	}
+
+	for _, bad := range [][]string{{"name"}, {"name", "url", "securitySchemes", "interface", "nickname"}} {
+		err := SignAgentCard(testSignedCard(), key, SignOptions{Fields: bad})
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr, "%v", bad)
+	}
+}
+
+// signCardFields signs card with key like SignAgentCard, but with any
+// "fields" header, as a careless or hostile signer could.
+func signCardFields(t *testing.T, card *AgentCardSpec, key *rsa.PrivateKey, fields []string) {
+	t.Helper()
+	card.Signature = nil
+	payload, err := card.canonicalJSON(fields)
+	require.NoError(t, err)
+	header, err := json.Marshal(map[string]interface{}{"alg": "RS256", "kid": "rsa-1", "fields": fields})
+	require.NoError(t, err)
+	input := b64(header) + "." + b64(payload)
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+	require.NoError(t, err)
+	card.Signature = &AgentCardSignature{Algorithm: stringPtr("RS256"), Signature: stringPtr(b64(header) + ".." + b64(signature))}
+}
+
+func TestVerifySignature_RequiresCoveredFields(t *testing.T) {
+	key, public := rsaJWK(t, "rsa-1")
+	set := &JSONWebKeySet{Keys: []JSONWebKey{public}}
+
+	for _, fields := range [][]string{
+		{},
+		{"name"},
+		{"nickname"},
+		{"name", "url", "securitySchemes"},
+		{"url", "securitySchemes", "interface", "nickname"},
+	} {
+		card := testSignedCard()
+		signCardFields(t, card, key, fields)
+		card.URL = "https://attacker.example.com"
+		card.SecuritySchemes = SecuritySchemes{"none": {Type: "none"}}
+		err := card.VerifySignature(context.Background(), VerifyOptions{KeySet: set})
+		var sigErr *SignatureVerificationError
+		assert.ErrorAs(t, err, &sigErr, "%v", fields)
+	}
+
+	card := testSignedCard()
+	signCardFields(t, card, key, []string{"url", "securitySchemes", "interface"})
+	require.NoError(t, card.VerifySignature(context.Background(), VerifyOptions{KeySet: set}))
+}
+
+func TestSignAgentCard_KeyMismatch(t *testing.T) {
+	key, _ := rsaJWK(t, "rsa-1")
+	card := testSignedCard()
+	err := SignAgentCard(card, key, SignOptions{Algorithm: "ES256"})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Nil(t, card.Signature)
+}
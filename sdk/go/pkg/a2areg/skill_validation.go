@@ -0,0 +1,115 @@
+package a2areg
+
+import (
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Defaults for SkillLimits.
+const (
+	DefaultMaxSkillExamples      = 10
+	DefaultMaxSkillExampleLength = 500
+)
+
+// SkillLimits bounds the skill content ValidateAgent accepts. Zero fields
+// use the defaults; negative fields disable that limit.
+type SkillLimits struct {
+	// MaxExamples caps the number of examples per skill.
+	MaxExamples int
+	// MaxExampleLength caps the length of each example, in characters.
+	MaxExampleLength int
+	// ModeAliases lists input/output mode names accepted even though they
+	// are not MIME types.
+	ModeAliases []string
+}
+
+// withDefaults fills in zero limits.
+func (l SkillLimits) withDefaults() SkillLimits {
+	if l.MaxExamples == 0 {
+		l.MaxExamples = DefaultMaxSkillExamples
+	}
+	if l.MaxExampleLength == 0 {
+		l.MaxExampleLength = DefaultMaxSkillExampleLength
+	}
+	return l
+}
+
+// FieldViolation is one problem found while validating an agent. Path
+// locates the offending value, e.g. "skills[2].inputModes[0]".
+type FieldViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v FieldViolation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+var (
+	skillIDPattern  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+	skillTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.+-]*$`)
+)
+
+// checkSkills returns every violation of the skill rules in skills.
+func (l SkillLimits) checkSkills(skills []AgentSkill) []FieldViolation {
+	var violations []FieldViolation
+	add := func(path, format string, args ...interface{}) {
+		violations = append(violations, FieldViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for i, skill := range skills {
+		prefix := fmt.Sprintf("skills[%d]", i)
+
+		if !skillIDPattern.MatchString(skill.ID) {
+			add(prefix+".id", "%q is not a valid skill ID (letters, digits, '_', '-' and '.', at most 64 characters)", skill.ID)
+		}
+
+		for j, tag := range skill.Tags {
+			if !skillTagPattern.MatchString(tag) {
+				add(fmt.Sprintf("%s.tags[%d]", prefix, j), "%q is not a lowercase tag", tag)
+			}
+		}
+
+		if l.MaxExamples > 0 && len(skill.Examples) > l.MaxExamples {
+			add(prefix+".examples", "has %d examples; at most %d are allowed", len(skill.Examples), l.MaxExamples)
+		}
+		if l.MaxExampleLength > 0 {
+			for j, example := range skill.Examples {
+				if n := utf8.RuneCountInString(example); n > l.MaxExampleLength {
+					add(fmt.Sprintf("%s.examples[%d]", prefix, j), "is %d characters; at most %d are allowed", n, l.MaxExampleLength)
+				}
+			}
+		}
+
+		for j, mode := range skill.InputModes {
+			if !l.validMode(mode) {
+				add(fmt.Sprintf("%s.inputModes[%d]", prefix, j), "%q is not a MIME type", mode)
+			}
+		}
+		for j, mode := range skill.OutputModes {
+			if !l.validMode(mode) {
+				add(fmt.Sprintf("%s.outputModes[%d]", prefix, j), "%q is not a MIME type", mode)
+			}
+		}
+	}
+	return violations
+}
+
+// validMode reports whether mode is a type/subtype MIME type or a
+// registered alias.
+func (l SkillLimits) validMode(mode string) bool {
+	for _, alias := range l.ModeAliases {
+		if mode == alias {
+			return true
+		}
+	}
+	mediaType, _, err := mime.ParseMediaType(mode)
+	if err != nil {
+		return false
+	}
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	return ok && typ != "" && subtype != ""
+}
@@ -0,0 +1,103 @@
+package a2areg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func skillAgent(skills ...AgentSkill) *Agent {
+	return &Agent{
+		Name:        "Recipe Agent",
+		Description: "Finds recipes",
+		Version:     "1.0.0",
+		Provider:    "acme",
+		Skills:      skills,
+	}
+}
+
+func validSkill() AgentSkill {
+	return AgentSkill{
+		ID:          "find_recipe",
+		Name:        "Find recipe",
+		Description: "Finds a recipe",
+		Tags:        []string{"cooking", "text-generation"},
+		Examples:    []string{"Find me a pasta recipe"},
+		InputModes:  []string{"text/plain", "application/json; charset=utf-8"},
+		OutputModes: []string{"text/markdown"},
+	}
+}
+
+func skillViolations(t *testing.T, err error) []FieldViolation {
+	t.Helper()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "expected a ValidationError, got %v", err)
+	violations, ok := validationErr.Details["violations"].([]FieldViolation)
+	require.True(t, ok)
+	return violations
+}
+
+func TestValidateAgent_SkillsValid(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{})
+	assert.NoError(t, client.ValidateAgent(skillAgent(validSkill())))
+}
+
+func TestValidateAgent_SkillViolations(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{})
+
+	bad := validSkill()
+	bad.ID = "find recipe!"
+	bad.Tags = []string{"cooking", "", "Baking"}
+	bad.InputModes = []string{"text", "text/plain"}
+	bad.OutputModes = []string{"image/"}
+	bad.Examples = []string{"ok", strings.Repeat("x", DefaultMaxSkillExampleLength+1)}
+
+	err := client.ValidateAgent(skillAgent(validSkill(), bad))
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Contains(t, err.Error(), `skills[1].inputModes[0]: "text" is not a MIME type`)
+
+	var paths []string
+	for _, v := range skillViolations(t, err) {
+		paths = append(paths, v.Path)
+	}
+	assert.Equal(t, []string{
+		"skills[1].id",
+		"skills[1].tags[1]",
+		"skills[1].tags[2]",
+		"skills[1].examples[1]",
+		"skills[1].inputModes[0]",
+		"skills[1].outputModes[0]",
+	}, paths)
+}
+
+func TestValidateAgent_SkillExampleLimits(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{SkillLimits: SkillLimits{MaxExamples: 2, MaxExampleLength: 5}})
+
+	skill := validSkill()
+	skill.Examples = []string{"one", "two", "three!"}
+	violations := skillViolations(t, client.ValidateAgent(skillAgent(skill)))
+	assert.Equal(t, []FieldViolation{
+		{Path: "skills[0].examples", Message: "has 3 examples; at most 2 are allowed"},
+		{Path: "skills[0].examples[2]", Message: "is 6 characters; at most 5 are allowed"},
+	}, violations)
+
+	unlimited := NewA2ARegClient(A2ARegClientOptions{SkillLimits: SkillLimits{MaxExamples: -1, MaxExampleLength: -1}})
+	skill.Examples = append(make([]string, 20), strings.Repeat("x", 10000))
+	for i := range skill.Examples[:20] {
+		skill.Examples[i] = "example"
+	}
+	assert.NoError(t, unlimited.ValidateAgent(skillAgent(skill)))
+}
+
+func TestValidateAgent_SkillModeAliases(t *testing.T) {
+	skill := validSkill()
+	skill.InputModes = []string{"text"}
+
+	assert.Error(t, NewA2ARegClient(A2ARegClientOptions{}).ValidateAgent(skillAgent(skill)))
+
+	client := NewA2ARegClient(A2ARegClientOptions{SkillLimits: SkillLimits{ModeAliases: []string{"text"}}})
+	assert.NoError(t, client.ValidateAgent(skillAgent(skill)))
+}
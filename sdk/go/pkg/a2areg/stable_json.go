@@ -0,0 +1,32 @@
+package a2areg
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MarshalStable encodes v as indented JSON with the keys of every object
+// sorted, whatever the field order of the Go types involved, so that equal
+// values always give identical bytes. Numbers are kept as written, HTML
+// characters are not escaped, and the output ends with a newline.
+func MarshalStable(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to encode value", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode value", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(tree); err != nil {
+		return nil, withCode(NewA2AError("Failed to encode value", map[string]interface{}{"error": err.Error()}), CodeEncodeFailed)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RegistryStats is the registry's /stats response.
+type RegistryStats struct {
+	TotalAgents   int64
+	PublicAgents  int64
+	PrivateAgents int64
+	// The counts below are not reported by every registry; they are nil
+	// when missing.
+	TotalProviders       *int64
+	TotalSkills          *int64
+	TotalSearchesLast24h *int64
+	// Extra holds the fields not listed above, so counters added by newer
+	// registries stay readable. Numbers are kept as json.Number.
+	Extra ResponseMap
+}
+
+// statsCounter is a RegistryStats count and the JSON key it is read from.
+type statsCounter struct {
+	key      string
+	required *int64
+	optional **int64
+}
+
+func (s *RegistryStats) counters() []statsCounter {
+	return []statsCounter{
+		{key: "total_agents", required: &s.TotalAgents},
+		{key: "public_agents", required: &s.PublicAgents},
+		{key: "private_agents", required: &s.PrivateAgents},
+		{key: "total_providers", optional: &s.TotalProviders},
+		{key: "total_skills", optional: &s.TotalSkills},
+		{key: "total_searches_last_24h", optional: &s.TotalSearchesLast24h},
+	}
+}
+
+// UnmarshalJSON decodes the known counters and keeps every other field in
+// Extra. A known counter that is not an integer is an error.
+func (s *RegistryStats) UnmarshalJSON(data []byte) error {
+	var m ResponseMap
+	if err := decodeNumbers(data, &m); err != nil {
+		return err
+	}
+	*s = RegistryStats{}
+	for _, counter := range s.counters() {
+		if value, present := m[counter.key]; !present || value == nil {
+			delete(m, counter.key)
+			continue
+		}
+		n, ok := m.GetInt64(counter.key)
+		if !ok {
+			return fmt.Errorf("a2areg: stats field %s is not an integer: %v", counter.key, m[counter.key])
+		}
+		if counter.required != nil {
+			*counter.required = n
+		} else {
+			*counter.optional = &n
+		}
+		delete(m, counter.key)
+	}
+	if len(m) > 0 {
+		s.Extra = m
+	}
+	return nil
+}
+
+// MarshalJSON encodes the counters and Extra as one object, the shape the
+// registry sends.
+func (s RegistryStats) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(s.Extra)+6)
+	for k, v := range s.Extra {
+		m[k] = v
+	}
+	for _, counter := range s.counters() {
+		switch {
+		case counter.required != nil:
+			m[counter.key] = *counter.required
+		case *counter.optional != nil:
+			m[counter.key] = **counter.optional
+		}
+	}
+	return json.Marshal(m)
+}
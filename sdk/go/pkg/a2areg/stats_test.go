@@ -0,0 +1,72 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statsServer(t *testing.T, body string) *A2ARegClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+}
+
+func TestGetRegistryStats_ExtraFields(t *testing.T) {
+	client := statsServer(t, `{
+		"total_agents": 12, "public_agents": 9, "private_agents": 3,
+		"total_providers": 4, "total_skills": 40, "total_searches_last_24h": 1500,
+		"agents_by_region": {"eu-west-1": 7, "us-east-1": 5}, "index_version": "v3"
+	}`)
+
+	stats, err := client.GetRegistryStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), stats.TotalAgents)
+	assert.Equal(t, int64(9), stats.PublicAgents)
+	assert.Equal(t, int64(3), stats.PrivateAgents)
+	require.NotNil(t, stats.TotalProviders)
+	assert.Equal(t, int64(4), *stats.TotalProviders)
+	require.NotNil(t, stats.TotalSkills)
+	assert.Equal(t, int64(40), *stats.TotalSkills)
+	require.NotNil(t, stats.TotalSearchesLast24h)
+	assert.Equal(t, int64(1500), *stats.TotalSearchesLast24h)
+
+	assert.Len(t, stats.Extra, 2)
+	version, _ := stats.Extra.GetString("index_version")
+	assert.Equal(t, "v3", version)
+	regions, ok := stats.Extra.GetMap("agents_by_region")
+	require.True(t, ok)
+	eu, _ := regions.GetInt64("eu-west-1")
+	assert.Equal(t, int64(7), eu)
+
+	data, err := json.Marshal(stats)
+	require.NoError(t, err)
+	var again RegistryStats
+	require.NoError(t, json.Unmarshal(data, &again))
+	assert.Equal(t, *stats, again)
+}
+
+func TestGetRegistryStats_MissingOptionalCounts(t *testing.T) {
+	client := statsServer(t, `{"total_agents": 2, "public_agents": 2, "total_skills": null}`)
+
+	stats, err := client.GetRegistryStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.TotalAgents)
+	assert.Equal(t, int64(0), stats.PrivateAgents)
+	assert.Nil(t, stats.TotalProviders)
+	assert.Nil(t, stats.TotalSkills)
+	assert.Nil(t, stats.TotalSearchesLast24h)
+	assert.Nil(t, stats.Extra)
+}
+
+func TestGetRegistryStats_InvalidCounter(t *testing.T) {
+	client := statsServer(t, `{"total_agents": "many"}`)
+	_, err := client.GetRegistryStats()
+	assert.Equal(t, CodeDecodeFailed, ErrorCode(err))
+}
@@ -0,0 +1,141 @@
+package a2areg
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"a2areg/internal/clock"
+)
+
+// Store is a key/value persistence backend for the client's caches. Values
+// are opaque byte slices (typically JSON). Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Get returns the value for key. Expired or missing entries report false.
+	Get(key string) ([]byte, bool, error)
+	// Set stores value under key. A ttl of zero means the entry never expires.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(key string) error
+	// Range calls fn for each live entry until fn returns false.
+	Range(fn func(key string, value []byte) bool) error
+}
+
+// MemoryStore is an in-process Store with optional LRU eviction.
+type MemoryStore struct {
+	mu         sync.Mutex
+	clock      Clock
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List // front is most recently used
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore. If maxEntries is positive, the
+// least recently used entries are evicted once the store grows past it.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		clock:      clock.Real{},
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// WithClock sets the clock used for TTL expiry and returns the store.
+func (s *MemoryStore) WithClock(c Clock) *MemoryStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = c
+	return s
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if s.expiredLocked(entry) {
+		s.removeLocked(elem)
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(elem)
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryEntry{key: key, value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = s.clock.Now().Add(ttl)
+	}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.lru.MoveToFront(elem)
+	} else {
+		s.entries[key] = s.lru.PushFront(entry)
+	}
+
+	for s.maxEntries > 0 && s.lru.Len() > s.maxEntries {
+		s.removeLocked(s.lru.Back())
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.removeLocked(elem)
+	}
+	return nil
+}
+
+// Range implements Store. Entries are visited from most to least recently
+// used; fn must not call back into the store.
+func (s *MemoryStore) Range(fn func(key string, value []byte) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for elem := s.lru.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*memoryEntry)
+		if s.expiredLocked(entry) {
+			s.removeLocked(elem)
+		} else if !fn(entry.key, append([]byte(nil), entry.value...)) {
+			return nil
+		}
+		elem = next
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet purged.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+func (s *MemoryStore) expiredLocked(entry *memoryEntry) bool {
+	return !entry.expiresAt.IsZero() && !s.clock.Now().Before(entry.expiresAt)
+}
+
+func (s *MemoryStore) removeLocked(elem *list.Element) {
+	delete(s.entries, elem.Value.(*memoryEntry).key)
+	s.lru.Remove(elem)
+}
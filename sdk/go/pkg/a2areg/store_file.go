@@ -0,0 +1,287 @@
+package a2areg
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"a2areg/internal/clock"
+)
+
+// fileStoreExt is the extension of entry files written by FileStore.
+const fileStoreExt = ".entry"
+
+// FileStoreOptions configures a FileStore.
+type FileStoreOptions struct {
+	// MaxBytes caps the total size of stored values. When exceeded, the
+	// least recently used entries are evicted. Zero means unbounded.
+	MaxBytes int64
+	// Clock is used for TTL expiry and the access times that order
+	// eviction. Nil uses the wall clock.
+	Clock Clock
+}
+
+// FileStore is a Store that keeps each entry as a JSON file under a
+// directory, so cached data survives process restarts. Every entry carries a
+// SHA-256 checksum of its value; entries that fail to parse or verify are
+// treated as corrupt, deleted, and reported as misses.
+type FileStore struct {
+	dir      string
+	maxBytes int64
+	clock    Clock
+
+	mu    sync.Mutex
+	index map[string]*list.Element
+	lru   *list.List // front is most recently used
+	size  int64
+}
+
+// fileEntry is the on-disk form of a FileStore entry.
+type fileEntry struct {
+	Key       string     `json:"key"`
+	Value     []byte     `json:"value"`
+	Checksum  string     `json:"checksum"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// fileIndexEntry is the in-memory bookkeeping for one entry file.
+type fileIndexEntry struct {
+	key  string
+	size int64
+}
+
+// NewFileStore opens (creating if needed) a FileStore rooted at dir and loads
+// the index of existing entries, ordered by last access.
+func NewFileStore(dir string, opts FileStoreOptions) (*FileStore, error) {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("a2areg: create store directory: %w", err)
+	}
+
+	s := &FileStore{
+		dir:      dir,
+		maxBytes: opts.MaxBytes,
+		clock:    opts.Clock,
+		index:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load rebuilds the index from the directory. Corrupt entries are removed.
+func (s *FileStore) load() error {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("a2areg: read store directory: %w", err)
+	}
+
+	type loaded struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	var entries []loaded
+	for _, de := range dirEntries {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), fileStoreExt) {
+			continue
+		}
+		path := filepath.Join(s.dir, de.Name())
+		entry, ok := s.readEntry(path)
+		if !ok {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, loaded{key: entry.Key, size: int64(len(entry.Value)), modTime: info.ModTime()})
+	}
+
+	// Oldest access first, so that pushing to the front leaves the most
+	// recently used entry at the front.
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+	for _, e := range entries {
+		s.index[e.key] = s.lru.PushFront(&fileIndexEntry{key: e.key, size: e.size})
+		s.size += e.size
+	}
+	s.evictLocked()
+	return nil
+}
+
+// readEntry reads and verifies an entry file, deleting it if it is corrupt.
+func (s *FileStore) readEntry(path string) (*fileEntry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry fileEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Checksum != checksum(entry.Value) || s.path(entry.Key) != path {
+		os.Remove(path)
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Get implements Store.
+func (s *FileStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.index[key]
+	if !ok {
+		return nil, false, nil
+	}
+	path := s.path(key)
+	entry, ok := s.readEntry(path)
+	if !ok || entry.Key != key {
+		s.removeLocked(elem)
+		return nil, false, nil
+	}
+	if entry.ExpiresAt != nil && !s.clock.Now().Before(*entry.ExpiresAt) {
+		s.deleteLocked(elem)
+		return nil, false, nil
+	}
+
+	s.lru.MoveToFront(elem)
+	now := s.clock.Now()
+	os.Chtimes(path, now, now)
+	return entry.Value, true, nil
+}
+
+// Set implements Store.
+func (s *FileStore) Set(key string, value []byte, ttl time.Duration) error {
+	entry := fileEntry{Key: key, Value: value, Checksum: checksum(value)}
+	if ttl > 0 {
+		expiresAt := s.clock.Now().Add(ttl)
+		entry.ExpiresAt = &expiresAt
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("a2areg: encode store entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write to a temporary file and rename so readers never see a partial
+	// entry.
+	tmp, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("a2areg: write store entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("a2areg: write store entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("a2areg: write store entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("a2areg: write store entry: %w", err)
+	}
+	// Access times order eviction after a reopen, so they follow the clock.
+	now := s.clock.Now()
+	os.Chtimes(s.path(key), now, now)
+
+	size := int64(len(value))
+	if elem, ok := s.index[key]; ok {
+		idx := elem.Value.(*fileIndexEntry)
+		s.size += size - idx.size
+		idx.size = size
+		s.lru.MoveToFront(elem)
+	} else {
+		s.index[key] = s.lru.PushFront(&fileIndexEntry{key: key, size: size})
+		s.size += size
+	}
+	s.evictLocked()
+	return nil
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.index[key]; ok {
+		s.deleteLocked(elem)
+	}
+	return nil
+}
+
+// Range implements Store. Entries are visited from most to least recently
+// used; fn must not call back into the store.
+func (s *FileStore) Range(fn func(key string, value []byte) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for elem := s.lru.Front(); elem != nil; {
+		next := elem.Next()
+		key := elem.Value.(*fileIndexEntry).key
+		entry, ok := s.readEntry(s.path(key))
+		switch {
+		case !ok:
+			s.removeLocked(elem)
+		case entry.ExpiresAt != nil && !now.Before(*entry.ExpiresAt):
+			s.deleteLocked(elem)
+		case !fn(key, entry.Value):
+			return nil
+		}
+		elem = next
+	}
+	return nil
+}
+
+// Size returns the total size in bytes of the stored values.
+func (s *FileStore) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+func (s *FileStore) evictLocked() {
+	for s.maxBytes > 0 && s.size > s.maxBytes && s.lru.Len() > 0 {
+		s.deleteLocked(s.lru.Back())
+	}
+}
+
+// deleteLocked removes the entry from the index and from disk.
+func (s *FileStore) deleteLocked(elem *list.Element) {
+	os.Remove(s.path(elem.Value.(*fileIndexEntry).key))
+	s.removeLocked(elem)
+}
+
+// removeLocked removes the entry from the index only.
+func (s *FileStore) removeLocked(elem *list.Element) {
+	idx := elem.Value.(*fileIndexEntry)
+	delete(s.index, idx.key)
+	s.size -= idx.size
+	s.lru.Remove(elem)
+}
+
+// path returns the entry file for key. Keys are hashed so that arbitrary
+// strings map to safe file names.
+func (s *FileStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+fileStoreExt)
+}
+
+// checksum returns the hex SHA-256 of value.
+func checksum(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
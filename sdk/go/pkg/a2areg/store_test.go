@@ -0,0 +1,151 @@
+package a2areg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_TTLAndLRU(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	s := NewMemoryStore(2).WithClock(fake)
+
+	require.NoError(t, s.Set("a", []byte("1"), time.Minute))
+	require.NoError(t, s.Set("b", []byte("2"), 0))
+
+	_, ok, _ := s.Get("a") // a is now most recently used
+	assert.True(t, ok)
+	require.NoError(t, s.Set("c", []byte("3"), 0))
+
+	_, ok, _ = s.Get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+
+	fake.Advance(time.Minute)
+	_, ok, _ = s.Get("a")
+	assert.False(t, ok, "entry expires at its TTL")
+
+	value, ok, _ := s.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), value)
+
+	require.NoError(t, s.Delete("c"))
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestFileStore_PersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, FileStoreOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.Set("card:agent-1", []byte(`{"name":"x"}`), time.Hour))
+	require.NoError(t, s.Set("card:agent-2", []byte(`{"name":"y"}`), 0))
+
+	reopened, err := NewFileStore(dir, FileStoreOptions{})
+	require.NoError(t, err)
+	value, ok, err := reopened.Get("card:agent-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte(`{"name":"x"}`), value)
+
+	keys := map[string]string{}
+	require.NoError(t, reopened.Range(func(key string, value []byte) bool {
+		keys[key] = string(value)
+		return true
+	}))
+	assert.Equal(t, map[string]string{"card:agent-1": `{"name":"x"}`, "card:agent-2": `{"name":"y"}`}, keys)
+}
+
+func TestFileStore_DetectsCorruption(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, FileStoreOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.Set("good", []byte("ok"), 0))
+	require.NoError(t, s.Set("tampered", []byte("original"), 0))
+
+	// Flip the stored value without updating its checksum, and drop in a
+	// file that isn't valid JSON at all.
+	path := s.path("tampered")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	entry := string(data)
+	require.Contains(t, entry, `"b3JpZ2luYWw="`)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(entry, `"b3JpZ2luYWw="`, `"ZXZpbA=="`, 1)), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "garbage"+fileStoreExt), []byte("{not json"), 0o600))
+
+	_, ok, err := s.Get("tampered")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoFileExists(t, path, "corrupt entry is removed")
+
+	reopened, err := NewFileStore(dir, FileStoreOptions{})
+	require.NoError(t, err)
+	_, ok, _ = reopened.Get("good")
+	assert.True(t, ok)
+	assert.NoFileExists(t, filepath.Join(dir, "garbage"+fileStoreExt))
+}
+
+func TestFileStore_SizeCapEvictsLRU(t *testing.T) {
+	s, err := NewFileStore(t.TempDir(), FileStoreOptions{MaxBytes: 10})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Set("a", []byte("aaaa"), 0))
+	require.NoError(t, s.Set("b", []byte("bbbb"), 0))
+	_, ok, _ := s.Get("a")
+	require.True(t, ok)
+	require.NoError(t, s.Set("c", []byte("cccc"), 0))
+
+	_, ok, _ = s.Get("b")
+	assert.False(t, ok)
+	_, ok, _ = s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, int64(8), s.Size())
+}
+
+func TestFileStore_TTL(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	s, err := NewFileStore(t.TempDir(), FileStoreOptions{Clock: fake})
+	require.NoError(t, err)
+	require.NoError(t, s.Set("k", []byte("v"), time.Minute))
+
+	fake.Advance(59 * time.Second)
+	_, ok, _ := s.Get("k")
+	assert.True(t, ok)
+
+	fake.Advance(time.Second)
+	_, ok, _ = s.Get("k")
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), s.Size())
+}
+
+func TestFileStore_AccessTimesFollowClock(t *testing.T) {
+	dir := t.TempDir()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s, err := NewFileStore(dir, FileStoreOptions{Clock: fake})
+	require.NoError(t, err)
+	require.NoError(t, s.Set("a", []byte("1"), 0))
+	require.NoError(t, s.Set("b", []byte("2"), 0))
+
+	fake.Advance(time.Hour)
+	_, ok, err := s.Get("a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	info, err := os.Stat(s.path("a"))
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(fake.Now()))
+
+	// The reopened store keeps "a" as the most recently used entry.
+	reopened, err := NewFileStore(dir, FileStoreOptions{Clock: fake})
+	require.NoError(t, err)
+	var keys []string
+	require.NoError(t, reopened.Range(func(key string, value []byte) bool {
+		keys = append(keys, key)
+		return true
+	}))
+	assert.Equal(t, []string{"a", "b"}, keys)
+}
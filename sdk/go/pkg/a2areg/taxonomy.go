@@ -0,0 +1,240 @@
+package a2areg
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TagTaxonomy is a controlled vocabulary for skill tags. Tags are compared
+// case-insensitively after trimming. Create one with NewTagTaxonomy,
+// ParseTagTaxonomy or LoadTagTaxonomy; do not modify it afterwards.
+type TagTaxonomy struct {
+	// Allowed lists the permitted tags.
+	Allowed []string `json:"allowed" yaml:"allowed"`
+	// Parents maps a tag to its broader parent tag, forming a hierarchy.
+	Parents map[string]string `json:"parents,omitempty" yaml:"parents,omitempty"`
+	// Aliases maps deprecated tags to their replacements.
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+
+	allowed map[string]bool
+	parents map[string]string
+	aliases map[string]string
+}
+
+// NewTagTaxonomy builds a taxonomy from allowed tags, a child-to-parent
+// hierarchy and deprecated aliases. Either map may be nil.
+func NewTagTaxonomy(allowed []string, parents, aliases map[string]string) (*TagTaxonomy, error) {
+	t := &TagTaxonomy{Allowed: allowed, Parents: parents, Aliases: aliases}
+	if err := t.compile(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// TagIssue describes a tag that is not in the taxonomy.
+type TagIssue struct {
+	Skill string `json:"skill,omitempty"`
+	Tag   string `json:"tag"`
+	// Suggestion is the closest allowed tag, or empty if none is close.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+func (i TagIssue) String() string {
+	s := fmt.Sprintf("unknown tag %q", i.Tag)
+	if i.Skill != "" {
+		s = fmt.Sprintf("skill %q has %s", i.Skill, s)
+	}
+	if i.Suggestion != "" {
+		s += fmt.Sprintf(" (did you mean %q?)", i.Suggestion)
+	}
+	return s
+}
+
+// ParseTagTaxonomy parses a taxonomy from JSON or YAML.
+func ParseTagTaxonomy(data []byte) (*TagTaxonomy, error) {
+	var t TagTaxonomy
+	// YAML is a superset of JSON, so one decoder reads both.
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, withCode(NewValidationError("Invalid tag taxonomy: "+err.Error(), nil), CodeDecodeFailed)
+	}
+	if err := t.compile(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// LoadTagTaxonomy reads a taxonomy from a JSON or YAML file.
+func LoadTagTaxonomy(path string) (*TagTaxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, withCode(NewA2AError("Failed to read tag taxonomy", map[string]interface{}{"error": err.Error(), "path": path}), CodeInvalidRequest)
+	}
+	return ParseTagTaxonomy(data)
+}
+
+// compile builds the lookup tables and checks that parents and alias
+// replacements are allowed tags and that the hierarchy has no cycles.
+func (t *TagTaxonomy) compile() error {
+	t.allowed = make(map[string]bool, len(t.Allowed))
+	for _, tag := range t.Allowed {
+		t.allowed[canonicalTag(tag)] = true
+	}
+	t.parents = make(map[string]string, len(t.Parents))
+	for tag, parent := range t.Parents {
+		tag, parent = canonicalTag(tag), canonicalTag(parent)
+		if !t.allowed[tag] || !t.allowed[parent] {
+			return NewValidationError(fmt.Sprintf("Tag taxonomy parent %q of %q is not an allowed tag", parent, tag), nil)
+		}
+		t.parents[tag] = parent
+	}
+	t.aliases = make(map[string]string, len(t.Aliases))
+	for alias, replacement := range t.Aliases {
+		alias, replacement = canonicalTag(alias), canonicalTag(replacement)
+		if !t.allowed[replacement] {
+			return NewValidationError(fmt.Sprintf("Tag taxonomy alias %q points at unknown tag %q", alias, replacement), nil)
+		}
+		t.aliases[alias] = replacement
+	}
+
+	for tag := range t.parents {
+		seen := map[string]bool{tag: true}
+		for p, ok := t.parents[tag]; ok; p, ok = t.parents[p] {
+			if seen[p] {
+				return NewValidationError(fmt.Sprintf("Tag taxonomy has a parent cycle through %q", tag), nil)
+			}
+			seen[p] = true
+		}
+	}
+	return nil
+}
+
+func canonicalTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// Known reports whether tag is allowed or a deprecated alias.
+func (t *TagTaxonomy) Known(tag string) bool {
+	tag = canonicalTag(tag)
+	_, aliased := t.aliases[tag]
+	return t.allowed[tag] || aliased
+}
+
+// Ancestors returns tag's parents, nearest first.
+func (t *TagTaxonomy) Ancestors(tag string) []string {
+	var ancestors []string
+	for p, ok := t.parents[canonicalTag(tag)]; ok; p, ok = t.parents[p] {
+		ancestors = append(ancestors, p)
+	}
+	return ancestors
+}
+
+// NormalizeTags returns tags in canonical form with deprecated aliases
+// replaced and duplicates removed, preserving order. Unknown tags are kept.
+func (t *TagTaxonomy) NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = canonicalTag(tag)
+		if replacement, ok := t.aliases[tag]; ok {
+			tag = replacement
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// Suggest returns the allowed tag closest to tag by edit distance, or ""
+// when none is within a third of the tag's length (at least 1 edit).
+func (t *TagTaxonomy) Suggest(tag string) string {
+	tag = canonicalTag(tag)
+	maxDistance := len(tag) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	candidates := make([]string, 0, len(t.allowed))
+	for allowed := range t.allowed {
+		candidates = append(candidates, allowed)
+	}
+	sort.Strings(candidates)
+
+	best, bestDistance := "", maxDistance+1
+	for _, candidate := range candidates {
+		if d := editDistance(tag, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	for alias, replacement := range t.aliases {
+		if d := editDistance(tag, alias); d < bestDistance || (d == bestDistance && replacement < best) {
+			best, bestDistance = replacement, d
+		}
+	}
+	return best
+}
+
+// Check returns an issue for each tag on the agent or its skills that is
+// not in the taxonomy.
+func (t *TagTaxonomy) Check(agent *Agent) []TagIssue {
+	var issues []TagIssue
+	for _, tag := range agent.Tags {
+		if !t.Known(tag) {
+			issues = append(issues, TagIssue{Tag: tag, Suggestion: t.Suggest(tag)})
+		}
+	}
+	for _, skill := range agent.Skills {
+		for _, tag := range skill.Tags {
+			if !t.Known(tag) {
+				issues = append(issues, TagIssue{Skill: skill.ID, Tag: tag, Suggestion: t.Suggest(tag)})
+			}
+		}
+	}
+	return issues
+}
+
+// normalizeAgentTags returns a copy of agent with its tags and skill tags
+// normalized, leaving the caller's agent untouched.
+func (t *TagTaxonomy) normalizeAgentTags(agent *Agent) *Agent {
+	normalized := *agent
+	normalized.Tags = t.NormalizeTags(agent.Tags)
+	if agent.Skills != nil {
+		normalized.Skills = make([]AgentSkill, len(agent.Skills))
+		for i, skill := range agent.Skills {
+			skill.Tags = t.NormalizeTags(skill.Tags)
+			normalized.Skills[i] = skill
+		}
+	}
+	return &normalized
+}
+
+// editDistance is the Levenshtein distance between a and b, by rune.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
@@ -0,0 +1,126 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTaxonomyYAML = `
+allowed: [food, cooking, baking, nutrition, travel, weather, search]
+parents:
+  cooking: food
+  baking: cooking
+  nutrition: food
+aliases:
+  cookery: cooking
+  recipes: cooking
+  diet: nutrition
+`
+
+func testTaxonomy(t *testing.T) *TagTaxonomy {
+	taxonomy, err := ParseTagTaxonomy([]byte(testTaxonomyYAML))
+	require.NoError(t, err)
+	return taxonomy
+}
+
+func TestParseTagTaxonomy_JSONAndYAML(t *testing.T) {
+	fromJSON, err := ParseTagTaxonomy([]byte(`{"allowed": ["Food", "cooking"], "parents": {"cooking": "food"}, "aliases": {"cookery": "Cooking"}}`))
+	require.NoError(t, err)
+	assert.True(t, fromJSON.Known("food"))
+	assert.True(t, fromJSON.Known(" COOKERY "))
+	assert.Equal(t, []string{"food"}, fromJSON.Ancestors("cooking"))
+
+	path := filepath.Join(t.TempDir(), "tags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testTaxonomyYAML), 0o600))
+	fromYAML, err := LoadTagTaxonomy(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cooking", "food"}, fromYAML.Ancestors("baking"))
+}
+
+func TestParseTagTaxonomy_Invalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unknown parent":      `{"allowed": ["a"], "parents": {"a": "b"}}`,
+		"unknown replacement": `{"allowed": ["a"], "aliases": {"x": "b"}}`,
+		"cycle":               `{"allowed": ["a", "b"], "parents": {"a": "b", "b": "a"}}`,
+		"syntax":              `{"allowed": [`,
+	} {
+		_, err := ParseTagTaxonomy([]byte(doc))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestTagTaxonomy_NormalizeTags(t *testing.T) {
+	taxonomy := testTaxonomy(t)
+	assert.Equal(t,
+		[]string{"cooking", "nutrition", "unknown-tag"},
+		taxonomy.NormalizeTags([]string{"Recipes", "cookery", "diet", " cooking ", "unknown-tag"}))
+	assert.Nil(t, taxonomy.NormalizeTags(nil))
+}
+
+func TestTagTaxonomy_Suggest(t *testing.T) {
+	taxonomy := testTaxonomy(t)
+	for tag, want := range map[string]string{
+		"cookng":    "cooking",
+		"Bakng":     "baking",
+		"nutriton":  "nutrition",
+		"wheather":  "weather",
+		"traval":    "travel",
+		"recipies":  "cooking", // near an alias: suggest its replacement
+		"xyz":       "",
+		"astronomy": "",
+	} {
+		assert.Equal(t, want, taxonomy.Suggest(tag), tag)
+	}
+}
+
+func TestA2ARegClient_ValidateAgent_TagTaxonomy(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{TagTaxonomy: testTaxonomy(t)})
+
+	agent := testPublishAgent()
+	agent.Skills[0].Tags = []string{"cooking", "cookng"}
+	err := client.ValidateAgent(agent)
+	require.Error(t, err)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+	assert.Contains(t, err.Error(), `skill "s1" has unknown tag "cookng" (did you mean "cooking"?)`)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []TagIssue{{Skill: "s1", Tag: "cookng", Suggestion: "cooking"}}, validationErr.Details["unknown_tags"])
+
+	// Deprecated aliases are accepted; publishing rewrites them.
+	agent.Skills[0].Tags = []string{"recipes"}
+	assert.NoError(t, client.ValidateAgent(agent))
+
+	// Without a taxonomy, tags are free-form.
+	agent.Skills[0].Tags = []string{"anything"}
+	assert.NoError(t, NewA2ARegClient(A2ARegClientOptions{}).ValidateAgent(agent))
+}
+
+func TestA2ARegClient_PublishAgent_RewritesAliases(t *testing.T) {
+	var published []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Card map[string]interface{} `json:"card"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		published = req.Card["skills"].([]interface{})[0].(map[string]interface{})["tags"].([]interface{})
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "agent-1", "name": "Recipe Agent"})
+	}))
+	defer server.Close()
+
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", TagTaxonomy: testTaxonomy(t)})
+	agent := testPublishAgent()
+	agent.Skills[0].Tags = []string{"Recipes", "search"}
+
+	_, err := client.PublishAgent(agent, true)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"cooking", "search"}, published)
+	assert.Equal(t, []string{"Recipes", "search"}, agent.Skills[0].Tags, "caller's agent is not modified")
+}
@@ -0,0 +1,146 @@
+package a2areg
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultTokenRefreshWindow is how long before the access token expires
+// StartAutoRefresh replaces it, when TokenRefreshWindow is not set.
+const DefaultTokenRefreshWindow = 2 * time.Minute
+
+// Failed background refreshes are retried after minRefreshBackoff,
+// doubling up to maxRefreshBackoff.
+const (
+	minRefreshBackoff = time.Second
+	maxRefreshBackoff = time.Minute
+)
+
+// minRefreshInterval is the least time StartAutoRefresh leaves between
+// issuing a token and replacing it, however short-lived the tokens are.
+const minRefreshInterval = 10 * time.Second
+
+// autoRefreshState tracks the StartAutoRefresh goroutine.
+type autoRefreshState struct {
+	mu   sync.Mutex
+	stop chan struct{} // closed to stop the goroutine; nil when none runs
+}
+
+// StartAutoRefresh starts a goroutine that replaces the OAuth access token
+// shortly before it expires, so requests do not wait for a refresh. The
+// token is refreshed TokenRefreshWindow before its expiry, or half its
+// lifetime for short-lived tokens, less a random jitter of up to a tenth of
+// the window that spreads the refreshes of clients started together. A
+// token is never replaced sooner than minRefreshInterval after it was
+// issued. A failed refresh is reported to
+// OnRefreshError and retried with exponential backoff; requests keep
+// refreshing an expired token themselves meanwhile.
+//
+// The goroutine stops when ctx is done or the client is closed. Calling
+// StartAutoRefresh while it runs does nothing. It returns an error when
+// the client has no OAuth client credentials.
+func (c *A2ARegClient) StartAutoRefresh(ctx context.Context) error {
+	if c.closed.Load() {
+		return errClientClosed()
+	}
+	if c.configErr != nil {
+		return c.configErr
+	}
+	if c.clientID == "" || c.clientSecret.Empty() {
+		return withCode(NewAuthenticationError("Client ID and secret are required for automatic token refresh", nil), CodeAuthMissingCredentials)
+	}
+
+	s := &c.autoRefresh
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return nil
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	go c.runAutoRefresh(ctx, stop)
+	return nil
+}
+
+// stopAutoRefresh stops the StartAutoRefresh goroutine, if one runs.
+func (c *A2ARegClient) stopAutoRefresh() {
+	s := &c.autoRefresh
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (c *A2ARegClient) runAutoRefresh(ctx context.Context, stop chan struct{}) {
+	defer func() {
+		s := &c.autoRefresh
+		s.mu.Lock()
+		if s.stop == stop {
+			s.stop = nil
+		}
+		s.mu.Unlock()
+	}()
+
+	var backoff time.Duration
+	for {
+		delay := backoff
+		if delay == 0 {
+			delay = c.untilTokenRefresh(rand.Float64() / 10)
+		}
+		timer := c.clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+		if c.closed.Load() || c.usingAPIKey() {
+			return
+		}
+		// A request may have refreshed the token while we waited.
+		if backoff == 0 && c.untilTokenRefresh(0.1) > 0 {
+			continue
+		}
+
+		if err := c.authenticate(ctx); err != nil {
+			if ctx.Err() != nil || c.closed.Load() {
+				return
+			}
+			if c.onRefreshError != nil {
+				c.onRefreshError(err)
+			}
+			backoff = min(max(2*backoff, minRefreshBackoff), maxRefreshBackoff)
+			continue
+		}
+		backoff = 0
+	}
+}
+
+// untilTokenRefresh returns how long until the token should be replaced:
+// when it enters the refresh window, widened by jitter (a fraction of the
+// window), but not before minRefreshInterval after it was issued. The
+// window is TokenRefreshWindow, capped at half the token's lifetime. It
+// returns zero when there is no token, and the window itself when the
+// token does not expire, to check again later.
+func (c *A2ARegClient) untilTokenRefresh(jitter float64) time.Duration {
+	c.tokens.mu.RLock()
+	missing, issuedAt, expiresAt := c.tokens.accessToken.Empty(), c.tokens.issuedAt, c.tokens.expiresAt
+	c.tokens.mu.RUnlock()
+	switch {
+	case missing:
+		return 0
+	case expiresAt == nil:
+		return c.tokenRefreshWindow
+	}
+	window := min(c.tokenRefreshWindow, expiresAt.Sub(issuedAt)/2)
+	window += time.Duration(jitter * float64(window))
+	now := c.clock.Now()
+	return max(expiresAt.Sub(now)-window, issuedAt.Add(minRefreshInterval).Sub(now), 0)
+}
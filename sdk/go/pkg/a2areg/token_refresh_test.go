@@ -0,0 +1,193 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"a2areg/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshingRegistry issues tokens valid for lifetime on the fake clock and
+// records, for every other request, how long its token had left.
+type refreshingRegistry struct {
+	*httptest.Server
+	mu        sync.Mutex
+	issued    []time.Time // expiry of token-<i>
+	failNext  int         // token requests to fail
+	remaining []time.Duration
+}
+
+func newRefreshingRegistry(t *testing.T, fake *clock.Fake, lifetime time.Duration) *refreshingRegistry {
+	r := &refreshingRegistry{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if req.URL.Path == "/auth/oauth/token" {
+			if r.failNext > 0 {
+				r.failNext--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			r.issued = append(r.issued, fake.Now().Add(lifetime))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": fmt.Sprintf("token-%d", len(r.issued)-1),
+				"expires_in":   int(lifetime / time.Second),
+			})
+			return
+		}
+		i, err := strconv.Atoi(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer token-"))
+		require.NoError(t, err)
+		r.remaining = append(r.remaining, r.issued[i].Sub(fake.Now()))
+		w.Write([]byte(`{"status": "healthy"}`))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *refreshingRegistry) tokens() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.issued)
+}
+
+func TestStartAutoRefresh_RequestsNeverSeeExpiredTokens(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	registry := newRefreshingRegistry(t, fake, 6*time.Minute)
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:  registry.URL,
+		ClientID:     "svc",
+		ClientSecret: "secret",
+		Clock:        fake,
+		OnRefreshError: func(err error) {
+			t.Errorf("unexpected refresh error: %v", err)
+		},
+	})
+	defer client.Close()
+	require.NoError(t, client.StartAutoRefresh(context.Background()))
+
+	for i := 0; i < 120; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(10 * time.Second)
+		fake.BlockUntil(1)
+		_, err := client.Health(context.Background())
+		require.NoError(t, err)
+	}
+
+	// 20 minutes of 6-minute tokens, each replaced between 3 and 3m12s
+	// after issue: the client's 60s margin plus the 2m window and jitter.
+	assert.GreaterOrEqual(t, registry.tokens(), 7)
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, left := range registry.remaining {
+		assert.Greater(t, left, time.Minute, "no request waited on a refresh")
+	}
+}
+
+func TestStartAutoRefresh_ShortLivedTokens(t *testing.T) {
+	for _, tc := range []struct {
+		lifetime  time.Duration
+		min, max  int
+		narrative string
+	}{
+		// expires_in 120s leaves 60s after the client's margin: refreshed
+		// after 27 to 30s, at half the lifetime rather than at once.
+		{lifetime: 2 * time.Minute, min: 10, max: 12, narrative: "half the lifetime"},
+		// expires_in 30s is already expired with the margin: refreshed
+		// every minRefreshInterval.
+		{lifetime: 30 * time.Second, min: 30, max: 31, narrative: "minimum interval"},
+	} {
+		fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		registry := newRefreshingRegistry(t, fake, tc.lifetime)
+		client := NewA2ARegClient(A2ARegClientOptions{
+			RegistryURL:  registry.URL,
+			ClientID:     "svc",
+			ClientSecret: "secret",
+			Clock:        fake,
+		})
+		require.NoError(t, client.StartAutoRefresh(context.Background()))
+
+		for i := 0; i < 300; i++ {
+			fake.BlockUntil(1)
+			fake.Advance(time.Second)
+		}
+		fake.BlockUntil(1)
+		tokens := registry.tokens()
+		assert.GreaterOrEqual(t, tokens, tc.min, tc.narrative)
+		assert.LessOrEqual(t, tokens, tc.max, tc.narrative)
+		client.Close()
+	}
+}
+
+func TestStartAutoRefresh_RetriesWithBackoff(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	registry := newRefreshingRegistry(t, fake, 6*time.Minute)
+	errs := make(chan error, 10)
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:    registry.URL,
+		ClientID:       "svc",
+		ClientSecret:   "secret",
+		Clock:          fake,
+		OnRefreshError: func(err error) { errs <- err },
+	})
+	defer client.Close()
+	require.NoError(t, client.Authenticate())
+	require.NoError(t, client.StartAutoRefresh(context.Background()))
+
+	registry.mu.Lock()
+	registry.failNext = 3
+	registry.mu.Unlock()
+	fake.BlockUntil(1)
+	fake.Advance(190 * time.Second)
+	var authErr *AuthenticationError
+	require.ErrorAs(t, <-errs, &authErr)
+
+	// Retries come after 1s, 2s and 4s.
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		fake.BlockUntil(1)
+		fake.Advance(backoff - time.Millisecond)
+		fake.BlockUntil(1)
+		assert.Empty(t, errs)
+		fake.Advance(time.Millisecond)
+		if backoff < 4*time.Second {
+			require.ErrorAs(t, <-errs, &authErr)
+		}
+	}
+	fake.BlockUntil(1)
+	assert.Equal(t, 2, registry.tokens())
+	assert.Empty(t, errs)
+	assert.Equal(t, "token-1", client.token())
+}
+
+func TestStartAutoRefresh_Stops(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	registry := newRefreshingRegistry(t, fake, time.Hour)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, ClientID: "svc", ClientSecret: "secret", Clock: fake})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, client.StartAutoRefresh(ctx))
+	require.NoError(t, client.StartAutoRefresh(ctx), "starting twice is a no-op")
+	fake.BlockUntil(1)
+	assert.Equal(t, 1, fake.Pending(), "one goroutine runs")
+	cancel()
+	assert.Eventually(t, func() bool { return fake.Pending() == 0 }, time.Second, time.Millisecond)
+
+	require.NoError(t, client.StartAutoRefresh(context.Background()))
+	fake.BlockUntil(1)
+	require.NoError(t, client.Close())
+	assert.Eventually(t, func() bool { return fake.Pending() == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, CodeClientClosed, ErrorCode(client.StartAutoRefresh(context.Background())))
+
+	apiKeyClient := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "key"})
+	assert.Equal(t, CodeAuthMissingCredentials, ErrorCode(apiKeyClient.StartAutoRefresh(context.Background())))
+}
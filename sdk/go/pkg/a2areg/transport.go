@@ -0,0 +1,202 @@
+package a2areg
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// newHTTPClient builds the client used for registry requests from
+// HTTPClient, Transport and Timeout.
+func newHTTPClient(opts A2ARegClientOptions) *http.Client {
+	client := &http.Client{Timeout: opts.Timeout}
+	if opts.HTTPClient != nil {
+		injected := *opts.HTTPClient
+		client = &injected
+		if client.Timeout == 0 {
+			client.Timeout = opts.Timeout
+		}
+	}
+	client.Transport = newTransport(opts)
+	return client
+}
+
+// newCardHTTPClient builds the client used to fetch agent cards from agent
+// hosts. It is the registry client without PinnedCertificates, which pin
+// the registry's certificates and would refuse every other host; TLSConfig,
+// HostOverrides and Resolver still apply.
+func newCardHTTPClient(opts A2ARegClientOptions) *http.Client {
+	opts.PinnedCertificates = nil
+	return newHTTPClient(opts)
+}
+
+// baseTransport returns the injected transport, nil meaning
+// http.DefaultTransport.
+func baseTransport(opts A2ARegClientOptions) http.RoundTripper {
+	if opts.Transport == nil && opts.HTTPClient != nil {
+		return opts.HTTPClient.Transport
+	}
+	return opts.Transport
+}
+
+// configuresTransport reports whether opts set anything newTransport has to
+// apply to an *http.Transport.
+func configuresTransport(opts A2ARegClientOptions) bool {
+	return opts.TLSConfig != nil || len(opts.PinnedCertificates) > 0 || len(opts.HostOverrides) > 0 || opts.Resolver != nil
+}
+
+// validateTransport refuses TLS and dialing options on an injected transport
+// they cannot be applied to, rather than sending requests without them.
+func validateTransport(opts A2ARegClientOptions) error {
+	if !configuresTransport(opts) {
+		return nil
+	}
+	switch base := baseTransport(opts).(type) {
+	case nil, *http.Transport:
+		return nil
+	default:
+		return withCode(NewValidationError(
+			"TLSConfig, PinnedCertificates, HostOverrides and Resolver need an *http.Transport; configure them on the injected transport instead",
+			map[string]interface{}{"transport": fmt.Sprintf("%T", base)},
+		), CodeInvalidRequest)
+	}
+}
+
+// newTransport builds the RoundTripper used for registry requests: the
+// injected transport, with TLSConfig, PinnedCertificates, HostOverrides and
+// Resolver applied. validateTransport has made sure that the transport is
+// an *http.Transport when any of those is set.
+func newTransport(opts A2ARegClientOptions) http.RoundTripper {
+	base := baseTransport(opts)
+	if !configuresTransport(opts) {
+		return base // nil means http.DefaultTransport
+	}
+	customDial := len(opts.HostOverrides) > 0 || opts.Resolver != nil
+
+	var transport *http.Transport
+	switch t := base.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return base
+	}
+
+	tlsConfig := &tls.Config{}
+	if opts.TLSConfig != nil {
+		tlsConfig = opts.TLSConfig.Clone()
+	} else if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+	if len(opts.PinnedCertificates) > 0 {
+		tlsConfig.VerifyPeerCertificate = verifyPins(opts.PinnedCertificates, tlsConfig.VerifyPeerCertificate)
+	}
+	transport.TLSClientConfig = tlsConfig
+	if customDial {
+		transport.DialContext = overrideDial(transport.DialContext, opts.HostOverrides, opts.Resolver)
+	}
+	return transport
+}
+
+// overrideDial wraps dial so that connections to a host in overrides go to
+// the mapped address instead. Only the TCP destination changes: the request
+// URL, and with it the TLS server name and certificate verification, still
+// use the original host name. Other hosts are resolved with resolver when it
+// is set. A nil dial means a default net.Dialer.
+func overrideDial(dial func(ctx context.Context, network, addr string) (net.Conn, error), overrides map[string]string, resolver *net.Resolver) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil || resolver != nil {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Resolver: resolver}
+		dial = dialer.DialContext
+	}
+	targets := make(map[string]string, len(overrides))
+	for host, target := range overrides {
+		targets[strings.ToLower(host)] = target
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return dial(ctx, network, addr)
+		}
+		target, ok := targets[strings.ToLower(addr)]
+		if !ok {
+			target, ok = targets[strings.ToLower(host)]
+		}
+		if ok {
+			if _, _, err := net.SplitHostPort(target); err != nil {
+				target = net.JoinHostPort(target, port)
+			}
+			addr = target
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+// SPKIPin returns the base64 SHA-256 hash of cert's SubjectPublicKeyInfo, the
+// format expected by PinnedCertificates.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// verifyPins returns a VerifyPeerCertificate callback that accepts a
+// connection only if some certificate in a verified chain matches one of
+// pins. It runs after, not instead of, standard chain verification; next is
+// any callback already present on the base TLS config.
+func verifyPins(pins []string, next func([][]byte, [][]*x509.Certificate) error) func([][]byte, [][]*x509.Certificate) error {
+	allowed := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		allowed[pin] = true
+	}
+
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if next != nil {
+			if err := next(rawCerts, verifiedChains); err != nil {
+				return err
+			}
+		}
+
+		for _, chain := range verifiedChains {
+			for _, cert := range chain {
+				if allowed[SPKIPin(cert)] {
+					return nil
+				}
+			}
+		}
+		if len(verifiedChains) == 0 {
+			// Chain verification was skipped (InsecureSkipVerify), so the
+			// pins are the only check; match against what was presented.
+			for _, raw := range rawCerts {
+				if cert, err := x509.ParseCertificate(raw); err == nil && allowed[SPKIPin(cert)] {
+					return nil
+				}
+			}
+		}
+
+		presented := ""
+		if len(rawCerts) > 0 {
+			if leaf, err := x509.ParseCertificate(rawCerts[0]); err == nil {
+				presented = SPKIPin(leaf)
+			}
+		}
+		return NewPinMismatchError(presented)
+	}
+}
+
+// asPinMismatch extracts a PinMismatchError from a transport error.
+func asPinMismatch(err error) *PinMismatchError {
+	var pinErr *PinMismatchError
+	if errors.As(err, &pinErr) {
+		return pinErr
+	}
+	return nil
+}
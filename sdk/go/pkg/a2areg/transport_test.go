@@ -0,0 +1,362 @@
+package a2areg
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTLSServerWithKey starts a TLS test server with a freshly generated
+// self-signed certificate, so that each server presents a distinct key.
+func newTLSServerWithKey(t *testing.T, handler http.Handler) (*httptest.Server, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "registry.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"registry.test"},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // handshake failures are expected
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server, cert
+}
+
+func TestA2ARegClient_PinnedCertificates(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+	pinnedServer, pinnedCert := newTLSServerWithKey(t, handler)
+	otherServer, otherCert := newTLSServerWithKey(t, handler)
+	require.NotEqual(t, SPKIPin(pinnedCert), SPKIPin(otherCert))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(pinnedCert)
+	roots.AddCert(otherCert)
+
+	newClient := func(url string, pins ...string) *A2ARegClient {
+		return NewA2ARegClient(A2ARegClientOptions{
+			RegistryURL:        url,
+			APIKey:             "test-key",
+			TLSConfig:          &tls.Config{RootCAs: roots},
+			PinnedCertificates: pins,
+		})
+	}
+
+	t.Run("match", func(t *testing.T) {
+		_, err := newClient(pinnedServer.URL, SPKIPin(pinnedCert)).Health(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("rotation accepts any listed pin", func(t *testing.T) {
+		client := newClient(pinnedServer.URL, "c3RhbGUtcGluLXN0YWxlLXBpbi1zdGFsZS1waW4tc3RhbGU=", SPKIPin(pinnedCert))
+		_, err := client.Health(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		_, err := newClient(otherServer.URL, SPKIPin(pinnedCert)).Health(context.Background())
+		var pinErr *PinMismatchError
+		require.ErrorAs(t, err, &pinErr)
+		assert.Equal(t, SPKIPin(otherCert), pinErr.Pin)
+		assert.Equal(t, CodePinMismatch, ErrorCode(err))
+		assert.Contains(t, err.Error(), SPKIPin(otherCert))
+	})
+
+	t.Run("mismatch during authentication", func(t *testing.T) {
+		client := NewA2ARegClient(A2ARegClientOptions{
+			RegistryURL:        otherServer.URL,
+			ClientID:           "id",
+			ClientSecret:       "secret",
+			TLSConfig:          &tls.Config{RootCAs: roots},
+			PinnedCertificates: []string{SPKIPin(pinnedCert)},
+		})
+		var pinErr *PinMismatchError
+		assert.ErrorAs(t, client.Authenticate(), &pinErr)
+	})
+
+	t.Run("agent cards are not pinned", func(t *testing.T) {
+		agentServer, agentCert := newTLSServerWithKey(t, serveCard(t, "/.well-known/agent-card.json"))
+		roots := roots.Clone()
+		roots.AddCert(agentCert)
+		client := NewA2ARegClient(A2ARegClientOptions{
+			RegistryURL:        pinnedServer.URL,
+			APIKey:             "test-key",
+			TLSConfig:          &tls.Config{RootCAs: roots},
+			PinnedCertificates: []string{SPKIPin(pinnedCert)},
+		})
+
+		card, err := client.FetchAgentCard(context.Background(), agentServer.URL)
+		require.NoError(t, err)
+		assert.Equal(t, testAgentCard().Name, card.Name)
+		_, err = client.Health(context.Background())
+		assert.NoError(t, err, "the registry is still pinned")
+	})
+
+	t.Run("agent cards still use TLSConfig", func(t *testing.T) {
+		agentServer, _ := newTLSServerWithKey(t, serveCard(t, "/.well-known/agent-card.json"))
+		_, err := newClient(pinnedServer.URL, SPKIPin(pinnedCert)).FetchAgentCard(context.Background(), agentServer.URL)
+		var fetchErr *CardFetchError
+		assert.ErrorAs(t, err, &fetchErr, "the agent's certificate is not in RootCAs")
+	})
+
+	t.Run("CA validation still applies", func(t *testing.T) {
+		client := NewA2ARegClient(A2ARegClientOptions{
+			RegistryURL:        pinnedServer.URL,
+			APIKey:             "test-key",
+			PinnedCertificates: []string{SPKIPin(pinnedCert)},
+		})
+		_, err := client.Health(context.Background())
+		assert.Equal(t, CodeRequestFailed, ErrorCode(err))
+	})
+}
+
+// recordingTransport records the path of every request before passing it to
+// http.DefaultTransport.
+type recordingTransport struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.paths = append(rt.paths, req.URL.Path)
+	rt.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func oauthHealthServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/oauth/token" {
+			w.Write([]byte(`{"access_token": "token", "expires_in": 3600}`))
+			return
+		}
+		w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestA2ARegClient_InjectedTransport(t *testing.T) {
+	server := oauthHealthServer(t)
+	rt := &recordingTransport{}
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:  server.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Transport:    rt,
+	})
+
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/auth/oauth/token", "/health"}, rt.paths)
+	assert.Equal(t, 30*time.Second, client.httpClient.Timeout)
+}
+
+func TestA2ARegClient_InjectedHTTPClient(t *testing.T) {
+	server := oauthHealthServer(t)
+	rt := &recordingTransport{}
+	injected := &http.Client{Transport: rt}
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:  server.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		HTTPClient:   injected,
+		Timeout:      5 * time.Second,
+	})
+
+	require.NoError(t, client.Authenticate())
+	_, err := client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/auth/oauth/token", "/health"}, rt.paths)
+
+	// The client's missing timeout is filled in on a copy.
+	assert.Equal(t, 5*time.Second, client.httpClient.Timeout)
+	assert.Zero(t, injected.Timeout)
+
+	// A client with its own timeout keeps it, and Transport replaces its
+	// transport.
+	override := &recordingTransport{}
+	client = NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL: server.URL,
+		APIKey:      "test-key",
+		HTTPClient:  &http.Client{Transport: rt, Timeout: time.Minute},
+		Transport:   override,
+	})
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, client.httpClient.Timeout)
+	assert.Equal(t, []string{"/health"}, override.paths)
+	assert.Len(t, rt.paths, 2)
+}
+
+func TestA2ARegClient_PinsApplyToInjectedTransport(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+	server, cert := newTLSServerWithKey(t, handler)
+	_, otherCert := newTLSServerWithKey(t, handler)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	newClient := func(pin string) *A2ARegClient {
+		return NewA2ARegClient(A2ARegClientOptions{
+			RegistryURL:        server.URL,
+			APIKey:             "test-key",
+			Transport:          &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+			PinnedCertificates: []string{pin},
+		})
+	}
+
+	_, err := newClient(SPKIPin(cert)).Health(context.Background())
+	assert.NoError(t, err, "the transport's own TLS config is kept")
+
+	_, err = newClient(SPKIPin(otherCert)).Health(context.Background())
+	var pinErr *PinMismatchError
+	assert.ErrorAs(t, err, &pinErr)
+}
+
+func TestA2ARegClient_PinsRejectCustomRoundTripper(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy"}`))
+	})
+	server, cert := newTLSServerWithKey(t, handler)
+	rt := &recordingTransport{}
+	opts := A2ARegClientOptions{
+		RegistryURL:        server.URL,
+		APIKey:             "test-key",
+		Transport:          rt,
+		PinnedCertificates: []string{SPKIPin(cert)},
+	}
+
+	err := opts.Validate()
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, CodeInvalidRequest, ErrorCode(err))
+
+	// The pins are not silently dropped: no request is sent at all.
+	_, err = NewA2ARegClient(opts).Health(context.Background())
+	assert.Equal(t, CodeInvalidRequest, ErrorCode(err))
+	assert.Empty(t, rt.paths)
+
+	opts.Transport = nil
+	opts.HTTPClient = &http.Client{Transport: rt}
+	assert.Equal(t, CodeInvalidRequest, ErrorCode(opts.Validate()))
+}
+
+func TestA2ARegClient_HostOverrides(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server, cert := newTLSServerWithKey(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.TLS.ServerName+" "+r.Host+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/auth/oauth/token":
+			w.Write([]byte(`{"access_token": "token", "expires_in": 3600}`))
+		case "/agents/agent-1/card":
+			w.Write([]byte(`{"name": "Recipe Agent"}`))
+		default:
+			w.Write([]byte(`{"status":"healthy"}`))
+		}
+	}))
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	client := NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:   "https://registry.test:" + port,
+		ClientID:      "id",
+		ClientSecret:  "secret",
+		TLSConfig:     &tls.Config{RootCAs: roots},
+		HostOverrides: map[string]string{"REGISTRY.test": "127.0.0.1"},
+	})
+	_, err = client.Health(context.Background())
+	require.NoError(t, err)
+	card, err := client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Recipe Agent", card.Name)
+
+	host := "registry.test:" + port
+	assert.Equal(t, []string{
+		"registry.test " + host + " /auth/oauth/token",
+		"registry.test " + host + " /health",
+		"registry.test " + host + " /agents/agent-1/card",
+	}, seen)
+
+	// The certificate is still checked against the original host name.
+	client = NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:   "https://elsewhere.test",
+		APIKey:        "test",
+		TLSConfig:     &tls.Config{RootCAs: roots},
+		HostOverrides: map[string]string{"elsewhere.test:443": server.Listener.Addr().String()},
+	})
+	_, err = client.Health(context.Background())
+	var requestErr *A2AError
+	require.ErrorAs(t, err, &requestErr)
+	assert.Contains(t, requestErr.Details["error"], "certificate is valid for registry.test, not elsewhere.test")
+}
+
+func TestA2ARegClient_CustomResolver(t *testing.T) {
+	var lookups atomic.Int32
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			lookups.Add(1)
+			return nil, errors.New("no resolver in tests")
+		},
+	}
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: "http://registry.invalid-zone", APIKey: "test", Resolver: resolver})
+
+	_, err := client.Health(context.Background())
+	require.Error(t, err)
+	assert.NotZero(t, lookups.Load())
+	lookups.Store(0)
+
+	// Overridden hosts skip the resolver.
+	server := oauthHealthServer(t)
+	client = NewA2ARegClient(A2ARegClientOptions{
+		RegistryURL:   "http://registry.invalid-zone",
+		APIKey:        "test",
+		Resolver:      resolver,
+		HostOverrides: map[string]string{"registry.invalid-zone": server.Listener.Addr().String()},
+	})
+	_, err = client.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, lookups.Load())
+}
@@ -0,0 +1,88 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// AgentVersion is one published version of an agent.
+type AgentVersion struct {
+	Version     string    `json:"version"`
+	PublishedAt time.Time `json:"published_at"`
+	Deprecated  bool      `json:"deprecated"`
+	// Changelog is the release note the version was published with.
+	Changelog string `json:"changelog,omitempty"`
+}
+
+// ListAgentVersions lists the versions the registry keeps of agentID, in
+// the order it returns them.
+func (c *A2ARegClient) ListAgentVersions(ctx context.Context, agentID string) ([]AgentVersion, error) {
+	data, err := c.makeRequestContext(ctx, "GET", "/agents/"+agentID+"/versions", nil, nil)
+	if err != nil {
+		return nil, agentNotFound(err)
+	}
+	var listing struct {
+		Versions []AgentVersion `json:"versions"`
+	}
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return nil, withCode(NewA2AError("Failed to decode versions response", map[string]interface{}{"error": err.Error()}), CodeDecodeFailed)
+	}
+	return listing.Versions, nil
+}
+
+// GetAgentVersion gets agentID as it was published at version. An unknown
+// agent or version gives a *NotFoundError with code CodeVersionNotFound.
+func (c *A2ARegClient) GetAgentVersion(ctx context.Context, agentID, version string) (*Agent, error) {
+	body, err := c.makeRequestContext(ctx, "GET", "/agents/"+agentID+"/versions/"+url.PathEscape(version), nil, nil)
+	if err != nil {
+		if nf, ok := err.(*NotFoundError); ok && nf.Code == CodeNotFound {
+			nf.Message = fmt.Sprintf("Version %s of agent %s not found", version, agentID)
+			nf.Code = CodeVersionNotFound
+		}
+		return nil, err
+	}
+	return decodeAgent(body)
+}
+
+// GetLatestCompatibleVersion gets the highest version of agentID that
+// satisfies constraint, which takes the syntax of an AgentRef constraint
+// ("^1.2", ">=2.0 <3.0", ...). Deprecated versions are chosen only when no
+// other version satisfies it. It returns a *NotFoundError with code
+// CodeVersionNotFound when none does.
+func (c *A2ARegClient) GetLatestCompatibleVersion(ctx context.Context, agentID, constraint string) (*Agent, error) {
+	clauses, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return nil, NewValidationError(fmt.Sprintf("Invalid version constraint %q: %s", constraint, err), map[string]interface{}{"constraint": constraint})
+	}
+	versions, err := c.ListAgentVersions(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowed []AgentVersion
+	all := make([]string, 0, len(versions))
+	for _, v := range versions {
+		all = append(all, v.Version)
+		if versionAllowed(clauses, v.Version) {
+			allowed = append(allowed, v)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, withCode(NewNotFoundError(
+			fmt.Sprintf("No version of agent %s satisfies %s", agentID, constraint),
+			map[string]interface{}{"constraint": constraint, "versions": all},
+		), CodeVersionNotFound)
+	}
+
+	sort.SliceStable(allowed, func(i, j int) bool {
+		if allowed[i].Deprecated != allowed[j].Deprecated {
+			return !allowed[i].Deprecated
+		}
+		return compareRefVersions(allowed[i].Version, allowed[j].Version) > 0
+	})
+	return c.GetAgentVersion(ctx, agentID, allowed[0].Version)
+}
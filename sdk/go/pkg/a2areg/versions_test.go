@@ -0,0 +1,108 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionsRegistry serves the version history of agent-1.
+func versionsRegistry(t *testing.T, versions ...AgentVersion) *A2ARegClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/agents/agent-1/versions")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if rest == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"versions": versions})
+			return
+		}
+		for _, v := range versions {
+			if "/"+v.Version == rest {
+				json.NewEncoder(w).Encode(Agent{Name: "Versioned", Version: v.Version})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail": "Version not found"}`))
+	}))
+	t.Cleanup(server.Close)
+	return NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test-key"})
+}
+
+func TestListAgentVersions(t *testing.T) {
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	client := versionsRegistry(t,
+		AgentVersion{Version: "1.0.0", PublishedAt: published, Deprecated: true, Changelog: "Initial release"},
+		AgentVersion{Version: "1.1.0", PublishedAt: published.Add(24 * time.Hour)},
+	)
+
+	versions, err := client.ListAgentVersions(context.Background(), "agent-1")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, AgentVersion{Version: "1.0.0", PublishedAt: published, Deprecated: true, Changelog: "Initial release"}, versions[0])
+
+	_, err = client.ListAgentVersions(context.Background(), "missing")
+	assert.Equal(t, CodeAgentNotFound, ErrorCode(err))
+}
+
+func TestGetAgentVersion(t *testing.T) {
+	client := versionsRegistry(t, AgentVersion{Version: "1.0.0"})
+
+	agent, err := client.GetAgentVersion(context.Background(), "agent-1", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", agent.Version)
+
+	_, err = client.GetAgentVersion(context.Background(), "agent-1", "9.9.9")
+	var nf *NotFoundError
+	require.True(t, errors.As(err, &nf))
+	assert.Equal(t, CodeVersionNotFound, ErrorCode(err))
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+}
+
+func TestGetLatestCompatibleVersion(t *testing.T) {
+	client := versionsRegistry(t,
+		AgentVersion{Version: "1.0.0"},
+		AgentVersion{Version: "1.2.0"},
+		AgentVersion{Version: "1.2.5"},
+		AgentVersion{Version: "1.10.0", Deprecated: true},
+		AgentVersion{Version: "2.0.0"},
+		AgentVersion{Version: "2.3.1"},
+		AgentVersion{Version: "3.0.0"},
+	)
+
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.2", "1.2.5"}, // 1.10.0 is deprecated
+		{">=2.0 <3.0", "2.3.1"},
+		{"~1.2", "1.2.5"},
+		{">=1.10, <2", "1.10.0"}, // only a deprecated version fits
+		{"1.0", "1.0.0"},
+		{"*", "3.0.0"},
+	}
+	for _, tt := range tests {
+		agent, err := client.GetLatestCompatibleVersion(context.Background(), "agent-1", tt.constraint)
+		require.NoError(t, err, tt.constraint)
+		assert.Equal(t, tt.want, agent.Version, tt.constraint)
+	}
+
+	_, err := client.GetLatestCompatibleVersion(context.Background(), "agent-1", "^4")
+	var nf *NotFoundError
+	require.True(t, errors.As(err, &nf))
+	assert.Equal(t, CodeVersionNotFound, ErrorCode(err))
+
+	_, err = client.GetLatestCompatibleVersion(context.Background(), "agent-1", ">=one")
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+}
@@ -0,0 +1,55 @@
+package a2areg
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetAgentVisibility changes only the visibility of an agent. Registries
+// without visibility support can only make an agent public or private;
+// asking them for VisibilityUnlisted fails with CodeUnsupportedFeature
+// rather than silently hiding the agent.
+func (c *A2ARegClient) SetAgentVisibility(ctx context.Context, agentID string, visibility Visibility) error {
+	if err := checkVisibility(visibility); err != nil {
+		return err
+	}
+
+	update := map[string]interface{}{"is_public": visibility == VisibilityPublic}
+	if c.supports(ctx, FeatureVisibility) {
+		update["visibility"] = visibility
+	} else if visibility == VisibilityUnlisted {
+		return withCode(NewA2AError("Registry does not support unlisted agents", map[string]interface{}{"feature": FeatureVisibility}), CodeUnsupportedFeature)
+	}
+
+	_, err := c.sendMutation(ctx, "PUT", "/agents/"+agentID, agentID, update)
+	c.invalidateAgent(agentID)
+	return agentNotFound(err)
+}
+
+// checkVisibility rejects visibilities other than the three known ones.
+func checkVisibility(visibility Visibility) error {
+	if !visibility.valid() {
+		return NewValidationError(fmt.Sprintf("Unknown agent visibility %q", visibility), map[string]interface{}{"visibility": string(visibility)})
+	}
+	return nil
+}
+
+// wireVisibility prepares agent for sending: registries that support
+// visibility get the field, older ones only is_public, derived from it. An
+// agent without Visibility set is sent unchanged, without probing. The
+// returned bool reports that an unlisted agent had to be sent as private.
+func (c *A2ARegClient) wireVisibility(ctx context.Context, agent *Agent) (*Agent, bool, error) {
+	if agent.Visibility == "" {
+		return agent, false, nil
+	}
+	if err := checkVisibility(agent.Visibility); err != nil {
+		return nil, false, err
+	}
+	if c.supports(ctx, FeatureVisibility) {
+		return agent, false, nil
+	}
+	legacy := *agent
+	legacy.IsPublic = agent.Visibility == VisibilityPublic
+	legacy.Visibility = ""
+	return &legacy, agent.Visibility == VisibilityUnlisted, nil
+}
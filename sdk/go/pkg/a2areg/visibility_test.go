@@ -0,0 +1,184 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func visibilityRegistry(t *testing.T, newStyle bool) (*fakeregistry.Registry, *A2ARegClient) {
+	registry := fakeregistry.New()
+	registry.Visibility = newStyle
+	server := registry.Start()
+	t.Cleanup(server.Close)
+	return registry, NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+}
+
+func listedIDs(t *testing.T, client *A2ARegClient) []string {
+	summaries, err := client.ListSummaries(1, 50, false)
+	require.NoError(t, err)
+	ids := []string{}
+	for _, summary := range summaries {
+		ids = append(ids, summary.ID)
+	}
+	return ids
+}
+
+func TestVisibility_Publish(t *testing.T) {
+	tests := []struct {
+		visibility Visibility
+		newStyle   bool
+		stored     Visibility
+		listed     bool
+		warning    bool
+	}{
+		{VisibilityPublic, true, VisibilityPublic, true, false},
+		{VisibilityUnlisted, true, VisibilityUnlisted, false, false},
+		{VisibilityPrivate, true, VisibilityPrivate, true, false},
+		{VisibilityPublic, false, VisibilityPublic, true, false},
+		{VisibilityUnlisted, false, VisibilityPrivate, true, true},
+		{VisibilityPrivate, false, VisibilityPrivate, true, false},
+	}
+
+	for _, tt := range tests {
+		name := string(tt.visibility) + "/old"
+		if tt.newStyle {
+			name = string(tt.visibility) + "/new"
+		}
+		t.Run(name, func(t *testing.T) {
+			registry, client := visibilityRegistry(t, tt.newStyle)
+			agent := testPublishAgent()
+			agent.Visibility = tt.visibility
+
+			receipt, err := client.PublishAgentVerbose(context.Background(), agent, PublishOptions{SkipNormalizationCheck: true})
+			require.NoError(t, err)
+			id := *receipt.Agent.ID
+			assert.Equal(t, tt.stored, receipt.Agent.Visibility)
+			assert.Equal(t, tt.stored == VisibilityPublic, receipt.Agent.IsPublic)
+			if tt.warning {
+				assert.Contains(t, receipt.Warnings, "Registry does not support unlisted agents; published as private")
+			} else {
+				assert.Empty(t, receipt.Warnings)
+			}
+
+			fetched, err := client.GetAgent(id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.stored, fetched.Visibility)
+			if tt.listed {
+				assert.Contains(t, listedIDs(t, client), id)
+			} else {
+				assert.NotContains(t, listedIDs(t, client), id)
+			}
+			assert.Equal(t, 1, registry.Calls("GET /health"))
+		})
+	}
+}
+
+func TestVisibility_SetAgentVisibility(t *testing.T) {
+	for _, newStyle := range []bool{true, false} {
+		registry, client := visibilityRegistry(t, newStyle)
+		id := registry.Put("", false, map[string]interface{}{"name": "Recipe Agent"})
+		ctx := context.Background()
+
+		require.NoError(t, client.SetAgentVisibility(ctx, id, VisibilityPublic))
+		agent, err := client.GetAgent(id)
+		require.NoError(t, err)
+		assert.Equal(t, VisibilityPublic, agent.Visibility)
+
+		err = client.SetAgentVisibility(ctx, id, VisibilityUnlisted)
+		if newStyle {
+			require.NoError(t, err)
+			agent, _ = client.GetAgent(id)
+			assert.Equal(t, VisibilityUnlisted, agent.Visibility)
+			assert.NotContains(t, listedIDs(t, client), id)
+		} else {
+			assert.Equal(t, CodeUnsupportedFeature, ErrorCode(err))
+		}
+
+		require.NoError(t, client.SetAgentVisibility(ctx, id, VisibilityPrivate))
+		agent, _ = client.GetAgent(id)
+		assert.Equal(t, VisibilityPrivate, agent.Visibility)
+		assert.False(t, agent.IsPublic)
+	}
+
+	_, client := visibilityRegistry(t, true)
+	var validationErr *ValidationError
+	assert.ErrorAs(t, client.SetAgentVisibility(context.Background(), "agent-1", "hidden"), &validationErr)
+	assert.ErrorAs(t, client.SetAgentVisibility(context.Background(), "agent-1", ""), &validationErr)
+}
+
+func TestVisibility_UpdateAgent(t *testing.T) {
+	registry, client := visibilityRegistry(t, true)
+	id := registry.Put("", true, map[string]interface{}{"name": "Recipe Agent"})
+
+	agent, err := client.GetAgent(id)
+	require.NoError(t, err)
+	agent.Visibility = VisibilityUnlisted
+	updated, err := client.UpdateAgent(id, agent)
+	require.NoError(t, err)
+	assert.Equal(t, VisibilityUnlisted, updated.Visibility)
+
+	agent.Visibility = "hidden"
+	_, err = client.UpdateAgent(id, agent)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+}
+
+func TestVisibility_Decode(t *testing.T) {
+	tests := []struct {
+		doc        string
+		visibility Visibility
+		public     bool
+	}{
+		{`{"is_public": true}`, VisibilityPublic, true},
+		{`{"is_public": false}`, VisibilityPrivate, false},
+		{`{"visibility": "unlisted"}`, VisibilityUnlisted, false},
+		{`{"visibility": "public"}`, VisibilityPublic, true},
+		{`{"visibility": "private", "is_public": true}`, VisibilityPrivate, false},
+		{`{}`, "", false},
+	}
+	for _, tt := range tests {
+		var agent Agent
+		require.NoError(t, json.Unmarshal([]byte(tt.doc), &agent), tt.doc)
+		assert.Equal(t, tt.visibility, agent.Visibility, tt.doc)
+		assert.Equal(t, tt.public, agent.IsPublic, tt.doc)
+	}
+}
+
+func TestVisibility_Encode(t *testing.T) {
+	data, err := json.Marshal(Agent{Visibility: VisibilityPublic})
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, true, doc["is_public"])
+	assert.Equal(t, "public", doc["visibility"])
+
+	data, err = json.Marshal(Agent{IsPublic: true})
+	require.NoError(t, err)
+	doc = nil
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, true, doc["is_public"])
+	assert.NotContains(t, doc, "visibility")
+}
+
+func TestServerCapabilities(t *testing.T) {
+	registry, client := visibilityRegistry(t, true)
+	caps, err := client.ServerCapabilities(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "fake", caps.Version)
+	assert.True(t, caps.Supports(FeatureVisibility))
+
+	_, err = client.ServerCapabilities(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.Calls("GET /health"))
+
+	_, client = visibilityRegistry(t, false)
+	caps, err = client.ServerCapabilities(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, caps.Features)
+	assert.False(t, caps.Supports(FeatureVisibility))
+}
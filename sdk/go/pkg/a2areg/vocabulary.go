@@ -0,0 +1,137 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Transport is the protocol an agent interface is served over.
+type Transport string
+
+const (
+	TransportJSONRPC Transport = "jsonrpc"
+	TransportGRPC    Transport = "grpc"
+	TransportHTTP    Transport = "http"
+)
+
+// IsValid reports whether t is one of the known transports.
+func (t Transport) IsValid() bool {
+	switch t {
+	case TransportJSONRPC, TransportGRPC, TransportHTTP:
+		return true
+	}
+	return false
+}
+
+// UnmarshalJSON accepts case variants and the ADK names of the known
+// transports ("JSONRPC", "HTTP+JSON") and normalizes them. Unknown
+// transports are kept as is, for validation to reject.
+func (t *Transport) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*t = normalizeTransport(s)
+	return nil
+}
+
+// normalizeTransport maps case variants and ADK names of known transports
+// onto their canonical form.
+func normalizeTransport(s string) Transport {
+	if t, ok := adkTransports[strings.ToUpper(s)]; ok {
+		return t
+	}
+	if t := Transport(strings.ToLower(s)); t.IsValid() {
+		return t
+	}
+	return Transport(s)
+}
+
+// AuthSchemeType is the kind of a security scheme.
+type AuthSchemeType string
+
+const (
+	AuthSchemeAPIKey AuthSchemeType = "apiKey"
+	AuthSchemeOAuth2 AuthSchemeType = "oauth2"
+	AuthSchemeJWT    AuthSchemeType = "jwt"
+	AuthSchemeMTLS   AuthSchemeType = "mTLS"
+	AuthSchemeBearer AuthSchemeType = "bearer"
+)
+
+// authSchemeTypes lists the known scheme types; ValidateAgent accepts
+// exactly these.
+var authSchemeTypes = []AuthSchemeType{AuthSchemeAPIKey, AuthSchemeOAuth2, AuthSchemeJWT, AuthSchemeMTLS, AuthSchemeBearer}
+
+// IsValid reports whether t is one of the known scheme types.
+func (t AuthSchemeType) IsValid() bool {
+	for _, known := range authSchemeTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAPIKeyScheme returns an API key scheme taking the key in the header
+// headerName.
+func NewAPIKeyScheme(headerName string) SecurityScheme {
+	location := LocationHeader
+	return SecurityScheme{Type: AuthSchemeAPIKey, Location: &location, Name: &headerName}
+}
+
+// NewOAuth2Scheme returns an OAuth2 client credentials scheme with the
+// given token endpoint and scopes.
+func NewOAuth2Scheme(tokenURL string, scopes ...string) SecurityScheme {
+	flow := "client_credentials"
+	return SecurityScheme{Type: AuthSchemeOAuth2, Flow: &flow, TokenURL: &tokenURL, Scopes: scopes}
+}
+
+// Location is where a security scheme expects its credentials.
+type Location string
+
+const (
+	LocationHeader Location = "header"
+	LocationQuery  Location = "query"
+	LocationBody   Location = "body"
+)
+
+// IsValid reports whether l is one of the known locations.
+func (l Location) IsValid() bool {
+	switch l {
+	case LocationHeader, LocationQuery, LocationBody:
+		return true
+	}
+	return false
+}
+
+// UnmarshalJSON accepts case variants of the known locations and
+// normalizes them. Unknown locations are kept as is.
+func (l *Location) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*l = normalizeLocation(s)
+	return nil
+}
+
+// normalizeLocation maps case variants of known locations onto their
+// canonical form.
+func normalizeLocation(s string) Location {
+	if l := Location(strings.ToLower(s)); l.IsValid() {
+		return l
+	}
+	return Location(s)
+}
+
+// Common input and output modes. Modes are MIME types, so any other
+// type/subtype is valid too.
+const (
+	ModeText     = "text/plain"
+	ModeMarkdown = "text/markdown"
+	ModeHTML     = "text/html"
+	ModeJSON     = "application/json"
+	ModePDF      = "application/pdf"
+	ModePNG      = "image/png"
+	ModeJPEG     = "image/jpeg"
+)
@@ -0,0 +1,95 @@
+package a2areg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_Normalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Transport
+	}{
+		{"jsonrpc", TransportJSONRPC},
+		{"JSONRPC", TransportJSONRPC},
+		{"JsonRpc", TransportJSONRPC},
+		{"GRPC", TransportGRPC},
+		{"HTTP", TransportHTTP},
+		{"HTTP+JSON", TransportHTTP},
+		{"WebSocket", "WebSocket"},
+	}
+	for _, tt := range tests {
+		var entry AgentInterfaceEntry
+		require.NoError(t, json.Unmarshal([]byte(`{"transport": "`+tt.in+`", "url": "https://a.example.com"}`), &entry))
+		assert.Equal(t, tt.want, entry.Transport, tt.in)
+	}
+
+	var agent Agent
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "Recipe Agent", "preferred_transport": "GRPC", "agent_card": {"interface": {"preferredTransport": "JSONRPC"}}}`), &agent))
+	assert.Equal(t, TransportGRPC, agent.PreferredTransport)
+	assert.Equal(t, TransportJSONRPC, agent.AgentCard.Interface.PreferredTransport)
+}
+
+func TestLocation_Normalize(t *testing.T) {
+	var scheme SecurityScheme
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "apiKey", "location": "Header"}`), &scheme))
+	require.NotNil(t, scheme.Location)
+	assert.Equal(t, LocationHeader, *scheme.Location)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "apiKey", "location": "cookie"}`), &scheme))
+	assert.Equal(t, Location("cookie"), *scheme.Location)
+	assert.False(t, scheme.Location.IsValid())
+}
+
+func TestValidateAgent_Transports(t *testing.T) {
+	client := NewA2ARegClient(A2ARegClientOptions{})
+	permissive := NewA2ARegClient(A2ARegClientOptions{AllowUnknownTransports: true})
+
+	agent := testPublishAgent()
+	agent.PreferredTransport = "websocket"
+	assert.ErrorContains(t, client.ValidateAgent(agent), "invalid preferred transport: websocket")
+	assert.NoError(t, permissive.ValidateAgent(agent))
+
+	agent = testPublishAgent()
+	agent.Interfaces = []AgentInterfaceEntry{{Transport: "", URL: "https://a.example.com"}}
+	assert.ErrorContains(t, client.ValidateAgent(agent), "Interface 0 has invalid transport")
+	assert.ErrorContains(t, permissive.ValidateAgent(agent), "Interface 0 has invalid transport")
+
+	agent = testPublishAgent()
+	location := Location("cookie")
+	agent.AuthSchemes = []SecurityScheme{{Type: "apiKey", Location: &location}}
+	assert.ErrorContains(t, client.ValidateAgent(agent), "Auth scheme 0 has invalid location: cookie")
+
+	card := testAgentCard()
+	card.Interface.PreferredTransport = "websocket"
+	assert.ErrorContains(t, client.ValidateAgentCard(card), "invalid preferred transport")
+	assert.NoError(t, permissive.ValidateAgentCard(card))
+}
+
+func TestAuthSchemeType_IsValid(t *testing.T) {
+	for _, typ := range []AuthSchemeType{AuthSchemeAPIKey, AuthSchemeOAuth2, AuthSchemeJWT, AuthSchemeMTLS, AuthSchemeBearer} {
+		assert.True(t, typ.IsValid(), typ)
+	}
+	assert.False(t, AuthSchemeType("apikey").IsValid())
+	assert.False(t, AuthSchemeType("").IsValid())
+
+	client := NewA2ARegClient(A2ARegClientOptions{})
+	agent := testPublishAgent()
+	agent.AuthSchemes = []SecurityScheme{NewAPIKeyScheme("X-API-Key"), NewOAuth2Scheme("https://auth.example.com/token", "read", "write")}
+	require.NoError(t, client.ValidateAgent(agent))
+	agent.AuthSchemes[0].Type = "apikey"
+	assert.Error(t, client.ValidateAgent(agent))
+}
+
+func TestSchemeConstructors(t *testing.T) {
+	data, err := json.Marshal(NewAPIKeyScheme("X-API-Key"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "apiKey", "location": "header", "name": "X-API-Key"}`, string(data))
+
+	data, err = json.Marshal(NewOAuth2Scheme("https://auth.example.com/token", "read"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "oauth2", "flow": "client_credentials", "tokenUrl": "https://auth.example.com/token", "scopes": ["read"]}`, string(data))
+}
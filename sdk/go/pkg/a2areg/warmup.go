@@ -0,0 +1,115 @@
+package a2areg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// warmupState makes Warmup single-flight: concurrent callers share one run,
+// and once a run succeeds later calls return immediately.
+type warmupState struct {
+	mu       sync.Mutex
+	done     bool
+	inflight chan struct{}
+	err      error
+}
+
+// Warmup prepares the client so that the next call does not pay for
+// connection setup: it opens a connection to the registry (with a HEAD
+// /health) while it fetches an access token for OAuth clients and then
+// probes the ServerCapabilities. A failed capability probe does not fail
+// the warmup; it is retried when the capabilities are next needed.
+// It is safe to call repeatedly and from several goroutines; only one
+// warmup runs at a time, and after one succeeds Warmup returns nil without
+// doing anything. A failed warmup is retried by the next call.
+func (c *A2ARegClient) Warmup(ctx context.Context) error {
+	w := &c.warmup
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		return nil
+	}
+	if w.inflight == nil {
+		w.inflight = make(chan struct{})
+		go c.runWarmup(w.inflight)
+	}
+	inflight := w.inflight
+	w.mu.Unlock()
+
+	select {
+	case <-inflight:
+	case <-ctx.Done():
+		return withCode(NewA2AError("Warmup interrupted", map[string]interface{}{"error": ctx.Err().Error()}), CodeRequestFailed)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// runWarmup performs one warmup and publishes its result. It runs detached
+// from any caller's context, bounded by the client timeout, so that one
+// caller giving up does not fail the warmup for the others.
+func (c *A2ARegClient) runWarmup(inflight chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var connErr, authErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		connErr = c.preconnect(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		// The probe is an authenticated request, so it follows the token
+		// fetch rather than racing it for a second token.
+		if authErr = c.ensureAuthenticated(ctx); authErr != nil {
+			return
+		}
+		if _, err := c.ServerCapabilities(ctx); err != nil {
+			c.logger.Debug("a2areg: capability probe during warmup failed", "error", err)
+		}
+	}()
+	wg.Wait()
+
+	err := connErr
+	if err == nil {
+		err = authErr
+	}
+
+	w := &c.warmup
+	w.mu.Lock()
+	w.err = err
+	w.done = err == nil
+	w.inflight = nil
+	w.mu.Unlock()
+	close(inflight)
+}
+
+// preconnect opens a pooled connection to the registry with an
+// unauthenticated HEAD /health. Any HTTP response counts as success.
+func (c *A2ARegClient) preconnect(ctx context.Context) error {
+	healthURL, err := NormalizeEndpoint(c.registryURL, "/health")
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "HEAD", healthURL, nil)
+	if err != nil {
+		return withCode(NewA2AError("Failed to create request", map[string]interface{}{"error": err.Error()}), CodeInvalidRequest)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if pinErr := asPinMismatch(err); pinErr != nil {
+			return pinErr
+		}
+		return withCode(NewA2AError("Request failed", map[string]interface{}{"error": err.Error()}), CodeRequestFailed)
+	}
+	// Drain so the connection returns to the pool.
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
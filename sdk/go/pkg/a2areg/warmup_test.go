@@ -0,0 +1,153 @@
+package a2areg
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"a2areg/internal/fakeregistry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type warmupRegistry struct {
+	*httptest.Server
+	connections   atomic.Int32
+	healthHeads   atomic.Int32
+	tokenRequests atomic.Int32
+	fail          atomic.Bool
+}
+
+func newWarmupRegistry(t *testing.T) *warmupRegistry {
+	r := &warmupRegistry{}
+	r.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == "HEAD" && req.URL.Path == "/health":
+			r.healthHeads.Add(1)
+			// Give concurrent Warmup calls time to pile up.
+			time.Sleep(20 * time.Millisecond)
+		case req.URL.Path == "/auth/oauth/token":
+			r.tokenRequests.Add(1)
+			if r.fail.Load() {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "expires_in": 3600})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"agents": []interface{}{}})
+		}
+	}))
+	r.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			r.connections.Add(1)
+		}
+	}
+	r.Start()
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestA2ARegClient_Warmup(t *testing.T) {
+	registry := newWarmupRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, ClientID: "id", ClientSecret: "secret"})
+
+	require.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, int32(1), registry.healthHeads.Load())
+	assert.Equal(t, int32(1), registry.tokenRequests.Load())
+	connections := registry.connections.Load()
+
+	_, err := client.ListAgentsPage(context.Background(), 1, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), registry.tokenRequests.Load(), "no token request after warmup")
+	assert.Equal(t, connections, registry.connections.Load(), "warm connection is reused")
+
+	// Later calls are no-ops.
+	require.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, int32(1), registry.healthHeads.Load())
+}
+
+func TestA2ARegClient_Warmup_Concurrent(t *testing.T) {
+	registry := newWarmupRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, ClientID: "id", ClientSecret: "secret"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.Warmup(context.Background()))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), registry.healthHeads.Load())
+	assert.Equal(t, int32(1), registry.tokenRequests.Load())
+}
+
+func TestA2ARegClient_Warmup_RetriesAfterFailure(t *testing.T) {
+	registry := newWarmupRegistry(t)
+	registry.fail.Store(true)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, ClientID: "id", ClientSecret: "secret"})
+
+	err := client.Warmup(context.Background())
+	assert.Equal(t, CodeAuthInvalidClient, ErrorCode(err))
+
+	registry.fail.Store(false)
+	require.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, int32(2), registry.healthHeads.Load())
+}
+
+func TestA2ARegClient_WarmupOnStart(t *testing.T) {
+	registry := newWarmupRegistry(t)
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: registry.URL, APIKey: "key", WarmupOnStart: true})
+
+	assert.Eventually(t, func() bool { return registry.healthHeads.Load() == 1 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, int32(0), registry.tokenRequests.Load(), "API key clients need no token")
+}
+
+func TestA2ARegClient_Warmup_ProbesCapabilities(t *testing.T) {
+	registry := fakeregistry.New()
+	registry.DisableSearch = true
+	registry.FeatureMap = true
+	server := registry.Start()
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test", SearchFallback: true})
+
+	require.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, 1, registry.Calls("GET /health"))
+
+	_, err := client.ServerCapabilities(context.Background())
+	require.NoError(t, err)
+	_, err = client.Search(context.Background(), SearchRequest{Query: "weather", Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, registry.Calls("GET /health"), "warmup probe is cached")
+	assert.Equal(t, 0, registry.Calls("POST /agents/search"), "warmup probe drives the fallback")
+}
+
+func TestA2ARegClient_Warmup_ProbeFailureIsNotFatal(t *testing.T) {
+	var probes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "GET" && req.URL.Path == "/health" {
+			probes.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewA2ARegClient(A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+
+	require.NoError(t, client.Warmup(context.Background()))
+	assert.Equal(t, int32(1), probes.Load())
+
+	// The failed probe is not cached.
+	_, err := client.ServerCapabilities(context.Background())
+	assert.Equal(t, CodeNotFound, ErrorCode(err))
+	assert.Equal(t, int32(2), probes.Load())
+}
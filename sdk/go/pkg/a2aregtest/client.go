@@ -0,0 +1,529 @@
+package a2aregtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"a2areg/pkg/a2areg"
+)
+
+// FakeClient is an in-memory a2areg.RegistryClient for unit tests of code
+// that depends on the registry but should not need an HTTP server. It
+// stores copies of the agents it is given, assigns IDs like the fake
+// registry ("agent-1", "agent-2", ...), and answers listings in ID order.
+// Request options are accepted and ignored.
+//
+// A FakeClient is safe for concurrent use.
+type FakeClient struct {
+	mu      sync.Mutex
+	agents  map[string]*a2areg.Agent
+	apiKeys map[string]a2areg.ResponseMap
+	errs    map[string]error
+	calls   map[string]int
+	nextID  int
+	nextKey int
+}
+
+var _ a2areg.RegistryClient = (*FakeClient)(nil)
+
+// NewFakeClient returns a FakeClient holding agents. Agents without an ID
+// are given one.
+func NewFakeClient(agents ...*a2areg.Agent) *FakeClient {
+	f := &FakeClient{
+		agents:  make(map[string]*a2areg.Agent),
+		apiKeys: make(map[string]a2areg.ResponseMap),
+		errs:    make(map[string]error),
+		calls:   make(map[string]int),
+	}
+	for _, agent := range agents {
+		f.storeLocked(agent)
+	}
+	return f
+}
+
+// FailWith makes every later call to the named method ("GetAgent",
+// "PublishAgent", ...) return err. A nil err clears it.
+func (f *FakeClient) FailWith(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// Calls returns how many times the named method was called.
+func (f *FakeClient) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// Agents returns copies of the stored agents, ordered by ID.
+func (f *FakeClient) Agents() []*a2areg.Agent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var agents []*a2areg.Agent
+	for _, agent := range f.sortedLocked() {
+		agents = append(agents, copyAgent(agent))
+	}
+	return agents
+}
+
+// Health reports a healthy registry with no dependencies.
+func (f *FakeClient) Health(ctx context.Context, opts ...a2areg.RequestOption) (*a2areg.HealthStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("Health"); err != nil {
+		return nil, err
+	}
+	return &a2areg.HealthStatus{Status: "healthy", Version: "fake"}, nil
+}
+
+// Ready reports a ready registry.
+func (f *FakeClient) Ready(ctx context.Context, opts ...a2areg.RequestOption) (bool, error) {
+	health, err := f.Health(ctx)
+	if err != nil {
+		return false, err
+	}
+	return health.Healthy(), nil
+}
+
+// GetRegistryStats reports agent counts.
+func (f *FakeClient) GetRegistryStats(opts ...a2areg.RequestOption) (*a2areg.RegistryStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("GetRegistryStats"); err != nil {
+		return nil, err
+	}
+	public := 0
+	for _, agent := range f.agents {
+		if agent.EffectiveVisibility() == a2areg.VisibilityPublic {
+			public++
+		}
+	}
+	return &a2areg.RegistryStats{
+		TotalAgents:   int64(len(f.agents)),
+		PublicAgents:  int64(public),
+		PrivateAgents: int64(len(f.agents) - public),
+	}, nil
+}
+
+// ListAgentsPage returns a page of the listing, public agents only with
+// publicOnly.
+func (f *FakeClient) ListAgentsPage(ctx context.Context, page, limit int, publicOnly bool, opts ...a2areg.RequestOption) (*a2areg.AgentList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("ListAgentsPage"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.agentPageLocked(page, limit, publicOnly), nil
+}
+
+func (f *FakeClient) agentPageLocked(page, limit int, publicOnly bool) *a2areg.AgentList {
+	all := f.listedLocked(publicOnly)
+	agents, page, limit := paginate(all, page, limit)
+	list := &a2areg.AgentList{Agents: make([]a2areg.Agent, 0, len(agents)), Total: len(all), Page: page, Limit: limit}
+	for _, agent := range agents {
+		list.Agents = append(list.Agents, *copyAgent(agent))
+	}
+	return list
+}
+
+// ListSummaries returns a page of agent summaries.
+func (f *FakeClient) ListSummaries(page, limit int, publicOnly bool, opts ...a2areg.RequestOption) ([]a2areg.AgentSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("ListSummaries"); err != nil {
+		return nil, err
+	}
+	agents, _, _ := paginate(f.listedLocked(publicOnly), page, limit)
+	summaries := make([]a2areg.AgentSummary, 0, len(agents))
+	for _, agent := range agents {
+		summaries = append(summaries, agent.Summary())
+	}
+	return summaries, nil
+}
+
+// GetAgent returns a copy of the agent with agentID.
+func (f *FakeClient) GetAgent(agentID string, opts ...a2areg.RequestOption) (*a2areg.Agent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("GetAgent"); err != nil {
+		return nil, err
+	}
+	agent, ok := f.agents[agentID]
+	if !ok {
+		return nil, agentNotFound(agentID)
+	}
+	return copyAgent(agent), nil
+}
+
+// GetAgentCard converts the stored agent with a2areg.ConvertAgentToCard.
+func (f *FakeClient) GetAgentCard(agentID string, opts ...a2areg.RequestOption) (*a2areg.AgentCardSpec, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("GetAgentCard"); err != nil {
+		return nil, err
+	}
+	agent, ok := f.agents[agentID]
+	if !ok {
+		return nil, agentNotFound(agentID)
+	}
+	card, _, err := a2areg.ConvertAgentToCard(agent)
+	return card, err
+}
+
+// Search matches the query case-insensitively against agent names and
+// descriptions and applies the tag, provider and region filters.
+// Capability and skill filters are ignored, and Semantic makes no
+// difference.
+func (f *FakeClient) Search(ctx context.Context, req a2areg.SearchRequest, opts ...a2areg.RequestOption) (*a2areg.SearchResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("Search"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.searchLocked(req), nil
+}
+
+// PublishAgent stores a copy of agent and returns it with its ID. With
+// validate, the agent must pass ValidateAgent first.
+func (f *FakeClient) PublishAgent(agent *a2areg.Agent, validate bool, opts ...a2areg.RequestOption) (*a2areg.Agent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("PublishAgent"); err != nil {
+		return nil, err
+	}
+	if validate {
+		if err := validateAgent(agent); err != nil {
+			return nil, err
+		}
+	}
+	return copyAgent(f.storeLocked(agent)), nil
+}
+
+// UpdateAgent replaces the stored agent with a copy of agent.
+func (f *FakeClient) UpdateAgent(agentID string, agent *a2areg.Agent, opts ...a2areg.RequestOption) (*a2areg.Agent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("UpdateAgent"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.agents[agentID]; !ok {
+		return nil, agentNotFound(agentID)
+	}
+	updated := copyAgent(agent)
+	updated.ID = &agentID
+	f.agents[agentID] = updated
+	return copyAgent(updated), nil
+}
+
+// DeleteAgent removes the agent with agentID.
+func (f *FakeClient) DeleteAgent(agentID string, opts ...a2areg.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("DeleteAgent"); err != nil {
+		return err
+	}
+	if _, ok := f.agents[agentID]; !ok {
+		return agentNotFound(agentID)
+	}
+	delete(f.agents, agentID)
+	return nil
+}
+
+// ValidateAgent checks the fields the registry requires.
+func (f *FakeClient) ValidateAgent(agent *a2areg.Agent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("ValidateAgent"); err != nil {
+		return err
+	}
+	return validateAgent(agent)
+}
+
+// GenerateAPIKey creates a key with scopes. expiresDays is recorded but
+// keys never expire.
+func (f *FakeClient) GenerateAPIKey(scopes []string, expiresDays *int) (string, a2areg.ResponseMap, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("GenerateAPIKey"); err != nil {
+		return "", nil, err
+	}
+	f.nextKey++
+	apiKey := fmt.Sprintf("a2a_fake_%d", f.nextKey)
+	info := a2areg.ResponseMap{
+		"key_id":    fmt.Sprintf("key-%d", f.nextKey),
+		"scopes":    append([]string(nil), scopes...),
+		"is_active": true,
+	}
+	if expiresDays != nil {
+		info["expires_days"] = json.Number(fmt.Sprint(*expiresDays))
+	}
+	f.apiKeys[apiKey] = info
+	return apiKey, copyMap(info), nil
+}
+
+// ValidateAPIKey returns the key's info, or nil for an unknown or revoked
+// key or one missing a required scope.
+func (f *FakeClient) ValidateAPIKey(apiKey string, requiredScopes []string) (a2areg.ResponseMap, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("ValidateAPIKey"); err != nil {
+		return nil, err
+	}
+	info, ok := f.apiKeys[apiKey]
+	if !ok || info["is_active"] != true {
+		return nil, nil
+	}
+	granted := info["scopes"].([]string)
+	for _, scope := range requiredScopes {
+		if !contains(granted, scope) {
+			return nil, nil
+		}
+	}
+	return copyMap(info), nil
+}
+
+// RevokeAPIKey deactivates the key with keyID, reporting whether it
+// existed.
+func (f *FakeClient) RevokeAPIKey(keyID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("RevokeAPIKey"); err != nil {
+		return false, err
+	}
+	for _, info := range f.apiKeys {
+		if info["key_id"] == keyID {
+			info["is_active"] = false
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListAPIKeys returns the generated keys' info, ordered by key ID.
+func (f *FakeClient) ListAPIKeys(activeOnly bool) ([]a2areg.ResponseMap, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("ListAPIKeys"); err != nil {
+		return nil, err
+	}
+	keys := []a2areg.ResponseMap{}
+	for _, info := range f.apiKeys {
+		if !activeOnly || info["is_active"] == true {
+			keys = append(keys, copyMap(info))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i]["key_id"].(string) < keys[j]["key_id"].(string)
+	})
+	return keys, nil
+}
+
+// enterLocked counts a call to method and returns the error set for it
+// with FailWith.
+func (f *FakeClient) enterLocked(method string) error {
+	f.calls[method]++
+	return f.errs[method]
+}
+
+// storeLocked stores a copy of agent, assigning an ID if it has none.
+func (f *FakeClient) storeLocked(agent *a2areg.Agent) *a2areg.Agent {
+	stored := copyAgent(agent)
+	if stored.ID == nil || *stored.ID == "" {
+		id := f.newIDLocked()
+		stored.ID = &id
+	}
+	f.agents[*stored.ID] = stored
+	return stored
+}
+
+func (f *FakeClient) newIDLocked() string {
+	for {
+		f.nextID++
+		id := fmt.Sprintf("agent-%d", f.nextID)
+		if _, taken := f.agents[id]; !taken {
+			return id
+		}
+	}
+}
+
+// sortedLocked returns the stored agents ordered by ID.
+func (f *FakeClient) sortedLocked() []*a2areg.Agent {
+	agents := make([]*a2areg.Agent, 0, len(f.agents))
+	for _, agent := range f.agents {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool { return *agents[i].ID < *agents[j].ID })
+	return agents
+}
+
+// listedLocked returns the agents that appear in listings and search:
+// all but unlisted ones, or only public ones with publicOnly.
+func (f *FakeClient) listedLocked(publicOnly bool) []*a2areg.Agent {
+	var agents []*a2areg.Agent
+	for _, agent := range f.sortedLocked() {
+		switch agent.EffectiveVisibility() {
+		case a2areg.VisibilityPublic:
+			agents = append(agents, agent)
+		case a2areg.VisibilityPrivate:
+			if !publicOnly {
+				agents = append(agents, agent)
+			}
+		}
+	}
+	return agents
+}
+
+func (f *FakeClient) searchLocked(req a2areg.SearchRequest) *a2areg.SearchResponse {
+	query := strings.ToLower(req.Query)
+	var matched []*a2areg.Agent
+	for _, agent := range f.listedLocked(false) {
+		if !strings.Contains(strings.ToLower(agent.Name+" "+agent.Description), query) {
+			continue
+		}
+		if req.Filters != nil {
+			if req.Filters.Provider != "" && !strings.EqualFold(agent.ProviderName(), req.Filters.Provider) {
+				continue
+			}
+			if req.Filters.Region != "" && !strings.EqualFold(agent.Region, req.Filters.Region) {
+				continue
+			}
+			if !containsAll(agent.Tags, req.Filters.Tags) {
+				continue
+			}
+		}
+		matched = append(matched, agent)
+	}
+
+	page, pageNumber, limit := paginate(matched, req.Page, req.Limit)
+	resp := &a2areg.SearchResponse{Agents: make([]a2areg.Agent, 0, len(page)), Total: len(matched), Page: pageNumber, Limit: limit}
+	for _, agent := range page {
+		resp.Agents = append(resp.Agents, *copyAgent(agent))
+	}
+	return resp
+}
+
+// paginate returns the page of agents the registry would, defaulting page
+// to 1 and limit to 20.
+func paginate(agents []*a2areg.Agent, page, limit int) ([]*a2areg.Agent, int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	start := min((page-1)*limit, len(agents))
+	end := min(start+limit, len(agents))
+	return agents[start:end], page, limit
+}
+
+func validateAgent(agent *a2areg.Agent) error {
+	switch {
+	case agent == nil:
+		return a2areg.NewValidationError("Agent is required", nil)
+	case agent.Name == "":
+		return a2areg.NewValidationError("Agent name is required", nil)
+	case agent.Description == "":
+		return a2areg.NewValidationError("Agent description is required", nil)
+	case agent.Version == "":
+		return a2areg.NewValidationError("Agent version is required", nil)
+	case agent.ProviderName() == "":
+		return a2areg.NewValidationError("Agent provider is required", nil)
+	}
+	return nil
+}
+
+// agentNotFound is the error the real client returns for a missing agent.
+func agentNotFound(agentID string) error {
+	err := a2areg.NewNotFoundError("Resource not found", map[string]interface{}{"agent_id": agentID})
+	err.Code = a2areg.CodeAgentNotFound
+	err.StatusCode = http.StatusNotFound
+	return err
+}
+
+// copyAgent returns a copy of agent that shares no slices with it.
+func copyAgent(agent *a2areg.Agent) *a2areg.Agent {
+	copied := *agent
+	if agent.ID != nil {
+		id := *agent.ID
+		copied.ID = &id
+	}
+	if agent.ProviderInfo != nil {
+		provider := *agent.ProviderInfo
+		copied.ProviderInfo = &provider
+	}
+	copied.Tags = append([]string(nil), agent.Tags...)
+	copied.AuthSchemes = append([]a2areg.SecurityScheme(nil), agent.AuthSchemes...)
+	copied.Skills = append([]a2areg.AgentSkill(nil), agent.Skills...)
+	copied.Interfaces = append([]a2areg.AgentInterfaceEntry(nil), agent.Interfaces...)
+	copied.Changelog = append([]a2areg.ChangelogEntry(nil), agent.Changelog...)
+	if agent.Labels != nil {
+		copied.Labels = make(map[string]string, len(agent.Labels))
+		for k, v := range agent.Labels {
+			copied.Labels[k] = v
+		}
+	}
+	return &copied
+}
+
+func copyMap(m a2areg.ResponseMap) a2areg.ResponseMap {
+	copied := make(a2areg.ResponseMap, len(m))
+	for k, v := range m {
+		if scopes, ok := v.([]string); ok {
+			v = append([]string(nil), scopes...)
+		}
+		copied[k] = v
+	}
+	return copied
+}
+
+// roundTrip encodes v and decodes it into a map, so callers see the same
+// types they would get from a real response.
+func roundTrip(v interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := roundTripInto(v, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func roundTripInto(v, into interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
+
+func contains(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(values, want []string) bool {
+	for _, w := range want {
+		if !contains(values, w) {
+			return false
+		}
+	}
+	return true
+}
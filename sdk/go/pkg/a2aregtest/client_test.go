@@ -0,0 +1,141 @@
+package a2aregtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"a2areg/pkg/a2areg"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAgent(name string, public bool, tags ...string) *a2areg.Agent {
+	return &a2areg.Agent{
+		Name:        name,
+		Description: "An agent for " + name,
+		Version:     "1.0.0",
+		Provider:    "acme",
+		IsPublic:    public,
+		Tags:        tags,
+	}
+}
+
+func TestFakeClient_CRUD(t *testing.T) {
+	var client a2areg.RegistryClient = NewFakeClient(testAgent("Recipes", true))
+
+	published, err := client.PublishAgent(testAgent("Travel", false), true)
+	require.NoError(t, err)
+	assert.Equal(t, "agent-2", *published.ID)
+
+	agent, err := client.GetAgent("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Recipes", agent.Name)
+
+	// Returned agents are copies.
+	agent.Name = "Changed"
+	agent, _ = client.GetAgent("agent-1")
+	assert.Equal(t, "Recipes", agent.Name)
+
+	agent.Version = "2.0.0"
+	updated, err := client.UpdateAgent("agent-1", agent)
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", updated.Version)
+
+	card, err := client.GetAgentCard("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Recipes", card.Name)
+
+	require.NoError(t, client.DeleteAgent("agent-1"))
+	_, err = client.GetAgent("agent-1")
+	var notFound *a2areg.NotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, a2areg.CodeAgentNotFound, a2areg.ErrorCode(err))
+	assert.Equal(t, 404, a2areg.StatusCode(err))
+
+	_, err = client.PublishAgent(&a2areg.Agent{Name: "No description"}, true)
+	var invalid *a2areg.ValidationError
+	assert.ErrorAs(t, err, &invalid)
+}
+
+func TestFakeClient_ListAndSearch(t *testing.T) {
+	client := NewFakeClient(
+		testAgent("Recipe Finder", true, "food"),
+		testAgent("Recipe Planner", false, "food", "planning"),
+		testAgent("Weather", true),
+	)
+
+	listing, err := client.ListAgentsPage(context.Background(), 1, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, listing.Total)
+	assert.Len(t, listing.Agents, 2)
+
+	summaries, err := client.ListSummaries(1, 10, true)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "Weather", summaries[1].Name)
+
+	resp, err := client.Search(context.Background(), a2areg.SearchRequest{
+		Query:   "recipe",
+		Filters: &a2areg.SearchFilters{Tags: []string{"planning"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Agents, 1)
+	assert.Equal(t, "Recipe Planner", resp.Agents[0].Name)
+	assert.Equal(t, 1, resp.Total)
+
+	resp, err = client.Search(context.Background(), a2areg.SearchRequest{
+		Query:   "RECIPE",
+		Filters: &a2areg.SearchFilters{Tags: []string{"food"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Total, "queries are case-insensitive")
+
+	stats, err := client.GetRegistryStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.TotalAgents)
+}
+
+func TestFakeClient_APIKeys(t *testing.T) {
+	client := NewFakeClient()
+
+	apiKey, info, err := client.GenerateAPIKey([]string{"read"}, nil)
+	require.NoError(t, err)
+	keyID, _ := info.GetString("key_id")
+
+	valid, err := client.ValidateAPIKey(apiKey, []string{"read"})
+	require.NoError(t, err)
+	assert.NotNil(t, valid)
+	valid, err = client.ValidateAPIKey(apiKey, []string{"write"})
+	require.NoError(t, err)
+	assert.Nil(t, valid)
+
+	revoked, err := client.RevokeAPIKey(keyID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, _ = client.RevokeAPIKey("missing")
+	assert.False(t, revoked)
+
+	valid, _ = client.ValidateAPIKey(apiKey, nil)
+	assert.Nil(t, valid)
+	active, _ := client.ListAPIKeys(true)
+	assert.Empty(t, active)
+	all, _ := client.ListAPIKeys(false)
+	assert.Len(t, all, 1)
+}
+
+func TestFakeClient_FailWith(t *testing.T) {
+	client := NewFakeClient(testAgent("Recipes", true))
+	boom := errors.New("boom")
+
+	client.FailWith("GetAgent", boom)
+	_, err := client.GetAgent("agent-1")
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, client.Calls("GetAgent"))
+
+	client.FailWith("GetAgent", nil)
+	_, err = client.GetAgent("agent-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.Calls("GetAgent"))
+}
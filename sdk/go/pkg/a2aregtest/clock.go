@@ -0,0 +1,18 @@
+// Package a2aregtest provides test helpers for code that uses the a2areg SDK.
+package a2aregtest
+
+import (
+	"time"
+
+	"a2areg/internal/clock"
+)
+
+// FakeClock is a manually advanced a2areg.Clock. Pass it as
+// A2ARegClientOptions.Clock and call Advance to move time forward; timers
+// and sleeps fire only when the clock passes their deadline.
+type FakeClock = clock.Fake
+
+// NewFakeClock returns a FakeClock starting at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return clock.NewFake(start)
+}
@@ -0,0 +1,43 @@
+//go:build !a2areg_strict
+
+package a2aregtest
+
+import "a2areg/pkg/a2areg"
+
+// GetHealth reports a healthy registry.
+func (f *FakeClient) GetHealth(opts ...a2areg.RequestOption) (a2areg.ResponseMap, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("GetHealth"); err != nil {
+		return nil, err
+	}
+	return a2areg.ResponseMap{"status": "healthy", "version": "fake"}, nil
+}
+
+// ListAgents is ListAgentsPage with the page in the shape the registry
+// returns.
+func (f *FakeClient) ListAgents(page, limit int, publicOnly bool, opts ...a2areg.RequestOption) (map[string]interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("ListAgents"); err != nil {
+		return nil, err
+	}
+	return roundTrip(f.agentPageLocked(page, limit, publicOnly))
+}
+
+// SearchAgents is Search with untyped filters and result.
+func (f *FakeClient) SearchAgents(query string, filters map[string]interface{}, semantic bool, page, limit int, opts ...a2areg.RequestOption) (map[string]interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enterLocked("SearchAgents"); err != nil {
+		return nil, err
+	}
+	req := a2areg.SearchRequest{Query: query, Semantic: semantic, Page: page, Limit: limit}
+	if filters != nil {
+		req.Filters = &a2areg.SearchFilters{}
+		if err := roundTripInto(filters, req.Filters); err != nil {
+			return nil, a2areg.NewValidationError("Invalid search filters", map[string]interface{}{"error": err.Error()})
+		}
+	}
+	return roundTrip(f.searchLocked(req))
+}
@@ -0,0 +1,27 @@
+//go:build !a2areg_strict
+
+package a2aregtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClient_LegacyListAndSearch(t *testing.T) {
+	client := NewFakeClient(
+		testAgent("Recipe Finder", true, "food"),
+		testAgent("Recipe Planner", false, "food", "planning"),
+		testAgent("Weather", true),
+	)
+
+	listing, err := client.ListAgents(1, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), listing["total"])
+	assert.Len(t, listing["agents"], 2)
+
+	results, err := client.SearchAgents("RECIPE", map[string]interface{}{"tags": []string{"food"}}, false, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), results["total"])
+}
@@ -0,0 +1,17 @@
+package a2aregtest
+
+import "a2areg/internal/fakeregistry"
+
+// FakeRegistry is an in-memory A2A registry served over HTTP, for tests
+// that exercise a real A2ARegClient end to end:
+//
+//	registry := a2aregtest.NewFakeRegistry()
+//	server := registry.Start()
+//	defer server.Close()
+//	client := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+type FakeRegistry = fakeregistry.Registry
+
+// NewFakeRegistry returns an empty FakeRegistry.
+func NewFakeRegistry() *FakeRegistry {
+	return fakeregistry.New()
+}
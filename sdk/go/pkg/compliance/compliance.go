@@ -0,0 +1,139 @@
+// Package compliance scans a registry for agents that break a policy: no
+// auth schemes, credentials embedded in public cards, missing
+// documentation, unattested TEE claims and the like.
+//
+//	report, err := compliance.ScanRegistry(ctx, client, compliance.DefaultPolicy(), compliance.ScanOptions{})
+//	if err != nil {
+//		return err
+//	}
+//	report.WriteCSV(os.Stdout)
+package compliance
+
+import (
+	"context"
+
+	"a2areg/pkg/a2areg"
+)
+
+// Severity ranks findings.
+type Severity string
+
+// Severities, from most to least severe.
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+)
+
+// severityOrder lists the severities in report order.
+var severityOrder = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+
+// Finding is one policy violation.
+type Finding struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	AgentID   string   `json:"agent_id"`
+	AgentName string   `json:"agent_name"`
+	Message   string   `json:"message"`
+}
+
+// ValidationRule checks agents one at a time.
+type ValidationRule interface {
+	// Name identifies the rule in reports.
+	Name() string
+	Severity() Severity
+	// Check returns one message per violation in agent, or none.
+	Check(agent *a2areg.Agent) []string
+}
+
+// RegistryRule checks properties of the registry as a whole, such as
+// uniqueness across agents.
+type RegistryRule interface {
+	Name() string
+	Severity() Severity
+	// Begin starts a scan. The returned RegistryCheck sees every agent and
+	// then reports its findings.
+	Begin() RegistryCheck
+}
+
+// RegistryCheck is the state of one RegistryRule during one scan.
+type RegistryCheck interface {
+	Observe(agent *a2areg.Agent)
+	Finish() []Finding
+}
+
+// Policy is the set of rules a scan evaluates.
+type Policy struct {
+	Rules         []ValidationRule
+	RegistryRules []RegistryRule
+}
+
+// DefaultPageSize is the listing page size ScanRegistry uses by default.
+const DefaultPageSize = 100
+
+// ScanOptions configures ScanRegistry.
+type ScanOptions struct {
+	// PublicOnly scans only the public listing instead of every agent the
+	// client is entitled to.
+	PublicOnly bool
+	// PageSize is the number of agents fetched per request. Defaults to
+	// DefaultPageSize.
+	PageSize int
+	// MaxAgents stops the scan after this many agents. Zero scans all.
+	MaxAgents int
+}
+
+// ScanRegistry lists the registry page by page, evaluating policy against
+// each agent. Only the current page and the findings are held in memory.
+func ScanRegistry(ctx context.Context, client *a2areg.A2ARegClient, policy Policy, opts ScanOptions) (*ComplianceReport, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+
+	checks := make([]RegistryCheck, len(policy.RegistryRules))
+	for i, rule := range policy.RegistryRules {
+		checks[i] = rule.Begin()
+	}
+
+	var findings []Finding
+	scanned := 0
+	it := client.IterAgents(ctx, a2areg.IterOptions{PublicOnly: opts.PublicOnly, PageSize: opts.PageSize, Max: opts.MaxAgents})
+	for it.Next() {
+		agent := it.Agent()
+		scanned++
+		for _, rule := range policy.Rules {
+			for _, message := range rule.Check(&agent) {
+				findings = append(findings, newFinding(rule.Name(), rule.Severity(), &agent, message))
+			}
+		}
+		for _, check := range checks {
+			check.Observe(&agent)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, check := range checks {
+		findings = append(findings, check.Finish()...)
+	}
+	return newReport(scanned, findings), nil
+}
+
+func newFinding(rule string, severity Severity, agent *a2areg.Agent, message string) Finding {
+	return Finding{
+		Rule:      rule,
+		Severity:  severity,
+		AgentID:   agentID(agent),
+		AgentName: agent.Name,
+		Message:   message,
+	}
+}
+
+func agentID(agent *a2areg.Agent) string {
+	if agent.ID == nil {
+		return ""
+	}
+	return *agent.ID
+}
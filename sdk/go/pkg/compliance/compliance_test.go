@@ -0,0 +1,187 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"a2areg/pkg/a2areg"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registryServer serves testdata/registry.json as a paged public listing.
+func registryServer(t *testing.T, pages *atomic.Int32) *a2areg.A2ARegClient {
+	t.Helper()
+	data, err := os.ReadFile("testdata/registry.json")
+	require.NoError(t, err)
+	var agents []json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &agents))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/agents/public" {
+			http.NotFound(w, r)
+			return
+		}
+		pages.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		start := min((page-1)*limit, len(agents))
+		end := min(start+limit, len(agents))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"agents": agents[start:end],
+			"total":  len(agents),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: server.URL, APIKey: "test"})
+}
+
+func TestScanRegistry_DefaultPolicy(t *testing.T) {
+	var pages atomic.Int32
+	client := registryServer(t, &pages)
+
+	report, err := ScanRegistry(context.Background(), client, DefaultPolicy(), ScanOptions{PublicOnly: true, PageSize: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.AgentsScanned)
+	assert.Equal(t, int32(3), pages.Load())
+	assert.Equal(t, map[Severity]int{
+		SeverityCritical: 1,
+		SeverityHigh:     1,
+		SeverityMedium:   3,
+		SeverityLow:      2,
+	}, report.Summary)
+
+	var severities []Severity
+	for _, group := range report.Groups {
+		severities = append(severities, group.Severity)
+	}
+	assert.Equal(t, []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}, severities)
+
+	critical := report.Groups[0].Agents
+	require.Len(t, critical, 1)
+	assert.Equal(t, "agent-leaky", critical[0].AgentID)
+	assert.Equal(t, "no-embedded-credentials", critical[0].Findings[0].Rule)
+
+	high := report.Groups[1].Agents
+	require.Len(t, high, 1)
+	assert.Equal(t, "agent-tee", high[0].AgentID)
+	assert.Equal(t, "require-tee-attestation", high[0].Findings[0].Rule)
+
+	var medium []string
+	for _, agent := range report.Groups[2].Agents {
+		for _, f := range agent.Findings {
+			medium = append(medium, agent.AgentID+"/"+f.Rule)
+		}
+	}
+	assert.Equal(t, []string{
+		"agent-dup-1/unique-agent-names",
+		"agent-dup-2/unique-agent-names",
+		"agent-leaky/require-https-endpoints",
+	}, medium)
+
+	low := report.Groups[3].Agents
+	require.Len(t, low, 1)
+	assert.Equal(t, "agent-leaky", low[0].AgentID)
+	require.Len(t, low[0].Findings, 2)
+	assert.Equal(t, "require-documentation-url", low[0].Findings[0].Rule)
+	assert.Equal(t, "require-semantic-version", low[0].Findings[1].Rule)
+}
+
+func TestScanRegistry_MaxAgents(t *testing.T) {
+	var pages atomic.Int32
+	client := registryServer(t, &pages)
+
+	report, err := ScanRegistry(context.Background(), client, DefaultPolicy(), ScanOptions{PublicOnly: true, PageSize: 2, MaxAgents: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.AgentsScanned)
+	assert.Equal(t, int32(2), pages.Load())
+	// The duplicate names are past the cut-off, so only the http endpoint
+	// is reported at medium.
+	assert.Equal(t, 1, report.Summary[SeverityMedium])
+}
+
+func TestScanRegistry_CustomRule(t *testing.T) {
+	var pages atomic.Int32
+	client := registryServer(t, &pages)
+
+	policy := Policy{Rules: []ValidationRule{
+		NewRule("no-acme", "info", func(agent *a2areg.Agent) []string {
+			if agent.ProviderName() == "acme" {
+				return []string{"acme agents are being retired"}
+			}
+			return nil
+		}),
+	}}
+	report, err := ScanRegistry(context.Background(), client, policy, ScanOptions{PublicOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[Severity]int{"info": 3}, report.Summary)
+	assert.Len(t, report.Findings(), 3)
+}
+
+func TestNoEmbeddedCredentials_Visibility(t *testing.T) {
+	credentials := "sk-live-123"
+	for visibility, want := range map[a2areg.Visibility]int{
+		a2areg.VisibilityPublic:   1,
+		a2areg.VisibilityUnlisted: 1,
+		a2areg.VisibilityPrivate:  0,
+	} {
+		t.Run(string(visibility), func(t *testing.T) {
+			agent := &a2areg.Agent{
+				Name:        "Leaky Agent",
+				Visibility:  visibility,
+				AuthSchemes: []a2areg.SecurityScheme{{Type: "apiKey", Credentials: &credentials}},
+			}
+			messages := NoEmbeddedCredentials().Check(agent)
+			assert.Len(t, messages, want)
+			if want > 0 {
+				assert.Equal(t, string(visibility)+" agent embeds credentials in its apiKey scheme", messages[0])
+			}
+		})
+	}
+}
+
+func TestComplianceReport_WriteCSVEscapesFormulas(t *testing.T) {
+	report := newReport(1, []Finding{{
+		Rule: "custom", Severity: SeverityLow, AgentID: "-agent", AgentName: "=HYPERLINK(\"http://evil\")", Message: "@SUM(A1)",
+	}})
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteCSV(&out))
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"low", "'-agent", "'=HYPERLINK(\"http://evil\")", "custom", "'@SUM(A1)"}, records[1])
+}
+
+func TestComplianceReport_Output(t *testing.T) {
+	var pages atomic.Int32
+	client := registryServer(t, &pages)
+	report, err := ScanRegistry(context.Background(), client, DefaultPolicy(), ScanOptions{PublicOnly: true})
+	require.NoError(t, err)
+
+	var jsonOut bytes.Buffer
+	require.NoError(t, report.WriteJSON(&jsonOut))
+	var decoded ComplianceReport
+	require.NoError(t, json.Unmarshal(jsonOut.Bytes(), &decoded))
+	assert.Equal(t, report.AgentsScanned, decoded.AgentsScanned)
+	assert.Equal(t, report.Summary, decoded.Summary)
+	assert.Equal(t, report.Groups, decoded.Groups)
+
+	var csvOut bytes.Buffer
+	require.NoError(t, report.WriteCSV(&csvOut))
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 1+len(report.Findings()))
+	assert.Equal(t, []string{"severity", "agent_id", "agent_name", "rule", "message"}, records[0])
+	assert.Equal(t, []string{"critical", "agent-leaky", "Leaky Agent", "no-embedded-credentials", "public agent embeds credentials in its apiKey scheme"}, records[1])
+}
@@ -0,0 +1,130 @@
+package compliance
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ComplianceReport is the outcome of ScanRegistry.
+type ComplianceReport struct {
+	AgentsScanned int `json:"agents_scanned"`
+	// Summary counts findings per severity.
+	Summary map[Severity]int `json:"summary"`
+	// Groups holds the findings by severity, most severe first, and within
+	// each severity by agent. Severities without findings are omitted.
+	Groups []SeverityGroup `json:"groups"`
+}
+
+// SeverityGroup is the findings of one severity.
+type SeverityGroup struct {
+	Severity Severity        `json:"severity"`
+	Agents   []AgentFindings `json:"agents"`
+}
+
+// AgentFindings is the findings of one severity for one agent.
+type AgentFindings struct {
+	AgentID   string    `json:"agent_id"`
+	AgentName string    `json:"agent_name"`
+	Findings  []Finding `json:"findings"`
+}
+
+func newReport(scanned int, findings []Finding) *ComplianceReport {
+	report := &ComplianceReport{AgentsScanned: scanned, Summary: make(map[Severity]int)}
+
+	bySeverity := make(map[Severity][]Finding)
+	for _, f := range findings {
+		bySeverity[f.Severity] = append(bySeverity[f.Severity], f)
+		report.Summary[f.Severity]++
+	}
+
+	for _, severity := range orderedSeverities(bySeverity) {
+		group := bySeverity[severity]
+		sort.SliceStable(group, func(i, j int) bool {
+			if group[i].AgentID != group[j].AgentID {
+				return group[i].AgentID < group[j].AgentID
+			}
+			return group[i].Rule < group[j].Rule
+		})
+		sg := SeverityGroup{Severity: severity}
+		for _, f := range group {
+			if n := len(sg.Agents); n == 0 || sg.Agents[n-1].AgentID != f.AgentID {
+				sg.Agents = append(sg.Agents, AgentFindings{AgentID: f.AgentID, AgentName: f.AgentName})
+			}
+			last := &sg.Agents[len(sg.Agents)-1]
+			last.Findings = append(last.Findings, f)
+		}
+		report.Groups = append(report.Groups, sg)
+	}
+	return report
+}
+
+// orderedSeverities returns the severities present in bySeverity: the
+// known ones first in severity order, then any custom ones by name.
+func orderedSeverities(bySeverity map[Severity][]Finding) []Severity {
+	var ordered []Severity
+	known := make(map[Severity]bool)
+	for _, severity := range severityOrder {
+		known[severity] = true
+		if len(bySeverity[severity]) > 0 {
+			ordered = append(ordered, severity)
+		}
+	}
+	var custom []Severity
+	for severity := range bySeverity {
+		if !known[severity] {
+			custom = append(custom, severity)
+		}
+	}
+	sort.Slice(custom, func(i, j int) bool { return custom[i] < custom[j] })
+	return append(ordered, custom...)
+}
+
+// Findings returns every finding in report order.
+func (r *ComplianceReport) Findings() []Finding {
+	var findings []Finding
+	for _, group := range r.Groups {
+		for _, agent := range group.Agents {
+			findings = append(findings, agent.Findings...)
+		}
+	}
+	return findings
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *ComplianceReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per finding, in report order, after a header row.
+// Cells that a spreadsheet would evaluate as a formula are prefixed with a
+// single quote, as agent names and messages come from the registry.
+func (r *ComplianceReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"severity", "agent_id", "agent_name", "rule", "message"}); err != nil {
+		return err
+	}
+	for _, f := range r.Findings() {
+		row := []string{string(f.Severity), f.AgentID, f.AgentName, f.Rule, f.Message}
+		for i, cell := range row {
+			row[i] = csvCell(cell)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell neutralizes a cell starting with a formula trigger character.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
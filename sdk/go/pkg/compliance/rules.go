@@ -0,0 +1,202 @@
+package compliance
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"a2areg/pkg/a2areg"
+)
+
+// DefaultPolicy returns a policy with every built-in rule.
+func DefaultPolicy() Policy {
+	return Policy{
+		Rules: []ValidationRule{
+			RequireAuthSchemes(),
+			NoEmbeddedCredentials(),
+			RequireDocumentationURL(),
+			RequireTEEAttestation(),
+			RequireHTTPSEndpoints(),
+			RequireSemanticVersion(),
+		},
+		RegistryRules: []RegistryRule{
+			UniqueAgentNames(),
+		},
+	}
+}
+
+// rule is a ValidationRule backed by a function.
+type rule struct {
+	name     string
+	severity Severity
+	check    func(agent *a2areg.Agent) []string
+}
+
+func (r rule) Name() string                       { return r.name }
+func (r rule) Severity() Severity                 { return r.severity }
+func (r rule) Check(agent *a2areg.Agent) []string { return r.check(agent) }
+
+// NewRule returns a ValidationRule that reports the messages check returns.
+func NewRule(name string, severity Severity, check func(agent *a2areg.Agent) []string) ValidationRule {
+	return rule{name: name, severity: severity, check: check}
+}
+
+// securitySchemes returns the agent's schemes from both the agent record and
+// its card.
+func securitySchemes(agent *a2areg.Agent) []a2areg.SecurityScheme {
+	schemes := append([]a2areg.SecurityScheme(nil), agent.AuthSchemes...)
+	if agent.AgentCard != nil {
+		names := make([]string, 0, len(agent.AgentCard.SecuritySchemes))
+		for name := range agent.AgentCard.SecuritySchemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			schemes = append(schemes, agent.AgentCard.SecuritySchemes[name])
+		}
+	}
+	return schemes
+}
+
+// RequireAuthSchemes flags agents that declare no way to authenticate.
+func RequireAuthSchemes() ValidationRule {
+	return NewRule("require-auth-schemes", SeverityHigh, func(agent *a2areg.Agent) []string {
+		if len(securitySchemes(agent)) == 0 {
+			return []string{"agent declares no auth schemes"}
+		}
+		return nil
+	})
+}
+
+// NoEmbeddedCredentials flags public and unlisted agents whose card carries
+// credentials.
+func NoEmbeddedCredentials() ValidationRule {
+	return NewRule("no-embedded-credentials", SeverityCritical, func(agent *a2areg.Agent) []string {
+		visibility := agent.EffectiveVisibility()
+		if visibility == a2areg.VisibilityPrivate {
+			return nil
+		}
+		var messages []string
+		for _, scheme := range securitySchemes(agent) {
+			if scheme.Credentials != nil && *scheme.Credentials != "" {
+				messages = append(messages, fmt.Sprintf("%s agent embeds credentials in its %s scheme", visibility, scheme.Type))
+			}
+		}
+		return messages
+	})
+}
+
+// RequireDocumentationURL flags cards without a documentationUrl.
+func RequireDocumentationURL() ValidationRule {
+	return NewRule("require-documentation-url", SeverityLow, func(agent *a2areg.Agent) []string {
+		if agent.AgentCard == nil || agent.AgentCard.DocumentationURL == nil || *agent.AgentCard.DocumentationURL == "" {
+			return []string{"card has no documentationUrl"}
+		}
+		return nil
+	})
+}
+
+// RequireTEEAttestation flags agents that claim a TEE without attestation.
+func RequireTEEAttestation() ValidationRule {
+	return NewRule("require-tee-attestation", SeverityHigh, func(agent *a2areg.Agent) []string {
+		tee := agent.TEEDetails
+		if tee != nil && tee.Enabled && (tee.Attestation == nil || *tee.Attestation == "") {
+			return []string{"agent claims a TEE but provides no attestation"}
+		}
+		return nil
+	})
+}
+
+// RequireHTTPSEndpoints flags endpoint URLs that are not https.
+func RequireHTTPSEndpoints() ValidationRule {
+	return NewRule("require-https-endpoints", SeverityMedium, func(agent *a2areg.Agent) []string {
+		var urls []string
+		if agent.LocationURL != nil {
+			urls = append(urls, *agent.LocationURL)
+		}
+		for _, entry := range agent.Interfaces {
+			urls = append(urls, entry.URL)
+		}
+		if agent.AgentCard != nil && agent.AgentCard.URL != "" {
+			urls = append(urls, agent.AgentCard.URL)
+		}
+
+		var messages []string
+		seen := make(map[string]bool)
+		for _, raw := range urls {
+			if seen[raw] {
+				continue
+			}
+			seen[raw] = true
+			if u, err := url.Parse(raw); err != nil || u.Scheme != "https" {
+				messages = append(messages, fmt.Sprintf("endpoint %q is not https", raw))
+			}
+		}
+		return messages
+	})
+}
+
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// RequireSemanticVersion flags versions that are not MAJOR.MINOR.PATCH.
+func RequireSemanticVersion() ValidationRule {
+	return NewRule("require-semantic-version", SeverityLow, func(agent *a2areg.Agent) []string {
+		if !semverPattern.MatchString(agent.Version) {
+			return []string{fmt.Sprintf("version %q is not a semantic version", agent.Version)}
+		}
+		return nil
+	})
+}
+
+// UniqueAgentNames flags agents sharing a name (case-insensitively) with
+// another agent of the same provider, which makes them ambiguous in
+// discovery.
+func UniqueAgentNames() RegistryRule {
+	return uniqueNames{}
+}
+
+type uniqueNames struct{}
+
+func (uniqueNames) Name() string       { return "unique-agent-names" }
+func (uniqueNames) Severity() Severity { return SeverityMedium }
+func (uniqueNames) Begin() RegistryCheck {
+	return &uniqueNamesCheck{seen: make(map[string][]namedAgent)}
+}
+
+type namedAgent struct{ id, name string }
+
+type uniqueNamesCheck struct {
+	seen map[string][]namedAgent
+}
+
+func (c *uniqueNamesCheck) Observe(agent *a2areg.Agent) {
+	key := strings.ToLower(agent.ProviderName()) + "\x00" + strings.ToLower(agent.Name)
+	c.seen[key] = append(c.seen[key], namedAgent{id: agentID(agent), name: agent.Name})
+}
+
+func (c *uniqueNamesCheck) Finish() []Finding {
+	var findings []Finding
+	for _, agents := range c.seen {
+		if len(agents) < 2 {
+			continue
+		}
+		for i, agent := range agents {
+			var others []string
+			for j, other := range agents {
+				if i != j {
+					others = append(others, other.id)
+				}
+			}
+			findings = append(findings, Finding{
+				Rule:      uniqueNames{}.Name(),
+				Severity:  uniqueNames{}.Severity(),
+				AgentID:   agent.id,
+				AgentName: agent.name,
+				Message:   "name is shared with " + strings.Join(others, ", "),
+			})
+		}
+	}
+	return findings
+}
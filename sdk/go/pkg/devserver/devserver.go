@@ -0,0 +1,227 @@
+// Package devserver runs a small, self-contained A2A registry for local
+// development, so the SDK can be exercised end to end without a shared
+// registry. It is not a production server: it keeps everything in memory,
+// persists to a single JSON file, and knows one set of credentials.
+package devserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"a2areg/internal/fakeregistry"
+)
+
+// HeaderDevServer is set on every response so clients and proxies can tell
+// they are talking to a development server.
+const HeaderDevServer = "X-A2A-Dev-Server"
+
+// maxBodyBytes matches the size limit of the real registry.
+const maxBodyBytes = 1 << 20
+
+// Options configures a DevServer.
+type Options struct {
+	// Addr is the address to listen on. Defaults to "127.0.0.1:0", a free
+	// loopback port.
+	Addr string
+	// DataFile persists agents between runs. Empty keeps them in memory
+	// only.
+	DataFile string
+	// ClientID and ClientSecret are the only credentials the OAuth token
+	// endpoint accepts. If both are empty, any client may obtain a token.
+	ClientID     string
+	ClientSecret string
+	// APIKey, when set, is also accepted, either as a bearer credential or
+	// in the X-API-Key header.
+	APIKey string
+	// Force allows listening on non-loopback interfaces.
+	Force bool
+}
+
+// DevServer is a running development registry.
+type DevServer struct {
+	registry *fakeregistry.Registry
+	listener net.Listener
+	server   *http.Server
+	dataFile string
+	apiKey   string
+	token    string
+
+	saveMu sync.Mutex
+}
+
+// NewDevServer starts a DevServer listening on opts.Addr. It refuses to listen on a
+// non-loopback address unless opts.Force is set, and loads opts.DataFile if
+// it exists.
+func NewDevServer(opts Options) (*DevServer, error) {
+	if opts.Addr == "" {
+		opts.Addr = "127.0.0.1:0"
+	}
+	if !opts.Force && !isLoopback(opts.Addr) {
+		return nil, fmt.Errorf("devserver: refusing to listen on non-loopback address %q (set Force to override)", opts.Addr)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	s := &DevServer{
+		registry: fakeregistry.New(),
+		dataFile: opts.DataFile,
+		apiKey:   opts.APIKey,
+		token:    token,
+	}
+	s.registry.ClientID = opts.ClientID
+	s.registry.ClientSecret = opts.ClientSecret
+	s.registry.Token = token
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	s.listener, err = net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("devserver: %w", err)
+	}
+	s.server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	go s.server.Serve(s.listener)
+	return s, nil
+}
+
+// URL returns the base URL of the server.
+func (s *DevServer) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// Close stops the server and writes the data file.
+func (s *DevServer) Close() error {
+	err := s.server.Close()
+	if saveErr := s.save(); err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+// ServeHTTP authenticates the request and passes it to the registry
+// handlers, saving the data file after each successful change.
+func (s *DevServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(HeaderDevServer, "true")
+	w.Header().Set("Server", "a2areg-devserver")
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	path := strings.Trim(r.URL.Path, "/")
+	if path != "health" && path != "auth/oauth/token" && !s.authorized(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{"detail": "Not authenticated"})
+		return
+	}
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.registry.ServeHTTP(rec, r)
+	if r.Method != "GET" && rec.status < 300 && path != "auth/oauth/token" {
+		s.save()
+	}
+}
+
+func (s *DevServer) authorized(r *http.Request) bool {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return s.apiKey != "" && key == s.apiKey
+	}
+	credential := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if credential == "" {
+		return false
+	}
+	return credential == s.token || (s.apiKey != "" && credential == s.apiKey)
+}
+
+func (s *DevServer) load() error {
+	if s.dataFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.dataFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("devserver: %w", err)
+	}
+	var stored struct {
+		Agents []fakeregistry.Agent `json:"agents"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("devserver: reading %s: %w", s.dataFile, err)
+	}
+	s.registry.Restore(stored.Agents)
+	return nil
+}
+
+// save writes the data file atomically.
+func (s *DevServer) save() error {
+	if s.dataFile == "" {
+		return nil
+	}
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	data, err := json.MarshalIndent(map[string]interface{}{"agents": s.registry.Snapshot()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.dataFile), ".devserver-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.dataFile)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("devserver: %w", err)
+	}
+	return "dev-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,129 @@
+package devserver
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"a2areg/pkg/a2areg"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAgent() *a2areg.Agent {
+	return &a2areg.Agent{
+		Name:        "Recipe Agent",
+		Description: "Finds recipes",
+		Version:     "1.0.0",
+		Provider:    "acme",
+		IsPublic:    true,
+		Skills: []a2areg.AgentSkill{
+			{ID: "search", Name: "Search", Description: "Search recipes", Tags: []string{"cooking"}},
+		},
+	}
+}
+
+func TestDevServer_EndToEnd(t *testing.T) {
+	server, err := NewDevServer(Options{ClientID: "dev", ClientSecret: "secret"})
+	require.NoError(t, err)
+	defer server.Close()
+
+	client := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{
+		RegistryURL:  server.URL(),
+		ClientID:     "dev",
+		ClientSecret: "secret",
+	})
+
+	published, err := client.PublishAgent(testAgent(), true)
+	require.NoError(t, err)
+	require.NotNil(t, published.ID)
+	assert.Equal(t, "Recipe Agent", published.Name)
+
+	got, err := client.GetAgent(*published.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", got.Provider)
+
+	listing, err := client.ListAgentsPage(context.Background(), 1, 10, true)
+	require.NoError(t, err)
+	assert.Len(t, listing.Agents, 1)
+
+	results, err := client.Search(context.Background(), a2areg.SearchRequest{Query: "recipe", Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, results.Agents, 1)
+
+	require.NoError(t, client.DeleteAgent(*published.ID))
+	_, err = client.GetAgent(*published.ID)
+	assert.Equal(t, a2areg.CodeAgentNotFound, a2areg.ErrorCode(err))
+}
+
+func TestDevServer_Auth(t *testing.T) {
+	server, err := NewDevServer(Options{ClientID: "dev", ClientSecret: "secret", APIKey: "dev-key"})
+	require.NoError(t, err)
+	defer server.Close()
+
+	wrongSecret := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: server.URL(), ClientID: "dev", ClientSecret: "nope"})
+	_, err = wrongSecret.ListAgentsPage(context.Background(), 1, 10, true)
+	assert.Equal(t, a2areg.CodeAuthInvalidClient, a2areg.ErrorCode(err))
+
+	wrongKey := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: server.URL(), APIKey: "other"})
+	_, err = wrongKey.ListAgentsPage(context.Background(), 1, 10, true)
+	assert.Equal(t, a2areg.CodeAuthRequired, a2areg.ErrorCode(err))
+
+	apiKey := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: server.URL(), APIKey: "dev-key"})
+	_, err = apiKey.ListAgentsPage(context.Background(), 1, 10, true)
+	assert.NoError(t, err)
+}
+
+func TestDevServer_PersistsAcrossRestart(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "agents.json")
+
+	server, err := NewDevServer(Options{DataFile: dataFile, APIKey: "dev-key"})
+	require.NoError(t, err)
+	client := a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: server.URL(), APIKey: "dev-key"})
+	published, err := client.PublishAgent(testAgent(), false)
+	require.NoError(t, err)
+	require.NoError(t, server.Close())
+
+	restarted, err := NewDevServer(Options{DataFile: dataFile, APIKey: "dev-key"})
+	require.NoError(t, err)
+	defer restarted.Close()
+	client = a2areg.NewA2ARegClient(a2areg.A2ARegClientOptions{RegistryURL: restarted.URL(), APIKey: "dev-key"})
+
+	got, err := client.GetAgent(*published.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Recipe Agent", got.Name)
+
+	// New IDs do not collide with restored ones.
+	second, err := client.PublishAgent(testAgent(), false)
+	require.NoError(t, err)
+	assert.NotEqual(t, *published.ID, *second.ID)
+}
+
+func TestDevServer_IdentifiesItself(t *testing.T) {
+	server, err := NewDevServer(Options{})
+	require.NoError(t, err)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL() + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(HeaderDevServer))
+}
+
+func TestDevServer_RefusesNonLoopback(t *testing.T) {
+	for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:0"} {
+		_, err := NewDevServer(Options{Addr: addr})
+		assert.Error(t, err, addr)
+	}
+
+	server, err := NewDevServer(Options{Addr: "localhost:0"})
+	require.NoError(t, err)
+	server.Close()
+
+	server, err = NewDevServer(Options{Addr: "0.0.0.0:0", Force: true})
+	require.NoError(t, err)
+	server.Close()
+}